@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// maxAliasDepth bounds how many aliases may expand into one another
+const maxAliasDepth = 10
+
+// expandAliases rewrites the command line using the user's aliases and
+// command defaults. An alias applies only as the first argument and can't
+// shadow a built-in command. Defaults go right after the command name, so
+// arguments given on the command line take precedence.
+func expandAliases(root *cobra.Command, args []string, aliases, defaults map[string]string) ([]string, error) {
+	for depth := 0; len(args) > 0 && !isBuiltinCommand(root, args[0]); depth++ {
+		expansion, ok := aliases[args[0]]
+		if !ok {
+			break
+		}
+		if depth == maxAliasDepth {
+			return nil, fmt.Errorf("alias %q expands too deeply; check for a loop", args[0])
+		}
+		words, err := splitArgs(expansion)
+		if err != nil {
+			return nil, fmt.Errorf("alias %q: %w", args[0], err)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("alias %q is empty", args[0])
+		}
+		args = append(words, args[1:]...)
+	}
+
+	if len(args) == 0 {
+		return args, nil
+	}
+	extra, ok := defaults[args[0]]
+	if !ok || !isBuiltinCommand(root, args[0]) {
+		return args, nil
+	}
+	words, err := splitArgs(extra)
+	if err != nil {
+		return nil, fmt.Errorf("defaults for %q: %w", args[0], err)
+	}
+	expanded := append([]string{args[0]}, words...)
+	return append(expanded, args[1:]...), nil
+}
+
+// isBuiltinCommand reports whether name is one of root's subcommands
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	// Cobra adds these itself when the command runs
+	if name == "help" || name == "completion" {
+		return true
+	}
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArgs splits s into words like a shell would, honoring single and
+// double quotes and backslash escapes, without expanding anything
+func splitArgs(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExpandAliases(t *testing.T) {
+	root := &cobra.Command{Use: "roborev"}
+	root.AddCommand(&cobra.Command{Use: "review"}, &cobra.Command{Use: "show"})
+
+	aliases := map[string]string{
+		"sec":    "review --type security --wait",
+		"secsha": `sec --branch "my feature"`,
+		"show":   "review",
+		"loop":   "loop",
+	}
+	defaults := map[string]string{"review": "--wait --quiet"}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{"alias with extra args", []string{"sec", "HEAD~1"}, []string{"review", "--wait", "--quiet", "--type", "security", "--wait", "HEAD~1"}, ""},
+		{"alias of alias", []string{"secsha"}, []string{"review", "--wait", "--quiet", "--type", "security", "--wait", "--branch", "my feature"}, ""},
+		{"builtin not shadowed", []string{"show", "1"}, []string{"show", "1"}, ""},
+		{"defaults for builtin", []string{"review", "--type", "design"}, []string{"review", "--wait", "--quiet", "--type", "design"}, ""},
+		{"no args", nil, nil, ""},
+		{"unknown command", []string{"bogus"}, []string{"bogus"}, ""},
+		{"loop", []string{"loop"}, nil, "expands too deeply"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandAliases(root, tt.args, aliases, defaults)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	got, err := splitArgs(`review  --message "fix it" --label 'a b' esc\ aped ""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"review", "--message", "fix it", "--label", "a b", "esc aped", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := splitArgs(`review "open`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
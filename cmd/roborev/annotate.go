@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// listItemPattern matches the start of a markdown list item
+var listItemPattern = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+
+// annotation is a review finding anchored to a line of a changed file
+type annotation struct {
+	file string
+	line int
+	text string
+}
+
+// reviewDiff returns the diff a review's job covered
+func reviewDiff(job *storage.ReviewJob) (string, error) {
+	switch {
+	case job == nil:
+		return "", fmt.Errorf("review has no job information")
+	case job.IsTaskJob():
+		return "", fmt.Errorf("task output has no diff to annotate")
+	case job.JobType == storage.JobTypeDirty || job.GitRef == "dirty":
+		return "", fmt.Errorf("annotated output is not available for reviews of uncommitted changes")
+	case strings.Contains(job.GitRef, ".."):
+		return git.GetRangeDiff(job.RepoPath, job.GitRef)
+	default:
+		return git.GetDiff(job.RepoPath, job.GitRef)
+	}
+}
+
+// reviewBlocks splits review output into its findings, located with the
+// shared finding parser, and the prose around them as paragraphs.
+// Headings are dropped since they carry no finding text.
+func reviewBlocks(output string) (findings []storage.MarkdownFinding, text []string, prose []string) {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	findings = storage.FindMarkdownFindings(output)
+	for _, f := range findings {
+		text = append(text, strings.Join(lines[f.Start:f.End], "\n"))
+	}
+
+	var cur []string
+	flush := func() {
+		if len(cur) > 0 {
+			prose = append(prose, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+	next := 0
+	for i := 0; i < len(lines); i++ {
+		if next < len(findings) && i == findings[next].Start {
+			flush()
+			i = findings[next].End - 1
+			next++
+			continue
+		}
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			flush()
+			continue
+		}
+		cur = append(cur, lines[i])
+	}
+	flush()
+	return findings, text, prose
+}
+
+// matchDiffFile resolves a path mentioned in a review to a file in the
+// diff. Reviews often shorten paths, so a suffix on a directory boundary
+// also matches.
+func matchDiffFile(ref string, files []string) string {
+	ref = strings.TrimPrefix(strings.ReplaceAll(ref, "\\", "/"), "./")
+	for _, f := range files {
+		if f == ref {
+			return f
+		}
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f, "/"+ref) {
+			return f
+		}
+	}
+	return ""
+}
+
+// renderAnnotatedReview renders a diff with each review finding inserted
+// as a comment block below the line it refers to. Findings without a
+// location in the diff are listed before it.
+func renderAnnotatedReview(output, diff string) string {
+	files := git.ChangedFiles(diff)
+	byFile := make(map[string][]annotation)
+	findings, text, general := reviewBlocks(output)
+	for i, f := range findings {
+		file := ""
+		if f.File != "" && f.Line > 0 {
+			file = matchDiffFile(f.File, files)
+		}
+		if file == "" {
+			general = append(general, text[i])
+			continue
+		}
+		byFile[file] = append(byFile[file], annotation{file: file, line: f.Line, text: text[i]})
+	}
+	for _, anns := range byFile {
+		sort.SliceStable(anns, func(i, j int) bool { return anns[i].line < anns[j].line })
+	}
+
+	var sb strings.Builder
+	if len(general) > 0 {
+		writeCommentBlock(&sb, "", strings.Join(general, "\n\n"))
+		sb.WriteString("\n")
+	}
+
+	// Findings for a file are emitted after the line they name; any left
+	// at the end of the file point outside the hunks and go after its last
+	// hunk
+	var pending []annotation
+	flushPending := func() {
+		for _, a := range pending {
+			writeCommentBlock(&sb, fmt.Sprintf(" (line %d)", a.line), a.text)
+		}
+		pending = nil
+	}
+
+	for _, f := range git.ParseDiff(diff) {
+		pending = nil
+		if f.NewPath != "" {
+			pending = byFile[f.NewPath]
+		}
+		for _, line := range f.Header {
+			sb.WriteString(line + "\n")
+		}
+		for _, h := range f.Hunks {
+			sb.WriteString(h.Header + "\n")
+			for _, l := range h.Lines {
+				sb.WriteString(string(l.Kind) + l.Text + "\n")
+				if l.NewLine == 0 {
+					continue
+				}
+				// Findings between hunks attach to the next line that is shown
+				for len(pending) > 0 && pending[0].line < l.NewLine {
+					writeCommentBlock(&sb, fmt.Sprintf(" (line %d)", pending[0].line), pending[0].text)
+					pending = pending[1:]
+				}
+				for len(pending) > 0 && pending[0].line == l.NewLine {
+					writeCommentBlock(&sb, "", pending[0].text)
+					pending = pending[1:]
+				}
+			}
+		}
+		flushPending()
+	}
+
+	return sb.String()
+}
+
+// writeCommentBlock writes an indented, boxed review comment
+func writeCommentBlock(sb *strings.Builder, label, text string) {
+	sb.WriteString("    +-- roborev" + label + "\n")
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString(strings.TrimRight("    | "+line, " ") + "\n")
+	}
+	sb.WriteString("    +--\n")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const annotateTestDiff = `diff --git a/internal/db.go b/internal/db.go
+index 1111111..2222222 100644
+--- a/internal/db.go
++++ b/internal/db.go
+@@ -10,3 +10,4 @@ func query() {
+ 	name := input()
++	rows, err := db.Query("SELECT * FROM t WHERE name = '" + name + "'")
+ 	if err != nil {
+ 		return err
+@@ -40,2 +41,2 @@ func close() {
+-	db.Close()
++	_ = db.Close()
+ }
+diff --git a/README.md b/README.md
+new file mode 100644
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1 @@
++# Title
+`
+
+func TestRenderAnnotatedReview(t *testing.T) {
+	review := `## Summary
+
+The query change introduces an injection.
+
+## Issues
+
+- **High**: SQL injection in db.go:11 when name contains quotes.
+  Use a placeholder instead.
+- **Low**: Ignored close error at internal/db.go:41
+- **Medium**: Missing test for db.go:25
+- **Low**: Stale comment at db.go:90
+- **Low**: Unrelated file other.go:3 has a typo`
+
+	got := renderAnnotatedReview(review, annotateTestDiff)
+
+	wantInOrder := []string{
+		"    | The query change introduces an injection.",
+		"    | - **Low**: Unrelated file other.go:3 has a typo",
+		"+\trows, err := db.Query(",
+		"    | - **High**: SQL injection in db.go:11 when name contains quotes.",
+		"    |   Use a placeholder instead.",
+		"+\t_ = db.Close()",
+		"    +-- roborev (line 25)",
+		"    | - **Low**: Ignored close error at internal/db.go:41",
+		" }",
+		"    +-- roborev (line 90)",
+		"diff --git a/README.md b/README.md",
+	}
+	pos := 0
+	for _, want := range wantInOrder {
+		i := strings.Index(got[pos:], want)
+		if i < 0 {
+			t.Fatalf("expected %q after position %d in output:\n%s", want, pos, got)
+		}
+		pos += i + len(want)
+	}
+
+}
+
+func TestMatchDiffFile(t *testing.T) {
+	files := []string{"internal/db.go", "cmd/db.go", "db_test.go"}
+	tests := []struct{ ref, want string }{
+		{"internal/db.go", "internal/db.go"},
+		{"./cmd/db.go", "cmd/db.go"},
+		{"db.go", "internal/db.go"},
+		{"test.go", ""},
+		{"other.go", ""},
+	}
+	for _, tt := range tests {
+		if got := matchDiffFile(tt.ref, files); got != tt.want {
+			t.Errorf("matchDiffFile(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
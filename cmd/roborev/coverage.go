@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// coverageReport summarizes how many commits in a window have reviews
+type coverageReport struct {
+	Branch     string                       `json:"branch"`
+	Since      time.Time                    `json:"since"`
+	Total      int                          `json:"total"`
+	Reviewed   int                          `json:"reviewed"`
+	Pending    int                          `json:"pending"`
+	Skipped    int                          `json:"skipped"`
+	Unreviewed int                          `json:"unreviewed"`
+	Coverage   float64                      `json:"coverage"` // reviewed / (total - skipped), 0..1
+	Commits    []storage.CommitReviewStatus `json:"commits"`
+}
+
+func coverageCmd() *cobra.Command {
+	var (
+		repoPath   string
+		branch     string
+		days       int
+		backfill   bool
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "coverage",
+		Short: "Report what fraction of recent commits have reviews",
+		Long: `Report what fraction of the commits on a branch in a recent window have
+completed reviews, and list the commits that were never reviewed.
+
+A commit counts as reviewed once a review of it has completed, whatever
+its verdict. Commits skipped by [skip] rules are left out of the
+percentage. Use --backfill to queue reviews for every unreviewed commit.`,
+		Example: `  roborev coverage                      # Current branch, last 30 days
+  roborev coverage --branch main --days 90
+  roborev coverage --branch main --backfill  # Queue reviews for the gaps`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if days <= 0 {
+				return fmt.Errorf("--days must be positive")
+			}
+			if repoPath == "" {
+				repoPath = "."
+			}
+			repoRoot, err := git.GetRepoRoot(repoPath)
+			if err != nil {
+				return fmt.Errorf("not in a git repository: %w", err)
+			}
+			if branch == "" {
+				if branch = git.GetCurrentBranch(repoRoot); branch == "" {
+					return fmt.Errorf("not on a branch; use --branch")
+				}
+			}
+
+			since := time.Now().AddDate(0, 0, -days)
+			commits, err := git.GetCommitsSinceTime(repoRoot, branch, since)
+			if err != nil {
+				return fmt.Errorf("list commits on %s: %w", branch, err)
+			}
+
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+
+			report := coverageReport{Branch: branch, Since: since, Commits: []storage.CommitReviewStatus{}}
+			if len(commits) > 0 {
+				statuses, err := fetchCommitStatuses(repoRoot, commits)
+				if err != nil {
+					return err
+				}
+				report = newCoverageReport(branch, since, statuses)
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printCoverageReport(cmd.OutOrStdout(), repoRoot, report, days, backfill)
+			}
+
+			if backfill && report.Unreviewed > 0 {
+				queued, err := backfillReviews(repoRoot, branch, report.Commits)
+				if !jsonOutput {
+					cmd.Printf("Queued %d review(s)\n", queued)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "path to git repository (default: current directory)")
+	cmd.Flags().StringVar(&branch, "branch", "", "branch to report on (default: current branch)")
+	cmd.Flags().IntVar(&days, "days", 30, "only count commits from the last N days")
+	cmd.Flags().BoolVar(&backfill, "backfill", false, "queue reviews for the unreviewed commits")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+
+	return cmd
+}
+
+// fetchCommitStatuses asks the daemon for the review status of each commit
+func fetchCommitStatuses(repoRoot string, commits []string) ([]storage.CommitReviewStatus, error) {
+	reqBody, _ := json.Marshal(daemon.VerifyRequest{RepoPath: repoRoot, Commits: commits})
+	resp, err := http.Post(getDaemonAddr()+"/api/verify", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get review status: %s", body)
+	}
+
+	var result daemon.VerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return result.Commits, nil
+}
+
+// newCoverageReport tallies commit statuses into a report
+func newCoverageReport(branch string, since time.Time, statuses []storage.CommitReviewStatus) coverageReport {
+	report := coverageReport{Branch: branch, Since: since, Total: len(statuses), Commits: statuses}
+	for _, c := range statuses {
+		switch c.Status {
+		case storage.CommitReviewPassed, storage.CommitReviewFailed, storage.CommitReviewAddressed:
+			report.Reviewed++
+		case storage.CommitReviewPending:
+			report.Pending++
+		case storage.CommitReviewSkipped:
+			report.Skipped++
+		default:
+			report.Unreviewed++
+		}
+	}
+	if counted := report.Total - report.Skipped; counted > 0 {
+		report.Coverage = float64(report.Reviewed) / float64(counted)
+	} else {
+		report.Coverage = 1
+	}
+	return report
+}
+
+// printCoverageReport lists the unreviewed commits and a summary line
+func printCoverageReport(w io.Writer, repoRoot string, report coverageReport, days int, backfill bool) {
+	if report.Total == 0 {
+		fmt.Fprintf(w, "No commits on %s in the last %d days\n", report.Branch, days)
+		return
+	}
+
+	for _, c := range report.Commits {
+		if c.Status != storage.CommitReviewUnreviewed {
+			continue
+		}
+		subject := ""
+		if info, err := git.GetCommitInfo(repoRoot, c.SHA); err == nil {
+			subject = truncateString(info.Subject, 60)
+		}
+		fmt.Fprintf(w, "  %s  %s\n", shortSHA(c.SHA), subject)
+	}
+
+	fmt.Fprintf(w, "%s, last %d days: %d of %d commit(s) reviewed (%.0f%%), %d pending, %d skipped, %d unreviewed\n",
+		report.Branch, days, report.Reviewed, report.Total-report.Skipped, report.Coverage*100,
+		report.Pending, report.Skipped, report.Unreviewed)
+	if report.Unreviewed > 0 && !backfill {
+		fmt.Fprintf(w, "Queue reviews for the gaps with: roborev coverage --branch %s --days %d --backfill\n", report.Branch, days)
+	}
+}
+
+// backfillReviews queues a review of each unreviewed commit and returns how
+// many were queued
+func backfillReviews(repoRoot, branch string, statuses []storage.CommitReviewStatus) (int, error) {
+	queued := 0
+	for _, c := range statuses {
+		if c.Status != storage.CommitReviewUnreviewed {
+			continue
+		}
+		reqBody, _ := json.Marshal(daemon.EnqueueRequest{RepoPath: repoRoot, GitRef: c.SHA, Branch: branch})
+		resp, err := http.Post(getDaemonAddr()+"/api/enqueue", "application/json", bytes.NewReader(reqBody))
+		if err != nil {
+			return queued, fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusCreated:
+			queued++
+		case http.StatusOK:
+			// Skipped by a [skip] rule since the last review status check
+		default:
+			return queued, fmt.Errorf("queue review of %s: %s", shortSHA(c.SHA), body)
+		}
+	}
+	return queued, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestNewCoverageReport(t *testing.T) {
+	report := newCoverageReport("main", time.Now(), []storage.CommitReviewStatus{
+		{SHA: "a", Status: storage.CommitReviewPassed},
+		{SHA: "b", Status: storage.CommitReviewFailed},
+		{SHA: "c", Status: storage.CommitReviewAddressed},
+		{SHA: "d", Status: storage.CommitReviewPending},
+		{SHA: "e", Status: storage.CommitReviewSkipped},
+		{SHA: "f", Status: storage.CommitReviewUnreviewed},
+	})
+	if report.Total != 6 || report.Reviewed != 3 || report.Pending != 1 || report.Skipped != 1 || report.Unreviewed != 1 {
+		t.Errorf("unexpected tally %+v", report)
+	}
+	if report.Coverage != 0.6 {
+		t.Errorf("expected skipped commits left out of the coverage, got %v", report.Coverage)
+	}
+
+	if all := newCoverageReport("main", time.Now(), []storage.CommitReviewStatus{{SHA: "a", Status: storage.CommitReviewSkipped}}); all.Coverage != 1 {
+		t.Errorf("expected full coverage when every commit is skipped, got %v", all.Coverage)
+	}
+}
+
+func TestCoverageCmd(t *testing.T) {
+	repo := newTestGitRepo(t)
+	reviewed := repo.CommitFile("a.txt", "a", "reviewed commit")
+	gap := repo.CommitFile("b.txt", "b", "commit nobody reviewed")
+	branch := repo.Run("rev-parse", "--abbrev-ref", "HEAD")
+
+	var mu sync.Mutex
+	var enqueued []daemon.EnqueueRequest
+	_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/verify":
+			var req daemon.VerifyRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			resp := daemon.VerifyResponse{}
+			for _, sha := range req.Commits {
+				status := storage.CommitReviewUnreviewed
+				if sha == reviewed {
+					status = storage.CommitReviewPassed
+				}
+				resp.Commits = append(resp.Commits, storage.CommitReviewStatus{SHA: sha, Status: status})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/api/enqueue":
+			var req daemon.EnqueueRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			mu.Lock()
+			enqueued = append(enqueued, req)
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(storage.ReviewJob{ID: 1, Agent: "test"})
+		}
+	}))
+	defer cleanup()
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := coverageCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{"--repo", repo.Dir}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("coverage %v: %v", args, err)
+		}
+		return out.String()
+	}
+
+	t.Run("reports gaps", func(t *testing.T) {
+		out := run()
+		if !strings.Contains(out, "1 of 2 commit(s) reviewed (50%)") {
+			t.Errorf("expected 50%% coverage, got:\n%s", out)
+		}
+		if !strings.Contains(out, shortSHA(gap)) || strings.Contains(out, shortSHA(reviewed)) {
+			t.Errorf("expected only the unreviewed commit listed, got:\n%s", out)
+		}
+		if !strings.Contains(out, "--backfill") {
+			t.Errorf("expected a backfill hint, got:\n%s", out)
+		}
+		if len(enqueued) != 0 {
+			t.Errorf("should not enqueue without --backfill, got %+v", enqueued)
+		}
+	})
+
+	t.Run("backfill queues the gaps", func(t *testing.T) {
+		out := run("--branch", branch, "--backfill")
+		mu.Lock()
+		defer mu.Unlock()
+		if len(enqueued) != 1 || enqueued[0].GitRef != gap || enqueued[0].Branch != branch {
+			t.Fatalf("expected one review of the gap queued, got %+v", enqueued)
+		}
+		if !strings.Contains(out, "Queued 1 review(s)") {
+			t.Errorf("expected the queued count, got:\n%s", out)
+		}
+	})
+}
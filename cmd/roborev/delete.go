@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+func deleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete <job_id>",
+		Short: "Delete a job and its review (recoverable with 'roborev undo')",
+		Long: `Delete a finished job along with its review and comments.
+
+Deleted jobs are hidden immediately but kept for the configured undo
+window (undo_window in config.toml, default 24h). Run 'roborev undo'
+to restore the most recent deletion before it is purged.
+
+Queued and running jobs must be canceled before they can be deleted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+
+			jobID, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || jobID <= 0 {
+				return fmt.Errorf("invalid job_id: %s", args[0])
+			}
+
+			reqBody, _ := json.Marshal(map[string]interface{}{"job_id": jobID})
+			resp, err := http.Post(getDaemonAddr()+"/api/job/delete", "application/json", bytes.NewReader(reqBody))
+			if err != nil {
+				return fmt.Errorf("failed to connect to daemon: %w", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("failed to delete job: %s", body)
+			}
+
+			var result struct {
+				UndoWindow string `json:"undo_window"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+
+			fmt.Printf("Job %d deleted (run 'roborev undo' within %s to restore)\n", jobID, result.UndoWindow)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func undoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Restore the most recently deleted job",
+		Long: `Restore the most recent deletion made with 'roborev delete'.
+
+Run repeatedly to step back through earlier deletions. Only deletions
+made within the undo window (undo_window in config.toml, default 24h)
+can be restored.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+
+			resp, err := http.Post(getDaemonAddr()+"/api/undo", "application/json", nil)
+			if err != nil {
+				return fmt.Errorf("failed to connect to daemon: %w", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode == http.StatusNotFound {
+				fmt.Println("Nothing to undo")
+				return nil
+			}
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("failed to undo: %s", body)
+			}
+
+			var result struct {
+				Restored storage.DeleteCounts `json:"restored"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+
+			fmt.Printf("Restored %d job(s), %d review(s), %d comment(s)\n",
+				result.Restored.Jobs, result.Restored.Reviews, result.Restored.Responses)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func purgeCmd() *cobra.Command {
+	var (
+		repoPath string
+		status   string
+		before   string
+		dryRun   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete finished jobs matching filters (recoverable with 'roborev undo')",
+		Long: `Delete every finished job matching the filters, along with their reviews
+and comments, in one step. Filters combine; with none, all finished jobs
+are deleted.
+
+Like 'roborev delete', purged jobs are kept for the undo window and a
+single 'roborev undo' restores the whole purge.
+
+Examples:
+  roborev purge --repo . --status failed --before 30d
+  roborev purge --status canceled --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := map[string]interface{}{"status": status, "dry_run": dryRun}
+			if before != "" {
+				age, err := parseAge(before)
+				if err != nil {
+					return err
+				}
+				req["before"] = time.Now().Add(-age).UTC().Format(time.RFC3339)
+			}
+			if repoPath != "" {
+				// Normalize to the main repo root so worktree paths match
+				// the daemon's stored repo path.
+				root, err := git.GetMainRepoRoot(repoPath)
+				if err != nil {
+					return fmt.Errorf("not a git repository: %s", repoPath)
+				}
+				req["repo_path"] = root
+			}
+
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+
+			reqBody, _ := json.Marshal(req)
+			resp, err := http.Post(getDaemonAddr()+"/api/jobs/purge", "application/json", bytes.NewReader(reqBody))
+			if err != nil {
+				return fmt.Errorf("failed to connect to daemon: %w", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("failed to purge jobs: %s", body)
+			}
+
+			var result struct {
+				Purged     storage.DeleteCounts `json:"purged"`
+				UndoWindow string               `json:"undo_window"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+
+			counts := fmt.Sprintf("%d job(s), %d review(s), %d comment(s)",
+				result.Purged.Jobs, result.Purged.Reviews, result.Purged.Responses)
+			switch {
+			case dryRun:
+				fmt.Printf("Would delete %s\n", counts)
+			case result.Purged.Jobs == 0:
+				fmt.Println("No matching jobs")
+			default:
+				fmt.Printf("Deleted %s (run 'roborev undo' within %s to restore)\n", counts, result.UndoWindow)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "only jobs in this repo")
+	cmd.Flags().StringVar(&status, "status", "", "only jobs with this status (done, failed, canceled, skipped)")
+	cmd.Flags().StringVar(&before, "before", "", "only jobs enqueued longer ago than this age (e.g. 30d, 12h)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show what would be deleted without deleting")
+	return cmd
+}
+
+// parseAge parses an age like "30d" or "2w", or a Go duration like "12h"
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w, or 12h)", s)
+	}
+	return d, nil
+}
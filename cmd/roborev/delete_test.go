@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "0d", want: 0},
+		{in: "1.5d", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAge(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAge(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
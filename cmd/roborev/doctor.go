@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+func doctorCmd() *cobra.Command {
+	var (
+		timeoutSecs int
+		agentFilter string
+		jsonOutput  bool
+		largePrompt bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "doctor",
+		Aliases: []string{"check-agents"},
+		Short:   "Diagnose agent installation, versions, and authentication",
+		Long: `Check every registered agent and report what is wrong with it.
+
+For each agent, doctor checks that its binary is on PATH, reports its
+version, and runs a short smoke-test prompt. Failures are classified, so a
+missing login or exhausted quota is reported as such with a suggested fix.
+
+With --large-prompt the smoke test pads the prompt past 33KB, to check
+that agents receive long prompts intact (Windows limits command lines).
+
+'check-agents' is an alias of this command.
+
+The daemon reports the install status and last job outcome of each agent
+in 'roborev status' without running prompts.`,
+		Example: `  roborev doctor                  # Check all agents
+  roborev doctor --agent codex    # Check only codex
+  roborev doctor --json           # Machine-readable output
+  roborev doctor --large-prompt   # Test with a 33KB+ prompt`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := agent.Available()
+			sort.Strings(names)
+
+			smokePrompt := daemon.SmokePrompt
+			if largePrompt {
+				smokePrompt += "\n" + strings.Repeat("// padding line\n", 2200)
+			}
+
+			// Smoke prompts run in the current directory
+			repoPath, err := os.Getwd()
+			if err != nil {
+				repoPath = "."
+			}
+
+			var results []storage.AgentHealth
+			for _, name := range names {
+				if name == "test" {
+					continue
+				}
+				if agentFilter != "" && name != agentFilter {
+					continue
+				}
+				a, err := agent.Get(name)
+				if err != nil {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSecs)*time.Second)
+				results = append(results, daemon.CheckAgent(ctx, a, repoPath, smokePrompt))
+				cancel()
+			}
+			if agentFilter != "" && len(results) == 0 {
+				return fmt.Errorf("unknown agent: %s", agentFilter)
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(results); err != nil {
+					return err
+				}
+			} else {
+				printDoctorResults(cmd.OutOrStdout(), results)
+			}
+
+			for _, h := range results {
+				if h.Installed && !h.Healthy {
+					return fmt.Errorf("one or more installed agents failed their checks")
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.SilenceUsage = true
+	cmd.Flags().IntVar(&timeoutSecs, "timeout", 60, "timeout in seconds per agent")
+	cmd.Flags().StringVar(&agentFilter, "agent", "", "check only this agent")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+	cmd.Flags().BoolVar(&largePrompt, "large-prompt", false,
+		"use a 33KB+ prompt to test Windows command-line limits")
+
+	return cmd
+}
+
+// printDoctorResults lists each agent's checks and a summary line
+func printDoctorResults(w io.Writer, results []storage.AgentHealth) {
+	var healthy, failed, missing int
+	for _, h := range results {
+		switch {
+		case !h.Installed:
+			fmt.Fprintf(w, "  - %-14s %s (not found in PATH)\n", h.Name, h.Command)
+			missing++
+			continue
+		case h.Healthy:
+			fmt.Fprintf(w, "  + %-14s OK\n", h.Name)
+			healthy++
+		default:
+			if h.ErrorClass != "" {
+				fmt.Fprintf(w, "  ! %-14s FAIL (%s)\n", h.Name, h.ErrorClass)
+			} else {
+				fmt.Fprintf(w, "  ! %-14s FAIL\n", h.Name)
+			}
+			failed++
+		}
+		if h.Path != "" {
+			fmt.Fprintf(w, "      path:    %s\n", h.Path)
+		}
+		if h.Version != "" {
+			fmt.Fprintf(w, "      version: %s\n", h.Version)
+		}
+		if h.Error != "" {
+			fmt.Fprintf(w, "      error:   %s\n", truncateString(h.Error, 200))
+		}
+	}
+	fmt.Fprintf(w, "\n%d healthy, %d failed, %d not installed\n", healthy, failed, missing)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestPrintDoctorResults(t *testing.T) {
+	var buf bytes.Buffer
+	printDoctorResults(&buf, []storage.AgentHealth{
+		{Name: "claude-code", Command: "claude", Path: "/usr/bin/claude", Version: "2.0.1", Installed: true,
+			ErrorClass: storage.ErrorClassAuth, Error: "agent: not logged in (check that the agent CLI is logged in or its API key is set)"},
+		{Name: "codex", Command: "codex", Path: "/usr/bin/codex", Version: "codex-cli 0.45.0", Installed: true, Healthy: true},
+		{Name: "gemini", Command: "gemini"},
+	})
+	out := buf.String()
+
+	for _, want := range []string{
+		"! claude-code    FAIL (auth)",
+		"error:   agent: not logged in",
+		"+ codex          OK",
+		"version: codex-cli 0.45.0",
+		"- gemini         gemini (not found in PATH)",
+		"1 healthy, 1 failed, 1 not installed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
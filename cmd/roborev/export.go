@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/export"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/integrity"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+func exportCmd() *cobra.Command {
+	var (
+		format    string
+		output    string
+		repoPath  string
+		since     string
+		verifyKey string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <findings|jobs>",
+		Short: "Export findings or job metrics as CSV or Parquet",
+		Long: `Export review data for analysis in other tools.
+
+  findings  One row per structured finding, with its job's repo, ref,
+            agent, and review type
+  jobs      One row per job: status, timing, retries, tokens, cost,
+            verdict, finding count, and signature state
+
+The format defaults to the output file's extension, or CSV when writing
+to stdout. Timestamps are UTC RFC 3339.
+
+When review_signing_key is configured, or --verify-key names a signing
+key or its public key, each review's signature is verified and reported
+as valid, invalid, unsigned, or unknown-key.
+
+Examples:
+  roborev export findings -o findings.csv
+  roborev export jobs --repo . --since 30d -o jobs.parquet`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"findings", "jobs"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "" {
+				format = strings.TrimPrefix(filepath.Ext(output), ".")
+				if !slices.Contains(export.Formats, format) {
+					format = "csv"
+				}
+			}
+			if !slices.Contains(export.Formats, format) {
+				return fmt.Errorf("unknown format %q (use csv or parquet)", format)
+			}
+
+			var filter storage.ExportFilter
+			if repoPath != "" {
+				root, err := git.GetMainRepoRoot(repoPath)
+				if err != nil {
+					return fmt.Errorf("not a git repository: %s", repoPath)
+				}
+				filter.RepoPath = root
+			}
+			if since != "" {
+				age, err := parseAge(since)
+				if err != nil {
+					return err
+				}
+				filter.Since = time.Now().Add(-age)
+			}
+
+			dbPath := storage.DefaultDBPath()
+			if dbPath == "" {
+				return fmt.Errorf("cannot determine database path")
+			}
+			db, err := storage.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("open database: %w", err)
+			}
+			defer db.Close()
+
+			var table *export.Table
+			switch args[0] {
+			case "findings":
+				findings, err := db.ExportFindings(filter)
+				if err != nil {
+					return err
+				}
+				table = export.FindingsTable(findings)
+			case "jobs":
+				jobs, err := db.ExportJobMetrics(filter)
+				if err != nil {
+					return err
+				}
+				if verifyKey == "" {
+					if cfg, err := config.LoadGlobal(); err == nil {
+						verifyKey = cfg.ReviewSigningKey
+					}
+				}
+				if verifyKey != "" {
+					if err := verifyJobSignatures(db, verifyKey, jobs); err != nil {
+						return err
+					}
+				}
+				table = export.JobsTable(jobs)
+			default:
+				return fmt.Errorf("unknown export %q (use findings or jobs)", args[0])
+			}
+
+			if output == "" || output == "-" {
+				return export.Write(cmd.OutOrStdout(), format, table)
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			if err := export.Write(f, format, table); err != nil {
+				f.Close()
+				return fmt.Errorf("write %s: %w", output, err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("write %s: %w", output, err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d row(s) to %s\n", len(table.Rows), output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", "output format: csv or parquet (default: from --output extension, else csv)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write (default: stdout)")
+	cmd.Flags().StringVar(&repoPath, "repo", "", "only jobs in this repo")
+	cmd.Flags().StringVar(&since, "since", "", "only jobs enqueued within this age (e.g. 30d, 12h)")
+	cmd.Flags().StringVar(&verifyKey, "verify-key", "", "key file to verify review signatures with (default: review_signing_key)")
+	return cmd
+}
+
+// verifyJobSignatures records the signature state of each job's review
+func verifyJobSignatures(db *storage.DB, keyPath string, jobs []storage.JobMetrics) error {
+	key, err := integrity.LoadKey(keyPath)
+	if err != nil {
+		return err
+	}
+	for i, j := range jobs {
+		if j.Status != storage.JobStatusDone {
+			continue
+		}
+		if jobs[i].Signature, err = integrity.Check(db, key, j.JobID); err != nil {
+			return fmt.Errorf("job %d: %w", j.JobID, err)
+		}
+	}
+	return nil
+}
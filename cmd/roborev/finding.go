@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+func findingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "finding",
+		Short: "List a review's findings and change their status",
+		Long: `List the findings of a review and move them through their lifecycle.
+
+A finding is open until it is fixed, dismissed as not worth fixing, or
+acknowledged as a real issue left for later. The daemon marks open and
+acknowledged findings fixed on its own when a later review of the same
+files on the same branch no longer reports them.
+
+Subcommands:
+  list     - List a job's findings with their IDs and statuses
+  fix      - Mark findings fixed
+  dismiss  - Mark findings dismissed
+  ack      - Mark findings acknowledged
+  reopen   - Mark findings open again`,
+	}
+
+	cmd.AddCommand(findingListCmd())
+	cmd.AddCommand(findingStatusCmd("fix", storage.FindingFixed, "Mark findings fixed"))
+	cmd.AddCommand(findingStatusCmd("dismiss", storage.FindingDismissed, "Mark findings dismissed as not worth fixing"))
+	cmd.AddCommand(findingStatusCmd("ack", storage.FindingAcknowledged, "Mark findings acknowledged, to fix later"))
+	cmd.AddCommand(findingStatusCmd("reopen", storage.FindingOpen, "Mark findings open again"))
+
+	return cmd
+}
+
+func findingListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list <job-id>",
+		Short:   "List a job's findings with their IDs and statuses",
+		Example: `  roborev finding list 42`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobID, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || jobID <= 0 {
+				return fmt.Errorf("invalid job_id: %s", args[0])
+			}
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			findings, err := fetchFindings(ctx, getDaemonAddr(), jobID)
+			if err != nil {
+				return err
+			}
+			if len(findings) == 0 {
+				cmd.Printf("No findings recorded for job %d\n", jobID)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTATUS\tSEVERITY\tLOCATION\tTITLE")
+			for _, f := range findings {
+				location := f.File
+				if f.Line > 0 {
+					location = fmt.Sprintf("%s:%d", f.File, f.Line)
+				}
+				title := f.Title
+				if title == "" {
+					title = f.Message
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", f.ID, f.Status, f.SeverityLabel(), location, truncateString(title, 70))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// findingStatusCmd returns a subcommand that moves findings to status
+func findingStatusCmd(name, status, short string) *cobra.Command {
+	var note string
+
+	cmd := &cobra.Command{
+		Use:   name + " <finding-id>...",
+		Short: short,
+		Long: short + `.
+
+Finding IDs are the ones shown by 'roborev finding list'. A finding can
+move to any status from any other; --note records why.`,
+		Example: fmt.Sprintf(`  roborev finding %s 17
+  roborev finding %s 17 18 --note "tracked in #123"`, name, name),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]int64, 0, len(args))
+			for _, arg := range args {
+				id, err := strconv.ParseInt(arg, 10, 64)
+				if err != nil || id <= 0 {
+					return fmt.Errorf("invalid finding id: %s", arg)
+				}
+				ids = append(ids, id)
+			}
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			addr := getDaemonAddr()
+			for _, id := range ids {
+				if err := setFindingStatus(ctx, addr, id, status, note); err != nil {
+					return fmt.Errorf("finding %d: %w", id, err)
+				}
+				cmd.Printf("Finding %d is now %s\n", id, status)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&note, "note", "", "why the status changed")
+
+	return cmd
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestFindingCmd(t *testing.T) {
+	var updates []daemon.FindingStatusRequest
+	_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/findings":
+			json.NewEncoder(w).Encode(map[string]interface{}{"findings": []storage.Finding{
+				{ID: 7, Severity: "high", File: "main.go", Line: 12, Title: "Nil dereference", Status: storage.FindingOpen},
+			}})
+		case "/api/findings/status":
+			var req daemon.FindingStatusRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			updates = append(updates, req)
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+		}
+	}))
+	defer cleanup()
+
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := findingCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("list", "42")
+	if err != nil {
+		t.Fatalf("finding list: %v", err)
+	}
+	if !strings.Contains(out, "main.go:12") || !strings.Contains(out, "Nil dereference") || !strings.Contains(out, "open") {
+		t.Errorf("unexpected listing:\n%s", out)
+	}
+
+	if _, err := run("dismiss", "7", "8", "--note", "intended"); err != nil {
+		t.Fatalf("finding dismiss: %v", err)
+	}
+	if len(updates) != 2 || updates[0].ID != 7 || updates[1].ID != 8 ||
+		updates[0].Status != storage.FindingDismissed || updates[0].Note != "intended" {
+		t.Errorf("unexpected status updates: %+v", updates)
+	}
+
+	if _, err := run("ack", "abc"); err == nil {
+		t.Error("expected an error for a non-numeric finding id")
+	}
+}
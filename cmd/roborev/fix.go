@@ -281,6 +281,30 @@ func fixJobDirect(ctx context.Context, params fixJobParams, prompt string) (*fix
 	return &fixJobResult{NoChanges: !hasChanges, AgentOutput: agentOutput}, nil
 }
 
+// stampFixCommit adds a Roborev-Reviewed trailer for each fixed review to
+// the commit the fix agent created, when commit trailers are enabled. The
+// commit is amended, so result is updated with its new SHA.
+func stampFixCommit(repoRoot string, result *fixJobResult, reviews ...*storage.Review) error {
+	cfg, _ := config.LoadGlobal()
+	if !config.ResolveCommitTrailers(repoRoot, cfg) {
+		return nil
+	}
+	// Only the agent's own commit is amended, never one made since
+	if head, err := git.ResolveSHA(repoRoot, "HEAD"); err != nil || head != result.NewCommitSHA {
+		return fmt.Errorf("HEAD is no longer the fix commit")
+	}
+	trailers := make([]string, len(reviews))
+	for i, r := range reviews {
+		trailers[i] = reviewTrailer(r)
+	}
+	sha, err := git.AmendTrailers(repoRoot, trailers...)
+	if err != nil {
+		return err
+	}
+	result.NewCommitSHA = sha
+	return nil
+}
+
 // resolveFixAgent resolves and configures the agent for fix operations.
 func resolveFixAgent(repoPath string, opts fixOptions) (agent.Agent, error) {
 	cfg, err := config.LoadGlobal()
@@ -672,6 +696,12 @@ func fixSingleJob(cmd *cobra.Command, repoRoot string, jobID int64, opts fixOpti
 		}
 	}
 
+	if result.CommitCreated {
+		if err := stampFixCommit(repoRoot, result, review); err != nil && !opts.quiet {
+			cmd.Printf("Warning: could not add commit trailer: %v\n", err)
+		}
+	}
+
 	// Enqueue review for fix commit
 	if result.CommitCreated {
 		if err := enqueueIfNeeded(serverAddr, repoRoot, result.NewCommitSHA); err != nil && !opts.quiet {
@@ -848,6 +878,16 @@ func runFixBatch(cmd *cobra.Command, jobIDs []int64, branch string, newestFirst
 			}
 		}
 
+		if result.CommitCreated {
+			reviews := make([]*storage.Review, len(batch))
+			for j, e := range batch {
+				reviews[j] = e.review
+			}
+			if stampErr := stampFixCommit(repoRoot, result, reviews...); stampErr != nil && !opts.quiet {
+				cmd.Printf("Warning: could not add commit trailer: %v\n", stampErr)
+			}
+		}
+
 		// Enqueue review for fix commit
 		if result.CommitCreated {
 			if enqErr := enqueueIfNeeded(serverAddr, repoRoot, result.NewCommitSHA); enqErr != nil && !opts.quiet {
@@ -691,6 +691,45 @@ func initTestGitRepo(t *testing.T) string {
 	return tmpDir
 }
 
+func TestStampFixCommit(t *testing.T) {
+	tmpDir := initTestGitRepo(t)
+	os.WriteFile(filepath.Join(tmpDir, ".roborev.toml"), []byte("commit_trailers = true\n"), 0644)
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "Fix findings"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	headOut, err := exec.Command("git", "-C", tmpDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := strings.TrimSpace(string(headOut))
+
+	result := &fixJobResult{CommitCreated: true, NewCommitSHA: head}
+	reviews := []*storage.Review{
+		{JobID: 3, Output: "- **High**: unchecked error in a.go:3"},
+		{JobID: 4, Output: "No issues found."},
+	}
+	if err := stampFixCommit(tmpDir, result, reviews...); err != nil {
+		t.Fatalf("stampFixCommit: %v", err)
+	}
+	if result.NewCommitSHA == head {
+		t.Error("expected the fix commit to be amended")
+	}
+
+	out, err := exec.Command("git", "-C", tmpDir, "log", "-1", "--format=%B").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Roborev-Reviewed: job 3 (high findings)", "Roborev-Reviewed: job 4 (no high findings)"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in commit message, got %q", want, out)
+		}
+	}
+}
+
 // fakeAgent implements agent.Agent for testing fixJobDirect.
 type fakeAgent struct {
 	name     string
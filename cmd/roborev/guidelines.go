@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// maxSuggestReviewSize caps how much of each review goes into the
+// suggestion prompt
+const maxSuggestReviewSize = 4000
+
+// guidelinesKeyPattern matches the review_guidelines key in .roborev.toml
+var guidelinesKeyPattern = regexp.MustCompile(`^\s*review_guidelines\s*=`)
+
+func guidelinesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "guidelines",
+		Short: "Manage review guidelines",
+	}
+	cmd.AddCommand(guidelinesSuggestCmd())
+	return cmd
+}
+
+func guidelinesSuggestCmd() *cobra.Command {
+	var (
+		agentName string
+		model     string
+		limit     int
+		apply     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "suggest",
+		Short: "Propose review guidelines from responses to past reviews",
+		Long: `Analyze the comments left on this repo's reviews and propose additions to
+review_guidelines in .roborev.toml.
+
+Responses that dismiss findings ("intentional", "we don't care about this
+here") are the clearest signal of what reviews should stop flagging. An
+agent reads recent reviews with their responses and suggests guidelines
+that would have prevented the dismissed findings.
+
+The proposed change is printed as a diff; use --apply to write it.`,
+		Example: `  roborev guidelines suggest
+  roborev guidelines suggest --limit 100 --agent claude-code
+  roborev guidelines suggest --apply`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ensureDaemon(); err != nil {
+				return err
+			}
+			workDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+			repoRoot, err := git.GetMainRepoRoot(workDir)
+			if err != nil {
+				return fmt.Errorf("not in a git repository: %w", err)
+			}
+
+			entries, err := collectReviewResponses(repoRoot, limit)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No responses to reviews found for this repo; nothing to suggest.")
+				return nil
+			}
+
+			current := ""
+			if repoCfg, err := config.LoadRepoConfig(repoRoot); err != nil {
+				return fmt.Errorf("load .roborev.toml: %w", err)
+			} else if repoCfg != nil {
+				current = repoCfg.ReviewGuidelines
+			}
+
+			a, err := resolveSuggestAgent(repoRoot, agentName, model)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Analyzing %d review(s) with responses using %s...\n", len(entries), a.Name())
+			output, err := a.Review(cmd.Context(), repoRoot, "HEAD", buildGuidelineSuggestionPrompt(current, entries), nil)
+			if err != nil {
+				return fmt.Errorf("agent failed: %w", err)
+			}
+
+			suggestions := parseGuidelineSuggestions(output)
+			if len(suggestions) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No new guidelines suggested.")
+				return nil
+			}
+
+			path := filepath.Join(repoRoot, ".roborev.toml")
+			oldContent, err := os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("read .roborev.toml: %w", err)
+			}
+			newContent := addGuidelinesToTOML(string(oldContent), current, suggestions)
+
+			if !apply {
+				fmt.Fprint(cmd.OutOrStdout(), unifiedDiff(".roborev.toml", string(oldContent), newContent))
+				fmt.Fprintln(cmd.OutOrStdout(), "\nRun with --apply to write these guidelines.")
+				return nil
+			}
+			if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
+				return fmt.Errorf("write .roborev.toml: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Added %d guideline(s) to %s\n", len(suggestions), path)
+			return nil
+		},
+	}
+
+	cmd.SilenceUsage = true
+	cmd.Flags().StringVar(&agentName, "agent", "", "agent to analyze responses (default: from config)")
+	cmd.Flags().StringVar(&model, "model", "", "model for agent")
+	cmd.Flags().IntVar(&limit, "limit", 50, "number of recent reviews to examine")
+	cmd.Flags().BoolVar(&apply, "apply", false, "write the suggested guidelines to .roborev.toml")
+
+	return cmd
+}
+
+// reviewResponses is a review together with the responses left on it
+type reviewResponses struct {
+	job       storage.ReviewJob
+	output    string
+	responses []storage.Response
+}
+
+// collectReviewResponses returns the repo's most recent completed reviews
+// that have at least one response
+func collectReviewResponses(repoRoot string, limit int) ([]reviewResponses, error) {
+	addr := getDaemonAddr()
+	resp, err := http.Get(fmt.Sprintf("%s/api/jobs?status=done&repo=%s&limit=%d", addr, url.QueryEscape(repoRoot), limit))
+	if err != nil {
+		return nil, fmt.Errorf("query jobs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server error (%d): %s", resp.StatusCode, body)
+	}
+	var jobsResp struct {
+		Jobs []storage.ReviewJob `json:"jobs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jobsResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	var entries []reviewResponses
+	for _, job := range jobsResp.Jobs {
+		if job.IsTaskJob() {
+			continue
+		}
+		responses, err := getCommentsForJob(job.ID)
+		if err != nil || len(responses) == 0 {
+			continue
+		}
+		review, err := fetchReview(context.Background(), addr, job.ID)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, reviewResponses{job: job, output: review.Output, responses: responses})
+	}
+	return entries, nil
+}
+
+// resolveSuggestAgent returns a read-only agent for analyzing responses
+func resolveSuggestAgent(repoRoot, agentName, model string) (agent.Agent, error) {
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	a, err := agent.GetAvailable(config.ResolveAgent(agentName, repoRoot, cfg))
+	if err != nil {
+		return nil, fmt.Errorf("get agent: %w", err)
+	}
+	a = a.WithAgentic(false)
+	if m := config.ResolveModel(model, repoRoot, cfg); m != "" {
+		a = a.WithModel(m)
+	}
+	return a, nil
+}
+
+// buildGuidelineSuggestionPrompt asks an agent for guidelines that account
+// for how developers responded to past findings
+func buildGuidelineSuggestionPrompt(current string, entries []reviewResponses) string {
+	var sb strings.Builder
+	sb.WriteString("# Review Guideline Suggestions\n\n")
+	sb.WriteString("Below are code reviews of this repository and the developers' responses to them. ")
+	sb.WriteString("Identify findings that developers dismissed, disputed, or marked as intentional, ")
+	sb.WriteString("and propose project review guidelines that would stop future reviews from raising them. ")
+	sb.WriteString("Only propose a guideline when the responses show a clear, repeatable project convention.\n\n")
+
+	sb.WriteString("## Current Guidelines\n\n")
+	if strings.TrimSpace(current) == "" {
+		sb.WriteString("(none)\n\n")
+	} else {
+		sb.WriteString(strings.TrimSpace(current))
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString("## Reviews and Responses\n\n")
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "### Review of %s (job %d)\n\n", shortRef(e.job.GitRef), e.job.ID)
+		sb.WriteString(truncateString(strings.TrimSpace(e.output), maxSuggestReviewSize))
+		sb.WriteString("\n\nResponses:\n")
+		for _, r := range e.responses {
+			fmt.Fprintf(&sb, "- %s: %s\n", r.Responder, strings.TrimSpace(r.Response))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Instructions\n\n")
+	sb.WriteString("Reply with only the new guidelines, one per line, each starting with \"- \". ")
+	sb.WriteString("Do not repeat the current guidelines. ")
+	sb.WriteString("If no guideline is warranted, reply with exactly: NONE\n")
+	return sb.String()
+}
+
+// parseGuidelineSuggestions extracts the list items from an agent's reply
+func parseGuidelineSuggestions(output string) []string {
+	var suggestions []string
+	for _, line := range strings.Split(output, "\n") {
+		if !listItemPattern.MatchString(line) {
+			continue
+		}
+		if s := strings.TrimSpace(listItemPattern.ReplaceAllString(line, "")); s != "" {
+			suggestions = append(suggestions, s)
+		}
+	}
+	return suggestions
+}
+
+// addGuidelinesToTOML returns content with suggestions appended to
+// review_guidelines as list items. An existing value is rewritten as a
+// multi-line string; a missing one is added before the first table so it
+// stays a top-level key.
+func addGuidelinesToTOML(content, current string, suggestions []string) string {
+	guidelines := strings.TrimRight(current, "\n")
+	for _, s := range suggestions {
+		if guidelines != "" {
+			guidelines += "\n"
+		}
+		guidelines += "- " + s
+	}
+	value := "review_guidelines = \"\"\"\n" + escapeTOMLMultiline(guidelines) + "\n\"\"\""
+
+	lines := strings.Split(content, "\n")
+	start, end := -1, -1
+	firstTable := len(lines)
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "[") {
+			firstTable = i
+			break
+		}
+		if guidelinesKeyPattern.MatchString(line) {
+			start, end = i, i
+			rest := strings.TrimSpace(line[strings.Index(line, "=")+1:])
+			for _, delim := range []string{`"""`, `'''`} {
+				if strings.HasPrefix(rest, delim) && !strings.Contains(rest[len(delim):], delim) {
+					for j := i + 1; j < len(lines); j++ {
+						if strings.Contains(lines[j], delim) {
+							end = j
+							break
+						}
+					}
+				}
+			}
+			break
+		}
+	}
+
+	var out []string
+	if start >= 0 {
+		out = append(out, lines[:start]...)
+		out = append(out, value)
+		out = append(out, lines[end+1:]...)
+		return strings.Join(out, "\n")
+	}
+	out = append(out, lines[:firstTable]...)
+	// Keep a blank line between the new key and what follows
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	if len(out) > 0 {
+		out = append(out, "")
+	}
+	out = append(out, value, "")
+	if firstTable < len(lines) {
+		out = append(out, lines[firstTable:]...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// escapeTOMLMultiline escapes s for a TOML basic multi-line string
+func escapeTOMLMultiline(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"""`, `""\"`)
+}
+
+// unifiedDiff returns a single-hunk unified diff between two versions of
+// a file. Changes made by addGuidelinesToTOML are contiguous, so one hunk
+// spanning them is enough.
+func unifiedDiff(name, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	oldLines := splitDiffLines(oldText)
+	newLines := splitDiffLines(newText)
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	const diffContext = 3
+	from := max(prefix-diffContext, 0)
+	oldTo := min(len(oldLines)-suffix+diffContext, len(oldLines))
+	newTo := min(len(newLines)-suffix+diffContext, len(newLines))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(from, oldTo-from), hunkRange(from, newTo-from))
+	for _, l := range oldLines[from:prefix] {
+		sb.WriteString(" " + l + "\n")
+	}
+	for _, l := range oldLines[prefix : len(oldLines)-suffix] {
+		sb.WriteString("-" + l + "\n")
+	}
+	for _, l := range newLines[prefix : len(newLines)-suffix] {
+		sb.WriteString("+" + l + "\n")
+	}
+	for _, l := range oldLines[len(oldLines)-suffix : oldTo] {
+		sb.WriteString(" " + l + "\n")
+	}
+	return sb.String()
+}
+
+// splitDiffLines splits text into lines, ignoring a final newline
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// hunkRange formats a hunk header range from a 0-based start and a count
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestParseGuidelineSuggestions(t *testing.T) {
+	output := "Here are my suggestions:\n\n- Generated files under gen/ are not reviewed\n* Panics in tests are fine\n1. Prefer table tests\n\nNONE of these are critical."
+	got := parseGuidelineSuggestions(output)
+	want := []string{"Generated files under gen/ are not reviewed", "Panics in tests are fine", "Prefer table tests"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := parseGuidelineSuggestions("NONE"); len(got) != 0 {
+		t.Errorf("expected no suggestions, got %q", got)
+	}
+}
+
+func TestAddGuidelinesToTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "no file",
+			content: "",
+			want:    "- Ignore gen/",
+		},
+		{
+			name:    "key missing, table present",
+			content: "agent = \"codex\"\n\n[ci]\nagents = [\"codex\"]\n",
+			want:    "- Ignore gen/",
+		},
+		{
+			name:    "single-line value",
+			content: "review_guidelines = \"Be brief\"\nagent = \"codex\"\n",
+			want:    "Be brief\n- Ignore gen/",
+		},
+		{
+			name:    "multi-line value",
+			content: "review_guidelines = \"\"\"\n- No panics\n- Use \\\"errors\\\"\n\"\"\"\n\n[ci]\nagents = [\"codex\"]\n",
+			want:    "- No panics\n- Use \"errors\"\n- Ignore gen/",
+		},
+		{
+			name:    "literal multi-line value",
+			content: "review_guidelines = '''\nPaths like C:\\tmp are fine\n'''\n",
+			want:    "Paths like C:\\tmp are fine\n- Ignore gen/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before config.RepoConfig
+			if _, err := toml.Decode(tt.content, &before); err != nil {
+				t.Fatalf("decode input: %v", err)
+			}
+			updated := addGuidelinesToTOML(tt.content, before.ReviewGuidelines, []string{"Ignore gen/"})
+
+			var after config.RepoConfig
+			if _, err := toml.Decode(updated, &after); err != nil {
+				t.Fatalf("decode output: %v\n%s", err, updated)
+			}
+			if strings.TrimSpace(after.ReviewGuidelines) != tt.want {
+				t.Errorf("guidelines = %q, want %q\n%s", after.ReviewGuidelines, tt.want, updated)
+			}
+			if before.Agent != after.Agent || len(before.CI.Agents) != len(after.CI.Agents) {
+				t.Errorf("other settings changed:\n%s", updated)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\n"
+	newText := "a\nb\nc\nd\nX\ne\nf\ng\n"
+	want := "--- a/f.toml\n+++ b/f.toml\n@@ -2,6 +2,7 @@\n b\n c\n d\n+X\n e\n f\n g\n"
+	if got := unifiedDiff("f.toml", oldText, newText); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got := unifiedDiff("f.toml", "", "x\n")
+	if !strings.Contains(got, "@@ -0,0 +1,1 @@\n+x\n") {
+		t.Errorf("new file diff:\n%s", got)
+	}
+	if unifiedDiff("f.toml", "same\n", "same\n") != "" {
+		t.Error("expected empty diff for identical text")
+	}
+}
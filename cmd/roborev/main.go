@@ -17,6 +17,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -64,6 +65,13 @@ func main() {
 	rootCmd.AddCommand(commentCmd())
 	rootCmd.AddCommand(respondCmd()) // hidden alias for backward compatibility
 	rootCmd.AddCommand(addressCmd())
+	rootCmd.AddCommand(deleteCmd())
+	rootCmd.AddCommand(undoCmd())
+	rootCmd.AddCommand(purgeCmd())
+	rootCmd.AddCommand(exportCmd())
+	rootCmd.AddCommand(verifyCmd())
+	rootCmd.AddCommand(coverageCmd())
+	rootCmd.AddCommand(mergeQueueCmd())
 	rootCmd.AddCommand(installHookCmd())
 	rootCmd.AddCommand(uninstallHookCmd())
 	rootCmd.AddCommand(daemonCmd())
@@ -73,15 +81,37 @@ func main() {
 	rootCmd.AddCommand(runCmd())
 	rootCmd.AddCommand(analyzeCmd())
 	rootCmd.AddCommand(fixCmd())
+	rootCmd.AddCommand(recheckCmd())
+	rootCmd.AddCommand(findingCmd())
+	rootCmd.AddCommand(searchCmd())
 	rootCmd.AddCommand(promptCmd()) // hidden alias for backward compatibility
 	rootCmd.AddCommand(repoCmd())
 	rootCmd.AddCommand(skillsCmd())
 	rootCmd.AddCommand(syncCmd())
-	rootCmd.AddCommand(checkAgentsCmd())
+	rootCmd.AddCommand(doctorCmd())
+	rootCmd.AddCommand(guidelinesCmd())
 	rootCmd.AddCommand(configCmd())
 	rootCmd.AddCommand(updateCmd())
 	rootCmd.AddCommand(versionCmd())
 
+	// Executables in agents.d are registered alongside the built-in agents
+	if _, err := agent.LoadPlugins(filepath.Join(config.DataDir(), "agents.d")); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Expand the user's aliases and command defaults from the global config,
+	// and sandbox the agents that commands such as fix, run, analyze, and
+	// review --local start in this process
+	if cfg, err := config.LoadGlobal(); err == nil {
+		agent.SetSandbox(agent.Sandbox(cfg.Sandbox))
+		args, err := expandAliases(rootCmd, os.Args[1:], cfg.Aliases, cfg.CommandDefaults)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		rootCmd.SetArgs(args)
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		// Check for exitError to exit with specific code without extra output
 		if exitErr, ok := err.(*exitError); ok {
@@ -606,6 +636,8 @@ func daemonCmd() *cobra.Command {
 	})
 
 	cmd.AddCommand(daemonRunCmd())
+	cmd.AddCommand(daemonSnapshotCmd())
+	cmd.AddCommand(daemonRestoreCmd())
 
 	return cmd
 }
@@ -616,6 +648,7 @@ func daemonRunCmd() *cobra.Command {
 		dbPath     string
 		configPath string
 		addr       string
+		portRange  string
 		workers    int
 	)
 
@@ -657,6 +690,9 @@ func daemonRunCmd() *cobra.Command {
 			if addr != "" {
 				cfg.ServerAddr = addr
 			}
+			if portRange != "" {
+				cfg.ServerPortRange = portRange
+			}
 			if workers > 0 {
 				cfg.MaxWorkers = workers
 			}
@@ -761,6 +797,7 @@ func daemonRunCmd() *cobra.Command {
 	cmd.Flags().StringVar(&dbPath, "db", storage.DefaultDBPath(), "path to sqlite database")
 	cmd.Flags().StringVar(&configPath, "config", config.GlobalConfigPath(), "path to config file")
 	cmd.Flags().StringVar(&addr, "addr", "", "server address (overrides config)")
+	cmd.Flags().StringVar(&portRange, "port-range", "", `ports to listen on, e.g. "7373-7380", or one port to require it (overrides config)`)
 	cmd.Flags().IntVar(&workers, "workers", 0, "number of workers (overrides config)")
 
 	return cmd
@@ -778,13 +815,17 @@ func reviewCmd() *cobra.Command {
 		reasoning  string
 		reviewType string
 		fast       bool
+		quick      bool
+		perCommit  bool
 		quiet      bool
 		dirty      bool
+		scope      string
 		wait       bool
 		branch     string
 		baseBranch string
 		since      string
 		local      bool
+		force      bool
 	)
 
 	cmd := &cobra.Command{
@@ -799,6 +840,7 @@ Examples:
   roborev review abc123 def456  # Review range from abc123 to def456 (inclusive)
   roborev review --dirty      # Review uncommitted changes
   roborev review --dirty --wait  # Review uncommitted changes and wait for result
+  roborev review --scope staged  # Review only what is staged for the next commit
   roborev review --type design   # Design-focused review of HEAD
   roborev review --branch     # Review all commits on current branch since main
   roborev review --branch --base develop  # Review branch against develop
@@ -807,6 +849,9 @@ Examples:
   roborev review --since abc123  # Review commits since abc123 (exclusive)
   roborev review --type security   # Security-focused review of HEAD
   roborev review --branch --type security  # Security review of branch
+  roborev review --force      # Review HEAD even if it matches a [skip] rule
+  roborev review --dirty --quick --wait  # Fast, trimmed review of work in progress
+  roborev review --branch --per-commit   # Review each commit on the branch, then summarize
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// In quiet mode, suppress cobra's error output (hook uses &, so exit code doesn't matter)
@@ -815,8 +860,24 @@ Examples:
 				cmd.SilenceUsage = true
 			}
 
+			// --quick is shorthand for --type quick, which also implies --fast
+			if quick {
+				if reviewType != "" && reviewType != "quick" {
+					return fmt.Errorf("cannot use --quick with --type %s", reviewType)
+				}
+				reviewType = "quick"
+			}
+
 			// --fast is shorthand for --reasoning fast (explicit --reasoning takes precedence)
-			reasoning = resolveReasoningWithFast(reasoning, fast, cmd.Flags().Changed("reasoning"))
+			reasoning = resolveReasoningWithFast(reasoning, fast || quick, cmd.Flags().Changed("reasoning"))
+
+			// --scope narrows a dirty review, so it implies --dirty
+			if scope != "" {
+				if !git.IsValidDirtyScope(scope) {
+					return fmt.Errorf("invalid --scope %q (valid: %s, %s, %s)", scope, git.DirtyScopeAll, git.DirtyScopeWorktree, git.DirtyScopeStaged)
+				}
+				dirty = true
+			}
 
 			// Default to current directory
 			if repoPath == "" {
@@ -863,10 +924,13 @@ Examples:
 			if since != "" && len(args) > 0 {
 				return fmt.Errorf("cannot specify commits with --since")
 			}
+			if perCommit && branch == "" && since == "" && len(args) < 2 {
+				return fmt.Errorf("--per-commit requires a range (two commits, --branch, or --since)")
+			}
 
 			// Validate --type flag
-			if reviewType != "" && reviewType != "security" && reviewType != "design" {
-				return fmt.Errorf("invalid --type %q (valid: security, design)", reviewType)
+			if reviewType != "" && !slices.Contains(config.SpecialReviewTypes, reviewType) {
+				return fmt.Errorf("invalid --type %q (valid: %s)", reviewType, strings.Join(config.SpecialReviewTypes, ", "))
 			}
 
 			var gitRef string
@@ -954,8 +1018,8 @@ Examples:
 					return fmt.Errorf("no uncommitted changes to review")
 				}
 
-				// Generate dirty diff (includes untracked files)
-				diffContent, err = git.GetDirtyDiff(root)
+				// Generate dirty diff (includes untracked files unless scoped)
+				diffContent, err = git.GetDirtyDiffScope(root, scope)
 				if err != nil {
 					return fmt.Errorf("get dirty diff: %w", err)
 				}
@@ -967,6 +1031,9 @@ Examples:
 				}
 
 				if diffContent == "" {
+					if scope != "" && scope != git.DirtyScopeAll {
+						return fmt.Errorf("no %s changes to review", scope)
+					}
 					return fmt.Errorf("no changes to review (diff is empty)")
 				}
 
@@ -991,7 +1058,7 @@ Examples:
 
 			// Handle --local mode: run agent directly without daemon
 			if local {
-				return runLocalReview(cmd, root, gitRef, diffContent, agent, model, reasoning, reviewType, quiet)
+				return runLocalReview(cmd, root, gitRef, diffContent, agent, model, reasoning, reviewType, perCommit, quiet)
 			}
 
 			// Build request body
@@ -1004,6 +1071,8 @@ Examples:
 				"reasoning":    reasoning,
 				"review_type":  reviewType,
 				"diff_content": diffContent,
+				"force":        force,
+				"per_commit":   perCommit,
 			}
 
 			reqBody, _ := json.Marshal(reqFields)
@@ -1037,6 +1106,13 @@ Examples:
 			var job storage.ReviewJob
 			json.Unmarshal(body, &job)
 
+			if job.Status == storage.JobStatusSkipped {
+				if !quiet {
+					cmd.Printf("Skipped job %d for %s: %s (use --force to review)\n", job.ID, shortRef(job.GitRef), job.Error)
+				}
+				return nil
+			}
+
 			if !quiet {
 				if dirty {
 					cmd.Printf("Enqueued dirty review job %d (agent: %s)\n", job.ID, job.Agent)
@@ -1069,19 +1145,23 @@ Examples:
 	cmd.Flags().BoolVar(&fast, "fast", false, "shorthand for --reasoning fast")
 	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "suppress output (for use in hooks)")
 	cmd.Flags().BoolVar(&dirty, "dirty", false, "review uncommitted changes instead of a commit")
+	cmd.Flags().StringVar(&scope, "scope", "", "uncommitted changes to review: all (default, includes untracked files), worktree (tracked files only), or staged; implies --dirty")
 	cmd.Flags().BoolVar(&wait, "wait", false, "wait for review to complete and show result")
 	cmd.Flags().StringVar(&branch, "branch", "", "review all changes since branch diverged from base (optionally specify branch name)")
 	cmd.Flags().Lookup("branch").NoOptDefVal = "HEAD"
 	cmd.Flags().StringVar(&baseBranch, "base", "", "base branch for --branch comparison (default: auto-detect)")
 	cmd.Flags().StringVar(&since, "since", "", "review commits since this commit (exclusive, like git's .. range)")
 	cmd.Flags().BoolVar(&local, "local", false, "run review locally without daemon (streams output to console)")
-	cmd.Flags().StringVar(&reviewType, "type", "", "review type (security, design) — changes system prompt")
+	cmd.Flags().StringVar(&reviewType, "type", "", "review type (security, design, bench, performance, docs, tests, quick) — changes system prompt")
+	cmd.Flags().BoolVar(&quick, "quick", false, "quick review: bugs and security only, no previous reviews, fast reasoning, and a short timeout")
+	cmd.Flags().BoolVar(&force, "force", false, "review even if the commit matches a skip rule")
+	cmd.Flags().BoolVar(&perCommit, "per-commit", false, "for ranges, review each commit separately and then summarize the range")
 
 	return cmd
 }
 
 // runLocalReview runs a review directly without the daemon
-func runLocalReview(cmd *cobra.Command, repoPath, gitRef, diffContent, agentName, model, reasoning, reviewType string, quiet bool) error {
+func runLocalReview(cmd *cobra.Command, repoPath, gitRef, diffContent, agentName, model, reasoning, reviewType string, perCommit, quiet bool) error {
 	// Load config
 	cfg, err := config.LoadGlobal()
 	if err != nil {
@@ -1126,20 +1206,33 @@ func runLocalReview(cmd *cobra.Command, repoPath, gitRef, diffContent, agentName
 		fmt.Fprintf(out, "Running %s review (model: %s, reasoning: %s)...\n\n", a.Name(), model, reasoning)
 	}
 
+	// Commands the builder and agent run, like the repo's tests, are in
+	// their own process group, so pass Ctrl-C on by canceling them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	// Build prompt
+	builder := prompt.NewBuilderWithConfig(nil, cfg).WithModel(model).WithContext(ctx)
+	if perCommit {
+		builder = builder.WithPerCommit()
+	}
 	var reviewPrompt string
 	if diffContent != "" {
 		// Dirty review
-		reviewPrompt, err = prompt.NewBuilder(nil).BuildDirty(repoPath, diffContent, 0, cfg.ReviewContextCount, a.Name(), reviewType)
+		reviewPrompt, err = builder.BuildDirty(repoPath, diffContent, 0, config.ResolveContextCommits(nil, repoPath, cfg), a.Name(), reviewType)
 	} else {
-		reviewPrompt, err = prompt.NewBuilder(nil).Build(repoPath, gitRef, 0, cfg.ReviewContextCount, a.Name(), reviewType)
+		reviewPrompt, err = builder.Build(repoPath, gitRef, 0, config.ResolveContextCommits(nil, repoPath, cfg), a.Name(), reviewType)
 	}
 	if err != nil {
 		return fmt.Errorf("build prompt: %w", err)
 	}
 
 	// Run review with output writer
-	ctx := context.Background()
+	if reviewType == "quick" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.ResolveQuickReviewTimeout(cfg))
+		defer cancel()
+	}
 	_, err = a.Review(ctx, repoPath, gitRef, reviewPrompt, out)
 	if err != nil {
 		return fmt.Errorf("review failed: %w", err)
@@ -1214,6 +1307,12 @@ func waitForJob(cmd *cobra.Command, serverAddr string, jobID int64, quiet bool)
 			}
 			return fmt.Errorf("review was canceled")
 
+		case storage.JobStatusSkipped:
+			if !quiet {
+				cmd.Printf(" skipped: %s\n", job.Error)
+			}
+			return nil
+
 		case storage.JobStatusQueued, storage.JobStatusRunning:
 			// Still in progress, continue polling
 			unknownStatusCount = 0 // Reset counter on known status
@@ -1270,7 +1369,7 @@ func showReview(cmd *cobra.Command, addr string, jobID int64, quiet bool) error
 	}
 
 	if !quiet {
-		cmd.Printf("Review (by %s)\n", review.Agent)
+		cmd.Printf("Review (by %s)\n", formatReviewer(review.Agent, review.AgentVersion))
 		cmd.Println(strings.Repeat("-", 60))
 		cmd.Println(review.Output)
 	}
@@ -1491,6 +1590,31 @@ func statusCmd() *cobra.Command {
 			fmt.Printf("Workers: %d/%d active\n", status.ActiveWorkers, status.MaxWorkers)
 			fmt.Printf("Jobs:    %d queued, %d running, %d completed, %d failed\n",
 				status.QueuedJobs, status.RunningJobs, status.CompletedJobs, status.FailedJobs)
+			if len(status.FailureClasses) > 0 {
+				fmt.Printf("Failed:  %s\n", formatFailureClasses(status.FailureClasses))
+			}
+			if b := status.Budget; b != nil {
+				fmt.Printf("Spend:   %s\n", formatBudget(b))
+				for _, e := range b.Exceeded {
+					scope := "all repos"
+					if e.RepoPath != "" {
+						scope = e.RepoPath
+					}
+					action := "new jobs paused until tomorrow"
+					if b.FallbackAgent != "" {
+						action = "new jobs use " + b.FallbackAgent
+					}
+					fmt.Printf("Budget:  reached for %s ($%.2f of $%.2f); %s\n", scope, e.SpentUSD, e.LimitUSD, action)
+				}
+			}
+			for _, h := range status.Agents {
+				if h.Installed && !h.Healthy {
+					fmt.Printf("Agent:   %s unhealthy: %s (run 'roborev doctor')\n", h.Name, truncateString(h.Error, 120))
+				}
+				if h.QualityAlarm != "" {
+					fmt.Printf("Agent:   %s quality alarm: %s\n", h.Name, truncateString(h.QualityAlarm, 160))
+				}
+			}
 			fmt.Println()
 
 			// Display health status
@@ -1579,6 +1703,31 @@ func statusCmd() *cobra.Command {
 	}
 }
 
+// formatBudget renders today's estimated spending, e.g. "$1.20 today of
+// $5.00 daily budget"
+func formatBudget(b *storage.BudgetStatus) string {
+	s := fmt.Sprintf("$%.2f today", b.SpentTodayUSD)
+	if b.DailyLimitUSD > 0 {
+		s += fmt.Sprintf(" of $%.2f daily budget", b.DailyLimitUSD)
+	}
+	return s + " (estimated)"
+}
+
+// formatFailureClasses renders failed job counts by error class, e.g.
+// "2 auth, 1 timeout"
+func formatFailureClasses(counts map[storage.ErrorClass]int) string {
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, string(class))
+	}
+	sort.Strings(classes)
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%d %s", counts[storage.ErrorClass(class)], class)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func listCmd() *cobra.Command {
 	var (
 		branch     string
@@ -1713,7 +1862,13 @@ Examples:
 func showCmd() *cobra.Command {
 	var forceJobID bool
 	var showPrompt bool
+	var showFull bool
 	var jsonOutput bool
+	var annotate bool
+	var minSeverity string
+	var collapse bool
+	var raw bool
+	var lang string
 
 	cmd := &cobra.Command{
 		Use:   "show [job_id|sha]",
@@ -1731,7 +1886,15 @@ Examples:
   roborev show abc123       # Show review for commit
   roborev show 42           # Job ID (if "42" is not a valid git ref)
   roborev show --job 42     # Force as job ID even if "42" is a valid ref
-  roborev show --prompt 42  # Show the prompt sent to the agent`,
+  roborev show --prompt 42  # Show the prompt sent to the agent
+  roborev show --full 42    # Show the complete output of a condensed review
+  roborev show --annotate   # Show the diff with findings inline at each line
+  roborev show --min-severity high   # Only show high and critical findings
+  roborev show --collapse   # Collapse sections that have no findings
+  roborev show --lang French 42     # Translate the review (cached after the first run)
+
+In a terminal, the review is rendered with severity colors. Use --raw for
+the plain markdown.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Ensure daemon is running (and restart if version mismatch)
@@ -1744,6 +1907,8 @@ Examples:
 
 			var queryURL string
 			var displayRef string
+			// Repo the SHA was resolved in, scoping the lookup to it
+			var repoPath string
 
 			if len(args) == 0 {
 				if forceJobID {
@@ -1754,9 +1919,10 @@ Examples:
 				if root, err := git.GetRepoRoot("."); err == nil {
 					if resolved, err := git.ResolveSHA(root, sha); err == nil {
 						sha = resolved
+						repoPath = root
 					}
 				}
-				queryURL = addr + "/api/review?sha=" + sha
+				queryURL = addr + "/api/review?sha=" + sha + repoQuery(repoPath)
 				displayRef = shortSHA(sha)
 			} else {
 				arg := args[0]
@@ -1770,6 +1936,7 @@ Examples:
 					if root, err := git.GetRepoRoot("."); err == nil {
 						if resolved, err := git.ResolveSHA(root, arg); err == nil {
 							resolvedSHA = resolved
+							repoPath = root
 						}
 					}
 					// If not resolvable as SHA and is numeric, treat as job ID
@@ -1788,11 +1955,30 @@ Examples:
 					if resolvedSHA != "" {
 						sha = resolvedSHA
 					}
-					queryURL = addr + "/api/review?sha=" + sha
+					queryURL = addr + "/api/review?sha=" + sha + repoQuery(repoPath)
 					displayRef = shortSHA(sha)
 				}
 			}
 
+			if annotate && (showPrompt || jsonOutput) {
+				return fmt.Errorf("--annotate cannot be combined with --prompt or --json")
+			}
+			severity, err := config.NormalizeMinSeverity(minSeverity)
+			if err != nil {
+				return err
+			}
+			if showFull {
+				queryURL += "&full=1"
+			}
+			if lang != "" {
+				if showPrompt {
+					return fmt.Errorf("--lang cannot be combined with --prompt")
+				}
+				queryURL += "&lang=" + url.QueryEscape(lang)
+				// The first request for a language waits for the agent
+				client.Timeout = 6 * time.Minute
+			}
+
 			resp, err := client.Get(queryURL)
 			if err != nil {
 				return fmt.Errorf("failed to connect to daemon (is it running?)")
@@ -1802,6 +1988,10 @@ Examples:
 			if resp.StatusCode == http.StatusNotFound {
 				return fmt.Errorf("no review found for %s", displayRef)
 			}
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("daemon returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+			}
 
 			var review storage.Review
 			if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
@@ -1816,15 +2006,42 @@ Examples:
 
 			// Avoid redundant "job X (job X, ...)" output
 			if strings.HasPrefix(displayRef, "job ") {
-				fmt.Printf("Review for %s (by %s)\n", displayRef, review.Agent)
+				fmt.Printf("Review for %s (by %s)\n", displayRef, formatReviewer(review.Agent, review.AgentVersion))
 			} else {
-				fmt.Printf("Review for %s (job %d, by %s)\n", displayRef, review.JobID, review.Agent)
+				fmt.Printf("Review for %s (job %d, by %s)\n", displayRef, review.JobID, formatReviewer(review.Agent, review.AgentVersion))
+			}
+			if review.InjectionWarning != "" {
+				fmt.Printf("Warning: possible prompt injection: %s\n", review.InjectionWarning)
 			}
 			fmt.Println(strings.Repeat("-", 60))
-			if showPrompt {
-				fmt.Println(review.Prompt)
-			} else {
-				fmt.Println(review.Output)
+			output, hidden := filterFindings(review.Output, severity)
+			if collapse {
+				output = collapseSections(output)
+			}
+
+			switch {
+			case showPrompt:
+				stored, err := fetchReviewPrompt(client, addr, review.ID)
+				if err != nil {
+					return err
+				}
+				fmt.Print(stored)
+				if !strings.HasSuffix(stored, "\n") {
+					fmt.Println()
+				}
+			case annotate:
+				diff, err := reviewDiff(review.Job)
+				if err != nil {
+					return fmt.Errorf("get reviewed diff: %w", err)
+				}
+				fmt.Print(renderAnnotatedReview(output, diff))
+			case !raw && isTerminal(os.Stdout.Fd()) && os.Getenv("NO_COLOR") == "":
+				fmt.Println(renderReviewForTerminal(output))
+			default:
+				fmt.Println(output)
+			}
+			if hidden > 0 && !showPrompt {
+				fmt.Printf("(%d finding(s) below %s hidden)\n", hidden, severity)
 			}
 
 			return nil
@@ -1833,10 +2050,34 @@ Examples:
 
 	cmd.Flags().BoolVar(&forceJobID, "job", false, "force argument to be treated as job ID")
 	cmd.Flags().BoolVar(&showPrompt, "prompt", false, "show the prompt sent to the agent instead of the review output")
+	cmd.Flags().BoolVar(&showFull, "full", false, "show the complete output for reviews that were condensed to fit the size limit")
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+	cmd.Flags().BoolVar(&annotate, "annotate", false, "show the reviewed diff with findings inserted below the lines they refer to")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", "", "only show findings at or above this severity (critical, high, medium, low)")
+	cmd.Flags().BoolVar(&collapse, "collapse", false, "collapse sections that contain no findings")
+	cmd.Flags().BoolVar(&raw, "raw", false, "print the review as plain markdown, even in a terminal")
+	cmd.Flags().StringVar(&lang, "lang", "", "translate the review into this language using the configured agent")
 	return cmd
 }
 
+// fetchReviewPrompt gets the prompt stored with a review
+func fetchReviewPrompt(client *http.Client, addr string, reviewID int64) (string, error) {
+	resp, err := client.Get(fmt.Sprintf("%s/api/review/%d/prompt", addr, reviewID))
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to daemon (is it running?)")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("get review prompt: daemon returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var result daemon.ReviewPromptResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return result.Prompt, nil
+}
+
 func commentCmd() *cobra.Command {
 	var (
 		commenter  string
@@ -1869,7 +2110,7 @@ Examples:
 
 			// Check if ref is a job ID (numeric) or SHA
 			var jobID int64
-			var sha string
+			var sha, repoPath string
 
 			if forceJobID {
 				// --job flag: treat ref as job ID
@@ -1884,6 +2125,7 @@ Examples:
 				if root, err := git.GetRepoRoot("."); err == nil {
 					if resolved, err := git.ResolveSHA(root, ref); err == nil {
 						sha = resolved
+						repoPath = root
 					}
 				}
 
@@ -1952,6 +2194,9 @@ Examples:
 				reqData["job_id"] = jobID
 			} else {
 				reqData["sha"] = sha
+				if repoPath != "" {
+					reqData["repo"] = repoPath
+				}
 			}
 
 			reqBody, _ := json.Marshal(reqData)
@@ -2172,6 +2417,9 @@ func waitForReviewWithInterval(jobID int64, pollInterval time.Duration) (*storag
 
 		case storage.JobStatusCanceled:
 			return nil, fmt.Errorf("job was canceled")
+
+		case storage.JobStatusSkipped:
+			return nil, fmt.Errorf("job was skipped: %s", job.Error)
 		}
 
 		time.Sleep(pollInterval)
@@ -2207,6 +2455,15 @@ func enqueueReview(repoPath, gitRef, agentName string) (int64, error) {
 	return job.ID, nil
 }
 
+// repoQuery returns a query parameter that scopes a SHA lookup to a repo,
+// or "" when the repo is unknown
+func repoQuery(repoPath string) string {
+	if repoPath == "" {
+		return ""
+	}
+	return "&repo=" + url.QueryEscape(repoPath)
+}
+
 // getCommentsForJob fetches comments for a job
 func getCommentsForJob(jobID int64) ([]storage.Response, error) {
 	addr := getDaemonAddr()
@@ -3030,112 +3287,6 @@ func syncNowCmd() *cobra.Command {
 	}
 }
 
-func checkAgentsCmd() *cobra.Command {
-	var (
-		timeoutSecs int
-		agentFilter string
-		largePrompt bool
-	)
-
-	cmd := &cobra.Command{
-		Use:   "check-agents",
-		Short: "Check which agents are available and responding",
-		Long: `Check which agents are installed and can produce output.
-
-For each agent found on PATH, runs a short smoke-test prompt with a timeout
-to verify the agent is actually functional.
-
-Examples:
-  roborev check-agents                  # Check all agents
-  roborev check-agents --agent codex    # Check only codex
-  roborev check-agents --timeout 30     # 30 second timeout per agent
-  roborev check-agents --large-prompt   # Test with 33KB+ prompt (Windows limit check)`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			names := agent.Available()
-			sort.Strings(names)
-
-			timeout := time.Duration(timeoutSecs) * time.Second
-			smokePrompt := "Respond with exactly: OK"
-			if largePrompt {
-				smokePrompt = "Respond with exactly: OK\n" +
-					strings.Repeat("// padding line\n", 2200)
-			}
-
-			// Use current directory as repo path for the smoke test
-			repoPath, err := os.Getwd()
-			if err != nil {
-				repoPath = "."
-			}
-
-			var passed, failed, skipped int
-
-			for _, name := range names {
-				if name == "test" {
-					continue
-				}
-				if agentFilter != "" && name != agentFilter {
-					continue
-				}
-
-				a, _ := agent.Get(name)
-				if a == nil {
-					continue
-				}
-
-				cmdName := ""
-				if ca, ok := a.(agent.CommandAgent); ok {
-					cmdName = ca.CommandName()
-				}
-
-				if !agent.IsAvailable(name) {
-					fmt.Printf("  - %-14s %s (not found in PATH)\n", name, cmdName)
-					skipped++
-					continue
-				}
-
-				path, _ := exec.LookPath(cmdName)
-				fmt.Printf("  ? %-14s %s (%s) ... ", name, cmdName, path)
-
-				ctx, cancel := context.WithTimeout(context.Background(), timeout)
-				result, err := a.Review(ctx, repoPath, "HEAD", smokePrompt, nil)
-				cancel()
-
-				if err != nil {
-					fmt.Printf("FAIL\n")
-					// Indent each line of the error for readability
-					for _, line := range strings.Split(err.Error(), "\n") {
-						line = strings.TrimSpace(line)
-						if line != "" {
-							fmt.Printf("    %s\n", line)
-						}
-					}
-					failed++
-				} else if strings.TrimSpace(result) == "" {
-					fmt.Printf("FAIL (empty response)\n")
-					failed++
-				} else {
-					fmt.Printf("OK (%d bytes)\n", len(result))
-					passed++
-				}
-			}
-
-			fmt.Printf("\n%d passed, %d failed, %d skipped\n", passed, failed, skipped)
-			if failed > 0 {
-				return fmt.Errorf("%d agent(s) failed health check", failed)
-			}
-			return nil
-		},
-	}
-
-	cmd.SilenceUsage = true
-	cmd.Flags().IntVar(&timeoutSecs, "timeout", 60, "timeout in seconds per agent")
-	cmd.Flags().StringVar(&agentFilter, "agent", "", "check only this agent")
-	cmd.Flags().BoolVar(&largePrompt, "large-prompt", false,
-		"use a 33KB+ prompt to test Windows command-line limits")
-
-	return cmd
-}
-
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
@@ -3191,6 +3342,15 @@ func formatAgentLabel(agent string, model string) string {
 	return agent
 }
 
+// formatReviewer appends the agent version recorded with a review, if any.
+// Format: "agent" or "agent, version"
+func formatReviewer(agent, version string) string {
+	if version != "" {
+		return agent + ", " + version
+	}
+	return agent
+}
+
 // resolveReasoningWithFast returns the effective reasoning value, applying
 // the --fast shorthand only when --reasoning wasn't explicitly set.
 func resolveReasoningWithFast(reasoning string, fast bool, reasoningExplicitlySet bool) string {
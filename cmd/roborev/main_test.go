@@ -325,6 +325,29 @@ func TestSummarizeAgentOutput(t *testing.T) {
 	}
 }
 
+func TestReviewTrailer(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"no findings", "No issues found.", "Roborev-Reviewed: job 7 (no high findings)"},
+		{"medium only", "1. **Medium** - unchecked error in foo.go:12", "Roborev-Reviewed: job 7 (no high findings)"},
+		{"high", "- Severity: High\n- [Low] typo", "Roborev-Reviewed: job 7 (high findings)"},
+		{"critical beats high", "- **High** one\n- **Critical** two\n- **High** three", "Roborev-Reviewed: job 7 (critical findings)"},
+		{"unlabeled word ignored", "This has high test coverage.", "Roborev-Reviewed: job 7 (no high findings)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reviewTrailer(&storage.Review{JobID: 7, Output: tt.output})
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestRefineNoChangeSkipsImmediately(t *testing.T) {
 	// When the agent makes no changes, refine should skip the review
 	// immediately. The skip path is triggered by IsWorkingTreeClean
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+func mergeQueueCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "merge-queue [commit]",
+		Short: "Review a merge queue commit and report pass or fail",
+		Long: `Review a merge queue's speculative merge commit and wait for a result.
+
+Run this from the merge queue's CI job with the merge group commit checked
+out. The commit is reviewed against its first parent, so the review covers
+exactly what the merge adds to the target branch. The command exits non-zero
+when the review fails or does not finish before the deadline.
+
+Merge automation can also poll the daemon directly:
+
+  GET /api/merge-queue/status?job_id=<id>
+
+which returns {"result": "pending" | "pass" | "fail", "reason": ...}.
+
+The deadline and timeout result come from .roborev.toml:
+
+  [merge_queue]
+  deadline = "10m"        # default 15m
+  on_timeout = "fail"     # or "pass" to let slow reviews through`,
+		Example: `  roborev merge-queue            # Review HEAD (the merge group commit)
+  roborev merge-queue abc1234    # Review a specific commit
+  roborev merge-queue --json     # Print the final status as JSON`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoRoot, err := git.GetRepoRoot(".")
+			if err != nil {
+				return fmt.Errorf("not in a git repository: %w", err)
+			}
+
+			ref := "HEAD"
+			if len(args) == 1 {
+				ref = args[0]
+			}
+			sha, err := git.ResolveSHA(repoRoot, ref+"^{commit}")
+			if err != nil {
+				return fmt.Errorf("invalid commit %q: %w", ref, err)
+			}
+
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+			serverAddr := getDaemonAddr()
+
+			// The daemon maps a root commit's missing parent to the empty tree
+			reqBody, _ := json.Marshal(daemon.EnqueueRequest{
+				RepoPath: repoRoot,
+				GitRef:   sha + "^.." + sha,
+			})
+			resp, err := http.Post(serverAddr+"/api/enqueue", "application/json", bytes.NewReader(reqBody))
+			if err != nil {
+				return fmt.Errorf("failed to connect to daemon: %w", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				// Excluded branches are skipped without a job
+				var skipResp struct {
+					Skipped bool   `json:"skipped"`
+					Reason  string `json:"reason"`
+				}
+				if err := json.Unmarshal(body, &skipResp); err == nil && skipResp.Skipped {
+					cmd.Printf("Merge queue review of %s: pass (skipped: %s)\n", shortSHA(sha), skipResp.Reason)
+					return nil
+				}
+			}
+			if resp.StatusCode != http.StatusCreated {
+				return fmt.Errorf("failed to enqueue merge queue review: %s", body)
+			}
+
+			var job storage.ReviewJob
+			if err := json.Unmarshal(body, &job); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+			if !jsonOutput {
+				cmd.Printf("Reviewing %s as job %d (agent: %s)\n", shortSHA(sha), job.ID, job.Agent)
+			}
+
+			status, err := waitForMergeQueueResult(serverAddr, job.ID)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(status); err != nil {
+					return err
+				}
+			} else if status.Reason != "" {
+				cmd.Printf("Merge queue review of %s: %s (%s)\n", shortSHA(sha), status.Result, status.Reason)
+			} else {
+				cmd.Printf("Merge queue review of %s: %s\n", shortSHA(sha), status.Result)
+			}
+
+			if status.Result != daemon.MergeQueuePass {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("merge queue review failed (job %d)", job.ID)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output the final status as JSON")
+
+	return cmd
+}
+
+// waitForMergeQueueResult polls the merge queue status endpoint until the
+// daemon reports pass or fail. The daemon enforces the deadline, so this
+// loop ends once it passes even if the review is still running.
+func waitForMergeQueueResult(serverAddr string, jobID int64) (*daemon.MergeQueueStatusResponse, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	pollInterval := pollStartInterval
+
+	for {
+		resp, err := client.Get(fmt.Sprintf("%s/api/merge-queue/status?job_id=%d", serverAddr, jobID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to check merge queue status: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("server error checking merge queue status (%d): %s", resp.StatusCode, body)
+		}
+
+		var status daemon.MergeQueueStatusResponse
+		err = json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse merge queue status: %w", err)
+		}
+		if status.Result != daemon.MergeQueuePending {
+			return &status, nil
+		}
+
+		time.Sleep(pollInterval)
+		if pollInterval < pollMaxInterval {
+			pollInterval = min(pollInterval*3/2, pollMaxInterval)
+		}
+	}
+}
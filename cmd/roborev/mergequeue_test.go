@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/daemon"
+)
+
+func TestWaitForMergeQueueResult(t *testing.T) {
+	setupFastPolling(t)
+
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/merge-queue/status" || r.URL.Query().Get("job_id") != "7" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		polls++
+		result := daemon.MergeQueuePending
+		if polls == 3 {
+			result = daemon.MergeQueueFail
+		}
+		json.NewEncoder(w).Encode(daemon.MergeQueueStatusResponse{JobID: 7, Result: result, Reason: "review found issues"})
+	}))
+	defer ts.Close()
+
+	status, err := waitForMergeQueueResult(ts.URL, 7)
+	if err != nil {
+		t.Fatalf("waitForMergeQueueResult: %v", err)
+	}
+	if status.Result != daemon.MergeQueueFail || polls != 3 {
+		t.Errorf("expected fail after 3 polls, got %+v after %d", status, polls)
+	}
+}
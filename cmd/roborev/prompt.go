@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/prompt"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// promptCmd returns a hidden alias of run for backward compatibility,
+// which also hosts developer tooling for prompts
+func promptCmd() *cobra.Command {
+	var (
+		snapshot    bool
+		snapshotDir string
+	)
+
+	cmd := runCmd()
+	cmd.Use = "prompt [task]"
+	cmd.Hidden = true
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if !snapshot {
+			return run(cmd, args)
+		}
+		if len(args) > 0 {
+			return fmt.Errorf("--snapshot takes no arguments")
+		}
+		return writePromptSnapshots(cmd, snapshotDir)
+	}
+
+	cmd.Flags().BoolVar(&snapshot, "snapshot", false, "regenerate the prompt snapshot golden files (run from a roborev checkout)")
+	cmd.Flags().StringVar(&snapshotDir, "snapshot-dir", "", "where to write snapshots (default: "+prompt.SnapshotDir+" in this repo)")
+	cmd.AddCommand(promptShowCmd())
+	return cmd
+}
+
+// promptShowCmd prints the review prompt the daemon would build, for tuning
+// guidelines and context files without running an agent
+func promptShowCmd() *cobra.Command {
+	var (
+		repoPath   string
+		agentName  string
+		model      string
+		reasoning  string
+		reviewType string
+		dirty      bool
+		breakdown  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show [commit|range]",
+		Short: "Print the review prompt for a commit without running an agent",
+		Long: `Build the review prompt for a commit (default HEAD), a range such as
+main..feature, or uncommitted changes with --dirty, and print it along with
+the size of each section. The prompt goes to stdout and the breakdown to
+stderr, so the prompt can be redirected to a file.
+
+Previous reviews are included from the local database as the daemon would.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dirty && len(args) > 0 {
+				return fmt.Errorf("--dirty takes no commit")
+			}
+			if repoPath == "" {
+				repoPath = "."
+			}
+			root, err := git.GetRepoRoot(repoPath)
+			if err != nil {
+				return fmt.Errorf("not in a git repository: %w", err)
+			}
+
+			cfg, err := config.LoadGlobal()
+			if err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+			reasoning, err = config.ResolveReviewReasoning(reasoning, root)
+			if err != nil {
+				return fmt.Errorf("invalid reasoning: %w", err)
+			}
+			workflow := "review"
+			if !config.IsDefaultReviewType(reviewType) {
+				workflow = reviewType
+			}
+			agentName = config.ResolveAgentForWorkflow(agentName, root, cfg, workflow, reasoning)
+			model = config.ResolveModelForWorkflow(model, root, cfg, workflow, reasoning)
+
+			db, repoID := openPromptDB(root)
+			if db != nil {
+				defer db.Close()
+			}
+			// Commands the builder runs, like the repo's tests, are in their
+			// own process group, so pass Ctrl-C on by canceling them
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			builder := prompt.NewBuilderWithConfig(db, cfg).WithModel(model).WithContext(ctx)
+
+			var gitRef, text string
+			if dirty {
+				diff, err := git.GetDirtyDiff(root)
+				if err != nil {
+					return fmt.Errorf("get dirty diff: %w", err)
+				}
+				if diff == "" {
+					return fmt.Errorf("no uncommitted changes")
+				}
+				gitRef = "dirty"
+				text, err = builder.BuildDirty(root, diff, repoID, config.ResolveContextCommits(nil, root, cfg), agentName, reviewType)
+				if err != nil {
+					return fmt.Errorf("build prompt: %w", err)
+				}
+			} else {
+				gitRef = "HEAD"
+				if len(args) > 0 {
+					gitRef = args[0]
+				}
+				if !git.IsRange(gitRef) {
+					sha, err := git.ResolveSHA(root, gitRef)
+					if err != nil {
+						return fmt.Errorf("invalid commit %q: %w", gitRef, err)
+					}
+					gitRef = sha
+				}
+				text, err = builder.Build(root, gitRef, repoID, config.ResolveContextCommits(nil, root, cfg), agentName, reviewType)
+				if err != nil {
+					return fmt.Errorf("build prompt: %w", err)
+				}
+			}
+
+			if !breakdown {
+				fmt.Fprintln(cmd.OutOrStdout(), text)
+			}
+			if model == "" {
+				model = "default"
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Prompt for %s (agent %s, model %s, type %s):\n", shortRef(gitRef), agentName, model, workflow)
+			writePromptBreakdown(cmd.ErrOrStderr(), text, builder.Budget(root, agentName))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "path to git repository (default: current directory)")
+	cmd.Flags().StringVar(&agentName, "agent", "", "agent whose prompt to build (default: from config)")
+	cmd.Flags().StringVar(&model, "model", "", "model, which sets the prompt budget (default: from config)")
+	cmd.Flags().StringVar(&reasoning, "reasoning", "", "reasoning level used to pick the agent: thorough (default), standard, or fast")
+	cmd.Flags().StringVar(&reviewType, "type", "", "review type (security, design, bench, performance, docs, tests)")
+	cmd.Flags().BoolVar(&dirty, "dirty", false, "build the prompt for uncommitted changes")
+	cmd.Flags().BoolVar(&breakdown, "breakdown", false, "print only the size breakdown")
+	return cmd
+}
+
+// openPromptDB opens the local database for previous review context,
+// returning nil when there's none or the repo hasn't been reviewed
+func openPromptDB(root string) (*storage.DB, int64) {
+	dbPath := storage.DefaultDBPath()
+	if dbPath == "" {
+		return nil, 0
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, 0
+	}
+	mainRoot, err := git.GetMainRepoRoot(root)
+	if err != nil {
+		return nil, 0
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		return nil, 0
+	}
+	repo, err := db.GetRepoByPath(mainRoot)
+	if err != nil {
+		db.Close()
+		return nil, 0
+	}
+	return db, repo.ID
+}
+
+// writePromptBreakdown lists the size of each section of a prompt and the
+// total against the prompt budget
+func writePromptBreakdown(out io.Writer, text string, budget prompt.PromptBudget) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SECTION\tBYTES\tTOKENS\n")
+	totalTokens := 0
+	for _, s := range budget.Breakdown(text) {
+		heading := "(system prompt)"
+		if s.Level > 0 {
+			heading = strings.Repeat("  ", s.Level-2) + s.Heading
+		}
+		fmt.Fprintf(w, "%s\t%d\t~%d\n", heading, s.Bytes, s.Tokens)
+		totalTokens += s.Tokens
+	}
+	fmt.Fprintf(w, "Total\t%d\t~%d\n", len(text), totalTokens)
+	w.Flush()
+
+	limit, unit := budget.Limit()
+	used := len(text)
+	if unit == "tokens" {
+		used = totalTokens
+	}
+	fmt.Fprintf(out, "Budget: %d %s (%d%% used)\n", limit, unit, used*100/limit)
+}
+
+// writePromptSnapshots regenerates the prompt golden files and lists the
+// ones that changed, so prompt layout changes are reviewed like code
+func writePromptSnapshots(cmd *cobra.Command, dir string) error {
+	if dir == "" {
+		root, err := git.GetRepoRoot(".")
+		if err != nil {
+			return fmt.Errorf("not in a git repository (use --snapshot-dir): %w", err)
+		}
+		// Don't scatter golden files into other repos
+		if _, err := os.Stat(filepath.Join(root, "internal", "prompt", "prompt.go")); err != nil {
+			return fmt.Errorf("%s is not a roborev checkout (use --snapshot-dir)", root)
+		}
+		dir = filepath.Join(root, filepath.FromSlash(prompt.SnapshotDir))
+	}
+
+	written, removed, err := prompt.WriteSnapshots(dir)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	for _, name := range written {
+		fmt.Fprintf(out, "updated %s\n", prompt.SnapshotPath(dir, name))
+	}
+	for _, name := range removed {
+		fmt.Fprintf(out, "removed %s\n", prompt.SnapshotPath(dir, name))
+	}
+	if len(written) == 0 && len(removed) == 0 {
+		fmt.Fprintln(out, "Prompt snapshots are up to date.")
+	}
+	return nil
+}
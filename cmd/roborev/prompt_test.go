@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func runPromptShow(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	cmd := promptCmd()
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs(append([]string{"show"}, args...))
+	err := cmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestPromptShow(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.CommitFile(".roborev.toml", `review_guidelines = "Check error wrapping."`, "Add config")
+	repo.CommitFile("main.go", "package main\n", "Add main")
+	sha := repo.CommitFile("main.go", "package main\n\nfunc main() {}\n", "Add func main")
+
+	stdout, stderr, err := runPromptShow(t, "--repo", repo.Dir, "--agent", "codex")
+	if err != nil {
+		t.Fatalf("prompt show failed: %v", err)
+	}
+	if !strings.Contains(stdout, "Check error wrapping.") || !strings.Contains(stdout, "func main() {}") {
+		t.Errorf("expected the HEAD commit's prompt on stdout, got:\n%s", stdout)
+	}
+	for _, want := range []string{"Prompt for " + sha[:7], "Project Guidelines", "  Diff", "Total", "Budget:"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in the breakdown, got:\n%s", want, stderr)
+		}
+	}
+
+	stdout, _, err = runPromptShow(t, "--repo", repo.Dir, "--agent", "codex", "--breakdown", "HEAD~1")
+	if err != nil || stdout != "" {
+		t.Errorf("expected only a breakdown, got %q, %v", stdout, err)
+	}
+
+	if _, _, err := runPromptShow(t, "--repo", repo.Dir, "--dirty"); err == nil {
+		t.Error("expected an error without uncommitted changes")
+	}
+}
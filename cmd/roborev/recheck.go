@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// recheckHunkSlack is how far outside a hunk a finding's line can be
+	// and still count as pointing at it
+	recheckHunkSlack = 10
+	// recheckContextLines is how many lines of current code are shown on
+	// each side of a finding's line
+	recheckContextLines = 10
+	// maxRecheckHunksSize caps the diff excerpt included per finding
+	maxRecheckHunksSize = 8000
+)
+
+// recheckVerdictPattern matches an answer line such as
+// "Finding 3: INVALID - the nil check was added in handler.go"
+var recheckVerdictPattern = regexp.MustCompile(`(?i)^[\s*>#-]*finding\s+#?(\d+)[\s*:.)-]*\b(valid|invalid)\b[\s*:.-]*(.*)$`)
+
+func recheckCmd() *cobra.Command {
+	var (
+		findingList string
+		agentName   string
+		model       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "recheck <job-id>",
+		Short: "Ask an agent whether selected findings are still valid",
+		Long: `Re-validate findings from a completed review without re-reviewing the
+whole change.
+
+The agent sees only the selected findings, the diff hunks they point at,
+and the current code around each one, and answers whether each finding
+still applies. Findings it judges invalid are marked fixed; valid ones
+stay open. Findings are numbered from 1 in the order the review lists them;
+with no --finding flag, every finding is rechecked.`,
+		Example: `  roborev recheck 42
+  roborev recheck 42 --finding 3,5
+  roborev recheck 42 --finding 2 --agent claude-code`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobID, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || jobID <= 0 {
+				return fmt.Errorf("invalid job_id: %s", args[0])
+			}
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+			addr := getDaemonAddr()
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			job, err := fetchJob(ctx, addr, jobID)
+			if err != nil {
+				return fmt.Errorf("fetch job: %w", err)
+			}
+			if job.Status != storage.JobStatusDone {
+				return fmt.Errorf("job %d is %s, not done", jobID, job.Status)
+			}
+			findings, err := fetchFindings(ctx, addr, jobID)
+			if err != nil {
+				return err
+			}
+			if len(findings) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Job %d has no recorded findings to recheck.\n", jobID)
+				return nil
+			}
+			numbers, err := parseFindingNumbers(findingList, len(findings))
+			if err != nil {
+				return err
+			}
+			diff, err := fetchJobDiff(ctx, addr, jobID)
+			if err != nil {
+				return err
+			}
+
+			selected := make([]recheckFinding, 0, len(numbers))
+			for _, n := range numbers {
+				selected = append(selected, recheckFinding{num: n, finding: findings[n-1]})
+			}
+			prompt := buildRecheckPrompt(job, selected, diff, func(path string) ([]byte, error) {
+				return os.ReadFile(filepath.Join(job.RepoPath, filepath.FromSlash(path)))
+			})
+
+			a, err := resolveSuggestAgent(job.RepoPath, agentName, model)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Rechecking %d finding(s) from job %d using %s...\n", len(selected), jobID, a.Name())
+			output, err := a.Review(ctx, job.RepoPath, "HEAD", prompt, nil)
+			if err != nil {
+				return fmt.Errorf("agent failed: %w", err)
+			}
+
+			verdicts := parseRecheckVerdicts(output)
+			out := cmd.OutOrStdout()
+			var unanswered []string
+			for _, sel := range selected {
+				v, ok := verdicts[sel.num]
+				if !ok {
+					unanswered = append(unanswered, strconv.Itoa(sel.num))
+					continue
+				}
+				status, label := storage.FindingOpen, "still valid"
+				if !v.valid {
+					status, label = storage.FindingFixed, "fixed"
+				}
+				if err := setFindingStatus(ctx, addr, sel.finding.ID, status, v.reason); err != nil {
+					return fmt.Errorf("update finding %d: %w", sel.num, err)
+				}
+				fmt.Fprintf(out, "Finding %d (%s): %s", sel.num, findingLocation(sel.finding), label)
+				if v.reason != "" {
+					fmt.Fprintf(out, " - %s", v.reason)
+				}
+				fmt.Fprintln(out)
+			}
+			if len(unanswered) > 0 {
+				fmt.Fprintf(out, "No answer for finding(s) %s; their status is unchanged.\n", strings.Join(unanswered, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.SilenceUsage = true
+	cmd.Flags().StringVar(&findingList, "finding", "", "comma-separated finding numbers to recheck (default: all)")
+	cmd.Flags().StringVar(&agentName, "agent", "", "agent to recheck findings (default: from config)")
+	cmd.Flags().StringVar(&model, "model", "", "model for agent")
+
+	return cmd
+}
+
+// recheckFinding is a finding selected for recheck with its 1-based
+// position in the review
+type recheckFinding struct {
+	num     int
+	finding storage.Finding
+}
+
+// recheckVerdict is the agent's answer for one finding
+type recheckVerdict struct {
+	valid  bool
+	reason string
+}
+
+// parseFindingNumbers parses a comma-separated list of 1-based finding
+// numbers, returning them sorted and deduplicated. An empty list selects
+// all n findings.
+func parseFindingNumbers(list string, n int) ([]int, error) {
+	if strings.TrimSpace(list) == "" {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i + 1
+		}
+		return all, nil
+	}
+	seen := make(map[int]bool)
+	var nums []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid finding number %q", part)
+		}
+		if num < 1 || num > n {
+			return nil, fmt.Errorf("finding %d out of range (review has %d findings)", num, n)
+		}
+		if !seen[num] {
+			seen[num] = true
+			nums = append(nums, num)
+		}
+	}
+	if len(nums) == 0 {
+		return nil, fmt.Errorf("no finding numbers in %q", list)
+	}
+	sort.Ints(nums)
+	return nums, nil
+}
+
+// findingLocation formats a finding's file and line for display
+func findingLocation(f storage.Finding) string {
+	switch {
+	case f.File == "":
+		return "general"
+	case f.Line > 0:
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	default:
+		return f.File
+	}
+}
+
+// buildRecheckPrompt asks an agent to judge whether each selected finding
+// still applies, given the hunks it points at and the current code
+func buildRecheckPrompt(job *storage.ReviewJob, selected []recheckFinding, diff string, readFile func(string) ([]byte, error)) string {
+	files := git.ChangedFiles(diff)
+
+	var sb strings.Builder
+	sb.WriteString("# Finding Recheck\n\n")
+	fmt.Fprintf(&sb, "An earlier code review of %s reported the findings below. ", job.GitRef)
+	sb.WriteString("For each one, decide whether it is still a real problem in the current code. ")
+	sb.WriteString("A finding is INVALID if it was wrong to begin with or the code has since been fixed; ")
+	sb.WriteString("otherwise it is VALID. Judge only the findings listed; do not report new issues.\n\n")
+
+	for _, sel := range selected {
+		f := sel.finding
+		fmt.Fprintf(&sb, "## Finding %d\n\n", sel.num)
+		if f.SeverityLabel() != "" {
+			fmt.Fprintf(&sb, "Severity: %s\n", f.SeverityLabel())
+		}
+		fmt.Fprintf(&sb, "Location: %s\n\n", findingLocation(f))
+		sb.WriteString(strings.TrimSpace(f.Message))
+		sb.WriteString("\n\n")
+		if f.File == "" {
+			continue
+		}
+
+		file := matchDiffFile(f.File, files)
+		if file == "" {
+			file = f.File
+		}
+		if hunks := selectHunks(diff, file, f.Line); hunks != "" {
+			sb.WriteString("### Reviewed Change\n\n```diff\n")
+			sb.WriteString(hunks)
+			sb.WriteString("```\n\n")
+		}
+		content, err := readFile(file)
+		switch {
+		case err != nil:
+			fmt.Fprintf(&sb, "`%s` no longer exists in the working tree.\n\n", file)
+		case f.Line > 0:
+			if excerpt := numberedExcerpt(string(content), f.Line, recheckContextLines); excerpt != "" {
+				sb.WriteString("### Current Code\n\n```\n")
+				sb.WriteString(excerpt)
+				sb.WriteString("```\n\n")
+			}
+		}
+	}
+
+	sb.WriteString("## Answer Format\n\n")
+	sb.WriteString("Answer with exactly one line per finding, in this form:\n\n")
+	sb.WriteString("Finding <number>: VALID - <one-sentence reason>\n")
+	sb.WriteString("Finding <number>: INVALID - <one-sentence reason>\n")
+	return sb.String()
+}
+
+// selectHunks returns the hunks of file's diff that contain or are near
+// line, or all of the file's hunks when line is 0. The result is capped
+// at maxRecheckHunksSize.
+func selectHunks(diff, file string, line int) string {
+	var sb strings.Builder
+	for _, f := range git.ParseDiff(diff) {
+		if f.NewPath != file {
+			continue
+		}
+		for _, h := range f.Hunks {
+			if line > 0 && (line < h.NewStart-recheckHunkSlack || line > h.NewStart+h.NewCount+recheckHunkSlack) {
+				continue
+			}
+			if sb.Len()+len(h.Text) <= maxRecheckHunksSize {
+				sb.WriteString(h.Text)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// numberedExcerpt returns the lines of content within radius of line,
+// prefixed with their line numbers
+func numberedExcerpt(content string, line, radius int) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if line > len(lines) {
+		return ""
+	}
+	start := max(line-radius, 1)
+	end := min(line+radius, len(lines))
+	var sb strings.Builder
+	for i := start; i <= end; i++ {
+		fmt.Fprintf(&sb, "%5d  %s\n", i, lines[i-1])
+	}
+	return sb.String()
+}
+
+// parseRecheckVerdicts extracts the agent's answer for each finding
+// number. The first answer for a number wins.
+func parseRecheckVerdicts(output string) map[int]recheckVerdict {
+	verdicts := make(map[int]recheckVerdict)
+	for _, line := range strings.Split(output, "\n") {
+		m := recheckVerdictPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(m[1])
+		if _, ok := verdicts[num]; ok {
+			continue
+		}
+		verdicts[num] = recheckVerdict{
+			valid:  strings.EqualFold(m[2], "valid"),
+			reason: strings.TrimSpace(strings.Trim(m[3], "*")),
+		}
+	}
+	return verdicts
+}
+
+// fetchFindings returns the structured findings recorded for a job
+func fetchFindings(ctx context.Context, serverAddr string, jobID int64) ([]storage.Finding, error) {
+	var resp struct {
+		Findings []storage.Finding `json:"findings"`
+	}
+	if err := daemonGetJSON(ctx, fmt.Sprintf("%s/api/findings?job_id=%d", serverAddr, jobID), &resp); err != nil {
+		return nil, fmt.Errorf("fetch findings: %w", err)
+	}
+	return resp.Findings, nil
+}
+
+// fetchJobDiff returns the diff a job reviewed
+func fetchJobDiff(ctx context.Context, serverAddr string, jobID int64) (string, error) {
+	var resp daemon.JobDiffResponse
+	if err := daemonGetJSON(ctx, fmt.Sprintf("%s/api/jobs/%d/diff", serverAddr, jobID), &resp); err != nil {
+		return "", fmt.Errorf("fetch diff: %w", err)
+	}
+	return resp.Diff, nil
+}
+
+// daemonGetJSON decodes the JSON response of a GET request to the daemon
+func daemonGetJSON(ctx context.Context, url string, v any) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server error (%d): %s", resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// setFindingStatus records a finding's status with the daemon
+func setFindingStatus(ctx context.Context, serverAddr string, id int64, status, note string) error {
+	reqBody, _ := json.Marshal(daemon.FindingStatusRequest{ID: id, Status: status, Note: note})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverAddr+"/api/findings/status", bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server error (%d): %s", resp.StatusCode, body)
+	}
+	return nil
+}
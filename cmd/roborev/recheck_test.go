@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+const recheckTestDiff = `diff --git a/cache.go b/cache.go
+index 1111111..2222222 100644
+--- a/cache.go
++++ b/cache.go
+@@ -10,3 +10,4 @@ func Get() {
+ 	mu.Lock()
++	v := m[k]
+ 	mu.Unlock()
+ }
+@@ -80,2 +81,3 @@ func Put() {
+ 	m[k] = v
++	evict()
+ }
+diff --git a/main.go b/main.go
+index 3333333..4444444 100644
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,3 @@
+ package main
++import "os"
+ func main() {}
+`
+
+func TestParseFindingNumbers(t *testing.T) {
+	got, err := parseFindingNumbers("5, 3,5", 6)
+	if err != nil {
+		t.Fatalf("parseFindingNumbers: %v", err)
+	}
+	if !reflect.DeepEqual(got, []int{3, 5}) {
+		t.Errorf("got %v, want [3 5]", got)
+	}
+	if got, _ := parseFindingNumbers("", 3); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("empty list should select all findings, got %v", got)
+	}
+	for _, bad := range []string{"0", "7", "two", ","} {
+		if _, err := parseFindingNumbers(bad, 6); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestSelectHunks(t *testing.T) {
+	got := selectHunks(recheckTestDiff, "cache.go", 12)
+	if !strings.Contains(got, "v := m[k]") || strings.Contains(got, "evict()") {
+		t.Errorf("expected only the first cache.go hunk, got:\n%s", got)
+	}
+	if got := selectHunks(recheckTestDiff, "cache.go", 0); !strings.Contains(got, "v := m[k]") || !strings.Contains(got, "evict()") {
+		t.Errorf("line 0 should select every hunk in the file, got:\n%s", got)
+	}
+	if got := selectHunks(recheckTestDiff, "cache.go", 50); got != "" {
+		t.Errorf("expected no hunks far from the line, got:\n%s", got)
+	}
+	if got := selectHunks(recheckTestDiff, "main.go", 2); strings.Contains(got, "cache.go") || !strings.Contains(got, `import "os"`) {
+		t.Errorf("unexpected main.go hunks:\n%s", got)
+	}
+}
+
+func TestParseRecheckVerdicts(t *testing.T) {
+	output := "Here is my assessment.\n\n" +
+		"Finding 3: INVALID - the lock now covers the read\n" +
+		"- **Finding 5:** VALID - evict still races with Put\n" +
+		"Finding 3: VALID - duplicate answer is ignored\n" +
+		"Finding 7 is unclear\n"
+	got := parseRecheckVerdicts(output)
+	want := map[int]recheckVerdict{
+		3: {valid: false, reason: "the lock now covers the read"},
+		5: {valid: true, reason: "evict still races with Put"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildRecheckPrompt(t *testing.T) {
+	job := &storage.ReviewJob{GitRef: "abc1234"}
+	selected := []recheckFinding{
+		{num: 2, finding: storage.Finding{Severity: "high", File: "cache.go", Line: 12, Message: "Read of m is not locked"}},
+		{num: 4, finding: storage.Finding{Severity: "low", File: "gone.go", Line: 3, Message: "Unused variable"}},
+	}
+	readFile := func(path string) ([]byte, error) {
+		if path == "cache.go" {
+			return []byte(strings.Repeat("// filler\n", 11) + "v := m[k] // locked\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	prompt := buildRecheckPrompt(job, selected, recheckTestDiff, readFile)
+	for _, want := range []string{
+		"## Finding 2", "Location: cache.go:12", "Read of m is not locked",
+		"+\tv := m[k]", "   12  v := m[k] // locked",
+		"## Finding 4", "`gone.go` no longer exists",
+		"Finding <number>: VALID",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "## Finding 1") || strings.Contains(prompt, "evict()") {
+		t.Errorf("prompt includes unselected findings or unrelated hunks:\n%s", prompt)
+	}
+}
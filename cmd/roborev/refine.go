@@ -569,6 +569,13 @@ func runRefine(opts refineOptions) error {
 		cleanupWorktree()
 
 		commitMsg := fmt.Sprintf("Address review findings (job %d)\n\n%s", currentFailedReview.JobID, summarizeAgentOutput(output))
+		if config.ResolveCommitTrailers(repoPath, cfg) {
+			if stamped, err := git.AppendTrailers(commitMsg, reviewTrailer(currentFailedReview)); err != nil {
+				fmt.Printf("Warning: could not add commit trailer: %v\n", err)
+			} else {
+				commitMsg = stamped
+			}
+		}
 		newCommit, err := commitWithHookRetry(repoPath, commitMsg, addressAgent, opts.quiet)
 		if err != nil {
 			return fmt.Errorf("failed to commit changes: %w", err)
@@ -995,6 +1002,23 @@ func summarizeAgentOutput(output string) string {
 	return strings.Join(summary, "\n")
 }
 
+// reviewTrailer builds the Roborev-Reviewed trailer for a commit that
+// addresses the given review, noting the most severe finding level.
+func reviewTrailer(review *storage.Review) string {
+	summary := "no high findings"
+	top := 0
+	for _, f := range storage.ParseMarkdownFindings(review.Output) {
+		top = max(top, config.SeverityRank(f.Severity))
+	}
+	switch {
+	case top >= config.SeverityRank("critical"):
+		summary = "critical findings"
+	case top >= config.SeverityRank("high"):
+		summary = "high findings"
+	}
+	return fmt.Sprintf("Roborev-Reviewed: job %d (%s)", review.JobID, summary)
+}
+
 // createTempWorktree creates a temporary git worktree for isolated agent work
 func createTempWorktree(repoPath string) (string, func(), error) {
 	worktreeDir, err := os.MkdirTemp("", "roborev-refine-")
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// findingSeverityPattern matches a severity label in a finding
+var findingSeverityPattern = regexp.MustCompile(`(?i)\b(critical|high|medium|low)\b`)
+
+// Severity styles for rendered reviews, matching the TUI palette
+var (
+	severityCriticalStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.AdaptiveColor{Light: "124", Dark: "196"}) // Red
+	severityHighStyle     = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "166", Dark: "208"})            // Orange
+	severityMediumStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "136", Dark: "226"})            // Yellow/Gold
+	severityLowStyle      = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "25", Dark: "33"})              // Blue
+)
+
+// showWrapWidth is the word-wrap column for rendered reviews
+const showWrapWidth = 100
+
+// filterFindings removes findings below minSeverity from review output,
+// keeping everything else as written. It returns the filtered output and
+// how many findings were removed.
+func filterFindings(output, minSeverity string) (string, int) {
+	minRank := config.SeverityRank(minSeverity)
+	if minRank == 0 {
+		return output, 0
+	}
+
+	lines := strings.Split(output, "\n")
+	drop := make([]bool, len(lines))
+	hidden := 0
+	for _, f := range storage.FindMarkdownFindings(output) {
+		if config.SeverityRank(f.Severity) >= minRank {
+			continue
+		}
+		hidden++
+		for i := f.Start; i < f.End; i++ {
+			drop[i] = true
+		}
+	}
+
+	var kept []string
+	for i, line := range lines {
+		if !drop[i] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n"), hidden
+}
+
+// collapseSections replaces the body of each heading section that has no
+// findings with a one-line note, so the findings stand out
+func collapseSections(output string) string {
+	lines := strings.Split(output, "\n")
+	findingAt := make(map[int]bool)
+	for _, f := range storage.FindMarkdownFindings(output) {
+		findingAt[f.Start] = true
+	}
+	var out []string
+	var heading string
+	var body []string
+	hasHeading, hasFinding := false, false
+
+	flush := func() {
+		if hasHeading {
+			out = append(out, heading)
+		}
+		nonBlank := 0
+		for _, line := range body {
+			if strings.TrimSpace(line) != "" {
+				nonBlank++
+			}
+		}
+		if hasHeading && !hasFinding && nonBlank > 0 {
+			out = append(out, fmt.Sprintf("_(%d line(s) collapsed)_", nonBlank), "")
+		} else {
+			out = append(out, body...)
+		}
+		body, hasFinding = nil, false
+	}
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") && !findingAt[i] {
+			flush()
+			heading, hasHeading = line, true
+			continue
+		}
+		if findingAt[i] {
+			hasFinding = true
+		}
+		body = append(body, line)
+	}
+	flush()
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// renderReviewForTerminal renders review markdown with glamour and colors
+// each finding's severity label
+func renderReviewForTerminal(output string) string {
+	lines := renderMarkdownLines(output, showWrapWidth, showWrapWidth, newGlamourStyle(), 2)
+	for i, line := range lines {
+		lines[i] = colorSeverity(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// colorSeverity colors the first severity label on a rendered list item
+func colorSeverity(line string) string {
+	plain := strings.TrimSpace(xansi.Strip(line))
+	if !strings.HasPrefix(plain, "•") && !listItemPattern.MatchString(plain) {
+		return line
+	}
+	loc := findingSeverityPattern.FindStringIndex(line)
+	if loc == nil {
+		return line
+	}
+	word := line[loc[0]:loc[1]]
+	var style lipgloss.Style
+	switch strings.ToLower(word) {
+	case "critical":
+		style = severityCriticalStyle
+	case "high":
+		style = severityHighStyle
+	case "medium":
+		style = severityMediumStyle
+	default:
+		style = severityLowStyle
+	}
+	return line[:loc[0]] + style.Render(word) + line[loc[1]:]
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const renderTestReview = `## Summary
+
+Adds a query helper.
+It also renames a flag.
+
+## Findings
+
+- **Critical**: SQL injection in db.go:12
+  Use placeholders.
+- **Low**: Typo in a comment at db.go:3
+- **Medium**: Missing error check in api.go:40
+
+## Notes
+
+Low-level helpers were not reviewed.`
+
+func TestFilterFindings(t *testing.T) {
+	got, hidden := filterFindings(renderTestReview, "medium")
+	if hidden != 1 {
+		t.Errorf("hidden = %d, want 1", hidden)
+	}
+	if strings.Contains(got, "Typo in a comment") {
+		t.Errorf("low finding not filtered:\n%s", got)
+	}
+	for _, want := range []string{"SQL injection", "  Use placeholders.", "Missing error check", "Low-level helpers were not reviewed."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("filtered output missing %q:\n%s", want, got)
+		}
+	}
+
+	if got, hidden := filterFindings(renderTestReview, ""); got != renderTestReview || hidden != 0 {
+		t.Error("expected no filtering without a threshold")
+	}
+}
+
+func TestCollapseSections(t *testing.T) {
+	got := collapseSections(renderTestReview)
+
+	for _, want := range []string{"## Summary\n_(2 line(s) collapsed)_", "- **Critical**: SQL injection", "## Notes\n_(1 line(s) collapsed)_"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("collapsed output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "renames a flag") {
+		t.Errorf("section without findings was not collapsed:\n%s", got)
+	}
+}
+
+func TestColorSeverityOnlyListItems(t *testing.T) {
+	prose := "Low-level helpers were not reviewed."
+	if got := colorSeverity(prose); got != prose {
+		t.Errorf("prose line changed: %q", got)
+	}
+	item := "• High: missing check"
+	if got := colorSeverity(item); !strings.Contains(got, "High") || !strings.HasSuffix(got, ": missing check") {
+		t.Errorf("unexpected colored item: %q", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/roborev-dev/roborev/internal/config"
 	"github.com/roborev-dev/roborev/internal/git"
 	"github.com/roborev-dev/roborev/internal/storage"
 	"github.com/spf13/cobra"
@@ -72,23 +73,135 @@ func repoCmd() *cobra.Command {
 		Long: `Manage repositories tracked by roborev.
 
 Subcommands:
+  add     - Register a repository with the daemon's allowlist
+  remove  - Remove a repository from the allowlist
   list    - List all repositories with their review counts
   show    - Show details about a specific repository
   rename  - Rename a repository's display name
   delete  - Remove a repository from tracking
   merge   - Merge reviews from one repository into another
+  move    - Point a repository at its new location on disk
 `,
 	}
 
+	cmd.AddCommand(repoAddCmd())
+	cmd.AddCommand(repoRemoveCmd())
 	cmd.AddCommand(repoListCmd())
 	cmd.AddCommand(repoShowCmd())
 	cmd.AddCommand(repoRenameCmd())
 	cmd.AddCommand(repoDeleteCmd())
 	cmd.AddCommand(repoMergeCmd())
+	cmd.AddCommand(repoMoveCmd())
 
 	return cmd
 }
 
+func repoAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add [path]",
+		Short: "Register a repository",
+		Long: `Register a repository and add its checkout to the allowlist.
+
+With require_registered_repos = true in the global config, the daemon
+refuses to review or otherwise run git in paths that weren't added this
+way. Registration writes to the local database, so it has to be run on
+the daemon's host. Worktrees outside the repository directory are added
+separately. The path defaults to the current directory.
+
+Examples:
+  roborev repo add
+  roborev repo add /path/to/project
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			checkout, err := git.GetRepoRoot(absPath)
+			if err != nil {
+				return fmt.Errorf("not a git repository: %s", absPath)
+			}
+			repoRoot, err := git.GetMainRepoRoot(absPath)
+			if err != nil {
+				return fmt.Errorf("not a git repository: %s", absPath)
+			}
+
+			dbPath := storage.DefaultDBPath()
+			if dbPath == "" {
+				return fmt.Errorf("cannot determine database path")
+			}
+
+			db, err := storage.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("open database: %w", err)
+			}
+			defer db.Close()
+
+			repo, err := db.GetOrCreateRepo(repoRoot, config.ResolveRepoIdentity(repoRoot, nil))
+			if err != nil {
+				return fmt.Errorf("register repo: %w", err)
+			}
+			if err := db.AllowRepoPath(checkout); err != nil {
+				return fmt.Errorf("add to allowlist: %w", err)
+			}
+
+			fmt.Printf("Registered %s (%s)\n", repo.Name, checkout)
+			return nil
+		},
+	}
+}
+
+func repoRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove [path]",
+		Short: "Remove a repository from the allowlist",
+		Long: `Remove a checkout from the allowlist kept by "roborev repo add".
+
+Its reviews are kept; use "roborev repo delete" to remove those. The path
+defaults to the current directory.
+
+Examples:
+  roborev repo remove
+  roborev repo remove /path/to/project
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+			checkout := resolvePathToGitRoot(path)
+
+			dbPath := storage.DefaultDBPath()
+			if dbPath == "" {
+				return fmt.Errorf("cannot determine database path")
+			}
+
+			db, err := storage.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("open database: %w", err)
+			}
+			defer db.Close()
+
+			removed, err := db.DisallowRepoPath(checkout)
+			if err != nil {
+				return fmt.Errorf("remove from allowlist: %w", err)
+			}
+			if !removed {
+				return fmt.Errorf("not registered: %s", checkout)
+			}
+
+			fmt.Printf("Removed %s from the allowlist\n", checkout)
+			return nil
+		},
+	}
+}
+
 func repoListCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "list",
@@ -172,7 +285,7 @@ Examples:
 
 			fmt.Printf("Repository: %s\n", stats.Repo.Name)
 			fmt.Printf("Path:       %s\n", stats.Repo.RootPath)
-			fmt.Printf("Created:    %s\n", stats.Repo.CreatedAt.Format("2006-01-02 15:04:05"))
+			fmt.Printf("Created:    %s\n", stats.Repo.CreatedAt.Local().Format("2006-01-02 15:04:05"))
 			fmt.Println()
 			fmt.Printf("Jobs:       %d total\n", stats.TotalJobs)
 			if stats.QueuedJobs > 0 {
@@ -214,6 +327,7 @@ When to use rename vs merge:
   - Use RENAME when you have ONE repo entry and want a different name
   - Use MERGE when you have TWO repo entries that should be combined
     (e.g., after renaming a directory, you'll have both old and new entries)
+  - Use MOVE when a directory was moved and its entry should follow it
 
 The first argument can be either:
   - The repository path (absolute or relative, resolves to repo root)
@@ -373,6 +487,9 @@ Common use case - directory was renamed:
 
   Result: "new-project" now has all reviews from both entries.
 
+  If "new-project" has no entry yet, 'roborev repo move' carries the old
+  entry over instead.
+
 When to use merge vs rename:
   - Use MERGE when you have TWO repo entries that should be combined
   - Use RENAME when you have ONE repo entry and just want a different name
@@ -456,3 +573,66 @@ Examples:
 
 	return cmd
 }
+
+func repoMoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "move <old-path-or-name> <new-path>",
+		Short: "Point a repository at its new location on disk",
+		Long: `Point a repository's database entry at the new location of its checkout,
+keeping all of its jobs, reviews, and responses.
+
+Use this after moving or renaming a repository's directory. The old
+location can be given by its path, which no longer needs to exist, or by
+the repository's database name. The new path must be a git repository;
+a path inside one resolves to its root. If the display name still follows
+the old directory name, it follows the new one.
+
+The daemon usually does this on its own: when it first sees a checkout
+whose origin remote matches exactly one tracked repository that is gone
+from disk, it adopts that repository's history. Run move when there is
+no remote to match on, or when several missing checkouts share it.
+
+If the new path already has its own database entry, use merge instead.
+
+Examples:
+  # After mv ~/src/old-project ~/src/new-project
+  roborev repo move ~/src/old-project ~/src/new-project
+
+  # By database name, from inside the moved checkout
+  roborev repo move old-project .
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldIdent := resolveRepoIdentifier(args[0])
+
+			newRoot, err := git.GetRepoRoot(args[1])
+			if err != nil {
+				return fmt.Errorf("%s is not a git repository", args[1])
+			}
+
+			dbPath := storage.DefaultDBPath()
+			if dbPath == "" {
+				return fmt.Errorf("cannot determine database path")
+			}
+
+			db, err := storage.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("open database: %w", err)
+			}
+			defer db.Close()
+
+			repo, err := db.FindRepo(oldIdent)
+			if err != nil {
+				return fmt.Errorf("no repository found matching %q", oldIdent)
+			}
+
+			moved, err := db.MoveRepo(repo.ID, newRoot)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Moved %q from %s to %s\n", moved.Name, repo.RootPath, moved.RootPath)
+			return nil
+		},
+	}
+}
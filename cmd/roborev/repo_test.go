@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+
+	"github.com/roborev-dev/roborev/internal/storage"
 )
 
 func TestResolveRepoIdentifier(t *testing.T) {
@@ -119,3 +121,43 @@ func TestResolveRepoIdentifier(t *testing.T) {
 		}
 	})
 }
+
+func TestRepoMoveCmd(t *testing.T) {
+	t.Setenv("ROBOREV_DATA_DIR", t.TempDir())
+	repo := newTestGitRepo(t)
+
+	db, err := storage.Open(storage.DefaultDBPath())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	old, err := db.GetOrCreateRepo(filepath.Join(t.TempDir(), "gone", "old-project"))
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo: %v", err)
+	}
+	db.Close()
+
+	cmd := repoMoveCmd()
+	cmd.SetArgs([]string{"old-project", repo.Dir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("repo move: %v", err)
+	}
+
+	db, err = storage.Open(storage.DefaultDBPath())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	moved, err := db.GetRepoByID(old.ID)
+	if err != nil {
+		t.Fatalf("GetRepoByID: %v", err)
+	}
+	if moved.RootPath != repo.Dir || moved.Name != filepath.Base(repo.Dir) {
+		t.Errorf("expected repo moved to %s, got %+v", repo.Dir, moved)
+	}
+
+	cmd = repoMoveCmd()
+	cmd.SetArgs([]string{"old-project", t.TempDir()})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected an error moving to a path that is not a git repository")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -687,3 +689,196 @@ func TestReviewFastFlag(t *testing.T) {
 		}
 	})
 }
+
+func TestReviewQuickFlag(t *testing.T) {
+	t.Run("quick flag sets review type and fast reasoning", func(t *testing.T) {
+		type enqueueReq struct {
+			ReviewType string `json:"review_type"`
+			Reasoning  string `json:"reasoning"`
+		}
+		reqChan := make(chan enqueueReq, 1)
+		_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/enqueue" {
+				var req enqueueReq
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				reqChan <- req
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(storage.ReviewJob{ID: 1, Agent: "test"})
+				return
+			}
+		}))
+		defer cleanup()
+
+		repo := newTestGitRepo(t)
+		repo.CommitFile("file.txt", "content", "initial")
+
+		cmd := reviewCmd()
+		cmd.SetArgs([]string{"--repo", repo.Dir, "--quick"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case req := <-reqChan:
+			if req.ReviewType != "quick" || req.Reasoning != "fast" {
+				t.Errorf("expected quick review with fast reasoning, got %+v", req)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for enqueue request")
+		}
+	})
+
+	t.Run("quick conflicts with another type", func(t *testing.T) {
+		_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/enqueue" {
+				t.Error("should not enqueue")
+			}
+		}))
+		defer cleanup()
+
+		repo := newTestGitRepo(t)
+		repo.CommitFile("file.txt", "content", "initial")
+
+		cmd := reviewCmd()
+		cmd.SetArgs([]string{"--repo", repo.Dir, "--quick", "--type", "security"})
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--quick") {
+			t.Errorf("expected a --quick conflict error, got %v", err)
+		}
+	})
+}
+
+func TestReviewPerCommitFlag(t *testing.T) {
+	t.Run("sends per_commit for a range", func(t *testing.T) {
+		perCommitChan := make(chan bool, 1)
+		_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/enqueue" {
+				var req struct {
+					PerCommit bool `json:"per_commit"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				perCommitChan <- req.PerCommit
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(storage.ReviewJob{ID: 1, Agent: "test"})
+				return
+			}
+		}))
+		defer cleanup()
+
+		repo := newTestGitRepo(t)
+		repo.CommitFile("file.txt", "one", "first")
+		repo.CommitFile("file.txt", "two", "second")
+
+		cmd := reviewCmd()
+		cmd.SetArgs([]string{"--repo", repo.Dir, "--since", "HEAD~1", "--per-commit"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case perCommit := <-perCommitChan:
+			if !perCommit {
+				t.Error("expected per_commit to be sent")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for enqueue request")
+		}
+	})
+
+	t.Run("requires a range", func(t *testing.T) {
+		_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/enqueue" {
+				t.Error("should not enqueue")
+			}
+		}))
+		defer cleanup()
+
+		repo := newTestGitRepo(t)
+		repo.CommitFile("file.txt", "content", "initial")
+
+		cmd := reviewCmd()
+		cmd.SetArgs([]string{"--repo", repo.Dir, "--per-commit"})
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "requires a range") {
+			t.Errorf("expected a range error, got %v", err)
+		}
+	})
+}
+
+func TestReviewScopeFlag(t *testing.T) {
+	t.Run("sends only the staged changes", func(t *testing.T) {
+		reqChan := make(chan map[string]interface{}, 1)
+		_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/enqueue" {
+				var req map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				reqChan <- req
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(storage.ReviewJob{ID: 1, Agent: "test"})
+				return
+			}
+		}))
+		defer cleanup()
+
+		repo := newTestGitRepo(t)
+		repo.CommitFile("file.txt", "one\n", "initial")
+		repo.CommitFile("other.txt", "one\n", "second")
+		if err := os.WriteFile(filepath.Join(repo.Dir, "file.txt"), []byte("staged\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		repo.Run("add", "file.txt")
+		if err := os.WriteFile(filepath.Join(repo.Dir, "other.txt"), []byte("unstaged\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := reviewCmd()
+		cmd.SetArgs([]string{"--repo", repo.Dir, "--scope", "staged"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case req := <-reqChan:
+			diff, _ := req["diff_content"].(string)
+			if req["git_ref"] != "dirty" || !strings.Contains(diff, "+staged") || strings.Contains(diff, "unstaged") {
+				t.Errorf("expected a dirty review of the staged change, got %v", req)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for enqueue request")
+		}
+	})
+
+	t.Run("rejects an unknown scope", func(t *testing.T) {
+		_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/enqueue" {
+				t.Error("should not enqueue")
+			}
+		}))
+		defer cleanup()
+
+		repo := newTestGitRepo(t)
+		repo.CommitFile("file.txt", "content", "initial")
+
+		cmd := reviewCmd()
+		cmd.SetArgs([]string{"--repo", repo.Dir, "--scope", "index"})
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --scope") {
+			t.Errorf("expected an invalid scope error, got %v", err)
+		}
+	})
+}
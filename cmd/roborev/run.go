@@ -78,14 +78,6 @@ Examples:
 	return cmd
 }
 
-// promptCmd returns a hidden alias for backward compatibility
-func promptCmd() *cobra.Command {
-	cmd := runCmd()
-	cmd.Use = "prompt [task]"
-	cmd.Hidden = true
-	return cmd
-}
-
 func runPrompt(cmd *cobra.Command, args []string, agentName, modelStr, reasoningStr string, wait, quiet, includeContext, agentic bool, label string) error {
 	// Get prompt from args or stdin
 	var promptText string
@@ -308,7 +300,7 @@ func showPromptResult(cmd *cobra.Command, addr string, jobID int64, quiet bool,
 		if doneMsg != "" {
 			cmd.Print(doneMsg)
 		}
-		cmd.Printf("Result (by %s)\n", review.Agent)
+		cmd.Printf("Result (by %s)\n", formatReviewer(review.Agent, review.AgentVersion))
 		cmd.Println(strings.Repeat("-", 60))
 		cmd.Println(review.Output)
 	}
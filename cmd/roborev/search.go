@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+func searchCmd() *cobra.Command {
+	var (
+		repoPath   string
+		allRepos   bool
+		limit      int
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search review output and responses",
+		Long: `Search the text of completed reviews and the responses left on them.
+
+A result matches when its text has every word of the query, in any order
+and any form of the word: "racing" finds "race". End a word with * to
+match it as a prefix. Results are ranked best match first. The search
+covers the current repository unless --repo or --all-repos says otherwise.`,
+		Example: `  roborev search "race condition"
+  roborev search "nil deref*" --all-repos
+  roborev search timeout --repo ~/src/api --limit 10`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit <= 0 {
+				return fmt.Errorf("--limit must be positive")
+			}
+			if allRepos && repoPath != "" {
+				return fmt.Errorf("--repo and --all-repos cannot be used together")
+			}
+			if !allRepos {
+				// The daemon stores jobs under the main repo path
+				if repoPath == "" {
+					repoPath = "."
+				}
+				root, err := git.GetMainRepoRoot(repoPath)
+				if err != nil {
+					return fmt.Errorf("not in a git repository; use --repo or --all-repos")
+				}
+				repoPath = root
+			}
+
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			params := url.Values{}
+			params.Set("q", strings.Join(args, " "))
+			params.Set("limit", strconv.Itoa(limit))
+			if repoPath != "" {
+				params.Set("repo", repoPath)
+			}
+			var resp struct {
+				Results []storage.SearchResult `json:"results"`
+			}
+			if err := daemonGetJSON(ctx, getDaemonAddr()+"/api/search?"+params.Encode(), &resp); err != nil {
+				return fmt.Errorf("search: %w", err)
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(resp.Results)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(resp.Results) == 0 {
+				fmt.Fprintln(out, "No matches.")
+				return nil
+			}
+			for _, r := range resp.Results {
+				source := "review"
+				if r.Kind == storage.SearchKindResponse {
+					source = "response by " + r.Responder
+				}
+				fmt.Fprintf(out, "Job %d  %s  %s  %s, %s\n", r.JobID, r.RepoName, shortRef(r.GitRef), source, r.CreatedAt.Local().Format("2006-01-02"))
+				fmt.Fprintf(out, "  %s\n\n", strings.Join(strings.Fields(r.Snippet), " "))
+			}
+			if len(resp.Results) == limit {
+				fmt.Fprintln(out, "(more results may be available, use --limit to increase)")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "search this repo (default: current repo)")
+	cmd.Flags().BoolVar(&allRepos, "all-repos", false, "search every repo")
+	cmd.Flags().IntVar(&limit, "limit", 20, "max number of results")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+
+	return cmd
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestSearchCmd(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.CommitFile("a.txt", "a", "initial")
+
+	var queries []url.Values
+	_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search" {
+			return
+		}
+		queries = append(queries, r.URL.Query())
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": []storage.SearchResult{
+			{Kind: storage.SearchKindResponse, JobID: 42, RepoName: "api", GitRef: "abcdef1234567", Responder: "alice",
+				Snippet: "a [race]\n[condition] here", CreatedAt: time.Now()},
+		}})
+	}))
+	defer cleanup()
+
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := searchCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("race", "condition", "--repo", repo.Dir)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(queries) != 1 || queries[0].Get("q") != "race condition" || queries[0].Get("repo") != repo.Dir {
+		t.Errorf("unexpected query: %v", queries)
+	}
+	if !strings.Contains(out, "Job 42") || !strings.Contains(out, "response by alice") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	if _, err := run("race", "--all-repos"); err != nil {
+		t.Fatalf("search --all-repos: %v", err)
+	}
+	if len(queries) != 2 || queries[1].Has("repo") {
+		t.Errorf("expected an unscoped search, got %v", queries[len(queries)-1])
+	}
+
+	if _, err := run("race", "--all-repos", "--repo", repo.Dir); err == nil {
+		t.Error("expected an error combining --repo and --all-repos")
+	}
+}
@@ -3,9 +3,12 @@ package main
 // Tests for the show command
 
 import (
+	"encoding/json"
+	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/roborev-dev/roborev/internal/daemon"
 	"github.com/roborev-dev/roborev/internal/storage"
 )
 
@@ -137,3 +140,46 @@ func TestShowOutputFormat(t *testing.T) {
 		}
 	})
 }
+
+func TestShowLangFlag(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.CommitFile("file.txt", "content", "initial commit")
+
+	getQuery := mockReviewDaemon(t, storage.Review{
+		ID: 1, JobID: 42, Output: "Nenhum problema encontrado.", Agent: "test", Language: "pt-br",
+	})
+
+	chdir(t, repo.Dir)
+	output := runShowCmd(t, "--job", "42", "--lang", "pt-BR", "--raw")
+
+	if q := getQuery(); !strings.Contains(q, "lang=pt-BR") {
+		t.Errorf("expected lang=pt-BR in query, got: %s", q)
+	}
+	if !strings.Contains(output, "Nenhum problema encontrado.") {
+		t.Errorf("expected translated output, got: %s", output)
+	}
+}
+
+func TestShowPromptUsesStoredPrompt(t *testing.T) {
+	var promptPath string
+	_, cleanup := setupMockDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/review":
+			json.NewEncoder(w).Encode(storage.Review{ID: 7, JobID: 42, Output: "LGTM", Agent: "test"})
+		case "/api/review/7/prompt":
+			promptPath = r.URL.Path
+			json.NewEncoder(w).Encode(daemon.ReviewPromptResponse{ReviewID: 7, JobID: 42, Agent: "test", Prompt: "Review this commit.\n"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(cleanup)
+
+	out := runShowCmd(t, "--job", "--prompt", "42")
+	if promptPath == "" {
+		t.Fatal("expected the prompt to be fetched from /api/review/{id}/prompt")
+	}
+	if !strings.HasSuffix(out, "\nReview this commit.\n") || strings.Contains(out, "LGTM") {
+		t.Errorf("expected only the stored prompt after the header, got:\n%s", out)
+	}
+}
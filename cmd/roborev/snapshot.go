@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/snapshot"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// snapshotDir is where snapshots go when no path is given
+func snapshotDir() string {
+	return filepath.Join(config.DataDir(), "snapshots")
+}
+
+func daemonSnapshotCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "snapshot [file]",
+		Short: "Save the database, config, and daemon metadata to an archive",
+		Long: `Save the database, global config, and running daemon's metadata to a
+single archive that 'roborev daemon restore' can bring back, e.g. before an
+upgrade or to move roborev to another host. The database is copied
+consistently even while the daemon runs.
+
+Without a file, the snapshot goes in ~/.roborev/snapshots.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dst := snapshot.DefaultPath(snapshotDir(), time.Now())
+			if len(args) == 1 {
+				dst = args[0]
+			}
+			var info *snapshot.DaemonInfo
+			if rt, err := daemon.GetAnyRunningDaemon(); err == nil && rt != nil {
+				info = &snapshot.DaemonInfo{PID: rt.PID, Addr: rt.Addr, Version: rt.Version, StartedAt: rt.StartedAt}
+			}
+			if _, err := snapshot.Create(dst, storage.DefaultDBPath(), config.GlobalConfigPath(), info); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Snapshot saved to %s\n", dst)
+			return nil
+		},
+	}
+}
+
+func daemonRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore the database and config from a snapshot",
+		Long: `Replace the database and global config with those in a snapshot taken by
+'roborev daemon snapshot'. Stop the daemon first. The current state is
+snapshotted to ~/.roborev/snapshots before anything is replaced, so the
+restore can itself be undone.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rt, err := daemon.GetAnyRunningDaemon(); err == nil && rt != nil {
+				return fmt.Errorf("the daemon is running (pid %d); stop it with 'roborev daemon stop' first", rt.PID)
+			}
+
+			dbPath := storage.DefaultDBPath()
+			configPath := config.GlobalConfigPath()
+			if _, err := os.Stat(dbPath); err == nil {
+				backup := filepath.Join(snapshotDir(), "pre-restore-"+filepath.Base(snapshot.DefaultPath("", time.Now())))
+				if _, err := snapshot.Create(backup, dbPath, configPath, nil); err != nil {
+					return fmt.Errorf("snapshot current state: %w", err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Current state saved to %s\n", backup)
+			}
+
+			manifest, err := snapshot.Restore(args[0], dbPath, configPath)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored snapshot taken %s on %s by roborev %s\n",
+				manifest.CreatedAt.Local().Format(time.DateTime), manifest.Hostname, manifest.Version)
+			fmt.Fprintln(cmd.OutOrStdout(), "Start the daemon with 'roborev daemon start'")
+			return nil
+		},
+	}
+}
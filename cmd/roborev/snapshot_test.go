@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestDaemonSnapshotRestore(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("ROBOREV_DATA_DIR", dataDir)
+
+	addRepo := func(name string) {
+		t.Helper()
+		db, err := storage.Open(storage.DefaultDBPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if _, err := db.GetOrCreateRepo(filepath.Join(dataDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addRepo("kept")
+	archive := filepath.Join(t.TempDir(), "snap.tar.gz")
+	snap := daemonSnapshotCmd()
+	var out bytes.Buffer
+	snap.SetOut(&out)
+	snap.SetArgs([]string{archive})
+	if err := snap.Execute(); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Snapshot saved to "+archive) {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	addRepo("dropped")
+	restore := daemonRestoreCmd()
+	out.Reset()
+	restore.SetOut(&out)
+	restore.SetArgs([]string{archive})
+	if err := restore.Execute(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Current state saved to") {
+		t.Errorf("expected the current state to be saved first, got %q", out.String())
+	}
+	backups, _ := filepath.Glob(filepath.Join(dataDir, "snapshots", "pre-restore-*.tar.gz"))
+	if len(backups) != 1 {
+		t.Errorf("expected one pre-restore snapshot, got %v", backups)
+	}
+
+	db, err := storage.Open(storage.DefaultDBPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos, err := db.ListRepos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Name != "kept" {
+		t.Errorf("expected only the repo from the snapshot, got %+v", repos)
+	}
+}
@@ -983,7 +983,7 @@ func (m tuiModel) loadResponses(jobID int64, review *storage.Review) []storage.R
 		var shaResult struct {
 			Responses []storage.Response `json:"responses"`
 		}
-		if err := m.getJSON(fmt.Sprintf("/api/comments?sha=%s", review.Job.GitRef), &shaResult); err == nil {
+		if err := m.getJSON(fmt.Sprintf("/api/comments?sha=%s%s", review.Job.GitRef, repoQuery(review.Job.RepoPath)), &shaResult); err == nil {
 			// Merge and dedupe by ID
 			seen := make(map[int64]bool)
 			for _, r := range responses {
@@ -2415,7 +2415,7 @@ func (m tuiModel) renderJobLine(job storage.ReviewJob, selected bool, idWidth in
 			styledStatus = tuiDoneStyle.Render(status)
 		case storage.JobStatusFailed:
 			styledStatus = tuiFailedStyle.Render(status)
-		case storage.JobStatusCanceled:
+		case storage.JobStatusCanceled, storage.JobStatusSkipped:
 			styledStatus = tuiCanceledStyle.Render(status)
 		default:
 			styledStatus = status
@@ -2526,7 +2526,7 @@ func (m tuiModel) renderReviewView() string {
 		}
 		repoStr := m.getDisplayName(review.Job.RepoPath, defaultName)
 
-		agentStr := formatAgentLabel(review.Agent, review.Job.Model)
+		agentStr := formatReviewer(formatAgentLabel(review.Agent, review.Job.Model), review.AgentVersion)
 
 		title = fmt.Sprintf("Review %s%s (%s)", idStr, repoStr, agentStr)
 		titleLen = runewidth.StringWidth(title)
@@ -2555,7 +2555,7 @@ func (m tuiModel) renderReviewView() string {
 
 		// Show verdict and addressed status on next line
 		hasVerdict := review.Job.Verdict != nil && *review.Job.Verdict != ""
-		if hasVerdict || review.Addressed {
+		if hasVerdict || review.Addressed || review.InjectionWarning != "" {
 			b.WriteString("\n")
 			if hasVerdict {
 				v := *review.Job.Verdict
@@ -2572,6 +2572,13 @@ func (m tuiModel) renderReviewView() string {
 				}
 				b.WriteString(tuiAddressedStyle.Render("[ADDRESSED]"))
 			}
+			// Flag reviews that may have obeyed instructions in the diff
+			if review.InjectionWarning != "" {
+				if hasVerdict || review.Addressed {
+					b.WriteString(" ")
+				}
+				b.WriteString(tuiFailStyle.Render("[POSSIBLE PROMPT INJECTION]"))
+			}
 			b.WriteString("\x1b[K") // Clear to end of line
 		}
 		b.WriteString("\n")
@@ -2590,7 +2597,7 @@ func (m tuiModel) renderReviewView() string {
 	if len(m.currentResponses) > 0 {
 		content.WriteString("\n\n--- Comments ---\n")
 		for _, r := range m.currentResponses {
-			timestamp := r.CreatedAt.Format("Jan 02 15:04")
+			timestamp := r.CreatedAt.Local().Format("Jan 02 15:04")
 			content.WriteString(fmt.Sprintf("\n[%s] %s:\n", timestamp, r.Responder))
 			content.WriteString(r.Response)
 			content.WriteString("\n")
@@ -2637,7 +2644,7 @@ func (m tuiModel) renderReviewView() string {
 	// headerHeight = title + location line + status line (1) + help + verdict/addressed (0|1)
 	headerHeight := titleLines + locationLines + 1 + helpLines
 	hasVerdict := review.Job != nil && review.Job.Verdict != nil && *review.Job.Verdict != ""
-	if hasVerdict || review.Addressed {
+	if hasVerdict || review.Addressed || review.InjectionWarning != "" {
 		headerHeight++ // Add 1 for verdict/addressed line
 	}
 	visibleLines := m.height - headerHeight
@@ -2708,7 +2715,7 @@ func (m tuiModel) renderPromptView() string {
 	if review.Job != nil {
 		ref := shortJobRef(*review.Job)
 		idStr := fmt.Sprintf("#%d ", review.Job.ID)
-		agentStr := formatAgentLabel(review.Agent, review.Job.Model)
+		agentStr := formatReviewer(formatAgentLabel(review.Agent, review.Job.Model), review.AgentVersion)
 		title := fmt.Sprintf("Prompt %s%s (%s)", idStr, ref, agentStr)
 		b.WriteString(tuiTitleStyle.Render(title))
 	} else {
@@ -729,6 +729,8 @@ func (m tuiModel) handleEnterKey() (tea.Model, tea.Cmd) {
 		status = "in progress"
 	case storage.JobStatusCanceled:
 		status = "canceled"
+	case storage.JobStatusSkipped:
+		status = fmt.Sprintf("skipped (%s)", job.Error)
 	default:
 		status = string(job.Status)
 	}
@@ -853,7 +855,7 @@ func (m tuiModel) handleRerunKey() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 	job := &m.jobs[m.selectedIdx]
-	if job.Status == storage.JobStatusDone || job.Status == storage.JobStatusFailed || job.Status == storage.JobStatusCanceled {
+	if job.Status == storage.JobStatusDone || job.Status == storage.JobStatusFailed || job.Status == storage.JobStatusCanceled || job.Status == storage.JobStatusSkipped {
 		oldStatus := job.Status
 		oldStartedAt := job.StartedAt
 		oldFinishedAt := job.FinishedAt
@@ -872,11 +874,12 @@ func (m tuiModel) handleTailKey2() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 	job := m.jobs[m.selectedIdx]
-	if job.Status == storage.JobStatusRunning {
+	// Failed and canceled jobs show the output salvaged before the agent stopped
+	if job.Status == storage.JobStatusRunning || job.Status == storage.JobStatusFailed || job.Status == storage.JobStatusCanceled {
 		m.tailJobID = job.ID
 		m.tailLines = nil
 		m.tailScroll = 0
-		m.tailStreaming = true
+		m.tailStreaming = job.Status == storage.JobStatusRunning
 		m.tailFollow = true
 		m.tailFromView = tuiViewQueue
 		m.currentView = tuiViewTail
@@ -983,6 +986,8 @@ func (m tuiModel) handleCopyKey() (tea.Model, tea.Cmd) {
 			status = "in progress"
 		case storage.JobStatusCanceled:
 			status = "canceled"
+		case storage.JobStatusSkipped:
+			status = fmt.Sprintf("skipped (%s)", job.Error)
 		default:
 			status = string(job.Status)
 		}
@@ -179,8 +179,18 @@ type markdownCache struct {
 
 // newMarkdownCache creates a markdownCache, detecting terminal background
 // color now (before bubbletea enters raw mode and takes over stdin).
-// Builds a custom style with zero margins to avoid extra padding.
 func newMarkdownCache(tabWidth int) *markdownCache {
+	if tabWidth <= 0 {
+		tabWidth = 2
+	} else if tabWidth > 16 {
+		tabWidth = 16
+	}
+	return &markdownCache{glamourStyle: newGlamourStyle(), tabWidth: tabWidth}
+}
+
+// newGlamourStyle returns the glamour style for the terminal's background,
+// with zero margins to avoid extra padding.
+func newGlamourStyle() gansi.StyleConfig {
 	style := styles.LightStyleConfig
 	if termenv.HasDarkBackground() {
 		style = styles.DarkStyleConfig
@@ -193,12 +203,7 @@ func newMarkdownCache(tabWidth int) *markdownCache {
 	// colored blocks around `backtick` content).
 	style.Code.Prefix = ""
 	style.Code.Suffix = ""
-	if tabWidth <= 0 {
-		tabWidth = 2
-	} else if tabWidth > 16 {
-		tabWidth = 16
-	}
-	return &markdownCache{glamourStyle: style, tabWidth: tabWidth}
+	return style
 }
 
 // truncateLongLines normalizes tabs and truncates lines inside fenced code
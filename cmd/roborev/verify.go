@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/daemon"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/integrity"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+func verifyCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "verify [<range>|<commit>]",
+		Short: "Check that commits have passing reviews",
+		Long: `Check that every commit in a range has a passing review.
+
+Reports commits that were never reviewed, are still being reviewed, or
+whose review found issues, and exits non-zero if any commit fails the
+policy. Only default reviews count; specialized types such as security
+or quick don't. Use it in CI to enforce review requirements.
+
+With no argument, verify checks the branches in the repo's review policy
+(.roborev.toml):
+
+  [review_policy]
+  branches = ["main"]        # every commit on these branches needs a passing review
+  since = "v1.4.0"           # only check commits after this ref
+  allow_addressed = true     # failing reviews marked addressed count as passing
+  require_signatures = true  # reviews must be signed with review_signing_key
+
+Only per-commit reviews count; range and dirty reviews are ignored.
+Commits skipped by [skip] rules count as passing.`,
+		Example: `  roborev verify                      # Check branches from [review_policy]
+  roborev verify origin/main..HEAD    # Check commits on this branch
+  roborev verify abc1234              # Check a single commit
+  roborev verify v1.4.0..main --json  # Machine-readable output`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoRoot, err := git.GetRepoRoot(".")
+			if err != nil {
+				return fmt.Errorf("not in a git repository: %w", err)
+			}
+
+			commits, err := verifyTargetCommits(repoRoot, args)
+			if err != nil {
+				return err
+			}
+
+			if err := ensureDaemon(); err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+
+			reqBody, _ := json.Marshal(daemon.VerifyRequest{RepoPath: repoRoot, Commits: commits})
+			resp, err := http.Post(getDaemonAddr()+"/api/verify", "application/json", bytes.NewReader(reqBody))
+			if err != nil {
+				return fmt.Errorf("failed to connect to daemon: %w", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("failed to verify commits: %s", body)
+			}
+
+			var result daemon.VerifyResponse
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(result); err != nil {
+					return err
+				}
+			} else {
+				printVerifyResult(cmd.OutOrStdout(), repoRoot, result)
+			}
+
+			if !result.Passed {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("review policy not satisfied")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON")
+
+	return cmd
+}
+
+// verifyTargetCommits resolves the commits to check: a range or single
+// commit from args, or the branches listed in the repo's review policy.
+func verifyTargetCommits(repoRoot string, args []string) ([]string, error) {
+	if len(args) == 1 {
+		ref := args[0]
+		if strings.Contains(ref, "..") {
+			commits, err := git.GetRangeCommits(repoRoot, ref)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q: %w", ref, err)
+			}
+			return commits, nil
+		}
+		sha, err := git.ResolveSHA(repoRoot, ref+"^{commit}")
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit %q: %w", ref, err)
+		}
+		return []string{sha}, nil
+	}
+
+	repoCfg, err := config.LoadRepoConfig(repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("load repo config: %w", err)
+	}
+	if repoCfg == nil || len(repoCfg.ReviewPolicy.Branches) == 0 {
+		return nil, fmt.Errorf("no range given and no [review_policy] branches configured in .roborev.toml")
+	}
+	policy := repoCfg.ReviewPolicy
+	if policy.Since == "" {
+		return nil, fmt.Errorf("[review_policy] since must be set to verify without a range")
+	}
+
+	// Several policy branches usually share history; check each commit once
+	seen := make(map[string]bool)
+	var commits []string
+	for _, branch := range policy.Branches {
+		branchCommits, err := git.GetRangeCommits(repoRoot, policy.Since+".."+branch)
+		if err != nil {
+			return nil, fmt.Errorf("list commits on %s since %s: %w", branch, policy.Since, err)
+		}
+		for _, sha := range branchCommits {
+			if !seen[sha] {
+				seen[sha] = true
+				commits = append(commits, sha)
+			}
+		}
+	}
+	return commits, nil
+}
+
+// printVerifyResult lists commits that fail the policy and a summary line
+func printVerifyResult(w io.Writer, repoRoot string, result daemon.VerifyResponse) {
+	counts := make(map[string]int)
+	badSignatures := 0
+	for _, c := range result.Commits {
+		counts[c.Status]++
+		badSignature := c.Signature != "" && c.Signature != integrity.Valid
+		if badSignature {
+			badSignatures++
+		}
+		if (c.Status == storage.CommitReviewPassed || c.Status == storage.CommitReviewSkipped) && !badSignature {
+			continue
+		}
+		subject := ""
+		if info, err := git.GetCommitInfo(repoRoot, c.SHA); err == nil {
+			subject = truncateString(info.Subject, 50)
+		}
+		job := ""
+		if c.JobID > 0 {
+			job = fmt.Sprintf("job %d", c.JobID)
+		}
+		if badSignature {
+			subject = "[" + c.Signature + " signature] " + subject
+		}
+		fmt.Fprintf(w, "  %s  %-10s  %-9s  %s\n", shortSHA(c.SHA), c.Status, job, subject)
+	}
+
+	if len(result.Commits) == 0 {
+		fmt.Fprintln(w, "No commits to verify")
+		return
+	}
+	fmt.Fprintf(w, "%d commit(s): %d passed, %d skipped, %d failed, %d addressed, %d pending, %d unreviewed\n",
+		len(result.Commits),
+		counts[storage.CommitReviewPassed],
+		counts[storage.CommitReviewSkipped],
+		counts[storage.CommitReviewFailed],
+		counts[storage.CommitReviewAddressed],
+		counts[storage.CommitReviewPending],
+		counts[storage.CommitReviewUnreviewed])
+	if badSignatures > 0 {
+		fmt.Fprintf(w, "%d review(s) without a valid signature\n", badSignatures)
+	}
+	if result.Passed {
+		fmt.Fprintln(w, "Review policy satisfied")
+	}
+}
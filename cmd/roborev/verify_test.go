@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVerifyTargetCommits(t *testing.T) {
+	repo := newTestGitRepo(t)
+	base := repo.CommitFile("a.txt", "a", "base")
+	repo.Run("tag", "v1")
+	c1 := repo.CommitFile("b.txt", "b", "second")
+	c2 := repo.CommitFile("c.txt", "c", "third")
+	branch := repo.Run("rev-parse", "--abbrev-ref", "HEAD")
+
+	t.Run("range", func(t *testing.T) {
+		got, err := verifyTargetCommits(repo.Dir, []string{base + "..HEAD"})
+		if err != nil {
+			t.Fatalf("verifyTargetCommits: %v", err)
+		}
+		if !reflect.DeepEqual(got, []string{c1, c2}) {
+			t.Errorf("got %v, want [%s %s]", got, c1, c2)
+		}
+	})
+
+	t.Run("single commit", func(t *testing.T) {
+		got, err := verifyTargetCommits(repo.Dir, []string{c1[:7]})
+		if err != nil {
+			t.Fatalf("verifyTargetCommits: %v", err)
+		}
+		if !reflect.DeepEqual(got, []string{c1}) {
+			t.Errorf("got %v, want [%s]", got, c1)
+		}
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		_, err := verifyTargetCommits(repo.Dir, nil)
+		if err == nil || !strings.Contains(err.Error(), "review_policy") {
+			t.Errorf("expected missing policy error, got %v", err)
+		}
+	})
+
+	t.Run("policy branches since ref", func(t *testing.T) {
+		policy := "[review_policy]\nbranches = [\"" + branch + "\"]\nsince = \"v1\"\n"
+		if err := os.WriteFile(filepath.Join(repo.Dir, ".roborev.toml"), []byte(policy), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := verifyTargetCommits(repo.Dir, nil)
+		if err != nil {
+			t.Fatalf("verifyTargetCommits: %v", err)
+		}
+		if !reflect.DeepEqual(got, []string{c1, c2}) {
+			t.Errorf("got %v, want [%s %s]", got, c1, c2)
+		}
+	})
+}
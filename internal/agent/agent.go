@@ -21,6 +21,11 @@ const (
 	ReasoningFast ReasoningLevel = "fast"
 )
 
+// NoOutput is what an agent returns when its run produced no text, so a
+// review shows why it is blank. Callers checking for an empty review must
+// treat it as empty.
+const NoOutput = "No review output generated"
+
 // ParseReasoningLevel converts a string to ReasoningLevel, defaulting to standard
 func ParseReasoningLevel(s string) ReasoningLevel {
 	switch s {
@@ -42,6 +47,9 @@ type Agent interface {
 
 	// Review runs a code review and returns the output.
 	// If output is non-nil, agent progress is streamed to it in real-time.
+	// When ctx is canceled, Review stops the agent and returns an error
+	// promptly; agents that launch subprocesses must do so through
+	// agentCommand so the processes they start are killed with them.
 	Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (result string, err error)
 
 	// WithReasoning returns a copy of the agent configured with the specified reasoning level.
@@ -72,6 +80,27 @@ type CommandAgent interface {
 	CommandName() string
 }
 
+// Session is a long-lived agent process that handles successive prompts.
+// Conversation context carries over from one prompt to the next, so the
+// caller decides how much history a session may build up before it is
+// replaced.
+type Session interface {
+	// Review sends prompt to the running process and returns its reply.
+	// If output is non-nil, agent progress is streamed to it in real-time.
+	Review(ctx context.Context, prompt string, output io.Writer) (string, error)
+
+	// Close stops the process. It is safe to call more than once.
+	Close() error
+}
+
+// SessionAgent is an agent that can keep a process alive across reviews
+type SessionAgent interface {
+	Agent
+	// StartSession launches a process in repoPath that lives until the
+	// session is closed, independent of ctx's cancellation
+	StartSession(ctx context.Context, repoPath string) (Session, error)
+}
+
 // Registry holds available agents
 var registry = make(map[string]Agent)
 var allowUnsafeAgents atomic.Bool
@@ -101,6 +130,7 @@ func SetAnthropicAPIKey(key string) {
 // aliases maps short names to full agent names
 var aliases = map[string]string{
 	"claude": "claude-code",
+	"q":      "amazon-q",
 }
 
 // resolveAlias returns the canonical agent name, resolving aliases
@@ -166,8 +196,8 @@ func GetAvailable(preferred string) (Agent, error) {
 		return Get(preferred)
 	}
 
-	// Fallback order: codex, claude-code, gemini, copilot, opencode, cursor, droid
-	fallbacks := []string{"codex", "claude-code", "gemini", "copilot", "opencode", "cursor", "droid"}
+	// Fallback order: codex, claude-code, gemini, copilot, opencode, cursor, droid, aider, amazon-q
+	fallbacks := []string{"codex", "claude-code", "gemini", "copilot", "opencode", "cursor", "droid", "aider", "amazon-q"}
 	for _, name := range fallbacks {
 		if name != preferred && IsAvailable(name) {
 			return Get(name)
@@ -183,7 +213,7 @@ func GetAvailable(preferred string) (Agent, error) {
 	}
 
 	if len(available) == 0 {
-		return nil, fmt.Errorf("no agents available (install one of: codex, claude-code, gemini, copilot, opencode, cursor, droid)\nYou may need to run 'roborev daemon restart' from a shell that has access to your agents")
+		return nil, fmt.Errorf("no agents available (install one of: codex, claude-code, gemini, copilot, opencode, cursor, droid, aider, amazon-q)\nYou may need to run 'roborev daemon restart' from a shell that has access to your agents")
 	}
 
 	return Get(available[0])
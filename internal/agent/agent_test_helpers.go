@@ -12,7 +12,7 @@ import (
 )
 
 // expectedAgents is the single source of truth for registered agent names.
-var expectedAgents = []string{"codex", "claude-code", "gemini", "copilot", "opencode", "cursor", "test"}
+var expectedAgents = []string{"codex", "claude-code", "gemini", "copilot", "opencode", "cursor", "aider", "amazon-q", "test"}
 
 // verifyAgentPassesFlag creates a mock command that echoes args, runs the agent's Review method,
 // and validates that the output contains the expected flag and value.
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// AiderAgent runs code reviews using the aider CLI
+type AiderAgent struct {
+	Command   string         // The aider command to run (default: "aider")
+	Model     string         // Model to use (e.g., "sonnet", "gpt-4o")
+	Reasoning ReasoningLevel // Reasoning level for the agent
+	Agentic   bool           // Whether agentic mode is enabled (allow file edits)
+}
+
+// NewAiderAgent creates a new aider agent with standard reasoning
+func NewAiderAgent(command string) *AiderAgent {
+	if command == "" {
+		command = "aider"
+	}
+	return &AiderAgent{Command: command, Reasoning: ReasoningStandard}
+}
+
+// WithReasoning returns a copy of the agent with the specified reasoning level
+func (a *AiderAgent) WithReasoning(level ReasoningLevel) Agent {
+	return &AiderAgent{
+		Command:   a.Command,
+		Model:     a.Model,
+		Reasoning: level,
+		Agentic:   a.Agentic,
+	}
+}
+
+// WithAgentic returns a copy of the agent configured for agentic mode.
+func (a *AiderAgent) WithAgentic(agentic bool) Agent {
+	return &AiderAgent{
+		Command:   a.Command,
+		Model:     a.Model,
+		Reasoning: a.Reasoning,
+		Agentic:   agentic,
+	}
+}
+
+// WithModel returns a copy of the agent configured to use the specified model.
+func (a *AiderAgent) WithModel(model string) Agent {
+	if model == "" {
+		return a
+	}
+	return &AiderAgent{
+		Command:   a.Command,
+		Model:     model,
+		Reasoning: a.Reasoning,
+		Agentic:   a.Agentic,
+	}
+}
+
+// aiderReasoningEffort maps ReasoningLevel to aider's --reasoning-effort values
+func (a *AiderAgent) aiderReasoningEffort() string {
+	switch a.Reasoning {
+	case ReasoningThorough:
+		return "high"
+	case ReasoningFast:
+		return "low"
+	default:
+		return "" // use model default
+	}
+}
+
+func (a *AiderAgent) Name() string {
+	return "aider"
+}
+
+func (a *AiderAgent) CommandName() string {
+	return a.Command
+}
+
+func (a *AiderAgent) CommandLine() string {
+	agenticMode := a.Agentic || AllowUnsafeAgents()
+	return a.Command + " " + strings.Join(a.buildArgs(agenticMode), " ")
+}
+
+func (a *AiderAgent) buildArgs(agenticMode bool) []string {
+	// Never let aider commit on its own: roborev's address/fix flows
+	// own the commit step, and reviews must not touch the repo. Nor let
+	// it add .aider* to .gitignore or keep its history files in the repo.
+	args := []string{
+		"--yes-always",
+		"--no-auto-commits",
+		"--no-dirty-commits",
+		"--no-gitignore",
+		"--chat-history-file", os.DevNull,
+		"--input-history-file", os.DevNull,
+		"--no-check-update",
+		"--no-show-model-warnings",
+		"--no-pretty",
+		"--no-stream",
+		"--no-analytics",
+	}
+
+	if a.Model != "" {
+		args = append(args, "--model", a.Model)
+	}
+	if effort := a.aiderReasoningEffort(); effort != "" {
+		args = append(args, "--reasoning-effort", effort)
+	}
+
+	if !agenticMode {
+		// Review mode: report proposed edits without writing them, and
+		// skip the repo map so its tags cache isn't written to the repo
+		args = append(args, "--dry-run", "--map-tokens", "0")
+	}
+
+	return args
+}
+
+func (a *AiderAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	// Use agentic mode if either per-job setting or global setting enables it
+	agenticMode := a.Agentic || AllowUnsafeAgents()
+
+	// aider has no stdin prompt mode; pass the prompt through a temp file
+	// so large diffs don't hit command-line length limits.
+	promptFile, err := os.CreateTemp("", "roborev-aider-*.md")
+	if err != nil {
+		return "", fmt.Errorf("create prompt file: %w", err)
+	}
+	defer os.Remove(promptFile.Name())
+	if _, err := promptFile.WriteString(prompt); err != nil {
+		promptFile.Close()
+		return "", fmt.Errorf("write prompt file: %w", err)
+	}
+	if err := promptFile.Close(); err != nil {
+		return "", fmt.Errorf("close prompt file: %w", err)
+	}
+
+	args := a.buildArgs(agenticMode)
+	args = append(args, "--message-file", promptFile.Name())
+
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command, args...)
+	if err != nil {
+		return "", err
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	var stdout, stderr bytes.Buffer
+	if sw := newSyncWriter(output); sw != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, sw)
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
+	} else {
+		cmd.Stdout = &stdout
+		cmd.Stderr = traceStderr(ctx, &stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("aider failed: %w\nstderr: %s", err, truncateStderr(stderr.String()))
+	}
+
+	result := stdout.String()
+	if len(result) == 0 {
+		return NoOutput, nil
+	}
+
+	return result, nil
+}
+
+func init() {
+	Register(NewAiderAgent(""))
+}
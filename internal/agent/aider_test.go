@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAiderBuildArgsSuppressesCommits(t *testing.T) {
+	a := NewAiderAgent("aider")
+
+	for _, agentic := range []bool{false, true} {
+		args := a.buildArgs(agentic)
+		assertContainsArg(t, args, "--no-auto-commits")
+		assertContainsArg(t, args, "--no-dirty-commits")
+		assertContainsArg(t, args, "--yes-always")
+	}
+}
+
+func TestAiderBuildArgsAgenticMode(t *testing.T) {
+	a := NewAiderAgent("aider")
+
+	// Review mode must not write files
+	args := a.buildArgs(false)
+	assertContainsArg(t, args, "--dry-run")
+	assertContainsArg(t, args, "--no-gitignore")
+	assertArgsSequence(t, args, "--chat-history-file", os.DevNull)
+	assertArgsSequence(t, args, "--input-history-file", os.DevNull)
+	assertArgsSequence(t, args, "--map-tokens", "0")
+
+	// Agentic mode allows edits
+	args = a.buildArgs(true)
+	assertNotContainsArg(t, args, "--dry-run")
+}
+
+func TestAiderBuildArgsModelAndReasoning(t *testing.T) {
+	a := NewAiderAgent("aider").WithModel("sonnet").WithReasoning(ReasoningThorough).(*AiderAgent)
+	args := a.buildArgs(false)
+	assertArgsSequence(t, args, "--model", "sonnet")
+	assertArgsSequence(t, args, "--reasoning-effort", "high")
+
+	a = NewAiderAgent("aider").WithReasoning(ReasoningStandard).(*AiderAgent)
+	args = a.buildArgs(false)
+	assertNotContainsArg(t, args, "--reasoning-effort")
+	assertNotContainsArg(t, args, "--model")
+}
+
+func TestAiderReviewPassesPromptViaMessageFile(t *testing.T) {
+	skipIfWindows(t)
+
+	// Echo the contents of the file following --message-file
+	script := NewScriptBuilder().
+		AddRaw(`while [ $# -gt 0 ]; do if [ "$1" = "--message-file" ]; then cat "$2"; fi; shift; done`).
+		Build()
+	cmdPath := writeTempCommand(t, script)
+
+	a := NewAiderAgent(cmdPath)
+	prompt := "Review this commit carefully"
+	result, err := a.Review(context.Background(), t.TempDir(), "HEAD", prompt, nil)
+	if err != nil {
+		t.Fatalf("Review failed: %v", err)
+	}
+	if strings.TrimSpace(result) != prompt {
+		t.Errorf("result = %q, want %q", result, prompt)
+	}
+}
+
+func TestAiderReviewCleansUpPromptFile(t *testing.T) {
+	skipIfWindows(t)
+
+	mock := mockAgentCLI(t, MockCLIOpts{
+		CaptureArgs: true,
+		StdoutLines: []string{"ok"},
+	})
+
+	a := NewAiderAgent(mock.CmdPath)
+	if _, err := a.Review(context.Background(), t.TempDir(), "HEAD", "prompt", nil); err != nil {
+		t.Fatalf("Review failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(mock.ArgsFile)
+	if err != nil {
+		t.Fatalf("read args capture: %v", err)
+	}
+	args := strings.Fields(string(raw))
+	for i, arg := range args {
+		if arg == "--message-file" && i+1 < len(args) {
+			if _, err := os.Stat(args[i+1]); !os.IsNotExist(err) {
+				t.Errorf("expected prompt file %s to be removed, stat err: %v", args[i+1], err)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected --message-file in args, got %v", args)
+}
+
+func TestAiderReviewFailure(t *testing.T) {
+	script := NewScriptBuilder().AddRaw(`echo "boom" >&2`).AddRaw("exit 1").Build()
+	a := NewAiderAgent(writeTempCommand(t, script))
+	_, err := a.Review(context.Background(), t.TempDir(), "HEAD", "prompt", nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "aider failed") {
+		t.Fatalf("expected 'aider failed' in error, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AmazonQAgent runs code reviews using the Amazon Q Developer CLI
+type AmazonQAgent struct {
+	Command   string         // The q command to run (default: "q")
+	Model     string         // Model to use (e.g., "claude-sonnet-4")
+	Reasoning ReasoningLevel // Reasoning level (not supported by q; tracked for consistency)
+	Agentic   bool           // Whether agentic mode is enabled (trust all tools)
+}
+
+// NewAmazonQAgent creates a new Amazon Q agent
+func NewAmazonQAgent(command string) *AmazonQAgent {
+	if command == "" {
+		command = "q"
+	}
+	return &AmazonQAgent{Command: command, Reasoning: ReasoningStandard}
+}
+
+// WithReasoning returns a copy of the agent with the reasoning level recorded.
+// The q CLI has no reasoning control, so this does not change its arguments.
+func (a *AmazonQAgent) WithReasoning(level ReasoningLevel) Agent {
+	return &AmazonQAgent{
+		Command:   a.Command,
+		Model:     a.Model,
+		Reasoning: level,
+		Agentic:   a.Agentic,
+	}
+}
+
+// WithAgentic returns a copy of the agent configured for agentic mode.
+func (a *AmazonQAgent) WithAgentic(agentic bool) Agent {
+	return &AmazonQAgent{
+		Command:   a.Command,
+		Model:     a.Model,
+		Reasoning: a.Reasoning,
+		Agentic:   agentic,
+	}
+}
+
+// WithModel returns a copy of the agent configured to use the specified model.
+func (a *AmazonQAgent) WithModel(model string) Agent {
+	if model == "" {
+		return a
+	}
+	return &AmazonQAgent{
+		Command:   a.Command,
+		Model:     model,
+		Reasoning: a.Reasoning,
+		Agentic:   a.Agentic,
+	}
+}
+
+func (a *AmazonQAgent) Name() string {
+	return "amazon-q"
+}
+
+func (a *AmazonQAgent) CommandName() string {
+	return a.Command
+}
+
+func (a *AmazonQAgent) CommandLine() string {
+	agenticMode := a.Agentic || AllowUnsafeAgents()
+	return a.Command + " " + strings.Join(a.buildArgs(agenticMode), " ")
+}
+
+func (a *AmazonQAgent) buildArgs(agenticMode bool) []string {
+	args := []string{"chat", "--no-interactive"}
+
+	if agenticMode {
+		args = append(args, "--trust-all-tools")
+	} else {
+		// Review mode: allow reading the repo but nothing that writes or executes
+		args = append(args, "--trust-tools=fs_read")
+	}
+
+	if a.Model != "" {
+		args = append(args, "--model", a.Model)
+	}
+
+	return args
+}
+
+func (a *AmazonQAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	// Use agentic mode if either per-job setting or global setting enables it
+	agenticMode := a.Agentic || AllowUnsafeAgents()
+
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command, a.buildArgs(agenticMode)...)
+	if err != nil {
+		return "", err
+	}
+	// Pipe the prompt via stdin: a single argv entry is capped at 128KB on Linux
+	cmd.Stdin = strings.NewReader(prompt)
+	cmd.WaitDelay = 5 * time.Second
+
+	var stdout, stderr bytes.Buffer
+	if sw := newSyncWriter(output); sw != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, sw)
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
+	} else {
+		cmd.Stdout = &stdout
+		cmd.Stderr = traceStderr(ctx, &stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("amazon-q failed: %w\nstderr: %s", err, truncateStderr(stderr.String()))
+	}
+
+	result := parseAmazonQOutput(stdout.String())
+	if len(result) == 0 {
+		return NoOutput, nil
+	}
+
+	return result, nil
+}
+
+// ansiEscape matches terminal control sequences (colors, cursor movement)
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\x07]*\x07`)
+
+// parseAmazonQOutput cleans q chat's terminal output into plain review text.
+// Even with --no-interactive, q emits ANSI styling, spinner frames
+// overwritten with carriage returns, and a "> " marker before the response.
+func parseAmazonQOutput(raw string) string {
+	raw = ansiEscape.ReplaceAllString(raw, "")
+
+	lines := strings.Split(raw, "\n")
+	for i, line := range lines {
+		// Drop a CRLF line ending, then keep only what remains visible
+		// after carriage-return overwrites
+		line = strings.TrimSuffix(line, "\r")
+		if idx := strings.LastIndex(line, "\r"); idx >= 0 {
+			line = line[idx+1:]
+		}
+		lines[i] = line
+	}
+
+	// Drop the response marker on the first non-empty line
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines[i] = strings.TrimPrefix(line, "> ")
+		break
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func init() {
+	Register(NewAmazonQAgent(""))
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAmazonQBuildArgsAgenticMode(t *testing.T) {
+	a := NewAmazonQAgent("q")
+
+	// Review mode only trusts read-only tools
+	args := a.buildArgs(false)
+	assertArgsSequence(t, args, "chat", "--no-interactive")
+	assertContainsArg(t, args, "--trust-tools=fs_read")
+	assertNotContainsArg(t, args, "--trust-all-tools")
+
+	args = a.buildArgs(true)
+	assertContainsArg(t, args, "--trust-all-tools")
+	assertNotContainsArg(t, args, "--trust-tools=fs_read")
+}
+
+func TestAmazonQBuildArgsModel(t *testing.T) {
+	a := NewAmazonQAgent("q").WithModel("claude-sonnet-4").(*AmazonQAgent)
+	assertArgsSequence(t, a.buildArgs(false), "--model", "claude-sonnet-4")
+
+	a = NewAmazonQAgent("q")
+	assertNotContainsArg(t, a.buildArgs(false), "--model")
+}
+
+func TestAmazonQAlias(t *testing.T) {
+	a, err := Get("q")
+	if err != nil {
+		t.Fatalf("Get(q) failed: %v", err)
+	}
+	if a.Name() != "amazon-q" {
+		t.Errorf("expected amazon-q, got %s", a.Name())
+	}
+}
+
+func TestAmazonQReviewPipesPromptViaStdin(t *testing.T) {
+	skipIfWindows(t)
+
+	mock := mockAgentCLI(t, MockCLIOpts{
+		CaptureArgs:  true,
+		CaptureStdin: true,
+		StdoutLines:  []string{"> No issues found."},
+	})
+
+	a := NewAmazonQAgent(mock.CmdPath)
+	prompt := "Review this commit carefully"
+	result, err := a.Review(context.Background(), t.TempDir(), "HEAD", prompt, nil)
+	if err != nil {
+		t.Fatalf("Review failed: %v", err)
+	}
+	if result != "No issues found." {
+		t.Errorf("result = %q, want %q", result, "No issues found.")
+	}
+
+	stdin, err := os.ReadFile(mock.StdinFile)
+	if err != nil {
+		t.Fatalf("read stdin capture: %v", err)
+	}
+	if strings.TrimSpace(string(stdin)) != prompt {
+		t.Errorf("stdin = %q, want %q", string(stdin), prompt)
+	}
+	args, err := os.ReadFile(mock.ArgsFile)
+	if err != nil {
+		t.Fatalf("read args capture: %v", err)
+	}
+	if strings.Contains(string(args), prompt) {
+		t.Errorf("prompt leaked into argv: %s", string(args))
+	}
+}
+
+func TestParseAmazonQOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "plain text",
+			raw:  "No issues found.\n",
+			want: "No issues found.",
+		},
+		{
+			name: "response marker and colors",
+			raw:  "\x1b[32m> \x1b[0mFound 1 issue:\n- \x1b[1mbug\x1b[0m in main.go\n",
+			want: "Found 1 issue:\n- bug in main.go",
+		},
+		{
+			name: "spinner overwritten by carriage returns",
+			raw:  "⠋ Thinking...\r⠙ Thinking...\r\x1b[2K\r\n> Looks good.\n",
+			want: "Looks good.",
+		},
+		{
+			name: "marker only stripped once",
+			raw:  "> summary\n> quoted text\n",
+			want: "summary\n> quoted text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAmazonQOutput(tt.raw); got != tt.want {
+				t.Errorf("parseAmazonQOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmazonQReviewFailure(t *testing.T) {
+	script := NewScriptBuilder().AddRaw(`echo "not logged in" >&2`).AddRaw("exit 1").Build()
+	a := NewAmazonQAgent(writeTempCommand(t, script))
+	_, err := a.Review(context.Background(), t.TempDir(), "HEAD", "prompt", nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "amazon-q failed") {
+		t.Fatalf("expected 'amazon-q failed' in error, got %v", err)
+	}
+}
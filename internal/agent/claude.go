@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"strings"
 	"sync"
@@ -95,12 +94,17 @@ func (a *ClaudeAgent) buildArgs(agenticMode bool) []string {
 		args = append(args, claudeDangerousFlag)
 		args = append(args, "--allowedTools", "Edit,MultiEdit,Write,Read,Glob,Grep,Bash")
 	} else {
-		// Review mode: read-only tools only (no Bash to prevent arbitrary command execution)
+		// Review mode: read-only tools only (no Bash to prevent arbitrary command execution).
+		// Denying the rest explicitly keeps them off even if user settings allow them.
 		args = append(args, "--allowedTools", "Read,Glob,Grep")
+		args = append(args, "--disallowedTools", claudeReviewDeniedTools)
 	}
 	return args
 }
 
+// claudeReviewDeniedTools are the tools review mode never lets Claude use
+const claudeReviewDeniedTools = "Bash,Edit,MultiEdit,Write,NotebookEdit,WebFetch,WebSearch"
+
 func claudeSupportsDangerousFlag(ctx context.Context, command string) (bool, error) {
 	if cached, ok := claudeDangerousSupport.Load(command); ok {
 		return cached.(bool), nil
@@ -115,44 +119,56 @@ func claudeSupportsDangerousFlag(ctx context.Context, command string) (bool, err
 	return supported, nil
 }
 
-func (a *ClaudeAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+// command builds the claude command with its environment prepared.
+// extraArgs are inserted after the standard flags.
+func (a *ClaudeAgent) command(ctx, procCtx context.Context, repoPath string, extraArgs ...string) (*exec.Cmd, error) {
 	// Use agentic mode if either per-job setting or global setting enables it
 	agenticMode := a.Agentic || AllowUnsafeAgents()
 
 	if agenticMode {
 		supported, err := claudeSupportsDangerousFlag(ctx, a.Command)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if !supported {
-			return "", fmt.Errorf("claude does not support %s; upgrade claude or disable allow_unsafe_agents", claudeDangerousFlag)
+			return nil, fmt.Errorf("claude does not support %s; upgrade claude or disable allow_unsafe_agents", claudeDangerousFlag)
 		}
 	}
 
 	// Build args - always uses stdin piping + stream-json for non-interactive execution
-	args := a.buildArgs(agenticMode)
+	args := append(a.buildArgs(agenticMode), extraArgs...)
 
-	cmd := exec.CommandContext(ctx, a.Command, args...)
-	cmd.Dir = repoPath
+	cmd, err := agentCommand(procCtx, a.Name(), repoPath, a.Command, args...)
+	if err != nil {
+		return nil, err
+	}
 	cmd.WaitDelay = 5 * time.Second
 
 	// Strip CLAUDECODE to prevent nested-session detection (#270),
 	// and handle API key (configured key or subscription auth).
 	stripKeys := []string{"ANTHROPIC_API_KEY", "CLAUDECODE"}
 	if apiKey := AnthropicAPIKey(); apiKey != "" {
-		cmd.Env = append(filterEnv(os.Environ(), stripKeys...), "ANTHROPIC_API_KEY="+apiKey)
+		cmd.Env = append(filterEnv(cmd.Env, stripKeys...), "ANTHROPIC_API_KEY="+apiKey)
 	} else {
-		cmd.Env = filterEnv(os.Environ(), stripKeys...)
+		cmd.Env = filterEnv(cmd.Env, stripKeys...)
 	}
 	// Suppress sounds from Claude Code (notification/completion sounds)
 	cmd.Env = append(cmd.Env, "CLAUDE_NO_SOUND=1")
+	return cmd, nil
+}
+
+func (a *ClaudeAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	cmd, err := a.command(ctx, ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
 
 	var stderr bytes.Buffer
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", fmt.Errorf("create stdout pipe: %w", err)
 	}
-	cmd.Stderr = &stderr
+	cmd.Stderr = traceStderr(ctx, &stderr)
 
 	// Always pipe prompt via stdin (stream-json mode)
 	cmd.Stdin = strings.NewReader(prompt)
@@ -162,7 +178,7 @@ func (a *ClaudeAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 	}
 
 	// Parse stream-json output
-	result, err := a.parseStreamJSON(stdoutPipe, output)
+	result, err := a.parseStreamJSON(ctx, stdoutPipe, output)
 
 	if waitErr := cmd.Wait(); waitErr != nil {
 		if err != nil {
@@ -178,23 +194,86 @@ func (a *ClaudeAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 	return result, nil
 }
 
-// claudeStreamMessage represents a message in Claude's stream-json output format
+// claudeStreamMessage represents a message in Claude's stream-json output
+// format. The final "result" message has the same fields as the single
+// object --output-format json prints.
 type claudeStreamMessage struct {
 	Type    string `json:"type"`
 	Subtype string `json:"subtype,omitempty"`
 	Message struct {
-		Content string `json:"content,omitempty"`
+		Content    claudeContent `json:"content,omitempty"`
+		StopReason string        `json:"stop_reason,omitempty"`
 	} `json:"message,omitempty"`
-	Result string `json:"result,omitempty"`
+	Result       string       `json:"result,omitempty"`
+	IsError      bool         `json:"is_error,omitempty"`
+	StopReason   string       `json:"stop_reason,omitempty"`
+	TotalCostUSD float64      `json:"total_cost_usd,omitempty"`
+	Usage        *claudeUsage `json:"usage,omitempty"`
 }
 
-// parseStreamJSON parses Claude's stream-json output and extracts the final result.
+// claudeUsage is the token usage in a result message
+type claudeUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+}
+
+// claudeContent is a message's text. Claude sends either a plain string or
+// a list of content blocks, of which only the text blocks are kept.
+type claudeContent string
+
+func (c *claudeContent) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*c = claudeContent(s)
+		return nil
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return err
+	}
+	var texts []string
+	for _, b := range blocks {
+		if b.Type == "text" && b.Text != "" {
+			texts = append(texts, b.Text)
+		}
+	}
+	*c = claudeContent(strings.Join(texts, "\n"))
+	return nil
+}
+
+// usageReport converts a result message's usage and stop reason. The stop
+// reason falls back to the last assistant message's, or to the result's
+// subtype for errors like "error_max_turns".
+func (m *claudeStreamMessage) usageReport(lastStopReason string) UsageReport {
+	r := UsageReport{CostUSD: m.TotalCostUSD, StopReason: m.StopReason}
+	if r.StopReason == "" {
+		r.StopReason = lastStopReason
+	}
+	if r.StopReason == "" && m.Subtype != "" && m.Subtype != "success" {
+		r.StopReason = m.Subtype
+	}
+	if m.Usage != nil {
+		r.InputTokens = m.Usage.InputTokens + m.Usage.CacheCreationInputTokens + m.Usage.CacheReadInputTokens
+		r.OutputTokens = m.Usage.OutputTokens
+	}
+	return r
+}
+
+// parseStreamJSON parses Claude's stream-json output and extracts the final
+// result, reporting its usage to any Usage attached to ctx.
 // Uses bufio.Reader.ReadString to read lines without buffer size limits.
-func (a *ClaudeAgent) parseStreamJSON(r io.Reader, output io.Writer) (string, error) {
+func (a *ClaudeAgent) parseStreamJSON(ctx context.Context, r io.Reader, output io.Writer) (string, error) {
 	br := bufio.NewReader(r)
 
 	var lastResult string
+	var resultErr error
 	var assistantMessages []string
+	var stopReason string
 	var validEventsParsed bool
 
 	for {
@@ -216,13 +295,23 @@ func (a *ClaudeAgent) parseStreamJSON(r io.Reader, output io.Writer) (string, er
 				validEventsParsed = true
 
 				// Collect assistant messages for the result
-				if msg.Type == "assistant" && msg.Message.Content != "" {
-					assistantMessages = append(assistantMessages, msg.Message.Content)
+				if msg.Type == "assistant" {
+					if msg.Message.Content != "" {
+						assistantMessages = append(assistantMessages, string(msg.Message.Content))
+					}
+					if msg.Message.StopReason != "" {
+						stopReason = msg.Message.StopReason
+					}
 				}
 
-				// The final result message contains the summary
-				if msg.Type == "result" && msg.Result != "" {
-					lastResult = msg.Result
+				// The final result message contains the summary and usage
+				if msg.Type == "result" {
+					usageFrom(ctx).add(msg.usageReport(stopReason))
+					if msg.IsError {
+						resultErr = fmt.Errorf("error result (%s): %s", msg.Subtype, msg.Result)
+					} else if msg.Result != "" {
+						lastResult = msg.Result
+					}
 				}
 			}
 			// Skip malformed JSON lines silently
@@ -237,6 +326,9 @@ func (a *ClaudeAgent) parseStreamJSON(r io.Reader, output io.Writer) (string, er
 	if !validEventsParsed {
 		return "", fmt.Errorf("no valid stream-json events parsed from output")
 	}
+	if resultErr != nil {
+		return "", resultErr
+	}
 
 	// Prefer the result field if present, otherwise join assistant messages
 	if lastResult != "" {
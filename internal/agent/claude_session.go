@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// claudeSession keeps one claude process in stream-json input mode and
+// sends it a user message per review
+type claudeSession struct {
+	commandLine []string
+	cancel      context.CancelFunc
+	stdin       io.WriteCloser
+	lines       chan string   // stdout lines, closed when the process exits
+	done        chan struct{} // closed once the process has been waited on
+	stderr      *ExecTrace    // tail of the process's stderr
+
+	mu      sync.Mutex // serializes prompts
+	closeMu sync.Mutex
+	closed  bool
+	waitErr error
+}
+
+// claudeUserMessage is a prompt in Claude's stream-json input format
+type claudeUserMessage struct {
+	Type    string `json:"type"`
+	Message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"message"`
+}
+
+// StartSession launches claude reading prompts as stream-json from stdin
+func (a *ClaudeAgent) StartSession(ctx context.Context, repoPath string) (Session, error) {
+	procCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	cmd, err := a.command(ctx, procCtx, repoPath, "--input-format", "stream-json")
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &claudeSession{
+		commandLine: cmd.Args,
+		cancel:      cancel,
+		lines:       make(chan string),
+		done:        make(chan struct{}),
+		stderr:      &ExecTrace{},
+	}
+	// The process outlives the job that started it, so its stderr goes to
+	// the session rather than that job's trace
+	cmd.Stderr = s.stderr
+	if s.stdin, err = cmd.StdinPipe(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("start claude: %w", err)
+	}
+
+	go func() {
+		defer close(s.done)
+		br := bufio.NewReader(stdout)
+		for {
+			line, err := br.ReadString('\n')
+			if line = strings.TrimSpace(line); line != "" {
+				select {
+				case s.lines <- line:
+				case <-procCtx.Done():
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		close(s.lines)
+		s.waitErr = cmd.Wait()
+	}()
+	return s, nil
+}
+
+func (s *claudeSession) Review(ctx context.Context, prompt string, output io.Writer) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t := execTraceFrom(ctx); t != nil {
+		t.start(s.commandLine)
+	}
+
+	msg := claudeUserMessage{Type: "user"}
+	msg.Message.Role = "user"
+	msg.Message.Content = prompt
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.stdin.Write(append(data, '\n')); err != nil {
+		return "", s.fail(ctx, fmt.Errorf("send prompt: %w", err))
+	}
+
+	sw := newSyncWriter(output)
+	var assistantMessages []string
+	var stopReason string
+	for {
+		select {
+		case <-ctx.Done():
+			// The process is still working on the prompt, so it can't be reused
+			s.Close()
+			return "", ctx.Err()
+		case line, ok := <-s.lines:
+			if !ok {
+				<-s.done
+				return "", s.fail(ctx, fmt.Errorf("claude session exited: %v", s.waitErr))
+			}
+			if sw != nil {
+				sw.Write([]byte(line + "\n"))
+			}
+
+			var ev claudeStreamMessage
+			if json.Unmarshal([]byte(line), &ev) != nil {
+				continue
+			}
+			if ev.Type == "assistant" {
+				if ev.Message.Content != "" {
+					assistantMessages = append(assistantMessages, string(ev.Message.Content))
+				}
+				if ev.Message.StopReason != "" {
+					stopReason = ev.Message.StopReason
+				}
+			}
+			if ev.Type != "result" {
+				continue
+			}
+			usageFrom(ctx).add(ev.usageReport(stopReason))
+			if ev.IsError {
+				return "", s.fail(ctx, fmt.Errorf("claude failed: %s", ev.Result))
+			}
+			if ev.Result != "" {
+				return ev.Result, nil
+			}
+			return strings.Join(assistantMessages, "\n"), nil
+		}
+	}
+}
+
+// fail copies the session's stderr into the job's trace and returns err
+// with it attached
+func (s *claudeSession) fail(ctx context.Context, err error) error {
+	stderr := s.stderr.Stderr()
+	if t := execTraceFrom(ctx); t != nil {
+		t.Write([]byte(stderr))
+	}
+	if stderr == "" {
+		return err
+	}
+	return fmt.Errorf("%w\nstderr: %s", err, stderr)
+}
+
+func (s *claudeSession) Close() error {
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.stdin.Close()
+	s.cancel()
+	// The process is killed on purpose, so its exit status isn't an error
+	<-s.done
+	return nil
+}
@@ -28,6 +28,7 @@ func TestClaudeBuildArgs(t *testing.T) {
 		assertContainsArg(t, args, req)
 	}
 	assertNotContainsArg(t, args, claudeDangerousFlag)
+	assertContainsArg(t, args, "--disallowedTools")
 	tools := toolsArgValue(t, args)
 	toolList := strings.Split(tools, ",")
 	for _, forb := range []string{"Edit", "Write", "Bash"} {
@@ -41,6 +42,7 @@ func TestClaudeBuildArgs(t *testing.T) {
 	// Agentic mode: write tools + dangerous flag
 	args = a.buildArgs(true)
 	assertContainsArg(t, args, claudeDangerousFlag)
+	assertNotContainsArg(t, args, "--disallowedTools")
 	assertContainsArg(t, args, "--allowedTools")
 	tools = toolsArgValue(t, args)
 	toolList = strings.Split(tools, ",")
@@ -130,6 +132,18 @@ still not json
 			input:       "",
 			expectedErr: "no valid stream-json events",
 		},
+		{
+			name: "ContentBlocks",
+			input: `{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read"},{"type":"text","text":"Looks good."}]}}
+`,
+			expectedResult: "Looks good.",
+		},
+		{
+			name: "ErrorResult",
+			input: `{"type":"result","subtype":"error_max_turns","is_error":true,"result":"Reached max turns"}
+`,
+			expectedErr: "error_max_turns",
+		},
 		{
 			name: "StreamsToOutput",
 			input: `{"type":"system","subtype":"init"}
@@ -146,7 +160,7 @@ still not json
 
 			if tt.expectOutput {
 				var out bytes.Buffer
-				res, err := a.parseStreamJSON(strings.NewReader(tt.input), &out)
+				res, err := a.parseStreamJSON(context.Background(), strings.NewReader(tt.input), &out)
 				if err != nil {
 					t.Fatalf("unexpected error: %v", err)
 				}
@@ -159,7 +173,7 @@ still not json
 				return
 			}
 
-			res, err := a.parseStreamJSON(strings.NewReader(tt.input), nil)
+			res, err := a.parseStreamJSON(context.Background(), strings.NewReader(tt.input), nil)
 
 			if tt.expectedErr != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
@@ -283,3 +297,65 @@ func TestFilterEnvExactMatchOnly(t *testing.T) {
 		}
 	}
 }
+
+func TestClaudeSessionReusesProcess(t *testing.T) {
+	withUnsafeAgents(t, false)
+
+	// Replies to each stream-json prompt with the process ID, so reuse is visible
+	cmdPath := writeTempCommand(t, `#!/bin/sh
+while read -r line; do
+  echo '{"type":"assistant","message":{"content":"thinking"}}'
+  echo "{\"type\":\"result\",\"result\":\"pid $$\"}"
+done
+`)
+
+	s, err := NewClaudeAgent(cmdPath).StartSession(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	defer s.Close()
+
+	first, err := s.Review(context.Background(), "first", nil)
+	if err != nil {
+		t.Fatalf("first Review: %v", err)
+	}
+	var out bytes.Buffer
+	second, err := s.Review(context.Background(), "second", &out)
+	if err != nil {
+		t.Fatalf("second Review: %v", err)
+	}
+	if !strings.HasPrefix(first, "pid ") || first != second {
+		t.Errorf("expected both prompts answered by one process, got %q and %q", first, second)
+	}
+	if !strings.Contains(out.String(), "thinking") {
+		t.Errorf("expected progress streamed to output, got %q", out.String())
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := s.Review(context.Background(), "third", nil); err == nil {
+		t.Error("expected error after Close")
+	}
+}
+
+func TestParseStreamJSONReportsUsage(t *testing.T) {
+	input := `{"type":"assistant","message":{"content":[{"type":"text","text":"Reviewing"}],"stop_reason":"tool_use"}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Done"}],"stop_reason":"end_turn"}}
+{"type":"result","subtype":"success","result":"Done","total_cost_usd":0.25,"usage":{"input_tokens":100,"cache_creation_input_tokens":20,"cache_read_input_tokens":30,"output_tokens":50}}
+`
+	usage := &Usage{}
+	ctx := WithUsage(context.Background(), usage)
+	if _, err := NewClaudeAgent("claude").parseStreamJSON(ctx, strings.NewReader(input), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, ok := usage.Report()
+	if !ok {
+		t.Fatal("expected usage to be reported")
+	}
+	want := UsageReport{InputTokens: 150, OutputTokens: 50, CostUSD: 0.25, StopReason: "end_turn"}
+	if report != want {
+		t.Errorf("usage = %+v, want %+v", report, want)
+	}
+}
@@ -194,8 +194,10 @@ func (a *CodexAgent) Review(ctx context.Context, repoPath, commitSHA, prompt str
 	// The prompt is piped via stdin using "-" to avoid command line length limits on Windows
 	args := a.buildArgs(repoPath, agenticMode, autoApprove)
 
-	cmd := exec.CommandContext(ctx, a.Command, args...)
-	cmd.Dir = repoPath
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command, args...)
+	if err != nil {
+		return "", err
+	}
 	cmd.WaitDelay = 5 * time.Second
 
 	// Pipe prompt via stdin to avoid command line length limits on Windows.
@@ -212,9 +214,9 @@ func (a *CodexAgent) Review(ctx context.Context, repoPath, commitSHA, prompt str
 	}
 	// Tee stderr to output writer for live error visibility
 	if sw != nil {
-		cmd.Stderr = io.MultiWriter(&stderr, sw)
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
 	} else {
-		cmd.Stderr = &stderr
+		cmd.Stderr = traceStderr(ctx, &stderr)
 	}
 
 	if err := cmd.Start(); err != nil {
@@ -239,7 +241,7 @@ func (a *CodexAgent) Review(ctx context.Context, repoPath, commitSHA, prompt str
 	}
 
 	if result == "" {
-		return "No review output generated", nil
+		return NoOutput, nil
 	}
 
 	return result, nil
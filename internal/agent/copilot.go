@@ -5,11 +5,17 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 )
 
-// CopilotAgent runs code reviews using the GitHub Copilot CLI
+// CopilotAgent runs code reviews using the GitHub Copilot CLI.
+// When the standalone copilot binary isn't installed, it runs through
+// `gh copilot` so users with Copilot entitlements via the GitHub CLI
+// don't need a separate install.
 type CopilotAgent struct {
 	Command   string         // The copilot command to run (default: "copilot")
 	Model     string         // Model to use
@@ -65,34 +71,77 @@ func (a *CopilotAgent) Name() string {
 }
 
 func (a *CopilotAgent) CommandName() string {
-	return a.Command
+	name, _ := a.resolveCommand()
+	return name
 }
 
 func (a *CopilotAgent) CommandLine() string {
+	name, args := a.resolveCommand()
+	args = append(args, a.buildArgs()...)
+	return name + " " + strings.Join(args, " ")
+}
+
+// resolveCommand returns the executable and any leading arguments needed to
+// invoke Copilot. An explicitly configured command is always used as-is;
+// the default falls back to `gh copilot --` when only gh is on PATH and
+// its copilot command works.
+func (a *CopilotAgent) resolveCommand() (string, []string) {
+	if a.Command != "copilot" {
+		return a.Command, nil
+	}
+	if _, err := exec.LookPath("copilot"); err == nil {
+		return a.Command, nil
+	}
+	if gh, err := exec.LookPath("gh"); err == nil && ghHasCopilot(gh) {
+		// Everything after -- is passed through to the Copilot CLI
+		return "gh", []string{"copilot", "--"}
+	}
+	return a.Command, nil
+}
+
+// ghCopilotSupport caches whether each gh binary can run Copilot
+var ghCopilotSupport sync.Map
+
+// ghHasCopilot reports whether `gh copilot` works with the gh at path,
+// which it doesn't for GitHub CLI users without the Copilot extension
+func ghHasCopilot(path string) bool {
+	if cached, ok := ghCopilotSupport.Load(path); ok {
+		return cached.(bool)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "copilot", "--version")
+	cmd.Env = append(os.Environ(), "GH_PROMPT_DISABLED=1")
+	supported := cmd.Run() == nil
+	ghCopilotSupport.Store(path, supported)
+	return supported
+}
+
+func (a *CopilotAgent) buildArgs() []string {
 	var args []string
 	if a.Model != "" {
 		args = append(args, "--model", a.Model)
 	}
-	return a.Command + " " + strings.Join(args, " ")
+	return args
 }
 
 func (a *CopilotAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
-	args := []string{}
-	if a.Model != "" {
-		args = append(args, "--model", a.Model)
-	}
+	name, args := a.resolveCommand()
+	args = append(args, a.buildArgs()...)
 
-	cmd := exec.CommandContext(ctx, a.Command, args...)
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, name, args...)
+	if err != nil {
+		return "", err
+	}
 	cmd.Stdin = strings.NewReader(prompt)
-	cmd.Dir = repoPath
 
 	var stdout, stderr bytes.Buffer
 	if sw := newSyncWriter(output); sw != nil {
 		cmd.Stdout = io.MultiWriter(&stdout, sw)
-		cmd.Stderr = io.MultiWriter(&stderr, sw)
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
 	} else {
 		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
+		cmd.Stderr = traceStderr(ctx, &stderr)
 	}
 
 	if err := cmd.Run(); err != nil {
@@ -101,7 +150,7 @@ func (a *CopilotAgent) Review(ctx context.Context, repoPath, commitSHA, prompt s
 
 	result := stdout.String()
 	if len(result) == 0 {
-		return "No review output generated", nil
+		return NoOutput, nil
 	}
 
 	return result, nil
@@ -43,3 +43,62 @@ func TestCopilotReviewPipesPromptViaStdin(t *testing.T) {
 		t.Errorf("prompt leaked into argv: %s", string(args))
 	}
 }
+
+func TestCopilotFallsBackToGhCopilot(t *testing.T) {
+	skipIfWindows(t)
+
+	// Only gh is on PATH; it echoes its argv and stdin
+	binDir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$*\" = \"copilot --version\" ] && exit 0\necho \"args: $*\"\nwhile read -r line || [ -n \"$line\" ]; do echo \"$line\"; done\n"
+	if err := os.WriteFile(binDir+"/gh", []byte(script), 0755); err != nil {
+		t.Fatalf("write fake gh: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	a := NewCopilotAgent("").WithModel("gpt-5").(*CopilotAgent)
+	if got := a.CommandName(); got != "gh" {
+		t.Errorf("CommandName() = %q, want %q", got, "gh")
+	}
+	if got := a.CommandLine(); got != "gh copilot -- --model gpt-5" {
+		t.Errorf("CommandLine() = %q", got)
+	}
+
+	result, err := a.Review(context.Background(), t.TempDir(), "HEAD", "review prompt", nil)
+	if err != nil {
+		t.Fatalf("Review failed: %v", err)
+	}
+	if !strings.Contains(result, "args: copilot -- --model gpt-5") {
+		t.Errorf("expected gh copilot invocation, got %q", result)
+	}
+	if !strings.Contains(result, "review prompt") {
+		t.Errorf("expected prompt on stdin, got %q", result)
+	}
+}
+
+func TestCopilotNeedsGhCopilotExtension(t *testing.T) {
+	skipIfWindows(t)
+
+	// gh is on PATH, but without Copilot
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho \"unknown command $1 for gh\" >&2\nexit 1\n"
+	if err := os.WriteFile(binDir+"/gh", []byte(script), 0755); err != nil {
+		t.Fatalf("write fake gh: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	if got := NewCopilotAgent("").CommandName(); got != "copilot" {
+		t.Errorf("CommandName() = %q, want %q", got, "copilot")
+	}
+	if IsAvailable("copilot") {
+		t.Error("copilot should not be available through gh without the extension")
+	}
+}
+
+func TestCopilotExplicitCommandIsNotRewritten(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	a := NewCopilotAgent("/opt/bin/copilot")
+	if got := a.CommandName(); got != "/opt/bin/copilot" {
+		t.Errorf("CommandName() = %q, want explicit command", got)
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"os/exec"
 	"strings"
 	"time"
 )
@@ -109,9 +107,10 @@ func (a *CursorAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 
 	args := a.buildArgs(agenticMode)
 
-	cmd := exec.CommandContext(ctx, a.Command, args...)
-	cmd.Dir = repoPath
-	cmd.Env = os.Environ()
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command, args...)
+	if err != nil {
+		return "", err
+	}
 	cmd.WaitDelay = 5 * time.Second
 	cmd.Stdin = strings.NewReader(prompt)
 
@@ -120,7 +119,7 @@ func (a *CursorAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 	if err != nil {
 		return "", fmt.Errorf("create stdout pipe: %w", err)
 	}
-	cmd.Stderr = &stderr
+	cmd.Stderr = traceStderr(ctx, &stderr)
 
 	if err := cmd.Start(); err != nil {
 		return "", fmt.Errorf("start cursor agent: %w", err)
@@ -128,7 +127,7 @@ func (a *CursorAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 
 	// Reuse Claude's stream-json parser (same format)
 	claude := &ClaudeAgent{}
-	result, err := claude.parseStreamJSON(stdoutPipe, output)
+	result, err := claude.parseStreamJSON(ctx, stdoutPipe, output)
 
 	if waitErr := cmd.Wait(); waitErr != nil {
 		if err != nil {
@@ -90,7 +90,7 @@ func TestCursorParseStreamJSON(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			claude := &ClaudeAgent{}
-			res, err := claude.parseStreamJSON(strings.NewReader(tt.input), nil)
+			res, err := claude.parseStreamJSON(context.Background(), strings.NewReader(tt.input), nil)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
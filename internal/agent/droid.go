@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 )
 
@@ -101,16 +100,18 @@ func (a *DroidAgent) Review(ctx context.Context, repoPath, commitSHA, prompt str
 
 	args := a.buildArgs(agenticMode)
 
-	cmd := exec.CommandContext(ctx, a.Command, args...)
-	cmd.Dir = repoPath
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command, args...)
+	if err != nil {
+		return "", err
+	}
 	cmd.Stdin = strings.NewReader(prompt)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	if sw := newSyncWriter(output); sw != nil {
-		cmd.Stderr = io.MultiWriter(&stderr, sw)
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
 	} else {
-		cmd.Stderr = &stderr
+		cmd.Stderr = traceStderr(ctx, &stderr)
 	}
 
 	if err := cmd.Run(); err != nil {
@@ -119,7 +120,7 @@ func (a *DroidAgent) Review(ctx context.Context, repoPath, commitSHA, prompt str
 
 	result := stdout.String()
 	if len(result) == 0 {
-		return "No review output generated", nil
+		return NoOutput, nil
 	}
 
 	return result, nil
@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 	"time"
 )
@@ -115,8 +114,10 @@ func (a *GeminiAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 	agenticMode := a.Agentic || AllowUnsafeAgents()
 	args := a.buildArgs(agenticMode)
 
-	cmd := exec.CommandContext(ctx, a.Command, args...)
-	cmd.Dir = repoPath
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command, args...)
+	if err != nil {
+		return "", err
+	}
 	cmd.WaitDelay = 5 * time.Second
 
 	// Pipe prompt via stdin
@@ -132,9 +133,9 @@ func (a *GeminiAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 	}
 	// Tee stderr to output writer for live error visibility
 	if sw != nil {
-		cmd.Stderr = io.MultiWriter(&stderr, sw)
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
 	} else {
-		cmd.Stderr = &stderr
+		cmd.Stderr = traceStderr(ctx, &stderr)
 	}
 
 	if err := cmd.Start(); err != nil {
@@ -162,7 +163,7 @@ func (a *GeminiAgent) Review(ctx context.Context, repoPath, commitSHA, prompt st
 		return parsed.result, nil
 	}
 
-	return "No review output generated", nil
+	return NoOutput, nil
 }
 
 // geminiStreamMessage represents a message in Gemini's stream-json output format
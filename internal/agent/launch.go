@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Sandbox restricts the environment agent subprocesses run in
+type Sandbox struct {
+	// EnvAllowlist, when set, limits the environment to these variables
+	// plus the baseline ones agents need to run (PATH, HOME, ...)
+	EnvAllowlist []string
+	// EnvDenylist removes variables from the environment
+	EnvDenylist []string
+	// NoNetwork runs agents without network access, using firejail on
+	// Linux and sandbox-exec on macOS
+	NoNetwork bool
+}
+
+var sandbox atomic.Value
+
+// CurrentSandbox returns the sandbox applied to agent subprocesses
+func CurrentSandbox() Sandbox {
+	if v := sandbox.Load(); v != nil {
+		return v.(Sandbox)
+	}
+	return Sandbox{}
+}
+
+// SetSandbox sets the sandbox applied to agent subprocesses
+func SetSandbox(s Sandbox) {
+	sandbox.Store(s)
+}
+
+// baselineEnv lists variables kept by an env allowlist, since agents can't
+// find their binaries, config, or credentials without them. A trailing *
+// matches a prefix.
+var baselineEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "LC_*",
+	"TMPDIR", "TMP", "TEMP", "XDG_*",
+	// Windows
+	"SYSTEMROOT", "SYSTEMDRIVE", "COMSPEC", "PATHEXT", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+}
+
+// macOSNoNetworkProfile is the sandbox-exec profile for NoNetwork. Unix
+// sockets stay allowed so agents can still talk to helper processes, but
+// loopback TCP is blocked, as it is in firejail's empty network namespace.
+const macOSNoNetworkProfile = `(version 1)(allow default)(deny network-outbound (remote ip))(deny network-inbound (local ip))`
+
+var killProcessTree atomic.Bool
+
+// SetKillProcessTree sets whether canceling an agent kills every process it
+// started rather than just the agent itself. On Unix this runs agents in
+// their own process group, which stops them receiving terminal signals, so
+// only the daemon enables it.
+func SetKillProcessTree(enabled bool) {
+	killProcessTree.Store(enabled)
+}
+
+// hostedAgents are the built-in agents whose CLIs always send the prompt
+// to a hosted model API. aider and opencode can be pointed at other models
+// and plugins are unknown, so those are left to the user.
+var hostedAgents = map[string]bool{
+	"codex": true, "claude-code": true, "gemini": true, "copilot": true,
+	"cursor": true, "droid": true, "amazon-q": true,
+}
+
+// NeedsNetwork reports whether the named agent can't work without network
+// access. Supports aliases like "claude" for "claude-code".
+func NeedsNetwork(name string) bool {
+	return hostedAgents[resolveAlias(name)]
+}
+
+// CheckSandbox returns an error if the current sandbox stops the named
+// agent from working
+func CheckSandbox(name string) error {
+	if CurrentSandbox().NoNetwork && NeedsNetwork(name) {
+		return fmt.Errorf("sandbox no_network blocks %s, which needs network access to reach its hosted model; use aider, opencode, or a plugin agent with an offline model, or turn no_network off", resolveAlias(name))
+	}
+	return nil
+}
+
+// MaxAgentArgsLen caps the total length of an agent's arguments. Windows
+// limits a command line to 32K characters and Linux a single argument to
+// 128KB, so prompts must go through stdin or a temp file instead.
+const MaxAgentArgsLen = 30000
+
+// agentCommand builds a subprocess for the agent named agentName that runs
+// name with args in repoPath under the current sandbox. Every agent
+// launches through here so sandbox settings apply uniformly.
+func agentCommand(ctx context.Context, agentName, repoPath, name string, args ...string) (*exec.Cmd, error) {
+	argsLen := 0
+	for _, arg := range args {
+		argsLen += len(arg) + 1
+	}
+	if argsLen > MaxAgentArgsLen {
+		return nil, fmt.Errorf("%s arguments are %d bytes (max %d); pass large input via stdin or a file", name, argsLen, MaxAgentArgsLen)
+	}
+
+	if err := CheckSandbox(agentName); err != nil {
+		return nil, err
+	}
+	sb := CurrentSandbox()
+	if sb.NoNetwork {
+		wrapper, wrapperArgs, err := noNetworkWrapper()
+		if err != nil {
+			return nil, err
+		}
+		args = append(append(wrapperArgs, name), args...)
+		name = wrapper
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = repoPath
+	cmd.Env = sb.filterEnv(os.Environ())
+	if killProcessTree.Load() {
+		killTreeOnCancel(cmd)
+	}
+	if t := execTraceFrom(ctx); t != nil {
+		t.start(cmd.Args)
+	}
+	return cmd, nil
+}
+
+// MaxExecTraceStderr caps the stderr an ExecTrace keeps. The end of the
+// stream is kept since that is where errors usually are.
+const MaxExecTraceStderr = 256 * 1024
+
+// ExecTrace records how the most recent agent subprocess launched under a
+// context ran, so failures can be diagnosed after the fact
+type ExecTrace struct {
+	mu          sync.Mutex
+	commandLine string
+	stderr      []byte
+}
+
+type execTraceKey struct{}
+
+// WithExecTrace returns a context under which agent subprocesses record
+// their command line and stderr into t
+func WithExecTrace(ctx context.Context, t *ExecTrace) context.Context {
+	return context.WithValue(ctx, execTraceKey{}, t)
+}
+
+func execTraceFrom(ctx context.Context) *ExecTrace {
+	t, _ := ctx.Value(execTraceKey{}).(*ExecTrace)
+	return t
+}
+
+// CommandLine returns the command line of the most recent subprocess
+func (t *ExecTrace) CommandLine() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.commandLine
+}
+
+// Stderr returns the captured stderr of the most recent subprocess
+func (t *ExecTrace) Stderr() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.stderr)
+}
+
+// start resets the trace for a new subprocess
+func (t *ExecTrace) start(args []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.commandLine = strings.Join(args, " ")
+	t.stderr = nil
+}
+
+func (t *ExecTrace) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stderr = append(t.stderr, p...)
+	if over := len(t.stderr) - MaxExecTraceStderr; over > 0 {
+		t.stderr = append(t.stderr[:0], t.stderr[over:]...)
+	}
+	return len(p), nil
+}
+
+// traceStderr adds the context's ExecTrace, if any, as a destination for
+// an agent's stderr
+func traceStderr(ctx context.Context, w io.Writer) io.Writer {
+	if t := execTraceFrom(ctx); t != nil {
+		return io.MultiWriter(w, t)
+	}
+	return w
+}
+
+// noNetworkWrapper returns the command and leading arguments that run a
+// program without network access on this platform
+func noNetworkWrapper() (string, []string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("firejail"); err != nil {
+			return "", nil, fmt.Errorf("sandbox no_network requires firejail, which was not found in PATH")
+		}
+		return "firejail", []string{"--quiet", "--net=none", "--"}, nil
+	case "darwin":
+		if _, err := exec.LookPath("sandbox-exec"); err != nil {
+			return "", nil, fmt.Errorf("sandbox no_network requires sandbox-exec, which was not found in PATH")
+		}
+		return "sandbox-exec", []string{"-p", macOSNoNetworkProfile}, nil
+	default:
+		return "", nil, fmt.Errorf("sandbox no_network is not supported on %s", runtime.GOOS)
+	}
+}
+
+// filterEnv applies the allowlist and denylist to env
+func (s Sandbox) filterEnv(env []string) []string {
+	if len(s.EnvAllowlist) == 0 && len(s.EnvDenylist) == 0 {
+		return env
+	}
+	result := make([]string, 0, len(env))
+	for _, e := range env {
+		k, _, _ := strings.Cut(e, "=")
+		if len(s.EnvAllowlist) > 0 && !matchEnvName(k, baselineEnv) && !matchEnvName(k, s.EnvAllowlist) {
+			continue
+		}
+		if matchEnvName(k, s.EnvDenylist) {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// matchEnvName reports whether name matches any pattern. Patterns match
+// exactly, or by prefix when they end in *. Windows names are case
+// insensitive.
+func matchEnvName(name string, patterns []string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, p := range patterns {
+		if runtime.GOOS == "windows" {
+			p = strings.ToUpper(p)
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandboxFilterEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "HOME=/home/u", "LC_ALL=C", "AWS_SECRET_ACCESS_KEY=s", "AWS_REGION=us", "GITHUB_TOKEN=t", "EDITOR=vi"}
+
+	tests := []struct {
+		name string
+		sb   Sandbox
+		want []string
+	}{
+		{"no sandbox", Sandbox{}, env},
+		{"denylist prefix", Sandbox{EnvDenylist: []string{"AWS_*", "GITHUB_TOKEN"}},
+			[]string{"PATH=/usr/bin", "HOME=/home/u", "LC_ALL=C", "EDITOR=vi"}},
+		{"allowlist keeps baseline", Sandbox{EnvAllowlist: []string{"EDITOR"}},
+			[]string{"PATH=/usr/bin", "HOME=/home/u", "LC_ALL=C", "EDITOR=vi"}},
+		{"denylist wins over allowlist", Sandbox{EnvAllowlist: []string{"AWS_*"}, EnvDenylist: []string{"AWS_SECRET_ACCESS_KEY"}},
+			[]string{"PATH=/usr/bin", "HOME=/home/u", "LC_ALL=C", "AWS_REGION=us"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sb.filterEnv(env)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filterEnv = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgentCommandAppliesSandbox(t *testing.T) {
+	t.Cleanup(func() { SetSandbox(Sandbox{}) })
+	t.Setenv("ROBOREV_TEST_SECRET", "hunter2")
+	dir := t.TempDir()
+
+	SetSandbox(Sandbox{EnvDenylist: []string{"ROBOREV_TEST_*"}})
+	cmd, err := agentCommand(context.Background(), "test", dir, "echo", "hi")
+	if err != nil {
+		t.Fatalf("agentCommand: %v", err)
+	}
+	if cmd.Dir != dir {
+		t.Errorf("Dir = %q, want %q", cmd.Dir, dir)
+	}
+	for _, e := range cmd.Env {
+		if strings.HasPrefix(e, "ROBOREV_TEST_SECRET=") {
+			t.Errorf("denylisted variable passed to agent: %s", e)
+		}
+	}
+
+	t.Run("no network wraps command", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("wrapper selection tested on linux")
+		}
+		SetSandbox(Sandbox{NoNetwork: true})
+		cmd, err := agentCommand(context.Background(), "aider", dir, "aider", "--yes")
+		if _, lookErr := exec.LookPath("firejail"); lookErr != nil {
+			if err == nil || !strings.Contains(err.Error(), "firejail") {
+				t.Errorf("expected missing firejail error, got %v", err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("agentCommand: %v", err)
+		}
+		if got := strings.Join(cmd.Args, " "); got != "firejail --quiet --net=none -- aider --yes" {
+			t.Errorf("Args = %q", got)
+		}
+	})
+
+	t.Run("no network refuses hosted agents", func(t *testing.T) {
+		SetSandbox(Sandbox{NoNetwork: true})
+		_, err := agentCommand(context.Background(), "claude", dir, "claude", "-p")
+		if err == nil || !strings.Contains(err.Error(), "blocks claude-code") {
+			t.Errorf("expected hosted agent to be refused, got %v", err)
+		}
+	})
+}
+
+func TestExecTraceRecordsCommandAndStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	trace := &ExecTrace{}
+	ctx := WithExecTrace(context.Background(), trace)
+
+	cmd, err := agentCommand(ctx, "test", t.TempDir(), "sh", "-c", "echo boom >&2; exit 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = traceStderr(ctx, &stderr)
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("expected exit code 3, got %v", err)
+	}
+	if got := trace.CommandLine(); got != "sh -c echo boom >&2; exit 3" {
+		t.Errorf("CommandLine = %q", got)
+	}
+	if trace.Stderr() != "boom\n" || stderr.String() != "boom\n" {
+		t.Errorf("stderr: trace %q, agent %q", trace.Stderr(), stderr.String())
+	}
+}
+
+func TestExecTraceKeepsStderrTail(t *testing.T) {
+	trace := &ExecTrace{}
+	trace.Write([]byte(strings.Repeat("a", MaxExecTraceStderr)))
+	trace.Write([]byte("end"))
+	got := trace.Stderr()
+	if len(got) != MaxExecTraceStderr || !strings.HasSuffix(got, "end") {
+		t.Errorf("got %d bytes ending %q", len(got), got[len(got)-3:])
+	}
+}
+
+func TestAgentCommandKillsProcessTree(t *testing.T) {
+	// The background child inherits stdout, so Wait can't return until it
+	// is gone too
+	cmdPath := writeTempCommand(t, "#!/bin/sh\nsleep 60 &\necho started\nwait\n")
+	SetKillProcessTree(true)
+	t.Cleanup(func() { SetKillProcessTree(false) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd, err := agentCommand(ctx, "test", t.TempDir(), cmdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len("started"))
+	if _, err := stdout.Read(buf); err != nil {
+		t.Fatalf("waiting for command to start: %v", err)
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, stdout)
+		_ = cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("background child survived cancellation")
+	}
+}
+
+func TestAgentCommandRejectsLongArgs(t *testing.T) {
+	_, err := agentCommand(context.Background(), "codex", t.TempDir(), "codex", "exec", strings.Repeat("x", MaxAgentArgsLen))
+	if err == nil || !strings.Contains(err.Error(), "stdin") {
+		t.Fatalf("expected argument length error, got %v", err)
+	}
+}
+
+func TestAgentsPassLargePromptsOutsideArgs(t *testing.T) {
+	withUnsafeAgents(t, false)
+
+	// Larger than both the Windows command line and a Linux argument limit
+	prompt := strings.Repeat("review this line. ", 250*1024/18)
+
+	// Records the arguments, and the prompt from stdin or aider's --message-file
+	script := `#!/bin/sh
+if [ "$1" = "--help" ]; then echo "` + codexAutoApproveFlag + ` ` + codexDangerousFlag + `"; exit 0; fi
+printf '%s\n' "$@" > "$(dirname "$0")/args.txt"
+prev=""
+for a in "$@"; do
+  if [ "$prev" = "--message-file" ]; then cp "$a" "$(dirname "$0")/prompt.txt"; fi
+  prev="$a"
+done
+if [ ! -f "$(dirname "$0")/prompt.txt" ]; then cat > "$(dirname "$0")/prompt.txt"; fi
+`
+	agents := map[string]func(string) Agent{
+		"codex":    func(p string) Agent { return NewCodexAgent(p) },
+		"claude":   func(p string) Agent { return NewClaudeAgent(p) },
+		"gemini":   func(p string) Agent { return NewGeminiAgent(p) },
+		"copilot":  func(p string) Agent { return NewCopilotAgent(p) },
+		"opencode": func(p string) Agent { return NewOpenCodeAgent(p) },
+		"cursor":   func(p string) Agent { return NewCursorAgent(p) },
+		"droid":    func(p string) Agent { return NewDroidAgent(p) },
+		"aider":    func(p string) Agent { return NewAiderAgent(p) },
+		"amazon-q": func(p string) Agent { return NewAmazonQAgent(p) },
+		"plugin":   func(p string) Agent { return NewPluginAgent("plugin", p) },
+	}
+	for name, newAgent := range agents {
+		t.Run(name, func(t *testing.T) {
+			cmdPath := writeTempCommand(t, script)
+			dir := filepath.Dir(cmdPath)
+
+			// Output parsing may fail on the empty reply; only delivery matters here
+			newAgent(cmdPath).Review(context.Background(), t.TempDir(), "deadbeef", prompt, nil)
+
+			args, err := os.ReadFile(filepath.Join(dir, "args.txt"))
+			if err != nil {
+				t.Fatalf("agent was not run: %v", err)
+			}
+			if strings.Contains(string(args), "review this line") {
+				t.Error("prompt was passed as an argument")
+			}
+			got, err := os.ReadFile(filepath.Join(dir, "prompt.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), prompt) {
+				t.Errorf("agent received %d prompt bytes, want %d", len(got), len(prompt))
+			}
+		})
+	}
+}
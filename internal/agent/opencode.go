@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 )
 
@@ -128,17 +127,19 @@ func (a *OpenCodeAgent) Review(ctx context.Context, repoPath, commitSHA, prompt
 		args = append(args, "--model", a.Model)
 	}
 
-	cmd := exec.CommandContext(ctx, a.Command, args...)
-	cmd.Dir = repoPath
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command, args...)
+	if err != nil {
+		return "", err
+	}
 	cmd.Stdin = strings.NewReader(prompt)
 
 	var stdout, stderr bytes.Buffer
 	if sw := newSyncWriter(output); sw != nil {
 		cmd.Stdout = io.MultiWriter(&stdout, sw)
-		cmd.Stderr = io.MultiWriter(&stderr, sw)
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
 	} else {
 		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
+		cmd.Stderr = traceStderr(ctx, &stderr)
 	}
 
 	if err := cmd.Run(); err != nil {
@@ -153,7 +154,7 @@ func (a *OpenCodeAgent) Review(ctx context.Context, repoPath, commitSHA, prompt
 
 	result := filterOpencodeToolCallLines(stdout.String())
 	if len(result) == 0 {
-		return "No review output generated", nil
+		return NoOutput, nil
 	}
 	return result, nil
 }
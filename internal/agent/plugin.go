@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// PluginProtocolVersion is the version of the request sent to plugin agents
+const PluginProtocolVersion = 1
+
+// pluginNamePattern restricts plugin agent names to what can be typed in
+// --agent and config files
+var pluginNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// PluginAgent runs an external executable that speaks the plugin protocol:
+// it receives one JSON request on stdin, runs in the repo directory, and
+// writes one JSON response to stdout. Anything written to stderr is shown
+// as progress.
+type PluginAgent struct {
+	PluginName string         // Agent name (the executable's file name without extension)
+	Command    string         // Absolute path to the executable
+	Model      string         // Model to pass through to the plugin
+	Reasoning  ReasoningLevel // Reasoning level to pass through to the plugin
+	Agentic    bool           // Whether agentic mode is enabled (allow file edits)
+}
+
+// pluginRequest is what a plugin agent reads from stdin
+type pluginRequest struct {
+	Version   int    `json:"version"`
+	RepoPath  string `json:"repo_path"`
+	CommitSHA string `json:"commit_sha"`
+	Prompt    string `json:"prompt"`
+	Model     string `json:"model,omitempty"`
+	Reasoning string `json:"reasoning"`
+	Agentic   bool   `json:"agentic"`
+}
+
+// pluginResponse is what a plugin agent writes to stdout
+type pluginResponse struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NewPluginAgent creates an agent for the plugin executable at path
+func NewPluginAgent(name, path string) *PluginAgent {
+	return &PluginAgent{PluginName: name, Command: path, Reasoning: ReasoningStandard}
+}
+
+// WithReasoning returns a copy of the agent with the specified reasoning level
+func (a *PluginAgent) WithReasoning(level ReasoningLevel) Agent {
+	c := *a
+	c.Reasoning = level
+	return &c
+}
+
+// WithAgentic returns a copy of the agent configured for agentic mode.
+func (a *PluginAgent) WithAgentic(agentic bool) Agent {
+	c := *a
+	c.Agentic = agentic
+	return &c
+}
+
+// WithModel returns a copy of the agent configured to use the specified model.
+func (a *PluginAgent) WithModel(model string) Agent {
+	if model == "" {
+		return a
+	}
+	c := *a
+	c.Model = model
+	return &c
+}
+
+func (a *PluginAgent) Name() string {
+	return a.PluginName
+}
+
+func (a *PluginAgent) CommandName() string {
+	return a.Command
+}
+
+func (a *PluginAgent) CommandLine() string {
+	return a.Command
+}
+
+func (a *PluginAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	req, err := json.Marshal(pluginRequest{
+		Version:   PluginProtocolVersion,
+		RepoPath:  repoPath,
+		CommitSHA: commitSHA,
+		Prompt:    prompt,
+		Model:     a.Model,
+		Reasoning: string(a.Reasoning),
+		Agentic:   a.Agentic || AllowUnsafeAgents(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	cmd, err := agentCommand(ctx, a.Name(), repoPath, a.Command)
+	if err != nil {
+		return "", err
+	}
+	cmd.WaitDelay = 5 * time.Second
+	cmd.Stdin = bytes.NewReader(req)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	if sw := newSyncWriter(output); sw != nil {
+		cmd.Stderr = traceStderr(ctx, io.MultiWriter(&stderr, sw))
+	} else {
+		cmd.Stderr = traceStderr(ctx, &stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w\nstderr: %s", a.PluginName, err, truncateStderr(stderr.String()))
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &resp); err != nil {
+		return "", fmt.Errorf("%s: invalid plugin response: %w", a.PluginName, err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("%s: %s", a.PluginName, resp.Error)
+	}
+	if resp.Output == "" {
+		return NoOutput, nil
+	}
+	return resp.Output, nil
+}
+
+// LoadPlugins registers each executable in dir as a plugin agent named
+// after its file name without extension, and returns the registered names.
+// A missing dir is not an error. Plugins that can't be registered, such as
+// ones named after a built-in agent, are skipped and reported in the
+// returned error.
+func LoadPlugins(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read agent plugins: %w", err)
+	}
+
+	var names []string
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if !isExecutable(path) {
+			continue
+		}
+		if !pluginNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("agent plugin %s: invalid name %q", path, name))
+			continue
+		}
+		if _, exists := registry[resolveAlias(name)]; exists {
+			errs = append(errs, fmt.Errorf("agent plugin %s: agent %q already exists", path, name))
+			continue
+		}
+		Register(NewPluginAgent(name, path))
+		names = append(names, name)
+	}
+	return names, errors.Join(errs...)
+}
+
+// isExecutable reports whether path is a regular file the user can run
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return info.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPlugins(t *testing.T) {
+	skipIfWindows(t)
+
+	dir := t.TempDir()
+	write := func(name string, mode os.FileMode) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("my-agent.sh", 0755)
+	write("notes.txt", 0644)
+	write(".hidden", 0755)
+	write("codex", 0755)
+	t.Cleanup(func() { delete(registry, "my-agent") })
+
+	names, err := LoadPlugins(dir)
+	if len(names) != 1 || names[0] != "my-agent" {
+		t.Errorf("registered %q, want [my-agent]", names)
+	}
+	if err == nil || !strings.Contains(err.Error(), `"codex" already exists`) {
+		t.Errorf("expected collision with built-in agent to be reported, got %v", err)
+	}
+
+	a, getErr := Get("my-agent")
+	if getErr != nil {
+		t.Fatalf("Get: %v", getErr)
+	}
+	if _, ok := a.(*PluginAgent); !ok {
+		t.Errorf("expected *PluginAgent, got %T", a)
+	}
+	if !IsAvailable("my-agent") {
+		t.Error("expected plugin agent to be available")
+	}
+
+	if names, err := LoadPlugins(filepath.Join(dir, "missing")); names != nil || err != nil {
+		t.Errorf("missing dir: got %q, %v", names, err)
+	}
+}
+
+func TestPluginAgentReview(t *testing.T) {
+	// Saves the request next to itself and reports progress on stderr
+	cmdPath := writeTempCommand(t, `#!/bin/sh
+cat > "$(dirname "$0")/request.json"
+echo "working" >&2
+echo '{"output": "Looks good"}'
+`)
+	repoPath := t.TempDir()
+
+	a := NewPluginAgent("mine", cmdPath).WithModel("big").WithReasoning(ReasoningFast)
+	var progress bytes.Buffer
+	result, err := a.Review(context.Background(), repoPath, "abc123", "review this", &progress)
+	if err != nil {
+		t.Fatalf("Review: %v", err)
+	}
+	if result != "Looks good" {
+		t.Errorf("result = %q", result)
+	}
+	if !strings.Contains(progress.String(), "working") {
+		t.Errorf("expected stderr streamed as progress, got %q", progress.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(cmdPath), "request.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req pluginRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	want := pluginRequest{Version: PluginProtocolVersion, RepoPath: repoPath, CommitSHA: "abc123", Prompt: "review this", Model: "big", Reasoning: "fast"}
+	if req != want {
+		t.Errorf("request = %+v, want %+v", req, want)
+	}
+}
+
+func TestPluginAgentReviewError(t *testing.T) {
+	cmdPath := writeTempCommand(t, "#!/bin/sh\ncat >/dev/null\necho '{\"error\": \"quota exceeded\"}'\n")
+	_, err := NewPluginAgent("mine", cmdPath).Review(context.Background(), t.TempDir(), "abc123", "p", nil)
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("expected plugin error to be returned, got %v", err)
+	}
+
+	cmdPath = writeTempCommand(t, "#!/bin/sh\ncat >/dev/null\necho 'not json'\n")
+	_, err = NewPluginAgent("mine", cmdPath).Review(context.Background(), t.TempDir(), "abc123", "p", nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid plugin response") {
+		t.Errorf("expected invalid response error, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package agent
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// killTreeOnCancel makes context cancellation kill cmd and every process
+// it started, so children the agent spawned don't outlive it
+func killTreeOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		if err := kill.Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
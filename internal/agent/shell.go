@@ -0,0 +1,24 @@
+package agent
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// ShellCommand returns a command that runs command through the platform's
+// shell. Context cancellation kills the shell and every process it
+// started, and Wait stops waiting on output held open by any stragglers
+// shortly after.
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	killTreeOnCancel(cmd)
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
@@ -13,6 +13,7 @@ type TestAgent struct {
 	Output    string         // Fixed output to return
 	Fail      bool           // If true, returns an error
 	Reasoning ReasoningLevel // Reasoning level (for testing)
+	Usage     *UsageReport   // Usage to report, if set
 }
 
 // NewTestAgent creates a new test agent
@@ -31,6 +32,7 @@ func (a *TestAgent) WithReasoning(level ReasoningLevel) Agent {
 		Output:    a.Output,
 		Fail:      a.Fail,
 		Reasoning: level,
+		Usage:     a.Usage,
 	}
 }
 
@@ -63,6 +65,9 @@ func (a *TestAgent) Review(ctx context.Context, repoPath, commitSHA, prompt stri
 	if a.Fail {
 		return "", fmt.Errorf("test agent configured to fail")
 	}
+	if a.Usage != nil {
+		usageFrom(ctx).add(*a.Usage)
+	}
 
 	shortSHA := commitSHA
 	if len(shortSHA) > 7 {
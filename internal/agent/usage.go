@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"sync"
+)
+
+// UsageReport is what an agent reported about its runs: the tokens they
+// used, what they cost, and why the last one stopped
+type UsageReport struct {
+	InputTokens  int // Including cached input
+	OutputTokens int
+	CostUSD      float64 // As reported by the agent; zero if it doesn't say
+	StopReason   string  // e.g. "end_turn" or "max_tokens"
+}
+
+// Tokens returns the total tokens used
+func (r UsageReport) Tokens() int {
+	return r.InputTokens + r.OutputTokens
+}
+
+// Usage collects the usage that agents run under a context report. Agents
+// that don't report usage leave it empty.
+type Usage struct {
+	mu       sync.Mutex
+	report   UsageReport
+	reported bool
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context under which agents add the usage they report
+// to u
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+func usageFrom(ctx context.Context) *Usage {
+	u, _ := ctx.Value(usageKey{}).(*Usage)
+	return u
+}
+
+// add totals r into the usage, keeping r's stop reason
+func (u *Usage) add(r UsageReport) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.report.InputTokens += r.InputTokens
+	u.report.OutputTokens += r.OutputTokens
+	u.report.CostUSD += r.CostUSD
+	if r.StopReason != "" {
+		u.report.StopReason = r.StopReason
+	}
+	u.reported = true
+}
+
+// Report returns the usage totals, and false if no agent reported any
+func (u *Usage) Report() (UsageReport, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.report, u.reported
+}
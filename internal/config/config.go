@@ -7,7 +7,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/roborev-dev/roborev/internal/git"
@@ -17,7 +20,40 @@ import (
 type HookConfig struct {
 	Event   string `toml:"event"`   // "review.failed", "review.completed", "review.*"
 	Command string `toml:"command"` // shell command with {var} templates
-	Type    string `toml:"type"`    // "beads" for built-in, empty for command
+	Type    string `toml:"type"`    // "beads" or "webhook" for built-in, empty for command
+	URL     string `toml:"url"`     // endpoint the event is POSTed to as JSON (type "webhook")
+}
+
+// LinterConfig is a static analysis command run on the changed files before
+// a review, with its output included in the prompt
+type LinterConfig struct {
+	Name     string   `toml:"name"`     // heading for the output in the prompt (default: command)
+	Command  string   `toml:"command"`  // shell command; {files} expands to the matching changed files
+	Patterns []string `toml:"patterns"` // globs selecting changed files, e.g. ["*.go"] (default: all)
+}
+
+// OutputFilterConfig defines a filter applied to agent output before it is
+// stored
+type OutputFilterConfig struct {
+	Type    string `toml:"type"`    // built-in filter name, e.g. "strip-ansi"
+	Command string `toml:"command"` // shell command reading output on stdin and writing the filtered output
+}
+
+// PipelineConfig chains follow-up jobs after a review completes. Each step
+// runs once the previous one finishes, if its condition holds.
+type PipelineConfig struct {
+	Name    string               `toml:"name"`
+	Trigger string               `toml:"trigger"` // review type that starts the pipeline (default: the standard review)
+	Steps   []PipelineStepConfig `toml:"steps"`
+}
+
+// PipelineStepConfig is one step of a pipeline: a review to enqueue for the
+// same code, or a shell command to run
+type PipelineStepConfig struct {
+	When       string `toml:"when"`        // condition on the previous review, e.g. "findings > 0" (default: always)
+	ReviewType string `toml:"review_type"` // review type of the job to enqueue
+	Agent      string `toml:"agent"`       // agent for the job (default: the previous job's)
+	Command    string `toml:"command"`     // shell command with the same {var} templates as hooks
 }
 
 // Config holds the daemon configuration
@@ -29,6 +65,61 @@ type Config struct {
 	DefaultModel       string `toml:"default_model"` // Default model for agents (format varies by agent)
 	JobTimeoutMinutes  int    `toml:"job_timeout_minutes"`
 
+	// QuickReviewTimeoutSeconds bounds quick reviews (default: 90)
+	QuickReviewTimeoutSeconds int `toml:"quick_review_timeout_seconds"`
+
+	// ServerPortRange limits the daemon to the ports in an inclusive range,
+	// e.g. "7373-7380", for firewalled hosts and container port mappings.
+	// A single port pins the daemon to it, failing at startup when it's
+	// taken. Unset, the daemon searches upward from server_addr's port.
+	ServerPortRange string `toml:"server_port_range"`
+
+	// RequireRegisteredRepos makes the daemon refuse repo paths that weren't
+	// added with "roborev repo add", so an API reachable by others can't be
+	// used to run git and agents in arbitrary directories on the host
+	RequireRegisteredRepos bool `toml:"require_registered_repos"`
+
+	// AgentConcurrency caps how many jobs each agent runs at once, so a slow
+	// provider can't occupy every worker (e.g., gemini = 1). Agents not
+	// listed are limited only by max_workers.
+	AgentConcurrency map[string]int `toml:"agent_concurrency"`
+
+	// AgentRateLimits throttles requests to each agent across all workers,
+	// keyed by agent name, to stay under provider rate limits
+	AgentRateLimits map[string]RateLimitConfig `toml:"agent_rate_limits"`
+
+	// AgentSessions keeps agent processes alive between jobs for agents
+	// that support it
+	AgentSessions AgentSessionConfig `toml:"agent_sessions"`
+
+	// QualityAlarms warns when an agent's recent reviews keep coming back
+	// empty or without parseable findings, which usually means a broken
+	// CLI update or expired credentials
+	QualityAlarms QualityAlarmConfig `toml:"quality_alarms"`
+
+	// AgentCosts is the estimated price of each agent in USD per million
+	// tokens, used to track spending. Agents not listed count as free.
+	AgentCosts map[string]float64 `toml:"agent_costs"`
+
+	// DailyBudgetUSD caps estimated spending across all repos per day
+	// (default: no cap). Once it's reached, new jobs use
+	// BudgetFallbackAgent if set, or are refused until the next day.
+	DailyBudgetUSD      float64 `toml:"daily_budget_usd"`
+	BudgetFallbackAgent string  `toml:"budget_fallback_agent"`
+
+	// ReviewSigningKey is the path to an Ed25519 private key (PKCS #8 PEM)
+	// used to sign completed reviews, so their provenance can be verified
+	// later. Reviews aren't signed when it's empty.
+	ReviewSigningKey string `toml:"review_signing_key"`
+
+	// Aliases are user-defined CLI commands, e.g.
+	// sec = "review --type security --wait"
+	Aliases map[string]string `toml:"aliases"`
+
+	// CommandDefaults are arguments the CLI adds to a command ahead of the
+	// ones given on the command line, e.g. review = "--wait"
+	CommandDefaults map[string]string `toml:"command_defaults"`
+
 	// Workflow-specific agent/model configuration
 	ReviewAgent           string `toml:"review_agent"`
 	ReviewAgentFast       string `toml:"review_agent_fast"`
@@ -83,19 +174,219 @@ type Config struct {
 	// Hooks configuration
 	Hooks []HookConfig `toml:"hooks"`
 
+	// Pipelines of follow-up jobs run after reviews in any repo
+	Pipelines []PipelineConfig `toml:"pipelines"`
+
 	// Sync configuration for PostgreSQL
 	Sync SyncConfig `toml:"sync"`
 
+	// Shared review guidelines the daemon syncs and adds beneath each
+	// repo's own
+	OrgGuidelines OrgGuidelinesConfig `toml:"org_guidelines"`
+
 	// CI poller configuration
 	CI CIConfig `toml:"ci"`
 
 	// Analysis settings
-	DefaultMaxPromptSize int `toml:"default_max_prompt_size"` // Max prompt size in bytes before falling back to paths (default: 200KB)
+	DefaultMaxPromptSize int `toml:"default_max_prompt_size"` // Max prompt size in bytes before falling back to paths (default: 200KB); also caps review prompts for agents without a token budget
+
+	// PromptTokenBudgets sets the most tokens a review prompt may use, keyed
+	// by agent or model name, in place of the built-in context window table
+	PromptTokenBudgets map[string]int `toml:"prompt_token_budgets"`
+
+	// Review storage
+	DefaultMaxReviewOutputSize int `toml:"default_max_review_output_size"` // Max stored review size in bytes before summarizing (default: 64KB)
+
+	// Review scope
+	DefaultMaxFindings int `toml:"default_max_findings"` // Ask agents to report at most this many findings and summarize the rest (default: no limit)
+
+	// PreviousReviewsFormat is how earlier reviews appear in prompts:
+	// "findings" (default) lists their stored findings in a compact table,
+	// "full" includes each review's complete text
+	PreviousReviewsFormat string `toml:"previous_reviews_format"`
+
+	// OutputLanguage asks agents to write reviews in a language other than
+	// English, e.g. "de" or "Japanese"
+	OutputLanguage string `toml:"output_language"`
 
 	// UI preferences
 	HideAddressedByDefault bool `toml:"hide_addressed_by_default"`
 	AutoFilterRepo         bool `toml:"auto_filter_repo"`
 	TabWidth               int  `toml:"tab_width"` // Tab expansion width for TUI rendering (default: 2)
+
+	// UndoWindow is how long deleted jobs and reviews stay recoverable with
+	// `roborev undo` before they are purged (e.g., "24h", "168h"). Default: 24h
+	UndoWindow string `toml:"undo_window"`
+
+	// MaintenanceInterval is how often the daemon checkpoints the WAL,
+	// refreshes statistics, and vacuums free pages (e.g., "30m", "6h"). Default: 1h
+	MaintenanceInterval string `toml:"maintenance_interval"`
+
+	// StaleFindingDays sends a review.stale reminder for unaddressed reviews
+	// with critical or high findings once they are this many days old, and
+	// again each time their age doubles. 0 disables reminders.
+	StaleFindingDays int `toml:"stale_finding_days"`
+
+	// CommitTrailers appends Roborev-* trailers to commits created by
+	// refine and fix, recording which review each commit addresses
+	CommitTrailers bool `toml:"commit_trailers"`
+
+	// Skip rules for trivial commits (a repo [skip] section replaces these)
+	Skip SkipConfig `toml:"skip"`
+
+	// Sandbox restricts the environment agent subprocesses run in
+	Sandbox SandboxConfig `toml:"sandbox"`
+}
+
+// RateLimitConfig is a token bucket limit on agent requests. Each review,
+// corrective retry, or summary counts as one request.
+type RateLimitConfig struct {
+	RequestsPerMinute float64 `toml:"requests_per_minute"`
+	Burst             int     `toml:"burst"` // Requests allowed back to back (default: 1)
+}
+
+// AgentSessionConfig controls warm agent sessions. A session serves the
+// requests of one job, its review and follow-ups like corrective retries
+// and summaries, so reviews stay independent of each other. The agent sees
+// the earlier prompts and replies of its session as conversation history,
+// which it pays for in tokens and context on every later prompt, so a
+// session is restarted before that history would pass MaxContextKB.
+type AgentSessionConfig struct {
+	Enabled            bool `toml:"enabled"`
+	IdleTimeoutSeconds int  `toml:"idle_timeout_seconds"` // Close sessions idle this long (default: 300)
+	MaxPrompts         int  `toml:"max_prompts"`          // Restart a session after this many prompts (default: 20)
+	MaxContextKB       int  `toml:"max_context_kb"`       // Restart a session before its history passes this size (default: 256)
+}
+
+// IdleTimeout returns how long an unused session stays alive
+func (c AgentSessionConfig) IdleTimeout() time.Duration {
+	if c.IdleTimeoutSeconds <= 0 {
+		return 300 * time.Second
+	}
+	return time.Duration(c.IdleTimeoutSeconds) * time.Second
+}
+
+// PromptLimit returns how many prompts a session handles before restarting
+func (c AgentSessionConfig) PromptLimit() int {
+	if c.MaxPrompts <= 0 {
+		return 20
+	}
+	return c.MaxPrompts
+}
+
+// ContextLimit returns how many bytes of prompts and replies a session may
+// accumulate before restarting
+func (c AgentSessionConfig) ContextLimit() int {
+	if c.MaxContextKB <= 0 {
+		return 256 * 1024
+	}
+	return c.MaxContextKB * 1024
+}
+
+// QualityAlarmConfig sets when an agent's rolling review metrics raise an
+// alarm. Rates are fractions of the agent's last Window reviews.
+type QualityAlarmConfig struct {
+	Disabled            bool    `toml:"disabled"`
+	Window              int     `toml:"window"`                 // Reviews per agent the rates cover (default: 20)
+	MinSamples          int     `toml:"min_samples"`            // Reviews needed before alarming (default: 5)
+	MaxEmptyRate        float64 `toml:"max_empty_rate"`         // Empty or refused reviews (default: 0.3)
+	MaxParseFailureRate float64 `toml:"max_parse_failure_rate"` // Reviews without a findings block (default: 0.5)
+}
+
+// ResolvedWindow returns Window, or 20 when unset
+func (c QualityAlarmConfig) ResolvedWindow() int {
+	if c.Window <= 0 {
+		return 20
+	}
+	return c.Window
+}
+
+// ResolvedMinSamples returns MinSamples, or 5 when unset, capped at the
+// window
+func (c QualityAlarmConfig) ResolvedMinSamples() int {
+	n := c.MinSamples
+	if n <= 0 {
+		n = 5
+	}
+	return min(n, c.ResolvedWindow())
+}
+
+// ResolvedMaxEmptyRate returns MaxEmptyRate, or 0.3 when unset
+func (c QualityAlarmConfig) ResolvedMaxEmptyRate() float64 {
+	if c.MaxEmptyRate <= 0 {
+		return 0.3
+	}
+	return c.MaxEmptyRate
+}
+
+// ResolvedMaxParseFailureRate returns MaxParseFailureRate, or 0.5 when
+// unset
+func (c QualityAlarmConfig) ResolvedMaxParseFailureRate() float64 {
+	if c.MaxParseFailureRate <= 0 {
+		return 0.5
+	}
+	return c.MaxParseFailureRate
+}
+
+// SandboxConfig restricts what agent subprocesses can see and reach. Agents
+// receive the full diff and any context files, so these limit what else
+// they can read or send.
+type SandboxConfig struct {
+	EnvAllowlist []string `toml:"env_allowlist"` // Only pass these env vars (plus PATH, HOME, and similar); a trailing * matches a prefix
+	EnvDenylist  []string `toml:"env_denylist"`  // Never pass these env vars; a trailing * matches a prefix
+	NoNetwork    bool     `toml:"no_network"`    // Run agents without network access, loopback included, via firejail (Linux) or sandbox-exec (macOS); hosted agents are refused
+}
+
+// DefaultMaintenanceInterval is used when maintenance_interval is unset or invalid.
+const DefaultMaintenanceInterval = time.Hour
+
+// ResolvedMaintenanceInterval returns the parsed maintenance interval, falling
+// back to DefaultMaintenanceInterval when unset, invalid, or under a minute.
+func (c *Config) ResolvedMaintenanceInterval() time.Duration {
+	if c == nil || c.MaintenanceInterval == "" {
+		return DefaultMaintenanceInterval
+	}
+	d, err := time.ParseDuration(c.MaintenanceInterval)
+	if err != nil || d < time.Minute {
+		return DefaultMaintenanceInterval
+	}
+	return d
+}
+
+// DefaultUndoWindow is used when undo_window is unset or invalid.
+const DefaultUndoWindow = 24 * time.Hour
+
+// ResolvedUndoWindow returns the parsed undo window, falling back to
+// DefaultUndoWindow when unset, invalid, or non-positive.
+func (c *Config) ResolvedUndoWindow() time.Duration {
+	if c == nil || c.UndoWindow == "" {
+		return DefaultUndoWindow
+	}
+	d, err := time.ParseDuration(c.UndoWindow)
+	if err != nil || d <= 0 {
+		return DefaultUndoWindow
+	}
+	return d
+}
+
+// ParsePortRange parses a server_port_range value: "first-last" or a single
+// port
+func ParsePortRange(s string) (first, last int, err error) {
+	lo, hi, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	if !isRange {
+		hi = lo
+	}
+	first, err = strconv.Atoi(strings.TrimSpace(lo))
+	if err == nil {
+		last, err = strconv.Atoi(strings.TrimSpace(hi))
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: want a port or first-last", s)
+	}
+	if first < 1 || last > 65535 || first > last {
+		return 0, 0, fmt.Errorf("invalid port range %q: ports must be 1-65535, first no greater than last", s)
+	}
+	return first, last, nil
 }
 
 // GitHubAppConfig holds GitHub App authentication settings.
@@ -210,6 +501,11 @@ type CIConfig struct {
 	// Valid values: critical, high, medium, low. Empty means no filter (include all).
 	MinSeverity string `toml:"min_severity"`
 
+	// InlineComments posts findings that reference lines in the PR diff as
+	// inline review comments. The summary comment is then cut down to a
+	// count of them plus the findings outside the diff.
+	InlineComments bool `toml:"inline_comments"`
+
 	// GitHub App authentication (optional — comments appear as bot instead of personal account)
 	GitHubAppConfig
 }
@@ -232,6 +528,54 @@ func (c *CIConfig) ResolvedAgents() []string {
 	return []string{""}
 }
 
+// OrgGuidelinesConfig names an organization's shared review guidelines:
+// a file served over HTTPS, or a file in a git repository
+type OrgGuidelinesConfig struct {
+	// Source is an http(s) URL of a guidelines file, or a git repository
+	// URL or path. URLs ending in .git are cloned.
+	Source string `toml:"source"`
+
+	// Path is the guidelines file within a git source. Default: guidelines.md
+	Path string `toml:"path"`
+
+	// Ref is the branch or tag of a git source. Default: its default branch
+	Ref string `toml:"ref"`
+
+	// Interval is how often the daemon syncs the guidelines (e.g., "30m").
+	// Default: 1h
+	Interval string `toml:"interval"`
+}
+
+// DefaultOrgGuidelinesInterval is used when org_guidelines.interval is unset
+// or invalid
+const DefaultOrgGuidelinesInterval = time.Hour
+
+// IsGit reports whether the source is a git repository rather than a file
+// served over HTTP
+func (c OrgGuidelinesConfig) IsGit() bool {
+	lower := strings.ToLower(c.Source)
+	isHTTP := strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+	return !isHTTP || strings.HasSuffix(strings.TrimSuffix(lower, "/"), ".git")
+}
+
+// ResolvedPath returns the guidelines file within a git source
+func (c OrgGuidelinesConfig) ResolvedPath() string {
+	if c.Path == "" {
+		return "guidelines.md"
+	}
+	return c.Path
+}
+
+// ResolvedInterval returns the parsed sync interval, falling back to
+// DefaultOrgGuidelinesInterval when unset, invalid, or under a minute
+func (c OrgGuidelinesConfig) ResolvedInterval() time.Duration {
+	d, err := time.ParseDuration(c.Interval)
+	if err != nil || d < time.Minute {
+		return DefaultOrgGuidelinesInterval
+	}
+	return d
+}
+
 // SyncConfig holds configuration for PostgreSQL sync
 type SyncConfig struct {
 	// Enabled enables sync to PostgreSQL
@@ -322,12 +666,138 @@ type RepoCIConfig struct {
 	MinSeverity string `toml:"min_severity"`
 }
 
+// ReviewPolicyConfig defines which commits must have a passing review.
+// It is enforced by `roborev verify`.
+type ReviewPolicyConfig struct {
+	// Branches lists branches on which every commit needs a passing review
+	// (e.g., ["main"]).
+	Branches []string `toml:"branches"`
+
+	// Since is the commit (or ref) after which the policy applies. Commits
+	// reachable from it are not checked. Required to verify without a range.
+	Since string `toml:"since"`
+
+	// AllowAddressed counts failing reviews that were marked addressed as passing.
+	AllowAddressed bool `toml:"allow_addressed"`
+
+	// RequireSignatures fails commits whose review doesn't carry a valid
+	// signature from the daemon's review_signing_key.
+	RequireSignatures bool `toml:"require_signatures"`
+}
+
+// AutoAddressConfig lets the daemon fix low-risk review findings on its
+// own. It only acts on commit reviews outside the default branch whose
+// findings all pass its limits.
+type AutoAddressConfig struct {
+	// Enabled opts the repo in. Default: false
+	Enabled bool `toml:"enabled"`
+
+	// MaxSeverity is the highest finding severity fixed automatically
+	// (critical, high, medium, low, or one of the repo's severity labels).
+	// Default: low
+	MaxSeverity string `toml:"max_severity"`
+
+	// MaxFindings skips reviews with more findings than this. Default: 3
+	MaxFindings int `toml:"max_findings"`
+
+	// Patterns are regular expressions one of which every finding's message
+	// must match (e.g., ["(?i)wrap.*error"]). Default: any message
+	Patterns []string `toml:"patterns"`
+
+	// DryRun stores the fix as a patch on the review instead of committing
+	// it to a roborev/address-<job> branch. Default: true
+	DryRun *bool `toml:"dry_run"`
+}
+
+// ResolvedMaxSeverity returns the built-in level of MaxSeverity, which may
+// name one of the repo's severity levels, or low when unset or invalid
+func (c AutoAddressConfig) ResolvedMaxSeverity(levels []SeverityLevel) string {
+	name := strings.ToLower(strings.TrimSpace(c.MaxSeverity))
+	for _, l := range levels {
+		if name == l.Name {
+			return l.Level
+		}
+	}
+	if sev, err := NormalizeMinSeverity(name); err == nil && sev != "" {
+		return sev
+	}
+	return "low"
+}
+
+// ResolvedMaxFindings returns MaxFindings, or 3 when unset
+func (c AutoAddressConfig) ResolvedMaxFindings() int {
+	if c.MaxFindings > 0 {
+		return c.MaxFindings
+	}
+	return 3
+}
+
+// IsDryRun reports whether fixes are only proposed as patches
+func (c AutoAddressConfig) IsDryRun() bool {
+	return c.DryRun == nil || *c.DryRun
+}
+
+// SkipConfig defines trivial commits that are recorded as skipped instead
+// of being reviewed. A commit is skipped when any rule matches. Explicit
+// reviews with --force ignore these rules.
+type SkipConfig struct {
+	// MessagePatterns are regular expressions matched against the full
+	// commit message (e.g., ["(?i)\\btypo\\b", "^docs:"]).
+	MessagePatterns []string `toml:"message_patterns"`
+
+	// MaxDiffLines skips commits that add and delete at most this many
+	// lines in total. Zero disables the rule.
+	MaxDiffLines int `toml:"max_diff_lines"`
+
+	// DocsOnly skips commits that only touch documentation files.
+	DocsOnly bool `toml:"docs_only"`
+}
+
+// IsZero reports whether no skip rules are configured.
+func (c SkipConfig) IsZero() bool {
+	return len(c.MessagePatterns) == 0 && c.MaxDiffLines <= 0 && !c.DocsOnly
+}
+
+// DefaultMergeQueueDeadline is used when merge_queue.deadline is unset or invalid.
+const DefaultMergeQueueDeadline = 15 * time.Minute
+
+// MergeQueueConfig controls how `roborev merge-queue` reports results to
+// merge queue automation.
+type MergeQueueConfig struct {
+	// Deadline is how long a merge queue review may run before OnTimeout
+	// decides the result (e.g., "10m"). Default: 15m
+	Deadline string `toml:"deadline"`
+
+	// OnTimeout is the result reported once the deadline passes without a
+	// finished review: "fail" (default) or "pass".
+	OnTimeout string `toml:"on_timeout"`
+}
+
+// ResolvedDeadline returns the parsed deadline, falling back to
+// DefaultMergeQueueDeadline when unset, invalid, or non-positive.
+func (c MergeQueueConfig) ResolvedDeadline() time.Duration {
+	if c.Deadline == "" {
+		return DefaultMergeQueueDeadline
+	}
+	d, err := time.ParseDuration(c.Deadline)
+	if err != nil || d <= 0 {
+		return DefaultMergeQueueDeadline
+	}
+	return d
+}
+
+// PassOnTimeout reports whether a review that misses the deadline passes.
+func (c MergeQueueConfig) PassOnTimeout() bool {
+	return strings.EqualFold(strings.TrimSpace(c.OnTimeout), "pass")
+}
+
 // RepoConfig holds per-repo overrides
 type RepoConfig struct {
 	Agent              string   `toml:"agent"`
 	Model              string   `toml:"model"` // Model for agents (format varies by agent)
 	ReviewContextCount int      `toml:"review_context_count"`
 	ReviewGuidelines   string   `toml:"review_guidelines"`
+	ContextCommits     *int     `toml:"context_commits"` // Earlier commits whose reviews go in prompts when a request doesn't say (overrides global review_context_count)
 	JobTimeoutMinutes  int      `toml:"job_timeout_minutes"`
 	ExcludedBranches   []string `toml:"excluded_branches"`
 	DisplayName        string   `toml:"display_name"`
@@ -335,9 +805,33 @@ type RepoConfig struct {
 	RefineReasoning    string   `toml:"refine_reasoning"` // Reasoning level for refine: thorough, standard, fast
 	FixReasoning       string   `toml:"fix_reasoning"`    // Reasoning level for fix: thorough, standard, fast
 
+	// RequiredSections are headings every review must contain (e.g.,
+	// "Rollback plan assessment"). Reviews missing one are retried once.
+	RequiredSections []string `toml:"required_sections"`
+
+	// Severities replace the default severity labels in review prompts,
+	// listed highest first; findings are mapped back to them
+	Severities []SeverityLevel `toml:"severities"`
+
+	// Categories add the repo's own finding categories, such as
+	// "compliance", to the review criteria
+	Categories []FindingCategory `toml:"categories"`
+
 	// CI-specific overrides (used by CI poller for this repo)
 	CI RepoCIConfig `toml:"ci"`
 
+	// Review requirements checked by `roborev verify`
+	ReviewPolicy ReviewPolicyConfig `toml:"review_policy"`
+
+	// Automatic fixes for low-risk findings
+	AutoAddress AutoAddressConfig `toml:"auto_address"`
+
+	// Skip rules for trivial commits (replaces the global [skip] section)
+	Skip SkipConfig `toml:"skip"`
+
+	// Deadline and timeout result for `roborev merge-queue`
+	MergeQueue MergeQueueConfig `toml:"merge_queue"`
+
 	// Workflow-specific agent/model configuration
 	ReviewAgent           string `toml:"review_agent"`
 	ReviewAgentFast       string `toml:"review_agent_fast"`
@@ -383,8 +877,198 @@ type RepoConfig struct {
 	// Hooks configuration (per-repo)
 	Hooks []HookConfig `toml:"hooks"`
 
+	// Pipelines of follow-up jobs; one named like a global pipeline
+	// replaces it
+	Pipelines []PipelineConfig `toml:"pipelines"`
+
+	// Filters applied in order to agent output before it is stored
+	OutputFilters []OutputFilterConfig `toml:"output_filters"`
+
+	// System prompt overrides by review type ("review" or "default",
+	// "range", "dirty", "address", or a special review type): inline
+	// template text, or the path of a template file in the repo
+	Prompts map[string]string `toml:"prompts"`
+
+	// Linters run on the changed files, in a temporary worktree, when the
+	// reviewed code is checked out
+	Linters []LinterConfig `toml:"linters"`
+
+	// Test command run, in a temporary worktree, before reviews of
+	// checked-out code; output from a failing run is included in the prompt
+	TestCommand string `toml:"test_command"`
+
+	// Command printing the CI status and failing log excerpts for a commit,
+	// included in reviews of committed code; {sha} is replaced by the
+	// commit, e.g. "scripts/ci-log.sh {sha}"
+	CIStatusCommand string `toml:"ci_status_command"`
+
+	// Benchmark command run on the base and reviewed code in bench reviews,
+	// e.g. "go test -run '^$' -bench . -count 6 ./..."
+	BenchCommand string `toml:"bench_command"`
+
+	// Coverage profiles (Go cover profiles or LCOV tracefiles, relative to
+	// the repo root) summarized for the changed files in review prompts
+	CoverageProfiles []string `toml:"coverage_profiles"`
+
+	// Command describing a changed image for review prompts; {file} is the
+	// path to a copy of the image's new version
+	ImageDescriptionCommand string `toml:"image_description_command"`
+
 	// Analysis settings
-	MaxPromptSize int `toml:"max_prompt_size"` // Max prompt size in bytes before falling back to paths (overrides global default)
+	MaxPromptSize int `toml:"max_prompt_size"` // Max prompt size in bytes before falling back to paths (overrides global default); also caps review prompts for agents without a token budget
+
+	// PromptTokenBudget is the most tokens a review prompt may use in this
+	// repo, in place of prompt_token_budgets and the built-in context
+	// window table
+	PromptTokenBudget int `toml:"prompt_token_budget"`
+
+	// Review storage
+	MaxReviewOutputSize int `toml:"max_review_output_size"` // Max stored review size in bytes before summarizing (overrides global default)
+
+	// BlameContext adds who last changed the lines a diff modifies, and
+	// when, to review prompts. Off by default, since it runs git blame on
+	// every changed file.
+	BlameContext bool `toml:"blame_context"`
+
+	// RelatedTests includes the unchanged test files of changed code, such
+	// as foo_test.go for foo.go, in review prompts (default true)
+	RelatedTests *bool `toml:"related_tests"`
+
+	// ScrubSecrets redacts credentials such as cloud keys, private keys, and
+	// random tokens assigned to secret-like names from review prompts
+	// before they're sent to an agent (default true)
+	ScrubSecrets *bool `toml:"scrub_secrets"`
+
+	// ContextFiles are documents every review should see, such as ADRs or
+	// a style guide: paths or globs relative to the repo root, or https
+	// URLs, which are fetched and cached
+	ContextFiles []string `toml:"context_files"`
+
+	// ContextFilesMaxBytes caps the context files in one prompt (default:
+	// a quarter of the prompt budget)
+	ContextFilesMaxBytes int `toml:"context_files_max_bytes"`
+
+	// ContextFilePriorities weights context files by pattern: a
+	// context_files entry, or a glob matched against a file's repo-relative
+	// path. Files with higher weights come first and are the last left out
+	// when the budget runs short; files of equal weight sort by path.
+	ContextFilePriorities map[string]int `toml:"context_file_priorities"`
+
+	// Security reviews compare the repo's CycloneDX SBOM between the base
+	// and the reviewed code: SBOMPath is read at each version, or
+	// SBOMCommand runs in a temporary checkout of each and prints the SBOM
+	// as JSON (e.g., "syft . -o cyclonedx-json")
+	SBOMPath    string `toml:"sbom_path"`
+	SBOMCommand string `toml:"sbom_command"`
+
+	// Security reviews list known advisories for changed dependencies,
+	// found with osv-scanner or govulncheck when installed (default: true)
+	SecurityAdvisories *bool `toml:"security_advisories"`
+
+	// Reviews include the full contents of dependency manifests such as
+	// go.mod or package.json that the diff changes (default: true)
+	DependencyManifests *bool `toml:"dependency_manifests"`
+
+	// ReviewCommitMessage adds a section assessing commit messages (Conventional
+	// Commits compliance, body completeness) to commit and range reviews
+	ReviewCommitMessage bool `toml:"review_commit_message"`
+
+	// CompactDiff regenerates a commit or range diff with one line of
+	// context when the full diff doesn't fit the prompt budget, before
+	// leaving the diff out (default: true)
+	CompactDiff *bool `toml:"compact_diff"`
+
+	// DailyBudgetUSD caps this repo's estimated spending per day (default:
+	// no cap), enforced like the global daily_budget_usd
+	DailyBudgetUSD float64 `toml:"daily_budget_usd"`
+
+	// Review scope
+	MaxFindings int `toml:"max_findings"` // Ask agents to report at most this many findings and summarize the rest (overrides global default)
+
+	PreviousReviewsFormat string `toml:"previous_reviews_format"` // "findings" or "full" (overrides global default)
+
+	OutputLanguage string `toml:"output_language"` // Language agents write reviews in (overrides global default)
+
+	// Files left out of reviewed diffs, as gitignore-style patterns such as
+	// "vendor/**" or "*.pb.go". When DiffInclude is set, only matching files
+	// are kept; DiffExclude wins when both match.
+	DiffExclude []string `toml:"diff_exclude"`
+	DiffInclude []string `toml:"diff_include"`
+
+	// Commit stamping
+	CommitTrailers *bool `toml:"commit_trailers"` // Append Roborev-* trailers to refine and fix commits (overrides global setting)
+}
+
+// SeverityLevel is a repo-defined severity label, such as "blocker", and
+// the built-in level (critical, high, medium, or low) it ranks as
+type SeverityLevel struct {
+	Name        string `toml:"name"`
+	Level       string `toml:"level"`
+	Description string `toml:"description"`
+}
+
+// ResolveSeverities returns the repo's severity labels, or nil when it uses
+// the defaults
+func ResolveSeverities(repoPath string) []SeverityLevel {
+	repoCfg, err := LoadRepoConfig(repoPath)
+	if err != nil || repoCfg == nil {
+		return nil
+	}
+	return NormalizeSeverities(repoCfg.Severities)
+}
+
+// NormalizeSeverities lowercases labels and levels, and drops entries
+// without a name or with an unknown level
+func NormalizeSeverities(levels []SeverityLevel) []SeverityLevel {
+	var out []SeverityLevel
+	for _, l := range levels {
+		name := strings.ToLower(strings.TrimSpace(l.Name))
+		level, err := NormalizeMinSeverity(l.Level)
+		if name == "" || err != nil || level == "" {
+			continue
+		}
+		out = append(out, SeverityLevel{Name: name, Level: level, Description: strings.TrimSpace(l.Description)})
+	}
+	return out
+}
+
+// DefaultFindingCategories are the categories every review checks, in the
+// order the built-in prompts list them
+var DefaultFindingCategories = []string{"bugs", "security", "testing gaps", "regressions", "code quality"}
+
+// FindingCategory is a repo-defined finding category, such as "telemetry"
+type FindingCategory struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+}
+
+// ResolveCategories returns the repo's own finding categories, or nil when
+// it only uses the defaults
+func ResolveCategories(repoPath string) []FindingCategory {
+	repoCfg, err := LoadRepoConfig(repoPath)
+	if err != nil || repoCfg == nil {
+		return nil
+	}
+	return NormalizeCategories(repoCfg.Categories)
+}
+
+// NormalizeCategories lowercases category names, and drops entries without
+// a name and ones that repeat an earlier or default category
+func NormalizeCategories(categories []FindingCategory) []FindingCategory {
+	seen := make(map[string]bool)
+	for _, name := range DefaultFindingCategories {
+		seen[name] = true
+	}
+	var out []FindingCategory
+	for _, c := range categories {
+		name := strings.ToLower(strings.Join(strings.Fields(c.Name), " "))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, FindingCategory{Name: name, Description: strings.TrimSpace(c.Description)})
+	}
+	return out
 }
 
 // DefaultConfig returns the default configuration
@@ -509,6 +1193,34 @@ func ResolveJobTimeout(repoPath string, globalCfg *Config) int {
 	return resolve(30, repoVal, globalVal)
 }
 
+// ResolveQuickReviewTimeout returns how long a quick review may run: the
+// global quick_review_timeout_seconds, or 90 seconds
+func ResolveQuickReviewTimeout(globalCfg *Config) time.Duration {
+	seconds := 90
+	if globalCfg != nil && globalCfg.QuickReviewTimeoutSeconds > 0 {
+		seconds = globalCfg.QuickReviewTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ResolvePipelines returns the global pipelines followed by the repo's,
+// with a repo pipeline replacing a global one of the same name
+func ResolvePipelines(repoPath string, globalCfg *Config) []PipelineConfig {
+	var pipelines []PipelineConfig
+	if globalCfg != nil {
+		pipelines = append(pipelines, globalCfg.Pipelines...)
+	}
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		for _, p := range repoCfg.Pipelines {
+			pipelines = slices.DeleteFunc(pipelines, func(g PipelineConfig) bool {
+				return p.Name != "" && g.Name == p.Name
+			})
+			pipelines = append(pipelines, p)
+		}
+	}
+	return pipelines
+}
+
 // IsBranchExcluded checks if a branch should be excluded from reviews
 func IsBranchExcluded(repoPath, branch string) bool {
 	repoCfg, err := LoadRepoConfig(repoPath)
@@ -540,6 +1252,21 @@ func IsDefaultReviewType(rt string) bool {
 	return rt == "" || rt == "default" || rt == "general" || rt == "review"
 }
 
+// SpecialReviewTypes are the review types that swap in a specialized
+// system prompt
+var SpecialReviewTypes = []string{"security", "design", "bench", "performance", "docs", "tests", "quick"}
+
+// IsValidReviewType reports whether rt is the default review type (or one
+// of its aliases) or a special review type
+func IsValidReviewType(rt string) bool {
+	return IsDefaultReviewType(rt) || slices.Contains(SpecialReviewTypes, rt)
+}
+
+// ValidReviewTypes lists the review types for error messages
+func ValidReviewTypes() string {
+	return "default, " + strings.Join(SpecialReviewTypes, ", ")
+}
+
 // NormalizeReasoning validates and normalizes a reasoning level string.
 // Returns the canonical form (thorough, standard, fast) or an error if invalid.
 // Returns empty string (no error) for empty input.
@@ -578,6 +1305,22 @@ func NormalizeMinSeverity(value string) (string, error) {
 	}
 }
 
+// SeverityRank orders the built-in severity levels from 1 (low) to 4
+// (critical). Anything else ranks 0.
+func SeverityRank(level string) int {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "critical":
+		return 4
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	}
+	return 0
+}
+
 // ResolveReviewReasoning determines reasoning level for reviews.
 // Priority: explicit > per-repo config > default (thorough)
 func ResolveReviewReasoning(explicit string, repoPath string) (string, error) {
@@ -656,6 +1399,150 @@ func ResolveMaxPromptSize(repoPath string, globalCfg *Config) int {
 	return resolve(DefaultMaxPromptSize, repoVal, globalVal)
 }
 
+// ResolveReviewPromptSize returns the byte limit for review prompts to
+// agents whose context window isn't known: max_prompt_size from the repo
+// or default_max_prompt_size from the global config, or 0 when neither is
+// set and the prompt builder's own limit applies
+func ResolveReviewPromptSize(repoPath string, globalCfg *Config) int {
+	var repoVal int
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		repoVal = clampPositive(repoCfg.MaxPromptSize)
+	}
+	var globalVal int
+	if globalCfg != nil {
+		globalVal = clampPositive(globalCfg.DefaultMaxPromptSize)
+	}
+	return resolve(0, repoVal, globalVal)
+}
+
+// ResolvePromptTokenBudget returns the repo's prompt_token_budget, or 0
+// when it isn't set
+func ResolvePromptTokenBudget(repoPath string) int {
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		return clampPositive(repoCfg.PromptTokenBudget)
+	}
+	return 0
+}
+
+// DefaultMaxReviewOutputSize is the default maximum stored review size in bytes (64KB)
+const DefaultMaxReviewOutputSize = 64 * 1024
+
+// ResolveMaxReviewOutputSize determines the maximum review output size stored
+// in the review row based on config priority:
+// 1. Per-repo config (max_review_output_size in .roborev.toml)
+// 2. Global config (default_max_review_output_size in config.toml)
+// 3. Default (64KB)
+func ResolveMaxReviewOutputSize(repoPath string, globalCfg *Config) int {
+	var repoVal int
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		repoVal = clampPositive(repoCfg.MaxReviewOutputSize)
+	}
+	var globalVal int
+	if globalCfg != nil {
+		globalVal = clampPositive(globalCfg.DefaultMaxReviewOutputSize)
+	}
+	return resolve(DefaultMaxReviewOutputSize, repoVal, globalVal)
+}
+
+// ResolveMaxFindings determines how many findings a review may list, with
+// 0 meaning no limit:
+// 1. Per-repo config (max_findings in .roborev.toml)
+// 2. Global config (default_max_findings in config.toml)
+// 3. Default (no limit)
+func ResolveMaxFindings(repoPath string, globalCfg *Config) int {
+	var repoVal int
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		repoVal = clampPositive(repoCfg.MaxFindings)
+	}
+	var globalVal int
+	if globalCfg != nil {
+		globalVal = clampPositive(globalCfg.DefaultMaxFindings)
+	}
+	return resolve(0, repoVal, globalVal)
+}
+
+// ResolveContextCommits determines how many earlier commits' reviews go in
+// a review prompt:
+// 1. Explicit count (if non-nil), e.g. from the enqueue request
+// 2. Per-repo config (context_commits in .roborev.toml)
+// 3. Global config (review_context_count in config.toml)
+// Zero, from any source, leaves earlier reviews out.
+func ResolveContextCommits(explicit *int, repoPath string, globalCfg *Config) int {
+	if explicit != nil {
+		return clampPositive(*explicit)
+	}
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil && repoCfg.ContextCommits != nil {
+		return clampPositive(*repoCfg.ContextCommits)
+	}
+	if globalCfg != nil {
+		return clampPositive(globalCfg.ReviewContextCount)
+	}
+	return 0
+}
+
+// Values of previous_reviews_format
+const (
+	PreviousReviewsFindings = "findings"
+	PreviousReviewsFull     = "full"
+)
+
+// ResolvePreviousReviewsFormat determines how earlier reviews appear in
+// prompts: per-repo config, then global config, then
+// PreviousReviewsFindings. Unknown values are ignored.
+func ResolvePreviousReviewsFormat(repoPath string, globalCfg *Config) string {
+	valid := func(v string) string {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == PreviousReviewsFindings || v == PreviousReviewsFull {
+			return v
+		}
+		return ""
+	}
+	var repoVal, globalVal string
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		repoVal = valid(repoCfg.PreviousReviewsFormat)
+	}
+	if globalCfg != nil {
+		globalVal = valid(globalCfg.PreviousReviewsFormat)
+	}
+	return resolve(PreviousReviewsFindings, repoVal, globalVal)
+}
+
+// ResolveOutputLanguage determines the language agents write in: per-repo
+// config, then global config, then "" for the agent's default, English
+func ResolveOutputLanguage(repoPath string, globalCfg *Config) string {
+	var repoVal, globalVal string
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		repoVal = strings.TrimSpace(repoCfg.OutputLanguage)
+	}
+	if globalCfg != nil {
+		globalVal = strings.TrimSpace(globalCfg.OutputLanguage)
+	}
+	return resolve("", repoVal, globalVal)
+}
+
+// ResolveCommitTrailers determines whether refine and fix commits get Roborev-* trailers:
+// 1. Per-repo config (commit_trailers in .roborev.toml, if set)
+// 2. Global config (commit_trailers in config.toml)
+// 3. Default (false)
+func ResolveCommitTrailers(repoPath string, globalCfg *Config) bool {
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil && repoCfg.CommitTrailers != nil {
+		return *repoCfg.CommitTrailers
+	}
+	return globalCfg != nil && globalCfg.CommitTrailers
+}
+
+// ResolveSkipConfig returns the skip rules for a repo. A [skip] section in
+// the repo config replaces the global one rather than merging with it.
+func ResolveSkipConfig(repoPath string, globalCfg *Config) SkipConfig {
+	if repoCfg, err := LoadRepoConfig(repoPath); err == nil && repoCfg != nil && !repoCfg.Skip.IsZero() {
+		return repoCfg.Skip
+	}
+	if globalCfg != nil {
+		return globalCfg.Skip
+	}
+	return SkipConfig{}
+}
+
 // ResolveAgentForWorkflow determines which agent to use based on workflow and level.
 // Priority (Option A - layer wins first, then specificity):
 // 1. CLI explicit
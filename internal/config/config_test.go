@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/roborev-dev/roborev/internal/testenv"
 )
@@ -1088,6 +1089,60 @@ func TestResolveMaxPromptSize(t *testing.T) {
 	})
 }
 
+func TestResolveReviewPromptSize(t *testing.T) {
+	if size := ResolveReviewPromptSize(t.TempDir(), nil); size != 0 {
+		t.Errorf("Expected 0 when unset, got %d", size)
+	}
+	cfg := &Config{DefaultMaxPromptSize: 500 * 1024}
+	if size := ResolveReviewPromptSize(t.TempDir(), cfg); size != 500*1024 {
+		t.Errorf("Expected 500KB from global config, got %d", size)
+	}
+	tmpDir := newTempRepo(t, `max_prompt_size = 300000`)
+	if size := ResolveReviewPromptSize(tmpDir, cfg); size != 300000 {
+		t.Errorf("Expected 300000 from repo config, got %d", size)
+	}
+}
+
+func TestResolvePromptTokenBudget(t *testing.T) {
+	if n := ResolvePromptTokenBudget(t.TempDir()); n != 0 {
+		t.Errorf("Expected 0 when unset, got %d", n)
+	}
+	tmpDir := newTempRepo(t, `prompt_token_budget = 600000`)
+	if n := ResolvePromptTokenBudget(tmpDir); n != 600000 {
+		t.Errorf("Expected 600000 from repo config, got %d", n)
+	}
+}
+
+func TestResolvePipelines(t *testing.T) {
+	global := DefaultConfig()
+	global.Pipelines = []PipelineConfig{
+		{Name: "deep", Steps: []PipelineStepConfig{{ReviewType: "security"}}},
+		{Name: "notify", Steps: []PipelineStepConfig{{Command: "notify-send done"}}},
+	}
+	tmpDir := newTempRepo(t, `
+[[pipelines]]
+name = "deep"
+trigger = "default"
+
+[[pipelines.steps]]
+when = "findings > 0"
+review_type = "design"
+`)
+	pipelines := ResolvePipelines(tmpDir, global)
+	if len(pipelines) != 2 {
+		t.Fatalf("Expected 2 pipelines, got %+v", pipelines)
+	}
+	if pipelines[0].Name != "notify" || pipelines[1].Name != "deep" {
+		t.Errorf("Expected notify then the repo's deep, got %+v", pipelines)
+	}
+	if steps := pipelines[1].Steps; len(steps) != 1 || steps[0].ReviewType != "design" || steps[0].When != "findings > 0" {
+		t.Errorf("Expected the repo pipeline to replace the global one, got %+v", steps)
+	}
+	if len(global.Pipelines) != 2 || global.Pipelines[0].Name != "deep" {
+		t.Errorf("Global pipelines were modified: %+v", global.Pipelines)
+	}
+}
+
 func TestResolveAgentForWorkflow(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1727,3 +1782,273 @@ func TestIsDefaultReviewType(t *testing.T) {
 		}
 	}
 }
+
+func TestIsValidReviewType(t *testing.T) {
+	for _, rt := range []string{"", "default", "review", "security", "design", "bench", "performance", "docs", "tests", "quick"} {
+		if !IsValidReviewType(rt) {
+			t.Errorf("expected %q to be a valid review type", rt)
+		}
+	}
+	for _, rt := range []string{"bogus", "Security", "design-review"} {
+		if IsValidReviewType(rt) {
+			t.Errorf("expected %q to be an invalid review type", rt)
+		}
+	}
+}
+
+func TestResolveQuickReviewTimeout(t *testing.T) {
+	if got := ResolveQuickReviewTimeout(nil); got != 90*time.Second {
+		t.Errorf("default = %v, want 90s", got)
+	}
+	if got := ResolveQuickReviewTimeout(&Config{QuickReviewTimeoutSeconds: 45}); got != 45*time.Second {
+		t.Errorf("configured = %v, want 45s", got)
+	}
+	if got := ResolveQuickReviewTimeout(&Config{QuickReviewTimeoutSeconds: -1}); got != 90*time.Second {
+		t.Errorf("negative = %v, want the 90s default", got)
+	}
+}
+
+func TestResolvedUndoWindow(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", DefaultUndoWindow},
+		{"1h", time.Hour},
+		{"168h", 168 * time.Hour},
+		{"bogus", DefaultUndoWindow},
+		{"-5m", DefaultUndoWindow},
+	}
+	for _, tt := range tests {
+		cfg := &Config{UndoWindow: tt.value}
+		if got := cfg.ResolvedUndoWindow(); got != tt.want {
+			t.Errorf("ResolvedUndoWindow(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMergeQueueConfig(t *testing.T) {
+	tests := []struct {
+		cfg         MergeQueueConfig
+		wantTimeout time.Duration
+		wantPass    bool
+	}{
+		{MergeQueueConfig{}, DefaultMergeQueueDeadline, false},
+		{MergeQueueConfig{Deadline: "10m", OnTimeout: "pass"}, 10 * time.Minute, true},
+		{MergeQueueConfig{Deadline: "bogus", OnTimeout: " PASS "}, DefaultMergeQueueDeadline, true},
+		{MergeQueueConfig{Deadline: "-1m", OnTimeout: "fail"}, DefaultMergeQueueDeadline, false},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.ResolvedDeadline(); got != tt.wantTimeout {
+			t.Errorf("ResolvedDeadline(%q) = %v, want %v", tt.cfg.Deadline, got, tt.wantTimeout)
+		}
+		if got := tt.cfg.PassOnTimeout(); got != tt.wantPass {
+			t.Errorf("PassOnTimeout(%q) = %v, want %v", tt.cfg.OnTimeout, got, tt.wantPass)
+		}
+	}
+}
+
+func TestResolvedMaintenanceInterval(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", DefaultMaintenanceInterval},
+		{"30m", 30 * time.Minute},
+		{"6h", 6 * time.Hour},
+		{"10s", DefaultMaintenanceInterval},
+		{"bogus", DefaultMaintenanceInterval},
+	}
+	for _, tt := range tests {
+		cfg := &Config{MaintenanceInterval: tt.value}
+		if got := cfg.ResolvedMaintenanceInterval(); got != tt.want {
+			t.Errorf("ResolvedMaintenanceInterval(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestOrgGuidelinesIsGit(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"https://example.com/standards/guidelines.md", false},
+		{"http://intranet/guidelines.txt", false},
+		{"https://github.com/acme/standards.git", true},
+		{"HTTPS://github.com/acme/standards.git/", true},
+		{"git@github.com:acme/standards.git", true},
+		{"/srv/git/standards", true},
+	}
+	for _, tt := range tests {
+		if got := (OrgGuidelinesConfig{Source: tt.source}).IsGit(); got != tt.want {
+			t.Errorf("IsGit(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestResolveMaxReviewOutputSize(t *testing.T) {
+	t.Run("default when no config", func(t *testing.T) {
+		if size := ResolveMaxReviewOutputSize(t.TempDir(), nil); size != DefaultMaxReviewOutputSize {
+			t.Errorf("Expected default %d, got %d", DefaultMaxReviewOutputSize, size)
+		}
+	})
+
+	t.Run("global config takes precedence over default", func(t *testing.T) {
+		cfg := &Config{DefaultMaxReviewOutputSize: 16 * 1024}
+		if size := ResolveMaxReviewOutputSize(t.TempDir(), cfg); size != 16*1024 {
+			t.Errorf("Expected 16KB from global config, got %d", size)
+		}
+	})
+
+	t.Run("repo config takes precedence over global", func(t *testing.T) {
+		tmpDir := newTempRepo(t, `max_review_output_size = 8000`)
+		cfg := &Config{DefaultMaxReviewOutputSize: 16 * 1024}
+		if size := ResolveMaxReviewOutputSize(tmpDir, cfg); size != 8000 {
+			t.Errorf("Expected 8000 from repo config, got %d", size)
+		}
+	})
+
+	t.Run("negative values fall through to default", func(t *testing.T) {
+		tmpDir := newTempRepo(t, `max_review_output_size = -1`)
+		cfg := &Config{DefaultMaxReviewOutputSize: -1}
+		if size := ResolveMaxReviewOutputSize(tmpDir, cfg); size != DefaultMaxReviewOutputSize {
+			t.Errorf("Expected default %d, got %d", DefaultMaxReviewOutputSize, size)
+		}
+	})
+}
+
+func TestResolveMaxFindings(t *testing.T) {
+	if n := ResolveMaxFindings(t.TempDir(), nil); n != 0 {
+		t.Errorf("Expected no limit by default, got %d", n)
+	}
+	if n := ResolveMaxFindings(t.TempDir(), &Config{DefaultMaxFindings: 10}); n != 10 {
+		t.Errorf("Expected 10 from global config, got %d", n)
+	}
+	tmpDir := newTempRepo(t, `max_findings = 5`)
+	if n := ResolveMaxFindings(tmpDir, &Config{DefaultMaxFindings: 10}); n != 5 {
+		t.Errorf("Expected 5 from repo config, got %d", n)
+	}
+}
+
+func TestResolvePreviousReviewsFormat(t *testing.T) {
+	if f := ResolvePreviousReviewsFormat(t.TempDir(), nil); f != PreviousReviewsFindings {
+		t.Errorf("Expected findings by default, got %q", f)
+	}
+	if f := ResolvePreviousReviewsFormat(t.TempDir(), &Config{PreviousReviewsFormat: "Full"}); f != PreviousReviewsFull {
+		t.Errorf("Expected full from global config, got %q", f)
+	}
+	tmpDir := newTempRepo(t, `previous_reviews_format = "findings"`)
+	if f := ResolvePreviousReviewsFormat(tmpDir, &Config{PreviousReviewsFormat: "full"}); f != PreviousReviewsFindings {
+		t.Errorf("Expected findings from repo config, got %q", f)
+	}
+	tmpDir = newTempRepo(t, `previous_reviews_format = "verbose"`)
+	if f := ResolvePreviousReviewsFormat(tmpDir, &Config{PreviousReviewsFormat: "full"}); f != PreviousReviewsFull {
+		t.Errorf("Expected an unknown repo value to be ignored, got %q", f)
+	}
+}
+
+func TestResolveContextCommits(t *testing.T) {
+	five, zero := 5, 0
+	if n := ResolveContextCommits(nil, t.TempDir(), &Config{ReviewContextCount: 3}); n != 3 {
+		t.Errorf("Expected global review_context_count, got %d", n)
+	}
+	tmpDir := newTempRepo(t, `context_commits = 10`)
+	if n := ResolveContextCommits(nil, tmpDir, &Config{ReviewContextCount: 3}); n != 10 {
+		t.Errorf("Expected repo context_commits, got %d", n)
+	}
+	if n := ResolveContextCommits(&five, tmpDir, &Config{ReviewContextCount: 3}); n != 5 {
+		t.Errorf("Expected explicit count, got %d", n)
+	}
+	if n := ResolveContextCommits(&zero, tmpDir, &Config{ReviewContextCount: 3}); n != 0 {
+		t.Errorf("Expected explicit zero to disable context, got %d", n)
+	}
+	tmpDir = newTempRepo(t, `context_commits = 0`)
+	if n := ResolveContextCommits(nil, tmpDir, &Config{ReviewContextCount: 3}); n != 0 {
+		t.Errorf("Expected repo zero to disable context, got %d", n)
+	}
+}
+
+func TestResolveOutputLanguage(t *testing.T) {
+	if l := ResolveOutputLanguage(t.TempDir(), nil); l != "" {
+		t.Errorf("Expected no language by default, got %q", l)
+	}
+	if l := ResolveOutputLanguage(t.TempDir(), &Config{OutputLanguage: " de "}); l != "de" {
+		t.Errorf("Expected de from global config, got %q", l)
+	}
+	tmpDir := newTempRepo(t, `output_language = "Japanese"`)
+	if l := ResolveOutputLanguage(tmpDir, &Config{OutputLanguage: "de"}); l != "Japanese" {
+		t.Errorf("Expected Japanese from repo config, got %q", l)
+	}
+}
+
+func TestResolveCommitTrailers(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		if ResolveCommitTrailers(t.TempDir(), nil) {
+			t.Error("Expected commit trailers to be disabled by default")
+		}
+	})
+
+	t.Run("global config enables", func(t *testing.T) {
+		if !ResolveCommitTrailers(t.TempDir(), &Config{CommitTrailers: true}) {
+			t.Error("Expected global commit_trailers to enable trailers")
+		}
+	})
+
+	t.Run("repo config overrides global", func(t *testing.T) {
+		tmpDir := newTempRepo(t, `commit_trailers = false`)
+		if ResolveCommitTrailers(tmpDir, &Config{CommitTrailers: true}) {
+			t.Error("Expected repo commit_trailers = false to win over global")
+		}
+	})
+}
+
+func TestResolveSkipConfig(t *testing.T) {
+	global := &Config{Skip: SkipConfig{MessagePatterns: []string{"^wip"}, DocsOnly: true}}
+
+	t.Run("global applies without repo section", func(t *testing.T) {
+		got := ResolveSkipConfig(newTempRepo(t, `agent = "codex"`), global)
+		if !got.DocsOnly || len(got.MessagePatterns) != 1 {
+			t.Errorf("Expected global skip rules, got %+v", got)
+		}
+	})
+
+	t.Run("repo section replaces global", func(t *testing.T) {
+		got := ResolveSkipConfig(newTempRepo(t, "[skip]\nmax_diff_lines = 5\n"), global)
+		if got.MaxDiffLines != 5 || got.DocsOnly || len(got.MessagePatterns) != 0 {
+			t.Errorf("Expected repo skip rules only, got %+v", got)
+		}
+	})
+
+	t.Run("nil global config", func(t *testing.T) {
+		if got := ResolveSkipConfig(t.TempDir(), nil); !got.IsZero() {
+			t.Errorf("Expected no skip rules, got %+v", got)
+		}
+	})
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		in          string
+		first, last int
+		wantErr     bool
+	}{
+		{in: "7373-7380", first: 7373, last: 7380},
+		{in: " 8000 - 8000 ", first: 8000, last: 8000},
+		{in: "9000", first: 9000, last: 9000},
+		{in: "7380-7373", wantErr: true},
+		{in: "0-10", wantErr: true},
+		{in: "70000", wantErr: true},
+		{in: "http", wantErr: true},
+		{in: "1-2-3", wantErr: true},
+	}
+	for _, tt := range tests {
+		first, last, err := ParsePortRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePortRange(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if first != tt.first || last != tt.last {
+			t.Errorf("ParsePortRange(%q) = %d-%d, want %d-%d", tt.in, first, last, tt.first, tt.last)
+		}
+	}
+}
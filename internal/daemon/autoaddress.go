@@ -0,0 +1,203 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/prompt"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// autoAddressResponder signs the comments auto-address leaves on a job
+const autoAddressResponder = "roborev-auto-address"
+
+// maxAutoAddressCommentPatch caps the patch quoted in a dry-run comment; the
+// full patch is stored as an attachment
+const maxAutoAddressCommentPatch = 16 * 1024
+
+// autoAddressSkipReason returns why a review's findings aren't fixed
+// automatically under policy, or "" when they are. levels are the repo's
+// severity labels, which max_severity may name; the findings' severities
+// are already mapped to built-in levels.
+func autoAddressSkipReason(policy config.AutoAddressConfig, levels []config.SeverityLevel, job *storage.ReviewJob, findings []storage.Finding, defaultBranch string) string {
+	if job.JobType != storage.JobTypeReview || !config.IsDefaultReviewType(job.ReviewType) {
+		return "only standard reviews of single commits are addressed"
+	}
+	branch := git.LocalBranchName(job.Branch)
+	switch {
+	case branch == "":
+		return "the commit's branch is unknown"
+	case branch == git.LocalBranchName(defaultBranch) || (defaultBranch == "" && (branch == "main" || branch == "master")):
+		return fmt.Sprintf("%s is the default branch", branch)
+	}
+	if len(findings) == 0 {
+		return "the review has no structured findings"
+	}
+	if limit := policy.ResolvedMaxFindings(); len(findings) > limit {
+		return fmt.Sprintf("%d findings exceed max_findings (%d)", len(findings), limit)
+	}
+
+	var patterns []*regexp.Regexp
+	for _, p := range policy.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Sprintf("invalid pattern %q: %v", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+	maxSeverity := policy.ResolvedMaxSeverity(levels)
+	for _, f := range findings {
+		if rank := config.SeverityRank(f.Severity); rank == 0 || rank > config.SeverityRank(maxSeverity) {
+			return fmt.Sprintf("a %q finding is above max_severity (%s)", f.Severity, maxSeverity)
+		}
+		if len(patterns) > 0 && !matchesAny(patterns, f.Message) {
+			return fmt.Sprintf("finding %q matches no pattern", f.Message)
+		}
+	}
+	return ""
+}
+
+// matchesAny reports whether any pattern matches s
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// startAutoAddress runs autoAddress for a completed review in the
+// background when the repo's auto_address policy allows it, so the worker
+// is free for the next job. The run stops when the pool does.
+func (wp *WorkerPool) startAutoAddress(cfg *config.Config, job *storage.ReviewJob, agentName string, findings []storage.Finding) {
+	repoCfg, err := config.LoadRepoConfig(job.RepoPath)
+	if err != nil || repoCfg == nil || !repoCfg.AutoAddress.Enabled {
+		return
+	}
+	policy := repoCfg.AutoAddress
+	defaultBranch, _ := git.GetDefaultBranch(job.RepoPath)
+	if reason := autoAddressSkipReason(policy, config.NormalizeSeverities(repoCfg.Severities), job, findings, defaultBranch); reason != "" {
+		log.Printf("Auto-address: skipping job %d: %s", job.ID, reason)
+		return
+	}
+	if budget, err := budgetStatus(wp.db, cfg, time.Now()); err == nil {
+		if exceeded := exceededBudgetFor(budget, job.RepoPath); exceeded != nil {
+			log.Printf("Auto-address: skipping job %d: %s", job.ID, budgetMessage(exceeded))
+			return
+		}
+	}
+
+	wp.wg.Add(1)
+	go func() {
+		defer wp.wg.Done()
+		wp.autoAddress(cfg, policy, job, agentName, len(findings))
+	}()
+}
+
+// autoAddress runs the address flow for a completed review whose findings
+// policy allows fixing. The agent works in a temporary worktree of the
+// reviewed commit. Its changes are stored as a patch on the review, or
+// committed to a roborev/address-<job> branch when dry_run is off. What the
+// agent uses counts toward the review's usage.
+func (wp *WorkerPool) autoAddress(cfg *config.Config, policy config.AutoAddressConfig, job *storage.ReviewJob, agentName string, numFindings int) {
+	report := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		if _, err := wp.db.AddCommentToJob(job.ID, autoAddressResponder, msg); err != nil {
+			log.Printf("Auto-address: error commenting on job %d: %v", job.ID, err)
+		}
+	}
+	fail := func(err error) {
+		log.Printf("Auto-address: job %d: %v", job.ID, err)
+		if wp.errorLog != nil {
+			wp.errorLog.LogError("auto-address", err.Error(), job.ID)
+		}
+		report("Auto-address failed: %v", err)
+	}
+
+	review, err := wp.db.GetReviewByJobID(job.ID)
+	if err != nil {
+		fail(fmt.Errorf("get review: %w", err))
+		return
+	}
+	addressPrompt, err := prompt.NewBuilderWithConfig(wp.db, cfg).BuildAddressPrompt(job.RepoPath, review, nil)
+	if err != nil {
+		fail(fmt.Errorf("build prompt: %w", err))
+		return
+	}
+
+	worktree, cleanup, err := git.AddDetachedWorktree(job.RepoPath, job.GitRef)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer cleanup()
+
+	a, err := agent.GetAvailable(agentName)
+	if err != nil {
+		fail(fmt.Errorf("get agent: %w", err))
+		return
+	}
+	a = a.WithAgentic(true).WithReasoning(agent.ParseReasoningLevel(job.Reasoning)).WithModel(job.Model)
+	// No warm session: the worktree is new, so one could never be reused
+	a = wp.rateLimiter.limit(a, cfg.AgentRateLimits)
+	meter := &usageMeter{}
+	a = meter.wrap(a)
+	usage := &agent.Usage{}
+	defer wp.recordUsage("auto-address", cfg, job, a.Name(), usage, meter)
+
+	timeout := time.Duration(config.ResolveJobTimeout(job.RepoPath, cfg)) * time.Minute
+	ctx, cancel := wp.stopContext(timeout)
+	defer cancel()
+	ctx = agent.WithUsage(ctx, usage)
+	log.Printf("Auto-address: addressing %d finding(s) of job %d with %s", numFindings, job.ID, a.Name())
+	summary, err := a.Review(ctx, worktree, job.GitRef, addressPrompt, io.Discard)
+	if err != nil {
+		if wp.stopping() {
+			log.Printf("Auto-address: job %d interrupted by shutdown", job.ID)
+			return
+		}
+		fail(fmt.Errorf("agent: %w", err))
+		return
+	}
+
+	patch, err := git.GetDirtyDiff(worktree)
+	if err != nil {
+		fail(fmt.Errorf("diff: %w", err))
+		return
+	}
+	if strings.TrimSpace(patch) == "" {
+		report("Auto-address made no changes.\n\n%s", strings.TrimSpace(summary))
+		return
+	}
+
+	if policy.IsDryRun() {
+		if err := wp.db.SaveReviewAttachment(job.ID, storage.AttachmentAutoAddressPatch, patch); err != nil {
+			fail(fmt.Errorf("store patch: %w", err))
+			return
+		}
+		quoted := patch
+		if len(quoted) > maxAutoAddressCommentPatch {
+			quoted = prompt.TruncateUTF8(quoted, maxAutoAddressCommentPatch) + "\n... (truncated)\n"
+		}
+		report("Auto-address dry run: proposed patch for %d finding(s). Apply it with `git apply` on %s.\n\n%s\n\n```diff\n%s```",
+			numFindings, job.Branch, strings.TrimSpace(summary), quoted)
+		return
+	}
+
+	branch := fmt.Sprintf("roborev/address-%d", job.ID)
+	message := fmt.Sprintf("Address review findings (job %d)\n\n%s", job.ID, strings.TrimSpace(summary))
+	sha, err := git.CommitToBranch(worktree, branch, message)
+	if err != nil {
+		fail(err)
+		return
+	}
+	report("Auto-address committed fixes for %d finding(s) to branch %s (%s).\n\n%s", numFindings, branch, sha, strings.TrimSpace(summary))
+}
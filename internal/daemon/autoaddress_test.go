@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestAutoAddressSkipReason(t *testing.T) {
+	job := &storage.ReviewJob{JobType: storage.JobTypeReview, ReviewType: "default", Branch: "feature"}
+	low := []storage.Finding{{Severity: "low", Message: "missing error wrap"}}
+	levels := []config.SeverityLevel{{Name: "minor", Level: "medium"}}
+
+	tests := []struct {
+		name     string
+		policy   config.AutoAddressConfig
+		job      *storage.ReviewJob
+		findings []storage.Finding
+		want     string
+	}{
+		{name: "eligible", job: job, findings: low},
+		{name: "default branch", job: &storage.ReviewJob{JobType: storage.JobTypeReview, Branch: "main"}, findings: low, want: "default branch"},
+		{name: "unknown branch", job: &storage.ReviewJob{JobType: storage.JobTypeReview}, findings: low, want: "unknown"},
+		{name: "range", job: &storage.ReviewJob{JobType: storage.JobTypeRange, Branch: "feature"}, findings: low, want: "single commits"},
+		{name: "security review", job: &storage.ReviewJob{JobType: storage.JobTypeReview, ReviewType: "security", Branch: "feature"}, findings: low, want: "standard reviews"},
+		{name: "no findings", job: job, want: "no structured findings"},
+		{name: "too severe", job: job, findings: []storage.Finding{{Severity: "medium", Message: "x"}}, want: "above max_severity"},
+		{
+			name:     "raised max severity",
+			policy:   config.AutoAddressConfig{MaxSeverity: "medium"},
+			job:      job,
+			findings: []storage.Finding{{Severity: "medium", Message: "x"}},
+		},
+		{
+			name:     "max severity names a repo label",
+			policy:   config.AutoAddressConfig{MaxSeverity: "Minor"},
+			job:      job,
+			findings: []storage.Finding{{Severity: "medium", Label: "minor", Message: "x"}},
+		},
+		{
+			name:     "unknown severity",
+			job:      job,
+			findings: []storage.Finding{{Severity: "nit", Message: "x"}},
+			want:     "above max_severity",
+		},
+		{
+			name:     "too many",
+			policy:   config.AutoAddressConfig{MaxFindings: 1},
+			job:      job,
+			findings: append(low, low...),
+			want:     "exceed max_findings",
+		},
+		{
+			name:     "pattern mismatch",
+			policy:   config.AutoAddressConfig{Patterns: []string{"(?i)wrap"}},
+			job:      job,
+			findings: append(low, storage.Finding{Severity: "low", Message: "rename variable"}),
+			want:     "matches no pattern",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := autoAddressSkipReason(tt.policy, levels, tt.job, tt.findings, "origin/main")
+			if tt.want == "" && got != "" {
+				t.Errorf("expected eligible, got %q", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("expected reason containing %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// editingAgent appends to a file in the repo it runs in, like an agent
+// addressing findings
+type editingAgent struct {
+	*agent.TestAgent
+}
+
+func (a *editingAgent) WithReasoning(agent.ReasoningLevel) agent.Agent { return a }
+func (a *editingAgent) WithAgentic(bool) agent.Agent                   { return a }
+func (a *editingAgent) WithModel(string) agent.Agent                   { return a }
+
+func (a *editingAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	f, err := os.OpenFile(filepath.Join(repoPath, "test.txt"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString("\nfixed\n"); err != nil {
+		return "", err
+	}
+	return "Changes:\n- wrapped the error", nil
+}
+
+func TestAutoAddress(t *testing.T) {
+	agent.Register(&editingAgent{agent.NewTestAgent()})
+	t.Cleanup(func() { agent.Register(agent.NewTestAgent()) })
+
+	for _, dryRun := range []bool{true, false} {
+		name := "commit"
+		if dryRun {
+			name = "dry run"
+		}
+		t.Run(name, func(t *testing.T) {
+			tc := newWorkerTestContext(t, 1)
+			sha := testutil.GetHeadSHA(t, tc.TmpDir)
+			toml := "[auto_address]\nenabled = true\n"
+			if !dryRun {
+				toml += "dry_run = false\n"
+			}
+			if err := os.WriteFile(filepath.Join(tc.TmpDir, ".roborev.toml"), []byte(toml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			commit, err := tc.DB.GetOrCreateCommit(tc.Repo.ID, sha, "Author", "Subject", time.Now())
+			if err != nil {
+				t.Fatalf("GetOrCreateCommit failed: %v", err)
+			}
+			enqueued, err := tc.DB.EnqueueJob(storage.EnqueueOpts{RepoID: tc.Repo.ID, CommitID: commit.ID, GitRef: sha, Branch: "feature", Agent: "test"})
+			if err != nil {
+				t.Fatalf("EnqueueJob failed: %v", err)
+			}
+			if _, err := tc.DB.ClaimJob("w"); err != nil {
+				t.Fatalf("ClaimJob failed: %v", err)
+			}
+			if err := tc.DB.CompleteJob(enqueued.ID, "test", "prompt", "- Low: test.txt:1 - missing error wrap"); err != nil {
+				t.Fatalf("CompleteJob failed: %v", err)
+			}
+			job, err := tc.DB.GetJobByID(enqueued.ID)
+			if err != nil {
+				t.Fatalf("GetJobByID failed: %v", err)
+			}
+
+			tc.Pool.startAutoAddress(config.DefaultConfig(), job, "test", []storage.Finding{{Severity: "low", Message: "missing error wrap"}})
+			// The pool isn't started, so this waits for just the auto-address run
+			tc.Pool.wg.Wait()
+
+			comments, err := tc.DB.GetCommentsForJob(job.ID)
+			if err != nil || len(comments) != 1 {
+				t.Fatalf("expected one comment, got %v (err %v)", comments, err)
+			}
+			comment := comments[0].Response
+			if data, err := os.ReadFile(filepath.Join(tc.TmpDir, "test.txt")); err != nil || string(data) != "test content" {
+				t.Errorf("the repo's working tree was modified: %q", data)
+			}
+
+			if dryRun {
+				patch, err := tc.DB.GetReviewAttachment(job.ID, storage.AttachmentAutoAddressPatch)
+				if err != nil {
+					t.Fatalf("GetReviewAttachment failed: %v", err)
+				}
+				if !strings.Contains(patch, "+fixed") || !strings.Contains(comment, "dry run") || !strings.Contains(comment, "+fixed") {
+					t.Errorf("unexpected patch %q or comment %q", patch, comment)
+				}
+				return
+			}
+			branch := fmt.Sprintf("roborev/address-%d", job.ID)
+			if !strings.Contains(comment, branch) {
+				t.Errorf("expected the comment to name %s, got %q", branch, comment)
+			}
+			out, err := exec.Command("git", "-C", tc.TmpDir, "show", branch+":test.txt").CombinedOutput()
+			if err != nil || !strings.Contains(string(out), "fixed") {
+				t.Errorf("expected the fix committed to %s, got %q (err %v)", branch, out, err)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ type Event struct {
 	Verdict  string    `json:"verdict,omitempty"`
 	Findings string    `json:"findings,omitempty"`
 	Error    string    `json:"error,omitempty"`
+	Reminder int       `json:"reminder,omitempty"` // review.stale only: 1 for the first reminder, then 2, 3, ...
 }
 
 // Subscriber represents a client subscribed to events
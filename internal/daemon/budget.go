@@ -0,0 +1,190 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// bytesPerToken approximates how much text a token covers
+const bytesPerToken = 4
+
+// estimateUsage estimates the tokens a job used from the text it sent and
+// received, and their cost at the agent's configured price
+func estimateUsage(cfg *config.Config, agentName string, texts ...string) (int, float64) {
+	n := 0
+	for _, t := range texts {
+		n += len(t)
+	}
+	tokens := n / bytesPerToken
+	var price float64
+	if cfg != nil {
+		price = cfg.AgentCosts[agentName]
+	}
+	return tokens, float64(tokens) * price / 1e6
+}
+
+// jobUsage returns the tokens a job used and their cost, preferring what
+// the agent reported over an estimate. A configured price applies to
+// reported tokens too, since agents report list prices.
+func jobUsage(cfg *config.Config, agentName string, usage *agent.Usage, texts ...string) (int, float64) {
+	report, ok := usage.Report()
+	if !ok || report.Tokens() == 0 {
+		return estimateUsage(cfg, agentName, texts...)
+	}
+	tokens := report.Tokens()
+	if cfg != nil {
+		if price, ok := cfg.AgentCosts[agentName]; ok {
+			return tokens, float64(tokens) * price / 1e6
+		}
+	}
+	return tokens, report.CostUSD
+}
+
+// usageMeter counts the bytes a job's agent requests send and receive,
+// for estimating the job's usage when the agent doesn't report it
+type usageMeter struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+// wrap makes a count every request it makes in m
+func (m *usageMeter) wrap(a agent.Agent) agent.Agent {
+	return &meteredAgent{Agent: a, meter: m}
+}
+
+func (m *usageMeter) add(texts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.texts = append(m.texts, texts...)
+}
+
+func (m *usageMeter) all() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.texts...)
+}
+
+// meteredAgent records each request's prompt and reply in its meter,
+// including corrective retries, summaries, and requests that fail
+type meteredAgent struct {
+	agent.Agent
+	meter *usageMeter
+}
+
+func (m *meteredAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	result, err := m.Agent.Review(ctx, repoPath, commitSHA, prompt, output)
+	m.meter.add(prompt, result)
+	return result, err
+}
+
+func (m *meteredAgent) WithReasoning(level agent.ReasoningLevel) agent.Agent {
+	return &meteredAgent{Agent: m.Agent.WithReasoning(level), meter: m.meter}
+}
+
+func (m *meteredAgent) WithAgentic(agentic bool) agent.Agent {
+	return &meteredAgent{Agent: m.Agent.WithAgentic(agentic), meter: m.meter}
+}
+
+func (m *meteredAgent) WithModel(model string) agent.Agent {
+	return &meteredAgent{Agent: m.Agent.WithModel(model), meter: m.meter}
+}
+
+// startOfDay returns local midnight on t's day, when daily budgets reset
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// budgetStatus totals today's estimated spending and lists the global and
+// per-repo daily budgets it has reached. It returns nil when nothing is
+// being spent or capped.
+func budgetStatus(db *storage.DB, cfg *config.Config, now time.Time) (*storage.BudgetStatus, error) {
+	spend, err := db.GetSpendSince(startOfDay(now))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &storage.BudgetStatus{
+		DailyLimitUSD: cfg.DailyBudgetUSD,
+		FallbackAgent: cfg.BudgetFallbackAgent,
+	}
+	for _, s := range spend {
+		status.SpentTodayUSD += s.CostUSD
+		repoCfg, err := config.LoadRepoConfig(s.RepoPath)
+		if err != nil || repoCfg == nil || repoCfg.DailyBudgetUSD <= 0 {
+			continue
+		}
+		if s.CostUSD >= repoCfg.DailyBudgetUSD {
+			status.Exceeded = append(status.Exceeded, storage.BudgetExceeded{
+				RepoPath: s.RepoPath,
+				SpentUSD: s.CostUSD,
+				LimitUSD: repoCfg.DailyBudgetUSD,
+			})
+		}
+	}
+	if cfg.DailyBudgetUSD > 0 && status.SpentTodayUSD >= cfg.DailyBudgetUSD {
+		status.Exceeded = append([]storage.BudgetExceeded{{
+			SpentUSD: status.SpentTodayUSD,
+			LimitUSD: cfg.DailyBudgetUSD,
+		}}, status.Exceeded...)
+	}
+
+	if status.SpentTodayUSD == 0 && status.DailyLimitUSD == 0 {
+		return nil, nil
+	}
+	return status, nil
+}
+
+// exceededBudgetFor returns the reached budget that applies to a repo, if
+// any, checking the global budget first
+func exceededBudgetFor(status *storage.BudgetStatus, repoPath string) *storage.BudgetExceeded {
+	if status == nil {
+		return nil
+	}
+	for i, e := range status.Exceeded {
+		if e.RepoPath == "" || e.RepoPath == repoPath {
+			return &status.Exceeded[i]
+		}
+	}
+	return nil
+}
+
+// budgetAgent returns the agent a job claimed from the queue should run
+// with now that budgets may have been reached since it was enqueued: its
+// own agent, the fallback agent, or "" with the reason it can't run.
+func budgetAgent(db *storage.DB, cfg *config.Config, job *storage.ReviewJob) (string, string) {
+	budget, err := budgetStatus(db, cfg, time.Now())
+	if err != nil {
+		// Don't hold up the queue over a failed spending lookup
+		return job.Agent, ""
+	}
+	exceeded := exceededBudgetFor(budget, job.RepoPath)
+	if exceeded == nil {
+		return job.Agent, ""
+	}
+	fallback := budget.FallbackAgent
+	if fallback == "" {
+		return "", budgetMessage(exceeded) + "; job not run"
+	}
+	fallbackAgent, err := agent.Get(fallback)
+	if err != nil || !agent.IsAvailable(fallback) {
+		return "", fmt.Sprintf("%s; budget fallback agent %q is not available", budgetMessage(exceeded), fallback)
+	}
+	return fallbackAgent.Name(), ""
+}
+
+// budgetMessage describes a reached budget
+func budgetMessage(e *storage.BudgetExceeded) string {
+	scope := "all repos"
+	if e.RepoPath != "" {
+		scope = e.RepoPath
+	}
+	return fmt.Sprintf("daily budget for %s reached ($%.2f of $%.2f)", scope, e.SpentUSD, e.LimitUSD)
+}
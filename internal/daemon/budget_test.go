@@ -0,0 +1,197 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestEstimateUsage(t *testing.T) {
+	cfg := &config.Config{AgentCosts: map[string]float64{"codex": 2}}
+	tokens, cost := estimateUsage(cfg, "codex", string(make([]byte, 3000)), string(make([]byte, 1000)))
+	if tokens != 1000 {
+		t.Errorf("tokens = %d, want 1000", tokens)
+	}
+	if cost != 0.002 {
+		t.Errorf("cost = %v, want 0.002", cost)
+	}
+	if _, cost := estimateUsage(cfg, "gemini", "text"); cost != 0 {
+		t.Errorf("agents without a price should be free, got %v", cost)
+	}
+}
+
+func TestJobUsage(t *testing.T) {
+	cfg := &config.Config{AgentCosts: map[string]float64{"codex": 2}}
+	usage := &agent.Usage{}
+	if tokens, _ := jobUsage(cfg, "codex", usage, string(make([]byte, 400))); tokens != 100 {
+		t.Errorf("expected an estimate without reported usage, got %d tokens", tokens)
+	}
+
+	a := &agent.TestAgent{Usage: &agent.UsageReport{InputTokens: 1500, OutputTokens: 500, CostUSD: 0.5}}
+	if _, err := a.Review(agent.WithUsage(context.Background(), usage), "", "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if tokens, cost := jobUsage(cfg, "codex", usage); tokens != 2000 || cost != 0.004 {
+		t.Errorf("expected configured price applied to reported tokens, got %d tokens, $%v", tokens, cost)
+	}
+	if tokens, cost := jobUsage(cfg, "claude-code", usage); tokens != 2000 || cost != 0.5 {
+		t.Errorf("expected reported cost without a configured price, got %d tokens, $%v", tokens, cost)
+	}
+}
+
+func TestHandleEnqueueBudget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake agent")
+	}
+	server, db, tmpDir := newTestServer(t)
+	cfg := server.configWatcher.Config()
+
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+	repo, err := db.GetOrCreateRepo(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Spend $3 today in the repo
+	commit, err := db.GetOrCreateCommit(repo.ID, "abc123", "Author", "Subject", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "abc123", Agent: "codex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ClaimJob("w"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CompleteJob(job.ID, "codex", "prompt", "output"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RecordJobUsage(job.ID, 1000, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	defer testutil.MockBinaryInPath(t, "codex", "#!/bin/sh\nexit 0\n")()
+	enqueue := func() *httptest.ResponseRecorder {
+		reqData := map[string]any{"repo_path": repoDir, "git_ref": "HEAD", "agent": "codex", "force": true}
+		w := httptest.NewRecorder()
+		server.handleEnqueue(w, testutil.MakeJSONRequest(t, http.MethodPost, "/api/enqueue", reqData))
+		return w
+	}
+
+	t.Run("under budget", func(t *testing.T) {
+		cfg.DailyBudgetUSD = 5
+		if w := enqueue(); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("repo budget pauses enqueuing", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(repoDir, ".roborev.toml"), []byte("daily_budget_usd = 2\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Remove(filepath.Join(repoDir, ".roborev.toml")) })
+
+		w := enqueue()
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status 429, got %d: %s", w.Code, w.Body.String())
+		}
+
+		status, err := budgetStatus(db, cfg, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status == nil || status.SpentTodayUSD != 3 || len(status.Exceeded) != 1 || status.Exceeded[0].RepoPath != repo.RootPath {
+			t.Errorf("unexpected budget status %+v", status)
+		}
+	})
+
+	t.Run("global budget switches to fallback agent", func(t *testing.T) {
+		cfg.DailyBudgetUSD = 3
+		cfg.BudgetFallbackAgent = "test"
+		t.Cleanup(func() { cfg.DailyBudgetUSD, cfg.BudgetFallbackAgent = 0, "" })
+
+		w := enqueue()
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var job storage.ReviewJob
+		testutil.DecodeJSON(t, w, &job)
+		if job.Agent != "test" {
+			t.Errorf("Expected fallback agent, got %q", job.Agent)
+		}
+	})
+
+	t.Run("spending resets the next day", func(t *testing.T) {
+		cfg.DailyBudgetUSD = 3
+		t.Cleanup(func() { cfg.DailyBudgetUSD = 0 })
+
+		status, err := budgetStatus(db, cfg, time.Now().Add(24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.SpentTodayUSD != 0 || len(status.Exceeded) != 0 {
+			t.Errorf("expected a fresh budget tomorrow, got %+v", status)
+		}
+	})
+}
+
+func TestWorkerRecordsUsageOfFailedJobs(t *testing.T) {
+	failing := agent.NewTestAgent()
+	failing.Delay = 0
+	failing.Fail = true
+	agent.Register(failing)
+	t.Cleanup(func() { agent.Register(agent.NewTestAgent()) })
+
+	tc := newWorkerTestContext(t, 1)
+	// Price tokens at $1 each so any usage shows up as spending
+	tc.Pool.cfgGetter.Config().AgentCosts = map[string]float64{"test": 1e6}
+	job := tc.createJob(t, testutil.GetHeadSHA(t, tc.TmpDir))
+	tc.Pool.Start()
+	tc.waitForJobStatus(t, job.ID, storage.JobStatusFailed)
+	tc.Pool.Stop()
+
+	spend, err := tc.DB.GetSpendSince(startOfDay(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spend) != 1 || spend[0].CostUSD <= 0 {
+		t.Errorf("expected the failed job's requests to be counted, got %+v", spend)
+	}
+}
+
+func TestWorkerChecksBudgetAtClaim(t *testing.T) {
+	tc := newWorkerTestContext(t, 1)
+	cfg := tc.Pool.cfgGetter.Config()
+
+	// Spend $3 today, then set a $1 budget with a job already queued
+	spent := tc.createAndClaimJob(t, "abc123", "w")
+	if err := tc.DB.CompleteJob(spent.ID, "test", "prompt", "output"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.DB.RecordJobUsage(spent.ID, 1000, 3); err != nil {
+		t.Fatal(err)
+	}
+	job := tc.createJob(t, testutil.GetHeadSHA(t, tc.TmpDir))
+	cfg.DailyBudgetUSD = 1
+
+	tc.Pool.Start()
+	failed := tc.waitForJobStatus(t, job.ID, storage.JobStatusFailed)
+	tc.Pool.Stop()
+
+	if !strings.Contains(failed.Error, "daily budget") {
+		t.Errorf("expected the job to fail on the budget, got error %q", failed.Error)
+	}
+}
@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxPRFeedbackLen caps the human review comments included in a prompt
+const maxPRFeedbackLen = 20000
+
+// ghUser is the author of a GitHub review or comment
+type ghUser struct {
+	Login string `json:"login"`
+	Type  string `json:"type"` // "User" or "Bot"
+}
+
+// ghPRComment is a review, an inline review comment, or a conversation
+// comment on a pull request, as returned by the GitHub REST API
+type ghPRComment struct {
+	ID                  int64     `json:"id"`
+	User                ghUser    `json:"user"`
+	Body                string    `json:"body"`
+	State               string    `json:"state"`                  // Reviews only
+	Path                string    `json:"path"`                   // Inline comments only
+	Line                int       `json:"line"`                   // Inline comments only; 0 once outdated
+	OriginalLine        int       `json:"original_line"`          // Inline comments only
+	PullRequestReviewID int64     `json:"pull_request_review_id"` // Inline comments only
+	CreatedAt           time.Time `json:"created_at"`
+	SubmittedAt         time.Time `json:"submitted_at"` // Reviews only
+}
+
+// prFeedback fetches the comments human reviewers have left on a PR and
+// formats them for the review prompt
+func (p *CIPoller) prFeedback(ghRepo string, prNumber int) (string, error) {
+	reviews, err := p.ghAPIList(ghRepo, fmt.Sprintf("repos/%s/pulls/%d/reviews", ghRepo, prNumber))
+	if err != nil {
+		return "", err
+	}
+	inline, err := p.ghAPIList(ghRepo, fmt.Sprintf("repos/%s/pulls/%d/comments", ghRepo, prNumber))
+	if err != nil {
+		return "", err
+	}
+	conversation, err := p.ghAPIList(ghRepo, fmt.Sprintf("repos/%s/issues/%d/comments", ghRepo, prNumber))
+	if err != nil {
+		return "", err
+	}
+	return formatPRFeedback(reviews, inline, conversation), nil
+}
+
+// ghAPIList fetches every page of a GitHub list endpoint
+func (p *CIPoller) ghAPIList(ghRepo, endpoint string) ([]ghPRComment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gh", "api", "--paginate", endpoint)
+	if env := p.ghEnvForRepo(ghRepo); env != nil {
+		cmd.Env = env
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("gh api %s: %s", endpoint, string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("gh api %s: %w", endpoint, err)
+	}
+
+	// --paginate prints one JSON array per page
+	var all []ghPRComment
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var page []ghPRComment
+		if err := dec.Decode(&page); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("parse gh api %s: %w", endpoint, err)
+		}
+		all = append(all, page...)
+	}
+	return all, nil
+}
+
+// isRoborevComment reports whether a comment was posted by roborev itself,
+// which can share an account with a human when gh uses a personal token
+func isRoborevComment(body string) bool {
+	return strings.HasPrefix(strings.TrimSpace(body), "## roborev")
+}
+
+// formatPRFeedback lists human review comments oldest first, one bullet
+// each. Bots, roborev's own comments, and empty bodies are skipped.
+func formatPRFeedback(reviews, inline, conversation []ghPRComment) string {
+	type entry struct {
+		at   time.Time
+		text string
+	}
+	var entries []entry
+	add := func(c ghPRComment, at time.Time, label string) {
+		body := strings.TrimSpace(c.Body)
+		if body == "" || c.User.Type == "Bot" || isRoborevComment(body) {
+			return
+		}
+		body = strings.ReplaceAll(body, "\r\n", "\n")
+		body = strings.ReplaceAll(body, "\n", "\n  ")
+		entries = append(entries, entry{at: at, text: fmt.Sprintf("- @%s%s: %s\n", c.User.Login, label, body)})
+	}
+
+	ownReviews := make(map[int64]bool)
+	for _, r := range reviews {
+		if isRoborevComment(r.Body) {
+			ownReviews[r.ID] = true
+			continue
+		}
+		if r.State == "PENDING" {
+			continue
+		}
+		label := ""
+		switch r.State {
+		case "APPROVED":
+			label = " (approved)"
+		case "CHANGES_REQUESTED":
+			label = " (requested changes)"
+		}
+		add(r, r.SubmittedAt, label)
+	}
+	for _, c := range inline {
+		if ownReviews[c.PullRequestReviewID] {
+			continue
+		}
+		line := c.Line
+		if line == 0 {
+			line = c.OriginalLine
+		}
+		label := " on `" + c.Path + "`"
+		if line > 0 {
+			label = fmt.Sprintf(" on `%s:%d`", c.Path, line)
+		}
+		add(c, c.CreatedAt, label)
+	}
+	for _, c := range conversation {
+		add(c, c.CreatedAt, "")
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+
+	var b strings.Builder
+	for i, e := range entries {
+		if b.Len()+len(e.text) > maxPRFeedbackLen {
+			fmt.Fprintf(&b, "... (%d more comments omitted)\n", len(entries)-i)
+			break
+		}
+		b.WriteString(e.text)
+	}
+	return b.String()
+}
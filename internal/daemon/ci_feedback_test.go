@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestFormatPRFeedback(t *testing.T) {
+	at := func(min int) time.Time { return time.Date(2026, 1, 1, 12, min, 0, 0, time.UTC) }
+	alice := ghUser{Login: "alice", Type: "User"}
+	bob := ghUser{Login: "bob", Type: "User"}
+
+	reviews := []ghPRComment{
+		{ID: 1, User: alice, Body: "Needs a lock.", State: "CHANGES_REQUESTED", SubmittedAt: at(2)},
+		{ID: 2, User: bob, Body: "## roborev: Fail\n\nfindings", State: "COMMENTED", SubmittedAt: at(3)},
+		{ID: 3, User: bob, Body: "", State: "APPROVED", SubmittedAt: at(4)},
+		{ID: 4, User: alice, Body: "draft", State: "PENDING", SubmittedAt: at(5)},
+	}
+	inline := []ghPRComment{
+		{User: alice, Body: "Race here\r\nsee issue 12", Path: "cache.go", Line: 42, PullRequestReviewID: 1, CreatedAt: at(1)},
+		{User: bob, Body: "Unchecked error", Path: "main.go", Line: 7, PullRequestReviewID: 2, CreatedAt: at(3)},
+		{User: bob, Body: "Outdated note", Path: "old.go", OriginalLine: 9, PullRequestReviewID: 5, CreatedAt: at(6)},
+	}
+	conversation := []ghPRComment{
+		{User: ghUser{Login: "ci-bot", Type: "Bot"}, Body: "Coverage dropped", CreatedAt: at(0)},
+		{User: bob, Body: "Can we split this PR?", CreatedAt: at(7)},
+	}
+
+	got := formatPRFeedback(reviews, inline, conversation)
+	want := "- @alice on `cache.go:42`: Race here\n  see issue 12\n" +
+		"- @alice (requested changes): Needs a lock.\n" +
+		"- @bob on `old.go:9`: Outdated note\n" +
+		"- @bob: Can we split this PR?\n"
+	if got != want {
+		t.Errorf("formatPRFeedback() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatPRFeedbackTruncates(t *testing.T) {
+	var conversation []ghPRComment
+	for range 100 {
+		conversation = append(conversation, ghPRComment{User: ghUser{Login: "alice", Type: "User"}, Body: strings.Repeat("x", 500)})
+	}
+	got := formatPRFeedback(nil, nil, conversation)
+	if len(got) > maxPRFeedbackLen+100 {
+		t.Errorf("feedback not capped: %d bytes", len(got))
+	}
+	if !strings.Contains(got, "more comments omitted") {
+		t.Error("expected an omission note")
+	}
+}
+
+func TestCIPollerProcessPR_StoresPRFeedback(t *testing.T) {
+	h := newCIPollerHarness(t, "git@github.com:acme/api.git")
+	h.Cfg.CI.ReviewTypes = []string{"security"}
+	h.Cfg.CI.Agents = []string{"codex"}
+	h.Poller = NewCIPoller(h.DB, NewStaticConfig(h.Cfg), nil)
+	h.stubProcessPRGit()
+	h.Poller.prFeedbackFn = func(ghRepo string, prNumber int) (string, error) {
+		if ghRepo != "acme/api" || prNumber != 60 {
+			t.Errorf("prFeedback(%s, %d), want acme/api#60", ghRepo, prNumber)
+		}
+		return "- @alice: Needs a lock.\n", nil
+	}
+
+	err := h.Poller.processPR(context.Background(), "acme/api", ghPR{
+		Number: 60, HeadRefOid: "feedback-sha", BaseRefName: "main",
+	}, h.Cfg)
+	if err != nil {
+		t.Fatalf("processPR: %v", err)
+	}
+
+	jobs, err := h.DB.ListJobs("", h.RepoPath, 0, 0, storage.WithGitRef("base-feedback-sha..feedback-sha"))
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("expected 1 job, got %d", len(jobs))
+	}
+	feedback, err := h.DB.GetJobPRFeedback(jobs[0].ID)
+	if err != nil {
+		t.Fatalf("GetJobPRFeedback: %v", err)
+	}
+	if feedback != "- @alice: Needs a lock.\n" {
+		t.Errorf("stored feedback = %q", feedback)
+	}
+}
@@ -0,0 +1,278 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/prompt"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// prReview is the payload for GitHub's "create a review" endpoint.
+// Findings anchored to the diff go in Comments; Body carries the
+// summary comment.
+type prReview struct {
+	CommitID string            `json:"commit_id"`
+	Event    string            `json:"event"`
+	Body     string            `json:"body"`
+	Comments []prReviewComment `json:"comments"`
+}
+
+// prReviewComment is a single inline comment on a line of the PR diff
+type prReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// reviewFinding is a finding from review output that references a file line
+type reviewFinding struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Title     string
+	Text      string
+}
+
+// extractFindings keeps the findings in review output that reference a
+// file and line, using the shared finding parser
+func extractFindings(output string) []reviewFinding {
+	var findings []reviewFinding
+	for _, f := range storage.FindMarkdownFindings(output) {
+		if f.File == "" || f.Line <= 0 {
+			continue
+		}
+		findings = append(findings, reviewFinding{
+			Path:      strings.TrimPrefix(f.File, "./"),
+			StartLine: f.Line,
+			EndLine:   max(f.LineEnd, f.Line),
+			Title:     f.Title,
+			Text:      f.Message,
+		})
+	}
+	return findings
+}
+
+// parseDiffLines returns, for each file in a unified diff, the new-side
+// line numbers that GitHub accepts for inline comments (added and
+// context lines inside hunks).
+func parseDiffLines(diff string) map[string]map[int]bool {
+	files := make(map[string]map[int]bool)
+	for _, f := range git.ParseDiff(diff) {
+		if f.NewPath == "" || len(f.Hunks) == 0 {
+			continue
+		}
+		lines := make(map[int]bool)
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Kind == ' ' || l.Kind == '+' {
+					lines[l.NewLine] = true
+				}
+			}
+		}
+		files[f.NewPath] = lines
+	}
+	return files
+}
+
+// resolveDiffPath maps a path from review output to a file in the diff.
+// Reviews sometimes shorten paths, so a unique suffix match is accepted.
+func resolveDiffPath(path string, files map[string]map[int]bool) (string, bool) {
+	if _, ok := files[path]; ok {
+		return path, true
+	}
+	var match string
+	for f := range files {
+		if strings.HasSuffix(f, "/"+path) {
+			if match != "" {
+				return "", false // ambiguous
+			}
+			match = f
+		}
+	}
+	return match, match != ""
+}
+
+// buildInlineReview anchors findings to commentable diff lines. Since the
+// summary comment repeats every finding, the review body keeps only its
+// heading and footer, a count of the inline comments, and the findings
+// that could not be anchored, so each finding appears exactly once.
+func buildInlineReview(headSHA, summary string, findings []reviewFinding, files map[string]map[int]bool) *prReview {
+	review := &prReview{CommitID: headSHA, Event: "COMMENT"}
+	var unanchored []reviewFinding
+
+	for _, f := range findings {
+		path, ok := resolveDiffPath(f.Path, files)
+		line := 0
+		if ok {
+			for l := f.StartLine; l <= f.EndLine; l++ {
+				if files[path][l] {
+					line = l
+					break
+				}
+			}
+		}
+		if line == 0 {
+			unanchored = append(unanchored, f)
+			continue
+		}
+		review.Comments = append(review.Comments, prReviewComment{
+			Path: path,
+			Line: line,
+			Side: "RIGHT",
+			Body: f.Text,
+		})
+	}
+
+	sort.SliceStable(review.Comments, func(i, j int) bool {
+		if review.Comments[i].Path != review.Comments[j].Path {
+			return review.Comments[i].Path < review.Comments[j].Path
+		}
+		return review.Comments[i].Line < review.Comments[j].Line
+	})
+
+	if len(review.Comments) == 0 {
+		review.Body = summary
+		return review
+	}
+
+	heading, footer := summaryFrame(summary)
+	var b strings.Builder
+	if heading != "" {
+		b.WriteString(heading + "\n\n")
+	}
+	b.WriteString(fmt.Sprintf("%d finding(s) are posted inline on the changed lines.\n", len(review.Comments)))
+	if len(unanchored) > 0 {
+		b.WriteString("\n### Findings outside the diff\n\nThese could not be anchored to a changed line:\n\n")
+		for _, f := range unanchored {
+			ref := fmt.Sprintf("%s:%d", f.Path, f.StartLine)
+			if f.EndLine != f.StartLine {
+				ref += fmt.Sprintf("-%d", f.EndLine)
+			}
+			title := f.Title
+			if title == "" {
+				title, _, _ = strings.Cut(f.Text, "\n")
+			}
+			b.WriteString(fmt.Sprintf("- `%s`: %s\n", ref, strings.TrimSpace(title)))
+		}
+	}
+	if footer != "" {
+		b.WriteString("\n---\n" + footer)
+	}
+	review.Body = b.String()
+
+	return review
+}
+
+// summaryFrame returns the heading line of a summary comment and the
+// italic metadata line after its closing rule, either of which may be empty
+func summaryFrame(summary string) (heading, footer string) {
+	if first, _, _ := strings.Cut(summary, "\n"); strings.HasPrefix(first, "#") {
+		heading = strings.TrimSpace(first)
+	}
+	if i := strings.LastIndex(summary, "\n---\n"); i >= 0 {
+		last := strings.TrimSpace(summary[i+len("\n---\n"):])
+		if strings.HasPrefix(last, "*") && !strings.Contains(last, "\n") {
+			footer = last + "\n"
+		}
+	}
+	return heading, footer
+}
+
+// postBatchComment posts the batch result to the PR. With ci.inline_comments
+// enabled, findings on changed lines are posted as inline review comments;
+// if the diff can't be fetched or GitHub rejects the review, it falls back
+// to a plain PR comment.
+func (p *CIPoller) postBatchComment(batch *storage.CIPRBatch, comment string) error {
+	if !p.cfgGetter.Config().CI.InlineComments || batch.HeadSHA == "" {
+		return p.callPostPRComment(batch.GithubRepo, batch.PRNumber, comment)
+	}
+
+	findings := extractFindings(comment)
+	if len(findings) == 0 {
+		return p.callPostPRComment(batch.GithubRepo, batch.PRNumber, comment)
+	}
+
+	diff, err := p.callPRDiff(batch.GithubRepo, batch.PRNumber)
+	if err != nil {
+		log.Printf("CI poller: could not fetch diff for %s#%d, posting without inline comments: %v",
+			batch.GithubRepo, batch.PRNumber, err)
+		return p.callPostPRComment(batch.GithubRepo, batch.PRNumber, comment)
+	}
+
+	review := buildInlineReview(batch.HeadSHA, comment, findings, parseDiffLines(diff))
+	if len(review.Comments) == 0 {
+		return p.callPostPRComment(batch.GithubRepo, batch.PRNumber, comment)
+	}
+
+	if err := p.callPostPRReview(batch.GithubRepo, batch.PRNumber, review); err != nil {
+		log.Printf("CI poller: inline review rejected for %s#%d, falling back to a summary comment: %v",
+			batch.GithubRepo, batch.PRNumber, err)
+		return p.callPostPRComment(batch.GithubRepo, batch.PRNumber, comment)
+	}
+	return nil
+}
+
+// prDiff fetches the unified diff of a PR using the gh CLI
+func (p *CIPoller) prDiff(ghRepo string, prNumber int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gh", "pr", "diff",
+		"--repo", ghRepo,
+		fmt.Sprintf("%d", prNumber),
+	)
+	if env := p.ghEnvForRepo(ghRepo); env != nil {
+		cmd.Env = env
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("gh pr diff: %s", string(exitErr.Stderr))
+		}
+		return "", fmt.Errorf("gh pr diff: %w", err)
+	}
+	return string(out), nil
+}
+
+// postPRReview creates a PR review with inline comments using the gh CLI.
+// The review body is truncated the same way as plain PR comments.
+func (p *CIPoller) postPRReview(ghRepo string, prNumber int, review *prReview) error {
+	const maxBodyLen = 60000 // leave headroom below GitHub's ~65536 limit
+	payload := *review
+	if len(payload.Body) > maxBodyLen {
+		payload.Body = prompt.TruncateUTF8(payload.Body, maxBodyLen) + "\n\n...(truncated — comment exceeded size limit)"
+	}
+	for i := range payload.Comments {
+		if len(payload.Comments[i].Body) > maxBodyLen {
+			payload.Comments[i].Body = prompt.TruncateUTF8(payload.Comments[i].Body, maxBodyLen) + "\n\n...(truncated)"
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal review: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gh", "api",
+		"--method", "POST",
+		fmt.Sprintf("repos/%s/pulls/%d/reviews", ghRepo, prNumber),
+		"--input", "-",
+	)
+	cmd.Stdin = strings.NewReader(string(data))
+	if env := p.ghEnvForRepo(ghRepo); env != nil {
+		cmd.Env = env
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("gh api create review: %s: %s", err, string(out))
+	}
+	return nil
+}
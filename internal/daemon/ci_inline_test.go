@@ -0,0 +1,191 @@
+package daemon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPRDiff = `diff --git a/internal/db.go b/internal/db.go
+index 1111111..2222222 100644
+--- a/internal/db.go
++++ b/internal/db.go
+@@ -10,4 +10,5 @@ func Open() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	return
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+`
+
+func TestExtractFindings(t *testing.T) {
+	output := `Summary: two issues.
+
+- **High**: SQL built from user input in ` + "`internal/db.go:11`" + `
+  Use a parameterized query.
+- **Low**: Unused variable in main.go, line 7
+- General note without a location
+
+<details>
+1. **Medium** internal/db.go:20-25 leaks a handle
+</details>
+`
+	findings := extractFindings(output)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %d: %+v", len(findings), findings)
+	}
+
+	if findings[0].Path != "internal/db.go" || findings[0].StartLine != 11 || findings[0].EndLine != 11 {
+		t.Errorf("finding 0 = %+v", findings[0])
+	}
+	if !strings.Contains(findings[0].Text, "parameterized query") {
+		t.Errorf("expected continuation line in finding text, got %q", findings[0].Text)
+	}
+	if findings[1].Path != "main.go" || findings[1].StartLine != 7 {
+		t.Errorf("finding 1 = %+v", findings[1])
+	}
+	if findings[2].StartLine != 20 || findings[2].EndLine != 25 {
+		t.Errorf("finding 2 = %+v", findings[2])
+	}
+	if strings.Contains(findings[2].Text, "</details>") {
+		t.Errorf("HTML wrapper leaked into finding text: %q", findings[2].Text)
+	}
+}
+
+func TestParseDiffLines(t *testing.T) {
+	files := parseDiffLines(testPRDiff)
+
+	lines, ok := files["internal/db.go"]
+	if !ok {
+		t.Fatalf("expected internal/db.go in diff, got %v", files)
+	}
+	for _, l := range []int{10, 11, 12, 13} {
+		if !lines[l] {
+			t.Errorf("expected line %d to be commentable", l)
+		}
+	}
+	if lines[9] || lines[20] {
+		t.Errorf("lines outside the hunk should not be commentable: %v", lines)
+	}
+	if _, ok := files["old.go"]; ok {
+		t.Error("deleted file should have no commentable lines")
+	}
+}
+
+func TestBuildInlineReview(t *testing.T) {
+	findings := []reviewFinding{
+		{Path: "db.go", StartLine: 12, EndLine: 12, Text: "bad value"},
+		{Path: "internal/db.go", StartLine: 40, EndLine: 42, Text: "outside the hunk"},
+		{Path: "other.go", StartLine: 1, EndLine: 1, Text: "not in the PR"},
+	}
+
+	summary := "## roborev: Fail\n\n- internal/db.go:12 bad value\n\n---\n*Review type: security | Agent: codex*\n"
+	review := buildInlineReview("abc123", summary, findings, parseDiffLines(testPRDiff))
+
+	if review.CommitID != "abc123" || review.Event != "COMMENT" {
+		t.Errorf("unexpected review header: %+v", review)
+	}
+	if len(review.Comments) != 1 {
+		t.Fatalf("expected 1 inline comment, got %d: %+v", len(review.Comments), review.Comments)
+	}
+	c := review.Comments[0]
+	if c.Path != "internal/db.go" || c.Line != 12 || c.Side != "RIGHT" {
+		t.Errorf("unexpected inline comment: %+v", c)
+	}
+	assertContainsAll(t, review.Body, "review body",
+		"## roborev: Fail",
+		"Findings outside the diff",
+		"`internal/db.go:40-42`: outside the hunk",
+		"`other.go:1`: not in the PR",
+		"1 finding(s) are posted inline",
+		"*Review type: security | Agent: codex*",
+	)
+	if strings.Contains(review.Body, "bad value") {
+		t.Errorf("inline finding repeated in the review body: %q", review.Body)
+	}
+
+	// With nothing anchored the summary is posted as is
+	if r := buildInlineReview("abc123", summary, findings[1:], parseDiffLines(testPRDiff)); r.Body != summary {
+		t.Errorf("expected unchanged summary, got %q", r.Body)
+	}
+}
+
+func TestCIPollerPostBatchCommentInline(t *testing.T) {
+	comment := "## roborev: Fail\n\n- **High**: internal/db.go:11 wrong constant\n- **Low**: docs.md:3 typo\n"
+
+	t.Run("disabled posts plain comment", func(t *testing.T) {
+		h := newCIPollerHarness(t, "https://github.com/acme/api")
+		batch, _ := h.seedBatchJob(t, "acme/api", 3, "head", "a..b", "codex", "security")
+
+		var body string
+		h.Poller.postPRCommentFn = func(_ string, _ int, b string) error { body = b; return nil }
+		h.Poller.prDiffFn = func(string, int) (string, error) {
+			t.Fatal("diff should not be fetched when inline comments are disabled")
+			return "", nil
+		}
+
+		if err := h.Poller.postBatchComment(batch, comment); err != nil {
+			t.Fatalf("postBatchComment: %v", err)
+		}
+		if body != comment {
+			t.Errorf("expected plain comment, got %q", body)
+		}
+	})
+
+	t.Run("enabled posts review with inline comments", func(t *testing.T) {
+		h := newCIPollerHarness(t, "https://github.com/acme/api")
+		h.Cfg.CI.InlineComments = true
+		batch, _ := h.seedBatchJob(t, "acme/api", 3, "head", "a..b", "codex", "security")
+
+		h.Poller.prDiffFn = func(string, int) (string, error) { return testPRDiff, nil }
+		h.Poller.postPRCommentFn = func(string, int, string) error {
+			t.Fatal("plain comment should not be posted when the review succeeds")
+			return nil
+		}
+		var posted *prReview
+		h.Poller.postPRReviewFn = func(repo string, pr int, r *prReview) error {
+			if repo != "acme/api" || pr != 3 {
+				t.Errorf("posted to %s#%d, want acme/api#3", repo, pr)
+			}
+			posted = r
+			return nil
+		}
+
+		if err := h.Poller.postBatchComment(batch, comment); err != nil {
+			t.Fatalf("postBatchComment: %v", err)
+		}
+		if posted == nil || len(posted.Comments) != 1 || posted.CommitID != "head" {
+			t.Fatalf("unexpected review: %+v", posted)
+		}
+		if !strings.Contains(posted.Body, "`docs.md:3`: docs.md:3 typo") {
+			t.Errorf("expected unanchored finding in body, got %q", posted.Body)
+		}
+	})
+
+	t.Run("rejected review falls back to comment", func(t *testing.T) {
+		h := newCIPollerHarness(t, "https://github.com/acme/api")
+		h.Cfg.CI.InlineComments = true
+		batch, _ := h.seedBatchJob(t, "acme/api", 3, "head", "a..b", "codex", "security")
+
+		h.Poller.prDiffFn = func(string, int) (string, error) { return testPRDiff, nil }
+		h.Poller.postPRReviewFn = func(string, int, *prReview) error {
+			return errors.New("422 Unprocessable Entity")
+		}
+		var body string
+		h.Poller.postPRCommentFn = func(_ string, _ int, b string) error { body = b; return nil }
+
+		if err := h.Poller.postBatchComment(batch, comment); err != nil {
+			t.Fatalf("postBatchComment: %v", err)
+		}
+		if body != comment {
+			t.Errorf("expected fallback to the plain comment, got %q", body)
+		}
+	})
+}
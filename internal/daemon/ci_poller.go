@@ -43,6 +43,9 @@ type CIPoller struct {
 	gitFetchPRHeadFn func(context.Context, string, int) error
 	mergeBaseFn      func(string, string, string) (string, error)
 	postPRCommentFn  func(string, int, string) error
+	prDiffFn         func(string, int) (string, error)
+	prFeedbackFn     func(string, int) (string, error)
+	postPRReviewFn   func(string, int, *prReview) error
 	synthesizeFn     func(*storage.CIPRBatch, []storage.BatchReviewResult, *config.Config) (string, error)
 	agentResolverFn  func(name string) (string, error) // returns resolved agent name
 	jobCancelFn      func(jobID int64)                 // kills running worker process (optional)
@@ -69,6 +72,9 @@ func NewCIPoller(db *storage.DB, cfgGetter ConfigGetter, broadcaster Broadcaster
 	p.gitFetchPRHeadFn = gitFetchPRHead
 	p.mergeBaseFn = gitpkg.GetMergeBase
 	p.postPRCommentFn = p.postPRComment
+	p.prDiffFn = p.prDiff
+	p.prFeedbackFn = p.prFeedback
+	p.postPRReviewFn = p.postPRReview
 	p.synthesizeFn = p.synthesizeBatchResults
 
 	cfg := cfgGetter.Config()
@@ -286,15 +292,11 @@ func (p *CIPoller) processPR(ctx context.Context, ghRepo string, pr ghPR, cfg *c
 
 	// Validate, canonicalize, and dedupe review types.
 	// Empty string is rejected here (likely a config typo); use "default" explicitly.
-	validSpecialTypes := map[string]bool{"security": true, "design": true}
 	seen := make(map[string]bool, len(reviewTypes))
 	canonical := make([]string, 0, len(reviewTypes))
 	for _, rt := range reviewTypes {
-		if rt == "" {
-			return fmt.Errorf("invalid review_type %q (valid: default, security, design)", rt)
-		}
-		if !config.IsDefaultReviewType(rt) && !validSpecialTypes[rt] {
-			return fmt.Errorf("invalid review_type %q (valid: default, security, design)", rt)
+		if rt == "" || !config.IsValidReviewType(rt) {
+			return fmt.Errorf("invalid review_type %q (valid: %s)", rt, config.ValidReviewTypes())
 		}
 		// Normalize aliases to canonical "default"
 		if config.IsDefaultReviewType(rt) {
@@ -386,6 +388,16 @@ func (p *CIPoller) processPR(ctx context.Context, ghRepo string, pr ghPR, cfg *c
 		}
 	}
 
+	// Store what human reviewers already said before any job can start,
+	// so the agents build on it instead of repeating it
+	if feedback, err := p.callPRFeedback(ghRepo, pr.Number); err != nil {
+		log.Printf("CI poller: warning: could not fetch review comments for %s#%d: %v", ghRepo, pr.Number, err)
+	} else if feedback != "" {
+		if err := p.db.SetCIBatchFeedback(batch.ID, feedback); err != nil {
+			log.Printf("CI poller: warning: could not store review comments for %s#%d: %v", ghRepo, pr.Number, err)
+		}
+	}
+
 	// Enqueue jobs for each review_type x agent combination.
 	// If any enqueue fails, cancel already-created jobs and delete the batch
 	// so the next poll can retry cleanly.
@@ -835,7 +847,7 @@ func (p *CIPoller) postBatchResults(batch *storage.CIPRBatch) {
 		}
 	}
 
-	if err := p.callPostPRComment(batch.GithubRepo, batch.PRNumber, comment); err != nil {
+	if err := p.postBatchComment(batch, comment); err != nil {
 		log.Printf("CI poller: error posting batch comment for %s#%d: %v",
 			batch.GithubRepo, batch.PRNumber, err)
 		// Release claim so reconciler can retry
@@ -973,6 +985,27 @@ func (p *CIPoller) callPostPRComment(ghRepo string, prNumber int, body string) e
 	return p.postPRComment(ghRepo, prNumber, body)
 }
 
+func (p *CIPoller) callPRDiff(ghRepo string, prNumber int) (string, error) {
+	if p.prDiffFn != nil {
+		return p.prDiffFn(ghRepo, prNumber)
+	}
+	return p.prDiff(ghRepo, prNumber)
+}
+
+func (p *CIPoller) callPRFeedback(ghRepo string, prNumber int) (string, error) {
+	if p.prFeedbackFn != nil {
+		return p.prFeedbackFn(ghRepo, prNumber)
+	}
+	return p.prFeedback(ghRepo, prNumber)
+}
+
+func (p *CIPoller) callPostPRReview(ghRepo string, prNumber int, review *prReview) error {
+	if p.postPRReviewFn != nil {
+		return p.postPRReviewFn(ghRepo, prNumber, review)
+	}
+	return p.postPRReview(ghRepo, prNumber, review)
+}
+
 func (p *CIPoller) callSynthesize(batch *storage.CIPRBatch, reviews []storage.BatchReviewResult, cfg *config.Config) (string, error) {
 	if p.synthesizeFn != nil {
 		return p.synthesizeFn(batch, reviews, cfg)
@@ -46,6 +46,7 @@ func (h *ciPollerHarness) stubProcessPRGit() {
 	h.Poller.gitFetchPRHeadFn = func(context.Context, string, int) error { return nil }
 	h.Poller.mergeBaseFn = func(_, _, ref2 string) (string, error) { return "base-" + ref2, nil }
 	h.Poller.agentResolverFn = func(name string) (string, error) { return name, nil }
+	h.Poller.prFeedbackFn = func(string, int) (string, error) { return "", nil }
 }
 
 // seedBatchJob creates a CI batch, enqueues a job, and links them.
@@ -241,6 +241,8 @@ func (c *HTTPClient) WaitForReview(jobID int64) (*storage.Review, error) {
 			return nil, fmt.Errorf("job %d failed: %s", jobID, job.Error)
 		case storage.JobStatusCanceled:
 			return nil, fmt.Errorf("job %d was canceled", jobID)
+		case storage.JobStatusSkipped:
+			return nil, fmt.Errorf("job %d was skipped: %s", jobID, job.Error)
 		}
 
 		time.Sleep(c.pollInterval)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
 	"path/filepath"
 	"sync"
 	"time"
@@ -38,9 +39,10 @@ func (sc *StaticConfig) Config() *config.Config {
 // Hot-reloadable settings take effect immediately: default_agent, job_timeout,
 // allow_unsafe_agents, anthropic_api_key, review_context_count.
 //
-// Settings requiring restart: server_addr, max_workers, [sync] section.
+// Settings requiring restart: server_addr, server_port_range, max_workers,
+// [sync] section.
 // These are read at startup and the running values are preserved even if the
-// config file changes. CLI flag overrides (--addr, --workers) only apply to
+// config file changes. CLI flag overrides (--addr, --port-range, --workers) only apply to
 // restart-required settings, so they remain in effect for the daemon's lifetime.
 // The config object may show file values after reload, but the actual running
 // server address and worker pool size are fixed at startup.
@@ -204,6 +206,8 @@ func (cw *ConfigWatcher) reloadConfig() {
 	// Update global agent settings
 	agent.SetAllowUnsafeAgents(newCfg.AllowUnsafeAgents != nil && *newCfg.AllowUnsafeAgents)
 	agent.SetAnthropicAPIKey(newCfg.AnthropicAPIKey)
+	agent.SetSandbox(agent.Sandbox(newCfg.Sandbox))
+	warnSandboxAgent(newCfg)
 
 	// Log what changed (for debugging)
 	logConfigChanges(oldCfg, newCfg)
@@ -232,10 +236,19 @@ func logConfigChanges(old, new *config.Config) {
 	if oldUnsafe != newUnsafe {
 		log.Printf("Config change: allow_unsafe_agents %v -> %v", oldUnsafe, newUnsafe)
 	}
+	if !maps.Equal(old.AgentConcurrency, new.AgentConcurrency) {
+		log.Printf("Config change: agent_concurrency %v -> %v", old.AgentConcurrency, new.AgentConcurrency)
+	}
+	if !maps.Equal(old.AgentRateLimits, new.AgentRateLimits) {
+		log.Printf("Config change: agent_rate_limits %v -> %v", old.AgentRateLimits, new.AgentRateLimits)
+	}
 	if old.MaxWorkers != new.MaxWorkers {
 		log.Printf("Config change: max_workers %d -> %d (requires daemon restart to take effect)", old.MaxWorkers, new.MaxWorkers)
 	}
 	if old.ServerAddr != new.ServerAddr {
 		log.Printf("Config change: server_addr %q -> %q (requires daemon restart to take effect)", old.ServerAddr, new.ServerAddr)
 	}
+	if old.ServerPortRange != new.ServerPortRange {
+		log.Printf("Config change: server_port_range %q -> %q (requires daemon restart to take effect)", old.ServerPortRange, new.ServerPortRange)
+	}
 }
@@ -0,0 +1,205 @@
+package daemon
+
+import (
+	"context"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/prompt"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// SmokePrompt is the trivial prompt used to check that an agent responds
+const SmokePrompt = "Respond with exactly: OK"
+
+// agentProbeTTL is how long install and version probes stay cached for
+// /api/status
+const agentProbeTTL = 5 * time.Minute
+
+// versionTimeout bounds how long an agent's --version may take
+const versionTimeout = 10 * time.Second
+
+// ProbeAgent reports whether an agent is installed and its version,
+// without running a prompt
+func ProbeAgent(ctx context.Context, a agent.Agent) storage.AgentHealth {
+	h := storage.AgentHealth{Name: a.Name()}
+	ca, ok := a.(agent.CommandAgent)
+	if !ok {
+		// Non-command agents (like test) are always available
+		h.Installed = true
+		h.Healthy = true
+		return h
+	}
+
+	h.Command = ca.CommandName()
+	path, err := exec.LookPath(h.Command)
+	if err != nil {
+		h.Error = "not found in PATH"
+		return h
+	}
+	h.Path = path
+	h.Installed = true
+	h.Healthy = true
+	h.Version = commandVersion(ctx, path)
+	return h
+}
+
+// CheckAgent probes an agent and runs smokePrompt through it. A failed
+// run is classified so auth and rate limit problems are reported with
+// what to do about them.
+func CheckAgent(ctx context.Context, a agent.Agent, repoPath, smokePrompt string) storage.AgentHealth {
+	h := ProbeAgent(ctx, a)
+	if !h.Installed {
+		return h
+	}
+
+	output, err := a.Review(ctx, repoPath, "HEAD", smokePrompt, nil)
+	now := time.Now()
+	h.CheckedAt = &now
+	switch {
+	case err != nil:
+		h.ErrorClass = classifyFailure(ctx, err)
+		h.Error = describeFailure(err.Error(), h.ErrorClass)
+	case strings.TrimSpace(output) == "":
+		h.ErrorClass = storage.ErrorClassCrash
+		h.Error = "empty response"
+	}
+	h.Healthy = h.Error == ""
+	return h
+}
+
+// commandVersion returns the first line of `<path> --version`, or "" if
+// the command doesn't support it
+func commandVersion(ctx context.Context, path string) string {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return prompt.TruncateUTF8(line, 100)
+		}
+	}
+	return ""
+}
+
+// agentOutcome is the result of an agent's most recent job
+type agentOutcome struct {
+	at    time.Time
+	class storage.ErrorClass
+	err   string
+}
+
+// agentHealthTracker caches install probes and remembers each agent's
+// most recent job outcome, so /api/status can report agent health without
+// running prompts
+type agentHealthTracker struct {
+	mu         sync.Mutex
+	probes     []storage.AgentHealth
+	probedAt   time.Time
+	refreshing bool
+	outcomes   map[string]agentOutcome
+	versions   map[string]probedVersion
+}
+
+// probedVersion is an agent's reported version and when it was checked
+type probedVersion struct {
+	version string
+	at      time.Time
+}
+
+func newAgentHealthTracker() *agentHealthTracker {
+	return &agentHealthTracker{
+		outcomes: make(map[string]agentOutcome),
+		versions: make(map[string]probedVersion),
+	}
+}
+
+// version returns the agent's reported version, probing the binary at
+// most once per agentProbeTTL
+func (t *agentHealthTracker) version(ctx context.Context, a agent.Agent) string {
+	t.mu.Lock()
+	v, ok := t.versions[a.Name()]
+	t.mu.Unlock()
+	if ok && time.Since(v.at) < agentProbeTTL {
+		return v.version
+	}
+
+	v = probedVersion{version: ProbeAgent(ctx, a).Version, at: time.Now()}
+	t.mu.Lock()
+	t.versions[a.Name()] = v
+	t.mu.Unlock()
+	return v.version
+}
+
+// record stores the outcome of a job run by the named agent. errMsg is
+// empty for a successful run.
+func (t *agentHealthTracker) record(name string, class storage.ErrorClass, errMsg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.outcomes[name] = agentOutcome{at: time.Now(), class: class, err: errMsg}
+}
+
+// snapshot returns the health of every registered agent. Stale probes are
+// refreshed in the background so callers never wait on agent binaries.
+func (t *agentHealthTracker) snapshot() []storage.AgentHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.refreshing && time.Since(t.probedAt) > agentProbeTTL {
+		t.refreshing = true
+		go t.refresh()
+	}
+
+	byName := make(map[string]storage.AgentHealth, len(t.probes))
+	for _, h := range t.probes {
+		byName[h.Name] = h
+	}
+	// Agents that ran jobs are installed even if the probe hasn't finished
+	for name := range t.outcomes {
+		if _, ok := byName[name]; !ok {
+			byName[name] = storage.AgentHealth{Name: name, Installed: true, Healthy: true}
+		}
+	}
+
+	health := make([]storage.AgentHealth, 0, len(byName))
+	for name, h := range byName {
+		if o, ok := t.outcomes[name]; ok && h.Installed {
+			at := o.at
+			h.CheckedAt = &at
+			h.ErrorClass = o.class
+			h.Error = o.err
+			h.Healthy = o.err == ""
+		}
+		health = append(health, h)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Name < health[j].Name })
+	return health
+}
+
+// refresh re-probes every registered agent except the test agent
+func (t *agentHealthTracker) refresh() {
+	var probes []storage.AgentHealth
+	for _, name := range agent.Available() {
+		if name == "test" {
+			continue
+		}
+		a, err := agent.Get(name)
+		if err != nil {
+			continue
+		}
+		probes = append(probes, ProbeAgent(context.Background(), a))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.probes = probes
+	t.probedAt = time.Now()
+	t.refreshing = false
+}
@@ -0,0 +1,104 @@
+package daemon
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestCheckAgent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("responding agent is healthy", func(t *testing.T) {
+		h := CheckAgent(ctx, &agent.TestAgent{Output: "OK"}, t.TempDir(), SmokePrompt)
+		if !h.Installed || !h.Healthy || h.Error != "" {
+			t.Errorf("expected healthy agent, got %+v", h)
+		}
+		if h.CheckedAt == nil {
+			t.Error("expected CheckedAt to be set after a smoke test")
+		}
+	})
+
+	t.Run("failing agent is classified", func(t *testing.T) {
+		h := CheckAgent(ctx, &agent.TestAgent{Fail: true}, t.TempDir(), SmokePrompt)
+		if h.Healthy || h.ErrorClass != storage.ErrorClassCrash || h.Error == "" {
+			t.Errorf("expected unhealthy crash, got %+v", h)
+		}
+	})
+
+	t.Run("missing binary is not installed", func(t *testing.T) {
+		h := CheckAgent(ctx, agent.NewCodexAgent("roborev-no-such-agent"), t.TempDir(), SmokePrompt)
+		if h.Installed || h.Healthy || h.Command != "roborev-no-such-agent" {
+			t.Errorf("expected uninstalled agent, got %+v", h)
+		}
+	})
+}
+
+func TestAgentHealthTracker(t *testing.T) {
+	tracker := newAgentHealthTracker()
+	// Pretend the probe already ran so snapshot doesn't start one
+	tracker.probedAt = time.Now()
+	tracker.probes = []storage.AgentHealth{
+		{Name: "codex", Command: "codex", Installed: true, Healthy: true},
+		{Name: "gemini", Command: "gemini"},
+	}
+
+	tracker.record("codex", storage.ErrorClassAuth, "agent: not logged in")
+	tracker.record("gemini", storage.ErrorClassCrash, "agent: exit status 1")
+	tracker.record("test", "", "")
+
+	health := tracker.snapshot()
+	if len(health) != 3 {
+		t.Fatalf("expected 3 agents, got %+v", health)
+	}
+	byName := make(map[string]storage.AgentHealth)
+	for _, h := range health {
+		byName[h.Name] = h
+	}
+
+	if h := byName["codex"]; h.Healthy || h.ErrorClass != storage.ErrorClassAuth || h.CheckedAt == nil {
+		t.Errorf("expected codex unhealthy with auth error, got %+v", h)
+	}
+	// Outcomes don't make an agent that isn't installed look healthy or failing
+	if h := byName["gemini"]; h.Installed || h.Error != "" {
+		t.Errorf("expected gemini to stay uninstalled without job outcome, got %+v", h)
+	}
+	if h := byName["test"]; !h.Installed || !h.Healthy {
+		t.Errorf("expected agent with a successful job to be healthy, got %+v", h)
+	}
+
+	tracker.record("codex", "", "")
+	for _, h := range tracker.snapshot() {
+		if h.Name == "codex" && (!h.Healthy || h.Error != "") {
+			t.Errorf("expected codex healthy after a successful job, got %+v", h)
+		}
+	}
+}
+
+func TestAgentHealthTrackerVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake agent")
+	}
+	defer testutil.MockBinaryInPath(t, "codex", "#!/bin/sh\necho 'codex-cli 1.2.3'\n")()
+
+	tracker := newAgentHealthTracker()
+	a := agent.NewCodexAgent("codex")
+	if v := tracker.version(context.Background(), a); v != "codex-cli 1.2.3" {
+		t.Fatalf("version = %q, want %q", v, "codex-cli 1.2.3")
+	}
+
+	// Cached until the probe TTL expires
+	tracker.versions["codex"] = probedVersion{version: "cached", at: time.Now()}
+	if v := tracker.version(context.Background(), a); v != "cached" {
+		t.Errorf("expected cached version, got %q", v)
+	}
+	tracker.versions["codex"] = probedVersion{version: "cached", at: time.Now().Add(-agentProbeTTL)}
+	if v := tracker.version(context.Background(), a); v != "codex-cli 1.2.3" {
+		t.Errorf("expected version to be re-probed, got %q", v)
+	}
+}
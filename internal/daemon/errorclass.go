@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// rateLimitPattern matches provider rate limit and quota errors
+var rateLimitPattern = regexp.MustCompile(`(?i)rate[ _-]?limit|too many requests|(status|code|http)[: ]*429\b|quota|usage limit|overloaded|resource[ _]exhausted`)
+
+// authPattern matches login and credential errors
+var authPattern = regexp.MustCompile(`(?i)unauthori[sz]ed|(status|code|http)[: ]*40[13]\b|authentication (failed|error|required)|not (logged|signed) in|(log|sign) ?in (required|again)|invalid[ _]api[ _]key|api[ _]key\b.*\b(missing|invalid|not set|required)|invalid (credentials|token)|\bforbidden\b`)
+
+// classifyFailure sorts a failed agent run into an error class. ctx is the
+// job's context, which tells a timeout apart from the agent dying on its
+// own. Failures that match no known pattern are treated as crashes.
+func classifyFailure(ctx context.Context, err error) storage.ErrorClass {
+	var invalid *invalidOutputError
+	if errors.As(err, &invalid) {
+		if invalid.refusal {
+			return storage.ErrorClassRefusal
+		}
+		return storage.ErrorClassCrash
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return storage.ErrorClassTimeout
+	}
+
+	msg := err.Error()
+	switch {
+	case rateLimitPattern.MatchString(msg):
+		return storage.ErrorClassRateLimit
+	case authPattern.MatchString(msg):
+		return storage.ErrorClassAuth
+	}
+	return storage.ErrorClassCrash
+}
+
+// errorClassRetryable reports whether a failure of this class is worth
+// retrying. Auth errors and refusals fail the same way every time.
+func errorClassRetryable(class storage.ErrorClass) bool {
+	return class != storage.ErrorClassAuth && class != storage.ErrorClassRefusal
+}
+
+// errorClassHint returns what the user can do about a failure of this
+// class, or "" when there is no specific advice
+func errorClassHint(class storage.ErrorClass) string {
+	switch class {
+	case storage.ErrorClassAuth:
+		return "check that the agent CLI is logged in or its API key is set"
+	case storage.ErrorClassRateLimit:
+		return "the agent's provider is rate limiting requests; rerun later or lower max_workers"
+	case storage.ErrorClassTimeout:
+		return "the review exceeded its time limit; raise job_timeout_minutes or review a smaller change"
+	case storage.ErrorClassRefusal:
+		return "the agent declined to review; rerun with a different agent"
+	}
+	return ""
+}
+
+// describeFailure appends the class hint to an error message
+func describeFailure(errorMsg string, class storage.ErrorClass) string {
+	if hint := errorClassHint(class); hint != "" {
+		return errorMsg + " (" + hint + ")"
+	}
+	return errorMsg
+}
@@ -0,0 +1,82 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want storage.ErrorClass
+	}{
+		{"refusal", context.Background(), &invalidOutputError{msg: "invalid review output", refusal: true}, storage.ErrorClassRefusal},
+		{"empty output", context.Background(), &invalidOutputError{msg: "invalid review output"}, storage.ErrorClassCrash},
+		{"deadline", expired, errors.New("signal: killed"), storage.ErrorClassTimeout},
+		{"wrapped deadline", context.Background(), fmt.Errorf("run: %w", context.DeadlineExceeded), storage.ErrorClassTimeout},
+		{"429", context.Background(), errors.New("API error: status 429"), storage.ErrorClassRateLimit},
+		{"quota", context.Background(), errors.New("You have exceeded your quota"), storage.ErrorClassRateLimit},
+		{"unauthorized", context.Background(), errors.New("401 Unauthorized"), storage.ErrorClassAuth},
+		{"not logged in", context.Background(), errors.New("Error: not logged in. Run claude login"), storage.ErrorClassAuth},
+		{"invalid api key", context.Background(), errors.New("invalid_api_key: Incorrect API key provided"), storage.ErrorClassAuth},
+		{"line number is not a status", context.Background(), errors.New("exit status 1: panic at main.go:401"), storage.ErrorClassCrash},
+		{"exit status", context.Background(), errors.New("exit status 2"), storage.ErrorClassCrash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyFailure(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("classifyFailure(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailOrRetryByClass(t *testing.T) {
+	t.Run("auth fails without retry", func(t *testing.T) {
+		tc := newWorkerTestContext(t, 1)
+		job := tc.createAndClaimJob(t, testutil.GetHeadSHA(t, tc.TmpDir), "worker-1")
+
+		tc.Pool.failOrRetry("worker-1", job, "test", "agent: 401 Unauthorized", storage.ErrorClassAuth)
+
+		failed, err := tc.DB.GetJobByID(job.ID)
+		if err != nil {
+			t.Fatalf("GetJobByID failed: %v", err)
+		}
+		if failed.Status != storage.JobStatusFailed || failed.ErrorClass != storage.ErrorClassAuth {
+			t.Errorf("Expected failed auth job, got status %q class %q", failed.Status, failed.ErrorClass)
+		}
+		if !strings.Contains(failed.Error, "logged in") {
+			t.Errorf("Expected actionable hint in error, got %q", failed.Error)
+		}
+		if count, _ := tc.DB.GetJobRetryCount(job.ID); count != 0 {
+			t.Errorf("Expected no retries, got %d", count)
+		}
+	})
+
+	t.Run("rate limit is retried", func(t *testing.T) {
+		tc := newWorkerTestContext(t, 1)
+		job := tc.createAndClaimJob(t, testutil.GetHeadSHA(t, tc.TmpDir), "worker-1")
+
+		tc.Pool.failOrRetry("worker-1", job, "test", "agent: 429 Too Many Requests", storage.ErrorClassRateLimit)
+
+		requeued, err := tc.DB.GetJobByID(job.ID)
+		if err != nil {
+			t.Fatalf("GetJobByID failed: %v", err)
+		}
+		if requeued.Status != storage.JobStatusQueued {
+			t.Errorf("Expected job queued for retry, got %q", requeued.Status)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"log"
+
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// markFixedFindings closes the earlier findings in the files a completed
+// review covered that it no longer reports. Only reviews with a findings
+// block are used, since a review without one may have reported anything.
+func (wp *WorkerPool) markFixedFindings(workerID string, job *storage.ReviewJob) {
+	files := reviewedFiles(job)
+	if len(files) == 0 {
+		return
+	}
+	n, err := wp.db.MarkFixedFindings(job.ID, files)
+	if err != nil {
+		log.Printf("[%s] Error marking fixed findings for job %d: %v", workerID, job.ID, err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[%s] Job %d: marked %d earlier finding(s) fixed", workerID, job.ID, n)
+	}
+}
+
+// reviewedFiles returns the files a review job's diff covers, or nil if
+// they can't be listed
+func reviewedFiles(job *storage.ReviewJob) []string {
+	if job.DiffContent != nil {
+		return diffFilePaths(*job.DiffContent)
+	}
+	var files []string
+	var err error
+	if git.IsRange(job.GitRef) {
+		files, err = git.GetRangeFilesChanged(job.RepoPath, job.GitRef)
+	} else {
+		files, err = git.GetFilesChanged(job.RepoPath, job.GitRef)
+	}
+	if err != nil {
+		return nil
+	}
+	return files
+}
+
+// diffFilePaths returns the new-side paths of the files in a unified diff
+func diffFilePaths(diff string) []string {
+	var files []string
+	for _, f := range git.ParseDiff(diff) {
+		if p := f.Path(); p != "" {
+			files = append(files, p)
+		}
+	}
+	return files
+}
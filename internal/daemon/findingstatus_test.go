@@ -0,0 +1,15 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffFilePaths(t *testing.T) {
+	diff := "diff --git a/old.go b/new.go\nsimilarity index 90%\n" +
+		"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n"
+	want := []string{"new.go", "main.go"}
+	if got := diffFilePaths(diff); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffFilePaths = %v, want %v", got, want)
+	}
+}
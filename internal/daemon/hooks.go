@@ -70,17 +70,8 @@ func (hr *HookRunner) handleEvent(event Event) {
 		return
 	}
 
-	// Collect hooks: copy global slice to avoid aliasing, then append repo-specific
-	hooks := append([]config.HookConfig{}, cfg.Hooks...)
-
-	if event.Repo != "" {
-		if repoCfg, err := config.LoadRepoConfig(event.Repo); err == nil && repoCfg != nil {
-			hooks = append(hooks, repoCfg.Hooks...)
-		}
-	}
-
 	fired := 0
-	for _, hook := range hooks {
+	for _, hook := range collectHooks(cfg, event.Repo) {
 		if !matchEvent(hook.Event, event.Type) {
 			continue
 		}
@@ -100,6 +91,20 @@ func (hr *HookRunner) handleEvent(event Event) {
 	}
 }
 
+// collectHooks returns the global hooks followed by those configured for
+// the repo at repoPath
+func collectHooks(cfg *config.Config, repoPath string) []config.HookConfig {
+	// Copy global slice to avoid aliasing, then append repo-specific
+	hooks := append([]config.HookConfig{}, cfg.Hooks...)
+
+	if repoPath != "" {
+		if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+			hooks = append(hooks, repoCfg.Hooks...)
+		}
+	}
+	return hooks
+}
+
 // matchEvent checks if an event type matches a hook's event pattern.
 // Supports exact match and "review.*" wildcard.
 func matchEvent(pattern, eventType string) bool {
@@ -117,8 +122,12 @@ func matchEvent(pattern, eventType string) bool {
 // resolveCommand builds the shell command for a hook, handling built-in types
 // and template variable interpolation.
 func resolveCommand(hook config.HookConfig, event Event) string {
-	if hook.Type == "beads" {
+	switch hook.Type {
+	case "beads":
 		return beadsCommand(event)
+	case "webhook":
+		// Delivered by the WebhookDispatcher instead
+		return ""
 	}
 	return interpolate(hook.Command, event)
 }
@@ -145,6 +154,13 @@ func beadsCommand(event Event) string {
 			return fmt.Sprintf("bd create %q -p 2", title)
 		}
 		return "" // No issue for passing reviews
+	case "review.stale":
+		title := fmt.Sprintf("Unaddressed high-severity findings for %s (%s), reminder %d: roborev show %d / roborev fix %d", repoName, shortSHA, event.Reminder, event.JobID, event.JobID)
+		priority := 1
+		if event.Reminder > 1 {
+			priority = 0
+		}
+		return fmt.Sprintf("bd create %q -p %d", title, priority)
 	default:
 		return ""
 	}
@@ -166,6 +182,7 @@ func interpolate(cmd string, event Event) string {
 		"{verdict}", shellEscape(event.Verdict),
 		"{findings}", shellEscape(event.Findings),
 		"{error}", shellEscape(event.Error),
+		"{reminder}", fmt.Sprintf("%d", event.Reminder),
 	)
 	return r.Replace(cmd)
 }
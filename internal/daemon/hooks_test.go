@@ -280,6 +280,17 @@ func TestBeadsCommand(t *testing.T) {
 	}
 }
 
+func TestBeadsCommandStaleEscalates(t *testing.T) {
+	event := Event{Type: "review.stale", JobID: 7, RepoName: "myrepo", SHA: "abc123", Reminder: 1}
+	if cmd := beadsCommand(event); !contains(cmd, "reminder 1") || !contains(cmd, "-p 1") {
+		t.Errorf("expected priority 1 for first reminder, got %q", cmd)
+	}
+	event.Reminder = 2
+	if cmd := beadsCommand(event); !contains(cmd, "reminder 2") || !contains(cmd, "-p 0") {
+		t.Errorf("expected priority 0 for later reminders, got %q", cmd)
+	}
+}
+
 func TestBeadsCommandShortSHA(t *testing.T) {
 	event := Event{
 		Type:     "review.failed",
@@ -325,6 +336,17 @@ func TestResolveCommand(t *testing.T) {
 	if cmd == "" {
 		t.Error("expected non-empty beads command")
 	}
+
+	// Webhooks are delivered by the dispatcher, never run as commands
+	hook = config.HookConfig{
+		Event:   "review.failed",
+		Type:    "webhook",
+		URL:     "https://example.com/hook",
+		Command: "echo {job_id}",
+	}
+	if cmd := resolveCommand(hook, event); cmd != "" {
+		t.Errorf("expected no command for a webhook, got %q", cmd)
+	}
 }
 
 func TestHookRunnerFiresHooks(t *testing.T) {
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+const (
+	// jobOutputFlushBytes and jobOutputFlushInterval bound how much
+	// streamed output can be lost if the daemon dies mid-review.
+	jobOutputFlushBytes    = 16 * 1024
+	jobOutputFlushInterval = 2 * time.Second
+)
+
+// jobOutputRecorder persists a running job's normalized output lines in
+// batches, so partial output can be salvaged when the agent crashes or
+// the daemon restarts before the review completes.
+type jobOutputRecorder struct {
+	db    *storage.DB
+	jobID int64
+
+	mu        sync.Mutex
+	pending   strings.Builder
+	lastFlush time.Time
+}
+
+// newJobOutputRecorder creates a recorder for a job, discarding output
+// persisted by a previous attempt.
+func newJobOutputRecorder(db *storage.DB, jobID int64) *jobOutputRecorder {
+	if err := db.ClearJobOutput(jobID); err != nil {
+		log.Printf("Job %d: failed to clear previous output: %v", jobID, err)
+	}
+	return &jobOutputRecorder{db: db, jobID: jobID, lastFlush: time.Now()}
+}
+
+// Record buffers a line and writes the batch once it is large or old enough
+func (r *jobOutputRecorder) Record(line OutputLine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending.WriteString(line.Text)
+	r.pending.WriteByte('\n')
+	if r.pending.Len() >= jobOutputFlushBytes || time.Since(r.lastFlush) >= jobOutputFlushInterval {
+		r.flushLocked()
+	}
+}
+
+// Flush writes any buffered lines
+func (r *jobOutputRecorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked()
+}
+
+func (r *jobOutputRecorder) flushLocked() {
+	r.lastFlush = time.Now()
+	if r.pending.Len() == 0 {
+		return
+	}
+	chunk := r.pending.String()
+	r.pending.Reset()
+	if err := r.db.AppendJobOutput(r.jobID, chunk); err != nil {
+		log.Printf("Job %d: failed to persist output: %v", r.jobID, err)
+	}
+}
+
+// persistedOutputLines converts salvaged output into lines for the output
+// API. Per-line timestamps aren't persisted, so every line gets ts.
+func persistedOutputLines(output string, ts time.Time) []OutputLine {
+	output = strings.TrimSuffix(output, "\n")
+	if output == "" {
+		return nil
+	}
+	texts := strings.Split(output, "\n")
+	lines := make([]OutputLine, len(texts))
+	for i, text := range texts {
+		lines[i] = OutputLine{Timestamp: ts, Text: text, Type: "text"}
+	}
+	return lines
+}
@@ -0,0 +1,59 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestJobOutputRecorderPersistsWriterLines(t *testing.T) {
+	db := testutil.OpenTestDB(t)
+	repo, err := db.GetOrCreateRepo(t.TempDir())
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo: %v", err)
+	}
+	job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, GitRef: "abc", Agent: "test"})
+	if err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+	if err := db.AppendJobOutput(job.ID, "stale output from a previous attempt\n"); err != nil {
+		t.Fatalf("AppendJobOutput: %v", err)
+	}
+
+	ob := NewOutputBuffer(1024, 4096)
+	w := ob.Writer(job.ID, func(line string) *OutputLine {
+		return &OutputLine{Text: line, Type: "text"}
+	})
+	recorder := newJobOutputRecorder(db, job.ID)
+	w.onLine = recorder.Record
+
+	w.Write([]byte("first line\nsecond "))
+	w.Write([]byte("line\npartial"))
+
+	// Below the flush thresholds nothing is written until Flush
+	if out, _ := db.GetJobOutput(job.ID); out != "" {
+		t.Fatalf("expected stale output cleared and nothing flushed yet, got %q", out)
+	}
+
+	w.Flush()
+	recorder.Flush()
+
+	out, err := db.GetJobOutput(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobOutput: %v", err)
+	}
+	if out != "first line\nsecond line\npartial\n" {
+		t.Errorf("persisted output = %q", out)
+	}
+	if got := len(ob.GetLines(job.ID)); got != 3 {
+		t.Errorf("expected live buffer to still receive 3 lines, got %d", got)
+	}
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	lines := persistedOutputLines(out, ts)
+	if len(lines) != 3 || lines[2].Text != "partial" || !lines[0].Timestamp.Equal(ts) {
+		t.Errorf("unexpected persisted lines: %+v", lines)
+	}
+}
@@ -193,3 +193,39 @@ func killProcess(pid int) bool {
 
 	return false // Failed to kill
 }
+
+// processRunning reports whether a process with the PID exists. A process
+// owned by another user counts as running.
+func processRunning(pid int) bool {
+	process, _ := os.FindProcess(pid)
+	err := process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// processStartTime returns when the process started, as clock ticks since
+// boot on Linux or ps's start time elsewhere. ok is false when it can't be
+// determined.
+func processStartTime(pid int) (start string, ok bool) {
+	if stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat"); err == nil {
+		// The command name in parentheses may contain spaces, so count
+		// fields from the last ')'. starttime is field 22; the state after
+		// the name is field 3.
+		rest := string(stat)
+		if i := strings.LastIndexByte(rest, ')'); i >= 0 {
+			fields := strings.Fields(rest[i+1:])
+			if len(fields) > 19 {
+				return fields[19], true
+			}
+		}
+		return "", false
+	}
+
+	cmd := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "lstart=")
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "TZ=UTC")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	start = strings.Join(strings.Fields(string(out)), " ")
+	return start, start != ""
+}
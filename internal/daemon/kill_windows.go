@@ -229,3 +229,15 @@ func processExists(pid int) bool {
 	quotedPID := []byte("\"" + pidStr + "\"")
 	return len(output) > 0 && bytes.Contains(output, quotedPID)
 }
+
+// processRunning reports whether a process with the PID exists
+func processRunning(pid int) bool {
+	return processExists(pid)
+}
+
+// processStartTime isn't reported on Windows, where looking it up means
+// starting PowerShell; runtime files there rely on processRunning and the
+// daemon's HTTP check
+func processStartTime(pid int) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,266 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// MaintenanceStatus reports the state of the database maintenance loop
+type MaintenanceStatus struct {
+	Running    bool                       `json:"running"`
+	Interval   string                     `json:"interval"`
+	Runs       int                        `json:"runs"`
+	NextRunAt  *time.Time                 `json:"next_run_at,omitempty"`
+	LastError  string                     `json:"last_error,omitempty"`
+	LastResult *storage.MaintenanceResult `json:"last_result,omitempty"`
+	LastPurged *storage.DeleteCounts      `json:"last_purged,omitempty"`
+	// LastReminded counts the review.stale reminders the last pass sent
+	LastReminded int `json:"last_reminded,omitempty"`
+}
+
+// MaintenanceWorker periodically purges expired soft-deleted rows and runs
+// SQLite housekeeping so long-lived daemons don't accumulate an unbounded WAL.
+// It also sends reminders about findings left open too long.
+type MaintenanceWorker struct {
+	db          *storage.DB
+	cfgGetter   ConfigGetter
+	broadcaster Broadcaster
+	webhooks    *WebhookDispatcher // nil without a server
+
+	mu         sync.Mutex
+	running    bool
+	stopCh     chan struct{}
+	doneCh     chan struct{}
+	cancelFunc context.CancelFunc
+	runs       int
+	nextRunAt  time.Time
+	lastErr    error
+	lastResult *storage.MaintenanceResult
+	lastPurged *storage.DeleteCounts
+	reminded   int
+}
+
+// NewMaintenanceWorker creates a new maintenance worker. broadcaster may be
+// nil, in which case no stale-finding reminders are sent.
+func NewMaintenanceWorker(db *storage.DB, cfgGetter ConfigGetter, broadcaster Broadcaster) *MaintenanceWorker {
+	return &MaintenanceWorker{db: db, cfgGetter: cfgGetter, broadcaster: broadcaster}
+}
+
+// Start runs a maintenance pass immediately and then on the configured interval
+func (m *MaintenanceWorker) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return fmt.Errorf("maintenance worker already running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopCh = make(chan struct{})
+	m.doneCh = make(chan struct{})
+	m.cancelFunc = cancel
+	m.running = true
+
+	go m.run(ctx, m.stopCh, m.doneCh)
+	return nil
+}
+
+// Stop halts the maintenance loop and waits for an in-progress pass to finish
+func (m *MaintenanceWorker) Stop() {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return
+	}
+	stopCh := m.stopCh
+	doneCh := m.doneCh
+	cancel := m.cancelFunc
+	m.running = false
+	m.mu.Unlock()
+
+	cancel()
+	close(stopCh)
+	<-doneCh
+}
+
+// Status returns a snapshot of the maintenance loop state
+func (m *MaintenanceWorker) Status() MaintenanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := MaintenanceStatus{
+		Running:      m.running,
+		Interval:     m.cfgGetter.Config().ResolvedMaintenanceInterval().String(),
+		Runs:         m.runs,
+		LastResult:   m.lastResult,
+		LastPurged:   m.lastPurged,
+		LastReminded: m.reminded,
+	}
+	if m.running && !m.nextRunAt.IsZero() {
+		next := m.nextRunAt
+		status.NextRunAt = &next
+	}
+	if m.lastErr != nil {
+		status.LastError = m.lastErr.Error()
+	}
+	return status
+}
+
+func (m *MaintenanceWorker) run(ctx context.Context, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	for {
+		m.runOnce(ctx)
+
+		// Re-read the interval each pass so config reloads take effect
+		interval := m.cfgGetter.Config().ResolvedMaintenanceInterval()
+		m.mu.Lock()
+		m.nextRunAt = time.Now().Add(interval)
+		m.mu.Unlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-stopCh:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// runOnce performs a single maintenance pass and records the outcome
+func (m *MaintenanceWorker) runOnce(ctx context.Context) {
+	cfg := m.cfgGetter.Config()
+
+	purged, purgeErr := m.db.PurgeDeleted(cfg.ResolvedUndoWindow())
+	if purgeErr != nil {
+		log.Printf("Maintenance: failed to purge deleted jobs: %v", purgeErr)
+	} else if purged.Jobs > 0 {
+		log.Printf("Maintenance: purged %d deleted job(s) past the undo window", purged.Jobs)
+	}
+
+	if dropped, err := m.db.PurgeDeadWebhooks(deadWebhookRetention); err != nil {
+		log.Printf("Maintenance: failed to purge dead webhooks: %v", err)
+	} else if dropped > 0 {
+		log.Printf("Maintenance: purged %d dead webhook delivery(ies) past the retention window", dropped)
+	}
+
+	reminded, remindErr := m.remindStaleFindings(cfg.StaleFindingDays)
+	if remindErr != nil {
+		log.Printf("Maintenance: failed to send stale finding reminders: %v", remindErr)
+	} else if reminded > 0 {
+		log.Printf("Maintenance: sent %d stale finding reminder(s)", reminded)
+	}
+
+	result, err := m.db.RunMaintenance(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Maintenance: %v", err)
+	} else if result != nil && result.CheckpointBusy {
+		log.Printf("Maintenance: WAL checkpoint incomplete (%d/%d pages), readers active", result.CheckpointedPages, result.WALPages)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs++
+	m.reminded = reminded
+	if purgeErr == nil {
+		m.lastPurged = &purged
+	}
+	if result != nil {
+		m.lastResult = result
+	}
+	switch {
+	case err != nil:
+		m.lastErr = err
+	case purgeErr != nil:
+		m.lastErr = fmt.Errorf("purge deleted: %w", purgeErr)
+	case remindErr != nil:
+		m.lastErr = fmt.Errorf("stale finding reminders: %w", remindErr)
+	default:
+		m.lastErr = nil
+	}
+}
+
+// deadWebhookRetention is how long dead webhook deliveries are kept for
+// inspection and manual retry before maintenance deletes them
+const deadWebhookRetention = 30 * 24 * time.Hour
+
+// maxStaleRemindersPerPass bounds the reminders one pass sends, so enabling
+// reminders on an old database doesn't fire a hook for every review at once
+const maxStaleRemindersPerPass = 20
+
+// staleReminderLevel returns how many reminders a review of the given age
+// is due: the first at days old, then another each time its age doubles
+func staleReminderLevel(age time.Duration, days int) int {
+	period := time.Duration(days) * 24 * time.Hour
+	level := 0
+	for age >= period {
+		level++
+		period *= 2
+	}
+	return level
+}
+
+// remindStaleFindings broadcasts a review.stale event for each unaddressed
+// review with critical or high findings that is due a reminder, returning
+// how many were sent. Repeated reminders carry a higher Reminder number so
+// hooks can escalate.
+func (m *MaintenanceWorker) remindStaleFindings(days int) (int, error) {
+	if days <= 0 || m.broadcaster == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	stale, err := m.db.ListStaleReviews(now.Add(-time.Duration(days) * 24 * time.Hour))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, sr := range stale {
+		level := staleReminderLevel(now.Sub(sr.CreatedAt), days)
+		if level <= sr.Reminders {
+			continue
+		}
+		if sent == maxStaleRemindersPerPass {
+			break
+		}
+		// Record the reminder first so a failure can't repeat it every pass
+		if err := m.db.SetStaleReminders(sr.ReviewID, level); err != nil {
+			return sent, err
+		}
+
+		var findings strings.Builder
+		for _, f := range sr.Findings {
+			location := f.File
+			if f.File != "" && f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+			if location != "" {
+				location = " " + location + ":"
+			}
+			fmt.Fprintf(&findings, "- %s%s %s\n", f.Severity, location, f.Message)
+		}
+		event := Event{
+			Type:     "review.stale",
+			TS:       now,
+			JobID:    sr.JobID,
+			Repo:     sr.RepoPath,
+			RepoName: sr.RepoName,
+			SHA:      sr.GitRef,
+			Agent:    sr.Agent,
+			Findings: findings.String(),
+			Reminder: level,
+		}
+		m.broadcaster.Broadcast(event)
+		m.webhooks.Queue(event)
+		sent++
+	}
+	return sent, nil
+}
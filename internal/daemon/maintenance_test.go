@@ -0,0 +1,190 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestMaintenanceWorkerRunOnceRecordsStatus(t *testing.T) {
+	db, _ := testutil.OpenTestDBWithDir(t)
+	m := NewMaintenanceWorker(db, NewStaticConfig(config.DefaultConfig()), nil)
+
+	m.runOnce(context.Background())
+
+	status := m.Status()
+	if status.Runs != 1 {
+		t.Errorf("Expected 1 run, got %d", status.Runs)
+	}
+	if status.LastError != "" {
+		t.Errorf("Expected no error, got %q", status.LastError)
+	}
+	if status.LastResult == nil {
+		t.Fatal("Expected last result to be recorded")
+	}
+	if status.LastPurged == nil {
+		t.Error("Expected purge counts to be recorded")
+	}
+	if status.Interval != config.DefaultMaintenanceInterval.String() {
+		t.Errorf("Expected interval %s, got %s", config.DefaultMaintenanceInterval, status.Interval)
+	}
+}
+
+func TestMaintenanceWorkerStartStop(t *testing.T) {
+	db, _ := testutil.OpenTestDBWithDir(t)
+	m := NewMaintenanceWorker(db, NewStaticConfig(config.DefaultConfig()), nil)
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := m.Start(); err == nil {
+		t.Error("Expected error starting twice")
+	}
+
+	// The first pass runs immediately on start
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Status().NextRunAt == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for first maintenance pass")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Stop()
+	status := m.Status()
+	if status.Running {
+		t.Error("Expected worker to be stopped")
+	}
+	if status.Runs < 1 {
+		t.Errorf("Expected at least one run, got %d", status.Runs)
+	}
+
+	// Stop is idempotent
+	m.Stop()
+}
+
+func TestStaleReminderLevel(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		age  time.Duration
+		want int
+	}{
+		{6 * day, 0},
+		{7 * day, 1},
+		{13 * day, 1},
+		{14 * day, 2},
+		{28 * day, 3},
+	}
+	for _, tt := range tests {
+		if got := staleReminderLevel(tt.age, 7); got != tt.want {
+			t.Errorf("staleReminderLevel(%v, 7) = %d, want %d", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceWorkerRemindsStaleFindings(t *testing.T) {
+	db, _ := testutil.OpenTestDBWithDir(t)
+	repo, err := db.GetOrCreateRepo(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backdate := func(jobID int64, days int) {
+		t.Helper()
+		createdAt := time.Now().Add(-time.Duration(days) * 24 * time.Hour).UTC().Format(time.RFC3339)
+		if _, err := db.Exec(`UPDATE reviews SET created_at = ? WHERE job_id = ?`, createdAt, jobID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addReview := func(sha, severity string, days int) int64 {
+		t.Helper()
+		job := testutil.CreateCompletedReview(t, db, repo.ID, sha, "test", "review")
+		if err := db.SaveFindings(job.ID, []storage.Finding{{Severity: severity, File: "db.go", Line: 4, Message: "SQL injection"}}); err != nil {
+			t.Fatal(err)
+		}
+		backdate(job.ID, days)
+		return job.ID
+	}
+
+	stale := addReview("aaa111", "high", 10)
+	addReview("bbb222", "low", 30)
+	addReview("ccc333", "critical", 3)
+	addressed := addReview("ddd444", "high", 30)
+	if err := db.MarkReviewAddressedByJobID(addressed, true); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.StaleFindingDays = 7
+	broadcaster := NewBroadcaster()
+	_, events := broadcaster.Subscribe("")
+	m := NewMaintenanceWorker(db, NewStaticConfig(cfg), broadcaster)
+
+	m.runOnce(context.Background())
+	select {
+	case event := <-events:
+		if event.Type != "review.stale" || event.JobID != stale || event.Reminder != 1 {
+			t.Errorf("unexpected event %+v", event)
+		}
+		if event.Findings != "- high db.go:4: SQL injection\n" {
+			t.Errorf("unexpected findings %q", event.Findings)
+		}
+	default:
+		t.Fatal("expected a review.stale event")
+	}
+	if got := m.Status().LastReminded; got != 1 {
+		t.Errorf("LastReminded = %d, want 1", got)
+	}
+
+	// No repeat until the review's age doubles
+	m.runOnce(context.Background())
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected repeat reminder %+v", event)
+	default:
+	}
+
+	backdate(stale, 15)
+	m.runOnce(context.Background())
+	select {
+	case event := <-events:
+		if event.JobID != stale || event.Reminder != 2 {
+			t.Errorf("expected escalated reminder for job %d, got %+v", stale, event)
+		}
+	default:
+		t.Fatal("expected an escalated review.stale event")
+	}
+}
+
+func TestHandleMaintenance(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	server.maintenance.runOnce(context.Background())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/maintenance", nil)
+	w := httptest.NewRecorder()
+	server.handleMaintenance(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var status MaintenanceStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if status.Runs != 1 || status.LastResult == nil {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/maintenance", nil)
+	w = httptest.NewRecorder()
+	server.handleMaintenance(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
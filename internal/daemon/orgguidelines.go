@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/prompt"
+)
+
+// orgGuidelinesPollInterval is how often the syncer checks whether a sync
+// is due, so config changes take effect without waiting a full interval
+const orgGuidelinesPollInterval = time.Minute
+
+// orgGuidelinesSyncTimeout bounds one fetch or clone
+const orgGuidelinesSyncTimeout = 2 * time.Minute
+
+// OrgGuidelinesSyncer keeps a local copy of the organization's shared review
+// guidelines up to date, for the prompt builder to add to every review
+type OrgGuidelinesSyncer struct {
+	cfgGetter ConfigGetter
+
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	doneCh  chan struct{}
+}
+
+// NewOrgGuidelinesSyncer creates a syncer for the configured guidelines
+func NewOrgGuidelinesSyncer(cfgGetter ConfigGetter) *OrgGuidelinesSyncer {
+	return &OrgGuidelinesSyncer{cfgGetter: cfgGetter}
+}
+
+// Start syncs immediately when guidelines are configured, then whenever
+// their interval passes or their config changes
+func (s *OrgGuidelinesSyncer) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.doneCh = make(chan struct{})
+	s.running = true
+	go s.run(ctx, s.doneCh)
+}
+
+// Stop halts the syncer and waits for an in-progress sync to finish
+func (s *OrgGuidelinesSyncer) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	cancel, doneCh := s.cancel, s.doneCh
+	s.mu.Unlock()
+
+	cancel()
+	<-doneCh
+}
+
+func (s *OrgGuidelinesSyncer) run(ctx context.Context, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	var last config.OrgGuidelinesConfig
+	var lastAt time.Time
+	ticker := time.NewTicker(orgGuidelinesPollInterval)
+	defer ticker.Stop()
+	for {
+		cfg := s.cfgGetter.Config().OrgGuidelines
+		if orgGuidelinesSyncDue(cfg, last, lastAt, time.Now()) {
+			syncCtx, cancel := context.WithTimeout(ctx, orgGuidelinesSyncTimeout)
+			err := prompt.SyncOrgGuidelines(syncCtx, cfg)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Org guidelines: sync from %s failed: %v", cfg.Source, err)
+			}
+			// Failures wait for the next interval too, rather than retrying
+			// an unreachable source every poll
+			last, lastAt = cfg, time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// orgGuidelinesSyncDue reports whether guidelines configured as cfg should
+// be synced at now, given the config and time of the last sync
+func orgGuidelinesSyncDue(cfg, last config.OrgGuidelinesConfig, lastAt, now time.Time) bool {
+	if cfg.Source == "" {
+		return false
+	}
+	return cfg != last || now.Sub(lastAt) >= cfg.ResolvedInterval()
+}
@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestOrgGuidelinesSyncDue(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.OrgGuidelinesConfig{Source: "https://example.com/guidelines.md", Interval: "30m"}
+	moved := cfg
+	moved.Source = "https://example.com/v2/guidelines.md"
+
+	tests := []struct {
+		name   string
+		cfg    config.OrgGuidelinesConfig
+		last   config.OrgGuidelinesConfig
+		lastAt time.Time
+		want   bool
+	}{
+		{name: "not configured", cfg: config.OrgGuidelinesConfig{}, want: false},
+		{name: "never synced", cfg: cfg, want: true},
+		{name: "synced recently", cfg: cfg, last: cfg, lastAt: now.Add(-10 * time.Minute), want: false},
+		{name: "interval passed", cfg: cfg, last: cfg, lastAt: now.Add(-30 * time.Minute), want: true},
+		{name: "source changed", cfg: moved, last: cfg, lastAt: now.Add(-time.Minute), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orgGuidelinesSyncDue(tt.cfg, tt.last, tt.lastAt, now); got != tt.want {
+				t.Errorf("orgGuidelinesSyncDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -213,8 +213,18 @@ type outputWriter struct {
 	jobID      int64
 	normalize  OutputNormalizer
 	lineBuf    bytes.Buffer
-	maxLine    int  // Max line size before forced flush (prevents unbounded growth)
-	discarding bool // True when discarding bytes until next newline (after truncation)
+	maxLine    int              // Max line size before forced flush (prevents unbounded growth)
+	discarding bool             // True when discarding bytes until next newline (after truncation)
+	onLine     func(OutputLine) // Optional observer for every normalized line
+}
+
+// emit stores a normalized line and passes it to the observer, if any
+func (w *outputWriter) emit(line *OutputLine) {
+	line.Timestamp = time.Now()
+	w.buffer.Append(w.jobID, *line)
+	if w.onLine != nil {
+		w.onLine(*line)
+	}
 }
 
 func (w *outputWriter) Write(p []byte) (n int, err error) {
@@ -257,8 +267,7 @@ func (w *outputWriter) Write(p []byte) (n int, err error) {
 				// Enter discard mode - drop bytes until next newline
 				w.discarding = true
 				if normalized := w.normalize(line); normalized != nil {
-					normalized.Timestamp = time.Now()
-					w.buffer.Append(w.jobID, *normalized)
+					w.emit(normalized)
 				}
 				continue
 			}
@@ -272,8 +281,7 @@ func (w *outputWriter) Write(p []byte) (n int, err error) {
 		}
 		line = strings.TrimSuffix(line, "\r")
 		if normalized := w.normalize(line); normalized != nil {
-			normalized.Timestamp = time.Now()
-			w.buffer.Append(w.jobID, *normalized)
+			w.emit(normalized)
 		}
 	}
 	return len(p), nil
@@ -285,8 +293,7 @@ func (w *outputWriter) Flush() {
 		line := w.lineBuf.String()
 		w.lineBuf.Reset()
 		if normalized := w.normalize(line); normalized != nil {
-			normalized.Timestamp = time.Now()
-			w.buffer.Append(w.jobID, *normalized)
+			w.emit(normalized)
 		}
 	}
 }
@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// OutputFilter transforms raw agent output before it is stored
+type OutputFilter func(output string) (string, error)
+
+// outputFilterTimeout bounds how long a command filter may run
+const outputFilterTimeout = 30 * time.Second
+
+var (
+	outputFiltersMu sync.RWMutex
+	outputFilters   = map[string]OutputFilter{
+		"strip-ansi":       func(s string) (string, error) { return stripANSI(s), nil },
+		"strip-tool-calls": func(s string) (string, error) { return stripToolCallLines(s), nil },
+		"trim-whitespace":  func(s string) (string, error) { return trimLineWhitespace(s), nil },
+	}
+)
+
+// RegisterOutputFilter makes a Go filter available to repos as an
+// output_filters entry with the given type. Registering an existing name
+// replaces it.
+func RegisterOutputFilter(name string, f OutputFilter) {
+	outputFiltersMu.Lock()
+	defer outputFiltersMu.Unlock()
+	outputFilters[name] = f
+}
+
+func getOutputFilter(name string) (OutputFilter, bool) {
+	outputFiltersMu.RLock()
+	defer outputFiltersMu.RUnlock()
+	f, ok := outputFilters[name]
+	return f, ok
+}
+
+// applyOutputFilters runs the repo's output filters over output in order.
+// A filter that fails is skipped with a log message, so a broken filter
+// never loses a review.
+func applyOutputFilters(ctx context.Context, repoPath, output string) string {
+	repoCfg, err := config.LoadRepoConfig(repoPath)
+	if err != nil || repoCfg == nil {
+		return output
+	}
+	for _, fc := range repoCfg.OutputFilters {
+		filtered, err := runOutputFilter(ctx, fc, repoPath, output)
+		if err != nil {
+			log.Printf("Output filter error (repo=%q): %v", repoPath, err)
+			continue
+		}
+		output = filtered
+	}
+	return output
+}
+
+// runOutputFilter applies one configured filter. Built-in types take
+// precedence over command.
+func runOutputFilter(ctx context.Context, fc config.OutputFilterConfig, repoPath, output string) (string, error) {
+	if fc.Type != "" {
+		f, ok := getOutputFilter(fc.Type)
+		if !ok {
+			return "", fmt.Errorf("unknown output filter type %q", fc.Type)
+		}
+		return f(output)
+	}
+	if fc.Command == "" {
+		return "", fmt.Errorf("output filter has neither type nor command")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outputFilterTimeout)
+	defer cancel()
+	cmd := agent.ShellCommand(ctx, fc.Command)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(output)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("command %q: %w: %s", fc.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// stripToolCallLines removes raw tool-call JSON lines that leaked into
+// output
+func stripToolCallLines(s string) string {
+	lines := strings.Split(s, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !isToolCallJSON(line) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// trimLineWhitespace removes trailing whitespace from each line and
+// surrounding blank lines from the output
+func trimLineWhitespace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
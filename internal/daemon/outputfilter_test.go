@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestApplyOutputFiltersBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	writeRepoConfig(t, dir, `
+[[output_filters]]
+type = "strip-ansi"
+
+[[output_filters]]
+type = "strip-tool-calls"
+
+[[output_filters]]
+type = "trim-whitespace"
+`)
+	raw := "\n\x1b[1m## Findings\x1b[0m   \n{\"name\":\"read\",\"arguments\":{}}\n- High: bug  \n\n"
+	got := applyOutputFilters(context.Background(), dir, raw)
+	want := "## Findings\n- High: bug"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestApplyOutputFiltersCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	writeRepoConfig(t, dir, `
+[[output_filters]]
+command = "tr a-z A-Z"
+`)
+	if got := applyOutputFilters(context.Background(), dir, "no issues found"); got != "NO ISSUES FOUND" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRunOutputFilterStopsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	// The background sleep holds the output pipe open after sh is killed
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := runOutputFilter(ctx, config.OutputFilterConfig{Command: "sleep 30 & sleep 30"}, t.TempDir(), ""); err == nil {
+		t.Error("expected an error for a canceled filter")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancellation took %s; the filter's children were left running", elapsed)
+	}
+}
+
+func TestApplyOutputFiltersSkipsFailures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	writeRepoConfig(t, dir, `
+[[output_filters]]
+command = "exit 3"
+
+[[output_filters]]
+type = "no-such-filter"
+
+[[output_filters]]
+type = "trim-whitespace"
+`)
+	if got := applyOutputFilters(context.Background(), dir, "review  \n"); got != "review" {
+		t.Errorf("got %q, want later filters applied to the original output", got)
+	}
+}
+
+func TestRegisterOutputFilter(t *testing.T) {
+	RegisterOutputFilter("test-upper", func(s string) (string, error) { return strings.ToUpper(s), nil })
+	t.Cleanup(func() {
+		outputFiltersMu.Lock()
+		delete(outputFilters, "test-upper")
+		outputFiltersMu.Unlock()
+	})
+
+	dir := t.TempDir()
+	writeRepoConfig(t, dir, `
+[[output_filters]]
+type = "test-upper"
+`)
+	if got := applyOutputFilters(context.Background(), dir, "ok"); got != "OK" {
+		t.Errorf("got %q", got)
+	}
+}
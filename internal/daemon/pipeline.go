@@ -0,0 +1,243 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// pipelineResult is what a completed review tells pipeline conditions
+type pipelineResult struct {
+	Verdict  string             // P or F
+	Findings []storage.Finding  // structured findings, if the review gave any
+	HasBlock bool               // whether the review gave structured findings
+	Event    Event              // the review.completed event, for command templates
+	counts   map[string]float64 // lazily built by value
+}
+
+// value returns a condition variable: verdict, findings, or the number of
+// findings of a severity (critical, high, medium, low)
+func (r *pipelineResult) value(name string) (string, bool) {
+	if name == "verdict" {
+		return r.Verdict, true
+	}
+	if r.counts == nil {
+		r.counts = map[string]float64{"critical": 0, "high": 0, "medium": 0, "low": 0}
+		for _, f := range r.Findings {
+			if _, ok := r.counts[f.Severity]; ok {
+				r.counts[f.Severity]++
+			}
+		}
+		// Without structured findings, a failing verdict counts as one
+		r.counts["findings"] = float64(len(r.Findings))
+		if !r.HasBlock && r.Verdict == "F" {
+			r.counts["findings"] = 1
+		}
+	}
+	n, ok := r.counts[name]
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64), true
+}
+
+// pipelineOperators are the comparisons a condition may use. Two-character
+// operators come first so ">=" isn't read as ">".
+var pipelineOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// evalPipelineCondition evaluates a step's when condition: comparisons like
+// "findings > 0" or "verdict == F", joined by && and ||. An empty condition
+// or "always" holds.
+func evalPipelineCondition(cond string, result *pipelineResult) (bool, error) {
+	cond = strings.TrimSpace(cond)
+	if cond == "" || cond == "always" {
+		return true, nil
+	}
+	for _, alternative := range strings.Split(cond, "||") {
+		all := true
+		for _, term := range strings.Split(alternative, "&&") {
+			ok, err := evalPipelineComparison(strings.TrimSpace(term), result)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// evalPipelineComparison evaluates one comparison
+func evalPipelineComparison(term string, result *pipelineResult) (bool, error) {
+	for _, op := range pipelineOperators {
+		left, right, found := strings.Cut(term, op)
+		if !found {
+			continue
+		}
+		name := strings.TrimSpace(left)
+		want := strings.Trim(strings.TrimSpace(right), `"'`)
+		got, ok := result.value(name)
+		if !ok {
+			return false, fmt.Errorf("unknown variable %q in %q", name, term)
+		}
+		if name == "verdict" {
+			switch op {
+			case "==":
+				return strings.EqualFold(got, want), nil
+			case "!=":
+				return !strings.EqualFold(got, want), nil
+			}
+			return false, fmt.Errorf("verdict only supports == and != in %q", term)
+		}
+		a, _ := strconv.ParseFloat(got, 64)
+		b, err := strconv.ParseFloat(want, 64)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a number in %q", want, term)
+		}
+		switch op {
+		case "==":
+			return a == b, nil
+		case "!=":
+			return a != b, nil
+		case ">=":
+			return a >= b, nil
+		case "<=":
+			return a <= b, nil
+		case ">":
+			return a > b, nil
+		default:
+			return a < b, nil
+		}
+	}
+	return false, fmt.Errorf("invalid condition %q", term)
+}
+
+// pipelineTriggers reports whether a pipeline starts after reviews of
+// reviewType
+func pipelineTriggers(p config.PipelineConfig, reviewType string) bool {
+	if config.IsDefaultReviewType(p.Trigger) {
+		return config.IsDefaultReviewType(reviewType)
+	}
+	return p.Trigger == reviewType
+}
+
+// advancePipelines runs the next steps of the pipeline that enqueued a
+// completed review, or starts the pipelines the review triggers. Jobs a
+// pipeline enqueued never start other pipelines, so pipelines can't loop.
+func (wp *WorkerPool) advancePipelines(cfg *config.Config, job *storage.ReviewJob, result *pipelineResult) {
+	if job.IsTaskJob() {
+		return
+	}
+	pipelines := config.ResolvePipelines(job.RepoPath, cfg)
+	if len(pipelines) == 0 {
+		return
+	}
+	pos, err := wp.db.GetJobPipeline(job.ID)
+	if err != nil {
+		log.Printf("Pipelines: error loading job %d: %v", job.ID, err)
+		return
+	}
+
+	if pos.Pipeline != "" {
+		for _, p := range pipelines {
+			if p.Name == pos.Pipeline {
+				wp.runPipelineSteps(cfg, p, pos.Step+1, job, result)
+				return
+			}
+		}
+		log.Printf("Pipelines: pipeline %q of job %d is no longer configured", pos.Pipeline, job.ID)
+		return
+	}
+	for _, p := range pipelines {
+		if !pipelineTriggers(p, job.ReviewType) {
+			continue
+		}
+		if p.Name == "" {
+			log.Printf("Pipelines: skipping a pipeline without a name for job %d", job.ID)
+			continue
+		}
+		wp.runPipelineSteps(cfg, p, 0, job, result)
+	}
+}
+
+// runPipelineSteps runs a pipeline's steps from start: commands run in
+// turn until a step enqueues a review, which continues the pipeline once it
+// completes, or a condition fails
+func (wp *WorkerPool) runPipelineSteps(cfg *config.Config, p config.PipelineConfig, start int, job *storage.ReviewJob, result *pipelineResult) {
+	for i := start; i < len(p.Steps); i++ {
+		step := p.Steps[i]
+		ok, err := evalPipelineCondition(step.When, result)
+		if err != nil {
+			log.Printf("Pipelines: %s step %d: %v", p.Name, i+1, err)
+			if wp.errorLog != nil {
+				wp.errorLog.LogError("pipeline", fmt.Sprintf("%s step %d: %v", p.Name, i+1, err), job.ID)
+			}
+			return
+		}
+		if !ok {
+			log.Printf("Pipelines: %s stopped at step %d after job %d (%s)", p.Name, i+1, job.ID, step.When)
+			return
+		}
+
+		if step.Command != "" {
+			// Run async like hooks so commands don't block workers
+			go runHook(interpolate(step.Command, result.Event), job.RepoPath)
+			continue
+		}
+
+		next, err := wp.enqueuePipelineJob(cfg, p, i, step, job)
+		if err != nil {
+			log.Printf("Pipelines: %s step %d: %v", p.Name, i+1, err)
+			if wp.errorLog != nil {
+				wp.errorLog.LogError("pipeline", fmt.Sprintf("%s step %d: %v", p.Name, i+1, err), job.ID)
+			}
+			return
+		}
+		log.Printf("Pipelines: %s step %d enqueued job %d after job %d", p.Name, i+1, next.ID, job.ID)
+		return
+	}
+}
+
+// enqueuePipelineJob enqueues a step's review of the same code as job
+func (wp *WorkerPool) enqueuePipelineJob(cfg *config.Config, p config.PipelineConfig, index int, step config.PipelineStepConfig, job *storage.ReviewJob) (*storage.ReviewJob, error) {
+	reviewType := step.ReviewType
+	if config.IsDefaultReviewType(reviewType) {
+		reviewType = "default"
+	}
+	if !config.IsValidReviewType(reviewType) {
+		return nil, fmt.Errorf("invalid review_type %q (valid: %s)", step.ReviewType, config.ValidReviewTypes())
+	}
+	workflow := "review"
+	if !config.IsDefaultReviewType(reviewType) {
+		workflow = reviewType
+	}
+
+	opts := storage.EnqueueOpts{
+		RepoID:       job.RepoID,
+		GitRef:       job.GitRef,
+		Branch:       job.Branch,
+		Agent:        config.ResolveAgentForWorkflow(step.Agent, job.RepoPath, cfg, workflow, job.Reasoning),
+		Model:        config.ResolveModelForWorkflow("", job.RepoPath, cfg, workflow, job.Reasoning),
+		Reasoning:    job.Reasoning,
+		ReviewType:   reviewType,
+		ParentJobID:  job.ID,
+		Pipeline:     p.Name,
+		PipelineStep: index,
+	}
+	if job.CommitID != nil {
+		opts.CommitID = *job.CommitID
+	}
+	if job.DiffContent != nil {
+		opts.DiffContent = *job.DiffContent
+	}
+	return wp.db.EnqueueJob(opts)
+}
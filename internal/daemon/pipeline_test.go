@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestEvalPipelineCondition(t *testing.T) {
+	result := &pipelineResult{
+		Verdict:  "F",
+		Findings: []storage.Finding{{Severity: "high"}, {Severity: "low"}, {Severity: "low"}},
+		HasBlock: true,
+	}
+	tests := []struct {
+		cond string
+		want bool
+	}{
+		{"", true},
+		{"always", true},
+		{"findings > 0", true},
+		{"findings >= 4", false},
+		{"high > 0 && low == 2", true},
+		{"critical > 0 || medium > 0", false},
+		{"critical > 0 || verdict == f", true},
+		{"verdict != F", false},
+		{`verdict == "F"`, true},
+	}
+	for _, tt := range tests {
+		got, err := evalPipelineCondition(tt.cond, result)
+		if err != nil {
+			t.Errorf("%q: %v", tt.cond, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.cond, got, tt.want)
+		}
+	}
+
+	for _, cond := range []string{"score > 1", "findings > many", "verdict > P", "findings"} {
+		if _, err := evalPipelineCondition(cond, result); err == nil {
+			t.Errorf("%q: expected an error", cond)
+		}
+	}
+
+	// A failing review without structured findings counts as one finding
+	if ok, _ := evalPipelineCondition("findings == 1", &pipelineResult{Verdict: "F"}); !ok {
+		t.Error("expected a failing verdict to count as one finding")
+	}
+}
+
+func TestAdvancePipelines(t *testing.T) {
+	tc := newWorkerTestContext(t, 1)
+	sha := testutil.GetHeadSHA(t, tc.TmpDir)
+	marker := filepath.Join(t.TempDir(), "notified")
+
+	cfg := config.DefaultConfig()
+	cfg.Pipelines = []config.PipelineConfig{
+		{
+			Name: "deep",
+			Steps: []config.PipelineStepConfig{
+				{When: "findings > 0", ReviewType: "security"},
+				{When: "verdict == F", Command: "echo {job_id} > " + marker},
+			},
+		},
+		// Pipeline jobs never trigger other pipelines
+		{Name: "loop", Trigger: "security", Steps: []config.PipelineStepConfig{{ReviewType: "security"}}},
+	}
+
+	root := tc.createJob(t, sha)
+	loaded, err := tc.DB.GetJobByID(root.ID)
+	if err != nil {
+		t.Fatalf("GetJobByID failed: %v", err)
+	}
+
+	// A passing review stops at the first condition
+	tc.Pool.advancePipelines(cfg, loaded, &pipelineResult{Verdict: "P"})
+	if jobs, _ := tc.DB.ListJobs("", "", 0, 0); len(jobs) != 1 {
+		t.Fatalf("expected no follow-up job for a passing review, got %d jobs", len(jobs))
+	}
+
+	tc.Pool.advancePipelines(cfg, loaded, &pipelineResult{Verdict: "F"})
+	jobs, err := tc.DB.ListJobs("", "", 0, 0)
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected a follow-up job, got %d jobs", len(jobs))
+	}
+	var next *storage.ReviewJob
+	for i := range jobs {
+		if jobs[i].ID != root.ID {
+			next = &jobs[i]
+		}
+	}
+	if next.ReviewType != "security" || next.GitRef != sha || next.CommitID == nil {
+		t.Errorf("follow-up job = %+v, want a security review of %s", next, sha)
+	}
+	pos, err := tc.DB.GetJobPipeline(next.ID)
+	if err != nil {
+		t.Fatalf("GetJobPipeline failed: %v", err)
+	}
+	if pos != (storage.JobPipeline{ParentJobID: root.ID, Pipeline: "deep", Step: 0}) {
+		t.Errorf("pipeline position = %+v", pos)
+	}
+
+	// The follow-up job continues its pipeline with the command step
+	tc.Pool.advancePipelines(cfg, next, &pipelineResult{Verdict: "F", Event: Event{JobID: next.ID}})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(marker); err == nil && len(data) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the pipeline command")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if jobs, _ := tc.DB.ListJobs("", "", 0, 0); len(jobs) != 2 {
+		t.Errorf("expected the security pipeline not to start for a pipeline job, got %d jobs", len(jobs))
+	}
+}
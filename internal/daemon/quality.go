@@ -0,0 +1,140 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// reviewQuality is how usable one review from an agent was
+type reviewQuality int
+
+const (
+	qualityOK           reviewQuality = iota
+	qualityEmpty                      // Empty or refused, so the job failed
+	qualityParseFailure               // Completed without the requested findings block
+)
+
+// qualityWindow holds an agent's most recent review outcomes
+type qualityWindow struct {
+	samples []reviewQuality
+	alarm   string
+}
+
+// qualityTracker keeps rolling review quality metrics per agent, so a CLI
+// update that breaks output parsing, or credentials that expire into empty
+// responses, raise an alarm instead of silently degrading reviews
+type qualityTracker struct {
+	mu     sync.Mutex
+	agents map[string]*qualityWindow
+}
+
+func newQualityTracker() *qualityTracker {
+	return &qualityTracker{agents: make(map[string]*qualityWindow)}
+}
+
+// record adds a review outcome for the named agent. It returns the alarm
+// when one of the agent's rates first exceeds its limit, and reports
+// whether a previous alarm cleared.
+func (t *qualityTracker) record(cfg config.QualityAlarmConfig, name string, q reviewQuality) (alarm string, cleared bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.agents[name]
+	if !ok {
+		w = &qualityWindow{}
+		t.agents[name] = w
+	}
+	w.samples = append(w.samples, q)
+	if size := cfg.ResolvedWindow(); len(w.samples) > size {
+		w.samples = w.samples[len(w.samples)-size:]
+	}
+
+	current := qualityAlarm(cfg, w.samples)
+	previous := w.alarm
+	w.alarm = current
+	switch {
+	case current != "" && previous == "":
+		return current, false
+	case current == "" && previous != "":
+		return "", true
+	}
+	return "", false
+}
+
+// alarms returns the active alarm of each agent that has one
+func (t *qualityTracker) alarms() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	active := make(map[string]string)
+	for name, w := range t.agents {
+		if w.alarm != "" {
+			active[name] = w.alarm
+		}
+	}
+	return active
+}
+
+// qualityAlarm describes the rates in samples that exceed their limits, or
+// returns "" when none do or there are too few samples to tell
+func qualityAlarm(cfg config.QualityAlarmConfig, samples []reviewQuality) string {
+	if len(samples) < cfg.ResolvedMinSamples() {
+		return ""
+	}
+	var empty, unparsed int
+	for _, q := range samples {
+		switch q {
+		case qualityEmpty:
+			empty++
+		case qualityParseFailure:
+			unparsed++
+		}
+	}
+
+	var problems []string
+	check := func(count int, limit float64, what string) {
+		if rate := float64(count) / float64(len(samples)); rate > limit {
+			problems = append(problems, fmt.Sprintf("%d of the last %d reviews %s (limit %.0f%%)", count, len(samples), what, limit*100))
+		}
+	}
+	check(empty, cfg.ResolvedMaxEmptyRate(), "were empty or refused")
+	check(unparsed, cfg.ResolvedMaxParseFailureRate(), "had no parseable findings")
+	return strings.Join(problems, "; ")
+}
+
+// recordReviewQuality tracks a review's outcome for its agent, warning in
+// the error log and broadcasting a review.quality_alarm event, which hooks
+// can notify on, when the agent's quality drops
+func (wp *WorkerPool) recordReviewQuality(cfg *config.Config, job *storage.ReviewJob, agentName string, q reviewQuality) {
+	if cfg == nil || cfg.QualityAlarms.Disabled {
+		return
+	}
+	alarm, cleared := wp.quality.record(cfg.QualityAlarms, agentName, q)
+	if cleared {
+		log.Printf("Quality alarm for %s cleared", agentName)
+	}
+	if alarm == "" {
+		return
+	}
+
+	msg := fmt.Sprintf("%s: %s; check that its CLI still works and is logged in (run 'roborev doctor')", agentName, alarm)
+	log.Printf("Quality alarm: %s", msg)
+	if wp.errorLog != nil {
+		wp.errorLog.LogWarn("quality", msg, job.ID)
+	}
+	wp.emit(Event{
+		Type:     "review.quality_alarm",
+		TS:       time.Now(),
+		JobID:    job.ID,
+		Repo:     job.RepoPath,
+		RepoName: job.RepoName,
+		SHA:      job.GitRef,
+		Agent:    agentName,
+		Error:    alarm,
+	})
+}
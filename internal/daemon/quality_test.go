@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestQualityTracker(t *testing.T) {
+	cfg := config.QualityAlarmConfig{Window: 4, MinSamples: 3}
+	tr := newQualityTracker()
+
+	record := func(q reviewQuality) (string, bool) {
+		t.Helper()
+		return tr.record(cfg, "codex", q)
+	}
+
+	// Too few samples to alarm yet
+	if alarm, _ := record(qualityEmpty); alarm != "" {
+		t.Fatalf("expected no alarm before min_samples, got %q", alarm)
+	}
+	if alarm, _ := record(qualityOK); alarm != "" {
+		t.Fatalf("expected no alarm before min_samples, got %q", alarm)
+	}
+
+	alarm, _ := record(qualityEmpty)
+	if !strings.Contains(alarm, "2 of the last 3 reviews were empty") {
+		t.Fatalf("expected an empty-rate alarm, got %q", alarm)
+	}
+	if got := tr.alarms()["codex"]; got != alarm {
+		t.Errorf("expected the active alarm %q, got %q", alarm, got)
+	}
+
+	// An alarm already raised isn't raised again
+	if alarm, _ := record(qualityEmpty); alarm != "" {
+		t.Errorf("expected the alarm to fire once, got %q", alarm)
+	}
+
+	// Good reviews push the bad ones out of the window
+	cleared := false
+	for i := 0; i < 4 && !cleared; i++ {
+		_, cleared = record(qualityOK)
+	}
+	if !cleared || len(tr.alarms()) != 0 {
+		t.Errorf("expected the alarm to clear, active: %v", tr.alarms())
+	}
+}
+
+func TestQualityAlarmParseFailures(t *testing.T) {
+	cfg := config.QualityAlarmConfig{Window: 4, MinSamples: 4}
+	samples := []reviewQuality{qualityParseFailure, qualityParseFailure, qualityOK, qualityOK}
+	if got := qualityAlarm(cfg, samples); got != "" {
+		t.Errorf("expected a 50%% parse failure rate to be within the default limit, got %q", got)
+	}
+	samples[2] = qualityParseFailure
+	if got := qualityAlarm(cfg, samples); !strings.Contains(got, "3 of the last 4 reviews had no parseable findings") {
+		t.Errorf("expected a parse failure alarm, got %q", got)
+	}
+}
+
+func TestRecordReviewQualityBroadcasts(t *testing.T) {
+	tc := newWorkerTestContext(t, 1)
+	_, events := tc.Broadcaster.Subscribe("")
+	cfg := config.DefaultConfig()
+	cfg.QualityAlarms = config.QualityAlarmConfig{MinSamples: 2}
+	job := &storage.ReviewJob{ID: 7, RepoPath: tc.TmpDir, GitRef: "abc123"}
+
+	// processJob records the job's outcome alongside its quality
+	tc.Pool.agentHealth.record("claude-code", storage.ErrorClassCrash, "invalid review output")
+	tc.Pool.recordReviewQuality(cfg, job, "claude-code", qualityEmpty)
+	tc.Pool.recordReviewQuality(cfg, job, "claude-code", qualityEmpty)
+
+	select {
+	case e := <-events:
+		if e.Type != "review.quality_alarm" || e.Agent != "claude-code" || e.JobID != 7 || !strings.Contains(e.Error, "empty") {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a review.quality_alarm event")
+	}
+
+	var alarm string
+	for _, h := range tc.Pool.AgentHealth() {
+		if h.Name == "claude-code" {
+			alarm = h.QualityAlarm
+		}
+	}
+	if alarm == "" {
+		t.Error("expected the alarm in the agent's health")
+	}
+
+	cfg.QualityAlarms.Disabled = true
+	tc.Pool.recordReviewQuality(cfg, job, "gemini", qualityEmpty)
+	tc.Pool.recordReviewQuality(cfg, job, "gemini", qualityEmpty)
+	if _, ok := tc.Pool.quality.alarms()["gemini"]; ok {
+		t.Error("expected no tracking while alarms are disabled")
+	}
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// tokenBucket allows requests at a steady rate with bursts up to capacity
+type tokenBucket struct {
+	mu       sync.Mutex
+	limit    config.RateLimitConfig
+	tokens   float64
+	capacity float64
+	perSec   float64
+	last     time.Time
+}
+
+func newTokenBucket(limit config.RateLimitConfig) *tokenBucket {
+	capacity := float64(max(limit.Burst, 1))
+	return &tokenBucket{
+		limit:    limit,
+		tokens:   capacity,
+		capacity: capacity,
+		perSec:   limit.RequestsPerMinute / 60,
+		last:     time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it. Tokens are taken in call order, so waiters are served FIFO.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.perSec)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.perSec * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve that went unused
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.capacity, b.tokens+1)
+}
+
+// agentRateLimiter holds one token bucket per agent, shared by all workers
+type agentRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newAgentRateLimiter() *agentRateLimiter {
+	return &agentRateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// bucket returns the agent's bucket, replacing it when the configured
+// limit has changed
+func (l *agentRateLimiter) bucket(name string, limit config.RateLimitConfig) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[name]
+	if !ok || b.limit != limit {
+		b = newTokenBucket(limit)
+		l.buckets[name] = b
+	}
+	return b
+}
+
+// wait blocks until the agent may send another request or ctx is done
+func (l *agentRateLimiter) wait(ctx context.Context, name string, limit config.RateLimitConfig) error {
+	b := l.bucket(name, limit)
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	log.Printf("Rate limiting %s: waiting %s", name, delay.Round(time.Second))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// limit wraps a so every request waits for the agent's rate limit. Agents
+// without a positive requests_per_minute are returned unchanged.
+func (l *agentRateLimiter) limit(a agent.Agent, limits map[string]config.RateLimitConfig) agent.Agent {
+	limit, ok := limits[a.Name()]
+	if !ok || limit.RequestsPerMinute <= 0 {
+		return a
+	}
+	return &rateLimitedAgent{Agent: a, limiter: l, limit: limit}
+}
+
+// rateLimitedAgent waits for the shared rate limiter before each request
+type rateLimitedAgent struct {
+	agent.Agent
+	limiter *agentRateLimiter
+	limit   config.RateLimitConfig
+}
+
+func (r *rateLimitedAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	if err := r.limiter.wait(ctx, r.Name(), r.limit); err != nil {
+		return "", err
+	}
+	return r.Agent.Review(ctx, repoPath, commitSHA, prompt, output)
+}
+
+func (r *rateLimitedAgent) WithReasoning(level agent.ReasoningLevel) agent.Agent {
+	return &rateLimitedAgent{Agent: r.Agent.WithReasoning(level), limiter: r.limiter, limit: r.limit}
+}
+
+func (r *rateLimitedAgent) WithAgentic(agentic bool) agent.Agent {
+	return &rateLimitedAgent{Agent: r.Agent.WithAgentic(agentic), limiter: r.limiter, limit: r.limit}
+}
+
+func (r *rateLimitedAgent) WithModel(model string) agent.Agent {
+	return &rateLimitedAgent{Agent: r.Agent.WithModel(model), limiter: r.limiter, limit: r.limit}
+}
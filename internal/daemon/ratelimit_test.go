@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	b := newTokenBucket(config.RateLimitConfig{RequestsPerMinute: 60, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		if d := b.reserve(); d != 0 {
+			t.Fatalf("reserve %d within burst: got delay %v", i, d)
+		}
+	}
+	// One token per second: the next two callers queue behind each other
+	if d := b.reserve(); d < 900*time.Millisecond || d > time.Second {
+		t.Errorf("third reserve: got delay %v, want ~1s", d)
+	}
+	if d := b.reserve(); d < 1900*time.Millisecond || d > 2*time.Second {
+		t.Errorf("fourth reserve: got delay %v, want ~2s", d)
+	}
+}
+
+func TestAgentRateLimiterWait(t *testing.T) {
+	l := newAgentRateLimiter()
+	limit := config.RateLimitConfig{RequestsPerMinute: 1}
+
+	if err := l.wait(context.Background(), "codex", limit); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+
+	// The next request would wait a minute; cancellation must cut it short
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.wait(ctx, "codex", limit); err == nil {
+		t.Fatal("expected context error while rate limited")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("wait did not return promptly on cancellation")
+	}
+
+	// Other agents have their own bucket
+	if err := l.wait(context.Background(), "gemini", limit); err != nil {
+		t.Fatalf("gemini wait: %v", err)
+	}
+
+	// A changed limit replaces the bucket
+	if err := l.wait(context.Background(), "codex", config.RateLimitConfig{RequestsPerMinute: 600, Burst: 5}); err != nil {
+		t.Fatalf("wait after limit change: %v", err)
+	}
+}
+
+func TestAgentRateLimiterLimit(t *testing.T) {
+	l := newAgentRateLimiter()
+	base := agent.NewTestAgent()
+
+	if got := l.limit(base, nil); got != agent.Agent(base) {
+		t.Error("expected agent without a limit to be returned unchanged")
+	}
+	if got := l.limit(base, map[string]config.RateLimitConfig{"test": {}}); got != agent.Agent(base) {
+		t.Error("expected zero requests_per_minute to disable limiting")
+	}
+
+	limited := l.limit(base, map[string]config.RateLimitConfig{"test": {RequestsPerMinute: 60}})
+	if _, ok := limited.(*rateLimitedAgent); !ok {
+		t.Fatalf("expected rate limited agent, got %T", limited)
+	}
+	if _, ok := limited.WithAgentic(false).WithReasoning(agent.ReasoningFast).(*rateLimitedAgent); !ok {
+		t.Error("expected derived agents to stay rate limited")
+	}
+	if limited.Name() != "test" {
+		t.Errorf("Name() = %q, want test", limited.Name())
+	}
+}
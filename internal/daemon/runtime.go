@@ -16,11 +16,17 @@ import (
 
 // RuntimeInfo stores daemon runtime state
 type RuntimeInfo struct {
-	PID        int    `json:"pid"`
-	Addr       string `json:"addr"`
-	Port       int    `json:"port"`
-	Version    string `json:"version"`
-	SourcePath string `json:"-"` // Path to the runtime file (not serialized, set by ListAllRuntimes)
+	PID        int       `json:"pid"`
+	Addr       string    `json:"addr"`
+	Port       int       `json:"port"`
+	Version    string    `json:"version"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	SourcePath string    `json:"-"` // Path to the runtime file (not serialized, set by ListAllRuntimes)
+
+	// ProcessStart is the OS's start time for PID, in a platform-specific
+	// form, so a file left by a dead daemon isn't mistaken for a live one
+	// when its PID is reused. Empty where the platform can't report it.
+	ProcessStart string `json:"process_start,omitempty"`
 }
 
 // RuntimePath returns the path to the runtime info file for the current process
@@ -41,11 +47,15 @@ func LegacyRuntimePath() string {
 // WriteRuntime saves the daemon runtime info atomically.
 // Uses write-to-temp-then-rename to prevent readers from seeing partial writes.
 func WriteRuntime(addr string, port int, version string) error {
+	pid := os.Getpid()
+	start, _ := processStartTime(pid)
 	info := RuntimeInfo{
-		PID:     os.Getpid(),
-		Addr:    addr,
-		Port:    port,
-		Version: version,
+		PID:          pid,
+		Addr:         addr,
+		Port:         port,
+		Version:      version,
+		StartedAt:    time.Now().UTC(),
+		ProcessStart: start,
 	}
 
 	path := RuntimePath()
@@ -188,6 +198,11 @@ func ListAllRuntimes() ([]*RuntimeInfo, error) {
 			os.Remove(path)
 			continue
 		}
+		// Remove files left behind by daemons that died without cleaning up
+		if info.IsStale() {
+			os.Remove(path)
+			continue
+		}
 		// Track source path for proper cleanup
 		info.SourcePath = path
 		runtimes = append(runtimes, &info)
@@ -195,6 +210,25 @@ func ListAllRuntimes() ([]*RuntimeInfo, error) {
 	return runtimes, nil
 }
 
+// IsStale reports whether the runtime file's daemon process is known to be
+// gone: its PID isn't running, or, where the platform reports start times,
+// is running a process that started at a different time. Files from older
+// versions, which don't record when the daemon started, are never
+// considered stale here; callers still check that the daemon responds.
+func (info *RuntimeInfo) IsStale() bool {
+	if info.StartedAt.IsZero() || info.PID <= 0 {
+		return false
+	}
+	if !processRunning(info.PID) {
+		return true
+	}
+	if info.ProcessStart == "" {
+		return false
+	}
+	start, ok := processStartTime(info.PID)
+	return ok && start != info.ProcessStart
+}
+
 // GetAnyRunningDaemon returns info about a responsive daemon.
 // Returns os.ErrNotExist if no responsive daemon is found.
 func GetAnyRunningDaemon() (*RuntimeInfo, error) {
@@ -383,12 +417,53 @@ func CleanupZombieDaemons() int {
 // After zombie cleanup, this should usually succeed on the first try.
 // Falls back to searching if the port is still in use (e.g., by another service).
 func FindAvailablePort(startAddr string) (string, int, error) {
-	// Parse the address
+	host, start := splitServerAddr(startAddr)
+	addr, port, err := FindAvailablePortInRange(host, start, start+99)
+	if err != nil {
+		return "", 0, fmt.Errorf("no available port found starting from %d", start)
+	}
+	return addr, port, nil
+}
+
+// FindAvailablePortInRange finds an available port on host between first and
+// last, inclusive
+func FindAvailablePortInRange(host string, first, last int) (string, int, error) {
+	for port := first; port <= last; port++ {
+		addr := fmt.Sprintf("%s:%d", host, port)
+		ln, err := net.Listen("tcp", addr)
+		if err == nil {
+			ln.Close()
+			return addr, port, nil
+		}
+	}
+	if first == last {
+		return "", 0, fmt.Errorf("port %d is not available", first)
+	}
+	return "", 0, fmt.Errorf("no available port found between %d and %d", first, last)
+}
+
+// ListenAddr picks the daemon's listen address: a free port in portRange
+// (see config.Config.ServerPortRange) on serverAddr's host when it's set,
+// or the first free port from serverAddr's otherwise
+func ListenAddr(serverAddr, portRange string) (string, int, error) {
+	if portRange == "" {
+		return FindAvailablePort(serverAddr)
+	}
+	first, last, err := config.ParsePortRange(portRange)
+	if err != nil {
+		return "", 0, fmt.Errorf("server_port_range: %w", err)
+	}
+	host, _ := splitServerAddr(serverAddr)
+	return FindAvailablePortInRange(host, first, last)
+}
+
+// splitServerAddr parses a server_addr value, defaulting to 127.0.0.1:7373
+func splitServerAddr(serverAddr string) (string, int) {
 	host := "127.0.0.1"
 	port := 7373
 
-	if startAddr != "" {
-		parts := strings.Split(startAddr, ":")
+	if serverAddr != "" {
+		parts := strings.Split(serverAddr, ":")
 		if len(parts) == 2 {
 			host = parts[0]
 			if p, err := strconv.Atoi(parts[1]); err == nil {
@@ -396,16 +471,5 @@ func FindAvailablePort(startAddr string) (string, int, error) {
 			}
 		}
 	}
-
-	// Try ports starting from the configured one
-	for i := 0; i < 100; i++ {
-		addr := fmt.Sprintf("%s:%d", host, port+i)
-		ln, err := net.Listen("tcp", addr)
-		if err == nil {
-			ln.Close()
-			return addr, port + i, nil
-		}
-	}
-
-	return "", 0, fmt.Errorf("no available port found starting from %d", port)
+	return host, port
 }
@@ -2,11 +2,14 @@ package daemon
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -61,6 +64,33 @@ func TestFindAvailablePort(t *testing.T) {
 	}
 }
 
+func TestListenAddrPortRange(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	taken := ln.Addr().(*net.TCPAddr).Port
+
+	// A single port is required rather than searched from
+	if _, _, err := ListenAddr("127.0.0.1:7373", strconv.Itoa(taken)); err == nil {
+		t.Errorf("expected an error for the taken fixed port %d", taken)
+	}
+
+	// A range moves past the taken port, keeping server_addr's host
+	addr, port, err := ListenAddr("127.0.0.1:7373", fmt.Sprintf("%d-%d", taken, taken+20))
+	if err != nil {
+		t.Fatalf("ListenAddr failed: %v", err)
+	}
+	if port <= taken || port > taken+20 || addr != fmt.Sprintf("127.0.0.1:%d", port) {
+		t.Errorf("expected a free port in the range after %d, got %s", taken, addr)
+	}
+
+	if _, _, err := ListenAddr("127.0.0.1:7373", "7380-7373"); err == nil || !strings.Contains(err.Error(), "server_port_range") {
+		t.Errorf("expected a server_port_range error, got %v", err)
+	}
+}
+
 func TestRuntimeInfoReadWrite(t *testing.T) {
 	testenv.SetDataDir(t)
 
@@ -351,3 +381,62 @@ func TestListAllRuntimesWithGlobMetacharacters(t *testing.T) {
 		t.Errorf("Expected PID 12345, got %d", runtimes[0].PID)
 	}
 }
+
+func TestListAllRuntimesRemovesStaleFiles(t *testing.T) {
+	dataDir := testenv.SetDataDir(t)
+
+	if err := WriteRuntime("127.0.0.1:7373", 7373, "test"); err != nil {
+		t.Fatalf("WriteRuntime failed: %v", err)
+	}
+	own, err := ReadRuntime()
+	if err != nil {
+		t.Fatalf("ReadRuntime failed: %v", err)
+	}
+	if own.StartedAt.IsZero() || own.IsStale() {
+		t.Fatalf("expected the running process's runtime to record its start and not be stale: %+v", own)
+	}
+
+	// A process that has exited leaves a PID nothing runs under
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatalf("running helper process: %v", err)
+	}
+	deadPID := exited.Process.Pid
+	started := `"started_at": "2025-01-01T00:00:00Z"`
+	dead := createRuntimeFile(t, dataDir, deadPID, fmt.Sprintf(`{"pid": %d, "addr": "127.0.0.1:7374", %s}`, deadPID, started))
+	legacy := createRuntimeFile(t, dataDir, 99999999, `{"pid": 99999999, "addr": "127.0.0.1:7375"}`)
+
+	var reused string
+	if _, ok := processStartTime(os.Getpid()); ok {
+		// Our PID, but recorded with another process's start time
+		path := filepath.Join(dataDir, "daemon.reused.json")
+		content := fmt.Sprintf(`{"pid": %d, "addr": "127.0.0.1:7376", %s, "process_start": "earlier"}`, os.Getpid(), started)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		reused = path
+	}
+
+	runtimes, err := ListAllRuntimes()
+	if err != nil {
+		t.Fatalf("ListAllRuntimes failed: %v", err)
+	}
+	var addrs []string
+	for _, r := range runtimes {
+		addrs = append(addrs, r.Addr)
+	}
+	if got := strings.Join(addrs, ","); got != "127.0.0.1:7373,127.0.0.1:7375" && got != "127.0.0.1:7375,127.0.0.1:7373" {
+		t.Errorf("expected only the live and legacy runtimes, got %s", got)
+	}
+	for _, path := range []string{dead, reused} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected stale runtime file %s to be removed", filepath.Base(path))
+		}
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Errorf("expected the legacy runtime file to be kept for the HTTP check: %v", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/roborev-dev/roborev/internal/agent"
 	"github.com/roborev-dev/roborev/internal/config"
 	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/integrity"
 	"github.com/roborev-dev/roborev/internal/storage"
 	"github.com/roborev-dev/roborev/internal/version"
 )
@@ -31,6 +32,9 @@ type Server struct {
 	syncWorker    *storage.SyncWorker
 	ciPoller      *CIPoller
 	hookRunner    *HookRunner
+	maintenance   *MaintenanceWorker
+	orgGuidelines *OrgGuidelinesSyncer
+	webhooks      *WebhookDispatcher
 	errorLog      *ErrorLog
 	startTime     time.Time
 
@@ -39,11 +43,23 @@ type Server struct {
 	machineID   string
 }
 
+// warnSandboxAgent logs when the sandbox blocks the default agent, since
+// every job that uses it will fail
+func warnSandboxAgent(cfg *config.Config) {
+	if err := agent.CheckSandbox(cfg.DefaultAgent); err != nil {
+		log.Printf("Warning: %v; jobs using it will fail", err)
+	}
+}
+
 // NewServer creates a new daemon server
 func NewServer(db *storage.DB, cfg *config.Config, configPath string) *Server {
 	// Always set for deterministic state - default to false (conservative)
 	agent.SetAllowUnsafeAgents(cfg.AllowUnsafeAgents != nil && *cfg.AllowUnsafeAgents)
 	agent.SetAnthropicAPIKey(cfg.AnthropicAPIKey)
+	agent.SetSandbox(agent.Sandbox(cfg.Sandbox))
+	warnSandboxAgent(cfg)
+	// Canceled jobs must not leave agent subprocesses running
+	agent.SetKillProcessTree(true)
 	broadcaster := NewBroadcaster()
 
 	// Initialize error log
@@ -64,15 +80,25 @@ func NewServer(db *storage.DB, cfg *config.Config, configPath string) *Server {
 		broadcaster:   broadcaster,
 		workerPool:    NewWorkerPool(db, configWatcher, cfg.MaxWorkers, broadcaster, errorLog),
 		hookRunner:    hookRunner,
+		maintenance:   NewMaintenanceWorker(db, configWatcher, broadcaster),
+		orgGuidelines: NewOrgGuidelinesSyncer(configWatcher),
+		webhooks:      NewWebhookDispatcher(db, configWatcher),
 		errorLog:      errorLog,
 		startTime:     time.Now(),
 	}
+	// Review events are written to the webhook outbox where they happen
+	s.workerPool.webhooks = s.webhooks
+	s.maintenance.webhooks = s.webhooks
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/enqueue", s.handleEnqueue)
 	mux.HandleFunc("/api/health", s.handleHealth)
 	mux.HandleFunc("/api/jobs", s.handleListJobs)
+	mux.HandleFunc("/api/jobs/purge", s.handlePurgeJobs)
+	mux.HandleFunc("/api/jobs/{id}/logs", s.handleJobLogs)
+	mux.HandleFunc("/api/jobs/{id}/diff", s.handleJobDiff)
 	mux.HandleFunc("/api/job/cancel", s.handleCancelJob)
+	mux.HandleFunc("/api/job/delete", s.handleDeleteJob)
 	mux.HandleFunc("/api/job/output", s.handleJobOutput)
 	mux.HandleFunc("/api/job/rerun", s.handleRerunJob)
 	mux.HandleFunc("/api/job/update-branch", s.handleUpdateJobBranch)
@@ -81,12 +107,22 @@ func NewServer(db *storage.DB, cfg *config.Config, configPath string) *Server {
 	mux.HandleFunc("/api/branches", s.handleListBranches)
 	mux.HandleFunc("/api/review", s.handleGetReview)
 	mux.HandleFunc("/api/review/address", s.handleAddressReview)
+	mux.HandleFunc("/api/review/{id}/prompt", s.handleReviewPrompt)
 	mux.HandleFunc("/api/comment", s.handleAddComment)
 	mux.HandleFunc("/api/comments", s.handleListComments)
+	mux.HandleFunc("/api/findings", s.handleListFindings)
+	mux.HandleFunc("/api/findings/status", s.handleSetFindingStatus)
+	mux.HandleFunc("/api/search", s.handleSearch)
 	mux.HandleFunc("/api/status", s.handleStatus)
 	mux.HandleFunc("/api/stream/events", s.handleStreamEvents)
 	mux.HandleFunc("/api/sync/now", s.handleSyncNow)
 	mux.HandleFunc("/api/sync/status", s.handleSyncStatus)
+	mux.HandleFunc("/api/undo", s.handleUndo)
+	mux.HandleFunc("/api/maintenance", s.handleMaintenance)
+	mux.HandleFunc("/api/verify", s.handleVerify)
+	mux.HandleFunc("/api/merge-queue/status", s.handleMergeQueueStatus)
+	mux.HandleFunc("/api/webhooks/dead", s.handleListDeadWebhooks)
+	mux.HandleFunc("/api/webhooks/retry", s.handleRetryWebhook)
 
 	s.httpServer = &http.Server{
 		Addr:    cfg.ServerAddr,
@@ -121,7 +157,7 @@ func (s *Server) Start(ctx context.Context) error {
 
 	// Find available port
 	cfg := s.configWatcher.Config()
-	addr, port, err := FindAvailablePort(cfg.ServerAddr)
+	addr, port, err := ListenAddr(cfg.ServerAddr, cfg.ServerPortRange)
 	if err != nil {
 		s.configWatcher.Stop()
 		return fmt.Errorf("find available port: %w", err)
@@ -133,9 +169,20 @@ func (s *Server) Start(ctx context.Context) error {
 		log.Printf("Warning: failed to write runtime info: %v", err)
 	}
 
+	// Deliver webhooks left in the outbox and those the workers queue
+	s.webhooks.Start()
+
 	// Start worker pool
 	s.workerPool.Start()
 
+	// Start DB maintenance (WAL checkpoints, ANALYZE, vacuum, trash purge)
+	if err := s.maintenance.Start(); err != nil {
+		log.Printf("Warning: failed to start maintenance worker: %v", err)
+	}
+
+	// Keep the organization's shared guidelines current
+	s.orgGuidelines.Start()
+
 	// Check for outdated hooks in registered repos
 	if repos, err := s.db.ListRepos(); err == nil {
 		for _, repo := range repos {
@@ -150,6 +197,9 @@ func (s *Server) Start(ctx context.Context) error {
 	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
 		s.configWatcher.Stop()
 		s.workerPool.Stop()
+		s.maintenance.Stop()
+		s.orgGuidelines.Stop()
+		s.webhooks.Stop()
 		return err
 	}
 	return nil
@@ -196,6 +246,14 @@ func (s *Server) Stop() error {
 	// Stop worker pool
 	s.workerPool.Stop()
 
+	// Stop maintenance after workers so no pass races with shutdown writes
+	s.maintenance.Stop()
+	s.orgGuidelines.Stop()
+
+	// Stop webhooks after workers; whatever is undelivered stays in the
+	// outbox for the next start
+	s.webhooks.Stop()
+
 	// Stop hook runner
 	if s.hookRunner != nil {
 		s.hookRunner.Stop()
@@ -370,6 +428,10 @@ type EnqueueRequest struct {
 	CustomPrompt string `json:"custom_prompt,omitempty"` // Custom prompt for ad-hoc agent work
 	Agentic      bool   `json:"agentic,omitempty"`       // Enable agentic mode (allow file edits)
 	OutputPrefix string `json:"output_prefix,omitempty"` // Prefix to prepend to review output
+	Force        bool   `json:"force,omitempty"`         // Review even if the commit matches a skip rule
+	ContextCount *int   `json:"context_count,omitempty"` // Earlier commits' reviews to include; default from context_commits
+	PerCommit    bool   `json:"per_commit,omitempty"`    // Ranges only: review each commit separately, then summarize
+	DirtyScope   string `json:"dirty_scope,omitempty"`   // Dirty reviews without diff_content: capture "staged", "worktree", or "all" changes
 }
 
 type ErrorResponse struct {
@@ -431,13 +493,25 @@ func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "repo_path and git_ref (or commit_sha) are required")
 		return
 	}
+	if req.ContextCount != nil && *req.ContextCount < 0 {
+		writeError(w, http.StatusBadRequest, "context_count must not be negative")
+		return
+	}
+	if req.PerCommit && (req.CustomPrompt != "" || !strings.Contains(gitRef, "..")) {
+		writeError(w, http.StatusBadRequest, "per_commit requires a commit range")
+		return
+	}
 
 	// Validate and normalize review_type
 	if config.IsDefaultReviewType(req.ReviewType) {
 		req.ReviewType = "default"
 	}
-	if req.ReviewType != "default" && req.ReviewType != "security" && req.ReviewType != "design" {
-		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid review_type %q (valid: default, security, design)", req.ReviewType))
+	if !config.IsValidReviewType(req.ReviewType) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid review_type %q (valid: %s)", req.ReviewType, config.ValidReviewTypes()))
+		return
+	}
+
+	if !s.checkRepoAllowed(w, req.RepoPath) {
 		return
 	}
 
@@ -513,6 +587,29 @@ func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
 	// Resolve model for workflow at this reasoning level
 	model := config.ResolveModelForWorkflow(req.Model, repoRoot, s.configWatcher.Config(), workflow, reasoning)
 
+	// Once a daily budget is reached, switch to the fallback agent or stop
+	// taking jobs until the next day
+	budget, err := budgetStatus(s.db, s.configWatcher.Config(), time.Now())
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("check budget: %v", err))
+		return
+	}
+	if exceeded := exceededBudgetFor(budget, repo.RootPath); exceeded != nil {
+		fallback := budget.FallbackAgent
+		if fallback == "" {
+			writeError(w, http.StatusTooManyRequests, budgetMessage(exceeded)+"; new jobs are paused until tomorrow")
+			return
+		}
+		if !agent.IsAvailable(fallback) {
+			writeError(w, http.StatusTooManyRequests, fmt.Sprintf("%s; budget fallback agent %q is not available", budgetMessage(exceeded), fallback))
+			return
+		}
+		fallbackAgent, _ := agent.Get(fallback)
+		agentName = fallbackAgent.Name()
+		// The resolved model belongs to the original agent
+		model = ""
+	}
+
 	// Check if this is a custom prompt, dirty review, range, or single commit
 	// Note: isPrompt is determined by whether custom_prompt is provided, not git_ref value
 	// This allows reviewing a branch literally named "prompt" without collision
@@ -520,9 +617,33 @@ func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
 	isDirty := !isPrompt && gitRef == "dirty"
 	isRange := !isPrompt && !isDirty && strings.Contains(gitRef, "..")
 
-	// Validate dirty review has diff content
+	// Dirty reviews either bring their diff or name the changes to capture
+	if req.DirtyScope != "" {
+		if !isDirty {
+			writeError(w, http.StatusBadRequest, "dirty_scope requires git_ref \"dirty\"")
+			return
+		}
+		if req.DiffContent != "" {
+			writeError(w, http.StatusBadRequest, "dirty_scope and diff_content are mutually exclusive")
+			return
+		}
+		if !git.IsValidDirtyScope(req.DirtyScope) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid dirty_scope %q (valid: %s, %s, %s)", req.DirtyScope, git.DirtyScopeAll, git.DirtyScopeWorktree, git.DirtyScopeStaged))
+			return
+		}
+		diff, err := git.GetDirtyDiffScope(gitCwd, req.DirtyScope)
+		if err != nil {
+			s.writeInternalError(w, fmt.Sprintf("capture %s changes: %v", req.DirtyScope, err))
+			return
+		}
+		if diff == "" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("no %s changes to review", req.DirtyScope))
+			return
+		}
+		req.DiffContent = diff
+	}
 	if isDirty && req.DiffContent == "" {
-		writeError(w, http.StatusBadRequest, "diff_content required for dirty review")
+		writeError(w, http.StatusBadRequest, "diff_content required for dirty review (or dirty_scope to capture it)")
 		return
 	}
 
@@ -555,14 +676,15 @@ func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
 	} else if isDirty {
 		// Dirty review - use pre-captured diff
 		job, err = s.db.EnqueueJob(storage.EnqueueOpts{
-			RepoID:      repo.ID,
-			GitRef:      gitRef,
-			Branch:      req.Branch,
-			Agent:       agentName,
-			Model:       model,
-			Reasoning:   reasoning,
-			ReviewType:  req.ReviewType,
-			DiffContent: req.DiffContent,
+			RepoID:       repo.ID,
+			GitRef:       gitRef,
+			Branch:       req.Branch,
+			Agent:        agentName,
+			Model:        model,
+			Reasoning:    reasoning,
+			ReviewType:   req.ReviewType,
+			DiffContent:  req.DiffContent,
+			ContextCount: req.ContextCount,
 		})
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, fmt.Sprintf("enqueue dirty job: %v", err))
@@ -598,13 +720,15 @@ func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
 		// Store as full SHA range
 		fullRef := startSHA + ".." + endSHA
 		job, err = s.db.EnqueueJob(storage.EnqueueOpts{
-			RepoID:     repo.ID,
-			GitRef:     fullRef,
-			Branch:     req.Branch,
-			Agent:      agentName,
-			Model:      model,
-			Reasoning:  reasoning,
-			ReviewType: req.ReviewType,
+			RepoID:       repo.ID,
+			GitRef:       fullRef,
+			Branch:       req.Branch,
+			Agent:        agentName,
+			Model:        model,
+			Reasoning:    reasoning,
+			ReviewType:   req.ReviewType,
+			ContextCount: req.ContextCount,
+			PerCommit:    req.PerCommit,
 		})
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, fmt.Sprintf("enqueue job: %v", err))
@@ -632,15 +756,24 @@ func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Trivial commits are recorded as skipped so they show up in the queue
+		// without spending agent time
+		var skipReason string
+		if !req.Force {
+			skipReason = commitSkipReason(repoRoot, info, config.ResolveSkipConfig(repoRoot, s.configWatcher.Config()))
+		}
+
 		job, err = s.db.EnqueueJob(storage.EnqueueOpts{
-			RepoID:     repo.ID,
-			CommitID:   commit.ID,
-			GitRef:     sha,
-			Branch:     req.Branch,
-			Agent:      agentName,
-			Model:      model,
-			Reasoning:  reasoning,
-			ReviewType: req.ReviewType,
+			RepoID:       repo.ID,
+			CommitID:     commit.ID,
+			GitRef:       sha,
+			Branch:       req.Branch,
+			Agent:        agentName,
+			Model:        model,
+			Reasoning:    reasoning,
+			ReviewType:   req.ReviewType,
+			SkipReason:   skipReason,
+			ContextCount: req.ContextCount,
 		})
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, fmt.Sprintf("enqueue job: %v", err))
@@ -823,6 +956,9 @@ func (s *Server) handleRegisterRepo(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "repo_path is required")
 		return
 	}
+	if !s.checkRepoAllowed(w, req.RepoPath) {
+		return
+	}
 
 	// Resolve to main repo root (handles worktrees)
 	repoRoot, err := git.GetMainRepoRoot(req.RepoPath)
@@ -909,6 +1045,95 @@ func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
 }
 
+type DeleteJobRequest struct {
+	JobID int64 `json:"job_id"`
+}
+
+func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req DeleteJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.JobID == 0 {
+		writeError(w, http.StatusBadRequest, "job_id is required")
+		return
+	}
+
+	counts, err := s.db.SoftDeleteJob(req.JobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "job not found or still active")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("delete job: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"deleted":     counts,
+		"undo_window": s.configWatcher.Config().ResolvedUndoWindow().String(),
+	})
+}
+
+// PurgeJobsRequest selects finished jobs to delete. Before is an RFC 3339
+// time; empty fields match everything.
+type PurgeJobsRequest struct {
+	RepoPath string `json:"repo_path,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Before   string `json:"before,omitempty"`
+	DryRun   bool   `json:"dry_run,omitempty"`
+}
+
+func (s *Server) handlePurgeJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req PurgeJobsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	filter := storage.PurgeFilter{RepoPath: req.RepoPath, Status: req.Status}
+	if req.Before != "" {
+		before, err := time.Parse(time.RFC3339, req.Before)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid before: %v", err))
+			return
+		}
+		filter.Before = before
+	}
+	switch req.Status {
+	case "", "done", "failed", "canceled", "skipped":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid status %q: only done, failed, canceled, and skipped jobs can be purged", req.Status))
+		return
+	}
+
+	counts, err := s.db.PurgeJobs(filter, req.DryRun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("purge jobs: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"purged":      counts,
+		"dry_run":     req.DryRun,
+		"undo_window": s.configWatcher.Config().ResolvedUndoWindow().String(),
+	})
+}
+
 // JobOutputResponse is the response for /api/job/output
 type JobOutputResponse struct {
 	JobID   int64        `json:"job_id"`
@@ -948,6 +1173,19 @@ func (s *Server) handleJobOutput(w http.ResponseWriter, r *http.Request) {
 	if !stream {
 		// Return current buffer (polling mode)
 		lines := s.workerPool.GetJobOutput(jobID)
+		if lines == nil && job.Status != storage.JobStatusRunning {
+			// No live buffer: fall back to output salvaged from a failed
+			// or interrupted run
+			if output, err := s.db.GetJobOutput(jobID); err != nil {
+				log.Printf("Error loading persisted output for job %d: %v", jobID, err)
+			} else {
+				ts := job.EnqueuedAt
+				if job.FinishedAt != nil {
+					ts = *job.FinishedAt
+				}
+				lines = persistedOutputLines(output, ts)
+			}
+		}
 		if lines == nil {
 			lines = []OutputLine{}
 		}
@@ -1119,7 +1357,10 @@ func (s *Server) handleGetReview(w http.ResponseWriter, r *http.Request) {
 		}
 		review, err = s.db.GetReviewByJobID(jobID)
 	} else if sha := r.URL.Query().Get("sha"); sha != "" {
-		review, err = s.db.GetReviewByCommitSHA(sha)
+		var repoID int64
+		if repoID, err = s.repoIDForPath(r.URL.Query().Get("repo")); err == nil {
+			review, err = s.db.GetReviewByRepoAndCommitSHA(repoID, sha)
+		}
 	} else {
 		writeError(w, http.StatusBadRequest, "job_id or sha parameter required")
 		return
@@ -1130,11 +1371,78 @@ func (s *Server) handleGetReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// full=1 swaps a condensed review for the complete stored output
+	full := review.Summarized && r.URL.Query().Get("full") == "1"
+	if full {
+		fullOutput, err := s.db.GetReviewFullOutput(review.JobID)
+		if err != nil {
+			s.writeInternalError(w, fmt.Sprintf("get full output: %v", err))
+			return
+		}
+		review.Output = fullOutput
+		review.Summarized = false
+	}
+
+	// lang=<language> translates the output, caching it on the review
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		lang, err := normalizeLanguage(lang)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		translated, err := s.translateReview(r.Context(), review, lang, full)
+		if err != nil {
+			s.writeInternalError(w, fmt.Sprintf("translate review: %v", err))
+			return
+		}
+		review.Output = translated
+		review.Language = lang
+	}
+
 	writeJSON(w, http.StatusOK, review)
 }
 
+// ReviewPromptResponse is the response for /api/review/{id}/prompt
+type ReviewPromptResponse struct {
+	ReviewID int64  `json:"review_id"`
+	JobID    int64  `json:"job_id"`
+	Agent    string `json:"agent"`
+	Prompt   string `json:"prompt"`
+}
+
+// handleReviewPrompt returns the prompt stored with a review, exactly as the
+// agent received it
+func (s *Server) handleReviewPrompt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var reviewID int64
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &reviewID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid review id")
+		return
+	}
+	review, err := s.db.GetReviewByID(reviewID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "review not found")
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("get review: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, ReviewPromptResponse{
+		ReviewID: review.ID,
+		JobID:    review.JobID,
+		Agent:    review.Agent,
+		Prompt:   review.Prompt,
+	})
+}
+
 type AddCommentRequest struct {
 	SHA       string `json:"sha,omitempty"`    // Legacy: link to commit by SHA
+	Repo      string `json:"repo,omitempty"`   // Repo path scoping the SHA lookup
 	JobID     int64  `json:"job_id,omitempty"` // Preferred: link to job
 	Commenter string `json:"commenter"`
 	Comment   string `json:"comment"`
@@ -1179,7 +1487,13 @@ func (s *Server) handleAddComment(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		// Legacy: link to commit by SHA
-		commit, err := s.db.GetCommitBySHA(req.SHA)
+		var commit *storage.Commit
+		repoID, err := s.repoIDForPath(req.Repo)
+		if err == nil && repoID != 0 {
+			commit, err = s.db.GetCommitByRepoAndSHA(repoID, req.SHA)
+		} else if err == nil {
+			commit, err = s.db.GetCommitBySHA(req.SHA)
+		}
 		if err != nil {
 			writeError(w, http.StatusNotFound, "commit not found")
 			return
@@ -1195,6 +1509,52 @@ func (s *Server) handleAddComment(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, resp)
 }
 
+// repoIDForPath resolves the repo path that scopes a SHA lookup, since the
+// same SHA can exist in several repos. It returns 0 when no path is given.
+func (s *Server) repoIDForPath(repoPath string) (int64, error) {
+	if repoPath == "" {
+		return 0, nil
+	}
+	// Resolve worktrees to their main repo, unless git may not run there
+	if s.repoPathAllowed(repoPath) {
+		if root, err := git.GetMainRepoRoot(repoPath); err == nil {
+			repoPath = root
+		}
+	}
+	repo, err := s.db.GetRepoByPath(repoPath)
+	if err != nil {
+		return 0, err
+	}
+	return repo.ID, nil
+}
+
+// repoPathAllowed reports whether the daemon may run git and agents in
+// repoPath: always, unless require_registered_repos limits it to the
+// checkouts added with "roborev repo add"
+func (s *Server) repoPathAllowed(repoPath string) bool {
+	cfg := s.configWatcher.Config()
+	if cfg == nil || !cfg.RequireRegisteredRepos {
+		return true
+	}
+	allowed, err := s.db.IsRepoPathAllowed(repoPath)
+	if err != nil {
+		log.Printf("Error checking repo allowlist for %s: %v", repoPath, err)
+		return false
+	}
+	return allowed
+}
+
+// checkRepoAllowed writes a 403 and returns false for repo paths the
+// daemon may not use. Handlers call it before running git in the path,
+// since git can run hooks and commands configured in the repo.
+func (s *Server) checkRepoAllowed(w http.ResponseWriter, repoPath string) bool {
+	if s.repoPathAllowed(repoPath) {
+		return true
+	}
+	writeError(w, http.StatusForbidden, fmt.Sprintf("repo %s is not registered; run 'roborev repo add' in it on the daemon's host", repoPath))
+	return false
+}
+
 func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -1217,7 +1577,13 @@ func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else if sha := r.URL.Query().Get("sha"); sha != "" {
-		responses, err = s.db.GetCommentsForCommitSHA(sha)
+		var repoID int64
+		repoID, err = s.repoIDForPath(r.URL.Query().Get("repo"))
+		if err == nil && repoID != 0 {
+			responses, err = s.db.GetCommentsForRepoCommitSHA(repoID, sha)
+		} else if err == nil {
+			responses, err = s.db.GetCommentsForCommitSHA(sha)
+		}
 		if err != nil {
 			writeError(w, http.StatusNotFound, "commit not found")
 			return
@@ -1230,6 +1596,256 @@ func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]interface{}{"responses": responses})
 }
 
+func (s *Server) handleJobLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var jobID int64
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &jobID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	entry, err := s.db.GetJobLog(jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "no logs recorded for job")
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("get job logs: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// JobDiffResponse is the response for /api/jobs/{id}/diff
+type JobDiffResponse struct {
+	JobID  int64  `json:"job_id"`
+	GitRef string `json:"git_ref"`
+	Source string `json:"source"` // "git", or "snapshot" for uncommitted changes captured at enqueue
+	Diff   string `json:"diff"`
+}
+
+// handleJobDiff returns the diff a job reviewed: the snapshot stored for
+// uncommitted changes, or the commit or range's diff from git
+func (s *Server) handleJobDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var jobID int64
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &jobID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	job, err := s.db.GetJobByID(jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("get job: %v", err))
+		return
+	}
+
+	resp := JobDiffResponse{JobID: job.ID, GitRef: job.GitRef, Source: "git"}
+	switch {
+	case job.IsTaskJob():
+		writeError(w, http.StatusNotFound, "task jobs have no diff")
+		return
+	case job.IsDirtyJob():
+		resp.Source = "snapshot"
+		resp.Diff, err = s.db.GetJobDiffContent(job.ID)
+		if err != nil {
+			s.writeInternalError(w, fmt.Sprintf("get diff snapshot: %v", err))
+			return
+		}
+		if resp.Diff == "" {
+			writeError(w, http.StatusNotFound, "no diff was stored for this job")
+			return
+		}
+	case git.IsRange(job.GitRef):
+		resp.Diff, err = git.GetRangeDiff(job.RepoPath, job.GitRef)
+	default:
+		resp.Diff, err = git.GetDiff(job.RepoPath, job.GitRef)
+	}
+	if err != nil {
+		// The commits may have been rebased away or the repo moved
+		writeError(w, http.StatusNotFound, fmt.Sprintf("diff not available: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleListFindings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var jobID int64
+	if _, err := fmt.Sscanf(r.URL.Query().Get("job_id"), "%d", &jobID); err != nil {
+		writeError(w, http.StatusBadRequest, "job_id parameter required")
+		return
+	}
+	findings, err := s.db.GetFindingsForJob(jobID)
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("get findings: %v", err))
+		return
+	}
+	if findings == nil {
+		findings = []storage.Finding{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"findings": findings})
+}
+
+// maxSearchResults caps how many results a search returns
+const maxSearchResults = 500
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "q parameter required")
+		return
+	}
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if _, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+	}
+	limit = min(limit, maxSearchResults)
+
+	repoID, err := s.repoIDForPath(r.URL.Query().Get("repo"))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing is recorded for the repo, so nothing matches
+		writeJSON(w, http.StatusOK, map[string]interface{}{"results": []storage.SearchResult{}})
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("find repo: %v", err))
+		return
+	}
+
+	results, err := s.db.SearchReviews(query, repoID, limit)
+	if errors.Is(err, storage.ErrEmptySearch) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("search: %v", err))
+		return
+	}
+	if results == nil {
+		results = []storage.SearchResult{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// FindingStatusRequest moves a single finding to a status in
+// storage.FindingStatuses
+type FindingStatusRequest struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+func (s *Server) handleSetFindingStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req FindingStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ID == 0 {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	status, ok := storage.NormalizeFindingStatus(req.Status)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("status must be one of %s", strings.Join(storage.FindingStatuses, ", ")))
+		return
+	}
+
+	if err := s.db.SetFindingStatus(req.ID, status, req.Note); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "finding not found")
+			return
+		}
+		s.writeInternalError(w, fmt.Sprintf("set finding status: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func (s *Server) handleListDeadWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if _, err := fmt.Sscanf(limitStr, "%d", &limit); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+	}
+	deliveries, err := s.db.ListDeadWebhooks(limit)
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("list dead webhooks: %v", err))
+		return
+	}
+	if deliveries == nil {
+		deliveries = []storage.WebhookDelivery{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// WebhookRetryRequest requeues a dead webhook delivery
+type WebhookRetryRequest struct {
+	ID int64 `json:"id"`
+}
+
+func (s *Server) handleRetryWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req WebhookRetryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ID == 0 {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	if err := s.db.RetryDeadWebhook(req.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "dead webhook delivery not found")
+			return
+		}
+		s.writeInternalError(w, fmt.Sprintf("retry webhook: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
 // getMachineID returns the cached machine ID, fetching it on first successful call.
 // Retries on each call until successful to handle transient DB errors.
 func (s *Server) getMachineID() string {
@@ -1258,6 +1874,11 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		s.writeInternalError(w, fmt.Sprintf("get counts: %v", err))
 		return
 	}
+	failureClasses, err := s.db.GetFailureClassCounts()
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("get failure classes: %v", err))
+		return
+	}
 
 	// Get config reload time and counter
 	configReloadedAt := ""
@@ -1266,18 +1887,27 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	configReloadCounter := s.configWatcher.ReloadCounter()
 
+	budget, err := budgetStatus(s.db, s.configWatcher.Config(), time.Now())
+	if err != nil {
+		s.writeInternalError(w, fmt.Sprintf("get budget: %v", err))
+		return
+	}
+
 	status := storage.DaemonStatus{
 		Version:             version.Version,
 		QueuedJobs:          queued,
 		RunningJobs:         running,
 		CompletedJobs:       done,
 		FailedJobs:          failed,
+		FailureClasses:      failureClasses,
+		Agents:              s.workerPool.AgentHealth(),
 		CanceledJobs:        canceled,
 		ActiveWorkers:       s.workerPool.ActiveWorkers(),
 		MaxWorkers:          s.workerPool.MaxWorkers(),
 		MachineID:           s.getMachineID(),
 		ConfigReloadedAt:    configReloadedAt,
 		ConfigReloadCounter: configReloadCounter,
+		Budget:              budget,
 	}
 
 	writeJSON(w, http.StatusOK, status)
@@ -1362,6 +1992,130 @@ func (s *Server) handleStreamEvents(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) handleUndo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	counts, err := s.db.UndoLastDelete(s.configWatcher.Config().ResolvedUndoWindow())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "nothing to undo")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("undo: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "restored": counts})
+}
+
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.maintenance.Status())
+}
+
+// VerifyRequest asks whether commits satisfy the repo's review policy.
+// Commits must be full SHAs.
+type VerifyRequest struct {
+	RepoPath string   `json:"repo_path"`
+	Commits  []string `json:"commits"`
+}
+
+// VerifyResponse reports each commit's review status and whether all of
+// them satisfy the policy
+type VerifyResponse struct {
+	Passed  bool                         `json:"passed"`
+	Commits []storage.CommitReviewStatus `json:"commits"`
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.RepoPath == "" {
+		writeError(w, http.StatusBadRequest, "repo_path is required")
+		return
+	}
+	if !s.checkRepoAllowed(w, req.RepoPath) {
+		return
+	}
+
+	repoRoot, err := git.GetMainRepoRoot(req.RepoPath)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("not a git repository: %v", err))
+		return
+	}
+
+	resp := VerifyResponse{Passed: true, Commits: []storage.CommitReviewStatus{}}
+	repo, err := s.db.GetRepoByPath(repoRoot)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Never registered, so nothing was reviewed
+		for _, sha := range req.Commits {
+			resp.Commits = append(resp.Commits, storage.CommitReviewStatus{SHA: sha, Status: storage.CommitReviewUnreviewed})
+		}
+	case err != nil:
+		s.writeInternalError(w, fmt.Sprintf("lookup repo: %v", err))
+		return
+	default:
+		if resp.Commits, err = s.db.GetCommitReviewStatuses(repo.ID, req.Commits); err != nil {
+			s.writeInternalError(w, fmt.Sprintf("verify commits: %v", err))
+			return
+		}
+	}
+
+	var policy config.ReviewPolicyConfig
+	if repoCfg, err := config.LoadRepoConfig(repoRoot); err == nil && repoCfg != nil {
+		policy = repoCfg.ReviewPolicy
+	}
+
+	var key *integrity.Key
+	if keyPath := s.configWatcher.Config().ReviewSigningKey; keyPath != "" {
+		if key, err = integrity.LoadKey(keyPath); err != nil {
+			s.writeInternalError(w, err.Error())
+			return
+		}
+	} else if policy.RequireSignatures {
+		writeError(w, http.StatusBadRequest, "review_policy requires signatures but no review_signing_key is configured")
+		return
+	}
+	for i, c := range resp.Commits {
+		if key == nil || c.JobID == 0 || c.Status == storage.CommitReviewPending || c.Status == storage.CommitReviewSkipped {
+			continue
+		}
+		if resp.Commits[i].Signature, err = integrity.Check(s.db, key, c.JobID); err != nil {
+			s.writeInternalError(w, fmt.Sprintf("verify signature for job %d: %v", c.JobID, err))
+			return
+		}
+	}
+
+	for _, c := range resp.Commits {
+		switch {
+		case policy.RequireSignatures && c.Signature != "" && c.Signature != integrity.Valid:
+			resp.Passed = false
+		case c.Status == storage.CommitReviewPassed, c.Status == storage.CommitReviewSkipped:
+		case policy.AllowAddressed && c.Status == storage.CommitReviewAddressed:
+		default:
+			resp.Passed = false
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -1467,3 +2221,87 @@ func formatDuration(d time.Duration) string {
 	}
 	return fmt.Sprintf("%ds", s)
 }
+
+// Merge queue results reported by /api/merge-queue/status
+const (
+	MergeQueuePending = "pending"
+	MergeQueuePass    = "pass"
+	MergeQueueFail    = "fail"
+)
+
+// MergeQueueStatusResponse is the pass/fail answer for a merge queue review.
+// Result stays pending until the review finishes or the deadline passes.
+type MergeQueueStatusResponse struct {
+	JobID    int64     `json:"job_id"`
+	Result   string    `json:"result"`
+	Reason   string    `json:"reason,omitempty"`
+	Deadline time.Time `json:"deadline"`
+}
+
+func (s *Server) handleMergeQueueStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var jobID int64
+	if _, err := fmt.Sscanf(r.URL.Query().Get("job_id"), "%d", &jobID); err != nil || jobID <= 0 {
+		writeError(w, http.StatusBadRequest, "job_id is required")
+		return
+	}
+
+	job, err := s.db.GetJobByID(jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeInternalError(w, fmt.Sprintf("get job: %v", err))
+		return
+	}
+
+	var mq config.MergeQueueConfig
+	if repoCfg, err := config.LoadRepoConfig(job.RepoPath); err == nil && repoCfg != nil {
+		mq = repoCfg.MergeQueue
+	}
+	deadline := mq.ResolvedDeadline()
+	resp := MergeQueueStatusResponse{
+		JobID:    job.ID,
+		Result:   MergeQueuePending,
+		Deadline: job.EnqueuedAt.Add(deadline),
+	}
+
+	switch job.Status {
+	case storage.JobStatusDone:
+		review, err := s.db.GetReviewByJobID(job.ID)
+		if err != nil {
+			s.writeInternalError(w, fmt.Sprintf("get review: %v", err))
+			return
+		}
+		if storage.ParseVerdict(review.Output) == "P" {
+			resp.Result = MergeQueuePass
+		} else {
+			resp.Result = MergeQueueFail
+			resp.Reason = "review found issues"
+		}
+	case storage.JobStatusSkipped:
+		resp.Result = MergeQueuePass
+		resp.Reason = "skipped: " + job.Error
+	case storage.JobStatusFailed:
+		resp.Result = MergeQueueFail
+		resp.Reason = "review failed: " + job.Error
+	case storage.JobStatusCanceled:
+		resp.Result = MergeQueueFail
+		resp.Reason = "review was canceled"
+	default:
+		if time.Now().After(resp.Deadline) {
+			resp.Result = MergeQueueFail
+			if mq.PassOnTimeout() {
+				resp.Result = MergeQueuePass
+			}
+			resp.Reason = fmt.Sprintf("review did not finish within %s", deadline)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
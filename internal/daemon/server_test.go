@@ -4,7 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"database/sql"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -21,6 +27,7 @@ import (
 	"github.com/roborev-dev/roborev/internal/agent"
 	"github.com/roborev-dev/roborev/internal/config"
 	gitpkg "github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/integrity"
 	"github.com/roborev-dev/roborev/internal/storage"
 	"github.com/roborev-dev/roborev/internal/testutil"
 )
@@ -1827,6 +1834,48 @@ func TestHandleRegisterRepo(t *testing.T) {
 	})
 }
 
+func TestRequireRegisteredRepos(t *testing.T) {
+	db, tmpDir := testutil.OpenTestDBWithDir(t)
+	cfg := config.DefaultConfig()
+	cfg.RequireRegisteredRepos = true
+	server := NewServer(db, cfg, "")
+
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+
+	enqueue := func() *httptest.ResponseRecorder {
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/enqueue", map[string]string{
+			"repo_path": filepath.Join(repoDir, "subdir"), "git_ref": "HEAD", "agent": "test",
+		})
+		w := httptest.NewRecorder()
+		server.handleEnqueue(w, req)
+		return w
+	}
+
+	if w := enqueue(); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for unregistered repo, got %d: %s", w.Code, w.Body.String())
+	}
+	req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/repos/register", map[string]string{"repo_path": repoDir})
+	w := httptest.NewRecorder()
+	server.handleRegisterRepo(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected register endpoint to refuse unregistered repo, got %d: %s", w.Code, w.Body.String())
+	}
+	if repos, _ := db.ListRepos(); len(repos) != 0 {
+		t.Fatalf("Expected no repos to be created, got %d", len(repos))
+	}
+
+	if err := db.AllowRepoPath(repoDir); err != nil {
+		t.Fatalf("AllowRepoPath: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(repoDir, "subdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if w := enqueue(); w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 for registered repo, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestHandleEnqueueExcludedBranch(t *testing.T) {
 	server, db, tmpDir := newTestServer(t)
 
@@ -1903,6 +1952,69 @@ func TestHandleEnqueueExcludedBranch(t *testing.T) {
 	})
 }
 
+func TestHandleEnqueueSkipRules(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+
+	// HEAD only adds README.md, which counts as documentation
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# testrepo\n"), 0644); err != nil {
+		t.Fatalf("Failed to write README: %v", err)
+	}
+	for _, args := range [][]string{{"add", "README.md"}, {"commit", "-m", "add readme"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	configContent := "[skip]\ndocs_only = true\n"
+	if err := os.WriteFile(filepath.Join(repoDir, ".roborev.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write repo config: %v", err)
+	}
+
+	t.Run("matching commit is recorded as skipped", func(t *testing.T) {
+		reqData := map[string]string{"repo_path": repoDir, "git_ref": "HEAD", "agent": "test"}
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/enqueue", reqData)
+		w := httptest.NewRecorder()
+
+		server.handleEnqueue(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var job storage.ReviewJob
+		testutil.DecodeJSON(t, w, &job)
+		if job.Status != storage.JobStatusSkipped {
+			t.Errorf("Expected skipped status, got %q", job.Status)
+		}
+		if job.Error != "only documentation changed" {
+			t.Errorf("Expected skip reason, got %q", job.Error)
+		}
+
+		queued, _, _, _, _, _ := db.GetJobCounts()
+		if queued != 0 {
+			t.Errorf("Expected 0 queued jobs, got %d", queued)
+		}
+	})
+
+	t.Run("force bypasses skip rules", func(t *testing.T) {
+		reqData := map[string]any{"repo_path": repoDir, "git_ref": "HEAD", "agent": "test", "force": true}
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/enqueue", reqData)
+		w := httptest.NewRecorder()
+
+		server.handleEnqueue(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var job storage.ReviewJob
+		testutil.DecodeJSON(t, w, &job)
+		if job.Status != storage.JobStatusQueued {
+			t.Errorf("Expected queued status with force, got %q", job.Status)
+		}
+	})
+}
+
 func TestHandleEnqueueBranchFallback(t *testing.T) {
 	server, db, tmpDir := newTestServer(t)
 
@@ -1944,6 +2056,137 @@ func TestHandleEnqueueBranchFallback(t *testing.T) {
 	}
 }
 
+func TestHandleEnqueueContextCount(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+
+	enqueue := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/enqueue", body)
+		w := httptest.NewRecorder()
+		server.handleEnqueue(w, req)
+		return w
+	}
+
+	w := enqueue(map[string]interface{}{"repo_path": repoDir, "git_ref": "HEAD", "agent": "test", "context_count": -1})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a negative context_count, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, tc := range []struct {
+		name string
+		body map[string]interface{}
+		want *int
+	}{
+		{"unset", map[string]interface{}{"repo_path": repoDir, "git_ref": "HEAD", "agent": "test", "force": true}, nil},
+		{"zero", map[string]interface{}{"repo_path": repoDir, "git_ref": "HEAD", "agent": "test", "force": true, "context_count": 0}, new(int)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := enqueue(tc.body)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+			}
+			var job storage.ReviewJob
+			testutil.DecodeJSON(t, w, &job)
+			got, err := db.GetJobContextCount(job.ID)
+			if err != nil {
+				t.Fatalf("GetJobContextCount: %v", err)
+			}
+			if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+				t.Errorf("context count = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandleEnqueuePerCommit(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+
+	enqueue := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/enqueue", body)
+		w := httptest.NewRecorder()
+		server.handleEnqueue(w, req)
+		return w
+	}
+
+	w := enqueue(map[string]interface{}{"repo_path": repoDir, "git_ref": "HEAD", "agent": "test", "per_commit": true})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for per_commit on a single commit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = enqueue(map[string]interface{}{"repo_path": repoDir, "git_ref": "HEAD..HEAD", "agent": "test", "per_commit": true})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var job storage.ReviewJob
+	testutil.DecodeJSON(t, w, &job)
+	perCommit, err := db.GetJobPerCommit(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobPerCommit: %v", err)
+	}
+	if !perCommit {
+		t.Error("expected the range job to be marked per-commit")
+	}
+}
+
+func TestHandleEnqueueDirtyScope(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+
+	enqueue := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/enqueue", body)
+		w := httptest.NewRecorder()
+		server.handleEnqueue(w, req)
+		return w
+	}
+	dirty := func(scope string) map[string]interface{} {
+		return map[string]interface{}{"repo_path": repoDir, "git_ref": "dirty", "agent": "test", "dirty_scope": scope}
+	}
+
+	if w := enqueue(dirty(gitpkg.DirtyScopeStaged)); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 with nothing staged, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "test.txt"), []byte("staged change\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "-C", repoDir, "add", "test.txt").CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "untracked.txt"), []byte("untracked\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := enqueue(dirty(gitpkg.DirtyScopeStaged))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp storage.ReviewJob
+	testutil.DecodeJSON(t, w, &resp)
+	diff, err := db.GetJobDiffContent(resp.ID)
+	if err != nil {
+		t.Fatalf("GetJobDiffContent: %v", err)
+	}
+	if !strings.Contains(diff, "+staged change") || strings.Contains(diff, "untracked.txt") {
+		t.Errorf("expected only the staged change to be captured, got %q", diff)
+	}
+
+	if w := enqueue(dirty("index")); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown scope, got %d", w.Code)
+	}
+	withDiff := dirty(gitpkg.DirtyScopeAll)
+	withDiff["diff_content"] = "diff --git a/x b/x\n"
+	if w := enqueue(withDiff); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 with both dirty_scope and diff_content, got %d", w.Code)
+	}
+	if w := enqueue(map[string]interface{}{"repo_path": repoDir, "git_ref": "HEAD", "agent": "test", "dirty_scope": "all"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for dirty_scope on a commit review, got %d", w.Code)
+	}
+}
+
 func TestHandleEnqueueBodySizeLimit(t *testing.T) {
 	server, _, tmpDir := newTestServer(t)
 
@@ -2749,6 +2992,10 @@ func TestHandleEnqueueReviewTypeNormalization(t *testing.T) {
 		{name: "default stored as-is", reviewType: "default", wantCode: http.StatusCreated, wantStored: "default"},
 		{name: "security stored as-is", reviewType: "security", wantCode: http.StatusCreated, wantStored: "security"},
 		{name: "design stored as-is", reviewType: "design", wantCode: http.StatusCreated, wantStored: "design"},
+		{name: "bench stored as-is", reviewType: "bench", wantCode: http.StatusCreated, wantStored: "bench"},
+		{name: "performance stored as-is", reviewType: "performance", wantCode: http.StatusCreated, wantStored: "performance"},
+		{name: "docs stored as-is", reviewType: "docs", wantCode: http.StatusCreated, wantStored: "docs"},
+		{name: "tests stored as-is", reviewType: "tests", wantCode: http.StatusCreated, wantStored: "tests"},
 		{name: "invalid type rejected", reviewType: "bogus", wantCode: http.StatusBadRequest, wantErrorMsg: "invalid review_type"},
 	}
 
@@ -3164,3 +3411,754 @@ func TestHandleEnqueueRangeNonCommitObjectRejects(t *testing.T) {
 		t.Errorf("expected 'invalid start commit' error, got: %s", w.Body.String())
 	}
 }
+
+func TestHandleDeleteJobAndUndo(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repo, err := db.GetOrCreateRepo(tmpDir)
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+
+	t.Run("delete queued job fails", func(t *testing.T) {
+		commit, _ := db.GetOrCreateCommit(repo.ID, "delete-queued", "Author", "Subject", time.Now())
+		job, _ := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "delete-queued", Agent: "test"})
+
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/job/delete", DeleteJobRequest{JobID: job.ID})
+		w := httptest.NewRecorder()
+
+		server.handleDeleteJob(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+		}
+		db.CancelJob(job.ID)
+	})
+
+	t.Run("delete then undo", func(t *testing.T) {
+		commit, _ := db.GetOrCreateCommit(repo.ID, "delete-failed", "Author", "Subject", time.Now())
+		job, _ := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "delete-failed", Agent: "test"})
+		db.ClaimJob("worker-1")
+		db.FailJob(job.ID, "some error")
+
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/job/delete", DeleteJobRequest{JobID: job.ID})
+		w := httptest.NewRecorder()
+		server.handleDeleteJob(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := db.GetJobByID(job.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("Expected deleted job to be hidden, got %v", err)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/api/undo", nil)
+		w = httptest.NewRecorder()
+		server.handleUndo(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := db.GetJobByID(job.ID); err != nil {
+			t.Errorf("Expected job restored, got %v", err)
+		}
+	})
+
+	t.Run("undo with nothing deleted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/undo", nil)
+		w := httptest.NewRecorder()
+		server.handleUndo(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestHandlePurgeJobs(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repo, _ := db.GetOrCreateRepo(tmpDir)
+	commit, _ := db.GetOrCreateCommit(repo.ID, "purge-failed", "Author", "Subject", time.Now())
+	job, _ := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "purge-failed", Agent: "test"})
+	db.ClaimJob("worker-1")
+	db.FailJob(job.ID, "some error")
+
+	purge := func(req PurgeJobsRequest) (int, storage.DeleteCounts) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.handlePurgeJobs(w, testutil.MakeJSONRequest(t, http.MethodPost, "/api/jobs/purge", req))
+		var resp struct {
+			Purged storage.DeleteCounts `json:"purged"`
+		}
+		if w.Code == http.StatusOK {
+			testutil.DecodeJSON(t, w, &resp)
+		}
+		return w.Code, resp.Purged
+	}
+
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	if code, counts := purge(PurgeJobsRequest{RepoPath: tmpDir, Status: "failed", Before: future, DryRun: true}); code != http.StatusOK || counts.Jobs != 1 {
+		t.Fatalf("dry run: got %d, %+v", code, counts)
+	}
+	if _, err := db.GetJobByID(job.ID); err != nil {
+		t.Fatalf("dry run deleted the job: %v", err)
+	}
+	if code, counts := purge(PurgeJobsRequest{RepoPath: tmpDir, Status: "done"}); code != http.StatusOK || counts.Jobs != 0 {
+		t.Errorf("status filter: got %d, %+v", code, counts)
+	}
+	if code, counts := purge(PurgeJobsRequest{RepoPath: tmpDir, Status: "failed"}); code != http.StatusOK || counts.Jobs != 1 {
+		t.Errorf("purge: got %d, %+v", code, counts)
+	}
+	if _, err := db.GetJobByID(job.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected purged job to be hidden, got %v", err)
+	}
+
+	if code, _ := purge(PurgeJobsRequest{Status: "queued"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for active status, got %d", code)
+	}
+	if code, _ := purge(PurgeJobsRequest{Before: "30d"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid before, got %d", code)
+	}
+}
+
+func TestHandleGetReviewFullOutput(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repo, _ := db.GetOrCreateRepo(tmpDir)
+	commit, _ := db.GetOrCreateCommit(repo.ID, "full-output", "Author", "Subject", time.Now())
+	job, _ := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "full-output", Agent: "test"})
+	db.ClaimJob("worker-1")
+	if err := db.CompleteJobWithFullOutput(job.ID, "test", "prompt", "summary", "complete output"); err != nil {
+		t.Fatalf("CompleteJobWithFullOutput failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		query          string
+		wantOutput     string
+		wantSummarized bool
+	}{
+		{fmt.Sprintf("job_id=%d", job.ID), "summary", true},
+		{fmt.Sprintf("job_id=%d&full=1", job.ID), "complete output", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/review?"+tc.query, nil)
+		w := httptest.NewRecorder()
+		server.handleGetReview(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", tc.query, w.Code, w.Body.String())
+		}
+		var review storage.Review
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+			t.Fatalf("%s: decode: %v", tc.query, err)
+		}
+		if review.Output != tc.wantOutput || review.Summarized != tc.wantSummarized {
+			t.Errorf("%s: got output=%q summarized=%v, want %q/%v", tc.query, review.Output, review.Summarized, tc.wantOutput, tc.wantSummarized)
+		}
+	}
+}
+
+func TestHandleReviewPrompt(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repo, _ := db.GetOrCreateRepo(tmpDir)
+	commit, _ := db.GetOrCreateCommit(repo.ID, "stored-prompt", "Author", "Subject", time.Now())
+	job, _ := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "stored-prompt", Agent: "test"})
+	db.ClaimJob("worker-1")
+	if err := db.CompleteJob(job.ID, "test", "the exact prompt\n", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+	review, err := db.GetReviewByJobID(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(id string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/review/"+id+"/prompt", nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		server.handleReviewPrompt(w, req)
+		return w
+	}
+
+	w := get(fmt.Sprint(review.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ReviewPromptResponse
+	testutil.DecodeJSON(t, w, &resp)
+	if resp.Prompt != "the exact prompt\n" || resp.JobID != job.ID || resp.ReviewID != review.ID || resp.Agent != "test" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if w := get("999999"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing review, got %d", w.Code)
+	}
+	if w := get("abc"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid id, got %d", w.Code)
+	}
+}
+
+func TestHandleJobDiff(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+	repoDir := filepath.Join(tmpDir, "repo")
+	testutil.InitTestGitRepo(t, repoDir)
+	sha := testutil.GetHeadSHA(t, repoDir)
+
+	repo, _ := db.GetOrCreateRepo(repoDir)
+	commitJob := testutil.CreateTestJobWithSHA(t, db, repo, sha, "test")
+	dirtyJob, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, GitRef: "dirty", Agent: "test", DiffContent: "diff --git a/x b/x\n+snapshot\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskJob, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, GitRef: "run", Agent: "test", Prompt: "do something"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(id int64) (*httptest.ResponseRecorder, JobDiffResponse) {
+		t.Helper()
+		path := fmt.Sprintf("/api/jobs/%d/diff", id)
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetPathValue("id", fmt.Sprint(id))
+		w := httptest.NewRecorder()
+		server.handleJobDiff(w, req)
+		var resp JobDiffResponse
+		if w.Code == http.StatusOK {
+			testutil.DecodeJSON(t, w, &resp)
+		}
+		return w, resp
+	}
+
+	w, resp := get(commitJob.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Source != "git" || resp.GitRef != sha || !strings.Contains(resp.Diff, "+test content") {
+		t.Errorf("unexpected commit diff response %+v", resp)
+	}
+
+	_, resp = get(dirtyJob.ID)
+	if resp.Source != "snapshot" || resp.Diff != "diff --git a/x b/x\n+snapshot\n" {
+		t.Errorf("expected the stored snapshot, got %+v", resp)
+	}
+
+	if w, _ := get(taskJob.ID); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a task job, got %d", w.Code)
+	}
+	if w, _ := get(999999); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing job, got %d", w.Code)
+	}
+}
+
+func TestHandleGetReviewTranslation(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".roborev.toml"), []byte(`agent = "test"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, _ := db.GetOrCreateRepo(tmpDir)
+	commit, _ := db.GetOrCreateCommit(repo.ID, "translate", "Author", "Subject", time.Now())
+	job, _ := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "translate", Agent: "test"})
+	db.ClaimJob("worker-1")
+	if err := db.CompleteJob(job.ID, "test", "prompt", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+
+	get := func(lang string) (int, storage.Review) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/review?job_id=%d&lang=%s", job.ID, url.QueryEscape(lang)), nil)
+		w := httptest.NewRecorder()
+		server.handleGetReview(w, req)
+		var review storage.Review
+		if w.Code == http.StatusOK {
+			testutil.DecodeJSON(t, w, &review)
+		}
+		return w.Code, review
+	}
+
+	code, review := get("French")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if review.Language != "french" || review.Output == "No issues found." {
+		t.Errorf("expected translated output, got language=%q output=%q", review.Language, review.Output)
+	}
+	cached, err := db.GetReviewAttachment(job.ID, storage.AttachmentTranslationPrefix+"french")
+	if err != nil || cached != review.Output {
+		t.Fatalf("expected translation cached, got %q, %v", cached, err)
+	}
+
+	// Later requests are served from the cache
+	if err := db.SaveReviewAttachment(job.ID, storage.AttachmentTranslationPrefix+"french", "Aucun problème."); err != nil {
+		t.Fatal(err)
+	}
+	if _, review := get("french"); review.Output != "Aucun problème." {
+		t.Errorf("expected cached translation, got %q", review.Output)
+	}
+	if stored, _ := db.GetReviewByJobID(job.ID); stored.Output != "No issues found." {
+		t.Errorf("stored review changed: %q", stored.Output)
+	}
+
+	if code, _ := get("fr; rm -rf"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid language, got %d", code)
+	}
+}
+
+func TestHandleVerify(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+	repoRoot, err := gitpkg.GetMainRepoRoot(repoDir)
+	if err != nil {
+		t.Fatalf("GetMainRepoRoot: %v", err)
+	}
+
+	verify := func(t *testing.T, commits ...string) VerifyResponse {
+		t.Helper()
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/verify", VerifyRequest{RepoPath: repoDir, Commits: commits})
+		w := httptest.NewRecorder()
+		server.handleVerify(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp VerifyResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("unregistered repo reports unreviewed", func(t *testing.T) {
+		resp := verify(t, "aaa")
+		if resp.Passed || len(resp.Commits) != 1 || resp.Commits[0].Status != storage.CommitReviewUnreviewed {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+
+	repo, err := db.GetOrCreateRepo(repoRoot)
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo: %v", err)
+	}
+	for _, c := range []struct{ sha, output string }{
+		{"pass-sha", "No issues found."},
+		{"addressed-sha", "- **High**: unchecked error in main.go:3"},
+	} {
+		commit, _ := db.GetOrCreateCommit(repo.ID, c.sha, "Author", "Subject", time.Now())
+		job, _ := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: c.sha, Agent: "test"})
+		db.ClaimJob("worker-1")
+		if err := db.CompleteJob(job.ID, "test", "prompt", c.output); err != nil {
+			t.Fatalf("CompleteJob: %v", err)
+		}
+		if c.sha == "addressed-sha" {
+			db.MarkReviewAddressedByJobID(job.ID, true)
+		}
+	}
+
+	t.Run("addressed findings fail by default", func(t *testing.T) {
+		resp := verify(t, "pass-sha", "addressed-sha")
+		if resp.Passed {
+			t.Errorf("expected policy failure, got %+v", resp)
+		}
+		if resp.Commits[1].Status != storage.CommitReviewAddressed {
+			t.Errorf("expected addressed status, got %+v", resp.Commits[1])
+		}
+	})
+
+	t.Run("allow_addressed policy passes", func(t *testing.T) {
+		policy := "[review_policy]\nallow_addressed = true\n"
+		if err := os.WriteFile(filepath.Join(repoDir, ".roborev.toml"), []byte(policy), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if resp := verify(t, "pass-sha", "addressed-sha"); !resp.Passed {
+			t.Errorf("expected policy to pass, got %+v", resp)
+		}
+	})
+
+	t.Run("require_signatures policy", func(t *testing.T) {
+		policy := "[review_policy]\nrequire_signatures = true\n"
+		if err := os.WriteFile(filepath.Join(repoDir, ".roborev.toml"), []byte(policy), 0644); err != nil {
+			t.Fatal(err)
+		}
+		req := testutil.MakeJSONRequest(t, http.MethodPost, "/api/verify", VerifyRequest{RepoPath: repoDir, Commits: []string{"pass-sha"}})
+		w := httptest.NewRecorder()
+		server.handleVerify(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 without a signing key, got %d: %s", w.Code, w.Body.String())
+		}
+
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyPath := filepath.Join(tmpDir, "signing.pem")
+		if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		server.configWatcher.Config().ReviewSigningKey = keyPath
+
+		resp := verify(t, "pass-sha")
+		if resp.Passed || resp.Commits[0].Signature != integrity.Unsigned {
+			t.Errorf("expected unsigned review to fail, got %+v", resp)
+		}
+		key, err := integrity.LoadKey(keyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := integrity.SignReview(db, key, resp.Commits[0].JobID); err != nil {
+			t.Fatalf("SignReview: %v", err)
+		}
+		if resp := verify(t, "pass-sha"); !resp.Passed || resp.Commits[0].Signature != integrity.Valid {
+			t.Errorf("expected signed review to pass, got %+v", resp)
+		}
+	})
+}
+
+func TestHandleMergeQueueStatus(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+	repoDir := filepath.Join(tmpDir, "testrepo")
+	testutil.InitTestGitRepo(t, repoDir)
+	repo, err := db.GetOrCreateRepo(repoDir)
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo: %v", err)
+	}
+
+	status := func(t *testing.T, jobID int64) MergeQueueStatusResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/merge-queue/status?job_id=%d", jobID), nil)
+		w := httptest.NewRecorder()
+		server.handleMergeQueueStatus(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp MergeQueueStatusResponse
+		testutil.DecodeJSON(t, w, &resp)
+		return resp
+	}
+	complete := func(t *testing.T, ref, output string) int64 {
+		t.Helper()
+		job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, GitRef: ref, Agent: "test"})
+		if err != nil {
+			t.Fatalf("EnqueueJob: %v", err)
+		}
+		if _, err := db.ClaimJob("worker-1"); err != nil {
+			t.Fatalf("ClaimJob: %v", err)
+		}
+		if err := db.CompleteJob(job.ID, "test", "prompt", output); err != nil {
+			t.Fatalf("CompleteJob: %v", err)
+		}
+		return job.ID
+	}
+	writeConfig := func(t *testing.T, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoDir, ".roborev.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("passing review", func(t *testing.T) {
+		if resp := status(t, complete(t, "a^..a", "No issues found.")); resp.Result != MergeQueuePass {
+			t.Errorf("Expected pass, got %+v", resp)
+		}
+	})
+
+	t.Run("failing review", func(t *testing.T) {
+		resp := status(t, complete(t, "b^..b", "- **High**: unchecked error in main.go:3"))
+		if resp.Result != MergeQueueFail || resp.Reason != "review found issues" {
+			t.Errorf("Expected fail, got %+v", resp)
+		}
+	})
+
+	pending, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, GitRef: "c^..c", Agent: "test"})
+	if err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+
+	t.Run("queued within deadline is pending", func(t *testing.T) {
+		resp := status(t, pending.ID)
+		if resp.Result != MergeQueuePending {
+			t.Errorf("Expected pending, got %+v", resp)
+		}
+		// Stored timestamps have second precision
+		want := pending.EnqueuedAt.Add(config.DefaultMergeQueueDeadline)
+		if diff := resp.Deadline.Sub(want); diff < -time.Second || diff > time.Second {
+			t.Errorf("Expected default deadline near %v, got %v", want, resp.Deadline)
+		}
+	})
+
+	t.Run("deadline passed fails by default", func(t *testing.T) {
+		writeConfig(t, "[merge_queue]\ndeadline = \"1ns\"\n")
+		resp := status(t, pending.ID)
+		if resp.Result != MergeQueueFail || !strings.Contains(resp.Reason, "did not finish") {
+			t.Errorf("Expected timeout fail, got %+v", resp)
+		}
+	})
+
+	t.Run("deadline passed with on_timeout pass", func(t *testing.T) {
+		writeConfig(t, "[merge_queue]\ndeadline = \"1ns\"\non_timeout = \"pass\"\n")
+		if resp := status(t, pending.ID); resp.Result != MergeQueuePass {
+			t.Errorf("Expected timeout pass, got %+v", resp)
+		}
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/merge-queue/status?job_id=99999", nil)
+		w := httptest.NewRecorder()
+		server.handleMergeQueueStatus(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleListFindings(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repo, err := db.GetOrCreateRepo(filepath.Join(tmpDir, "test-repo"))
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	commit, err := db.GetOrCreateCommit(repo.ID, "abc123", "Author", "Test commit", time.Now())
+	if err != nil {
+		t.Fatalf("GetOrCreateCommit failed: %v", err)
+	}
+	job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "abc123", Agent: "test"})
+	if err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	if err := db.SaveFindings(job.ID, []storage.Finding{{Severity: "high", File: "main.go", Line: 7, Message: "nil dereference"}}); err != nil {
+		t.Fatalf("SaveFindings failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/findings?job_id=%d", job.ID), nil)
+	w := httptest.NewRecorder()
+	server.handleListFindings(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Findings []storage.Finding `json:"findings"`
+	}
+	testutil.DecodeJSON(t, w, &resp)
+	if len(resp.Findings) != 1 || resp.Findings[0].File != "main.go" || resp.Findings[0].Line != 7 {
+		t.Errorf("unexpected findings: %+v", resp.Findings)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/findings", nil)
+	w = httptest.NewRecorder()
+	server.handleListFindings(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without job_id, got %d", w.Code)
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repoDir := filepath.Join(tmpDir, "search-repo")
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := db.GetOrCreateRepo(repoDir)
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	commit, err := db.GetOrCreateCommit(repo.ID, "abc123", "Author", "Test commit", time.Now())
+	if err != nil {
+		t.Fatalf("GetOrCreateCommit failed: %v", err)
+	}
+	job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "abc123", Agent: "test"})
+	if err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE review_jobs SET status = 'running' WHERE id = ?`, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CompleteJob(job.ID, "test", "prompt", "Race condition in the session cache"); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+
+	search := func(query string) (*httptest.ResponseRecorder, []storage.SearchResult) {
+		req := httptest.NewRequest(http.MethodGet, "/api/search?"+query, nil)
+		w := httptest.NewRecorder()
+		server.handleSearch(w, req)
+		var resp struct {
+			Results []storage.SearchResult `json:"results"`
+		}
+		if w.Code == http.StatusOK {
+			testutil.DecodeJSON(t, w, &resp)
+		}
+		return w, resp.Results
+	}
+
+	w, results := search("q=race+condition&repo=" + url.QueryEscape(repoDir))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(results) != 1 || results[0].JobID != job.ID || results[0].Kind != storage.SearchKindReview {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	if w, results := search("q=race&repo=" + url.QueryEscape(filepath.Join(tmpDir, "untracked"))); w.Code != http.StatusOK || len(results) != 0 {
+		t.Errorf("Expected no results for an untracked repo, got %d %+v", w.Code, results)
+	}
+	if w, _ := search(""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without q, got %d", w.Code)
+	}
+	if w, _ := search("q=race&limit=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a zero limit, got %d", w.Code)
+	}
+}
+
+func TestHandleSetFindingStatus(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repo, err := db.GetOrCreateRepo(filepath.Join(tmpDir, "test-repo"))
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	commit, err := db.GetOrCreateCommit(repo.ID, "abc123", "Author", "Test commit", time.Now())
+	if err != nil {
+		t.Fatalf("GetOrCreateCommit failed: %v", err)
+	}
+	job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "abc123", Agent: "test"})
+	if err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	if err := db.SaveFindings(job.ID, []storage.Finding{{Severity: "high", File: "main.go", Line: 7, Message: "nil dereference"}}); err != nil {
+		t.Fatalf("SaveFindings failed: %v", err)
+	}
+	findings, err := db.GetFindingsForJob(job.ID)
+	if err != nil || len(findings) != 1 {
+		t.Fatalf("GetFindingsForJob: %v, %d findings", err, len(findings))
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/findings/status", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleSetFindingStatus(w, req)
+		return w
+	}
+
+	w := post(fmt.Sprintf(`{"id": %d, "status": "resolved", "note": "guarded by caller"}`, findings[0].ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	findings, err = db.GetFindingsForJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetFindingsForJob failed: %v", err)
+	}
+	if findings[0].Status != storage.FindingFixed || findings[0].StatusNote != "guarded by caller" {
+		t.Errorf("status not updated: %+v", findings[0])
+	}
+
+	if w := post(fmt.Sprintf(`{"id": %d, "status": "Dismissed", "note": "intended"}`, findings[0].ID)); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if findings, _ = db.GetFindingsForJob(job.ID); findings[0].Status != storage.FindingDismissed {
+		t.Errorf("expected the finding dismissed, got %+v", findings[0])
+	}
+
+	if w := post(fmt.Sprintf(`{"id": %d, "status": "wontfix"}`, findings[0].ID)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown status, got %d", w.Code)
+	}
+	if w := post(`{"status": "open"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without id, got %d", w.Code)
+	}
+	if w := post(`{"id": 9999, "status": "open"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing finding, got %d", w.Code)
+	}
+}
+
+func TestHandleDeadWebhooks(t *testing.T) {
+	server, db, _ := newTestServer(t)
+
+	pending, err := db.EnqueueWebhook("https://example.com/hook", "review.completed", 1, `{}`)
+	if err != nil {
+		t.Fatalf("EnqueueWebhook failed: %v", err)
+	}
+	dead, err := db.EnqueueWebhook("https://example.com/hook", "review.failed", 2, `{}`)
+	if err != nil {
+		t.Fatalf("EnqueueWebhook failed: %v", err)
+	}
+	if err := db.RecordWebhookFailure(dead, "connection refused", time.Time{}); err != nil {
+		t.Fatalf("RecordWebhookFailure failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/webhooks/dead", nil)
+	w := httptest.NewRecorder()
+	server.handleListDeadWebhooks(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Deliveries []storage.WebhookDelivery `json:"deliveries"`
+	}
+	testutil.DecodeJSON(t, w, &resp)
+	if len(resp.Deliveries) != 1 || resp.Deliveries[0].ID != dead || resp.Deliveries[0].LastError != "connection refused" {
+		t.Fatalf("unexpected dead letters %+v", resp.Deliveries)
+	}
+
+	retry := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/retry", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleRetryWebhook(w, req)
+		return w
+	}
+	if w := retry(fmt.Sprintf(`{"id": %d}`, pending)); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 retrying a pending delivery, got %d", w.Code)
+	}
+	if w := retry(`{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without id, got %d", w.Code)
+	}
+	if w := retry(fmt.Sprintf(`{"id": %d}`, dead)); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if due, _ := db.ListDueWebhooks(time.Now(), 10); len(due) != 2 {
+		t.Errorf("expected the retried delivery back in the outbox, got %+v", due)
+	}
+}
+
+func TestHandleJobLogs(t *testing.T) {
+	server, db, tmpDir := newTestServer(t)
+
+	repo, err := db.GetOrCreateRepo(filepath.Join(tmpDir, "test-repo"))
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	commit, err := db.GetOrCreateCommit(repo.ID, "abc123", "Author", "Test commit", time.Now())
+	if err != nil {
+		t.Fatalf("GetOrCreateCommit failed: %v", err)
+	}
+	job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "abc123", Agent: "test"})
+	if err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/jobs/%d/logs", job.ID), nil)
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 before any log, got %d", w.Code)
+	}
+
+	code := 1
+	if err := db.SaveJobLog(storage.JobLog{JobID: job.ID, CommandLine: "test --run", ExitCode: &code, Stderr: "bad auth", Error: "agent: exit status 1"}); err != nil {
+		t.Fatalf("SaveJobLog failed: %v", err)
+	}
+	w := get()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var entry storage.JobLog
+	testutil.DecodeJSON(t, w, &entry)
+	if entry.Stderr != "bad auth" || entry.ExitCode == nil || *entry.ExitCode != 1 {
+		t.Errorf("unexpected log: %+v", entry)
+	}
+}
@@ -0,0 +1,209 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// pooledSession is a warm agent process and its usage so far
+type pooledSession struct {
+	agent.Session
+	key      string
+	job      int64
+	prompts  int
+	history  int // Bytes of prompts and replies the agent has seen
+	lastUsed time.Time
+}
+
+// agentSessionPool holds idle agent sessions, keyed by agent settings, repo,
+// and job, so a session only ever sees the prompts of one job: its review
+// and follow-ups like corrective retries and summaries. Reviews of other
+// commits must not see each other as conversation history.
+type agentSessionPool struct {
+	mu     sync.Mutex
+	idle   map[string][]*pooledSession
+	closed bool
+}
+
+func newAgentSessionPool() *agentSessionPool {
+	return &agentSessionPool{idle: make(map[string][]*pooledSession)}
+}
+
+func sessionKey(a agent.Agent, repoPath string, job int64) string {
+	return a.Name() + "\x00" + a.CommandLine() + "\x00" + repoPath + "\x00" + strconv.FormatInt(job, 10)
+}
+
+// acquire returns an idle session for the agent and repo that has room in
+// its history for a prompt of promptSize bytes, starting one if none does.
+// Sessions without room are closed, since every later prompt would only
+// add to their history.
+func (p *agentSessionPool) acquire(ctx context.Context, a agent.SessionAgent, repoPath string, job int64, promptSize int, cfg config.AgentSessionConfig) (*pooledSession, error) {
+	key := sessionKey(a, repoPath, job)
+	var full []*pooledSession
+	p.mu.Lock()
+	var found *pooledSession
+	for found == nil && len(p.idle[key]) > 0 {
+		idle := p.idle[key]
+		s := idle[len(idle)-1]
+		p.idle[key] = idle[:len(idle)-1]
+		if s.history+promptSize > cfg.ContextLimit() {
+			full = append(full, s)
+		} else {
+			found = s
+		}
+	}
+	p.mu.Unlock()
+
+	for _, s := range full {
+		s.Close()
+	}
+	if found != nil {
+		return found, nil
+	}
+	s, err := a.StartSession(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledSession{Session: s, key: key, job: job}, nil
+}
+
+// release returns s to the pool after a prompt and reply adding size bytes
+// to its history, closing it instead if the prompt failed or it has reached
+// its prompt or history limit
+func (p *agentSessionPool) release(s *pooledSession, size int, err error, cfg config.AgentSessionConfig) {
+	s.prompts++
+	s.history += size
+	s.lastUsed = time.Now()
+	p.mu.Lock()
+	if err != nil || p.closed || s.prompts >= cfg.PromptLimit() || s.history >= cfg.ContextLimit() {
+		p.mu.Unlock()
+		s.Close()
+		return
+	}
+	p.idle[s.key] = append(p.idle[s.key], s)
+	p.mu.Unlock()
+}
+
+// evictIdle closes sessions unused for longer than timeout
+func (p *agentSessionPool) evictIdle(timeout time.Duration) {
+	cutoff := time.Now().Add(-timeout)
+	var stale []*pooledSession
+	p.mu.Lock()
+	for key, idle := range p.idle {
+		kept := idle[:0]
+		for _, s := range idle {
+			if s.lastUsed.Before(cutoff) {
+				stale = append(stale, s)
+			} else {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == 0 {
+			delete(p.idle, key)
+		} else {
+			p.idle[key] = kept
+		}
+	}
+	p.mu.Unlock()
+
+	for _, s := range stale {
+		s.Close()
+	}
+}
+
+// closeJob closes the idle sessions of a finished job
+func (p *agentSessionPool) closeJob(job int64) {
+	var done []*pooledSession
+	p.mu.Lock()
+	for key, idle := range p.idle {
+		kept := idle[:0]
+		for _, s := range idle {
+			if s.job == job {
+				done = append(done, s)
+			} else {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == 0 {
+			delete(p.idle, key)
+		} else {
+			p.idle[key] = kept
+		}
+	}
+	p.mu.Unlock()
+
+	for _, s := range done {
+		s.Close()
+	}
+}
+
+// closeAll closes every idle session; sessions in use are closed when
+// released
+func (p *agentSessionPool) closeAll() {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = make(map[string][]*pooledSession)
+	p.mu.Unlock()
+
+	for _, sessions := range idle {
+		for _, s := range sessions {
+			s.Close()
+		}
+	}
+}
+
+// wrap makes a send job's requests through pooled sessions. Agents that
+// can't hold a session, or all agents when sessions are disabled, are
+// returned unchanged.
+func (p *agentSessionPool) wrap(a agent.Agent, job int64, cfg config.AgentSessionConfig) agent.Agent {
+	sa, ok := a.(agent.SessionAgent)
+	if !ok || !cfg.Enabled {
+		return a
+	}
+	return &sessionAgent{SessionAgent: sa, pool: p, job: job, cfg: cfg}
+}
+
+// sessionAgent runs each of a job's requests in a warm session from the
+// pool
+type sessionAgent struct {
+	agent.SessionAgent
+	pool *agentSessionPool
+	job  int64
+	cfg  config.AgentSessionConfig
+}
+
+func (s *sessionAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	// A prompt too big to share a session even with an empty history gets
+	// a process of its own
+	if len(prompt) > s.cfg.ContextLimit() {
+		return s.SessionAgent.Review(ctx, repoPath, commitSHA, prompt, output)
+	}
+	session, err := s.pool.acquire(ctx, s.SessionAgent, repoPath, s.job, len(prompt), s.cfg)
+	if err != nil {
+		log.Printf("Starting %s session failed, running without one: %v", s.Name(), err)
+		return s.SessionAgent.Review(ctx, repoPath, commitSHA, prompt, output)
+	}
+	result, err := session.Review(ctx, prompt, output)
+	s.pool.release(session, len(prompt)+len(result), err, s.cfg)
+	return result, err
+}
+
+func (s *sessionAgent) WithReasoning(level agent.ReasoningLevel) agent.Agent {
+	return s.pool.wrap(s.SessionAgent.WithReasoning(level), s.job, s.cfg)
+}
+
+func (s *sessionAgent) WithAgentic(agentic bool) agent.Agent {
+	return s.pool.wrap(s.SessionAgent.WithAgentic(agentic), s.job, s.cfg)
+}
+
+func (s *sessionAgent) WithModel(model string) agent.Agent {
+	return s.pool.wrap(s.SessionAgent.WithModel(model), s.job, s.cfg)
+}
@@ -0,0 +1,176 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// fakeSessionAgent counts the sessions it starts and the prompts each sees
+type fakeSessionAgent struct {
+	*agent.TestAgent
+	started atomic.Int32
+	fail    bool
+}
+
+type fakeSession struct {
+	id      int32
+	prompts int
+	fail    bool
+	closed  atomic.Bool
+}
+
+func (a *fakeSessionAgent) StartSession(ctx context.Context, repoPath string) (agent.Session, error) {
+	return &fakeSession{id: a.started.Add(1), fail: a.fail}, nil
+}
+
+func (s *fakeSession) Review(ctx context.Context, prompt string, output io.Writer) (string, error) {
+	if s.fail {
+		return "", errors.New("session failed")
+	}
+	s.prompts++
+	return fmt.Sprintf("session %d prompt %d", s.id, s.prompts), nil
+}
+
+func (s *fakeSession) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+func TestSessionAgentReusesSessions(t *testing.T) {
+	p := newAgentSessionPool()
+	base := &fakeSessionAgent{TestAgent: agent.NewTestAgent()}
+	a := p.wrap(base, 1, config.AgentSessionConfig{Enabled: true, MaxPrompts: 2})
+
+	var got []string
+	for _, repo := range []string{"/repo1", "/repo1", "/repo1", "/repo2"} {
+		out, err := a.Review(context.Background(), repo, "HEAD", "prompt", nil)
+		if err != nil {
+			t.Fatalf("Review: %v", err)
+		}
+		got = append(got, out)
+	}
+	// The third prompt exceeds max_prompts, and other repos get their own session
+	want := []string{"session 1 prompt 1", "session 1 prompt 2", "session 2 prompt 1", "session 3 prompt 1"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSessionAgentKeepsJobsApart(t *testing.T) {
+	p := newAgentSessionPool()
+	base := &fakeSessionAgent{TestAgent: agent.NewTestAgent()}
+	cfg := config.AgentSessionConfig{Enabled: true}
+
+	var got []string
+	for _, job := range []int64{1, 1, 2} {
+		out, err := p.wrap(base, job, cfg).Review(context.Background(), "/repo", "HEAD", "prompt", nil)
+		if err != nil {
+			t.Fatalf("Review: %v", err)
+		}
+		got = append(got, out)
+	}
+	// A job's follow-up reuses its session; the next job's review doesn't
+	// see it as history
+	want := []string{"session 1 prompt 1", "session 1 prompt 2", "session 2 prompt 1"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	p.closeJob(1)
+	if len(p.idle) != 1 {
+		t.Errorf("expected only job 2's session to stay idle, got %d keys", len(p.idle))
+	}
+}
+
+func TestSessionAgentBoundsHistory(t *testing.T) {
+	p := newAgentSessionPool()
+	base := &fakeSessionAgent{TestAgent: agent.NewTestAgent()}
+	a := p.wrap(base, 1, config.AgentSessionConfig{Enabled: true, MaxContextKB: 1})
+
+	prompt := strings.Repeat("x", 400)
+	var got []string
+	for i := 0; i < 3; i++ {
+		out, err := a.Review(context.Background(), "/repo", "HEAD", prompt, nil)
+		if err != nil {
+			t.Fatalf("Review: %v", err)
+		}
+		got = append(got, out)
+	}
+	// Two prompts and replies fill most of the 1 KB history, so the third
+	// starts over in a new session
+	want := []string{"session 1 prompt 1", "session 1 prompt 2", "session 2 prompt 1"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A prompt bigger than the whole history limit runs without a session
+	out, err := a.Review(context.Background(), "/repo", "HEAD", strings.Repeat("x", 2048), nil)
+	if err != nil {
+		t.Fatalf("Review: %v", err)
+	}
+	if strings.HasPrefix(out, "session") || base.started.Load() != 2 {
+		t.Errorf("expected the oversized prompt to bypass sessions, got %q with %d sessions", out, base.started.Load())
+	}
+}
+
+func TestSessionAgentClosesFailedSessions(t *testing.T) {
+	p := newAgentSessionPool()
+	base := &fakeSessionAgent{TestAgent: agent.NewTestAgent(), fail: true}
+	a := p.wrap(base, 1, config.AgentSessionConfig{Enabled: true})
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.Review(context.Background(), "/repo", "HEAD", "prompt", nil); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if n := base.started.Load(); n != 2 {
+		t.Errorf("started %d sessions, want 2 (failed sessions are not reused)", n)
+	}
+}
+
+func TestAgentSessionPoolEvictIdle(t *testing.T) {
+	p := newAgentSessionPool()
+	base := &fakeSessionAgent{TestAgent: agent.NewTestAgent()}
+	cfg := config.AgentSessionConfig{Enabled: true}
+
+	s, err := p.acquire(context.Background(), base, "/repo", 1, 10, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.release(s, 20, nil, cfg)
+
+	p.evictIdle(time.Hour)
+	if s.Session.(*fakeSession).closed.Load() {
+		t.Fatal("session closed before its idle timeout")
+	}
+	s.lastUsed = time.Now().Add(-2 * time.Hour)
+	p.evictIdle(time.Hour)
+	if !s.Session.(*fakeSession).closed.Load() {
+		t.Error("expected idle session to be closed")
+	}
+	if len(p.idle) != 0 {
+		t.Errorf("expected no idle sessions, got %d keys", len(p.idle))
+	}
+}
+
+func TestAgentSessionPoolWrap(t *testing.T) {
+	p := newAgentSessionPool()
+	if got := p.wrap(&fakeSessionAgent{TestAgent: agent.NewTestAgent()}, 1, config.AgentSessionConfig{}); got == nil {
+		t.Fatal("nil agent")
+	} else if _, ok := got.(*sessionAgent); ok {
+		t.Error("expected disabled sessions to leave the agent unchanged")
+	}
+	plain := agent.NewTestAgent()
+	if got := p.wrap(plain, 1, config.AgentSessionConfig{Enabled: true}); got != agent.Agent(plain) {
+		t.Error("expected agents without session support to be returned unchanged")
+	}
+}
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// docExtensions are file extensions treated as documentation by the
+// docs_only skip rule
+var docExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".mdx":      true,
+	".rst":      true,
+	".adoc":     true,
+}
+
+// isDocFile reports whether a repo-relative path is documentation
+func isDocFile(file string) bool {
+	file = strings.ToLower(file)
+	if docExtensions[path.Ext(file)] {
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if dir == "docs" || dir == "doc" {
+			return true
+		}
+	}
+	return false
+}
+
+// commitSkipReason checks a commit against the skip rules and returns why
+// it should be skipped, or "" if it should be reviewed. Rules whose inputs
+// can't be read from git are ignored so the commit still gets reviewed.
+func commitSkipReason(repoPath string, info *git.CommitInfo, rules config.SkipConfig) string {
+	message := info.Subject
+	if info.Body != "" {
+		message += "\n\n" + info.Body
+	}
+	for _, pattern := range rules.MessagePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("Invalid skip message pattern %q: %v", pattern, err)
+			continue
+		}
+		if re.MatchString(message) {
+			return fmt.Sprintf("commit message matches %q", pattern)
+		}
+	}
+
+	if rules.MaxDiffLines > 0 {
+		if lines, err := git.GetLinesChanged(repoPath, info.SHA); err == nil && lines <= rules.MaxDiffLines {
+			return fmt.Sprintf("diff changes %d line(s) (max_diff_lines = %d)", lines, rules.MaxDiffLines)
+		}
+	}
+
+	if rules.DocsOnly {
+		files, err := git.GetFilesChanged(repoPath, info.SHA)
+		if err == nil && len(files) > 0 {
+			docsOnly := true
+			for _, f := range files {
+				if !isDocFile(f) {
+					docsOnly = false
+					break
+				}
+			}
+			if docsOnly {
+				return "only documentation changed"
+			}
+		}
+	}
+
+	return ""
+}
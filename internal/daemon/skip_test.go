@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestIsDocFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"README.md", true},
+		{"docs/setup.html", true},
+		{"internal/doc/guide.txt", true},
+		{"CHANGELOG.rst", true},
+		{"main.go", false},
+		{"docsite/main.go", false},
+		{"cmd/docs.go", false},
+		{"requirements.txt", false},
+		{"CMakeLists.txt", false},
+	}
+	for _, tt := range tests {
+		if got := isDocFile(tt.path); got != tt.want {
+			t.Errorf("isDocFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCommitSkipReason(t *testing.T) {
+	dir := t.TempDir()
+	testutil.InitTestGitRepo(t, dir)
+
+	commit := func(file, content, message string) *git.CommitInfo {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", "."}, {"commit", "-m", message}} {
+			if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
+		}
+		info, err := git.GetCommitInfo(dir, testutil.GetHeadSHA(t, dir))
+		if err != nil {
+			t.Fatalf("GetCommitInfo: %v", err)
+		}
+		return info
+	}
+
+	code := commit("main.go", strings.Repeat("x := 1\n", 20), "Add main loop\n\nFixes a typo in the body")
+	small := commit("util.go", "package util\n", "Add util package")
+	docs := commit("docs/guide.md", "# Guide\n", "Document setup")
+
+	tests := []struct {
+		name  string
+		info  *git.CommitInfo
+		rules config.SkipConfig
+		want  string
+	}{
+		{"no rules", code, config.SkipConfig{}, ""},
+		{"message pattern matches body", code, config.SkipConfig{MessagePatterns: []string{`(?i)\btypo\b`}}, `commit message matches "(?i)\\btypo\\b"`},
+		{"invalid pattern ignored", code, config.SkipConfig{MessagePatterns: []string{"("}}, ""},
+		{"small diff", small, config.SkipConfig{MaxDiffLines: 3}, "diff changes 1 line(s) (max_diff_lines = 3)"},
+		{"large diff", code, config.SkipConfig{MaxDiffLines: 3}, ""},
+		{"docs only", docs, config.SkipConfig{DocsOnly: true}, "only documentation changed"},
+		{"code is not docs", code, config.SkipConfig{DocsOnly: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitSkipReason(dir, tt.info, tt.rules); got != tt.want {
+				t.Errorf("commitSkipReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
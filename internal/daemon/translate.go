@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/prompt"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// translateTimeout bounds one translation run
+const translateTimeout = 5 * time.Minute
+
+// normalizeLanguage validates a requested language, such as "French" or
+// "pt-BR", and returns the lowercase form used to name its cached
+// translation.
+func normalizeLanguage(lang string) (string, error) {
+	lang = strings.Join(strings.Fields(lang), " ")
+	if lang == "" || len(lang) > 40 {
+		return "", fmt.Errorf("invalid language %q", lang)
+	}
+	for _, r := range lang {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != ' ' {
+			return "", fmt.Errorf("invalid language %q", lang)
+		}
+	}
+	return strings.ToLower(lang), nil
+}
+
+// translateReview returns review's output translated into lang by the
+// repo's configured agent. Translations are cached as review attachments,
+// so each language is only translated once per review; full selects the
+// complete output of a condensed review.
+func (s *Server) translateReview(ctx context.Context, review *storage.Review, lang string, full bool) (string, error) {
+	name := storage.AttachmentTranslationPrefix + lang
+	if full {
+		name += ":full"
+	}
+	cached, err := s.db.GetReviewAttachment(review.JobID, name)
+	if err == nil {
+		return cached, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("get cached translation: %w", err)
+	}
+
+	var repoPath string
+	if review.Job != nil {
+		repoPath = review.Job.RepoPath
+	}
+	cfg := s.configWatcher.Config()
+	a, err := agent.GetAvailable(config.ResolveAgent("", repoPath, cfg))
+	if err != nil {
+		return "", err
+	}
+	if model := config.ResolveModel("", repoPath, cfg); model != "" {
+		a = a.WithModel(model)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, translateTimeout)
+	defer cancel()
+	translated, err := a.WithAgentic(false).Review(ctx, repoPath, "", prompt.BuildTranslatePrompt(review.Output, lang), nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", a.Name(), err)
+	}
+	translated = strings.TrimSpace(translated)
+	if translated == "" {
+		return "", fmt.Errorf("%s returned an empty translation", a.Name())
+	}
+
+	if err := s.db.SaveReviewAttachment(review.JobID, name, translated); err != nil {
+		log.Printf("Job %d: caching %s translation failed: %v", review.JobID, lang, err)
+	}
+	return translated, nil
+}
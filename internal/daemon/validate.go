@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/prompt"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// refusalPattern matches refusal and apology boilerplate at the start of
+// agent output
+var refusalPattern = regexp.MustCompile(`(?i)^\s*(i'm sorry|i am sorry|i apologi[sz]e|sorry,|i can(not|'t|’t) (help|assist|review|comply)|i'm unable to|i am unable to|as an ai\b)`)
+
+// isRefusal reports whether output is a refusal rather than a review
+func isRefusal(output string) bool {
+	return refusalPattern.MatchString(strings.TrimSpace(output))
+}
+
+// isEmptyOutput reports whether output holds no review: nothing but
+// whitespace, or the placeholder agents return when they produced nothing
+func isEmptyOutput(output string) bool {
+	output = strings.TrimSpace(output)
+	return output == "" || output == agent.NoOutput
+}
+
+// invalidOutputError is returned when no attempt produced a usable review.
+// Refusal distinguishes an agent that declined from one that returned
+// nothing, so the failure can be classified.
+type invalidOutputError struct {
+	msg     string
+	refusal bool
+}
+
+func (e *invalidOutputError) Error() string { return e.msg }
+
+// reviewProblems lists what is wrong with a review's output. Unusable is
+// set when the output must not be stored at all; other problems only
+// warrant asking the agent again.
+func reviewProblems(output string, requiredSections []string) (problems []string, unusable bool) {
+	trimmed := strings.TrimSpace(output)
+	if isEmptyOutput(trimmed) {
+		return []string{"the response was empty"}, true
+	}
+	if isRefusal(trimmed) {
+		return []string{"the response declined or apologized instead of reviewing the changes"}, true
+	}
+
+	if storage.ParseVerdict(trimmed) != "P" && !hasSeverityFindings(trimmed) {
+		problems = append(problems, "findings have no severity (Critical, High, Medium, or Low), and there is no explicit \"No issues found\"")
+	}
+	if missing := prompt.MissingSections(trimmed, requiredSections); len(missing) > 0 {
+		problems = append(problems, "it omitted these required sections: "+strings.Join(missing, ", "))
+	}
+	return problems, false
+}
+
+// hasSeverityFindings reports whether output holds findings labeled with a
+// severity, in a machine-readable block or in the prose
+func hasSeverityFindings(output string) bool {
+	if _, _, ok := storage.ExtractFindings(output); ok {
+		return true
+	}
+	return len(storage.ParseMarkdownFindings(output)) > 0
+}
+
+// validateReviewOutput checks a review before it is stored. When the output
+// has problems the agent is asked once more with a corrective instruction.
+// It returns the output to store, or an error when neither attempt produced
+// a usable review. Reviews with only minor problems are stored rather than
+// discarded.
+func validateReviewOutput(ctx context.Context, a agent.Agent, job *storage.ReviewJob, reviewPrompt, output string, w io.Writer) (string, error) {
+	var required []string
+	if repoCfg, err := config.LoadRepoConfig(job.RepoPath); err == nil && repoCfg != nil {
+		required = repoCfg.RequiredSections
+	}
+
+	problems, unusable := reviewProblems(output, required)
+	if len(problems) == 0 {
+		return output, nil
+	}
+
+	log.Printf("Job %d: review output rejected (%s), retrying once", job.ID, strings.Join(problems, "; "))
+	retried, err := a.Review(ctx, job.RepoPath, job.GitRef, reviewPrompt+prompt.CorrectionInstruction(problems), w)
+	if err != nil {
+		if unusable {
+			return "", &invalidOutputError{
+				msg:     fmt.Sprintf("invalid review output (%s); retry failed: %v", problems[0], err),
+				refusal: isRefusal(output),
+			}
+		}
+		log.Printf("Job %d: corrective retry failed, keeping first review: %v", job.ID, err)
+		return output, nil
+	}
+
+	retried = applyOutputFilters(ctx, job.RepoPath, retried)
+	retryProblems, retryUnusable := reviewProblems(retried, required)
+	switch {
+	case !retryUnusable:
+		if len(retryProblems) > 0 {
+			log.Printf("Job %d: retried review still has problems: %s", job.ID, strings.Join(retryProblems, "; "))
+		}
+		return retried, nil
+	case !unusable:
+		return output, nil
+	default:
+		return "", &invalidOutputError{
+			msg:     "invalid review output after retry: " + retryProblems[0],
+			refusal: isRefusal(retried),
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// scriptedAgent returns canned outputs in order and records each prompt
+type scriptedAgent struct {
+	outputs []string
+	prompts []string
+}
+
+func (a *scriptedAgent) Name() string                                   { return "scripted" }
+func (a *scriptedAgent) CommandLine() string                            { return "scripted" }
+func (a *scriptedAgent) WithReasoning(agent.ReasoningLevel) agent.Agent { return a }
+func (a *scriptedAgent) WithAgentic(bool) agent.Agent                   { return a }
+func (a *scriptedAgent) WithModel(string) agent.Agent                   { return a }
+
+func (a *scriptedAgent) Review(ctx context.Context, repoPath, commitSHA, prompt string, output io.Writer) (string, error) {
+	a.prompts = append(a.prompts, prompt)
+	if len(a.outputs) == 0 {
+		return "", fmt.Errorf("no scripted output left")
+	}
+	out := a.outputs[0]
+	a.outputs = a.outputs[1:]
+	return out, nil
+}
+
+func TestReviewProblems(t *testing.T) {
+	sections := []string{"Telemetry impact"}
+	tests := []struct {
+		name         string
+		output       string
+		wantProblems int
+		wantUnusable bool
+	}{
+		{"valid findings", "- **High**: nil dereference in main.go:12\n## Telemetry impact\nNone.", 0, false},
+		{"valid pass", "No issues found.\n\n**Telemetry impact:** none", 0, false},
+		{"empty", "  \n", 1, true},
+		{"agent placeholder", agent.NoOutput + "\n", 1, true},
+		{"refusal", "I'm sorry, but I can't help with reviewing this code.", 1, true},
+		{"apology", "I apologize, I was unable to read the diff.", 1, true},
+		{"no severity markers", "The code changes the parser.\n## Telemetry impact\nNone.", 1, false},
+		{"missing section", "- Medium: missing check in api.go:40", 1, false},
+		{"both soft problems", "Looks like a refactor of the parser.", 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, unusable := reviewProblems(tt.output, sections)
+			if len(problems) != tt.wantProblems || unusable != tt.wantUnusable {
+				t.Errorf("reviewProblems() = %q, unusable=%v; want %d problems, unusable=%v", problems, unusable, tt.wantProblems, tt.wantUnusable)
+			}
+		})
+	}
+}
+
+func TestValidateReviewOutput(t *testing.T) {
+	repoDir := t.TempDir()
+	cfg := "required_sections = [\"Rollback plan assessment\", \"Telemetry impact\"]\n"
+	if err := os.WriteFile(filepath.Join(repoDir, ".roborev.toml"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	job := &storage.ReviewJob{ID: 3, RepoPath: repoDir, GitRef: "abc123"}
+	complete := "No issues found.\n\n## Rollback plan assessment\nRevert is safe.\n\n**Telemetry impact:** none\n"
+
+	t.Run("valid review is kept without retry", func(t *testing.T) {
+		a := &scriptedAgent{}
+		got, err := validateReviewOutput(context.Background(), a, job, "PROMPT", complete, nil)
+		if err != nil || got != complete {
+			t.Errorf("Expected original output, got %q, %v", got, err)
+		}
+		if len(a.prompts) != 0 {
+			t.Errorf("Expected no retry, got %d agent calls", len(a.prompts))
+		}
+	})
+
+	t.Run("missing section triggers one corrective retry", func(t *testing.T) {
+		a := &scriptedAgent{outputs: []string{complete}}
+		got, err := validateReviewOutput(context.Background(), a, job, "PROMPT", "No issues found.\n## Rollback plan assessment\nFine.\n", nil)
+		if err != nil || got != complete {
+			t.Errorf("Expected retried output, got %q, %v", got, err)
+		}
+		if len(a.prompts) != 1 || !strings.HasPrefix(a.prompts[0], "PROMPT") || !strings.Contains(a.prompts[0], "required sections: Telemetry impact\n") {
+			t.Errorf("Expected corrective prompt naming only the missing section, got %q", a.prompts)
+		}
+	})
+
+	t.Run("soft problem keeps first review when retry fails", func(t *testing.T) {
+		a := &scriptedAgent{}
+		got, err := validateReviewOutput(context.Background(), a, job, "PROMPT", "No issues found.", nil)
+		if err != nil || got != "No issues found." {
+			t.Errorf("Expected first review, got %q, %v", got, err)
+		}
+	})
+
+	t.Run("refusal is replaced by retry", func(t *testing.T) {
+		a := &scriptedAgent{outputs: []string{complete}}
+		got, err := validateReviewOutput(context.Background(), a, job, "PROMPT", "I'm sorry, I can't help with that.", nil)
+		if err != nil || got != complete {
+			t.Errorf("Expected retried output, got %q, %v", got, err)
+		}
+		if !strings.Contains(a.prompts[0], "declined or apologized") {
+			t.Errorf("Expected corrective prompt to mention the refusal, got %q", a.prompts[0])
+		}
+	})
+
+	t.Run("unusable output after retry fails", func(t *testing.T) {
+		a := &scriptedAgent{outputs: []string{""}}
+		_, err := validateReviewOutput(context.Background(), a, job, "PROMPT", "", nil)
+		if err == nil || !strings.Contains(err.Error(), "empty") {
+			t.Errorf("Expected error for empty output, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+const (
+	// webhookPollInterval is how often the outbox is checked for retries
+	// that have come due
+	webhookPollInterval = 5 * time.Second
+
+	// webhookTimeout bounds a single delivery attempt
+	webhookTimeout = 10 * time.Second
+
+	// webhookMaxAttempts is how many times a delivery is tried before it
+	// moves to the dead letters
+	webhookMaxAttempts = 8
+
+	// webhookBaseBackoff doubles after each failed attempt, up to
+	// webhookMaxBackoff
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = time.Hour
+
+	// webhookBatchSize caps the deliveries attempted per pass
+	webhookBatchSize = 50
+)
+
+// WebhookDispatcher POSTs review events to hooks of type "webhook" from a
+// persistent outbox, retrying failed deliveries with exponential backoff so
+// a receiver that is briefly down misses nothing. Events are written to the
+// outbox by whatever creates them, alongside the job change they report
+// where there is one, so a busy daemon can't lose them.
+type WebhookDispatcher struct {
+	db        *storage.DB
+	cfgGetter ConfigGetter
+	client    *http.Client
+
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	wakeCh  chan struct{}
+}
+
+// NewWebhookDispatcher creates a dispatcher for the configured webhooks
+func NewWebhookDispatcher(db *storage.DB, cfgGetter ConfigGetter) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:        db,
+		cfgGetter: cfgGetter,
+		client:    &http.Client{Timeout: webhookTimeout},
+		wakeCh:    make(chan struct{}, 1),
+	}
+}
+
+// Start begins delivering the outbox, including deliveries left pending by
+// a previous run
+func (d *WebhookDispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.running = true
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.run(ctx)
+	}()
+}
+
+// Stop waits for in-progress deliveries to finish. Undelivered events stay
+// in the outbox for the next start.
+func (d *WebhookDispatcher) Stop() {
+	d.mu.Lock()
+	if !d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = false
+	d.mu.Unlock()
+
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *WebhookDispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		d.deliverDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wakeCh:
+		}
+	}
+}
+
+// Deliveries returns an outbox entry for each webhook matching event, for
+// the caller to write with the job change the event reports. Call Wake once
+// they are stored. A nil dispatcher has no webhooks.
+func (d *WebhookDispatcher) Deliveries(event Event) []storage.WebhookDelivery {
+	if d == nil || !strings.HasPrefix(event.Type, "review.") {
+		return nil
+	}
+	cfg := d.cfgGetter.Config()
+	if cfg == nil {
+		return nil
+	}
+
+	var deliveries []storage.WebhookDelivery
+	var payload []byte
+	for _, hook := range collectHooks(cfg, event.Repo) {
+		if hook.Type != "webhook" || hook.URL == "" || !matchEvent(hook.Event, event.Type) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(event); err != nil {
+				log.Printf("Webhooks: encode %s event: %v", event.Type, err)
+				return nil
+			}
+		}
+		deliveries = append(deliveries, storage.WebhookDelivery{
+			URL:       hook.URL,
+			EventType: event.Type,
+			JobID:     event.JobID,
+			Payload:   string(payload),
+		})
+	}
+	return deliveries
+}
+
+// Queue writes event to the outbox for each matching webhook, for events
+// that aren't tied to a job change of their own
+func (d *WebhookDispatcher) Queue(event Event) {
+	deliveries := d.Deliveries(event)
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := d.db.EnqueueWebhooks(deliveries); err != nil {
+		log.Printf("Webhooks: queue %s for job %d: %v", event.Type, event.JobID, err)
+		return
+	}
+	d.Wake()
+}
+
+// Wake starts a delivery pass now rather than at the next poll
+func (d *WebhookDispatcher) Wake() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue attempts every delivery due at now
+func (d *WebhookDispatcher) deliverDue(ctx context.Context, now time.Time) {
+	due, err := d.db.ListDueWebhooks(now, webhookBatchSize)
+	if err != nil {
+		log.Printf("Webhooks: list outbox: %v", err)
+		return
+	}
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		err := d.post(ctx, delivery)
+		if ctx.Err() != nil {
+			// Shutting down; the attempt doesn't count
+			return
+		}
+		if err == nil {
+			if err := d.db.MarkWebhookDelivered(delivery.ID); err != nil {
+				log.Printf("Webhooks: mark delivery %d delivered: %v", delivery.ID, err)
+			}
+			continue
+		}
+
+		attempts := delivery.Attempts + 1
+		var retryAt time.Time
+		if attempts < webhookMaxAttempts {
+			retryAt = now.Add(webhookBackoff(attempts))
+		} else {
+			log.Printf("Webhooks: giving up on %s for job %d to %s after %d attempts: %v",
+				delivery.EventType, delivery.JobID, delivery.URL, attempts, err)
+		}
+		if err := d.db.RecordWebhookFailure(delivery.ID, err.Error(), retryAt); err != nil {
+			log.Printf("Webhooks: record failure of delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
+
+// post sends one delivery. Any 2xx response counts as delivered.
+func (d *WebhookDispatcher) post(ctx context.Context, delivery storage.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "roborev")
+	req.Header.Set("X-Roborev-Event", delivery.EventType)
+	req.Header.Set("X-Roborev-Delivery", strconv.FormatInt(delivery.ID, 10))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookBackoff returns the wait before retrying a delivery that has
+// failed attempts times
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func openWebhookTestDB(t *testing.T) *storage.DB {
+	t.Helper()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDispatcherQueue(t *testing.T) {
+	db := openWebhookTestDB(t)
+	cfg := &config.Config{
+		Hooks: []config.HookConfig{
+			{Event: "review.completed", Type: "webhook", URL: "https://example.com/completed"},
+			{Event: "review.*", Type: "webhook", URL: "https://example.com/all"},
+			{Event: "review.failed", Type: "webhook", URL: "https://example.com/failed"},
+			{Event: "review.completed", Type: "webhook"},
+			{Event: "review.completed", Command: "true"},
+		},
+	}
+	d := NewWebhookDispatcher(db, NewStaticConfig(cfg))
+
+	if got := d.Deliveries(Event{Type: "config.reloaded"}); len(got) != 0 {
+		t.Errorf("expected non-review events to be ignored, got %+v", got)
+	}
+	d.Queue(Event{Type: "review.completed", JobID: 3, SHA: "abc123", Verdict: "F"})
+
+	due, err := db.ListDueWebhooks(time.Now(), 10)
+	if err != nil {
+		t.Fatalf("ListDueWebhooks: %v", err)
+	}
+	if len(due) != 2 || due[0].URL != "https://example.com/completed" || due[1].URL != "https://example.com/all" {
+		t.Fatalf("unexpected deliveries %+v", due)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(due[0].Payload), &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if payload["type"] != "review.completed" || payload["job_id"] != float64(3) || payload["verdict"] != "F" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+}
+
+func TestWebhookDispatcherRetriesThenDeadLetters(t *testing.T) {
+	db := openWebhookTestDB(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	d := NewWebhookDispatcher(db, NewStaticConfig(&config.Config{}))
+	id, err := db.EnqueueWebhook(srv.URL, "review.failed", 1, `{}`)
+	if err != nil {
+		t.Fatalf("EnqueueWebhook: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	d.deliverDue(ctx, now)
+	due, _ := db.ListDueWebhooks(now.Add(webhookBackoff(1)), 10)
+	if len(due) != 1 || due[0].Attempts != 1 || due[0].LastError == "" {
+		t.Fatalf("expected one failed attempt rescheduled, got %+v", due)
+	}
+	if due, _ := db.ListDueWebhooks(now, 10); len(due) != 0 {
+		t.Errorf("retry should wait for the backoff, got %+v", due)
+	}
+
+	// Keep failing until the attempts run out
+	for i := 1; i < webhookMaxAttempts; i++ {
+		now = now.Add(webhookMaxBackoff)
+		d.deliverDue(ctx, now)
+	}
+	if got := calls.Load(); got != webhookMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", webhookMaxAttempts, got)
+	}
+	dead, err := db.ListDeadWebhooks(10)
+	if err != nil {
+		t.Fatalf("ListDeadWebhooks: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != id || dead[0].Attempts != webhookMaxAttempts {
+		t.Fatalf("expected the delivery in the dead letters, got %+v", dead)
+	}
+	if due, _ := db.ListDueWebhooks(now.Add(24*time.Hour), 10); len(due) != 0 {
+		t.Errorf("dead deliveries should not be retried, got %+v", due)
+	}
+}
+
+func TestWebhookDispatcherDeliversEvents(t *testing.T) {
+	db := openWebhookTestDB(t)
+	var mu sync.Mutex
+	var got []*http.Request
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, r)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Hooks: []config.HookConfig{{Event: "review.completed", Type: "webhook", URL: srv.URL}},
+	}
+	d := NewWebhookDispatcher(db, NewStaticConfig(cfg))
+	d.Start()
+	defer d.Stop()
+
+	d.Queue(Event{Type: "review.completed", JobID: 9, SHA: "abc123", Verdict: "P"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 {
+		t.Fatalf("expected one delivery, got %d", len(got))
+	}
+	if got[0].Method != http.MethodPost || got[0].Header.Get("X-Roborev-Event") != "review.completed" ||
+		got[0].Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected request %s %v", got[0].Method, got[0].Header)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(bodies[0]), &payload); err != nil || payload["job_id"] != float64(9) {
+		t.Errorf("unexpected body %q: %v", bodies[0], err)
+	}
+
+	// Delivered webhooks leave the outbox
+	deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if due, _ := db.ListDueWebhooks(time.Now().Add(time.Hour), 10); len(due) == 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("delivered webhook still in the outbox")
+}
@@ -2,8 +2,12 @@ package daemon
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os/exec"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -11,17 +15,17 @@ import (
 
 	"github.com/roborev-dev/roborev/internal/agent"
 	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/integrity"
 	"github.com/roborev-dev/roborev/internal/prompt"
 	"github.com/roborev-dev/roborev/internal/storage"
 )
 
 // WorkerPool manages a pool of review workers
 type WorkerPool struct {
-	db            *storage.DB
-	cfgGetter     ConfigGetter
-	promptBuilder *prompt.Builder
-	broadcaster   Broadcaster
-	errorLog      *ErrorLog
+	db          *storage.DB
+	cfgGetter   ConfigGetter
+	broadcaster Broadcaster
+	errorLog    *ErrorLog
 
 	numWorkers    int
 	activeWorkers atomic.Int32
@@ -36,6 +40,25 @@ type WorkerPool struct {
 	// Output capture for tail command
 	outputBuffers *OutputBuffer
 
+	// Per-agent install status and last job outcome for /api/status
+	agentHealth *agentHealthTracker
+
+	// Rolling per-agent review quality, for alarms when it drops
+	quality *qualityTracker
+
+	// Context file contents shared by every job's prompt builder
+	contextCache *prompt.ContextFileCache
+
+	// Request rate limits shared by all workers
+	rateLimiter *agentRateLimiter
+
+	// Warm agent sessions shared by all workers
+	sessions *agentSessionPool
+
+	// Webhook outbox for review events; nil when the pool runs without a
+	// server
+	webhooks *WebhookDispatcher
+
 	// Test hooks for deterministic synchronization (nil in production)
 	testHookAfterSecondCheck func() // Called after second runningJobs check, before second DB lookup
 }
@@ -45,7 +68,6 @@ func NewWorkerPool(db *storage.DB, cfgGetter ConfigGetter, numWorkers int, broad
 	return &WorkerPool{
 		db:             db,
 		cfgGetter:      cfgGetter,
-		promptBuilder:  prompt.NewBuilder(db),
 		broadcaster:    broadcaster,
 		errorLog:       errorLog,
 		numWorkers:     numWorkers,
@@ -53,6 +75,11 @@ func NewWorkerPool(db *storage.DB, cfgGetter ConfigGetter, numWorkers int, broad
 		runningJobs:    make(map[int64]context.CancelFunc),
 		pendingCancels: make(map[int64]bool),
 		outputBuffers:  NewOutputBuffer(512*1024, 4*1024*1024), // 512KB/job, 4MB total
+		agentHealth:    newAgentHealthTracker(),
+		quality:        newQualityTracker(),
+		contextCache:   prompt.NewContextFileCache(),
+		rateLimiter:    newAgentRateLimiter(),
+		sessions:       newAgentSessionPool(),
 	}
 }
 
@@ -64,21 +91,83 @@ func (wp *WorkerPool) Start() {
 		wp.wg.Add(1)
 		go wp.worker(i)
 	}
+
+	wp.wg.Add(1)
+	go wp.evictIdleSessions()
 }
 
 // Stop gracefully shuts down the worker pool
 func (wp *WorkerPool) Stop() {
 	log.Println("Stopping worker pool...")
 	close(wp.stopCh)
+	// Interrupt running jobs rather than waiting out their agents. They stay
+	// running in the DB and are requeued when the daemon next starts.
+	wp.runningJobsMu.Lock()
+	for jobID, cancel := range wp.runningJobs {
+		log.Printf("Interrupting job %d", jobID)
+		cancel()
+	}
+	wp.runningJobsMu.Unlock()
 	wp.wg.Wait()
+	wp.sessions.closeAll()
 	log.Println("Worker pool stopped")
 }
 
+// stopContext returns a context that times out after timeout and is also
+// canceled when the pool stops, for work that outlives the job it came from
+func (wp *WorkerPool) stopContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	go func() {
+		select {
+		case <-wp.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// stopping reports whether Stop has been called
+func (wp *WorkerPool) stopping() bool {
+	select {
+	case <-wp.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// evictIdleSessions periodically closes agent sessions that have sat idle
+// past the configured timeout
+func (wp *WorkerPool) evictIdleSessions() {
+	defer wp.wg.Done()
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wp.stopCh:
+			return
+		case <-ticker.C:
+			wp.sessions.evictIdle(wp.cfgGetter.Config().AgentSessions.IdleTimeout())
+		}
+	}
+}
+
 // ActiveWorkers returns the number of currently active workers
 func (wp *WorkerPool) ActiveWorkers() int {
 	return int(wp.activeWorkers.Load())
 }
 
+// AgentHealth returns install status and the last job outcome for each agent
+func (wp *WorkerPool) AgentHealth() []storage.AgentHealth {
+	health := wp.agentHealth.snapshot()
+	alarms := wp.quality.alarms()
+	for i := range health {
+		health[i].QualityAlarm = alarms[health[i].Name]
+	}
+	return health
+}
+
 // MaxWorkers returns the total number of workers in the pool
 func (wp *WorkerPool) MaxWorkers() int {
 	return wp.numWorkers
@@ -237,7 +326,7 @@ func (wp *WorkerPool) worker(id int) {
 		}
 
 		// Try to claim a job
-		job, err := wp.db.ClaimJob(workerID)
+		job, err := wp.db.ClaimJobWithLimits(workerID, wp.cfgGetter.Config().AgentConcurrency)
 		if err != nil {
 			log.Printf("[%s] Error claiming job: %v", workerID, err)
 			if wp.errorLog != nil {
@@ -260,6 +349,17 @@ func (wp *WorkerPool) worker(id int) {
 	}
 }
 
+// contextCount returns how many earlier commits' reviews a job's prompt
+// includes: the count it was enqueued with, else the repo's
+// context_commits, else the global review_context_count
+func (wp *WorkerPool) contextCount(job *storage.ReviewJob, cfg *config.Config) int {
+	requested, err := wp.db.GetJobContextCount(job.ID)
+	if err != nil {
+		log.Printf("Error loading context count for job %d: %v", job.ID, err)
+	}
+	return config.ResolveContextCommits(requested, job.RepoPath, cfg)
+}
+
 // maxRetries is the number of retry attempts allowed after initial failure.
 // With maxRetries=3, a job can run up to 4 times total (1 initial + 3 retries).
 const maxRetries = 3
@@ -271,9 +371,13 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 	// This prevents mixed settings if config reloads mid-job.
 	cfg := wp.cfgGetter.Config()
 
-	// Get timeout from config (per-repo or global, default 30 minutes)
-	timeoutMinutes := config.ResolveJobTimeout(job.RepoPath, cfg)
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMinutes)*time.Minute)
+	// Get timeout from config (per-repo or global, default 30 minutes).
+	// Quick reviews get their own much shorter one.
+	timeout := time.Duration(config.ResolveJobTimeout(job.RepoPath, cfg)) * time.Minute
+	if job.ReviewType == "quick" {
+		timeout = config.ResolveQuickReviewTimeout(cfg)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Register for cancellation tracking
@@ -281,6 +385,17 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 	defer wp.unregisterRunningJob(job.ID)
 
 	// Build the prompt (or use pre-stored prompt for task jobs)
+	builder := prompt.NewBuilderWithConfig(wp.db, cfg).WithModel(job.Model).WithContextCache(wp.contextCache).WithContext(ctx)
+	if feedback, err := wp.db.GetJobPRFeedback(job.ID); err != nil {
+		log.Printf("[%s] Error loading PR feedback: %v", workerID, err)
+	} else if feedback != "" {
+		builder = builder.WithPriorFeedback(feedback)
+	}
+	if perCommit, err := wp.db.GetJobPerCommit(job.ID); err != nil {
+		log.Printf("[%s] Error loading per-commit option: %v", workerID, err)
+	} else if perCommit {
+		builder = builder.WithPerCommit()
+	}
 	var reviewPrompt string
 	var err error
 	if job.IsTaskJob() && job.Prompt != "" {
@@ -291,6 +406,8 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 		} else {
 			reviewPrompt = job.Prompt
 		}
+		// Task prompts can carry whole files, e.g. from analyze
+		reviewPrompt = prompt.ScrubSecrets(job.RepoPath, reviewPrompt)
 	} else if job.IsTaskJob() {
 		// Task job with missing prompt - likely a daemon version mismatch where
 		// the prompt wasn't stored or loaded. Fail with a clear error instead of
@@ -298,14 +415,14 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 		err = fmt.Errorf("task job %d has no stored prompt (git_ref=%q); restart the daemon with 'roborev daemon restart'", job.ID, job.GitRef)
 	} else if job.DiffContent != nil {
 		// Dirty job - use pre-captured diff
-		reviewPrompt, err = wp.promptBuilder.BuildDirty(job.RepoPath, *job.DiffContent, job.RepoID, cfg.ReviewContextCount, job.Agent, job.ReviewType)
+		reviewPrompt, err = builder.BuildDirty(job.RepoPath, *job.DiffContent, job.RepoID, wp.contextCount(job, cfg), job.Agent, job.ReviewType)
 	} else {
 		// Normal job - build prompt from git ref
-		reviewPrompt, err = wp.promptBuilder.Build(job.RepoPath, job.GitRef, job.RepoID, cfg.ReviewContextCount, job.Agent, job.ReviewType)
+		reviewPrompt, err = builder.Build(job.RepoPath, job.GitRef, job.RepoID, wp.contextCount(job, cfg), job.Agent, job.ReviewType)
 	}
 	if err != nil {
 		log.Printf("[%s] Error building prompt: %v", workerID, err)
-		wp.failOrRetry(workerID, job, job.Agent, fmt.Sprintf("build prompt: %v", err))
+		wp.failOrRetry(workerID, job, job.Agent, fmt.Sprintf("build prompt: %v", err), "")
 		return
 	}
 
@@ -314,11 +431,30 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 		log.Printf("[%s] Error saving prompt: %v", workerID, err)
 	}
 
+	// A budget may have been reached since the job was enqueued
+	agentForBudget, reason := budgetAgent(wp.db, cfg, job)
+	if reason != "" {
+		log.Printf("[%s] Job %d: %s", workerID, job.ID, reason)
+		wp.failJob(job, job.Agent, reason, "", fmt.Sprintf("job %d: %s", job.ID, reason))
+		return
+	}
+	if agentForBudget != job.Agent {
+		log.Printf("[%s] Job %d: daily budget reached, using %s instead of %s", workerID, job.ID, agentForBudget, job.Agent)
+		job.Agent = agentForBudget
+		// The job's model belongs to its original agent
+		job.Model = ""
+	}
+
 	// Get the agent (falls back to available agent if preferred not installed)
 	baseAgent, err := agent.GetAvailable(job.Agent)
 	if err != nil {
 		log.Printf("[%s] Error getting agent: %v", workerID, err)
-		wp.failOrRetry(workerID, job, job.Agent, fmt.Sprintf("get agent: %v", err))
+		wp.failOrRetry(workerID, job, job.Agent, fmt.Sprintf("get agent: %v", err), "")
+		return
+	}
+	// A sandbox that blocks the agent fails it the same way every time
+	if err := agent.CheckSandbox(baseAgent.Name()); err != nil {
+		wp.failJob(job, baseAgent.Name(), err.Error(), "", fmt.Sprintf("job %d: %v", job.ID, err))
 		return
 	}
 
@@ -330,6 +466,9 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 	}
 	reasoningLevel := agent.ParseReasoningLevel(reasoning)
 	a := baseAgent.WithReasoning(reasoningLevel).WithAgentic(job.Agentic).WithModel(job.Model)
+	a = wp.sessions.wrap(a, job.ID, cfg.AgentSessions)
+	defer wp.sessions.closeJob(job.ID)
+	a = wp.rateLimiter.limit(a, cfg.AgentRateLimits)
 
 	// Use the actual agent name (may differ from requested if fallback occurred)
 	agentName := a.Name()
@@ -337,8 +476,14 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 		log.Printf("[%s] Agent %s not available, using %s", workerID, job.Agent, agentName)
 	}
 
+	// Count what every request of the job costs, however the job ends
+	meter := &usageMeter{}
+	a = meter.wrap(a)
+	usage := &agent.Usage{}
+	defer wp.recordUsage(workerID, cfg, job, agentName, usage, meter)
+
 	// Broadcast started event
-	wp.broadcaster.Broadcast(Event{
+	wp.emit(Event{
 		Type:     "review.started",
 		TS:       time.Now(),
 		JobID:    job.ID,
@@ -351,20 +496,37 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 	// Create output writer for tail command
 	normalizer := GetNormalizer(agentName)
 	outputWriter := wp.outputBuffers.Writer(job.ID, normalizer)
+	recorder := newJobOutputRecorder(wp.db, job.ID)
+	outputWriter.onLine = recorder.Record
 	defer func() {
 		outputWriter.Flush()
 		wp.outputBuffers.CloseJob(job.ID)
 	}()
 
+	// Record how the agent runs so a failure can be diagnosed later
+	trace := &agent.ExecTrace{}
+	ctx = agent.WithExecTrace(ctx, trace)
+	ctx = agent.WithUsage(ctx, usage)
+
 	// Run the review
 	log.Printf("[%s] Running %s review...", workerID, agentName)
 	output, err := a.Review(ctx, job.RepoPath, job.GitRef, reviewPrompt, outputWriter)
+
+	// Persist the tail of the stream before the job is finalized, so a
+	// failed job keeps everything the agent printed
+	outputWriter.Flush()
+	recorder.Flush()
+
 	if err != nil {
 		// Check if this was a cancellation
+		if ctx.Err() == context.Canceled && wp.stopping() {
+			log.Printf("[%s] Job %d interrupted by shutdown", workerID, job.ID)
+			return // Left running in the DB so the next daemon requeues it
+		}
 		if ctx.Err() == context.Canceled {
 			log.Printf("[%s] Job %d was canceled", workerID, job.ID)
 			// Broadcast cancellation event
-			wp.broadcaster.Broadcast(Event{
+			wp.emit(Event{
 				Type:     "review.canceled",
 				TS:       time.Now(),
 				JobID:    job.ID,
@@ -375,22 +537,66 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 			})
 			return // Job already marked as canceled in DB, nothing more to do
 		}
-		log.Printf("[%s] Agent error: %v", workerID, err)
-		wp.failOrRetry(workerID, job, agentName, fmt.Sprintf("agent: %v", err))
+		class := classifyFailure(ctx, err)
+		log.Printf("[%s] Agent error (%s): %v", workerID, class, err)
+		errorMsg := fmt.Sprintf("agent: %v", err)
+		wp.agentHealth.record(agentName, class, describeFailure(errorMsg, class))
+		wp.saveJobLog(job.ID, trace, err)
+		wp.failOrRetry(workerID, job, agentName, errorMsg, class)
 		return
 	}
 
-	// Store the result (use actual agent name, not requested)
-	if err := wp.db.CompleteJob(job.ID, agentName, reviewPrompt, output); err != nil {
-		log.Printf("[%s] Error storing review: %v", workerID, err)
-		return
+	output = applyOutputFilters(ctx, job.RepoPath, output)
+
+	// An empty first answer counts against the agent's quality even when
+	// the corrective retry recovers a review
+	emptyOutput := !job.IsTaskJob() && isEmptyOutput(output)
+	if emptyOutput {
+		wp.recordReviewQuality(cfg, job, agentName, qualityEmpty)
 	}
 
-	log.Printf("[%s] Completed job %d", workerID, job.ID)
+	if !job.IsTaskJob() {
+		output, err = validateReviewOutput(ctx, a, job, reviewPrompt, output, outputWriter)
+		if err != nil {
+			log.Printf("[%s] Invalid review output for job %d: %v", workerID, job.ID, err)
+			class := classifyFailure(ctx, err)
+			wp.agentHealth.record(agentName, class, describeFailure(err.Error(), class))
+			if !emptyOutput {
+				wp.recordReviewQuality(cfg, job, agentName, qualityEmpty)
+			}
+			wp.saveJobLog(job.ID, trace, err)
+			wp.failOrRetry(workerID, job, agentName, err.Error(), class)
+			return
+		}
+	}
 
-	// Broadcast completion event
-	verdict := storage.ParseVerdict(output)
-	wp.broadcaster.Broadcast(Event{
+	// Pull the machine-readable findings out of the prose before storing
+	var findings []storage.Finding
+	hasFindings := false
+	if !job.IsTaskJob() {
+		output, findings, hasFindings = storage.ExtractFindings(output)
+		storage.MapSeverities(findings, config.ResolveSeverities(job.RepoPath))
+		storage.MapCategories(findings, config.ResolveCategories(job.RepoPath))
+		if !emptyOutput {
+			quality := qualityOK
+			if !hasFindings && strings.Contains(reviewPrompt, prompt.FindingsFormatHeader) {
+				quality = qualityParseFailure
+			}
+			wp.recordReviewQuality(cfg, job, agentName, quality)
+		}
+	}
+
+	// Keep oversized reviews out of the review row: store a condensed version
+	// there and the complete output as an attachment
+	storedOutput, fullOutput := output, ""
+	if maxSize := config.ResolveMaxReviewOutputSize(job.RepoPath, cfg); len(output) > maxSize {
+		log.Printf("[%s] Review output for job %d is %d bytes (limit %d), condensing", workerID, job.ID, len(output), maxSize)
+		storedOutput = condenseReviewOutput(ctx, a, job, output, maxSize)
+		fullOutput = output
+	}
+
+	verdict := storage.ParseVerdict(storedOutput)
+	event := Event{
 		Type:     "review.completed",
 		TS:       time.Now(),
 		JobID:    job.ID,
@@ -399,20 +605,126 @@ func (wp *WorkerPool) processJob(workerID string, job *storage.ReviewJob) {
 		SHA:      job.GitRef,
 		Agent:    agentName,
 		Verdict:  verdict,
-		Findings: output,
-	})
+		Findings: storedOutput,
+	}
+
+	// Store the result (use actual agent name, not requested), queueing the
+	// completion's webhooks with it
+	if err := wp.db.CompleteJobWithFullOutput(job.ID, agentName, reviewPrompt, storedOutput, fullOutput, wp.webhooks.Deliveries(event)...); err != nil {
+		log.Printf("[%s] Error storing review: %v", workerID, err)
+		return
+	}
+	wp.webhooks.Wake()
+
+	if delta := builder.SBOMDelta(); delta != nil {
+		if data, err := json.Marshal(delta); err == nil {
+			if err := wp.db.SaveReviewAttachment(job.ID, storage.AttachmentSBOMDelta, string(data)); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("[%s] Error storing SBOM delta for job %d: %v", workerID, job.ID, err)
+			}
+		}
+	}
+
+	// Probe the unwrapped agent, since wrappers hide its command
+	if version := wp.agentHealth.version(ctx, baseAgent); version != "" {
+		if err := wp.db.SetReviewAgentVersion(job.ID, version); err != nil {
+			log.Printf("[%s] Error recording agent version for job %d: %v", workerID, job.ID, err)
+		}
+	}
+
+	if !job.IsTaskJob() {
+		if reasons := prompt.DetectInjection(reviewPrompt, output); len(reasons) > 0 {
+			warning := strings.Join(reasons, "; ")
+			log.Printf("[%s] Job %d: possible prompt injection: %s", workerID, job.ID, warning)
+			if err := wp.db.SetReviewInjectionWarning(job.ID, warning); err != nil {
+				log.Printf("[%s] Error recording injection warning for job %d: %v", workerID, job.ID, err)
+			}
+			if wp.errorLog != nil {
+				wp.errorLog.LogError("worker", "possible prompt injection: "+warning, job.ID)
+			}
+		}
+	}
+
+	if cfg.ReviewSigningKey != "" {
+		key, err := integrity.LoadKey(cfg.ReviewSigningKey)
+		if err == nil {
+			err = integrity.SignReview(wp.db, key, job.ID)
+		}
+		// No review is stored when the job was canceled while it ran
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("[%s] Error signing review for job %d: %v", workerID, job.ID, err)
+			if wp.errorLog != nil {
+				wp.errorLog.LogError("worker", "sign review: "+err.Error(), job.ID)
+			}
+		}
+	}
+
+	if hasFindings {
+		if err := wp.db.SaveFindings(job.ID, findings); err != nil {
+			log.Printf("[%s] Error storing findings for job %d: %v", workerID, job.ID, err)
+		} else {
+			wp.markFixedFindings(workerID, job)
+		}
+	} else if !job.IsTaskJob() {
+		// Without the block, keep what the prose lists for filtering and
+		// export; only structured findings drive pipelines and auto-address
+		parsed := storage.ParseMarkdownFindings(output)
+		storage.MapSeverities(parsed, config.ResolveSeverities(job.RepoPath))
+		storage.MapCategories(parsed, config.ResolveCategories(job.RepoPath))
+		if len(parsed) > 0 {
+			if err := wp.db.SaveFindings(job.ID, parsed); err != nil {
+				log.Printf("[%s] Error storing findings for job %d: %v", workerID, job.ID, err)
+			}
+		}
+	}
+
+	log.Printf("[%s] Completed job %d", workerID, job.ID)
+	wp.agentHealth.record(agentName, "", "")
+
+	// Broadcast completion event
+	wp.broadcaster.Broadcast(event)
+
+	wp.advancePipelines(cfg, job, &pipelineResult{Verdict: verdict, Findings: findings, HasBlock: hasFindings, Event: event})
+	if hasFindings {
+		wp.startAutoAddress(cfg, job, agentName, findings)
+	}
 }
 
-// failOrRetry attempts to retry the job, or marks it as failed if max retries reached
-func (wp *WorkerPool) failOrRetry(workerID string, job *storage.ReviewJob, agentName string, errorMsg string) {
+// condenseReviewOutput asks the agent to summarize an oversized review so it
+// fits in maxSize bytes. If summarization fails or the summary is still too
+// large, the output is truncated instead. A trailing note points at the full
+// output, which the caller stores separately.
+func condenseReviewOutput(ctx context.Context, a agent.Agent, job *storage.ReviewJob, output string, maxSize int) string {
+	note := fmt.Sprintf("\n\n[Review condensed from %d bytes. Run 'roborev show --job --full %d' for the complete output.]", len(output), job.ID)
+	budget := maxSize - len(note)
+	if budget <= 0 {
+		budget = maxSize
+	}
+
+	summary, err := a.WithAgentic(false).Review(ctx, job.RepoPath, job.GitRef, prompt.BuildSummarizePrompt(output, budget), nil)
+	summary = strings.TrimSpace(summary)
+	if err != nil || summary == "" || len(summary) > budget {
+		if err != nil {
+			log.Printf("Job %d: summarizing review output failed, truncating: %v", job.ID, err)
+		}
+		summary = prompt.TruncateUTF8(output, budget)
+	}
+	return summary + note
+}
+
+// failOrRetry attempts to retry the job, or marks it as failed if max retries
+// reached or the failure class won't succeed on a retry
+func (wp *WorkerPool) failOrRetry(workerID string, job *storage.ReviewJob, agentName string, errorMsg string, class storage.ErrorClass) {
+	errorMsg = describeFailure(errorMsg, class)
+	if !errorClassRetryable(class) {
+		log.Printf("[%s] Job %d failed with %s error, not retrying", workerID, job.ID, class)
+		wp.failJob(job, agentName, errorMsg, class, fmt.Sprintf("job %d failed (%s): %s", job.ID, class, errorMsg))
+		return
+	}
+
 	retried, err := wp.db.RetryJob(job.ID, maxRetries)
 	if err != nil {
 		log.Printf("[%s] Error retrying job: %v", workerID, err)
-		wp.db.FailJob(job.ID, errorMsg)
-		wp.broadcastFailed(job, agentName, errorMsg)
-		if wp.errorLog != nil {
-			wp.errorLog.LogError("worker", fmt.Sprintf("job %d failed: %s", job.ID, errorMsg), job.ID)
-		}
+		wp.failJob(job, agentName, errorMsg, class, fmt.Sprintf("job %d failed: %s", job.ID, errorMsg))
 		return
 	}
 
@@ -421,17 +733,61 @@ func (wp *WorkerPool) failOrRetry(workerID string, job *storage.ReviewJob, agent
 		log.Printf("[%s] Job %d queued for retry (%d/%d)", workerID, job.ID, retryCount, maxRetries)
 	} else {
 		log.Printf("[%s] Job %d failed after %d retries", workerID, job.ID, maxRetries)
-		wp.db.FailJob(job.ID, errorMsg)
-		wp.broadcastFailed(job, agentName, errorMsg)
-		if wp.errorLog != nil {
-			wp.errorLog.LogError("worker", fmt.Sprintf("job %d failed after %d retries: %s", job.ID, maxRetries, errorMsg), job.ID)
-		}
+		wp.failJob(job, agentName, errorMsg, class, fmt.Sprintf("job %d failed after %d retries: %s", job.ID, maxRetries, errorMsg))
+	}
+}
+
+// recordUsage adds the tokens and cost of a job's agent requests to the
+// job, whether it completed, failed, or was canceled
+func (wp *WorkerPool) recordUsage(workerID string, cfg *config.Config, job *storage.ReviewJob, agentName string, usage *agent.Usage, meter *usageMeter) {
+	texts := meter.all()
+	if len(texts) == 0 {
+		return
+	}
+	tokens, cost := jobUsage(cfg, agentName, usage, texts...)
+	if err := wp.db.RecordJobUsage(job.ID, tokens, cost); err != nil {
+		log.Printf("[%s] Error recording usage for job %d: %v", workerID, job.ID, err)
+	}
+	if report, ok := usage.Report(); ok {
+		log.Printf("[%s] Job %d used %d input and %d output tokens, stop reason %q",
+			workerID, job.ID, report.InputTokens, report.OutputTokens, report.StopReason)
 	}
 }
 
-// broadcastFailed sends a review.failed event for a job
-func (wp *WorkerPool) broadcastFailed(job *storage.ReviewJob, agentName, errorMsg string) {
-	wp.broadcaster.Broadcast(Event{
+// saveJobLog stores the command line, exit code, and stderr of a failed
+// agent run
+func (wp *WorkerPool) saveJobLog(jobID int64, trace *agent.ExecTrace, err error) {
+	entry := storage.JobLog{
+		JobID:       jobID,
+		CommandLine: trace.CommandLine(),
+		Stderr:      trace.Stderr(),
+		Error:       err.Error(),
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		code := exitErr.ExitCode()
+		entry.ExitCode = &code
+	}
+	if err := wp.db.SaveJobLog(entry); err != nil {
+		log.Printf("Error saving log for job %d: %v", jobID, err)
+	}
+}
+
+// failJob records a failed job, broadcasts the failure, and logs logMsg to
+// the error log
+func (wp *WorkerPool) failJob(job *storage.ReviewJob, agentName, errorMsg string, class storage.ErrorClass, logMsg string) {
+	event := failedEvent(job, agentName, errorMsg)
+	wp.db.FailJobWithClass(job.ID, errorMsg, class, wp.webhooks.Deliveries(event)...)
+	wp.webhooks.Wake()
+	wp.broadcaster.Broadcast(event)
+	if wp.errorLog != nil {
+		wp.errorLog.LogError("worker", logMsg, job.ID)
+	}
+}
+
+// failedEvent returns the review.failed event for a job
+func failedEvent(job *storage.ReviewJob, agentName, errorMsg string) Event {
+	return Event{
 		Type:     "review.failed",
 		TS:       time.Now(),
 		JobID:    job.ID,
@@ -440,5 +796,12 @@ func (wp *WorkerPool) broadcastFailed(job *storage.ReviewJob, agentName, errorMs
 		SHA:      job.GitRef,
 		Agent:    agentName,
 		Error:    errorMsg,
-	})
+	}
+}
+
+// emit broadcasts an event that isn't tied to a job status change and
+// queues its webhooks
+func (wp *WorkerPool) emit(event Event) {
+	wp.broadcaster.Broadcast(event)
+	wp.webhooks.Queue(event)
 }
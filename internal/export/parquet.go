@@ -0,0 +1,277 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/roborev-dev/roborev/internal/version"
+)
+
+// This is a minimal Parquet writer: one row group, one uncompressed
+// PLAIN-encoded data page per column, and every column optional so
+// missing values can be left out. That's enough for the tables exported
+// here and is readable by pandas, DuckDB, Spark, and friends.
+// Format reference: https://parquet.apache.org/docs/file-format/
+
+const parquetMagic = "PAR1"
+
+// Parquet enum values from parquet.thrift
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional  = 1 // FieldRepetitionType
+	parquetUTF8      = 0 // ConvertedType
+	parquetPlain     = 0 // Encoding
+	parquetRLE       = 3
+	parquetDataPage  = 0 // PageType
+	parquetCodecNone = 0
+)
+
+// Thrift compact protocol field types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// physicalType returns the Parquet type a column is stored as
+func physicalType(t ColumnType) int32 {
+	switch t {
+	case Int64:
+		return parquetInt64
+	case Float64:
+		return parquetDouble
+	default:
+		return parquetByteArray
+	}
+}
+
+// WriteParquet writes the table as a Parquet file
+func WriteParquet(w io.Writer, t *Table) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(t.Columns))
+	for i := range t.Columns {
+		page, err := columnPage(t, i)
+		if err != nil {
+			return err
+		}
+		var header thriftWriter
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structBegin(5) // DataPageHeader
+		header.i32(1, int32(len(t.Rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.stop()
+
+		chunks[i].offset = int64(file.Len())
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+		chunks[i].size = int64(file.Len()) - chunks[i].offset
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1) // version
+	meta.listBegin(2, thriftStruct, len(t.Columns)+1)
+	meta.elemBegin() // root
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(t.Columns)))
+	meta.end()
+	for _, col := range t.Columns {
+		meta.elemBegin()
+		meta.i32(1, physicalType(col.Type))
+		meta.i32(3, parquetOptional)
+		meta.binary(4, col.Name)
+		if col.Type == String {
+			meta.i32(6, parquetUTF8)
+		}
+		meta.end()
+	}
+	meta.i64(3, int64(len(t.Rows)))
+	meta.listBegin(4, thriftStruct, 1)
+	meta.elemBegin() // RowGroup
+	meta.listBegin(1, thriftStruct, len(t.Columns))
+	var total int64
+	for i, col := range t.Columns {
+		meta.elemBegin() // ColumnChunk
+		meta.i64(2, chunks[i].offset)
+		meta.structBegin(3) // ColumnMetaData
+		meta.i32(1, physicalType(col.Type))
+		meta.listBegin(2, thriftI32, 2)
+		meta.varint(zigzag(parquetPlain))
+		meta.varint(zigzag(parquetRLE))
+		meta.listBegin(3, thriftBinary, 1)
+		meta.varint(uint64(len(col.Name)))
+		meta.buf.WriteString(col.Name)
+		meta.i32(4, parquetCodecNone)
+		meta.i64(5, int64(len(t.Rows)))
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.end()
+		meta.end()
+		total += chunks[i].size
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(len(t.Rows)))
+	meta.end()
+	meta.binary(6, "roborev version "+version.Version)
+	meta.stop()
+
+	file.Write(meta.buf.Bytes())
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(meta.buf.Len())))
+	file.WriteString(parquetMagic)
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// columnPage encodes a column's definition levels and its non-missing
+// values
+func columnPage(t *Table, col int) ([]byte, error) {
+	var levels, values bytes.Buffer
+	var run uint64
+	var runDefined bool
+	flush := func() {
+		if run > 0 {
+			// RLE run: header, then the level in one byte (bit width 1)
+			levels.Write(binary.AppendUvarint(nil, run<<1))
+			if runDefined {
+				levels.WriteByte(1)
+			} else {
+				levels.WriteByte(0)
+			}
+		}
+	}
+
+	column := t.Columns[col]
+	for _, row := range t.Rows {
+		v := row[col]
+		defined := v != nil
+		if run > 0 && defined != runDefined {
+			flush()
+			run = 0
+		}
+		runDefined = defined
+		run++
+		if !defined {
+			continue
+		}
+
+		switch v := v.(type) {
+		case string:
+			if column.Type != String {
+				return nil, fmt.Errorf("column %s: unexpected string value", column.Name)
+			}
+			values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			values.WriteString(v)
+		case int64:
+			if column.Type != Int64 {
+				return nil, fmt.Errorf("column %s: unexpected int64 value", column.Name)
+			}
+			values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+		case float64:
+			if column.Type != Float64 {
+				return nil, fmt.Errorf("column %s: unexpected float64 value", column.Name)
+			}
+			values.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+		default:
+			return nil, fmt.Errorf("column %s: unsupported value %T", column.Name, v)
+		}
+	}
+	flush()
+
+	page := binary.LittleEndian.AppendUint32(nil, uint32(levels.Len()))
+	page = append(page, levels.Bytes()...)
+	return append(page, values.Bytes()...), nil
+}
+
+// thriftWriter encodes structs in the Thrift compact protocol, which
+// Parquet uses for its page headers and footer
+type thriftWriter struct {
+	buf    bytes.Buffer
+	lastID int16
+	stack  []int16
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(zigzag(int64(id)))
+	}
+	w.lastID = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(zigzag(int64(v)))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(zigzag(v))
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// listBegin writes a list field header; the caller writes n elements
+func (w *thriftWriter) listBegin(id int16, elemType byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.varint(uint64(n))
+	}
+}
+
+// structBegin starts a struct field and elemBegin a struct list element;
+// end closes either
+func (w *thriftWriter) structBegin(id int16) {
+	w.field(id, thriftStruct)
+	w.elemBegin()
+}
+
+func (w *thriftWriter) elemBegin() {
+	w.stack = append(w.stack, w.lastID)
+	w.lastID = 0
+}
+
+func (w *thriftWriter) end() {
+	w.stop()
+	w.lastID = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+// stop ends the current struct
+func (w *thriftWriter) stop() {
+	w.buf.WriteByte(0)
+}
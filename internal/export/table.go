@@ -0,0 +1,167 @@
+// Package export writes review data as tables for analysis outside
+// roborev, in CSV or Parquet.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// ColumnType is the type of a column's values
+type ColumnType int
+
+const (
+	String  ColumnType = iota // string
+	Int64                     // int64
+	Float64                   // float64
+)
+
+// Column describes a table column
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Table is a set of rows. Each value has its column's Go type, or is nil
+// for a missing value.
+type Table struct {
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+// Formats lists the supported output formats
+var Formats = []string{"csv", "parquet"}
+
+// Write writes the table in the given format
+func Write(w io.Writer, format string, t *Table) error {
+	switch format {
+	case "csv":
+		return WriteCSV(w, t)
+	case "parquet":
+		return WriteParquet(w, t)
+	default:
+		return fmt.Errorf("unknown export format %q (use csv or parquet)", format)
+	}
+}
+
+// WriteCSV writes the table as CSV with a header row. Missing values are
+// empty.
+func WriteCSV(w io.Writer, t *Table) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, v := range row {
+			switch v := v.(type) {
+			case nil:
+				record[i] = ""
+			case string:
+				record[i] = v
+			case int64:
+				record[i] = strconv.FormatInt(v, 10)
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				return fmt.Errorf("column %s: unsupported value %T", t.Columns[i].Name, v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// timeValue formats a timestamp column value
+func timeValue(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// FindingsTable lays out findings one per row
+func FindingsTable(findings []storage.FindingExport) *Table {
+	t := &Table{Columns: []Column{
+		{"job_id", Int64},
+		{"repo", String},
+		{"repo_path", String},
+		{"git_ref", String},
+		{"branch", String},
+		{"agent", String},
+		{"review_type", String},
+		{"severity", String},
+		{"category", String},
+		{"file", String},
+		{"line", Int64},
+		{"title", String},
+		{"message", String},
+		{"status", String},
+		{"created_at", String},
+	}}
+	for _, f := range findings {
+		t.Rows = append(t.Rows, []interface{}{
+			f.JobID, f.RepoName, f.RepoPath, f.GitRef, f.Branch, f.Agent, f.ReviewType,
+			f.Severity, f.Category, f.File, int64(f.Line), f.Title, f.Message, f.Status, timeValue(&f.CreatedAt),
+		})
+	}
+	return t
+}
+
+// JobsTable lays out job metrics one job per row. Durations are in
+// seconds and missing until the job has started or finished; signature is
+// empty unless signatures were verified.
+func JobsTable(jobs []storage.JobMetrics) *Table {
+	t := &Table{Columns: []Column{
+		{"job_id", Int64},
+		{"repo", String},
+		{"repo_path", String},
+		{"git_ref", String},
+		{"branch", String},
+		{"agent", String},
+		{"model", String},
+		{"job_type", String},
+		{"review_type", String},
+		{"status", String},
+		{"error_class", String},
+		{"enqueued_at", String},
+		{"started_at", String},
+		{"finished_at", String},
+		{"queue_seconds", Float64},
+		{"run_seconds", Float64},
+		{"retry_count", Int64},
+		{"tokens", Int64},
+		{"cost_usd", Float64},
+		{"verdict", String},
+		{"findings", Int64},
+		{"signature", String},
+	}}
+	for _, j := range jobs {
+		var queued, run interface{}
+		if j.StartedAt != nil {
+			queued = j.StartedAt.Sub(j.EnqueuedAt).Seconds()
+			if j.FinishedAt != nil {
+				run = j.FinishedAt.Sub(*j.StartedAt).Seconds()
+			}
+		}
+		t.Rows = append(t.Rows, []interface{}{
+			j.JobID, j.RepoName, j.RepoPath, j.GitRef, j.Branch, j.Agent, j.Model, j.JobType,
+			j.ReviewType, string(j.Status), string(j.ErrorClass), timeValue(&j.EnqueuedAt),
+			timeValue(j.StartedAt), timeValue(j.FinishedAt), queued, run, int64(j.RetryCount),
+			int64(j.Tokens), j.CostUSD, j.Verdict, int64(j.Findings), j.Signature,
+		})
+	}
+	return t
+}
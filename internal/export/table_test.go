@@ -0,0 +1,284 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func testTable() *Table {
+	return &Table{
+		Columns: []Column{{"id", Int64}, {"file", String}, {"cost", Float64}},
+		Rows: [][]interface{}{
+			{int64(1), "main.go", 0.25},
+			{int64(2), nil, nil},
+			{int64(3), "a,\"b\".go", 1.5},
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testTable()); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	want := "id,file,cost\n1,main.go,0.25\n2,,\n3,\"a,\"\"b\"\".go\",1.5\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, testTable()); err != nil {
+		t.Fatalf("WriteParquet failed: %v", err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("missing Parquet magic: %q", data)
+	}
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footer <= 0 || footer > len(data)-12 {
+		t.Fatalf("bad footer length %d for %d byte file", footer, len(data))
+	}
+	// Column names are in the footer; string values are stored as
+	// length-prefixed bytes in the pages
+	meta := data[len(data)-8-footer : len(data)-8]
+	for _, name := range []string{"id", "file", "cost"} {
+		if !bytes.Contains(meta, []byte(name)) {
+			t.Errorf("footer missing column %q", name)
+		}
+	}
+	value := append(binary.LittleEndian.AppendUint32(nil, 7), "main.go"...)
+	if !bytes.Contains(data[:len(data)-8-footer], value) {
+		t.Error("expected PLAIN-encoded string value in the data pages")
+	}
+
+	bad := &Table{Columns: []Column{{"id", Int64}}, Rows: [][]interface{}{{"one"}}}
+	if err := WriteParquet(&buf, bad); err == nil {
+		t.Error("expected error for a value of the wrong type")
+	}
+}
+
+func TestWriteParquetRoundTrip(t *testing.T) {
+	table := testTable()
+	table.Rows = append(table.Rows, []interface{}{nil, "", -0.5}, []interface{}{nil, nil, nil})
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, table); err != nil {
+		t.Fatalf("WriteParquet failed: %v", err)
+	}
+	data := buf.Bytes()
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := (&thriftReader{t: t, data: data[len(data)-8-footer : len(data)-8]}).readStruct()
+
+	if meta[3] != int64(len(table.Rows)) {
+		t.Errorf("num_rows = %v, want %d", meta[3], len(table.Rows))
+	}
+	schema := meta[2].([]interface{})
+	if len(schema) != len(table.Columns)+1 {
+		t.Fatalf("expected root and %d column schema elements, got %d", len(table.Columns), len(schema))
+	}
+	if root := schema[0].(map[int16]interface{}); root[5] != int64(len(table.Columns)) {
+		t.Errorf("root num_children = %v", root[5])
+	}
+	for i, col := range table.Columns {
+		el := schema[i+1].(map[int16]interface{})
+		if string(el[4].([]byte)) != col.Name || el[1] != int64(physicalType(col.Type)) || el[3] != int64(parquetOptional) {
+			t.Errorf("schema element %d = %v, want optional %s", i+1, el, col.Name)
+		}
+	}
+
+	groups := meta[4].([]interface{})
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 row group, got %d", len(groups))
+	}
+	chunks := groups[0].(map[int16]interface{})[1].([]interface{})
+	if len(chunks) != len(table.Columns) {
+		t.Fatalf("expected %d column chunks, got %d", len(table.Columns), len(chunks))
+	}
+	got := make([][]interface{}, len(table.Rows))
+	for i := range got {
+		got[i] = make([]interface{}, len(table.Columns))
+	}
+	for i, c := range chunks {
+		colMeta := c.(map[int16]interface{})[3].(map[int16]interface{})
+		offset := int(colMeta[9].(int64))
+		r := &thriftReader{t: t, data: data[offset:]}
+		header := r.readStruct()
+		if header[1] != int64(parquetDataPage) {
+			t.Fatalf("column %d: page type = %v", i, header[1])
+		}
+		page := r.data[r.pos : r.pos+int(header[3].(int64))]
+		if n := header[5].(map[int16]interface{})[1]; n != int64(len(table.Rows)) {
+			t.Fatalf("column %d: num_values = %v", i, n)
+		}
+		for row, v := range readParquetColumn(t, page, table.Columns[i].Type, len(table.Rows)) {
+			got[row][i] = v
+		}
+	}
+	if !reflect.DeepEqual(got, table.Rows) {
+		t.Errorf("decoded rows = %v, want %v", got, table.Rows)
+	}
+}
+
+// readParquetColumn decodes a data page written by columnPage, with nil
+// for rows whose definition level is 0
+func readParquetColumn(t *testing.T, page []byte, typ ColumnType, rows int) []interface{} {
+	t.Helper()
+	n := int(binary.LittleEndian.Uint32(page))
+	levels, values := page[4:4+n], page[4+n:]
+
+	// RLE/bit-packed hybrid runs with a bit width of 1
+	var defined []bool
+	for len(levels) > 0 && len(defined) < rows {
+		header, size := binary.Uvarint(levels)
+		levels = levels[size:]
+		if header&1 == 0 {
+			for range header >> 1 {
+				defined = append(defined, levels[0] == 1)
+			}
+			levels = levels[1:]
+			continue
+		}
+		for _, b := range levels[:header>>1] {
+			for bit := range 8 {
+				defined = append(defined, b>>bit&1 == 1)
+			}
+		}
+		levels = levels[header>>1:]
+	}
+	if len(defined) < rows {
+		t.Fatalf("got %d definition levels for %d rows", len(defined), rows)
+	}
+
+	out := make([]interface{}, rows)
+	for i := range out {
+		if !defined[i] {
+			continue
+		}
+		switch typ {
+		case Int64:
+			out[i] = int64(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+		case Float64:
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+		default:
+			n := int(binary.LittleEndian.Uint32(values))
+			out[i] = string(values[4 : 4+n])
+			values = values[4+n:]
+		}
+	}
+	if len(values) != 0 {
+		t.Errorf("%d bytes left over after the page's values", len(values))
+	}
+	return out
+}
+
+// thriftReader decodes Thrift compact protocol structs into maps from
+// field id to value: int64 for integers, []byte for binary, []interface{}
+// for lists, and nested maps for structs
+type thriftReader struct {
+	t    *testing.T
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.t.Fatalf("thrift: read past the end of %d bytes", len(r.data))
+	}
+	r.pos++
+	return r.data[r.pos-1]
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.t.Fatalf("thrift: bad varint at %d", r.pos)
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var id int16
+	for {
+		b := r.byte()
+		if b == 0 {
+			return fields
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.readValue(b & 0x0f)
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.uvarint())
+		if r.pos+n > len(r.data) {
+			r.t.Fatalf("thrift: binary of %d bytes runs past the end", n)
+		}
+		r.pos += n
+		return r.data[r.pos-n : r.pos]
+	case thriftList:
+		b := r.byte()
+		n := int(b >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.readValue(b & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.t.Fatalf("thrift: unexpected field type %d", typ)
+	return nil
+}
+
+func TestJobsTableDurations(t *testing.T) {
+	enqueued := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	started := enqueued.Add(30 * time.Second)
+	finished := started.Add(90 * time.Second)
+	table := JobsTable([]storage.JobMetrics{
+		{JobID: 1, Status: storage.JobStatusDone, EnqueuedAt: enqueued, StartedAt: &started, FinishedAt: &finished},
+		{JobID: 2, Status: storage.JobStatusQueued, EnqueuedAt: enqueued},
+	})
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, table); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], ",2026-01-02T03:04:30Z,2026-01-02T03:06:00Z,30,90,") {
+		t.Errorf("unexpected finished job row: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], ",queued,,2026-01-02T03:04:00Z,,,,,") {
+		t.Errorf("unexpected queued job row: %s", lines[2])
+	}
+}
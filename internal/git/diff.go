@@ -0,0 +1,244 @@
+package git
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeaderPattern matches a hunk header, capturing the old and new start
+// lines and line counts: @@ -a,b +c,d @@
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// DiffFile is one file's section of a unified diff
+type DiffFile struct {
+	// OldPath and NewPath are the file's path before and after the change.
+	// OldPath is empty for an added file and NewPath for a deleted one.
+	OldPath, NewPath string
+
+	// Header holds the lines before the first hunk, starting with the
+	// "diff --git" line when there is one
+	Header []string
+
+	Hunks []DiffHunk
+
+	// Text is the file's whole section as it appears in the diff
+	Text string
+}
+
+// Path returns the file's new path, or its old path when it was deleted
+func (f DiffFile) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// DiffHunk is one hunk of a file's diff
+type DiffHunk struct {
+	// Header is the "@@ -a,b +c,d @@" line
+	Header string
+
+	OldStart, OldCount int
+	NewStart, NewCount int
+
+	Lines []DiffLine
+
+	// Text is the hunk as it appears in the diff, header included
+	Text string
+}
+
+// DiffLine is a line of a hunk
+type DiffLine struct {
+	// Kind is ' ' for context, '+' for an added line, '-' for a removed
+	// one, and '\\' for a "\ No newline at end of file" marker
+	Kind byte
+
+	// Text is the line without its kind prefix
+	Text string
+
+	// OldLine and NewLine are the line's numbers in the old and new file,
+	// or 0 on a side the line isn't on
+	OldLine, NewLine int
+}
+
+// ParseDiff splits a unified diff into its files and their hunks. Text
+// before the first file is skipped. Hunks are read by their line counts,
+// so a removed line that starts with "--" isn't taken for a file header.
+func ParseDiff(diff string) []DiffFile {
+	lines := strings.SplitAfter(diff, "\n")
+	var files []DiffFile
+	var cur *DiffFile
+	var hunk *DiffHunk
+	fileStart, hunkStart := 0, 0
+	oldLine, newLine, oldLeft, newLeft := 0, 0, 0, 0
+
+	endHunk := func(end int) {
+		if hunk != nil {
+			hunk.Text = diff[hunkStart:end]
+			cur.Hunks = append(cur.Hunks, *hunk)
+			hunk = nil
+		}
+	}
+	endFile := func(end int) {
+		endHunk(end)
+		if cur != nil {
+			cur.Text = diff[fileStart:end]
+			files = append(files, *cur)
+			cur = nil
+		}
+	}
+	startFile := func(offset int) {
+		endFile(offset)
+		cur = &DiffFile{}
+		fileStart = offset
+	}
+
+	offset := 0
+	for i, raw := range lines {
+		lineStart := offset
+		offset += len(raw)
+		if raw == "" {
+			continue
+		}
+		line := strings.TrimSuffix(raw, "\n")
+
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			kind := byte(' ')
+			if line != "" {
+				kind = line[0]
+			}
+			text := ""
+			if line != "" {
+				text = line[1:]
+			}
+			switch kind {
+			case ' ':
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: kind, Text: text, OldLine: oldLine, NewLine: newLine})
+				oldLine, newLine = oldLine+1, newLine+1
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+				continue
+			case '-':
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: kind, Text: text, OldLine: oldLine})
+				oldLine, oldLeft = oldLine+1, oldLeft-1
+				continue
+			case '+':
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: kind, Text: text, NewLine: newLine})
+				newLine, newLeft = newLine+1, newLeft-1
+				continue
+			case '\\':
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: kind, Text: text})
+				continue
+			}
+			// A line that fits no hunk ends it early
+			oldLeft, newLeft = 0, 0
+		} else if hunk != nil && strings.HasPrefix(line, `\`) {
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: '\\', Text: line[1:]})
+			continue
+		}
+		endHunk(lineStart)
+
+		header := strings.TrimSuffix(line, "\r")
+		switch {
+		case strings.HasPrefix(header, "diff --git "):
+			startFile(lineStart)
+			cur.OldPath, cur.NewPath = gitHeaderPaths(strings.TrimPrefix(header, "diff --git "))
+			cur.Header = append(cur.Header, line)
+		case strings.HasPrefix(header, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") &&
+			(cur == nil || len(cur.Hunks) > 0):
+			// A file without a "diff --git" line, as other tools write
+			startFile(lineStart)
+			cur.OldPath = headerPath(strings.TrimPrefix(header, "--- "), "a/")
+			cur.Header = append(cur.Header, line)
+		case cur == nil:
+		case strings.HasPrefix(header, "@@ "):
+			m := hunkHeaderPattern.FindStringSubmatch(header)
+			if m == nil {
+				continue
+			}
+			hunk = &DiffHunk{Header: line}
+			hunk.OldStart, _ = strconv.Atoi(m[1])
+			hunk.OldCount = hunkCount(m[2])
+			hunk.NewStart, _ = strconv.Atoi(m[3])
+			hunk.NewCount = hunkCount(m[4])
+			hunkStart = lineStart
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			oldLeft, newLeft = hunk.OldCount, hunk.NewCount
+		case len(cur.Hunks) == 0:
+			cur.Header = append(cur.Header, line)
+			switch {
+			case strings.HasPrefix(header, "--- "):
+				cur.OldPath = headerPath(strings.TrimPrefix(header, "--- "), "a/")
+			case strings.HasPrefix(header, "+++ "):
+				cur.NewPath = headerPath(strings.TrimPrefix(header, "+++ "), "b/")
+			case strings.HasPrefix(header, "new file mode"):
+				cur.OldPath = ""
+			case strings.HasPrefix(header, "deleted file mode"):
+				cur.NewPath = ""
+			case strings.HasPrefix(header, "rename from "):
+				cur.OldPath = unquotePath(strings.TrimPrefix(header, "rename from "))
+			case strings.HasPrefix(header, "rename to "):
+				cur.NewPath = unquotePath(strings.TrimPrefix(header, "rename to "))
+			}
+		}
+	}
+	endFile(len(diff))
+	return files
+}
+
+// gitHeaderPaths returns the paths named by the rest of a "diff --git" line:
+// a/old b/new
+func gitHeaderPaths(rest string) (string, string) {
+	if strings.HasPrefix(rest, `"`) {
+		if old, err := strconv.QuotedPrefix(rest); err == nil {
+			return headerPath(old, "a/"), headerPath(strings.TrimSpace(rest[len(old):]), "b/")
+		}
+	}
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return headerPath(rest[:i], "a/"), rest[i+3:]
+	}
+	return "", ""
+}
+
+// headerPath returns the path in a "---" or "+++" line, without its a/ or
+// b/ prefix and any timestamp. /dev/null gives "".
+func headerPath(p, prefix string) string {
+	if i := strings.IndexByte(p, '\t'); i >= 0 {
+		p = p[:i]
+	}
+	p = unquotePath(p)
+	if p == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(p, prefix)
+}
+
+// unquotePath undoes the C-style quoting git applies to unusual paths
+func unquotePath(p string) string {
+	if strings.HasPrefix(p, `"`) {
+		if s, err := strconv.Unquote(p); err == nil {
+			return s
+		}
+	}
+	return p
+}
+
+// hunkCount parses a hunk header's line count, which is 1 when omitted
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// ChangedFiles returns the new paths of the files a diff adds or modifies
+func ChangedFiles(diff string) []string {
+	var files []string
+	for _, f := range ParseDiff(diff) {
+		if f.NewPath != "" {
+			files = append(files, f.NewPath)
+		}
+	}
+	return files
+}
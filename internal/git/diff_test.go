@@ -0,0 +1,101 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+const parseDiffInput = `preamble
+diff --git a/from.go b/to.go
+similarity index 100%
+rename from from.go
+rename to to.go
+diff --git a/app.go b/app.go
+index 1111111..2222222 100644
+--- a/app.go
++++ b/app.go
+@@ -10,3 +10,4 @@ func main() {
+ a
+--- a removed line that looks like a header
++++ an added line that looks like a header
++b
+ c
+\ No newline at end of file
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+--- plain.orig	2024-01-01
++++ plain	2024-01-02
+@@ -1 +1 @@
+-x
++y
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(parseDiffInput)
+
+	type path struct{ old, new string }
+	var got []path
+	for _, f := range files {
+		got = append(got, path{f.OldPath, f.NewPath})
+	}
+	want := []path{
+		{"from.go", "to.go"},
+		{"app.go", "app.go"},
+		{"", "new.txt"},
+		{"old.txt", ""},
+		{"plain.orig", "plain"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+
+	app := files[1]
+	if len(app.Hunks) != 1 {
+		t.Fatalf("expected 1 hunk in app.go, got %d", len(app.Hunks))
+	}
+	h := app.Hunks[0]
+	if h.OldStart != 10 || h.OldCount != 3 || h.NewStart != 10 || h.NewCount != 4 {
+		t.Errorf("unexpected hunk range: %+v", h)
+	}
+	wantLines := []DiffLine{
+		{Kind: ' ', Text: "a", OldLine: 10, NewLine: 10},
+		{Kind: '-', Text: "-- a removed line that looks like a header", OldLine: 11},
+		{Kind: '+', Text: "++ an added line that looks like a header", NewLine: 11},
+		{Kind: '+', Text: "b", NewLine: 12},
+		{Kind: ' ', Text: "c", OldLine: 12, NewLine: 13},
+		{Kind: '\\', Text: " No newline at end of file"},
+	}
+	if !reflect.DeepEqual(h.Lines, wantLines) {
+		t.Errorf("lines = %+v, want %+v", h.Lines, wantLines)
+	}
+
+	if files[0].Path() != "to.go" || files[3].Path() != "old.txt" {
+		t.Errorf("unexpected Path(): %q, %q", files[0].Path(), files[3].Path())
+	}
+
+	var text string
+	for _, f := range files {
+		text += f.Text
+	}
+	if text != parseDiffInput[len("preamble\n"):] {
+		t.Errorf("file texts don't cover the diff after the preamble")
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	got := ChangedFiles(parseDiffInput)
+	want := []string{"to.go", "app.go", "new.txt", "plain"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", got, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -100,7 +101,17 @@ func LocalBranchName(branch string) string {
 
 // GetDiff returns the full diff for a commit, excluding generated files like lock files
 func GetDiff(repoPath, sha string) (string, error) {
-	args := []string{"show", sha, "--format=", "--"}
+	return GetDiffWithContext(repoPath, sha, -1)
+}
+
+// GetDiffWithContext is GetDiff with contextLines lines of context around
+// each change, or git's default when contextLines is negative
+func GetDiffWithContext(repoPath, sha string, contextLines int) (string, error) {
+	args := []string{"show", sha, "--format="}
+	if contextLines >= 0 {
+		args = append(args, fmt.Sprintf("-U%d", contextLines))
+	}
+	args = append(args, "--")
 	args = append(args, ".")
 	args = append(args, excludedPathPatterns...)
 
@@ -115,9 +126,10 @@ func GetDiff(repoPath, sha string) (string, error) {
 	return string(out), nil
 }
 
-// GetFilesChanged returns the list of files changed in a commit
+// GetFilesChanged returns the list of files changed in a commit, including
+// the files added by a root commit
 func GetFilesChanged(repoPath, sha string) ([]string, error) {
-	cmd := exec.Command("git", "diff-tree", "--no-commit-id", "--name-only", "-r", sha)
+	cmd := exec.Command("git", "diff-tree", "--root", "--no-commit-id", "--name-only", "-r", sha)
 	cmd.Dir = repoPath
 
 	out, err := cmd.Output()
@@ -149,6 +161,34 @@ func GetStat(repoPath, sha string) (string, error) {
 	return string(out), nil
 }
 
+// GetLinesChanged returns the number of lines added plus deleted by a commit.
+// Each binary file counts as one changed line.
+func GetLinesChanged(repoPath, sha string) (int, error) {
+	cmd := exec.Command("git", "show", "--numstat", "--format=", sha)
+	cmd.Dir = repoPath
+
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("git show --numstat: %w", err)
+	}
+
+	total := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		if fields[0] == "-" {
+			total++
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+		total += added + deleted
+	}
+	return total, nil
+}
+
 // IsUnbornHead returns true if the repository has an unborn HEAD (no commits yet).
 // Returns false if HEAD points to a valid commit, if the path is not a git repo,
 // or if HEAD is corrupt (e.g., ref pointing to a missing object).
@@ -298,6 +338,35 @@ func ReadFile(repoPath, sha, filePath string) ([]byte, error) {
 	return stdout.Bytes(), nil
 }
 
+// AddDetachedWorktree checks out ref in a new temporary worktree, leaving
+// the repo's own working tree alone. Call cleanup to remove it.
+func AddDetachedWorktree(repoPath, ref string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "roborev-worktree-")
+	if err != nil {
+		return "", nil, err
+	}
+	cmd := exec.Command("git", "-C", repoPath, "-c", "core.hooksPath="+os.DevNull, "worktree", "add", "--detach", dir, ref)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("git worktree add: %w: %s", err, out)
+	}
+	cleanup := func() {
+		exec.Command("git", "-C", repoPath, "worktree", "remove", "--force", dir).Run()
+		os.RemoveAll(dir)
+	}
+	return dir, cleanup, nil
+}
+
+// ApplyPatch applies a diff to the working tree at dir
+func ApplyPatch(dir, patch string) error {
+	cmd := exec.Command("git", "-C", dir, "apply", "--whitespace=nowarn", "-")
+	cmd.Stdin = strings.NewReader(patch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git apply: %w: %s", err, out)
+	}
+	return nil
+}
+
 // GetParentCommits returns the N commits before the given commit (not including it)
 // Returns commits in reverse chronological order (most recent parent first)
 func GetParentCommits(repoPath, sha string, count int) ([]string, error) {
@@ -356,9 +425,39 @@ func GetRangeCommits(repoPath, rangeRef string) ([]string, error) {
 	return commits, nil
 }
 
+// GetCommitsSinceTime returns the commits reachable from ref with a commit
+// date after since (oldest first)
+func GetCommitsSinceTime(repoPath, ref string, since time.Time) ([]string, error) {
+	cmd := exec.Command("git", "log", "--format=%H", "--reverse", "--since="+since.Format(time.RFC3339), ref, "--")
+	cmd.Dir = repoPath
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", ref, err)
+	}
+
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits, nil
+}
+
 // GetRangeDiff returns the combined diff for a range, excluding generated files like lock files
 func GetRangeDiff(repoPath, rangeRef string) (string, error) {
-	args := []string{"diff", rangeRef, "--"}
+	return GetRangeDiffWithContext(repoPath, rangeRef, -1)
+}
+
+// GetRangeDiffWithContext is GetRangeDiff with contextLines lines of context
+// around each change, or git's default when contextLines is negative
+func GetRangeDiffWithContext(repoPath, rangeRef string, contextLines int) (string, error) {
+	args := []string{"diff", rangeRef}
+	if contextLines >= 0 {
+		args = append(args, fmt.Sprintf("-U%d", contextLines))
+	}
+	args = append(args, "--")
 	args = append(args, ".")
 	args = append(args, excludedPathPatterns...)
 
@@ -391,10 +490,37 @@ func HasUncommittedChanges(repoPath string) (bool, error) {
 // the root commit or repos with no commits.
 const EmptyTreeSHA = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
 
+// Dirty diff scopes select which uncommitted changes GetDirtyDiffScope
+// captures
+const (
+	DirtyScopeAll      = "all"      // staged and unstaged changes plus untracked files
+	DirtyScopeWorktree = "worktree" // staged and unstaged changes to tracked files
+	DirtyScopeStaged   = "staged"   // staged changes only
+)
+
+// IsValidDirtyScope reports whether scope names a dirty diff scope
+func IsValidDirtyScope(scope string) bool {
+	return scope == DirtyScopeAll || scope == DirtyScopeWorktree || scope == DirtyScopeStaged
+}
+
 // GetDirtyDiff returns a diff of all uncommitted changes including untracked files.
 // The diff includes both tracked file changes (via git diff HEAD) and untracked files
 // formatted as new-file diff entries. Excludes generated files like lock files.
 func GetDirtyDiff(repoPath string) (string, error) {
+	return GetDirtyDiffScope(repoPath, DirtyScopeAll)
+}
+
+// GetDirtyDiffScope returns a diff of the uncommitted changes in scope, one
+// of the DirtyScope constants (empty means DirtyScopeAll). Excludes generated
+// files like lock files.
+func GetDirtyDiffScope(repoPath, scope string) (string, error) {
+	if scope == "" {
+		scope = DirtyScopeAll
+	}
+	if !IsValidDirtyScope(scope) {
+		return "", fmt.Errorf("invalid dirty scope %q (valid: %s, %s, %s)", scope, DirtyScopeAll, DirtyScopeWorktree, DirtyScopeStaged)
+	}
+
 	var result strings.Builder
 
 	// Build diff args with exclusions
@@ -405,6 +531,21 @@ func GetDirtyDiff(repoPath string) (string, error) {
 		return args
 	}
 
+	if scope == DirtyScopeStaged {
+		// Index vs HEAD, or vs the empty tree before the first commit
+		base := "HEAD"
+		if _, err := ResolveSHA(repoPath, "HEAD"); err != nil {
+			base = EmptyTreeSHA
+		}
+		cmd := exec.Command("git", diffArgs("diff", "--cached", base)...)
+		cmd.Dir = repoPath
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git diff --cached: %w", err)
+		}
+		return string(out), nil
+	}
+
 	// 1. Get diff of tracked files (staged + unstaged)
 	cmd := exec.Command("git", diffArgs("diff", "HEAD")...)
 	cmd.Dir = repoPath
@@ -443,6 +584,10 @@ func GetDirtyDiff(repoPath string) (string, error) {
 		}
 	}
 
+	if scope == DirtyScopeWorktree {
+		return result.String(), nil
+	}
+
 	// 2. Get list of untracked files
 	cmd = exec.Command("git", "ls-files", "--others", "--exclude-standard")
 	cmd.Dir = repoPath
@@ -558,6 +703,135 @@ func isExcludedFile(filePath string) bool {
 	return false
 }
 
+// lockFilePathspecs matches the lock files excludedPathPatterns leaves out
+// of diffs, in any directory
+func lockFilePathspecs() []string {
+	var specs []string
+	for _, pattern := range excludedPathPatterns {
+		p := strings.TrimPrefix(pattern, ":(exclude)")
+		if _, ok := excludedDirPatterns[p]; !ok {
+			specs = append(specs, ":(glob)**/"+p)
+		}
+	}
+	return specs
+}
+
+// GetExcludedFilesChanged returns the lock files changed between baseRef and
+// targetRef, which diffs leave out. An empty targetRef compares against the
+// working tree, including untracked files.
+func GetExcludedFilesChanged(repoPath, baseRef, targetRef string) ([]string, error) {
+	args := []string{"-c", "core.quotepath=false", "diff", "--name-only", "--no-renames", baseRef}
+	if targetRef != "" {
+		args = append(args, targetRef)
+	}
+	args = append(args, "--")
+	args = append(args, lockFilePathspecs()...)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff --name-only: %w", err)
+	}
+
+	if targetRef == "" {
+		args = append([]string{"-c", "core.quotepath=false", "ls-files", "--others", "--exclude-standard", "--"}, lockFilePathspecs()...)
+		cmd = exec.Command("git", args...)
+		cmd.Dir = repoPath
+		untracked, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git ls-files: %w", err)
+		}
+		out = append(out, untracked...)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// FileSizes is a file's size in bytes before and after a change, -1 where
+// the file doesn't exist
+type FileSizes struct {
+	Old, New int64
+}
+
+// GetFileSizes returns the size of each path at baseRef and at targetRef,
+// or in the working tree when targetRef is empty
+func GetFileSizes(repoPath, baseRef, targetRef string, paths []string) (map[string]FileSizes, error) {
+	var input strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&input, "%s:%s\n", baseRef, p)
+		if targetRef != "" {
+			fmt.Fprintf(&input, "%s:%s\n", targetRef, p)
+		}
+	}
+
+	cmd := exec.Command("git", "cat-file", "--batch-check")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(input.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+
+	// Output has one line per input line, in order: "<oid> <type> <size>",
+	// or "<object> missing" for paths absent at that ref
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	size := func() int64 {
+		if len(lines) == 0 {
+			return -1
+		}
+		fields := strings.Fields(lines[0])
+		lines = lines[1:]
+		if len(fields) != 3 || fields[1] != "blob" {
+			return -1
+		}
+		n, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	sizes := make(map[string]FileSizes, len(paths))
+	for _, p := range paths {
+		s := FileSizes{Old: size(), New: -1}
+		if targetRef != "" {
+			s.New = size()
+		} else if info, err := os.Stat(filepath.Join(repoPath, filepath.FromSlash(p))); err == nil {
+			s.New = info.Size()
+		}
+		sizes[p] = s
+	}
+	return sizes, nil
+}
+
+// GetGeneratedFiles returns the paths .gitattributes marks linguist-generated
+func GetGeneratedFiles(repoPath string, paths []string) (map[string]bool, error) {
+	cmd := exec.Command("git", "check-attr", "-z", "--stdin", "linguist-generated")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git check-attr: %w", err)
+	}
+
+	// Output is NUL-separated "<path> <attribute> <value>" triples
+	generated := make(map[string]bool)
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		if v := fields[i+2]; v == "set" || v == "true" {
+			generated[fields[i]] = true
+		}
+	}
+	return generated, nil
+}
+
 // isBinaryContent checks if content appears to be binary (contains null bytes in first 8KB)
 func isBinaryContent(content []byte) bool {
 	// Check first 8KB for null bytes
@@ -854,6 +1128,84 @@ func CreateCommit(repoPath, message string) (string, error) {
 	return sha, nil
 }
 
+// CommitToBranch stages all changes and commits them, then points branch
+// at the new commit, creating or moving it. Commit hooks are skipped. It's
+// meant for temporary worktrees, so the branch needn't be checked out.
+func CommitToBranch(repoPath, branch, message string) (string, error) {
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "core.hooksPath=" + os.DevNull, "commit", "-m", message},
+		{"branch", "-f", branch, "HEAD"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return ResolveSHA(repoPath, "HEAD")
+}
+
+// AppendTrailers adds "Key: value" trailers to a commit message. It uses
+// git interpret-trailers so trailers merge into an existing trailer block
+// instead of starting a second one.
+func AppendTrailers(message string, trailers ...string) (string, error) {
+	if len(trailers) == 0 {
+		return message, nil
+	}
+	args := []string{"interpret-trailers"}
+	for _, t := range trailers {
+		args = append(args, "--trailer", t)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(message)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git interpret-trailers: %w: %s", err, stderr.String())
+	}
+	return string(out), nil
+}
+
+// AmendTrailers adds "Key: value" trailers to the message of the HEAD
+// commit and returns the SHA of the amended commit. Trailers already in the
+// message are not added twice. Pre-commit and commit-msg hooks are skipped
+// since the commit's content doesn't change.
+func AmendTrailers(repoPath string, trailers ...string) (string, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%B", "HEAD")
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("read HEAD message: %w", err)
+	}
+	message := strings.TrimRight(string(out), "\n") + "\n"
+
+	var missing []string
+	for _, t := range trailers {
+		if !strings.Contains(message, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) == 0 {
+		return ResolveSHA(repoPath, "HEAD")
+	}
+	stamped, err := AppendTrailers(message, missing...)
+	if err != nil {
+		return "", err
+	}
+
+	cmd = exec.Command("git", "commit", "--amend", "--no-verify", "--allow-empty", "--cleanup=verbatim", "-F", "-")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(stamped)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", &CommitError{Phase: "commit", Stderr: stderr.String(), Err: err}
+	}
+	return ResolveSHA(repoPath, "HEAD")
+}
+
 // IsWorkingTreeClean returns true if the working tree has no uncommitted or untracked changes
 func IsWorkingTreeClean(repoPath string) bool {
 	cmd := exec.Command("git", "-C", repoPath, "status", "--porcelain")
@@ -941,3 +1293,61 @@ func getAnyRemoteURL(repoPath string) string {
 	}
 	return ""
 }
+
+// BlameLine is who last changed a line, from git blame
+type BlameLine struct {
+	Line       int // Line number in the blamed version
+	Commit     string
+	Author     string
+	AuthorTime time.Time
+}
+
+// Blame reports who last changed the given line ranges of a file at ref.
+// Each range is a first and last line, 1-based and inclusive.
+func Blame(repoPath, ref, filePath string, ranges [][2]int) ([]BlameLine, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	args := []string{"blame", "--line-porcelain"}
+	for _, r := range ranges {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", r[0], r[1]))
+	}
+	args = append(args, ref, "--", filePath)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git blame %s: %w: %s", filePath, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseBlamePorcelain(stdout.String()), nil
+}
+
+// parseBlamePorcelain parses git blame --line-porcelain output, which
+// repeats the commit details for every line
+func parseBlamePorcelain(out string) []BlameLine {
+	var lines []BlameLine
+	var cur BlameLine
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			lines = append(lines, cur)
+			cur = BlameLine{}
+		case strings.HasPrefix(line, "author "):
+			cur.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if ts, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				cur.AuthorTime = time.Unix(ts, 0).UTC()
+			}
+		case cur.Commit == "":
+			// Header: <sha> <original line> <final line> [<group size>]
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && len(fields[0]) >= 40 {
+				cur.Commit = fields[0]
+				cur.Line, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	return lines
+}
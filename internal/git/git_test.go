@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestRepo wraps a temporary git repository for testing.
@@ -958,6 +959,64 @@ func TestGetDirtyDiff(t *testing.T) {
 	})
 }
 
+func TestGetDirtyDiffScope(t *testing.T) {
+	repo := NewTestRepo(t)
+	repo.CommitFile("staged.txt", "initial\n", "initial")
+	repo.CommitFile("unstaged.txt", "initial\n", "second")
+
+	repo.WriteFile("staged.txt", "staged change\n")
+	repo.Run("add", "staged.txt")
+	repo.WriteFile("unstaged.txt", "unstaged change\n")
+	repo.WriteFile("untracked.txt", "untracked\n")
+
+	tests := []struct {
+		scope   string
+		want    []string
+		notWant []string
+	}{
+		{DirtyScopeStaged, []string{"+staged change"}, []string{"unstaged change", "untracked.txt"}},
+		{DirtyScopeWorktree, []string{"+staged change", "+unstaged change"}, []string{"untracked.txt"}},
+		{DirtyScopeAll, []string{"+staged change", "+unstaged change", "+untracked"}, nil},
+		{"", []string{"+staged change", "+unstaged change", "+untracked"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run("scope "+tt.scope, func(t *testing.T) {
+			diff, err := GetDirtyDiffScope(repo.Dir, tt.scope)
+			if err != nil {
+				t.Fatalf("GetDirtyDiffScope failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(diff, want) {
+					t.Errorf("expected diff to contain %q:\n%s", want, diff)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(diff, notWant) {
+					t.Errorf("expected diff not to contain %q:\n%s", notWant, diff)
+				}
+			}
+		})
+	}
+
+	if _, err := GetDirtyDiffScope(repo.Dir, "index"); err == nil {
+		t.Error("expected an error for an unknown scope")
+	}
+
+	t.Run("staged before the first commit", func(t *testing.T) {
+		fresh := NewTestRepo(t)
+		fresh.WriteFile("first.txt", "first\n")
+		fresh.Run("add", "first.txt")
+		fresh.WriteFile("later.txt", "later\n")
+		diff, err := GetDirtyDiffScope(fresh.Dir, DirtyScopeStaged)
+		if err != nil {
+			t.Fatalf("GetDirtyDiffScope failed: %v", err)
+		}
+		if !strings.Contains(diff, "+first") || strings.Contains(diff, "later.txt") {
+			t.Errorf("expected only the staged file:\n%s", diff)
+		}
+	})
+}
+
 func TestGetDirtyDiffNoCommits(t *testing.T) {
 	repo := NewTestRepo(t)
 
@@ -1281,6 +1340,34 @@ func TestGetRangeFilesChanged(t *testing.T) {
 	})
 }
 
+func TestGetCommitsSinceTime(t *testing.T) {
+	repo := NewTestRepo(t)
+	repo.WriteFile("old.txt", "old")
+	repo.Run("add", "old.txt")
+	cmd := exec.Command("git", "commit", "-m", "old commit")
+	cmd.Dir = repo.Dir
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2020-01-01T00:00:00Z")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+	repo.CommitFile("a.txt", "a", "first recent")
+	first := repo.HeadSHA()
+	repo.CommitFile("b.txt", "b", "second recent")
+	second := repo.HeadSHA()
+
+	commits, err := GetCommitsSinceTime(repo.Dir, "HEAD", time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetCommitsSinceTime failed: %v", err)
+	}
+	if len(commits) != 2 || commits[0] != first || commits[1] != second {
+		t.Errorf("expected the two recent commits oldest first, got %v", commits)
+	}
+
+	if _, err := GetCommitsSinceTime(repo.Dir, "no-such-branch", time.Now()); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
+
 func TestCreateCommitPreCommitHookOutput(t *testing.T) {
 	repo := NewTestRepo(t)
 	repo.CommitFile("initial.txt", "initial", "initial commit")
@@ -1507,3 +1594,159 @@ func TestIsAncestor(t *testing.T) {
 		}
 	})
 }
+
+func TestGetLinesChangedAndFilesChanged(t *testing.T) {
+	repo := NewTestRepo(t)
+	repo.CommitFile("a.txt", "one\ntwo\n", "root")
+	root := repo.HeadSHA()
+	repo.CommitFile("a.txt", "one\n2\nthree\n", "edit")
+	edit := repo.HeadSHA()
+
+	files, err := GetFilesChanged(repo.Dir, root)
+	if err != nil {
+		t.Fatalf("GetFilesChanged: %v", err)
+	}
+	if len(files) != 1 || files[0] != "a.txt" {
+		t.Errorf("expected root commit to report a.txt, got %v", files)
+	}
+
+	for sha, want := range map[string]int{root: 2, edit: 3} {
+		got, err := GetLinesChanged(repo.Dir, sha)
+		if err != nil {
+			t.Fatalf("GetLinesChanged: %v", err)
+		}
+		if got != want {
+			t.Errorf("GetLinesChanged(%s) = %d, want %d", sha[:7], got, want)
+		}
+	}
+}
+
+func TestAppendTrailers(t *testing.T) {
+	t.Run("adds trailer block", func(t *testing.T) {
+		got, err := AppendTrailers("Fix bug\n\nDetails here.\n", "Roborev-Reviewed: job 12 (high findings)")
+		if err != nil {
+			t.Fatalf("AppendTrailers: %v", err)
+		}
+		want := "Fix bug\n\nDetails here.\n\nRoborev-Reviewed: job 12 (high findings)\n"
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("merges into existing trailers", func(t *testing.T) {
+		got, err := AppendTrailers("Fix bug\n\nSigned-off-by: A <a@example.com>\n", "Roborev-Reviewed: job 3 (no high findings)")
+		if err != nil {
+			t.Fatalf("AppendTrailers: %v", err)
+		}
+		if !strings.HasSuffix(got, "Signed-off-by: A <a@example.com>\nRoborev-Reviewed: job 3 (no high findings)\n") {
+			t.Errorf("expected trailer appended to existing block, got %q", got)
+		}
+	})
+
+	t.Run("no trailers leaves message unchanged", func(t *testing.T) {
+		if got, _ := AppendTrailers("msg"); got != "msg" {
+			t.Errorf("got %q", got)
+		}
+	})
+}
+
+func TestAmendTrailers(t *testing.T) {
+	repo := NewTestRepo(t)
+	repo.CommitFile("a.txt", "a", "Fix bug")
+	before := repo.HeadSHA()
+
+	sha, err := AmendTrailers(repo.Dir, "Roborev-Reviewed: job 4 (high findings)")
+	if err != nil {
+		t.Fatalf("AmendTrailers: %v", err)
+	}
+	if sha == before || sha != repo.HeadSHA() {
+		t.Errorf("expected HEAD to be amended, got %s (was %s)", sha, before)
+	}
+	msg := repo.Run("log", "-1", "--format=%B")
+	if !strings.Contains(msg, "Fix bug\n\nRoborev-Reviewed: job 4 (high findings)") {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	again, err := AmendTrailers(repo.Dir, "Roborev-Reviewed: job 4 (high findings)")
+	if err != nil {
+		t.Fatalf("AmendTrailers: %v", err)
+	}
+	if again != sha {
+		t.Errorf("expected a stamped commit to be left alone, got %s (was %s)", again, sha)
+	}
+}
+
+func TestGetExcludedFilesChangedAndSizes(t *testing.T) {
+	repo := NewTestRepo(t)
+	repo.CommitFile("go.sum", "sum\n", "initial")
+	base := repo.HeadSHA()
+
+	repo.WriteFile("go.sum", "sum\nmore\n")
+	repo.WriteFile("web/yarn.lock", "lock\n")
+	repo.WriteFile("keep.txt", "keep\n")
+	repo.CommitAll("update")
+
+	files, err := GetExcludedFilesChanged(repo.Dir, base, "HEAD")
+	if err != nil {
+		t.Fatalf("GetExcludedFilesChanged failed: %v", err)
+	}
+	if strings.Join(files, ",") != "go.sum,web/yarn.lock" {
+		t.Errorf("expected lock files only, got %v", files)
+	}
+
+	sizes, err := GetFileSizes(repo.Dir, base, "HEAD", files)
+	if err != nil {
+		t.Fatalf("GetFileSizes failed: %v", err)
+	}
+	if s := sizes["go.sum"]; s.Old != 4 || s.New != 9 {
+		t.Errorf("go.sum sizes = %+v, want {4 9}", s)
+	}
+	if s := sizes["web/yarn.lock"]; s.Old != -1 || s.New != 5 {
+		t.Errorf("web/yarn.lock sizes = %+v, want {-1 5}", s)
+	}
+
+	// Untracked lock files count as working tree changes
+	repo.WriteFile("uv.lock", "uv\n")
+	files, err = GetExcludedFilesChanged(repo.Dir, "HEAD", "")
+	if err != nil {
+		t.Fatalf("GetExcludedFilesChanged failed: %v", err)
+	}
+	if strings.Join(files, ",") != "uv.lock" {
+		t.Errorf("expected untracked uv.lock, got %v", files)
+	}
+}
+
+func TestBlame(t *testing.T) {
+	repo := NewTestRepoWithAuthor(t, "Alice")
+	repo.CommitFile("f.txt", "one\ntwo\nthree\nfour\n", "initial")
+	first := repo.HeadSHA()
+	repo.Run("config", "user.name", "Bob")
+	repo.CommitFile("f.txt", "one\nTWO\nthree\nfour\n", "edit")
+	second := repo.HeadSHA()
+
+	lines, err := Blame(repo.Dir, "HEAD", "f.txt", [][2]int{{1, 2}, {4, 4}})
+	if err != nil {
+		t.Fatalf("Blame failed: %v", err)
+	}
+	want := []struct {
+		line   int
+		commit string
+		author string
+	}{{1, first, "Alice"}, {2, second, "Bob"}, {4, first, "Alice"}}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i, w := range want {
+		l := lines[i]
+		if l.Line != w.line || l.Commit != w.commit || l.Author != w.author {
+			t.Errorf("line %d = %+v, want line %d by %s in %s", i, l, w.line, w.author, w.commit)
+		}
+		if l.AuthorTime.IsZero() {
+			t.Errorf("line %d has no author time", i)
+		}
+	}
+
+	if _, err := Blame(repo.Dir, "HEAD", "missing.txt", [][2]int{{1, 1}}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,179 @@
+// Package integrity signs stored reviews and verifies those signatures, so
+// a review's content can be shown to be what the daemon recorded.
+package integrity
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// Signature states reported by Check
+const (
+	Valid      = "valid"       // signed by the key and unchanged since
+	Invalid    = "invalid"     // the content or signature doesn't match
+	Unsigned   = "unsigned"    // the review has no signature
+	UnknownKey = "unknown-key" // signed by a different key
+)
+
+// hashVersion prefixes the hashed content, so the layout can change later
+const hashVersion = "roborev-review-v1"
+
+// Key signs reviews, or only verifies them when Private is nil
+type Key struct {
+	Private ed25519.PrivateKey
+	Public  ed25519.PublicKey
+}
+
+// ID identifies the key in stored signatures
+func (k *Key) ID() string {
+	sum := sha256.Sum256(k.Public)
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// LoadKey reads an Ed25519 key from a PEM file: a PKCS #8 private key, or
+// a PKIX public key for verifying only. A leading ~/ is expanded.
+func LoadKey(path string) (*Key, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = home + "/" + rest
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s: no PEM block", path)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", path, err)
+		}
+		priv, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %s: not an Ed25519 key", path)
+		}
+		return &Key{Private: priv, Public: priv.Public().(ed25519.PublicKey)}, nil
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", path, err)
+		}
+		pub, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %s: not an Ed25519 key", path)
+		}
+		return &Key{Public: pub}, nil
+	default:
+		return nil, fmt.Errorf("signing key %s: unexpected PEM type %q", path, block.Type)
+	}
+}
+
+// Signature is what's stored with a signed review
+type Signature struct {
+	KeyID     string `json:"key_id"`
+	Hash      string `json:"hash"`      // hex SHA-256 of the review content
+	Signature string `json:"signature"` // base64 Ed25519 signature of the hash
+}
+
+// ContentHash hashes the parts of a review that a signature covers: its
+// UUID, the reviewed ref, the agent, when it was created, the prompt, and
+// the output. Each field is length-prefixed so none can run into the next.
+func ContentHash(r *storage.Review) string {
+	var gitRef string
+	if r.Job != nil {
+		gitRef = r.Job.GitRef
+	}
+	h := sha256.New()
+	for _, field := range []string{
+		hashVersion, r.UUID, gitRef, r.Agent, r.CreatedAt.UTC().Format(time.RFC3339), r.Prompt, r.Output,
+	} {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(field))))
+		h.Write([]byte(field))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Sign signs a review's content
+func Sign(key *Key, r *storage.Review) (Signature, error) {
+	if key.Private == nil {
+		return Signature{}, errors.New("signing needs a private key")
+	}
+	hash := ContentHash(r)
+	return Signature{
+		KeyID:     key.ID(),
+		Hash:      hash,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key.Private, []byte(hash))),
+	}, nil
+}
+
+// Verify reports whether sig is key's signature of the review as it is now
+func Verify(key *Key, r *storage.Review, sig Signature) string {
+	if sig.KeyID != key.ID() {
+		return UnknownKey
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil || sig.Hash != ContentHash(r) || !ed25519.Verify(key.Public, []byte(sig.Hash), raw) {
+		return Invalid
+	}
+	return Valid
+}
+
+// SignReview signs a job's stored review and saves the signature with it
+func SignReview(db *storage.DB, key *Key, jobID int64) error {
+	review, err := db.GetReviewByJobID(jobID)
+	if err != nil {
+		return fmt.Errorf("get review: %w", err)
+	}
+	sig, err := Sign(key, review)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sig)
+	if err != nil {
+		return err
+	}
+	return db.SaveReviewAttachment(jobID, storage.AttachmentSignature, string(data))
+}
+
+// Check verifies the signature stored with a job's review. A job without
+// a review counts as unsigned.
+func Check(db *storage.DB, key *Key, jobID int64) (string, error) {
+	review, err := db.GetReviewByJobID(jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Unsigned, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get review: %w", err)
+	}
+	data, err := db.GetReviewAttachment(jobID, storage.AttachmentSignature)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Unsigned, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get signature: %w", err)
+	}
+	var sig Signature
+	if err := json.Unmarshal([]byte(data), &sig); err != nil {
+		return Invalid, nil
+	}
+	return Verify(key, review, sig), nil
+}
@@ -0,0 +1,148 @@
+package integrity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+// writeKey generates a key pair and writes the private key, and the public
+// key when public is set, as PEM files in a temp dir
+func writeKey(t *testing.T, public bool) string {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block *pem.Block
+	if public {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadKey(t *testing.T, path string) *Key {
+	t.Helper()
+	key, err := LoadKey(path)
+	if err != nil {
+		t.Fatalf("LoadKey failed: %v", err)
+	}
+	return key
+}
+
+func TestSignAndVerify(t *testing.T) {
+	key := loadKey(t, writeKey(t, false))
+	review := &storage.Review{
+		UUID:      "b3c1",
+		Agent:     "codex",
+		Prompt:    "Review this",
+		Output:    "No issues found.",
+		CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC),
+		Job:       &storage.ReviewJob{GitRef: "abc123"},
+	}
+	sig, err := Sign(key, review)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if got := Verify(key, review, sig); got != Valid {
+		t.Errorf("Verify() = %q, want %q", got, Valid)
+	}
+
+	verifyOnly := &Key{Public: key.Public}
+	if got := Verify(verifyOnly, review, sig); got != Valid {
+		t.Errorf("Verify() with public key = %q, want %q", got, Valid)
+	}
+	if _, err := Sign(verifyOnly, review); err == nil {
+		t.Error("expected Sign to fail without a private key")
+	}
+
+	tampered := *review
+	tampered.Output = "No issues found. (edited)"
+	if got := Verify(key, &tampered, sig); got != Invalid {
+		t.Errorf("Verify() of edited output = %q, want %q", got, Invalid)
+	}
+	other := loadKey(t, writeKey(t, false))
+	if got := Verify(other, review, sig); got != UnknownKey {
+		t.Errorf("Verify() with another key = %q, want %q", got, UnknownKey)
+	}
+}
+
+func TestLoadKeyPublic(t *testing.T) {
+	key := loadKey(t, writeKey(t, true))
+	if key.Private != nil || len(key.Public) != ed25519.PublicKeySize {
+		t.Errorf("expected a verify-only key, got %+v", key)
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.pem")
+	if err := os.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKey(path); err == nil {
+		t.Error("expected an error for a file without a PEM block")
+	}
+}
+
+func TestSignReviewAndCheck(t *testing.T) {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	key := loadKey(t, writeKey(t, false))
+
+	repo, err := db.GetOrCreateRepo(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := db.EnqueueJob(storage.EnqueueOpts{RepoID: repo.ID, GitRef: "abc123", Agent: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Check(db, key, job.ID); err != nil || got != Unsigned {
+		t.Errorf("Check() without a review = %q, %v; want %q", got, err, Unsigned)
+	}
+	if _, err := db.ClaimJob("worker-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CompleteJob(job.ID, "test", "prompt", "No issues found."); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Check(db, key, job.ID); err != nil || got != Unsigned {
+		t.Errorf("Check() before signing = %q, %v; want %q", got, err, Unsigned)
+	}
+
+	if err := SignReview(db, key, job.ID); err != nil {
+		t.Fatalf("SignReview failed: %v", err)
+	}
+	if got, err := Check(db, key, job.ID); err != nil || got != Valid {
+		t.Errorf("Check() after signing = %q, %v; want %q", got, err, Valid)
+	}
+
+	if _, err := db.Exec(`UPDATE reviews SET output = 'Approved.' WHERE job_id = ?`, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Check(db, key, job.ID); err != nil || got != Invalid {
+		t.Errorf("Check() after editing the review = %q, %v; want %q", got, err, Invalid)
+	}
+}
@@ -0,0 +1,313 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// AdvisoriesHeader introduces known vulnerabilities in dependencies the
+// diff touches
+const AdvisoriesHeader = `### Dependency Advisories
+
+Vulnerability scanners report these known advisories for dependencies changed
+in this diff. Ground your findings about them in this data: say whether the
+change introduces, fixes, or leaves each one exploitable.
+`
+
+// maxAdvisories caps how many advisories are listed in a prompt
+const maxAdvisories = 50
+
+// advisoryTimeout bounds each scanner run
+const advisoryTimeout = 2 * time.Minute
+
+// osvLockfiles are the manifests osv-scanner can scan on their own
+var osvLockfiles = map[string]bool{
+	"go.mod":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"requirements.txt":  true,
+	"poetry.lock":       true,
+	"Pipfile.lock":      true,
+	"Cargo.lock":        true,
+	"Gemfile.lock":      true,
+	"composer.lock":     true,
+}
+
+// advisory is a known vulnerability in a dependency
+type advisory struct {
+	ID      string
+	Aliases []string
+	Package string
+	Version string
+	Summary string
+}
+
+// manifestChanges returns the added lines of each dependency manifest the
+// diff touches, keyed by path
+func manifestChanges(diff string) map[string][]string {
+	changes := make(map[string][]string)
+	for _, f := range git.ParseDiff(diff) {
+		if f.NewPath == "" || !osvLockfiles[path.Base(f.NewPath)] {
+			continue
+		}
+		changes[f.NewPath] = nil
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Kind == '+' {
+					changes[f.NewPath] = append(changes[f.NewPath], l.Text)
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// lookupAdvisories scans the manifests changed in diff and returns the
+// advisories for dependencies on their added lines. readFile returns a
+// manifest's content at the reviewed version. Scanners that aren't
+// installed or fail are skipped.
+func lookupAdvisories(repoPath, diff string, readFile func(path string) ([]byte, error)) []advisory {
+	changes := manifestChanges(diff)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Scan in path order so the prompt doesn't depend on map order
+	manifests := make([]string, 0, len(changes))
+	for manifest := range changes {
+		manifests = append(manifests, manifest)
+	}
+	sort.Strings(manifests)
+
+	_, osvErr := exec.LookPath("osv-scanner")
+	var found []advisory
+	for _, manifest := range manifests {
+		added := changes[manifest]
+		if len(added) == 0 {
+			continue
+		}
+		var advisories []advisory
+		var err error
+		switch {
+		case osvErr == nil:
+			advisories, err = scanWithOSV(manifest, readFile)
+		case path.Base(manifest) == "go.mod":
+			advisories, err = scanWithGovulncheck(filepath.Join(repoPath, filepath.FromSlash(path.Dir(manifest))))
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("advisories: %s: %v", manifest, err)
+			continue
+		}
+		for _, a := range advisories {
+			if touchesPackage(added, a.Package) {
+				found = append(found, a)
+			}
+		}
+	}
+	return found
+}
+
+// touchesPackage reports whether any added manifest line names pkg
+func touchesPackage(added []string, pkg string) bool {
+	if pkg == "" {
+		return false
+	}
+	for _, line := range added {
+		if strings.Contains(line, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanWithOSV runs osv-scanner on a copy of the manifest written outside
+// the repo, so the reviewed version is scanned without touching the
+// working tree
+func scanWithOSV(manifest string, readFile func(path string) ([]byte, error)) ([]advisory, error) {
+	content, err := readFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "roborev-advisories-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	lockfile := filepath.Join(dir, path.Base(manifest))
+	if err := os.WriteFile(lockfile, content, 0600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), advisoryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "osv-scanner", "--format", "json", "--lockfile", lockfile)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// osv-scanner exits non-zero when it finds vulnerabilities, so the
+	// output decides success
+	runErr := cmd.Run()
+	advisories, err := parseOSVOutput(stdout.Bytes())
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("osv-scanner: %w: %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	return advisories, nil
+}
+
+// parseOSVOutput extracts advisories from osv-scanner's JSON report
+func parseOSVOutput(data []byte) ([]advisory, error) {
+	var report struct {
+		Results []struct {
+			Packages []struct {
+				Package struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"package"`
+				Vulnerabilities []struct {
+					ID      string   `json:"id"`
+					Summary string   `json:"summary"`
+					Aliases []string `json:"aliases"`
+				} `json:"vulnerabilities"`
+			} `json:"packages"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse osv-scanner output: %w", err)
+	}
+
+	var advisories []advisory
+	for _, result := range report.Results {
+		for _, pkg := range result.Packages {
+			for _, v := range pkg.Vulnerabilities {
+				advisories = append(advisories, advisory{
+					ID:      v.ID,
+					Aliases: v.Aliases,
+					Package: pkg.Package.Name,
+					Version: pkg.Package.Version,
+					Summary: v.Summary,
+				})
+			}
+		}
+	}
+	return advisories, nil
+}
+
+// scanWithGovulncheck runs govulncheck on the Go module in dir. Unlike
+// osv-scanner it needs a buildable module, so it scans the checkout
+// rather than the reviewed commit.
+func scanWithGovulncheck(dir string) ([]advisory, error) {
+	if _, err := exec.LookPath("govulncheck"); err != nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), advisoryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "govulncheck", "-json", "./...")
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("govulncheck: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseGovulncheckOutput(&stdout)
+}
+
+// parseGovulncheckOutput extracts advisories from govulncheck's stream of
+// JSON messages. Only advisories with a finding are reported.
+func parseGovulncheckOutput(r io.Reader) ([]advisory, error) {
+	type osvEntry struct {
+		ID      string   `json:"id"`
+		Summary string   `json:"summary"`
+		Aliases []string `json:"aliases"`
+	}
+	entries := make(map[string]osvEntry)
+	var advisories []advisory
+	seen := make(map[string]bool)
+
+	dec := json.NewDecoder(r)
+	for {
+		var msg struct {
+			OSV     *osvEntry `json:"osv"`
+			Finding *struct {
+				OSV   string `json:"osv"`
+				Trace []struct {
+					Module  string `json:"module"`
+					Version string `json:"version"`
+				} `json:"trace"`
+			} `json:"finding"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("parse govulncheck output: %w", err)
+		}
+		if msg.OSV != nil {
+			entries[msg.OSV.ID] = *msg.OSV
+		}
+		if f := msg.Finding; f != nil && len(f.Trace) > 0 {
+			key := f.OSV + "\x00" + f.Trace[0].Module
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			advisories = append(advisories, advisory{ID: f.OSV, Package: f.Trace[0].Module, Version: f.Trace[0].Version})
+		}
+	}
+	// OSV entries precede findings, but fill details once all are read
+	for i := range advisories {
+		e := entries[advisories[i].ID]
+		advisories[i].Summary = e.Summary
+		advisories[i].Aliases = e.Aliases
+	}
+	return advisories, nil
+}
+
+// writeAdvisories lists advisories for the dependencies the diff changes
+func writeAdvisories(sb *strings.Builder, advisories []advisory) {
+	if len(advisories) == 0 {
+		return
+	}
+
+	sb.WriteString(AdvisoriesHeader)
+	sb.WriteString("\n")
+	for i, a := range advisories {
+		if i == maxAdvisories {
+			fmt.Fprintf(sb, "- ... and %d more\n", len(advisories)-maxAdvisories)
+			break
+		}
+		id := a.ID
+		if len(a.Aliases) > 0 {
+			id += " (" + strings.Join(a.Aliases, ", ") + ")"
+		}
+		pkg := a.Package
+		if a.Version != "" {
+			pkg += "@" + a.Version
+		}
+		line := fmt.Sprintf("- %s in %s", id, pkg)
+		if a.Summary != "" {
+			line += ": " + a.Summary
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,114 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+const goModDiff = `diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3,3 +3,4 @@
+ require (
+-	golang.org/x/net v0.17.0
++	golang.org/x/net v0.18.0
++	github.com/pkg/errors v0.9.1
+ )
+diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package main
++package main // golang.org/x/text
+`
+
+func TestManifestChanges(t *testing.T) {
+	changes := manifestChanges(goModDiff)
+	if len(changes) != 1 {
+		t.Fatalf("expected only go.mod, got %v", changes)
+	}
+	added := changes["go.mod"]
+	if len(added) != 2 || !strings.Contains(added[0], "golang.org/x/net v0.18.0") {
+		t.Errorf("added lines = %q", added)
+	}
+}
+
+func TestParseOSVOutput(t *testing.T) {
+	out := `{"results": [{"packages": [{"package": {"name": "golang.org/x/net", "version": "0.18.0"},
+		"vulnerabilities": [{"id": "GO-2023-2102", "summary": "HTTP/2 rapid reset", "aliases": ["CVE-2023-39325"]}]}]}]}`
+	advisories, err := parseOSVOutput([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := advisory{ID: "GO-2023-2102", Aliases: []string{"CVE-2023-39325"}, Package: "golang.org/x/net", Version: "0.18.0", Summary: "HTTP/2 rapid reset"}
+	if len(advisories) != 1 || advisories[0].ID != want.ID || advisories[0].Package != want.Package ||
+		advisories[0].Summary != want.Summary || strings.Join(advisories[0].Aliases, ",") != "CVE-2023-39325" {
+		t.Errorf("got %+v, want %+v", advisories, want)
+	}
+}
+
+func TestParseGovulncheckOutput(t *testing.T) {
+	out := `{"config": {"scanner_name": "govulncheck"}}
+{"osv": {"id": "GO-2023-2102", "summary": "HTTP/2 rapid reset", "aliases": ["CVE-2023-39325"]}}
+{"osv": {"id": "GO-2024-0001", "summary": "unused"}}
+{"finding": {"osv": "GO-2023-2102", "trace": [{"module": "golang.org/x/net", "version": "v0.17.0"}]}}
+{"finding": {"osv": "GO-2023-2102", "trace": [{"module": "golang.org/x/net", "version": "v0.17.0", "function": "Serve"}]}}
+`
+	advisories, err := parseGovulncheckOutput(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(advisories) != 1 {
+		t.Fatalf("expected one advisory per finding module, got %+v", advisories)
+	}
+	if a := advisories[0]; a.ID != "GO-2023-2102" || a.Package != "golang.org/x/net" || a.Summary != "HTTP/2 rapid reset" {
+		t.Errorf("got %+v", a)
+	}
+}
+
+func TestBuildSecurityPromptIncludesAdvisories(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake scanner")
+	}
+	repoPath, _ := setupTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repoPath, "go.mod"), []byte("module x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Reports one advisory for a touched dependency and one for an untouched one
+	cleanup := testutil.MockBinaryInPath(t, "osv-scanner", `#!/bin/sh
+cat <<'EOF'
+{"results": [{"packages": [
+  {"package": {"name": "golang.org/x/net", "version": "0.18.0"}, "vulnerabilities": [{"id": "GO-2023-2102", "summary": "HTTP/2 rapid reset", "aliases": ["CVE-2023-39325"]}]},
+  {"package": {"name": "golang.org/x/text", "version": "0.3.0"}, "vulnerabilities": [{"id": "GO-2021-0113"}]}
+]}]}
+EOF
+exit 1
+`)
+	defer cleanup()
+
+	b := NewBuilder(nil)
+	prompt, err := b.BuildDirty(repoPath, goModDiff, 0, 0, "test", "security")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if !strings.Contains(prompt, "### Dependency Advisories") ||
+		!strings.Contains(prompt, "- GO-2023-2102 (CVE-2023-39325) in golang.org/x/net@0.18.0: HTTP/2 rapid reset") {
+		t.Errorf("expected advisory for the changed dependency in prompt:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "GO-2021-0113") {
+		t.Error("advisories for dependencies the diff doesn't touch should be left out")
+	}
+
+	prompt, err = b.BuildDirty(repoPath, goModDiff, 0, 0, "test", "review")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if strings.Contains(prompt, "Dependency Advisories") {
+		t.Error("only security reviews should look up advisories")
+	}
+}
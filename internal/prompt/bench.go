@@ -0,0 +1,124 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// BenchHeader introduces the benchmark comparison in bench reviews
+const BenchHeader = `### Benchmark Comparison
+
+The repo's benchmarks were run on the base and on the changes under review.
+`
+
+// benchTimeout bounds each benchmark run
+const benchTimeout = 15 * time.Minute
+
+// maxBenchOutput caps how much of each benchmark run goes in the prompt
+// when benchstat isn't available to summarize them
+const maxBenchOutput = 16 * 1024
+
+// runBenchmarks runs command at baseRef and at targetRef, each in its own
+// temporary worktree, and returns a benchstat comparison, or both raw
+// outputs when benchstat isn't installed. An empty targetRef benchmarks the
+// working tree instead, for uncommitted changes.
+func runBenchmarks(ctx context.Context, repoPath, command, baseRef, targetRef string) (string, error) {
+	base, err := benchAt(ctx, repoPath, command, baseRef)
+	if err != nil {
+		return "", fmt.Errorf("base %s: %w", baseRef, err)
+	}
+	var target string
+	if targetRef == "" {
+		target, err = benchIn(ctx, repoPath, command)
+	} else {
+		target, err = benchAt(ctx, repoPath, command, targetRef)
+	}
+	if err != nil {
+		return "", fmt.Errorf("target: %w", err)
+	}
+
+	if _, err := exec.LookPath("benchstat"); err != nil {
+		return fmt.Sprintf("benchstat is not installed, so these are the raw results.\n\nBase:\n\n```\n%s\n```\n\nTarget:\n\n```\n%s\n```",
+			truncateBench(base), truncateBench(target)), nil
+	}
+	return benchstat(base, target)
+}
+
+// benchAt runs command in a temporary worktree checked out at ref
+func benchAt(ctx context.Context, repoPath, command, ref string) (string, error) {
+	dir, cleanup, err := git.AddDetachedWorktree(repoPath, ref)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	return benchIn(ctx, dir, command)
+}
+
+// benchIn runs command in dir and returns its output
+func benchIn(ctx context.Context, dir, command string) (string, error) {
+	output, failed, err := runShellCommand(ctx, dir, command, benchTimeout)
+	if err != nil {
+		return "", err
+	}
+	if failed {
+		return "", fmt.Errorf("benchmarks failed:\n%s", truncateBench(strings.TrimSpace(output)))
+	}
+	return output, nil
+}
+
+// benchstat compares two benchmark runs with benchstat
+func benchstat(base, target string) (string, error) {
+	dir, err := os.MkdirTemp("", "roborev-bench-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	// benchstat labels columns with the file names
+	if err := os.WriteFile(filepath.Join(dir, "base"), []byte(base), 0600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "target"), []byte(target), 0600); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("benchstat", "base", "target")
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("benchstat: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return "```\n" + strings.TrimSpace(stdout.String()) + "\n```", nil
+}
+
+// truncateBench keeps the start of long benchmark output
+func truncateBench(s string) string {
+	if len(s) > maxBenchOutput {
+		return s[:maxBenchOutput] + "\n... (truncated)"
+	}
+	return s
+}
+
+// writeBenchComparison adds the benchmark comparison, or why there isn't
+// one, to the prompt
+func writeBenchComparison(sb *strings.Builder, command, comparison string, err error) {
+	sb.WriteString(BenchHeader)
+	sb.WriteString("\n")
+	switch {
+	case command == "":
+		sb.WriteString("No bench_command is configured for this repo, so no benchmarks were run. Assess performance from the diff alone.\n\n")
+	case err != nil:
+		fmt.Fprintf(sb, "The benchmarks (`%s`) could not be compared: %v\n\nAssess performance from the diff alone.\n\n", command, err)
+	default:
+		fmt.Fprintf(sb, "Comparison of `%s`:\n\n%s\n\n", command, comparison)
+	}
+}
@@ -0,0 +1,62 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/testutil"
+)
+
+func TestBuildBenchPrompt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bench commands use sh")
+	}
+	repoPath, commits := setupTestRepo(t)
+	b := NewBuilder(nil)
+
+	prompt, err := b.Build(repoPath, commits[5], 0, 0, "test", "bench")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(prompt, SystemPromptBench[:40]) || !strings.Contains(prompt, "No bench_command is configured") {
+		t.Errorf("expected bench prompt noting the missing command:\n%s", prompt)
+	}
+
+	// Reports file.txt's size, which grows by one byte each commit
+	toml := `bench_command = 'echo "BenchmarkRead 1 $(wc -c < file.txt | tr -d " ") ns/op"'`
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cleanup := testutil.MockBinaryInPath(t, "benchstat", "#!/bin/sh\necho \"benchstat $1 $2\"\ncat \"$1\" \"$2\"\n")
+	defer cleanup()
+
+	prompt, err = b.Build(repoPath, commits[5], 0, 0, "test", "bench")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	for _, want := range []string{"### Benchmark Comparison", "benchstat base target", "BenchmarkRead 1 5 ns/op\nBenchmarkRead 1 6 ns/op"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in prompt:\n%s", want, prompt)
+		}
+	}
+
+	out, err := exec.Command("git", "-C", repoPath, "worktree", "list").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Split(strings.TrimSpace(string(out)), "\n")); n != 1 {
+		t.Errorf("expected benchmark worktrees to be removed, got:\n%s", out)
+	}
+
+	prompt, err = b.Build(repoPath, commits[5], 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(prompt, "Benchmark Comparison") {
+		t.Error("only bench reviews should run benchmarks")
+	}
+}
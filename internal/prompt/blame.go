@@ -0,0 +1,164 @@
+package prompt
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// BlameHeader introduces who last changed the lines a diff touches
+const BlameHeader = `### Blame Context
+
+Who last changed the lines this diff modifies or removes, and when. Changes
+to code that has been stable for a long time, or that others maintain,
+deserve extra scrutiny.
+`
+
+// maxBlameRanges caps how many line ranges are blamed for one prompt, since
+// each file's blame is a separate git run
+const maxBlameRanges = 40
+
+// blameMergeGap is how many unchanged lines may separate two changed ones
+// for them to be summarized as one range
+const blameMergeGap = 3
+
+// blameRange is a range of lines in the base version of a file
+type blameRange struct {
+	Path        string
+	First, Last int
+}
+
+// changedBaseLines returns the ranges of base-version lines that a diff
+// modifies or removes, in diff order. Pure additions don't change any
+// existing line and aren't included.
+func changedBaseLines(diff string) []blameRange {
+	var ranges []blameRange
+	for _, f := range git.ParseDiff(diff) {
+		if f.OldPath == "" {
+			continue
+		}
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Kind != '-' || l.OldLine == 0 {
+					continue
+				}
+				n := len(ranges)
+				if n > 0 && ranges[n-1].Path == f.OldPath && l.OldLine-ranges[n-1].Last <= blameMergeGap+1 {
+					ranges[n-1].Last = l.OldLine
+				} else {
+					ranges = append(ranges, blameRange{Path: f.OldPath, First: l.OldLine, Last: l.OldLine})
+				}
+			}
+		}
+	}
+	return ranges
+}
+
+// blameSummary describes who last changed a range of lines
+type blameSummary struct {
+	blameRange
+	Oldest, Newest string // Author dates, YYYY-MM-DD
+	Authors        []string
+}
+
+// blameChangedLines blames the base-version lines the diff changes
+func blameChangedLines(repoPath, baseRef, diff string) []blameSummary {
+	ranges := changedBaseLines(diff)
+	if len(ranges) > maxBlameRanges {
+		ranges = ranges[:maxBlameRanges]
+	}
+
+	byPath := make(map[string][][2]int)
+	var paths []string
+	for _, r := range ranges {
+		if _, ok := byPath[r.Path]; !ok {
+			paths = append(paths, r.Path)
+		}
+		byPath[r.Path] = append(byPath[r.Path], [2]int{r.First, r.Last})
+	}
+	lines := make(map[string][]git.BlameLine)
+	for _, p := range paths {
+		blamed, err := git.Blame(repoPath, baseRef, p, byPath[p])
+		if err != nil {
+			log.Printf("blame: %v", err)
+			continue
+		}
+		lines[p] = blamed
+	}
+
+	var summaries []blameSummary
+	for _, r := range ranges {
+		counts := make(map[string]int)
+		s := blameSummary{blameRange: r}
+		for _, l := range lines[r.Path] {
+			if l.Line < r.First || l.Line > r.Last {
+				continue
+			}
+			counts[l.Author]++
+			date := l.AuthorTime.Format("2006-01-02")
+			if s.Oldest == "" || date < s.Oldest {
+				s.Oldest = date
+			}
+			if date > s.Newest {
+				s.Newest = date
+			}
+		}
+		if len(counts) == 0 {
+			continue
+		}
+		for author := range counts {
+			s.Authors = append(s.Authors, author)
+		}
+		sort.Slice(s.Authors, func(i, j int) bool {
+			a, b := s.Authors[i], s.Authors[j]
+			if counts[a] != counts[b] {
+				return counts[a] > counts[b]
+			}
+			return a < b
+		})
+		for i, a := range s.Authors {
+			s.Authors[i] = fmt.Sprintf("%s (%d)", a, counts[a])
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+// writeBlameSummaries lists who last changed each range
+func writeBlameSummaries(sb *strings.Builder, summaries []blameSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	sb.WriteString(BlameHeader)
+	sb.WriteString("\n")
+	for _, s := range summaries {
+		lines := strconv.Itoa(s.First)
+		if s.Last != s.First {
+			lines += "-" + strconv.Itoa(s.Last)
+		}
+		when := s.Oldest
+		if s.Newest != s.Oldest {
+			when += " to " + s.Newest
+		}
+		fmt.Fprintf(sb, "- %s:%s: last changed %s by %s\n", s.Path, lines, when, strings.Join(s.Authors, ", "))
+	}
+	sb.WriteString("\n")
+}
+
+// writeBlameContext summarizes who last changed the lines the diff touches,
+// as of baseRef, when the repo enables it
+func (b *Builder) writeBlameContext(sb *strings.Builder, repoPath, baseRef, diff string) {
+	if baseRef == git.EmptyTreeSHA {
+		return
+	}
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err != nil || repoCfg == nil || !repoCfg.BlameContext {
+		return
+	}
+	writeBlameSummaries(sb, blameChangedLines(repoPath, baseRef, diff))
+}
@@ -0,0 +1,78 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangedBaseLines(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -10,6 +10,6 @@ func f() {
+ ctx
+-old 11
++new 11
+ ctx
+ ctx
+-old 14
+--- a SQL comment
++new 14
+@@ -40,2 +40,3 @@
+ ctx
++added only
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1 @@
++package x
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -3 +2,0 @@
+-gone
+`
+	got := changedBaseLines(diff)
+	want := []blameRange{
+		{Path: "a.go", First: 11, Last: 15},
+		{Path: "b.go", First: 3, Last: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("range %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildIncludesBlameContext(t *testing.T) {
+	repoPath, commits := setupTestRepo(t)
+	b := NewBuilder(nil)
+
+	prompt, err := b.Build(repoPath, commits[5], 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(prompt, BlameHeader) {
+		t.Error("blame context should be off by default")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte("blame_context = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompt, err = b.Build(repoPath, commits[5], 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(prompt, BlameHeader) {
+		t.Fatal("expected blame context when blame_context is set")
+	}
+	if !strings.Contains(prompt, "- file.txt:1: last changed ") || !strings.Contains(prompt, "by Test (1)") {
+		t.Errorf("expected a blame summary for file.txt, got:\n%s", prompt)
+	}
+}
@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromptSection is one part of a built prompt, from a heading to the next,
+// and its size
+type PromptSection struct {
+	Heading string // Heading text without the "#"s, or "" before the first
+	Level   int    // 2 for "##", 3 for "###", 0 before the first heading
+	Bytes   int
+	Tokens  int
+}
+
+// Breakdown splits a built prompt at its "##" and "###" headings and
+// measures each part. Headings inside code blocks or untrusted content,
+// such as a context file's own, don't start a section. Tokens are estimated
+// with the budget's tokenizer, or the default one for byte budgets.
+func (p PromptBudget) Breakdown(prompt string) []PromptSection {
+	tokenizer := p.Tokenizer
+	if tokenizer == nil {
+		tokenizer = defaultTokenizer
+	}
+
+	var sections []PromptSection
+	var text strings.Builder
+	current := PromptSection{}
+	flush := func() {
+		if text.Len() > 0 || current.Level > 0 {
+			current.Bytes = text.Len()
+			current.Tokens = tokenizer.CountTokens(text.String())
+			sections = append(sections, current)
+		}
+		text.Reset()
+	}
+
+	closing, inFence := "", false
+	for _, line := range strings.SplitAfter(prompt, "\n") {
+		switch {
+		case closing != "":
+			if strings.TrimSuffix(line, "\n") == closing {
+				closing = ""
+			}
+		case strings.HasPrefix(line, "<untrusted-content "):
+			if m := untrustedOpenPattern.FindStringSubmatch(line); m != nil {
+				closing = fmt.Sprintf("</untrusted-content boundary=%q>", m[1])
+			}
+		case strings.HasPrefix(line, "```"):
+			inFence = !inFence
+		case inFence:
+		case strings.HasPrefix(line, "## "), strings.HasPrefix(line, "### "):
+			flush()
+			level := strings.Index(line, " ")
+			current = PromptSection{Heading: strings.TrimSpace(line[level:]), Level: level}
+		}
+		text.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// Limit returns the budget and its unit, "tokens" or "bytes"
+func (p PromptBudget) Limit() (int, string) {
+	if p.Tokenizer != nil {
+		return p.limit(), "tokens"
+	}
+	return p.limit(), "bytes"
+}
+
+// Budget returns how large a prompt for the agent may be in the repo
+func (b *Builder) Budget(repoPath, agentName string) PromptBudget {
+	return b.promptBudget(repoPath, agentName)
+}
@@ -0,0 +1,30 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBreakdown(t *testing.T) {
+	p := "System prompt\n" +
+		"```\n## Not a section\n```\n" +
+		"## Context Documents\n\n" +
+		"### a.md\n\n" + wrapUntrusted("context file", "## Heading in the file\n") +
+		"## Current Commit\n\n### Diff\n\ndiff\n"
+
+	var got []string
+	total := 0
+	for _, s := range (PromptBudget{}).Breakdown(p) {
+		got = append(got, strings.Repeat("#", s.Level)+s.Heading)
+		total += s.Bytes
+		if s.Tokens == 0 {
+			t.Errorf("section %q: expected a token estimate", s.Heading)
+		}
+	}
+	if want := ",##Context Documents,###a.md,##Current Commit,###Diff"; strings.Join(got, ",") != want {
+		t.Errorf("got sections %q, want %q", strings.Join(got, ","), want)
+	}
+	if total != len(p) {
+		t.Errorf("expected the sections to cover all %d bytes, got %d", len(p), total)
+	}
+}
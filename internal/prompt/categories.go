@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// FindingCategoriesHeader introduces a repo's own finding categories; %s is
+// the list of default categories
+const FindingCategoriesHeader = `
+## Finding Categories
+
+Besides %s, this repository wants its changes reviewed for the categories
+below. Give every finding in the JSON findings block a "category" naming the
+category it falls under, using the names as written here or the defaults.
+`
+
+// criteriaItemPattern matches an item of a system prompt's numbered list of
+// review criteria, such as "1. **Bugs**: ..."
+var criteriaItemPattern = regexp.MustCompile(`^(\d+)\. \*\*[^*]+\*\*`)
+
+// categoryPromptTypes are the prompt types whose criteria list takes a
+// repo's categories; specialized reviews keep their own focus
+var categoryPromptTypes = map[string]bool{"review": true, "range": true, "dirty": true}
+
+// withFindingCategories appends the repo's categories to the criteria list
+// of a general review's system prompt, continuing its numbering. Prompts
+// without such a list, such as repo templates, are returned unchanged; the
+// categories section still lists the categories for them.
+func withFindingCategories(systemPrompt, promptType string, categories []config.FindingCategory) string {
+	categories = config.NormalizeCategories(categories)
+	if len(categories) == 0 || !categoryPromptTypes[promptType] {
+		return systemPrompt
+	}
+
+	lines := strings.Split(systemPrompt, "\n")
+	last, number := -1, 0
+	for i, line := range lines {
+		m := criteriaItemPattern.FindStringSubmatch(line)
+		if m == nil {
+			if last >= 0 {
+				break
+			}
+			continue
+		}
+		last = i
+		number, _ = strconv.Atoi(m[1])
+	}
+	if last < 0 {
+		return systemPrompt
+	}
+
+	items := make([]string, 0, len(categories))
+	for _, c := range categories {
+		number++
+		item := fmt.Sprintf("%d. **%s**", number, capitalize(c.Name))
+		if c.Description != "" {
+			item += ": " + c.Description
+		}
+		items = append(items, item)
+	}
+	lines = append(lines[:last+1], append(items, lines[last+1:]...)...)
+	return strings.Join(lines, "\n")
+}
+
+// writeFindingCategories lists the repo's finding categories, if it defines
+// any, and asks for each finding's category in the JSON block
+func (b *Builder) writeFindingCategories(sb *strings.Builder, categories []config.FindingCategory) {
+	categories = config.NormalizeCategories(categories)
+	if len(categories) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, FindingCategoriesHeader, strings.Join(config.DefaultFindingCategories, ", "))
+	sb.WriteString("\n")
+	for _, c := range categories {
+		if c.Description != "" {
+			fmt.Fprintf(sb, "- **%s**: %s\n", c.Name, c.Description)
+		} else {
+			fmt.Fprintf(sb, "- **%s**\n", c.Name)
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,64 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestBuildPromptWithFindingCategories(t *testing.T) {
+	repoPath, commits := setupTestRepo(t)
+	targetSHA := commits[len(commits)-1]
+
+	configContent := `
+[[categories]]
+name = "Compliance"
+description = "Changes that conflict with data retention or licensing rules"
+
+[[categories]]
+name = "telemetry"
+
+[[categories]]
+name = "Security"
+`
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	prompt, err := BuildSimple(repoPath, targetSHA, "")
+	if err != nil {
+		t.Fatalf("BuildSimple failed: %v", err)
+	}
+	if !strings.Contains(prompt, "5. **Code quality**: Duplication that should be refactored, overly complex logic, unclear naming\n"+
+		"6. **Compliance**: Changes that conflict with data retention or licensing rules\n7. **Telemetry**\n") {
+		t.Errorf("Prompt should add the categories to the criteria list:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "## Finding Categories") || !strings.Contains(prompt, "- **compliance**: Changes that conflict") {
+		t.Errorf("Prompt should ask for each finding's category:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "8. **") || strings.Contains(prompt, "- **security**") {
+		t.Error("A category repeating a default should be left out")
+	}
+
+	security, err := NewBuilder(nil).Build(repoPath, targetSHA, 0, 0, "test", "security")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(security, "**Compliance**: Changes") {
+		t.Error("Specialized reviews should keep their own criteria")
+	}
+}
+
+func TestWithFindingCategoriesWithoutCriteriaList(t *testing.T) {
+	categories := []config.FindingCategory{{Name: "telemetry"}}
+	custom := "Review this change carefully.\n\n1. A summary\n2. The issues"
+	if got := withFindingCategories(custom, "review", categories); got != custom {
+		t.Errorf("expected a prompt without a criteria list unchanged, got %q", got)
+	}
+	if got := withFindingCategories(SystemPromptSingle, "review", nil); got != SystemPromptSingle {
+		t.Error("expected the prompt unchanged without categories")
+	}
+}
@@ -0,0 +1,65 @@
+package prompt
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// CIStatusHeader introduces the CI results for the reviewed commit
+const CIStatusHeader = `### CI Results
+
+The repo's CI has already run on this commit. Use its status and logs to
+see what broke, connect failures to the diff where you can, and don't
+report a failure CI already shows unless you can explain its cause.
+`
+
+// ciStatusTimeout bounds the CI status command
+const ciStatusTimeout = 2 * time.Minute
+
+// maxCIStatusOutput caps how much CI output goes in the prompt. The end is
+// kept since that is where failing steps usually log their errors.
+const maxCIStatusOutput = 16 * 1024
+
+// runCIStatus runs command with {sha} replaced by the commit and returns
+// what it prints, whatever its exit status. A command that can't be run
+// returns "".
+func runCIStatus(ctx context.Context, repoPath, command, sha string) string {
+	command = strings.ReplaceAll(command, "{sha}", shellQuote(sha))
+	output, _, err := runShellCommand(ctx, repoPath, command, ciStatusTimeout)
+	if err != nil {
+		log.Printf("ci status: %v", err)
+		return ""
+	}
+	output = strings.TrimSpace(output)
+	if len(output) > maxCIStatusOutput {
+		output = "(truncated) ...\n" + output[len(output)-maxCIStatusOutput:]
+	}
+	return output
+}
+
+// writeCIStatus runs the repo's CI status command for the commit at ref
+// and adds its output to the prompt
+func (b *Builder) writeCIStatus(sb *strings.Builder, repoPath, ref string) {
+	repoCfg, err := config.LoadRepoConfig(repoPath)
+	if err != nil || repoCfg == nil || repoCfg.CIStatusCommand == "" {
+		return
+	}
+	sha, err := git.ResolveSHA(repoPath, ref)
+	if err != nil {
+		return
+	}
+	output := runCIStatus(b.context(), repoPath, repoCfg.CIStatusCommand, sha)
+	if output == "" {
+		return
+	}
+
+	sb.WriteString(CIStatusHeader)
+	sb.WriteString("\n")
+	sb.WriteString(wrapUntrusted("ci log", "```\n"+output+"\n```\n"))
+	sb.WriteString("\n")
+}
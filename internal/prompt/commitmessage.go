@@ -0,0 +1,40 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommitMessageReviewHeader asks for a commit message assessment in its own
+// section, for repos that set review_commit_message
+const CommitMessageReviewHeader = `
+## Commit Message Review
+
+This repository also wants its commit messages reviewed; this takes precedence over any
+instruction above to ignore them. After your review of the code, add a "## Commit Message"
+section assessing %s:
+
+- **Conventional Commits**: whether the subject follows "type(scope): description" with a
+  standard type (feat, fix, docs, style, refactor, perf, test, build, ci, chore, revert),
+  is in the imperative mood, and marks breaking changes with "!" or a BREAKING CHANGE footer
+- **Body completeness**: whether the body explains what changed and why, and mentions
+  breaking changes, migrations, or follow-up work the diff implies
+- **Accuracy**: whether the message matches what the diff actually does
+
+Suggest a corrected message where one falls short. Keep commit message problems out of the
+findings list and the machine-readable findings block; report them only in this section.
+`
+
+// writeCommitMessageReview asks for the commit message section when the repo
+// opts in. rangeReview selects wording for the messages of several commits.
+func (b *Builder) writeCommitMessageReview(sb *strings.Builder, enabled, rangeReview bool) {
+	if !enabled {
+		return
+	}
+	subject := "the commit message shown with the commit below"
+	if rangeReview {
+		subject = "each commit message listed in the range below"
+	}
+	fmt.Fprintf(sb, CommitMessageReviewHeader, subject)
+	sb.WriteString("\n")
+}
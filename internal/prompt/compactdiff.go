@@ -0,0 +1,61 @@
+package prompt
+
+import (
+	"log"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// compactDiffContext is the context lines a compact diff keeps around each
+// change
+const compactDiffContext = 1
+
+// CompactDiffNote tells the agent a diff was regenerated with less context
+const CompactDiffNote = "(Shown with one line of context around each change to fit the prompt budget. Read the files for more context.)\n\n"
+
+// diffSection formats a diff under heading, fenced as untrusted content,
+// with an optional note between them
+func diffSection(heading, note, diff string) string {
+	var block strings.Builder
+	block.WriteString("```diff\n")
+	block.WriteString(diff)
+	if !strings.HasSuffix(diff, "\n") {
+		block.WriteString("\n")
+	}
+	block.WriteString("```\n")
+
+	var section strings.Builder
+	section.WriteString(heading + "\n\n")
+	section.WriteString(note)
+	section.WriteString(wrapUntrusted("diff", block.String()))
+	return section.String()
+}
+
+// compactDiff regenerates a diff with compactDiffContext lines of context
+// using regenerate, keeping only the files in full, the diff left after
+// omitFiles. It returns "" when the repo turns compact diffs off or the
+// diff can't be regenerated.
+func compactDiff(repoPath, full string, regenerate func(contextLines int) (string, error)) string {
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil &&
+		repoCfg.CompactDiff != nil && !*repoCfg.CompactDiff {
+		return ""
+	}
+	diff, err := regenerate(compactDiffContext)
+	if err != nil {
+		log.Printf("compact diff: %v", err)
+		return ""
+	}
+
+	kept := make(map[string]bool)
+	for _, f := range splitDiff(full) {
+		kept[f.Path] = true
+	}
+	var sb strings.Builder
+	for _, f := range splitDiff(diff) {
+		if kept[f.Path] {
+			sb.WriteString(f.Text)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,104 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildPromptCompactsDiffNearBudget(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	commit := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoPath, "big.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", "big.go"}, {"commit", "-q", "-m", "edit big.go"}} {
+			if out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+	var before, after strings.Builder
+	for i := range 400 {
+		fmt.Fprintf(&before, "var v%03d = %d // unchanged context line\n", i, i)
+		if i%20 == 10 {
+			fmt.Fprintf(&after, "var v%03d = %d // edited\n", i, i*2)
+		} else {
+			fmt.Fprintf(&after, "var v%03d = %d // unchanged context line\n", i, i)
+		}
+	}
+	commit(before.String())
+	commit(after.String())
+
+	setConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	build := func() string {
+		t.Helper()
+		p, err := NewBuilder(nil).Build(repoPath, "HEAD", 0, 0, "test", "")
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		return p
+	}
+
+	full := build()
+	if strings.Contains(full, CompactDiffNote) {
+		t.Fatal("expected the full diff when it fits")
+	}
+
+	// Just too small for the full diff, with room for the compact one
+	setConfig(fmt.Sprintf("max_prompt_size = %d\n", len(full)-200))
+	compact := build()
+	if !strings.Contains(compact, CompactDiffNote) {
+		t.Fatalf("expected a compact diff:\n%s", compact)
+	}
+	if !strings.Contains(compact, "+var v010 = 20 // edited") || strings.Contains(compact, "var v007 ") {
+		t.Errorf("expected one line of context around each change:\n%s", compact)
+	}
+
+	setConfig(fmt.Sprintf("max_prompt_size = %d\ncompact_diff = false\n", len(full)-200))
+	if p := build(); !strings.Contains(p, "(Diff too large to include") || strings.Contains(p, CompactDiffNote) {
+		t.Errorf("expected the diff to be left out with compact_diff off:\n%s", p)
+	}
+}
+
+func TestBuildPromptDropsContextBeforeDiff(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	writeContextTestFiles(t, repoPath, map[string]string{
+		".roborev.toml": "context_files = [\"NOTES.md\"]\ncontext_files_max_bytes = 10000\n",
+		"NOTES.md":      strings.Repeat("Project notes for reviewers.\n", 100),
+	})
+	build := func() string {
+		t.Helper()
+		p, err := NewBuilder(nil).Build(repoPath, "HEAD", 0, 0, "test", "")
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		return p
+	}
+
+	full := build()
+	if !strings.Contains(full, "### NOTES.md") || !strings.Contains(full, "### Diff") {
+		t.Fatalf("expected the context file and the diff when both fit:\n%s", full)
+	}
+
+	// Room for the diff, but not the context file alongside it
+	writeContextTestFiles(t, repoPath, map[string]string{
+		".roborev.toml": fmt.Sprintf("context_files = [\"NOTES.md\"]\ncontext_files_max_bytes = 10000\nmax_prompt_size = %d\ncompact_diff = false\n", len(full)-500),
+	})
+	p := build()
+	if strings.Contains(p, "### NOTES.md") {
+		t.Errorf("expected the context file to be left out:\n%s", p)
+	}
+	if strings.Contains(p, "(Diff too large to include") || !strings.Contains(p, "```diff") {
+		t.Errorf("expected the diff to be kept:\n%s", p)
+	}
+}
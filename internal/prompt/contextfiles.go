@@ -0,0 +1,311 @@
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// ContextFilesHeader introduces the documents a repo gives every review
+const ContextFilesHeader = `## Context Documents
+
+The repository provides these documents as background for every review. Use
+them to understand its conventions and past decisions.
+`
+
+// maxContextFileBytes caps one context file
+const maxContextFileBytes = 32 * 1024
+
+// contextFilesBudgetShare is the fraction of the prompt budget context files
+// may use when context_files_max_bytes isn't set, as its denominator
+const contextFilesBudgetShare = 4
+
+// contextURLTimeout bounds fetching one remote context file
+const contextURLTimeout = 10 * time.Second
+
+// contextURLCacheTTL is how long a fetched context file is used before it's
+// fetched again. A stale copy is still used when fetching fails.
+const contextURLCacheTTL = time.Hour
+
+// contextHTTPClient fetches remote context files. Replaced in tests.
+var contextHTTPClient = &http.Client{Timeout: contextURLTimeout}
+
+// contextEntry is one context file's contents
+type contextEntry struct {
+	Source  string // Repo-relative path or URL
+	Content string
+}
+
+// maxContextCacheEntries caps the files a ContextFileCache holds
+const maxContextCacheEntries = 1024
+
+// ContextFileCache keeps context file contents between prompt builds, so
+// repos with many context files aren't read from disk for every review.
+// Entries are keyed by path and reread when the file's size or modification
+// time changes. It's safe for concurrent use.
+type ContextFileCache struct {
+	mu    sync.Mutex
+	files map[string]cachedContextFile
+}
+
+// cachedContextFile is a context file as of its size and modification time
+type cachedContextFile struct {
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// NewContextFileCache creates an empty context file cache
+func NewContextFileCache() *ContextFileCache {
+	return &ContextFileCache{files: make(map[string]cachedContextFile)}
+}
+
+// read returns the start of the file at path, described by info, from the
+// cache while the file is unchanged
+func (c *ContextFileCache) read(path string, info os.FileInfo) ([]byte, error) {
+	c.mu.Lock()
+	cached, ok := c.files[path]
+	c.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.data, nil
+	}
+
+	data, err := readContextFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.files) >= maxContextCacheEntries {
+		// Start over rather than track recency; a full cache is rare
+		c.files = make(map[string]cachedContextFile)
+	}
+	c.files[path] = cachedContextFile{size: info.Size(), modTime: info.ModTime(), data: data}
+	return data, nil
+}
+
+// readContextFile reads at most one byte more of a file than a context file
+// may use, so oversized files are still truncated
+func readContextFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxContextFileBytes+1))
+}
+
+// collectContextEntries reads the repo's context files, expanding globs,
+// through cache when it isn't nil. Entries that can't be read are logged
+// and skipped. sortContextEntries puts them in prompt order.
+func collectContextEntries(repoPath string, patterns []string, cache *ContextFileCache) []contextEntry {
+	var entries []contextEntry
+	seen := make(map[string]bool)
+	add := func(source string, data []byte) {
+		if seen[source] {
+			return
+		}
+		seen[source] = true
+		entries = append(entries, contextEntry{Source: source, Content: string(data)})
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "":
+		case strings.HasPrefix(pattern, "https://"):
+			data, err := fetchContextURL(pattern)
+			if err != nil {
+				log.Printf("context_files: %v", err)
+				continue
+			}
+			add(pattern, data)
+		case strings.Contains(pattern, "://"):
+			log.Printf("context_files: %s: only https URLs are supported", pattern)
+		case !filepath.IsLocal(filepath.FromSlash(pattern)):
+			log.Printf("context_files: %s: must be relative to the repo root", pattern)
+		default:
+			matches, err := filepath.Glob(filepath.Join(repoPath, filepath.FromSlash(pattern)))
+			if err != nil {
+				log.Printf("context_files: %s: %v", pattern, err)
+				continue
+			}
+			root, _ := filepath.EvalSymlinks(repoPath)
+			for _, match := range matches {
+				info, err := os.Stat(match)
+				if err != nil || !info.Mode().IsRegular() {
+					continue
+				}
+				// A symlink in the repo mustn't pull in files from outside it
+				if resolved, err := filepath.EvalSymlinks(match); err != nil || !isWithin(root, resolved) {
+					log.Printf("context_files: %s is outside the repo", match)
+					continue
+				}
+				var data []byte
+				if cache != nil {
+					data, err = cache.read(match, info)
+				} else {
+					data, err = readContextFile(match)
+				}
+				if err != nil {
+					log.Printf("context_files: %v", err)
+					continue
+				}
+				rel, _ := filepath.Rel(repoPath, match)
+				add(filepath.ToSlash(rel), data)
+			}
+		}
+	}
+	return entries
+}
+
+// contextPriority is the weight of a context file: the highest of the
+// priorities whose pattern names it or matches its path, or 0
+func contextPriority(source string, priorities map[string]int) int {
+	weight, matched := 0, false
+	for pattern, w := range priorities {
+		ok := pattern == source
+		if !ok && !strings.Contains(pattern, "://") {
+			ok, _ = path.Match(pattern, source)
+		}
+		if ok && (!matched || w > weight) {
+			weight, matched = w, true
+		}
+	}
+	return weight
+}
+
+// sortContextEntries orders context files by descending priority, then by
+// path or URL, so prompts are the same on every platform whatever order
+// the config or file system lists them in
+func sortContextEntries(entries []contextEntry, priorities map[string]int) {
+	weights := make(map[string]int, len(entries))
+	for _, e := range entries {
+		weights[e.Source] = contextPriority(e.Source, priorities)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if weights[a.Source] != weights[b.Source] {
+			return weights[a.Source] > weights[b.Source]
+		}
+		return a.Source < b.Source
+	})
+}
+
+// isWithin reports whether path is root or inside it
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// contextCachePath is where a remote context file is cached
+func contextCachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(config.DataDir(), "cache", "context", hex.EncodeToString(sum[:]))
+}
+
+// fetchContextURL returns a remote context file, from the cache while it's
+// fresh. When fetching fails, a stale cached copy is returned instead.
+func fetchContextURL(url string) ([]byte, error) {
+	cachePath := contextCachePath(url)
+	info, statErr := os.Stat(cachePath)
+	if statErr == nil && time.Since(info.ModTime()) < contextURLCacheTTL {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			return data, nil
+		}
+	}
+
+	data, err := downloadContextURL(url)
+	if err != nil {
+		if statErr == nil {
+			if cached, readErr := os.ReadFile(cachePath); readErr == nil {
+				log.Printf("context_files: %v; using the copy cached %s", err, info.ModTime().Format(time.RFC3339))
+				return cached, nil
+			}
+		}
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err == nil {
+		if err := os.WriteFile(cachePath, data, 0600); err != nil {
+			log.Printf("context_files: caching %s: %v", url, err)
+		}
+	}
+	return data, nil
+}
+
+// downloadContextURL fetches url, keeping at most one byte more than a
+// context file may use so oversized files are still truncated
+func downloadContextURL(url string) ([]byte, error) {
+	resp, err := contextHTTPClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxContextFileBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// writeContextFiles includes the repo's context files, fenced like other
+// untrusted content, while they fit in their share of the budget
+func (b *Builder) writeContextFiles(sb *strings.Builder, repoPath, agentName string) {
+	repoCfg, err := config.LoadRepoConfig(repoPath)
+	if err != nil || repoCfg == nil || len(repoCfg.ContextFiles) == 0 {
+		return
+	}
+	budget := b.promptBudget(repoPath, agentName)
+	fits := func(section string) bool {
+		if repoCfg.ContextFilesMaxBytes > 0 {
+			return len(section) <= repoCfg.ContextFilesMaxBytes
+		}
+		return budget.size(section) <= budget.limit()/contextFilesBudgetShare
+	}
+
+	var section strings.Builder
+	entries := collectContextEntries(repoPath, repoCfg.ContextFiles, b.contextCache)
+	sortContextEntries(entries, repoCfg.ContextFilePriorities)
+	for _, e := range entries {
+		content := e.Content
+		if len(content) > maxContextFileBytes {
+			// Cut at a line break, which is also a character boundary
+			cut := strings.LastIndexByte(content[:maxContextFileBytes], '\n')
+			content = content[:cut+1] + "... (truncated)\n"
+		}
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		var entry strings.Builder
+		fmt.Fprintf(&entry, "### %s\n\n", e.Source)
+		entry.WriteString(wrapUntrusted("context file", "```\n"+content+"```\n"))
+		entry.WriteString("\n")
+		if !fits(section.String()+entry.String()) || !budget.Fits(sb.String(), ContextFilesHeader, section.String(), entry.String()) {
+			log.Printf("context_files: %s doesn't fit in the prompt budget, leaving it and later files out", e.Source)
+			break
+		}
+		section.WriteString(entry.String())
+	}
+	if section.Len() == 0 {
+		return
+	}
+	sb.WriteString(ContextFilesHeader)
+	sb.WriteString("\n")
+	sb.WriteString(section.String())
+}
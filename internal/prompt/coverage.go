@@ -0,0 +1,241 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// CoverageHeader introduces coverage of the changed files
+const CoverageHeader = `### Test Coverage
+
+Coverage of the changed files, from the repo's test coverage profiles. Base
+testing-gap findings on this data: the untested changed lines below are not
+executed by any test.
+`
+
+// fileCoverage records, per line, whether any test executed it. Lines the
+// profile doesn't instrument are absent.
+type fileCoverage map[int]bool
+
+// coverageResult summarizes coverage of one changed file
+type coverageResult struct {
+	Path            string
+	Covered, Lines  int   // Instrumented lines in the whole file
+	ChangedCovered  int   // Instrumented added lines that ran
+	ChangedLines    int   // Instrumented added lines
+	UntestedChanges []int // Added lines that no test ran
+}
+
+// addedLines returns the new-file line numbers of each file's added lines
+func addedLines(diff string) map[string][]int {
+	added := make(map[string][]int)
+	for _, f := range git.ParseDiff(diff) {
+		if f.NewPath == "" {
+			continue
+		}
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Kind == '+' {
+					added[f.NewPath] = append(added[f.NewPath], l.NewLine)
+				}
+			}
+		}
+	}
+	return added
+}
+
+// loadCoverage reads the coverage profiles, which may be Go cover profiles
+// or LCOV tracefiles, relative to repoPath. Profiles that can't be read
+// are logged and skipped.
+func loadCoverage(repoPath string, profiles []string) map[string]fileCoverage {
+	coverage := make(map[string]fileCoverage)
+	for _, p := range profiles {
+		path := p
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoPath, filepath.FromSlash(p))
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("coverage: %v", err)
+			continue
+		}
+		err = parseCoverage(f, repoPath, coverage)
+		f.Close()
+		if err != nil {
+			log.Printf("coverage: %s: %v", p, err)
+		}
+	}
+	return coverage
+}
+
+// parseCoverage adds the coverage in a Go cover profile or LCOV tracefile
+// to coverage, keyed by the file paths the profile uses
+func parseCoverage(r io.Reader, repoPath string, coverage map[string]fileCoverage) error {
+	mark := func(file string, line int, hit bool) {
+		fc := coverage[file]
+		if fc == nil {
+			fc = make(fileCoverage)
+			coverage[file] = fc
+		}
+		fc[line] = fc[line] || hit
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lcovFile string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "mode:"):
+		case strings.HasPrefix(line, "SF:"):
+			lcovFile = strings.TrimPrefix(line, "SF:")
+			if rel, err := filepath.Rel(repoPath, lcovFile); err == nil && filepath.IsAbs(lcovFile) && !strings.HasPrefix(rel, "..") {
+				lcovFile = filepath.ToSlash(rel)
+			}
+		case strings.HasPrefix(line, "DA:"):
+			n, hits, ok := strings.Cut(strings.TrimPrefix(line, "DA:"), ",")
+			ln, err := strconv.Atoi(n)
+			if !ok || err != nil || lcovFile == "" {
+				continue
+			}
+			hits, _, _ = strings.Cut(hits, ",")
+			count, _ := strconv.Atoi(hits)
+			mark(lcovFile, ln, count > 0)
+		case line == "end_of_record":
+			lcovFile = ""
+		case lcovFile == "" && strings.Contains(line, ".go:"):
+			// file.go:startLine.startCol,endLine.endCol numStmts count
+			file, rest, _ := strings.Cut(line, ".go:")
+			fields := strings.Fields(rest)
+			if len(fields) != 3 {
+				return fmt.Errorf("invalid cover profile line %q", line)
+			}
+			span, end, _ := strings.Cut(fields[0], ",")
+			startLine, _, _ := strings.Cut(span, ".")
+			endLine, _, _ := strings.Cut(end, ".")
+			from, err1 := strconv.Atoi(startLine)
+			to, err2 := strconv.Atoi(endLine)
+			count, err3 := strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil || err3 != nil {
+				return fmt.Errorf("invalid cover profile line %q", line)
+			}
+			for ln := from; ln <= to; ln++ {
+				mark(file+".go", ln, count > 0)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// coverageFor finds a changed file's coverage. Profiles name files by
+// repo-relative path, absolute path, or Go import path, so any profile path
+// ending in the changed file's path matches.
+func coverageFor(coverage map[string]fileCoverage, file string) fileCoverage {
+	if fc, ok := coverage[file]; ok {
+		return fc
+	}
+	// Check in path order so a file matching several profile paths always
+	// gets the same one
+	paths := make([]string, 0, len(coverage))
+	for p := range coverage {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if strings.HasSuffix(p, "/"+file) {
+			return coverage[p]
+		}
+	}
+	return nil
+}
+
+// coverageResults summarizes coverage of each changed file found in a
+// profile, in path order
+func coverageResults(coverage map[string]fileCoverage, diff string) []coverageResult {
+	var results []coverageResult
+	for file, lines := range addedLines(diff) {
+		fc := coverageFor(coverage, file)
+		if fc == nil {
+			continue
+		}
+		r := coverageResult{Path: file, Lines: len(fc)}
+		for _, hit := range fc {
+			if hit {
+				r.Covered++
+			}
+		}
+		for _, ln := range lines {
+			hit, instrumented := fc[ln]
+			if !instrumented {
+				continue
+			}
+			r.ChangedLines++
+			if hit {
+				r.ChangedCovered++
+			} else {
+				r.UntestedChanges = append(r.UntestedChanges, ln)
+			}
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results
+}
+
+// lineRanges renders sorted line numbers compactly, e.g. "3-5, 9"
+func lineRanges(lines []int) string {
+	var parts []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(lines[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeCoverage adds coverage of the changed files to the prompt
+func writeCoverage(sb *strings.Builder, results []coverageResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	sb.WriteString(CoverageHeader)
+	sb.WriteString("\n")
+	for _, r := range results {
+		pct := 0
+		if r.Lines > 0 {
+			pct = r.Covered * 100 / r.Lines
+		}
+		fmt.Fprintf(sb, "- %s: %d%% of lines covered; %d of %d changed lines covered\n", r.Path, pct, r.ChangedCovered, r.ChangedLines)
+	}
+
+	var untested []coverageResult
+	for _, r := range results {
+		if len(r.UntestedChanges) > 0 {
+			untested = append(untested, r)
+		}
+	}
+	if len(untested) > 0 {
+		sb.WriteString("\n#### Untested Changed Lines\n\n")
+		for _, r := range untested {
+			fmt.Fprintf(sb, "- %s: %s\n", r.Path, lineRanges(r.UntestedChanges))
+		}
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,95 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const coverageDiff = `diff --git a/pkg/calc.go b/pkg/calc.go
+--- a/pkg/calc.go
++++ b/pkg/calc.go
+@@ -2,4 +2,6 @@
+ func Add(a, b int) int {
+-	return a + b
++	if a < 0 {
++		return 0
++	}
++	return a + b
+ }
+diff --git a/web/app.js b/web/app.js
+--- a/web/app.js
++++ b/web/app.js
+@@ -10,2 +10,3 @@
+ const x = 1;
++const y = 2;
+ export default x;
+`
+
+const goCoverProfile = `mode: set
+example.com/mod/pkg/calc.go:2.24,3.12 1 1
+example.com/mod/pkg/calc.go:3.12,5.3 1 0
+example.com/mod/pkg/calc.go:6.2,6.14 1 1
+`
+
+func TestAddedLines(t *testing.T) {
+	added := addedLines(coverageDiff)
+	if got := added["pkg/calc.go"]; len(got) != 4 || got[0] != 3 || got[3] != 6 {
+		t.Errorf("pkg/calc.go added lines = %v, want [3 4 5 6]", got)
+	}
+	if got := added["web/app.js"]; len(got) != 1 || got[0] != 11 {
+		t.Errorf("web/app.js added lines = %v, want [11]", got)
+	}
+}
+
+func TestCoverageResults(t *testing.T) {
+	repoPath := t.TempDir()
+	lcov := "TN:\nSF:" + filepath.Join(repoPath, "web", "app.js") + "\nDA:10,1\nDA:11,0\nDA:12,1\nend_of_record\n"
+
+	coverage := make(map[string]fileCoverage)
+	if err := parseCoverage(strings.NewReader(goCoverProfile), repoPath, coverage); err != nil {
+		t.Fatal(err)
+	}
+	if err := parseCoverage(strings.NewReader(lcov), repoPath, coverage); err != nil {
+		t.Fatal(err)
+	}
+
+	results := coverageResults(coverage, coverageDiff)
+	if len(results) != 2 {
+		t.Fatalf("expected results for both files, got %+v", results)
+	}
+	// Line 3 is covered by the first block, 4-5 only by the unexecuted one
+	calc := results[0]
+	if calc.Path != "pkg/calc.go" || calc.ChangedLines != 4 || calc.ChangedCovered != 2 || lineRanges(calc.UntestedChanges) != "4-5" {
+		t.Errorf("pkg/calc.go: %+v", calc)
+	}
+	app := results[1]
+	if app.Path != "web/app.js" || app.Covered != 2 || app.Lines != 3 || lineRanges(app.UntestedChanges) != "11" {
+		t.Errorf("web/app.js: %+v", app)
+	}
+}
+
+func TestBuildPromptWithCoverage(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repoPath, "cover.out"), []byte(goCoverProfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(`coverage_profiles = ["cover.out", "missing.info"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt, err := NewBuilder(nil).BuildDirty(repoPath, coverageDiff, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	for _, want := range []string{
+		"### Test Coverage",
+		"- pkg/calc.go: 60% of lines covered; 2 of 4 changed lines covered",
+		"#### Untested Changed Lines\n\n- pkg/calc.go: 4-5",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in prompt:\n%s", want, prompt)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package prompt
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// dirGuidelines are the review guidelines a subdirectory's .roborev.toml
+// sets for the code under it
+type dirGuidelines struct {
+	Dir        string // Slash-separated and relative to the repo root
+	Guidelines string
+}
+
+// nestedGuidelines returns the review guidelines set by .roborev.toml files
+// in the directories holding the changed files, parents before their
+// subdirectories. The repo root's own config isn't included.
+func nestedGuidelines(repoPath string, files []string) []dirGuidelines {
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		dir := path.Dir(path.Clean(filepath.ToSlash(f)))
+		for dir != "." && dir != "/" && dir != ".." && !strings.HasPrefix(dir, "../") {
+			if dirs[dir] {
+				break
+			}
+			dirs[dir] = true
+			dir = path.Dir(dir)
+		}
+	}
+
+	var nested []dirGuidelines
+	for dir := range dirs {
+		repoCfg, err := config.LoadRepoConfig(filepath.Join(repoPath, filepath.FromSlash(dir)))
+		if err != nil || repoCfg == nil || strings.TrimSpace(repoCfg.ReviewGuidelines) == "" {
+			continue
+		}
+		nested = append(nested, dirGuidelines{Dir: dir, Guidelines: repoCfg.ReviewGuidelines})
+	}
+	sort.Slice(nested, func(i, j int) bool { return nested[i].Dir < nested[j].Dir })
+	return nested
+}
+
+// targetFiles returns the files a commit or range changes, or nil if git
+// can't list them
+func targetFiles(repoPath, gitRef string) []string {
+	var files []string
+	var err error
+	if git.IsRange(gitRef) {
+		files, err = git.GetRangeFilesChanged(repoPath, gitRef)
+	} else {
+		files, err = git.GetFilesChanged(repoPath, gitRef)
+	}
+	if err != nil {
+		return nil
+	}
+	return files
+}
@@ -0,0 +1,84 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGuidelines(t *testing.T, dir, guidelines string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "review_guidelines = \"\"\"\n" + guidelines + "\n\"\"\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".roborev.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNestedGuidelines(t *testing.T) {
+	repoPath := t.TempDir()
+	writeGuidelines(t, repoPath, "Root rules.")
+	writeGuidelines(t, filepath.Join(repoPath, "services"), "Services rules.")
+	writeGuidelines(t, filepath.Join(repoPath, "services", "api"), "API rules.")
+	writeGuidelines(t, filepath.Join(repoPath, "web"), "Web rules.")
+	if err := os.MkdirAll(filepath.Join(repoPath, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := nestedGuidelines(repoPath, []string{
+		"services/api/handler.go",
+		"services/api/routes.go",
+		"docs/README.md",
+		"main.go",
+		"../outside/file.go",
+	})
+	var dirs []string
+	for _, g := range got {
+		dirs = append(dirs, g.Dir)
+	}
+	if strings.Join(dirs, ",") != "services,services/api" {
+		t.Fatalf("expected guidelines for services and services/api, got %v", dirs)
+	}
+	if strings.TrimSpace(got[1].Guidelines) != "API rules." {
+		t.Errorf("unexpected guidelines for services/api: %q", got[1].Guidelines)
+	}
+}
+
+func TestBuildPromptMergesNestedGuidelines(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	runGit := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	writeGuidelines(t, filepath.Join(repoPath, "billing"), "Amounts are integer cents.")
+	writeGuidelines(t, filepath.Join(repoPath, "search"), "Queries must be paginated.")
+	if err := os.WriteFile(filepath.Join(repoPath, "billing", "invoice.go"), []byte("package billing\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("add", "billing/invoice.go")
+	runGit("commit", "-m", "add invoice")
+	sha := runGit("rev-parse", "HEAD")
+
+	prompt, err := BuildSimple(repoPath, sha, "")
+	if err != nil {
+		t.Fatalf("BuildSimple failed: %v", err)
+	}
+	if !strings.Contains(prompt, "## Project Guidelines") {
+		t.Error("Prompt should contain project guidelines section without a root config")
+	}
+	if !strings.Contains(prompt, "For changes under `billing/`:\nAmounts are integer cents.") {
+		t.Error("Prompt should contain the billing guidelines")
+	}
+	if strings.Contains(prompt, "Queries must be paginated") {
+		t.Error("Prompt should not contain guidelines for untouched directories")
+	}
+}
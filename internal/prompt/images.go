@@ -0,0 +1,111 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // Decoders for imageInfo
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// imageExts are the image formats whose dimensions can be read
+var imageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+// imageDescribeTimeout bounds each run of the image description command
+const imageDescribeTimeout = time.Minute
+
+// maxImageDescription caps how much of a description goes in the prompt
+const maxImageDescription = 1024
+
+// imageInfo returns an image's format and dimensions, e.g. "PNG 800x600",
+// or "" if it can't be decoded
+func imageInfo(data []byte) string {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %dx%d", strings.ToUpper(format), cfg.Width, cfg.Height)
+}
+
+// readVersion reads a file at ref, or from the working tree when ref is
+// empty
+func readVersion(repoPath, ref, file string) ([]byte, error) {
+	if ref == "" {
+		return os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(file)))
+	}
+	return git.ReadFile(repoPath, ref, file)
+}
+
+// describeImages adds the dimensions of changed images to their summaries,
+// and a description from the repo's image_description_command if set.
+// The command gets the new version of each image as {file}.
+func describeImages(ctx context.Context, repoPath, baseRef, targetRef string, files []omittedFile) {
+	var command string
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		command = repoCfg.ImageDescriptionCommand
+	}
+
+	for i := range files {
+		f := &files[i]
+		if f.Kind != "binary" || !imageExts[strings.ToLower(path.Ext(f.Path))] {
+			continue
+		}
+		var before, after string
+		if data, err := readVersion(repoPath, baseRef, f.Path); err == nil {
+			before = imageInfo(data)
+		}
+		data, err := readVersion(repoPath, targetRef, f.Path)
+		if err == nil {
+			after = imageInfo(data)
+		}
+		switch {
+		case before != "" && after != "" && before != after:
+			f.Image = before + " -> " + after
+		case after != "":
+			f.Image = after
+		default:
+			f.Image = before
+		}
+
+		if command != "" && err == nil {
+			f.Description = describeImage(ctx, repoPath, command, f.Path, data)
+		}
+	}
+}
+
+// describeImage runs command on a copy of the image and returns its output
+func describeImage(ctx context.Context, repoPath, command, file string, data []byte) string {
+	dir, err := os.MkdirTemp("", "roborev-image-")
+	if err != nil {
+		log.Printf("image description: %v", err)
+		return ""
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, path.Base(file))
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Printf("image description: %v", err)
+		return ""
+	}
+
+	output, failed, err := runShellCommand(ctx, repoPath, strings.ReplaceAll(command, "{file}", shellQuote(tmp)), imageDescribeTimeout)
+	if err != nil || failed {
+		log.Printf("image description for %s failed: %v %s", file, err, strings.TrimSpace(output))
+		return ""
+	}
+	description := strings.Join(strings.Fields(output), " ")
+	if len(description) > maxImageDescription {
+		description = description[:maxImageDescription] + "..."
+	}
+	return description
+}
@@ -0,0 +1,69 @@
+package prompt
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBuildPromptDescribesChangedImages(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("image description command uses sh")
+	}
+	repoPath, _ := setupTestRepo(t)
+	commit := func(content []byte) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoPath, "logo.png"), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", "logo.png"}, {"commit", "-q", "-m", "logo"}} {
+			if out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+		out, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	added := commit(encodePNG(t, 4, 2))
+	resized := commit(encodePNG(t, 8, 8))
+
+	prompt, err := NewBuilder(nil).Build(repoPath, added, 0, 0, "test", "design")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(prompt, "- logo.png: binary, added, ") || !strings.Contains(prompt, ", PNG 4x2\n") {
+		t.Errorf("expected dimensions of added image:\n%s", prompt)
+	}
+
+	toml := `image_description_command = "echo \"described $(basename {file})\""`
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompt, err = NewBuilder(nil).Build(repoPath, resized, 0, 0, "test", "design")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	for _, want := range []string{", PNG 4x2 -> PNG 8x8\n", "  Description: described logo.png\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in prompt:\n%s", want, prompt)
+		}
+	}
+}
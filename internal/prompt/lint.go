@@ -0,0 +1,152 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// LintHeader introduces linter output gathered before the review
+const LintHeader = `### Static Analysis
+
+These linters ran on the changed files before this review. Confirm which of
+their findings are real rather than repeating them as separate findings, and
+spend your review on issues linters can't catch.
+`
+
+// lintTimeout bounds each linter run
+const lintTimeout = 2 * time.Minute
+
+// maxLintOutput caps how much of each linter's output goes in the prompt
+const maxLintOutput = 16 * 1024
+
+// lintResult is one linter's output
+type lintResult struct {
+	Name   string
+	Output string
+}
+
+// matchingFiles returns the files matching any pattern, by base name or
+// full path. No patterns matches every file.
+func matchingFiles(files, patterns []string) []string {
+	if len(patterns) == 0 {
+		return files
+	}
+	var matched []string
+	for _, f := range files {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, path.Base(f)); ok {
+				matched = append(matched, f)
+				break
+			}
+			if ok, _ := path.Match(p, f); ok {
+				matched = append(matched, f)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// runLinters runs each linter that has matching changed files in repoPath
+// and returns the non-empty outputs. A linter that can't be run is logged
+// and skipped; a non-zero exit just means it found something.
+func runLinters(ctx context.Context, repoPath string, linters []config.LinterConfig, files []string) []lintResult {
+	var results []lintResult
+	for _, l := range linters {
+		if l.Command == "" {
+			continue
+		}
+		matched := matchingFiles(files, l.Patterns)
+		if len(matched) == 0 {
+			continue
+		}
+		name := l.Name
+		if name == "" {
+			name = l.Command
+		}
+
+		quoted := make([]string, len(matched))
+		for i, f := range matched {
+			quoted[i] = shellQuote(f)
+		}
+		command := strings.ReplaceAll(l.Command, "{files}", strings.Join(quoted, " "))
+
+		output, _, err := runShellCommand(ctx, repoPath, command, lintTimeout)
+		if err != nil {
+			log.Printf("lint: %s: %v", name, err)
+			continue
+		}
+		output = strings.TrimSpace(output)
+		if output == "" {
+			continue
+		}
+		if len(output) > maxLintOutput {
+			output = TruncateUTF8(output, maxLintOutput) + "\n... (truncated)"
+		}
+		results = append(results, lintResult{Name: name, Output: output})
+	}
+	return results
+}
+
+// runShellCommand runs command through the platform shell and returns its
+// combined output and whether it exited non-zero. Only failing to run the
+// command at all, including the shell's "cannot run" exit codes, is an
+// error. The command and everything it starts are killed when ctx is done
+// or after timeout.
+func runShellCommand(ctx context.Context, dir, command string, timeout time.Duration) (string, bool, error) {
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := agent.ShellCommand(runCtx, command)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	if runCtx.Err() != nil {
+		return "", false, fmt.Errorf("timed out after %s", timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code := exitErr.ExitCode(); code == 126 || code == 127 {
+			return "", false, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return string(output), true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(output), false, nil
+}
+
+// shellQuote quotes s as a single shell word: single quotes on all
+// platforms, with embedded quotes doubled for PowerShell or closed and
+// reopened for sh
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// writeLintResults adds linter output to the prompt
+func writeLintResults(sb *strings.Builder, results []lintResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	sb.WriteString(LintHeader)
+	sb.WriteString("\n")
+	for _, r := range results {
+		fmt.Fprintf(sb, "#### %s\n\n```\n%s\n```\n\n", r.Name, r.Output)
+	}
+}
@@ -0,0 +1,141 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+const lintDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package main
++package main // x
+diff --git a/docs/it's.md b/docs/it's.md
+new file mode 100644
+--- /dev/null
++++ b/docs/it's.md
+@@ -0,0 +1 @@
++hello
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package main
+`
+
+// setupLintRepo returns a test repo with the files lintDiff changes
+// committed, so the diff applies to it
+func setupLintRepo(t *testing.T) string {
+	t.Helper()
+	repoPath, _ := setupTestRepo(t)
+	for _, name := range []string{"main.go", "old.go"} {
+		if err := os.WriteFile(filepath.Join(repoPath, name), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"add", "main.go", "old.go"}, {"commit", "-q", "-m", "add go files"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return repoPath
+}
+
+func TestMatchingFiles(t *testing.T) {
+	got := git.ChangedFiles(lintDiff)
+	if strings.Join(got, ",") != "main.go,docs/it's.md" {
+		t.Errorf("ChangedFiles = %q", got)
+	}
+
+	if got := matchingFiles(got, []string{"*.go"}); len(got) != 1 || got[0] != "main.go" {
+		t.Errorf("matching *.go = %q", got)
+	}
+	if got := matchingFiles([]string{"docs/a.md", "b.md"}, []string{"docs/*"}); len(got) != 1 || got[0] != "docs/a.md" {
+		t.Errorf("matching docs/* = %q", got)
+	}
+}
+
+func TestBuildPromptWithLinters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("linter commands use sh")
+	}
+	repoPath := setupLintRepo(t)
+	toml := `[[linters]]
+name = "fake-vet"
+command = "for f in {files}; do echo \"$f:1: suspicious\"; done; exit 1"
+patterns = ["*.go"]
+
+[[linters]]
+name = "quiet"
+command = "true"
+
+[[linters]]
+name = "missing"
+command = "roborev-no-such-linter {files}"
+`
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuilder(nil)
+	prompt, err := b.BuildDirty(repoPath, lintDiff, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if !strings.Contains(prompt, "### Static Analysis") || !strings.Contains(prompt, "#### fake-vet") ||
+		!strings.Contains(prompt, "main.go:1: suspicious") {
+		t.Errorf("expected linter output in prompt:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "it's.md:1") {
+		t.Error("linter should only see files matching its patterns")
+	}
+	if strings.Contains(prompt, "#### quiet") || strings.Contains(prompt, "#### missing") {
+		t.Error("linters with no output or that can't run should be left out")
+	}
+
+	// An older commit isn't what's checked out, so linters would see the
+	// wrong code
+	prompt, err = b.Build(repoPath, "HEAD~2", 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(prompt, "Static Analysis") {
+		t.Error("linters should not run for commits other than HEAD")
+	}
+}
+
+func TestRunShellCommandKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	// The background sleep holds the output pipe open after sh is killed
+	start := time.Now()
+	_, _, err := runShellCommand(context.Background(), t.TempDir(), "sleep 30 & sleep 30", 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("timeout took %s; the command's children were left running", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	start = time.Now()
+	if _, _, err := runShellCommand(ctx, t.TempDir(), "sleep 30 & sleep 30", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancellation took %s", elapsed)
+	}
+}
@@ -0,0 +1,91 @@
+package prompt
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// DependencyManifestsHeader introduces the full contents of dependency
+// manifests the diff changes
+const DependencyManifestsHeader = `### Dependency Manifests
+
+The diff changes these dependency manifests. Their full contents are below,
+so findings about dependencies can account for entries the diff doesn't show
+(existing versions, replace directives, overrides, and constraints).
+`
+
+// maxManifestSize caps the size of a manifest included in full
+const maxManifestSize = 32 * 1024
+
+// dependencyManifests are the hand-edited manifests included in full when
+// the diff changes them. Lockfiles are left out: they are large, and
+// generated from these.
+var dependencyManifests = map[string]bool{
+	"go.mod":           true,
+	"package.json":     true,
+	"requirements.txt": true,
+	"pyproject.toml":   true,
+	"Pipfile":          true,
+	"Cargo.toml":       true,
+	"Gemfile":          true,
+	"composer.json":    true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+}
+
+// changedManifests returns the dependency manifests the diff modifies.
+// Added manifests are left out since the diff already shows all of them.
+func changedManifests(diff string) []string {
+	var manifests []string
+	for _, f := range git.ParseDiff(diff) {
+		if f.OldPath != "" && f.NewPath != "" && dependencyManifests[path.Base(f.NewPath)] {
+			manifests = append(manifests, f.NewPath)
+		}
+	}
+	return manifests
+}
+
+// writeDependencyManifests includes the full contents of each dependency
+// manifest the diff changes, read with readFile, unless the repo turns
+// this off
+func (b *Builder) writeDependencyManifests(sb *strings.Builder, repoPath, diff string, readFile func(path string) ([]byte, error)) {
+	manifests := changedManifests(diff)
+	if len(manifests) == 0 {
+		return
+	}
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil &&
+		repoCfg.DependencyManifests != nil && !*repoCfg.DependencyManifests {
+		return
+	}
+
+	var section strings.Builder
+	for _, p := range manifests {
+		content, err := readFile(p)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&section, "#### %s\n\n", p)
+		if len(content) > maxManifestSize {
+			fmt.Fprintf(&section, "(%d bytes, too large to include)\n\n", len(content))
+			continue
+		}
+		text := string(content)
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		section.WriteString(wrapUntrusted("dependency manifest", "```\n"+text+"```\n"))
+		section.WriteString("\n")
+	}
+	if section.Len() == 0 {
+		return
+	}
+
+	sb.WriteString(DependencyManifestsHeader)
+	sb.WriteString("\n")
+	sb.WriteString(section.String())
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangedManifests(t *testing.T) {
+	diff := `diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3 +3 @@
+-require example.com/a v1.0.0
++require example.com/a v1.1.0
+diff --git a/web/package.json b/web/package.json
+new file mode 100644
+--- /dev/null
++++ b/web/package.json
+@@ -0,0 +1 @@
++{}
+diff --git a/go.sum b/go.sum
+--- a/go.sum
++++ b/go.sum
+@@ -1 +1 @@
+-x
++y
+diff --git a/svc/requirements.txt b/svc/requirements.txt
+--- a/svc/requirements.txt
++++ b/svc/requirements.txt
+@@ -1 +1 @@
+-flask==2.0
++flask==3.0
+`
+	got := changedManifests(diff)
+	if strings.Join(got, ",") != "go.mod,svc/requirements.txt" {
+		t.Errorf("changedManifests() = %v, want go.mod and svc/requirements.txt", got)
+	}
+}
+
+func TestBuildPromptIncludesDependencyManifests(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	runGit := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	writeGoMod := func(version string) {
+		t.Helper()
+		content := "module example.com/app\n\ngo 1.22\n\nreplace example.com/lib => ./lib\n\nrequire (\n\texample.com/lib " + version + "\n\texample.com/other v0.3.0\n)\n"
+		if err := os.WriteFile(filepath.Join(repoPath, "go.mod"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeGoMod("v1.0.0")
+	runGit("add", "go.mod")
+	runGit("commit", "-m", "add go.mod")
+	writeGoMod("v1.1.0")
+	runGit("commit", "-am", "bump lib")
+	sha := runGit("rev-parse", "HEAD")
+
+	prompt, err := BuildSimple(repoPath, sha, "")
+	if err != nil {
+		t.Fatalf("BuildSimple failed: %v", err)
+	}
+	if !strings.Contains(prompt, "### Dependency Manifests") || !strings.Contains(prompt, "#### go.mod") {
+		t.Fatal("prompt should include the changed go.mod in full")
+	}
+	if !strings.Contains(prompt, "module example.com/app") {
+		t.Error("prompt should include lines of go.mod outside the diff hunk")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte("dependency_manifests = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompt, err = BuildSimple(repoPath, sha, "")
+	if err != nil {
+		t.Fatalf("BuildSimple failed: %v", err)
+	}
+	if strings.Contains(prompt, "### Dependency Manifests") {
+		t.Error("dependency_manifests = false should leave the manifests out")
+	}
+}
@@ -0,0 +1,329 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// OmittedFilesHeader introduces the changed files left out of the diff
+const OmittedFilesHeader = `### Files Not Shown
+
+These files changed, but their contents are left out of the diff below.
+`
+
+// generatedMarkers are phrases tools put in the header comment of files
+// they write, lowercased
+var generatedMarkers = []string{
+	"code generated",
+	"@generated",
+	"autogenerated",
+	"auto-generated",
+	"this file was generated",
+	"this file is generated",
+}
+
+// omittedFile summarizes a changed file whose contents aren't in the diff
+type omittedFile struct {
+	Path   string
+	Kind   string // "binary", "minified", "generated", "lock file", or "excluded"
+	Marker string // What marks a generated file as generated, or the filter excluding it
+	Sizes  *git.FileSizes
+
+	Image       string // Format and dimensions of an image
+	Description string // From the repo's image description command
+}
+
+// diffFile is one file's section of a diff
+type diffFile struct {
+	Path string
+	Text string
+	Diff git.DiffFile
+}
+
+// splitDiff splits a diff into per-file sections. Anything before the first
+// file header is returned as a section with no path.
+func splitDiff(diff string) []diffFile {
+	parsed := git.ParseDiff(diff)
+	size := 0
+	for _, f := range parsed {
+		size += len(f.Text)
+	}
+	var files []diffFile
+	if preamble := diff[:len(diff)-size]; preamble != "" {
+		files = append(files, diffFile{Text: preamble})
+	}
+	for _, f := range parsed {
+		files = append(files, diffFile{Path: f.Path(), Text: f.Text, Diff: f})
+	}
+	return files
+}
+
+// isBinaryDiff reports whether a file's diff section is for a binary file:
+// one git reported as binary, or one it showed as text whose changed lines
+// are mostly control characters or invalid UTF-8
+func isBinaryDiff(f git.DiffFile) bool {
+	for _, line := range f.Header {
+		line = strings.TrimSuffix(line, "\r")
+		if (strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ")) ||
+			line == "GIT binary patch" || line == "Binary file (not shown)" {
+			return true
+		}
+	}
+	var total, bad int
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind != '+' && l.Kind != '-' {
+				continue
+			}
+			for i := 0; i < len(l.Text); {
+				r, size := utf8.DecodeRuneInString(l.Text[i:])
+				switch {
+				case r == 0:
+					return true
+				case r == utf8.RuneError && size == 1, r < 0x20 && r != '\t' && r != '\r' && r != '\f':
+					bad++
+				}
+				total++
+				i += size
+			}
+		}
+	}
+	return total >= 32 && bad*10 > total
+}
+
+// minifiedSuffixes name files that bundlers and minifiers write
+var minifiedSuffixes = []string{".min.js", ".min.mjs", ".min.css", ".js.map", ".css.map"}
+
+// minifiedLineLen is the line length past which added code counts as
+// minified
+const minifiedLineLen = 1000
+
+// minifiedMarker returns why a file's diff section looks minified: its name,
+// or added lines so long they make up most of the change. It returns "" for
+// ordinary files.
+func minifiedMarker(f git.DiffFile) string {
+	for _, suffix := range minifiedSuffixes {
+		if strings.HasSuffix(strings.ToLower(f.Path()), suffix) {
+			return "*" + suffix
+		}
+	}
+	var added, long, longest int
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind != '+' {
+				continue
+			}
+			added += len(l.Text) + 1
+			if len(l.Text)+1 > minifiedLineLen {
+				long += len(l.Text) + 1
+			}
+			longest = max(longest, len(l.Text))
+		}
+	}
+	if long == 0 || long*2 < added {
+		return ""
+	}
+	return fmt.Sprintf("lines up to %d chars", longest)
+}
+
+// summarizeNoisyFiles replaces the sections of binary and minified files in
+// a diff with a one-line note, for prompts that include a diff without the
+// full file summary omitFiles produces
+func summarizeNoisyFiles(diff string) string {
+	var sb strings.Builder
+	for _, f := range splitDiff(diff) {
+		kind := ""
+		switch {
+		case f.Path == "":
+		case isBinaryDiff(f.Diff):
+			kind = "binary"
+		case minifiedMarker(f.Diff) != "":
+			kind = "minified"
+		}
+		if kind == "" {
+			sb.WriteString(f.Text)
+			continue
+		}
+		header, _, _ := strings.Cut(f.Text, "\n")
+		fmt.Fprintf(&sb, "%s\n(%s file not shown)\n", header, kind)
+	}
+	return sb.String()
+}
+
+// generatedMarker returns the line marking a file as generated, if a hunk
+// at the top of the file shows one
+func generatedMarker(f git.DiffFile) string {
+	for _, h := range f.Hunks {
+		// The header is visible if either side starts within the first few
+		// lines
+		if h.OldStart > 3 && h.NewStart > 3 {
+			continue
+		}
+		seen := 0
+		for _, l := range h.Lines {
+			if l.Kind == '\\' {
+				continue
+			}
+			if seen++; seen > 10 {
+				break
+			}
+			lower := strings.ToLower(l.Text)
+			for _, m := range generatedMarkers {
+				if strings.Contains(lower, m) {
+					marker := strings.TrimSpace(l.Text)
+					if len(marker) > 100 {
+						marker = TruncateUTF8(marker, 100) + "..."
+					}
+					return marker
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// omitFiles takes binary, minified, and generated files, and files the repo's
+// diff_include and diff_exclude patterns filter out, out of the diff and
+// returns what's left, along with summaries of those files and of the lock
+// files git leaves out of diffs. Sizes compare baseRef with targetRef, or with
+// the working tree when targetRef is empty.
+func omitFiles(ctx context.Context, repoPath, diff, baseRef, targetRef string) (string, []omittedFile) {
+	sections := splitDiff(diff)
+	var paths []string
+	for _, f := range sections {
+		if f.Path != "" {
+			paths = append(paths, f.Path)
+		}
+	}
+	attrGenerated, err := git.GetGeneratedFiles(repoPath, paths)
+	if err != nil {
+		log.Printf("omitted files: %v", err)
+	}
+	var include, exclude []string
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		include, exclude = repoCfg.DiffInclude, repoCfg.DiffExclude
+	}
+
+	var kept strings.Builder
+	var omitted []omittedFile
+	for _, f := range sections {
+		reason := diffFilterReason(f.Path, include, exclude)
+		switch {
+		case f.Path == "":
+			kept.WriteString(f.Text)
+		case reason != "":
+			omitted = append(omitted, omittedFile{Path: f.Path, Kind: "excluded", Marker: reason})
+		case isBinaryDiff(f.Diff):
+			omitted = append(omitted, omittedFile{Path: f.Path, Kind: "binary"})
+		case attrGenerated[f.Path]:
+			omitted = append(omitted, omittedFile{Path: f.Path, Kind: "generated", Marker: "linguist-generated"})
+		default:
+			if marker := minifiedMarker(f.Diff); marker != "" {
+				omitted = append(omitted, omittedFile{Path: f.Path, Kind: "minified", Marker: marker})
+			} else if marker := generatedMarker(f.Diff); marker != "" {
+				omitted = append(omitted, omittedFile{Path: f.Path, Kind: "generated", Marker: marker})
+			} else {
+				kept.WriteString(f.Text)
+			}
+		}
+	}
+
+	lockFiles, err := git.GetExcludedFilesChanged(repoPath, baseRef, targetRef)
+	if err != nil {
+		log.Printf("omitted files: %v", err)
+	}
+	for _, p := range lockFiles {
+		omitted = append(omitted, omittedFile{Path: p, Kind: "lock file"})
+	}
+	if len(omitted) == 0 {
+		return diff, nil
+	}
+
+	paths = paths[:0]
+	for _, o := range omitted {
+		paths = append(paths, o.Path)
+	}
+	sizes, err := git.GetFileSizes(repoPath, baseRef, targetRef, paths)
+	if err != nil {
+		log.Printf("omitted files: %v", err)
+	}
+	for i := range omitted {
+		if s, ok := sizes[omitted[i].Path]; ok {
+			omitted[i].Sizes = &s
+		}
+	}
+	describeImages(ctx, repoPath, baseRef, targetRef, omitted)
+	return kept.String(), omitted
+}
+
+// formatSize renders a byte count, e.g. "512 B" or "1.5 KB"
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
+
+// describeSizes renders how a file's size changed
+func describeSizes(s git.FileSizes) string {
+	switch {
+	case s.Old < 0 && s.New < 0:
+		return ""
+	case s.Old < 0:
+		return "added, " + formatSize(s.New)
+	case s.New < 0:
+		return "deleted, was " + formatSize(s.Old)
+	case s.New >= s.Old:
+		return fmt.Sprintf("%s -> %s (+%s)", formatSize(s.Old), formatSize(s.New), formatSize(s.New-s.Old))
+	default:
+		return fmt.Sprintf("%s -> %s (-%s)", formatSize(s.Old), formatSize(s.New), formatSize(s.Old-s.New))
+	}
+}
+
+// writeOmittedFiles lists the changed files left out of the diff
+func writeOmittedFiles(sb *strings.Builder, files []omittedFile) {
+	if len(files) == 0 {
+		return
+	}
+
+	sb.WriteString(OmittedFilesHeader)
+	sb.WriteString("\n")
+	excluded := 0
+	for _, f := range files {
+		if f.Kind == "excluded" {
+			if excluded++; excluded > maxListedExcluded {
+				continue
+			}
+		}
+		details := []string{f.Kind}
+		if f.Marker != "" {
+			details[0] += " (" + f.Marker + ")"
+		}
+		if f.Sizes != nil {
+			if s := describeSizes(*f.Sizes); s != "" {
+				details = append(details, s)
+			}
+		}
+		if f.Image != "" {
+			details = append(details, f.Image)
+		}
+		fmt.Fprintf(sb, "- %s: %s\n", f.Path, strings.Join(details, ", "))
+		if f.Description != "" {
+			fmt.Fprintf(sb, "  Description: %s\n", f.Description)
+		}
+	}
+	if excluded > maxListedExcluded {
+		fmt.Fprintf(sb, "- %d more files excluded by the repo's diff filters\n", excluded-maxListedExcluded)
+	}
+	sb.WriteString("\n")
+}
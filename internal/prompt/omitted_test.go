@@ -0,0 +1,241 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// testDiffFile parses hunks as the diff of a file at path
+func testDiffFile(path, hunks string) git.DiffFile {
+	return git.ParseDiff("diff --git a/" + path + " b/" + path + "\n--- a/" + path + "\n+++ b/" + path + "\n" + hunks)[0]
+}
+
+func TestGeneratedMarker(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{
+			name: "new generated file",
+			diff: "@@ -0,0 +1,3 @@\n+// Code generated by protoc-gen-go. DO NOT EDIT.\n+\n+package api\n",
+			want: "// Code generated by protoc-gen-go. DO NOT EDIT.",
+		},
+		{
+			name: "header shown as context",
+			diff: "@@ -1,4 +1,4 @@\n # @generated by pip-compile\n-a==1\n+a==2\n",
+			want: "# @generated by pip-compile",
+		},
+		{
+			name: "marker deep in the file",
+			diff: "@@ -40,3 +40,3 @@\n // Code generated values are cached\n-x\n+y\n",
+		},
+		{
+			name: "ordinary file",
+			diff: "@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generatedMarker(testDiffFile("api.go", tt.diff)); got != tt.want {
+				t.Errorf("generatedMarker() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinifiedMarker(t *testing.T) {
+	bundle := "+" + strings.Repeat("var a=1;", 200) + "\n"
+	tests := []struct {
+		name string
+		path string
+		diff string
+		want string
+	}{
+		{name: "min suffix", path: "static/app.MIN.js", diff: "@@ -0,0 +1 @@\n+x\n", want: "*.min.js"},
+		{name: "source map", path: "dist/app.js.map", diff: "@@ -0,0 +1 @@\n+{}\n", want: "*.js.map"},
+		{name: "long lines", path: "dist/app.js", diff: "@@ -0,0 +1,2 @@\n" + bundle + "+//# end\n", want: "lines up to 1600 chars"},
+		{name: "one long line among many", path: "data.go", diff: "@@ -0,0 +1,41 @@\n" + bundle + strings.Repeat("+\tx := compute(y, z) // ordinary code line here\n", 40)},
+		{name: "ordinary file", path: "main.go", diff: "@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := minifiedMarker(testDiffFile(tt.path, tt.diff)); got != tt.want {
+				t.Errorf("minifiedMarker() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinaryDiffTextMode(t *testing.T) {
+	garbage := "+" + strings.Repeat("\x01\x02\xff\xfeab", 20) + "\n"
+	if !isBinaryDiff(testDiffFile("a.bin", "@@ -0,0 +1 @@\n"+garbage)) {
+		t.Error("expected control characters and invalid UTF-8 to count as binary")
+	}
+	if !isBinaryDiff(testDiffFile("a.bin", "@@ -0,0 +1 @@\n+ok\x00\n")) {
+		t.Error("expected a NUL byte to count as binary")
+	}
+	if isBinaryDiff(testDiffFile("a.txt", "@@ -1 +1 @@\n-caf\xc3\xa9\n+na\xc3\xafve \x1b[31mred\x1b[0m and plain text around it\n")) {
+		t.Error("expected UTF-8 text with an escape sequence to be text")
+	}
+}
+
+func TestSummarizeNoisyFiles(t *testing.T) {
+	diff := "diff --git a/app.min.js b/app.min.js\n--- a/app.min.js\n+++ b/app.min.js\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/logo.png b/logo.png\nBinary files a/logo.png and b/logo.png differ\n" +
+		"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-x\n+y\n"
+	want := "diff --git a/app.min.js b/app.min.js\n(minified file not shown)\n" +
+		"diff --git a/logo.png b/logo.png\n(binary file not shown)\n" +
+		"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-x\n+y\n"
+	if got := summarizeNoisyFiles(diff); got != want {
+		t.Errorf("summarizeNoisyFiles() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuildPromptSummarizesOmittedFiles(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	write := func(name string, content []byte) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoPath, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("logo.png", append([]byte("PNG\x00"), make([]byte, 2044)...))
+	write("api.pb.go", []byte("// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n"))
+	write("go.sum", []byte("example.com/mod v1.0.0 h1:abc=\n"))
+	write("main.go", []byte("package main\n"))
+	write("app.min.js", []byte("var a=1;\n"))
+	if out, err := exec.Command("git", "-C", repoPath, "add", ".").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	if out, err := exec.Command("git", "-C", repoPath, "commit", "-q", "-m", "assets").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+
+	prompt, err := NewBuilder(nil).Build(repoPath, "HEAD", 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	for _, want := range []string{
+		"### Files Not Shown",
+		"- logo.png: binary, added, 2.0 KB",
+		"- api.pb.go: generated (// Code generated by protoc-gen-go. DO NOT EDIT.), added, 62 B",
+		"- go.sum: lock file, added, 31 B",
+		"- app.min.js: minified (*.min.js), added, 9 B",
+		"+++ b/main.go",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in prompt:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "package api") || strings.Contains(prompt, "Binary files") {
+		t.Errorf("expected omitted file contents to be left out of the diff:\n%s", prompt)
+	}
+
+	// Uncommitted changes are compared against the working tree
+	write("logo.png", make([]byte, 1024))
+	diffCmd := exec.Command("git", "diff", "HEAD")
+	diffCmd.Dir = repoPath
+	diff, err := diffCmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, err = NewBuilder(nil).BuildDirty(repoPath, string(diff), 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if !strings.Contains(prompt, "- logo.png: binary, 2.0 KB -> 1.0 KB (-1.0 KB)") {
+		t.Errorf("expected size change for modified binary:\n%s", prompt)
+	}
+}
+
+func TestMatchPathPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"vendor/**", "vendor/github.com/x/y.go", true},
+		{"vendor/**", "internal/vendor/y.go", false},
+		{"vendor", "internal/vendor/y.go", true},
+		{"*.pb.go", "api/v1/service.pb.go", true},
+		{"*.pb.go", "api/v1/service.go", false},
+		{"package-lock.json", "web/package-lock.json", true},
+		{"/docs", "docs/index.md", true},
+		{"/docs", "web/docs/index.md", false},
+		{"web/**/*.min.js", "web/static/js/app.min.js", true},
+		{"web/**/*.min.js", "web/app.min.js", true},
+		{"dist/", "dist", false},
+		{"dist/", "web/dist/app.js", true},
+	}
+	for _, tt := range tests {
+		if got := matchPathPattern(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPathPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBuildPromptAppliesDiffFilters(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("vendor/lib/lib.go", "package lib\n")
+	write("api/service.pb.go", "package api\n")
+	write("docs/notes.md", "notes\n")
+	write("internal/app.go", "package app\n")
+	if out, err := exec.Command("git", "-C", repoPath, "add", ".").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	if out, err := exec.Command("git", "-C", repoPath, "commit", "-q", "-m", "filters").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+	write(".roborev.toml", "diff_exclude = [\"vendor/**\", \"*.pb.go\"]\ndiff_include = [\"internal/**\", \"vendor/**\", \"api/**\"]\n")
+
+	prompt, err := NewBuilder(nil).Build(repoPath, "HEAD", 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	for _, want := range []string{
+		"- vendor/lib/lib.go: excluded (diff_exclude vendor/**), added, 12 B",
+		"- api/service.pb.go: excluded (diff_exclude *.pb.go), added, 12 B",
+		"- docs/notes.md: excluded (not in diff_include), added, 6 B",
+		"+++ b/internal/app.go",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in prompt:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "package lib") || strings.Contains(prompt, "+notes") {
+		t.Errorf("expected filtered files to be left out of the diff:\n%s", prompt)
+	}
+}
+
+func TestWriteOmittedFilesCapsExcluded(t *testing.T) {
+	var files []omittedFile
+	for i := 0; i < maxListedExcluded+5; i++ {
+		files = append(files, omittedFile{Path: fmt.Sprintf("vendor/f%d.go", i), Kind: "excluded", Marker: "diff_exclude vendor/**"})
+	}
+	var sb strings.Builder
+	writeOmittedFiles(&sb, files)
+	out := sb.String()
+	if got := strings.Count(out, ": excluded"); got != maxListedExcluded {
+		t.Errorf("listed %d excluded files, want %d:\n%s", got, maxListedExcluded, out)
+	}
+	if !strings.Contains(out, "- 5 more files excluded by the repo's diff filters") {
+		t.Errorf("expected summary of the rest:\n%s", out)
+	}
+}
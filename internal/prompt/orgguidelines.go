@@ -0,0 +1,130 @@
+package prompt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// maxOrgGuidelinesBytes caps the shared guidelines added to each prompt
+const maxOrgGuidelinesBytes = 32 * 1024
+
+// orgGuidelinesCachePath is where the daemon keeps the last synced copy of
+// the guidelines named by c. The name covers the whole source, so changing
+// it never serves guidelines from the old one.
+func orgGuidelinesCachePath(c config.OrgGuidelinesConfig) string {
+	sum := sha256.Sum256([]byte(c.Source + "\x00" + c.ResolvedPath() + "\x00" + c.Ref))
+	return filepath.Join(config.DataDir(), "cache", "org-guidelines", hex.EncodeToString(sum[:])+".md")
+}
+
+// SyncOrgGuidelines fetches the guidelines named by c and replaces the
+// cached copy prompts read. On failure the previous copy is kept.
+func SyncOrgGuidelines(ctx context.Context, c config.OrgGuidelinesConfig) error {
+	if c.Source == "" {
+		return nil
+	}
+	cachePath := orgGuidelinesCachePath(c)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if c.IsGit() {
+		data, err = readGitGuidelines(ctx, c, filepath.Dir(cachePath))
+	} else {
+		data, err = downloadGuidelines(ctx, c.Source)
+	}
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".guidelines-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath)
+}
+
+// readGitGuidelines shallow-clones a git source into a temporary directory
+// under dir and reads the guidelines file from it
+func readGitGuidelines(ctx context.Context, c config.OrgGuidelinesConfig, dir string) ([]byte, error) {
+	path := filepath.FromSlash(c.ResolvedPath())
+	if !filepath.IsLocal(path) {
+		return nil, fmt.Errorf("org_guidelines path %q is outside the repository", c.ResolvedPath())
+	}
+	cloneDir, err := os.MkdirTemp(dir, ".clone-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(cloneDir)
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if c.Ref != "" {
+		args = append(args, "--branch", c.Ref)
+	}
+	args = append(args, "--", c.Source, cloneDir)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("clone %s: %w: %s", c.Source, err, strings.TrimSpace(string(out)))
+	}
+
+	f, err := os.Open(filepath.Join(cloneDir, path))
+	if err != nil {
+		return nil, fmt.Errorf("read %s from %s: %w", c.ResolvedPath(), c.Source, err)
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxOrgGuidelinesBytes))
+}
+
+// downloadGuidelines fetches a guidelines file served over HTTP
+func downloadGuidelines(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := contextHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOrgGuidelinesBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// orgGuidelines returns the last synced copy of the configured shared
+// guidelines, or "" when none are configured or synced yet
+func (b *Builder) orgGuidelines() string {
+	if b.cfg == nil || b.cfg.OrgGuidelines.Source == "" {
+		return ""
+	}
+	data, err := os.ReadFile(orgGuidelinesCachePath(b.cfg.OrgGuidelines))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
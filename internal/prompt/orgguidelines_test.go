@@ -0,0 +1,111 @@
+package prompt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestSyncOrgGuidelinesFromGit(t *testing.T) {
+	t.Setenv("ROBOREV_DATA_DIR", t.TempDir())
+	standards := t.TempDir()
+	runGit := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", standards}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commitGuidelines := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(standards, "review"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(standards, "review", "rules.md"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit("add", ".")
+		runGit("commit", "-q", "-m", "update guidelines")
+	}
+	runGit("init", "-q")
+	commitGuidelines("Every exported function needs a doc comment.\n")
+
+	repoPath, commits := setupTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(`review_guidelines = "Prefer table tests."`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{OrgGuidelines: config.OrgGuidelinesConfig{Source: standards, Path: "review/rules.md"}}
+	build := func() string {
+		t.Helper()
+		p, err := NewBuilderWithConfig(nil, cfg).Build(repoPath, commits[len(commits)-1], 0, 0, "test", "")
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		return p
+	}
+
+	if strings.Contains(build(), "Organization-wide guidelines") {
+		t.Error("expected no org guidelines before the first sync")
+	}
+	if err := SyncOrgGuidelines(context.Background(), cfg.OrgGuidelines); err != nil {
+		t.Fatalf("SyncOrgGuidelines: %v", err)
+	}
+	p := build()
+	repoPos := strings.Index(p, "Prefer table tests.")
+	orgPos := strings.Index(p, "Every exported function needs a doc comment.")
+	if repoPos < 0 || orgPos < repoPos {
+		t.Errorf("expected org guidelines beneath the repo's own:\n%s", p)
+	}
+
+	commitGuidelines("Errors must be wrapped with context.\n")
+	if err := SyncOrgGuidelines(context.Background(), cfg.OrgGuidelines); err != nil {
+		t.Fatalf("SyncOrgGuidelines: %v", err)
+	}
+	if p := build(); !strings.Contains(p, "Errors must be wrapped with context.") || strings.Contains(p, "doc comment") {
+		t.Errorf("expected the updated guidelines after a resync:\n%s", p)
+	}
+
+	// A failed sync keeps the last good copy
+	broken := cfg.OrgGuidelines
+	broken.Path = "missing.md"
+	if err := SyncOrgGuidelines(context.Background(), broken); err == nil {
+		t.Error("expected an error for a missing guidelines file")
+	}
+	if !strings.Contains(build(), "Errors must be wrapped with context.") {
+		t.Error("expected the last synced guidelines to remain")
+	}
+}
+
+func TestSyncOrgGuidelinesFromURL(t *testing.T) {
+	t.Setenv("ROBOREV_DATA_DIR", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/guidelines.md" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("Log with structured fields.\n"))
+	}))
+	defer srv.Close()
+
+	c := config.OrgGuidelinesConfig{Source: srv.URL + "/guidelines.md"}
+	if err := SyncOrgGuidelines(context.Background(), c); err != nil {
+		t.Fatalf("SyncOrgGuidelines: %v", err)
+	}
+	b := NewBuilderWithConfig(nil, &config.Config{OrgGuidelines: c})
+	if got := b.orgGuidelines(); got != "Log with structured fields." {
+		t.Errorf("orgGuidelines() = %q", got)
+	}
+
+	if err := SyncOrgGuidelines(context.Background(), config.OrgGuidelinesConfig{Source: srv.URL + "/missing.md"}); err == nil {
+		t.Error("expected an error for a missing URL")
+	}
+}
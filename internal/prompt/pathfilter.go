@@ -0,0 +1,80 @@
+package prompt
+
+import (
+	"path"
+	"strings"
+)
+
+// maxListedExcluded caps how many files left out by diff filters are listed
+// individually, so excluding a vendor tree doesn't flood the prompt
+const maxListedExcluded = 20
+
+// matchPathPattern reports whether a repo-relative path matches a
+// gitignore-style pattern. A pattern without a slash matches a file or
+// directory name at any depth; one with a slash is anchored at the repo
+// root. "**" matches any number of directories, and a trailing slash
+// matches directories only. A pattern matching a directory matches
+// everything under it.
+func matchPathPattern(pattern, filePath string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+	segs := strings.Split(filePath, "/")
+	if dirOnly {
+		segs = segs[:len(segs)-1]
+	}
+
+	if !anchored {
+		for _, s := range segs {
+			if ok, _ := path.Match(pattern, s); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "/"), segs)
+}
+
+// matchSegments matches pattern segments against a prefix of path segments
+func matchSegments(pattern, segs []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pattern[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segs[1:])
+}
+
+// diffFilterReason returns why a file is left out by the repo's diff_include
+// and diff_exclude patterns, or "" to keep it
+func diffFilterReason(filePath string, include, exclude []string) string {
+	for _, p := range exclude {
+		if matchPathPattern(p, filePath) {
+			return "diff_exclude " + p
+		}
+	}
+	if len(include) == 0 {
+		return ""
+	}
+	for _, p := range include {
+		if matchPathPattern(p, filePath) {
+			return ""
+		}
+	}
+	return "not in diff_include"
+}
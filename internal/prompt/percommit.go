@@ -0,0 +1,61 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// PerCommitReviewHeader asks for a sub-review of each commit in a range and
+// a summary of the whole
+const PerCommitReviewHeader = `
+## Per-Commit Review
+
+Review this range commit by commit. For each commit, in the order listed, add a section
+headed "## Commit <short SHA>: <subject>" with the issues that commit introduces, or
+"No issues found." Then add a "## Overall Summary" section covering the range as a whole:
+what it accomplishes, problems that only show across commits, and the most important
+issues left at the end of the range. An issue introduced by one commit and fixed by a later
+one needs no finding, but mention it under the commit that introduced it.
+`
+
+// PerCommitFallbackNote explains why a per-commit review shows one diff
+const PerCommitFallbackNote = "(The diffs of the individual commits don't fit the prompt budget, so the combined diff is shown. View a commit's own changes with `git show <sha>`.)\n\n"
+
+// WithPerCommit returns a copy of the builder whose range prompts ask for a
+// sub-review of each commit plus an overall summary
+func (b *Builder) WithPerCommit() *Builder {
+	c := *b
+	c.perCommit = true
+	return &c
+}
+
+// perCommitDiffs formats the diff of each commit in commits under its own
+// heading, oldest first, leaving out the same files the combined diff does.
+// It returns "" if any commit's diff can't be read.
+func perCommitDiffs(ctx context.Context, repoPath string, commits []string) string {
+	var sb strings.Builder
+	for _, sha := range commits {
+		diff, err := git.GetDiff(repoPath, sha)
+		if err != nil {
+			return ""
+		}
+		diff, _ = omitFiles(ctx, repoPath, diff, parentRef(repoPath, sha), sha)
+
+		short := sha
+		if len(short) > 7 {
+			short = short[:7]
+		}
+		// Subjects stay in the untrusted commit list, out of headings
+		heading := "### Commit " + short
+		if strings.TrimSpace(diff) == "" {
+			fmt.Fprintf(&sb, "%s\n\n(No changes to review in this commit.)\n\n", heading)
+			continue
+		}
+		sb.WriteString(diffSection(heading, "", diff))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,93 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildPerCommitRangePrompt(t *testing.T) {
+	repoPath, commits := setupTestRepo(t)
+	rangeRef := commits[2] + ".." + commits[5]
+
+	p, err := NewBuilder(nil).WithPerCommit().Build(repoPath, rangeRef, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(p, "## Per-Commit Review") || !strings.Contains(p, `"## Overall Summary"`) {
+		t.Error("Prompt should ask for per-commit sub-reviews and a summary")
+	}
+	if strings.Contains(p, "### Combined Diff") {
+		t.Error("Per-commit prompt should show each commit's diff instead of the combined one")
+	}
+	last := -1
+	for _, sha := range commits[3:] {
+		pos := strings.Index(p, "### Commit "+sha[:7])
+		if pos == -1 {
+			t.Fatalf("Prompt missing the diff of %s:\n%s", sha[:7], p)
+		}
+		if pos < last {
+			t.Error("Commit diffs should be in order, oldest first")
+		}
+		last = pos
+	}
+	if strings.Contains(p, "### Commit "+commits[2][:7]) {
+		t.Error("The range start is not part of the range")
+	}
+
+	plain, err := NewBuilder(nil).Build(repoPath, rangeRef, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(plain, "## Per-Commit Review") || !strings.Contains(plain, "### Combined Diff") {
+		t.Error("Range prompts should review the combined diff by default")
+	}
+}
+
+func TestBuildPerCommitFallsBackToCombinedDiff(t *testing.T) {
+	repoPath, commits := setupTestRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	// Adding and then removing a large file leaves a small combined diff
+	// but large individual ones
+	var big strings.Builder
+	for i := range 300 {
+		fmt.Fprintf(&big, "line %d of a file that is removed again\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "big.txt"), []byte(big.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "big.txt")
+	run("commit", "-q", "-m", "add big.txt")
+	run("rm", "-q", "big.txt")
+	run("commit", "-q", "-m", "remove big.txt")
+	rangeRef := commits[5] + "..HEAD"
+
+	build := func() string {
+		t.Helper()
+		p, err := NewBuilder(nil).WithPerCommit().Build(repoPath, rangeRef, 0, 0, "test", "")
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		return p
+	}
+	full := build()
+	if strings.Contains(full, PerCommitFallbackNote) {
+		t.Fatal("expected per-commit diffs when they fit")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(fmt.Sprintf("max_prompt_size = %d\n", len(full)-200)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := build()
+	if !strings.Contains(p, "### Combined Diff") || !strings.Contains(p, PerCommitFallbackNote) {
+		t.Errorf("expected the combined diff with a note when per-commit diffs don't fit:\n%s", p)
+	}
+}
@@ -1,15 +1,22 @@
 package prompt
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/roborev-dev/roborev/internal/config"
 	"github.com/roborev-dev/roborev/internal/git"
+	"github.com/roborev-dev/roborev/internal/secrets"
 	"github.com/roborev-dev/roborev/internal/storage"
 )
 
-// MaxPromptSize is the maximum size of a prompt in bytes (250KB)
+// MaxPromptSize is the maximum size of a prompt in bytes (250KB) for agents
+// whose context window isn't known (see ResolvePromptBudget)
 // If the prompt with diffs exceeds this, we fall back to just commit info
 const MaxPromptSize = 250 * 1024
 
@@ -94,6 +101,66 @@ The following are project-specific guidelines for this repository. Take these in
 when reviewing the code - they may override or supplement the default review criteria.
 `
 
+// RequiredSectionsHeader introduces the output sections a repo requires in every review
+const RequiredSectionsHeader = `
+## Required Output Sections
+
+In addition to your findings, your review MUST include each of the following sections
+as a markdown heading (for example "## <section name>"). If a section does not apply
+to these changes, include the heading and say so briefly.
+`
+
+// SeverityLevelsHeader introduces a repo's own severity labels
+const SeverityLevelsHeader = `
+## Severity Levels
+
+This repository uses its own severity levels. Label every finding with one of
+the following, listed from most to least severe, instead of high/medium/low,
+and use the same label as the "severity" in the JSON findings block.
+`
+
+// FindingsLimitHeader introduces the cap on reported findings
+const FindingsLimitHeader = `
+## Finding Limit
+`
+
+// FindingsFormatHeader asks for a machine-readable copy of the findings,
+// which the daemon parses with storage.ExtractFindings and strips from the
+// stored review
+const FindingsFormatHeader = `
+## Machine-Readable Findings
+
+After your review, end your response with a fenced code block tagged json that
+lists every issue you reported, in this shape:
+
+` + "```json" + `
+{"findings": [{"severity": "high", "file": "path/to/file.go", "line": 42, "message": "Brief description of the problem"}]}
+` + "```" + `
+
+Use "line": 0 when no specific line applies. If you found no issues, use an empty
+findings array.
+`
+
+// OutputLanguageHeader asks agents to write in the configured language; %s
+// is the language. Parsed parts of the output stay in English.
+const OutputLanguageHeader = `
+## Output Language
+
+Write your response in %s. Keep file paths, identifiers, code, severity
+labels (Critical, High, Medium, Low), the JSON findings block, and a verdict
+line such as "No issues found." in English, since tools read them.
+`
+
+// PriorFeedbackHeader introduces comments human reviewers left on the
+// pull request
+const PriorFeedbackHeader = `
+## Prior Human Feedback
+
+Reviewers have already commented on this pull request. Build on what they said
+rather than repeating it: don't report an issue a reviewer already raised unless
+you have something to add, and note whether the changes address their comments.
+`
+
 // PreviousAttemptsForCommitHeader introduces previous review attempts for the same commit
 const PreviousAttemptsForCommitHeader = `
 ## Previous Review Attempts
@@ -110,31 +177,153 @@ type ReviewContext struct {
 	SHA       string
 	Review    *storage.Review
 	Responses []storage.Response
+	Findings  []storage.Finding // Stored findings, in place of the review text when set
 }
 
 // Builder constructs review prompts
 type Builder struct {
-	db *storage.DB
+	db    *storage.DB
+	cfg   *config.Config // Global config for settings a repo doesn't override (may be nil)
+	model string         // Model the prompt is for, to size it to the context window
+
+	sbomDelta *SBOMDelta // SBOM changes found by the last build, if any
+
+	contextCache *ContextFileCache // Context file contents, possibly shared with other builders
+
+	priorFeedback string // Comments human reviewers left on the pull request
+
+	perCommit bool // Range prompts ask for a sub-review of each commit
+
+	ctx context.Context // Stops commands run while building, e.g. tests and linters (may be nil)
 }
 
 // NewBuilder creates a new prompt builder
 func NewBuilder(db *storage.DB) *Builder {
-	return &Builder{db: db}
+	return &Builder{db: db, contextCache: NewContextFileCache()}
+}
+
+// NewBuilderWithConfig creates a prompt builder that falls back to the
+// global config for settings a repo doesn't override
+func NewBuilderWithConfig(db *storage.DB, cfg *config.Config) *Builder {
+	return &Builder{db: db, cfg: cfg, contextCache: NewContextFileCache()}
+}
+
+// WithContextCache returns a copy of the builder that reads context files
+// through cache, so builders created for each job can share one
+func (b *Builder) WithContextCache(cache *ContextFileCache) *Builder {
+	c := *b
+	c.contextCache = cache
+	return &c
+}
+
+// WithModel returns a copy of the builder that sizes prompts for model
+func (b *Builder) WithModel(model string) *Builder {
+	c := *b
+	c.model = model
+	return &c
+}
+
+// WithContext returns a copy of the builder whose commands, such as the
+// repo's tests, linters, and benchmarks, are killed when ctx is done
+func (b *Builder) WithContext(ctx context.Context) *Builder {
+	c := *b
+	c.ctx = ctx
+	return &c
+}
+
+// context returns the context commands run under
+func (b *Builder) context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+// WithPriorFeedback returns a copy of the builder that includes comments
+// human reviewers already left on the pull request under review
+func (b *Builder) WithPriorFeedback(feedback string) *Builder {
+	c := *b
+	c.priorFeedback = feedback
+	return &c
+}
+
+// SBOMDelta returns the SBOM changes included in the last prompt built,
+// or nil if it had none
+func (b *Builder) SBOMDelta() *SBOMDelta {
+	if b.sbomDelta.Empty() {
+		return nil
+	}
+	return b.sbomDelta
+}
+
+// promptBudget returns how large a prompt for the agent may be in the repo
+func (b *Builder) promptBudget(repoPath, agentName string) PromptBudget {
+	var overrides map[string]int
+	if b.cfg != nil {
+		overrides = b.cfg.PromptTokenBudgets
+	}
+	budget := ResolvePromptBudget(agentName, b.model, overrides)
+	budget.MaxBytes = config.ResolveReviewPromptSize(repoPath, b.cfg)
+	if n := config.ResolvePromptTokenBudget(repoPath); n > 0 {
+		budget = budget.WithTokens(n)
+	}
+	return budget
 }
 
 // Build constructs a review prompt for a commit or range with context from previous reviews.
 // reviewType selects the system prompt variant (e.g., "security"); any default alias (see config.IsDefaultReviewType) uses the standard prompt.
 func (b *Builder) Build(repoPath, gitRef string, repoID int64, contextCount int, agentName, reviewType string) (string, error) {
+	if reviewType == "quick" {
+		// Quick reviews leave out earlier reviews to keep the prompt small
+		contextCount = 0
+	}
+	var p string
+	var err error
 	if git.IsRange(gitRef) {
-		return b.buildRangePrompt(repoPath, gitRef, repoID, contextCount, agentName, reviewType)
+		p, err = b.buildRangePrompt(repoPath, gitRef, repoID, contextCount, agentName, reviewType)
+	} else {
+		p, err = b.buildSinglePrompt(repoPath, gitRef, repoID, contextCount, agentName, reviewType)
+	}
+	if err != nil {
+		return "", err
 	}
-	return b.buildSinglePrompt(repoPath, gitRef, repoID, contextCount, agentName, reviewType)
+	return ScrubSecrets(repoPath, p), nil
+}
+
+// ScrubSecrets redacts credentials from a prompt before it's sent to
+// an agent, logging what kinds were found where, unless the repo sets
+// scrub_secrets = false
+func ScrubSecrets(repoPath, p string) string {
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil && repoCfg.ScrubSecrets != nil && !*repoCfg.ScrubSecrets {
+		return p
+	}
+	scrubbed, redactions := secrets.Scrub(p)
+	if len(redactions) > 0 {
+		found := make([]string, len(redactions))
+		for i, r := range redactions {
+			found[i] = r.String()
+		}
+		log.Printf("Redacted %d secret(s) from the prompt for %s: %s", len(redactions), repoPath, strings.Join(found, ", "))
+	}
+	return scrubbed
 }
 
 // BuildDirty constructs a review prompt for uncommitted (dirty) changes.
 // The diff is provided directly since it was captured at enqueue time.
 // reviewType selects the system prompt variant (e.g., "security"); any default alias (see config.IsDefaultReviewType) uses the standard prompt.
 func (b *Builder) BuildDirty(repoPath, diff string, repoID int64, contextCount int, agentName, reviewType string) (string, error) {
+	if reviewType == "quick" {
+		contextCount = 0
+	}
+	p, err := b.buildDirtyPrompt(repoPath, diff, repoID, contextCount, agentName, reviewType)
+	if err != nil {
+		return "", err
+	}
+	return ScrubSecrets(repoPath, p), nil
+}
+
+// buildDirtyPrompt constructs a prompt for uncommitted changes
+func (b *Builder) buildDirtyPrompt(repoPath, diff string, repoID int64, contextCount int, agentName, reviewType string) (string, error) {
 	var sb strings.Builder
 
 	// Start with system prompt for dirty changes
@@ -145,19 +334,29 @@ func (b *Builder) BuildDirty(repoPath, diff string, repoID int64, contextCount i
 	if promptType == "design" {
 		promptType = "design-review"
 	}
-	sb.WriteString(GetSystemPrompt(agentName, promptType))
+	target := reviewTarget(repoPath, "dirty")
+	sb.WriteString(withFindingCategories(repoSystemPrompt(repoPath, agentName, promptType, target), promptType, config.ResolveCategories(repoPath)))
 	sb.WriteString("\n")
+	b.writeOutputLanguage(&sb, repoPath)
 
-	// Add project-specific guidelines if configured
-	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
-		b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines)
+	// Add project-specific guidelines, including those of the directories
+	// the change touches, if configured
+	repoCfg, _ := config.LoadRepoConfig(repoPath)
+	if repoCfg == nil {
+		repoCfg = &config.RepoConfig{}
 	}
+	b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines, nestedGuidelines(repoPath, git.ChangedFiles(diff)), promptVars(repoPath, agentName, promptType, target))
+	b.writeRequiredSections(&sb, repoCfg.RequiredSections)
+	b.writeSeverityLevels(&sb, repoCfg.Severities)
+	b.writeFindingCategories(&sb, repoCfg.Categories)
+	b.writeFindingsLimit(&sb, config.ResolveMaxFindings(repoPath, b.cfg))
+	sb.WriteString(FindingsFormatHeader)
 
 	// Get previous reviews for context (use HEAD as reference point)
 	if contextCount > 0 && b.db != nil {
 		headSHA, err := git.ResolveSHA(repoPath, "HEAD")
 		if err == nil {
-			contexts, err := b.getPreviousReviewContexts(repoPath, headSHA, contextCount)
+			contexts, err := b.getPreviousReviewContexts(repoPath, repoID, headSHA, contextCount)
 			if err == nil && len(contexts) > 0 {
 				b.writePreviousReviews(&sb, contexts)
 			}
@@ -165,35 +364,61 @@ func (b *Builder) BuildDirty(repoPath, diff string, repoID int64, contextCount i
 	}
 
 	// Uncommitted changes section
+	sb.WriteString(UntrustedContentNotice)
+	b.writeContextFiles(&sb, repoPath, agentName)
 	sb.WriteString("## Uncommitted Changes\n\n")
 	sb.WriteString("The following changes have not yet been committed.\n\n")
+	base := "HEAD"
+	if git.IsUnbornHead(repoPath) {
+		base = git.EmptyTreeSHA
+	}
+	patch := diff
+	diff, omitted := omitFiles(b.context(), repoPath, diff, base, "")
+	readFile := func(p string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(p)))
+	}
+	// Quick reviews skip the slow context: blame, and the repo's linters,
+	// tests, and coverage
+	quick := reviewType == "quick"
+	b.writeSecurityAdvisories(&sb, repoPath, reviewType, diff, readFile)
+	b.writeDependencyManifests(&sb, repoPath, diff, readFile)
+	if !quick {
+		b.writeBlameContext(&sb, repoPath, base, diff)
+	}
+	b.writeSBOMChanges(&sb, repoPath, reviewType, base, "")
+	b.writeBenchmarks(&sb, repoPath, reviewType, "HEAD", "")
+	if !quick {
+		b.writeWorkingTreeChecks(&sb, repoPath, "HEAD", patch, diff)
+	}
+	writeOmittedFiles(&sb, omitted)
 
 	// Build diff section
-	var diffSection strings.Builder
-	diffSection.WriteString("### Diff\n\n")
-	diffSection.WriteString("```diff\n")
-	diffSection.WriteString(diff)
+	var diffBlock strings.Builder
+	diffBlock.WriteString("```diff\n")
+	diffBlock.WriteString(diff)
 	if !strings.HasSuffix(diff, "\n") {
-		diffSection.WriteString("\n")
+		diffBlock.WriteString("\n")
 	}
-	diffSection.WriteString("```\n")
+	diffBlock.WriteString("```\n")
+	var diffSection strings.Builder
+	diffSection.WriteString("### Diff\n\n")
+	diffSection.WriteString(wrapUntrusted("diff", diffBlock.String()))
 
-	// Check if adding the diff would exceed max prompt size
-	if sb.Len()+diffSection.Len() > MaxPromptSize {
+	// Check if adding the diff would exceed the agent's prompt budget
+	budget := b.promptBudget(repoPath, agentName)
+	if !budget.Fits(sb.String(), diffSection.String()) {
 		// For dirty changes, we can't tell them to "use git diff" because
 		// the working tree may have changed. Just truncate with a note.
 		sb.WriteString("### Diff\n\n")
 		sb.WriteString("(Diff too large to include in full)\n")
 		// Include truncated diff
-		maxDiffLen := MaxPromptSize - sb.Len() - 100 // Leave room for closing markers
+		maxDiffLen := budget.FitBytes(sb.String(), diff) - 200 // Leave room for closing markers
 		if maxDiffLen > 1000 {
-			sb.WriteString("```diff\n")
-			sb.WriteString(diff[:maxDiffLen])
-			sb.WriteString("\n... (truncated)\n")
-			sb.WriteString("```\n")
+			sb.WriteString(wrapUntrusted("diff", "```diff\n"+diff[:maxDiffLen]+"\n... (truncated)\n```\n"))
 		}
 	} else {
 		sb.WriteString(diffSection.String())
+		b.writeRelatedTests(&sb, repoPath, "", diff, budget)
 	}
 
 	return sb.String(), nil
@@ -211,17 +436,28 @@ func (b *Builder) buildSinglePrompt(repoPath, sha string, repoID int64, contextC
 	if promptType == "design" {
 		promptType = "design-review"
 	}
-	sb.WriteString(GetSystemPrompt(agentName, promptType))
+	target := reviewTarget(repoPath, sha)
+	sb.WriteString(withFindingCategories(repoSystemPrompt(repoPath, agentName, promptType, target), promptType, config.ResolveCategories(repoPath)))
 	sb.WriteString("\n")
+	b.writeOutputLanguage(&sb, repoPath)
 
-	// Add project-specific guidelines if configured
-	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
-		b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines)
+	// Add project-specific guidelines, including those of the directories
+	// the change touches, if configured
+	repoCfg, _ := config.LoadRepoConfig(repoPath)
+	if repoCfg == nil {
+		repoCfg = &config.RepoConfig{}
 	}
+	b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines, nestedGuidelines(repoPath, targetFiles(repoPath, sha)), promptVars(repoPath, agentName, promptType, target))
+	b.writeRequiredSections(&sb, repoCfg.RequiredSections)
+	b.writeCommitMessageReview(&sb, repoCfg.ReviewCommitMessage, false)
+	b.writeSeverityLevels(&sb, repoCfg.Severities)
+	b.writeFindingCategories(&sb, repoCfg.Categories)
+	b.writeFindingsLimit(&sb, config.ResolveMaxFindings(repoPath, b.cfg))
+	sb.WriteString(FindingsFormatHeader)
 
 	// Get previous reviews if requested
 	if contextCount > 0 && b.db != nil {
-		contexts, err := b.getPreviousReviewContexts(repoPath, sha, contextCount)
+		contexts, err := b.getPreviousReviewContexts(repoPath, repoID, sha, contextCount)
 		if err != nil {
 			// Log but don't fail - previous reviews are nice-to-have context
 			// Just continue without them
@@ -231,7 +467,9 @@ func (b *Builder) buildSinglePrompt(repoPath, sha string, repoID int64, contextC
 	}
 
 	// Include previous review attempts for this same commit (for re-reviews)
-	b.writePreviousAttemptsForGitRef(&sb, sha)
+	if reviewType != "quick" {
+		b.writePreviousAttemptsForGitRef(&sb, sha)
+	}
 
 	// Current commit section
 	shortSHA := sha
@@ -245,44 +483,162 @@ func (b *Builder) buildSinglePrompt(repoPath, sha string, repoID int64, contextC
 		return "", fmt.Errorf("get commit info: %w", err)
 	}
 
-	sb.WriteString("## Current Commit\n\n")
-	sb.WriteString(fmt.Sprintf("**Commit:** %s\n", shortSHA))
-	sb.WriteString(fmt.Sprintf("**Author:** %s\n", info.Author))
-	sb.WriteString(fmt.Sprintf("**Subject:** %s\n", info.Subject))
+	sb.WriteString(UntrustedContentNotice)
+	contextFiles := writeSection(&sb, func(s *strings.Builder) { b.writeContextFiles(s, repoPath, agentName) })
+
+	var commit strings.Builder
+	b.writePriorFeedback(&commit)
+	commit.WriteString("## Current Commit\n\n")
+	commit.WriteString(fmt.Sprintf("**Commit:** %s\n", shortSHA))
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("**Author:** %s\n", info.Author))
+	message.WriteString(fmt.Sprintf("**Subject:** %s\n", info.Subject))
 	if info.Body != "" {
-		sb.WriteString(fmt.Sprintf("\n**Message:**\n%s\n", info.Body))
+		message.WriteString(fmt.Sprintf("\n**Message:**\n%s\n", info.Body))
 	}
-	sb.WriteString("\n")
+	commit.WriteString(wrapUntrusted("commit message", message.String()))
+	commit.WriteString("\n")
 
 	// Get and include the diff
 	diff, err := git.GetDiff(repoPath, sha)
 	if err != nil {
 		return "", fmt.Errorf("get diff: %w", err)
 	}
-
-	// Build diff section
-	var diffSection strings.Builder
-	diffSection.WriteString("### Diff\n\n")
-	diffSection.WriteString("```diff\n")
-	diffSection.WriteString(diff)
-	if !strings.HasSuffix(diff, "\n") {
-		diffSection.WriteString("\n")
+	diff, omitted := omitFiles(b.context(), repoPath, diff, parentRef(repoPath, sha), sha)
+	readFile := func(p string) ([]byte, error) {
+		return git.ReadFile(repoPath, sha, p)
+	}
+	var omittedNote strings.Builder
+	writeOmittedFiles(&omittedNote, omitted)
+
+	// The context around the diff, in prompt order. Sections with a drop
+	// rank are left out, lowest rank first, when the diff doesn't fit
+	// alongside them. Quick reviews skip the slow context: blame, CI
+	// status, and the repo's linters, tests, and coverage.
+	quick := reviewType == "quick"
+	parts := []promptPart{
+		{text: sb.String()},
+		{text: contextFiles, drop: 6},
+		{text: commit.String()},
+		{text: writeSection(nil, func(s *strings.Builder) { b.writeSecurityAdvisories(s, repoPath, reviewType, diff, readFile) }), drop: 8},
+		{text: writeSection(nil, func(s *strings.Builder) { b.writeDependencyManifests(s, repoPath, diff, readFile) }), drop: 5},
+	}
+	if !quick {
+		parts = append(parts, promptPart{text: writeSection(nil, func(s *strings.Builder) { b.writeBlameContext(s, repoPath, parentRef(repoPath, sha), diff) }), drop: 4})
+	}
+	parts = append(parts,
+		promptPart{text: writeSection(nil, func(s *strings.Builder) { b.writeSBOMChanges(s, repoPath, reviewType, parentRef(repoPath, sha), sha) }), drop: 2},
+		promptPart{text: writeSection(nil, func(s *strings.Builder) { b.writeBenchmarks(s, repoPath, reviewType, sha+"^", sha) }), drop: 1},
+	)
+	if !quick {
+		parts = append(parts, promptPart{text: writeSection(nil, func(s *strings.Builder) { b.writeCIStatus(s, repoPath, sha) }), drop: 3})
+		if isCheckedOut(repoPath, sha) {
+			parts = append(parts, promptPart{text: writeSection(nil, func(s *strings.Builder) { b.writeWorkingTreeChecks(s, repoPath, sha, "", diff) }), drop: 7})
+		}
+	}
+	parts = append(parts, promptPart{text: omittedNote.String()})
+
+	// Check if adding the diff would exceed the agent's prompt budget, and
+	// try it without the optional context, then with less diff context,
+	// before leaving it out
+	budget := b.promptBudget(repoPath, agentName)
+	section := diffSection("### Diff", "", diff)
+	prompt, ok := fitPromptParts(budget, parts, section)
+	if !ok {
+		if compact := compactDiff(repoPath, diff, func(n int) (string, error) {
+			return git.GetDiffWithContext(repoPath, sha, n)
+		}); compact != "" {
+			section = diffSection("### Diff", CompactDiffNote, compact)
+			prompt, ok = fitPromptParts(budget, parts, section)
+		}
 	}
-	diffSection.WriteString("```\n")
 
-	// Check if adding the diff would exceed max prompt size
-	if sb.Len()+diffSection.Len() > MaxPromptSize {
-		// Fall back to just commit info without diff
+	sb.Reset()
+	if !ok {
+		// Fall back to commit info and its context without the diff
+		for _, part := range parts {
+			sb.WriteString(part.text)
+		}
 		sb.WriteString("### Diff\n\n")
 		sb.WriteString("(Diff too large to include - please review the commit directly)\n")
 		sb.WriteString(fmt.Sprintf("View with: git show %s\n", sha))
 	} else {
-		sb.WriteString(diffSection.String())
+		sb.WriteString(prompt)
+		sb.WriteString(section)
+		b.writeRelatedTests(&sb, repoPath, sha, diff, budget)
 	}
 
 	return sb.String(), nil
 }
 
+// promptPart is a piece of a prompt. Parts with a drop rank above zero are
+// optional context, left out lowest rank first when the prompt runs over
+// its budget.
+type promptPart struct {
+	text string
+	drop int
+}
+
+// writeSection returns what write adds to a prompt. write sees the prompt
+// written so far in sb, when given, for sections that size themselves
+// against it.
+func writeSection(sb *strings.Builder, write func(*strings.Builder)) string {
+	var s strings.Builder
+	used := 0
+	if sb != nil {
+		s.WriteString(sb.String())
+		used = sb.Len()
+	}
+	write(&s)
+	return s.String()[used:]
+}
+
+// fitPromptParts joins parts, leaving out optional ones until what's left
+// fits in budget alongside tail. ok is false when tail doesn't fit even
+// without any optional parts.
+func fitPromptParts(budget PromptBudget, parts []promptPart, tail string) (string, bool) {
+	sizes := make([]int, len(parts))
+	total := budget.size(tail)
+	for i, part := range parts {
+		sizes[i] = budget.size(part.text)
+		total += sizes[i]
+	}
+
+	order := make([]int, 0, len(parts))
+	for i, part := range parts {
+		if part.drop > 0 && part.text != "" {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return parts[order[a]].drop < parts[order[b]].drop })
+
+	dropped := make([]bool, len(parts))
+	for _, i := range order {
+		if total <= budget.limit() {
+			break
+		}
+		dropped[i] = true
+		total -= sizes[i]
+	}
+	if total > budget.limit() {
+		return "", false
+	}
+
+	var sb strings.Builder
+	n := 0
+	for i, part := range parts {
+		if dropped[i] {
+			n++
+			continue
+		}
+		sb.WriteString(part.text)
+	}
+	if n > 0 {
+		log.Printf("prompt: left out %d context section(s) to fit the diff in the prompt budget", n)
+	}
+	return sb.String(), true
+}
+
 // buildRangePrompt constructs a prompt for a commit range
 func (b *Builder) buildRangePrompt(repoPath, rangeRef string, repoID int64, contextCount int, agentName, reviewType string) (string, error) {
 	var sb strings.Builder
@@ -295,19 +651,34 @@ func (b *Builder) buildRangePrompt(repoPath, rangeRef string, repoID int64, cont
 	if promptType == "design" {
 		promptType = "design-review"
 	}
-	sb.WriteString(GetSystemPrompt(agentName, promptType))
+	target := reviewTarget(repoPath, rangeRef)
+	sb.WriteString(withFindingCategories(repoSystemPrompt(repoPath, agentName, promptType, target), promptType, config.ResolveCategories(repoPath)))
 	sb.WriteString("\n")
+	b.writeOutputLanguage(&sb, repoPath)
 
-	// Add project-specific guidelines if configured
-	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
-		b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines)
+	// Add project-specific guidelines, including those of the directories
+	// the change touches, if configured
+	repoCfg, _ := config.LoadRepoConfig(repoPath)
+	if repoCfg == nil {
+		repoCfg = &config.RepoConfig{}
 	}
+	b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines, nestedGuidelines(repoPath, targetFiles(repoPath, rangeRef)), promptVars(repoPath, agentName, promptType, target))
+	b.writeRequiredSections(&sb, repoCfg.RequiredSections)
+	b.writeCommitMessageReview(&sb, repoCfg.ReviewCommitMessage, true)
+	if b.perCommit {
+		sb.WriteString(PerCommitReviewHeader)
+		sb.WriteString("\n")
+	}
+	b.writeSeverityLevels(&sb, repoCfg.Severities)
+	b.writeFindingCategories(&sb, repoCfg.Categories)
+	b.writeFindingsLimit(&sb, config.ResolveMaxFindings(repoPath, b.cfg))
+	sb.WriteString(FindingsFormatHeader)
 
 	// Get previous reviews from before the range start
 	if contextCount > 0 && b.db != nil {
 		startSHA, err := git.GetRangeStart(repoPath, rangeRef)
 		if err == nil {
-			contexts, err := b.getPreviousReviewContexts(repoPath, startSHA, contextCount)
+			contexts, err := b.getPreviousReviewContexts(repoPath, repoID, startSHA, contextCount)
 			if err == nil && len(contexts) > 0 {
 				b.writePreviousReviews(&sb, contexts)
 			}
@@ -315,7 +686,9 @@ func (b *Builder) buildRangePrompt(repoPath, rangeRef string, repoID int64, cont
 	}
 
 	// Include previous review attempts for this same range (for re-reviews)
-	b.writePreviousAttemptsForGitRef(&sb, rangeRef)
+	if reviewType != "quick" {
+		b.writePreviousAttemptsForGitRef(&sb, rangeRef)
+	}
 
 	// Get commits in range
 	commits, err := git.GetRangeCommits(repoPath, rangeRef)
@@ -324,9 +697,13 @@ func (b *Builder) buildRangePrompt(repoPath, rangeRef string, repoID int64, cont
 	}
 
 	// Commit range section
+	sb.WriteString(UntrustedContentNotice)
+	b.writeContextFiles(&sb, repoPath, agentName)
+	b.writePriorFeedback(&sb)
 	sb.WriteString("## Commit Range\n\n")
 	sb.WriteString(fmt.Sprintf("Reviewing %d commits:\n\n", len(commits)))
 
+	var subjects strings.Builder
 	for _, sha := range commits {
 		info, err := git.GetCommitInfo(repoPath, sha)
 		shortSHA := sha
@@ -334,11 +711,18 @@ func (b *Builder) buildRangePrompt(repoPath, rangeRef string, repoID int64, cont
 			shortSHA = shortSHA[:7]
 		}
 		if err == nil {
-			sb.WriteString(fmt.Sprintf("- %s %s\n", shortSHA, info.Subject))
+			subjects.WriteString(fmt.Sprintf("- %s %s\n", shortSHA, info.Subject))
+			// Bodies are only needed to assess the messages themselves
+			if repoCfg.ReviewCommitMessage && info.Body != "" {
+				for _, line := range strings.Split(strings.TrimRight(info.Body, "\n"), "\n") {
+					subjects.WriteString("  " + line + "\n")
+				}
+			}
 		} else {
-			sb.WriteString(fmt.Sprintf("- %s\n", shortSHA))
+			subjects.WriteString(fmt.Sprintf("- %s\n", shortSHA))
 		}
 	}
+	sb.WriteString(wrapUntrusted("commit messages", subjects.String()))
 	sb.WriteString("\n")
 
 	// Get and include the combined diff for the range
@@ -346,25 +730,57 @@ func (b *Builder) buildRangePrompt(repoPath, rangeRef string, repoID int64, cont
 	if err != nil {
 		return "", fmt.Errorf("get range diff: %w", err)
 	}
-
-	// Build diff section
-	var diffSection strings.Builder
-	diffSection.WriteString("### Combined Diff\n\n")
-	diffSection.WriteString("```diff\n")
-	diffSection.WriteString(diff)
-	if !strings.HasSuffix(diff, "\n") {
-		diffSection.WriteString("\n")
+	rangeStart, rangeEnd, _ := git.ParseRange(rangeRef)
+	diff, omitted := omitFiles(b.context(), repoPath, diff, rangeStart, rangeEnd)
+	readFile := func(p string) ([]byte, error) {
+		return git.ReadFile(repoPath, rangeEnd, p)
 	}
-	diffSection.WriteString("```\n")
-
-	// Check if adding the diff would exceed max prompt size
-	if sb.Len()+diffSection.Len() > MaxPromptSize {
+	// Quick reviews skip the slow context: blame, CI status, and the
+	// repo's linters, tests, and coverage
+	quick := reviewType == "quick"
+	b.writeSecurityAdvisories(&sb, repoPath, reviewType, diff, readFile)
+	b.writeDependencyManifests(&sb, repoPath, diff, readFile)
+	if !quick {
+		b.writeBlameContext(&sb, repoPath, rangeStart, diff)
+	}
+	b.writeSBOMChanges(&sb, repoPath, reviewType, rangeStart, rangeEnd)
+	b.writeBenchmarks(&sb, repoPath, reviewType, rangeStart, rangeEnd)
+	if !quick {
+		b.writeCIStatus(&sb, repoPath, rangeEnd)
+		if isCheckedOut(repoPath, rangeEnd) {
+			b.writeWorkingTreeChecks(&sb, repoPath, rangeEnd, "", diff)
+		}
+	}
+	writeOmittedFiles(&sb, omitted)
+
+	// Check if adding the diff would exceed the agent's prompt budget, and
+	// try it with less context before leaving it out
+	budget := b.promptBudget(repoPath, agentName)
+	note := ""
+	if b.perCommit {
+		if perCommit := perCommitDiffs(b.context(), repoPath, commits); perCommit != "" && budget.Fits(sb.String(), perCommit) {
+			sb.WriteString(perCommit)
+			b.writeRelatedTests(&sb, repoPath, rangeEnd, diff, budget)
+			return sb.String(), nil
+		}
+		note = PerCommitFallbackNote
+	}
+	section := diffSection("### Combined Diff", note, diff)
+	if !budget.Fits(sb.String(), section) {
+		if compact := compactDiff(repoPath, diff, func(n int) (string, error) {
+			return git.GetRangeDiffWithContext(repoPath, rangeRef, n)
+		}); compact != "" {
+			section = diffSection("### Combined Diff", CompactDiffNote, compact)
+		}
+	}
+	if !budget.Fits(sb.String(), section) {
 		// Fall back to just commit info without diff
 		sb.WriteString("### Combined Diff\n\n")
 		sb.WriteString("(Diff too large to include - please review the commits directly)\n")
 		sb.WriteString(fmt.Sprintf("View with: git diff %s\n", rangeRef))
 	} else {
-		sb.WriteString(diffSection.String())
+		sb.WriteString(section)
+		b.writeRelatedTests(&sb, repoPath, rangeEnd, diff, budget)
 	}
 
 	return sb.String(), nil
@@ -375,6 +791,28 @@ func (b *Builder) writePreviousReviews(sb *strings.Builder, contexts []ReviewCon
 	sb.WriteString(PreviousReviewsHeader)
 	sb.WriteString("\n")
 
+	// A finding repeated across reviews is listed once, under the newest
+	// review that reported it, with the number of reviews that did
+	reportedIn := make(map[string]int)
+	newestIn := make(map[string]int)
+	for i, ctx := range contexts {
+		if ctx.Review == nil {
+			continue
+		}
+		keys := make(map[string]bool)
+		for _, f := range ctx.Findings {
+			key := findingKey(f)
+			if keys[key] {
+				continue
+			}
+			keys[key] = true
+			reportedIn[key]++
+			if _, ok := newestIn[key]; !ok {
+				newestIn[key] = i
+			}
+		}
+	}
+
 	// Show in chronological order (oldest first) for narrative flow
 	for i := len(contexts) - 1; i >= 0; i-- {
 		ctx := contexts[i]
@@ -383,13 +821,40 @@ func (b *Builder) writePreviousReviews(sb *strings.Builder, contexts []ReviewCon
 			shortSHA = shortSHA[:7]
 		}
 
-		sb.WriteString(fmt.Sprintf("--- Review for commit %s ---\n", shortSHA))
-		if ctx.Review != nil {
+		switch {
+		case ctx.Review != nil && len(ctx.Findings) > 0:
+			status := "open"
+			if ctx.Review.Addressed {
+				status = "addressed"
+			}
+			sb.WriteString(fmt.Sprintf("--- Review for commit %s (%d findings, %s) ---\n", shortSHA, len(ctx.Findings), status))
+			var listed []storage.Finding
+			repeated := 0
+			for _, f := range ctx.Findings {
+				key := findingKey(f)
+				if newestIn[key] != i {
+					repeated++
+					continue
+				}
+				if n := reportedIn[key]; n > 1 {
+					f.Message += fmt.Sprintf(" (reported in %d reviews)", n)
+				}
+				listed = append(listed, f)
+			}
+			if len(listed) > 0 {
+				writeFindingsTable(sb, listed)
+			}
+			if repeated > 0 {
+				sb.WriteString(fmt.Sprintf("%d findings reported again by a later review are listed there.\n", repeated))
+			}
+		case ctx.Review != nil:
+			sb.WriteString(fmt.Sprintf("--- Review for commit %s ---\n", shortSHA))
 			sb.WriteString(ctx.Review.Output)
-		} else {
-			sb.WriteString("No review available.")
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(fmt.Sprintf("--- Review for commit %s ---\n", shortSHA))
+			sb.WriteString("No review available.\n")
 		}
-		sb.WriteString("\n")
 
 		// Include responses to this review
 		if len(ctx.Responses) > 0 {
@@ -402,16 +867,289 @@ func (b *Builder) writePreviousReviews(sb *strings.Builder, contexts []ReviewCon
 	}
 }
 
-// writeProjectGuidelines writes the project-specific guidelines section
-func (b *Builder) writeProjectGuidelines(sb *strings.Builder, guidelines string) {
-	if guidelines == "" {
+// findingKey identifies a finding across reviews by its severity, file, and
+// message. Line numbers are left out since they shift as code changes.
+func findingKey(f storage.Finding) string {
+	message := strings.Join(strings.Fields(strings.ToLower(f.Message)), " ")
+	return strings.ToLower(f.Severity) + "\x00" + f.File + "\x00" + message
+}
+
+// writeFindingsTable lists findings as a markdown table, one row each
+func writeFindingsTable(sb *strings.Builder, findings []storage.Finding) {
+	cell := strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+	sb.WriteString("| Severity | Location | Finding |\n")
+	sb.WriteString("|----------|----------|---------|\n")
+	for _, f := range findings {
+		location := f.File
+		if f.File != "" && f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n", cell.Replace(f.SeverityLabel()), cell.Replace(location), cell.Replace(f.Message)))
+	}
+}
+
+// reviewTarget returns the template variables describing gitRef: a commit,
+// a range, or "dirty" for uncommitted changes. Details git can't provide
+// are left empty.
+func reviewTarget(repoPath, gitRef string) PromptVars {
+	var target PromptVars
+	switch {
+	case gitRef == "dirty":
+		target.CommitSHA, _ = git.ResolveSHA(repoPath, "HEAD")
+		target.Branch = git.GetCurrentBranch(repoPath)
+		return target
+	case git.IsRange(gitRef):
+		_, end, _ := git.ParseRange(gitRef)
+		target.CommitSHA, _ = git.ResolveSHA(repoPath, end)
+		commits, _ := git.GetRangeCommits(repoPath, gitRef)
+		var authors []string
+		seen := make(map[string]bool)
+		for _, sha := range commits {
+			if info, err := git.GetCommitInfo(repoPath, sha); err == nil && !seen[info.Author] {
+				seen[info.Author] = true
+				authors = append(authors, info.Author)
+			}
+		}
+		target.Author = strings.Join(authors, ", ")
+	default:
+		target.CommitSHA, _ = git.ResolveSHA(repoPath, gitRef)
+		if info, err := git.GetCommitInfo(repoPath, gitRef); err == nil {
+			target.Author = info.Author
+		}
+	}
+	if target.CommitSHA != "" {
+		target.Branch = git.GetBranchName(repoPath, target.CommitSHA)
+	}
+	return target
+}
+
+// writeProjectGuidelines writes the project-specific guidelines section,
+// with template variables expanded. Guidelines from subdirectories follow
+// the repo's own, each labeled with the directory it applies to, and the
+// organization's synced guidelines come last.
+func (b *Builder) writeProjectGuidelines(sb *strings.Builder, guidelines string, nested []dirGuidelines, vars PromptVars) {
+	org := b.orgGuidelines()
+	if guidelines == "" && len(nested) == 0 && org == "" {
 		return
 	}
 
 	sb.WriteString(ProjectGuidelinesHeader)
 	sb.WriteString("\n")
-	sb.WriteString(strings.TrimSpace(guidelines))
-	sb.WriteString("\n\n")
+	if guidelines != "" {
+		sb.WriteString(strings.TrimSpace(expandGuidelines(guidelines, vars)))
+		sb.WriteString("\n\n")
+	}
+	for _, n := range nested {
+		fmt.Fprintf(sb, "For changes under `%s/`:\n", n.Dir)
+		sb.WriteString(strings.TrimSpace(expandGuidelines(n.Guidelines, vars)))
+		sb.WriteString("\n\n")
+	}
+	if org != "" {
+		sb.WriteString("Organization-wide guidelines (the repository's own guidelines above take precedence where they conflict):\n")
+		sb.WriteString(strings.TrimSpace(expandGuidelines(org, vars)))
+		sb.WriteString("\n\n")
+	}
+}
+
+// writeOutputLanguage asks for the response in the configured language, if
+// there is one
+func (b *Builder) writeOutputLanguage(sb *strings.Builder, repoPath string) {
+	if language := config.ResolveOutputLanguage(repoPath, b.cfg); language != "" {
+		fmt.Fprintf(sb, OutputLanguageHeader, language)
+	}
+}
+
+// writePriorFeedback includes the pull request's human review comments, if
+// the builder has any
+func (b *Builder) writePriorFeedback(sb *strings.Builder) {
+	if strings.TrimSpace(b.priorFeedback) == "" {
+		return
+	}
+
+	sb.WriteString(PriorFeedbackHeader)
+	sb.WriteString("\n")
+	sb.WriteString(wrapUntrusted("pull request comments", b.priorFeedback))
+	sb.WriteString("\n")
+}
+
+// writeRequiredSections lists the output sections the review must include
+func (b *Builder) writeRequiredSections(sb *strings.Builder, sections []string) {
+	if len(sections) == 0 {
+		return
+	}
+
+	sb.WriteString(RequiredSectionsHeader)
+	sb.WriteString("\n")
+	for _, section := range sections {
+		sb.WriteString("- " + section + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+// writeSeverityLevels lists the repo's severity labels, if it defines any
+func (b *Builder) writeSeverityLevels(sb *strings.Builder, levels []config.SeverityLevel) {
+	levels = config.NormalizeSeverities(levels)
+	if len(levels) == 0 {
+		return
+	}
+
+	sb.WriteString(SeverityLevelsHeader)
+	sb.WriteString("\n")
+	for _, l := range levels {
+		if l.Description != "" {
+			fmt.Fprintf(sb, "- **%s**: %s\n", l.Name, l.Description)
+		} else {
+			fmt.Fprintf(sb, "- **%s**\n", l.Name)
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// writeSecurityAdvisories lists known advisories for the dependencies a
+// security review's diff changes, unless the repo disables the lookup
+func (b *Builder) writeSecurityAdvisories(sb *strings.Builder, repoPath, reviewType, diff string, readFile func(path string) ([]byte, error)) {
+	if reviewType != "security" {
+		return
+	}
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil &&
+		repoCfg.SecurityAdvisories != nil && !*repoCfg.SecurityAdvisories {
+		return
+	}
+	writeAdvisories(sb, lookupAdvisories(repoPath, diff, readFile))
+}
+
+// writeBenchmarks compares the repo's benchmarks at baseRef and targetRef
+// for bench reviews. An empty targetRef means the working tree.
+func (b *Builder) writeBenchmarks(sb *strings.Builder, repoPath, reviewType, baseRef, targetRef string) {
+	if reviewType != "bench" {
+		return
+	}
+	var command string
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		command = repoCfg.BenchCommand
+	}
+	if command == "" {
+		writeBenchComparison(sb, "", "", nil)
+		return
+	}
+	comparison, err := runBenchmarks(b.context(), repoPath, command, baseRef, targetRef)
+	writeBenchComparison(sb, command, comparison, err)
+}
+
+// writeWorkingTreeChecks runs the repo's configured linters on the files the
+// diff changes and its test command if set, in a temporary worktree at ref
+// with patch applied so the user's checkout is left alone, and summarizes
+// coverage from its coverage profiles. Coverage reflects the working tree,
+// so callers only use this when the reviewed code is what's checked out.
+func (b *Builder) writeWorkingTreeChecks(sb *strings.Builder, repoPath, ref, patch, diff string) {
+	repoCfg, err := config.LoadRepoConfig(repoPath)
+	if err != nil || repoCfg == nil {
+		return
+	}
+	if len(repoCfg.Linters) > 0 || repoCfg.TestCommand != "" {
+		b.writeChecksAt(sb, repoPath, ref, patch, diff, repoCfg)
+	}
+	if len(repoCfg.CoverageProfiles) > 0 {
+		writeCoverage(sb, coverageResults(loadCoverage(repoPath, repoCfg.CoverageProfiles), diff))
+	}
+}
+
+// writeChecksAt runs the linters and test command in a temporary worktree
+// at ref with patch applied
+func (b *Builder) writeChecksAt(sb *strings.Builder, repoPath, ref, patch, diff string, repoCfg *config.RepoConfig) {
+	dir, cleanup, err := git.AddDetachedWorktree(repoPath, ref)
+	if err != nil {
+		log.Printf("checks: %v", err)
+		return
+	}
+	defer cleanup()
+	if patch != "" {
+		if err := git.ApplyPatch(dir, patch); err != nil {
+			log.Printf("checks: %v", err)
+			return
+		}
+	}
+	if len(repoCfg.Linters) > 0 {
+		writeLintResults(sb, runLinters(b.context(), dir, repoCfg.Linters, git.ChangedFiles(diff)))
+	}
+	if repoCfg.TestCommand != "" {
+		writeTestFailures(sb, repoCfg.TestCommand, runTests(b.context(), dir, repoCfg.TestCommand))
+	}
+}
+
+// parentRef returns sha's first parent, or the empty tree for a root commit
+func parentRef(repoPath, sha string) string {
+	if parent, err := git.ResolveSHA(repoPath, sha+"^"); err == nil {
+		return parent
+	}
+	return git.EmptyTreeSHA
+}
+
+// isCheckedOut reports whether ref resolves to the repo's HEAD
+func isCheckedOut(repoPath, ref string) bool {
+	sha, err := git.ResolveSHA(repoPath, ref)
+	if err != nil {
+		return false
+	}
+	head, err := git.ResolveSHA(repoPath, "HEAD")
+	return err == nil && sha == head
+}
+
+// writeFindingsLimit asks the agent to list only its most important
+// findings when the review is capped
+func (b *Builder) writeFindingsLimit(sb *strings.Builder, maxFindings int) {
+	if maxFindings <= 0 {
+		return
+	}
+
+	sb.WriteString(FindingsLimitHeader)
+	fmt.Fprintf(sb, "\nReport at most %d findings, choosing the most important and ordering them by\n", maxFindings)
+	sb.WriteString("severity. If there are more, summarize the rest in one short paragraph at the\n")
+	sb.WriteString("end of the review instead of listing them.\n\n")
+}
+
+// MissingSections returns the required sections that don't appear in the
+// output as a heading or bold label. Matching ignores case, markdown markers,
+// and trailing colons, and accepts text after the section name.
+func MissingSections(output string, sections []string) []string {
+	var labels []string
+	for _, line := range strings.Split(output, "\n") {
+		label := strings.TrimSpace(line)
+		if !strings.HasPrefix(label, "#") && !strings.HasPrefix(label, "**") {
+			continue
+		}
+		label = strings.Trim(label, "#*: \t")
+		labels = append(labels, strings.ToLower(label))
+	}
+
+	var missing []string
+	for _, section := range sections {
+		want := strings.ToLower(strings.TrimSpace(section))
+		found := false
+		for _, label := range labels {
+			if strings.HasPrefix(label, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, section)
+		}
+	}
+	return missing
+}
+
+// CorrectionInstruction is appended to the original prompt when a review's
+// output was rejected, listing what was wrong with it
+func CorrectionInstruction(problems []string) string {
+	var sb strings.Builder
+	sb.WriteString("\n## Correction\n\n")
+	sb.WriteString("Your previous response could not be used as a review:\n")
+	for _, problem := range problems {
+		sb.WriteString("- " + problem + "\n")
+	}
+	sb.WriteString("\nWrite the complete review again and fix these problems. Follow the output format described above.\n")
+	return sb.String()
 }
 
 // writePreviousAttemptsForGitRef writes previous review attempts for the same git ref (commit or range)
@@ -448,23 +1186,31 @@ func (b *Builder) writePreviousAttemptsForGitRef(sb *strings.Builder, gitRef str
 	}
 }
 
-// getPreviousReviewContexts gets the N commits before the target and looks up their reviews and responses
-func (b *Builder) getPreviousReviewContexts(repoPath, sha string, count int) ([]ReviewContext, error) {
+// getPreviousReviewContexts gets the N commits before the target and looks up their reviews and responses.
+// Reviews are looked up in repoID so a fork or mirror with the same history doesn't supply them;
+// a repoID of 0 matches any repo.
+func (b *Builder) getPreviousReviewContexts(repoPath string, repoID int64, sha string, count int) ([]ReviewContext, error) {
 	// Get parent commits from git
 	parentSHAs, err := git.GetParentCommits(repoPath, sha, count)
 	if err != nil {
 		return nil, fmt.Errorf("get parent commits: %w", err)
 	}
 
+	findings := config.ResolvePreviousReviewsFormat(repoPath, b.cfg) == config.PreviousReviewsFindings
 	var contexts []ReviewContext
 	for _, parentSHA := range parentSHAs {
 		ctx := ReviewContext{SHA: parentSHA}
 
 		// Try to look up review for this commit
-		review, err := b.db.GetReviewByCommitSHA(parentSHA)
+		review, err := b.db.GetReviewByRepoAndCommitSHA(repoID, parentSHA)
 		if err == nil {
 			ctx.Review = review
 
+			// Reviews without stored findings keep their full text
+			if findings && review.JobID > 0 {
+				ctx.Findings, _ = b.db.GetFindingsForJob(review.JobID)
+			}
+
 			// Also fetch comments for this review's job
 			if review.JobID > 0 {
 				responses, err := b.db.GetCommentsForJob(review.JobID)
@@ -537,6 +1283,95 @@ For each finding, provide:
 If you find no security issues, state "No issues found." after the summary.
 Do not report code quality or style issues unless they have security implications.`
 
+// SystemPromptBench is the instruction for benchmark regression reviews
+const SystemPromptBench = `You are a performance-focused code reviewer. Assess whether the code changes shown below make the code slower or use more memory. Focus on:
+
+1. **Measured regressions**: Benchmarks in the comparison below that got slower, allocate more, or use more memory, and the change that causes each
+2. **Noise**: Whether each difference is statistically meaningful (benchstat reports p-values and marks insignificant deltas with ~) rather than run-to-run variance
+3. **Unmeasured hot paths**: Changed code on performance-critical paths that no benchmark exercises
+4. **Algorithmic cost**: Added loops, copies, allocations, locking, or I/O that scale with input size
+5. **Trade-offs**: Whether a regression is justified by the change, and what would recover the cost
+
+For each finding, provide:
+- Severity (critical/high/medium/low)
+- The benchmark and the size of the change, or the file and line for unmeasured code
+- Description of the cause
+- Suggested remediation
+
+If there are no meaningful regressions, state "No issues found." after the summary.
+Do not report code quality or style issues unless they affect performance.`
+
+// SystemPromptPerformance is the instruction for performance-focused reviews
+const SystemPromptPerformance = `You are a performance-focused code reviewer. Analyze the code changes shown below for their effect on speed, memory, and resource use. Focus on:
+
+1. **Algorithmic complexity**: Nested loops, repeated scans, or quadratic behavior on inputs that can grow
+2. **Allocations and copies**: Unnecessary allocations in hot paths, large copies, buffers that could be reused or preallocated
+3. **I/O and queries**: N+1 queries, missing batching, unbuffered or repeated reads and writes, blocking calls on latency-sensitive paths
+4. **Concurrency**: Lock contention, work serialized that could run in parallel, unbounded goroutines or threads
+5. **Caching**: Repeated expensive computation that could be cached, and caches that can grow without bound
+6. **Resource leaks**: Unclosed files, connections, or handles, and timers or goroutines that never exit
+
+For each finding, provide:
+- Severity (critical/high/medium/low)
+- File and line reference
+- Description of the cost and when it matters (input sizes, call frequency)
+- Suggested remediation
+
+If you find no performance issues, state "No issues found." after the summary.
+Do not report code quality or style issues unless they affect performance.`
+
+// SystemPromptDocs is the instruction for documentation reviews
+const SystemPromptDocs = `You are a documentation reviewer. Analyze the code changes shown below for whether they are documented accurately and sufficiently. Focus on:
+
+1. **Stale documentation**: READMEs, guides, comments, or help text that the change makes inaccurate
+2. **Missing documentation**: New public APIs, commands, flags, configuration options, or behavior changes that users need to know about but aren't documented
+3. **Doc comments**: Exported functions and types without doc comments, or comments that don't match what the code does
+4. **Examples**: Examples that no longer work or would no longer compile
+5. **Clarity**: Documentation that is ambiguous, incomplete, or hard to follow
+6. **Changelogs and migration notes**: Breaking changes without upgrade guidance
+
+For each finding, provide:
+- Severity (high/medium/low)
+- File and line reference
+- What is missing or inaccurate
+- Suggested wording or addition
+
+If you find no documentation issues, state "No issues found." after the summary.
+Do not report code issues unless they make the documentation wrong.`
+
+// SystemPromptTests is the instruction for test coverage reviews
+const SystemPromptTests = `You are a test-focused code reviewer. Analyze whether the code changes shown below are adequately tested. Focus on:
+
+1. **Untested changes**: New or changed behavior with no test exercising it
+2. **Edge cases**: Boundary values, empty inputs, error paths, and concurrency that the tests skip
+3. **Assertions**: Tests that run code without checking its results, or check too little to catch regressions
+4. **Test quality**: Flaky timing, order dependence, shared state, or tests that touch real external services
+5. **Regression tests**: Bug fixes without a test that fails before the fix
+6. **Maintainability**: Duplicated setup that should be a helper, and tests coupled to implementation details rather than behavior
+
+For each finding, provide:
+- Severity (high/medium/low)
+- File and line reference for the untested code or the weak test
+- What is not covered or what could go wrong
+- A suggested test case
+
+If the changes are adequately tested, state "No issues found." after the summary.
+Do not report code quality or style issues in non-test code.`
+
+// SystemPromptQuick is the trimmed instruction for quick reviews, which
+// aim for feedback within a minute during active development
+const SystemPromptQuick = `You are a code reviewer giving quick feedback during active development. Review the changes shown below only for problems worth interrupting the author for:
+
+1. **Bugs**: Logic errors, crashes, and broken behavior the change introduces
+2. **Security**: Clear vulnerabilities such as injection or exposed credentials
+
+Skip style, naming, refactoring suggestions, and test coverage. Be brief: give a one-sentence summary, then each issue with:
+- Severity (high/medium/low)
+- File and line reference
+- One or two sentences on the problem and its fix
+
+If you find no issues, state "No issues found." after the summary.`
+
 // SystemPromptAddress is the instruction for addressing review findings
 const SystemPromptAddress = `You are a code assistant. Your task is to address the findings from a code review.
 
@@ -566,6 +1401,61 @@ Changes:
 
 Keep the summary concise (under 10 bullet points). Put the most important changes first.`
 
+// SystemPromptSummarize condenses an oversized review so it fits in the
+// review row while the full text is kept as an attachment.
+const SystemPromptSummarize = `You are condensing a code review that is too long to store in full.
+Rewrite the review below so it fits within %d bytes.
+
+Rules:
+- Keep every finding, ordered by severity (highest first)
+- For each finding keep its severity, file and line reference, and a one-sentence description
+- Drop explanations, code excerpts, and suggested patches
+- If the review states that no issues were found, keep that statement verbatim
+- Output only the condensed review, with no preamble
+
+## Review to Condense
+
+`
+
+// BuildSummarizePrompt constructs a prompt asking an agent to condense a
+// review to at most maxSize bytes.
+func BuildSummarizePrompt(output string, maxSize int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(SystemPromptSummarize, maxSize))
+	sb.WriteString(output)
+	if !strings.HasSuffix(output, "\n") {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// SystemPromptTranslate translates a stored review for a reader who
+// prefers another language.
+const SystemPromptTranslate = `You are translating a code review into %s.
+
+Rules:
+- Translate all prose, including finding descriptions and suggestions
+- Keep the markdown structure, severity labels, file paths, line references, identifiers, and code blocks exactly as they are
+- Keep any verdict line (e.g. "No issues found.") meaning the same, translated
+- Do not add, remove, or reorder findings
+- Output only the translated review, with no preamble
+
+## Review to Translate
+
+`
+
+// BuildTranslatePrompt constructs a prompt asking an agent to translate a
+// review into language.
+func BuildTranslatePrompt(output, language string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(SystemPromptTranslate, language))
+	sb.WriteString(output)
+	if !strings.HasSuffix(output, "\n") {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
 // PreviousAttemptsHeader introduces previous addressing attempts section
 const PreviousAttemptsHeader = `
 ## Previous Addressing Attempts
@@ -580,14 +1470,29 @@ If they were rejected for being over-engineered, keep it simpler.
 func (b *Builder) BuildAddressPrompt(repoPath string, review *storage.Review, previousAttempts []storage.Response) (string, error) {
 	var sb strings.Builder
 
+	var target PromptVars
+	var files []string
+	if job := review.Job; job != nil && job.GitRef != "" {
+		target = reviewTarget(repoPath, job.GitRef)
+		if job.DiffContent != nil {
+			files = git.ChangedFiles(*job.DiffContent)
+		} else if job.GitRef != "dirty" {
+			files = targetFiles(repoPath, job.GitRef)
+		}
+	}
+
 	// System prompt
-	sb.WriteString(GetSystemPrompt(review.Agent, "address"))
+	sb.WriteString(repoSystemPrompt(repoPath, review.Agent, "address", target))
 	sb.WriteString("\n")
+	b.writeOutputLanguage(&sb, repoPath)
 
-	// Add project-specific guidelines if configured
-	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
-		b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines)
+	// Add project-specific guidelines, including those of the directories
+	// the change touches, if configured
+	repoCfg, _ := config.LoadRepoConfig(repoPath)
+	if repoCfg == nil {
+		repoCfg = &config.RepoConfig{}
 	}
+	b.writeProjectGuidelines(&sb, repoCfg.ReviewGuidelines, nestedGuidelines(repoPath, files), promptVars(repoPath, review.Agent, "address", target))
 
 	// Include previous attempts to avoid repeating failed approaches
 	if len(previousAttempts) > 0 {
@@ -609,7 +1514,12 @@ func (b *Builder) BuildAddressPrompt(repoPath string, review *storage.Review, pr
 	// Include the original diff for context if we have job info
 	if review.Job != nil && review.Job.GitRef != "" && review.Job.GitRef != "dirty" {
 		diff, err := git.GetDiff(repoPath, review.Job.GitRef)
-		if err == nil && len(diff) > 0 && len(diff) < MaxPromptSize/2 {
+		diff = summarizeNoisyFiles(diff)
+		maxDiff := MaxPromptSize
+		if n := config.ResolveReviewPromptSize(repoPath, b.cfg); n > 0 {
+			maxDiff = n
+		}
+		if err == nil && len(diff) > 0 && len(diff) < maxDiff/2 {
 			sb.WriteString("## Original Commit Diff (for context)\n\n")
 			sb.WriteString("```diff\n")
 			sb.WriteString(diff)
@@ -620,5 +1530,5 @@ func (b *Builder) BuildAddressPrompt(repoPath string, review *storage.Review, pr
 		}
 	}
 
-	return sb.String(), nil
+	return ScrubSecrets(repoPath, sb.String()), nil
 }
@@ -0,0 +1,144 @@
+package prompt
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// RelatedTestsHeader introduces test files for changed code that the diff
+// leaves unchanged
+const RelatedTestsHeader = `### Related Tests
+
+These existing tests cover files the diff changes, but weren't changed
+themselves. Use them to judge whether the change is tested before reporting
+missing tests.
+`
+
+// maxRelatedTestBytes caps the related test files in one prompt
+const maxRelatedTestBytes = 64 * 1024
+
+// maxRelatedTestFileBytes caps one related test file
+const maxRelatedTestFileBytes = 24 * 1024
+
+// relatedTestCandidates returns where the tests for a source file would be
+// by the usual conventions of its language, or nil for test files and
+// languages without a convention
+func relatedTestCandidates(file string) []string {
+	dir, base := path.Split(file)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	switch ext {
+	case ".go":
+		if strings.HasSuffix(stem, "_test") {
+			return nil
+		}
+		return []string{dir + stem + "_test.go"}
+	case ".py":
+		if strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test") || stem == "__init__" || stem == "conftest" {
+			return nil
+		}
+		return []string{dir + "test_" + base, dir + stem + "_test.py", dir + "tests/test_" + base, "tests/test_" + base}
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		if strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") || strings.HasSuffix(stem, ".d") {
+			return nil
+		}
+		return []string{dir + stem + ".test" + ext, dir + stem + ".spec" + ext, dir + "__tests__/" + stem + ".test" + ext}
+	case ".rb":
+		if strings.HasSuffix(stem, "_spec") || strings.HasSuffix(stem, "_test") {
+			return nil
+		}
+		rel := strings.TrimPrefix(dir, "lib/")
+		if strings.HasPrefix(dir, "app/") {
+			rel = strings.TrimPrefix(dir, "app/")
+		}
+		return []string{"spec/" + rel + stem + "_spec.rb", "test/" + rel + stem + "_test.rb"}
+	case ".java", ".kt":
+		if strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests") {
+			return nil
+		}
+		testDir := strings.Replace(dir, "src/main/", "src/test/", 1)
+		return []string{testDir + stem + "Test" + ext, testDir + stem + "Tests" + ext}
+	}
+	return nil
+}
+
+// relatedTests returns the existing test counterparts of the files a diff
+// changes, leaving out tests the diff changes too
+func relatedTests(diff string, exists func(file string) bool) []string {
+	changed := make(map[string]bool)
+	for _, f := range git.ChangedFiles(diff) {
+		changed[f] = true
+	}
+	seen := make(map[string]bool)
+	var tests []string
+	for _, f := range git.ChangedFiles(diff) {
+		for _, candidate := range relatedTestCandidates(f) {
+			if changed[candidate] || seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+			if exists(candidate) {
+				tests = append(tests, candidate)
+				break
+			}
+		}
+	}
+	return tests
+}
+
+// writeRelatedTests includes unchanged test files for the changed code, as
+// of targetRef (the working tree when empty), while they fit in the budget
+// left after the rest of the prompt
+func (b *Builder) writeRelatedTests(sb *strings.Builder, repoPath, targetRef, diff string, budget PromptBudget) {
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil && repoCfg.RelatedTests != nil && !*repoCfg.RelatedTests {
+		return
+	}
+
+	contents := make(map[string][]byte)
+	tests := relatedTests(diff, func(file string) bool {
+		data, err := readVersion(repoPath, targetRef, file)
+		if err != nil {
+			return false
+		}
+		contents[file] = data
+		return true
+	})
+
+	var section strings.Builder
+	total := 0
+	for _, file := range tests {
+		content := string(contents[file])
+		if len(content) > maxRelatedTestFileBytes {
+			// Cut at a line break, which is also a character boundary
+			cut := strings.LastIndexByte(content[:maxRelatedTestFileBytes], '\n')
+			content = content[:cut+1] + "... (truncated)\n"
+		}
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if total+len(content) > maxRelatedTestBytes {
+			break
+		}
+		var entry strings.Builder
+		fmt.Fprintf(&entry, "#### %s\n\n", file)
+		entry.WriteString(wrapUntrusted("test file", "```\n"+content+"```\n"))
+		entry.WriteString("\n")
+		if !budget.Fits(sb.String(), RelatedTestsHeader, section.String(), entry.String()) {
+			break
+		}
+		section.WriteString(entry.String())
+		total += len(content)
+	}
+	if section.Len() == 0 {
+		return
+	}
+	sb.WriteString("\n")
+	sb.WriteString(RelatedTestsHeader)
+	sb.WriteString("\n")
+	sb.WriteString(section.String())
+}
@@ -0,0 +1,111 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRelatedTestCandidates(t *testing.T) {
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"internal/foo/bar.go", []string{"internal/foo/bar_test.go"}},
+		{"internal/foo/bar_test.go", nil},
+		{"pkg/util.py", []string{"pkg/test_util.py", "pkg/util_test.py", "pkg/tests/test_util.py", "tests/test_util.py"}},
+		{"src/App.tsx", []string{"src/App.test.tsx", "src/App.spec.tsx", "src/__tests__/App.test.tsx"}},
+		{"src/App.test.tsx", nil},
+		{"lib/acme/widget.rb", []string{"spec/acme/widget_spec.rb", "test/acme/widget_test.rb"}},
+		{"src/main/java/com/acme/Widget.java", []string{"src/test/java/com/acme/WidgetTest.java", "src/test/java/com/acme/WidgetTests.java"}},
+		{"README.md", nil},
+	}
+	for _, tt := range tests {
+		got := relatedTestCandidates(tt.file)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("relatedTestCandidates(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestRelatedTests(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-x
++y
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1 +1 @@
+-x
++y
+diff --git a/b_test.go b/b_test.go
+--- a/b_test.go
++++ b/b_test.go
+@@ -1 +1 @@
+-x
++y
+diff --git a/c.go b/c.go
+--- a/c.go
++++ b/c.go
+@@ -1 +1 @@
+-x
++y
+`
+	existing := map[string]bool{"a_test.go": true, "b_test.go": true}
+	got := relatedTests(diff, func(file string) bool { return existing[file] })
+	// b_test.go changes with b.go, and c.go has no tests
+	if strings.Join(got, ",") != "a_test.go" {
+		t.Errorf("relatedTests = %v, want [a_test.go]", got)
+	}
+}
+
+func TestBuildIncludesRelatedTests(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("calc.go", "package calc\n\nfunc Add(a, b int) int { return a + b }\n")
+	write("calc_test.go", "package calc\n\nfunc TestAdd(t *testing.T) {}\n")
+	git("add", ".")
+	git("commit", "-m", "add calc")
+	write("calc.go", "package calc\n\nfunc Add(a, b int) int { return b + a }\n")
+	git("commit", "-am", "change calc")
+	sha := git("rev-parse", "HEAD")
+
+	b := NewBuilder(nil)
+	prompt, err := b.Build(repoPath, sha, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(prompt, RelatedTestsHeader) || !strings.Contains(prompt, "#### calc_test.go") || !strings.Contains(prompt, "func TestAdd") {
+		t.Errorf("expected calc_test.go as a related test, got:\n%s", prompt)
+	}
+	if strings.Index(prompt, RelatedTestsHeader) < strings.Index(prompt, "### Diff") {
+		t.Error("expected related tests after the diff")
+	}
+
+	write(".roborev.toml", "related_tests = false\n")
+	prompt, err = b.Build(repoPath, sha, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(prompt, RelatedTestsHeader) {
+		t.Error("expected no related tests when related_tests is false")
+	}
+}
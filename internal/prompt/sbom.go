@@ -0,0 +1,268 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/agent"
+	"github.com/roborev-dev/roborev/internal/config"
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// SBOMHeader introduces the dependency changes found by comparing SBOMs
+const SBOMHeader = `### SBOM Changes
+
+The repo's CycloneDX software bill of materials was compared between the base
+and the changes under review. Check added and upgraded components for known
+vulnerabilities, license changes, and unexpected or unmaintained packages.
+`
+
+// sbomTimeout bounds each SBOM generation
+const sbomTimeout = 5 * time.Minute
+
+// maxSBOMChanges caps how many component changes are listed in a prompt
+const maxSBOMChanges = 100
+
+// SBOMComponent is a component listed in a CycloneDX SBOM
+type SBOMComponent struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// SBOMChange is a component whose version differs between two SBOMs
+type SBOMChange struct {
+	Name       string `json:"name"`
+	OldVersion string `json:"old_version"`
+	NewVersion string `json:"new_version"`
+}
+
+// SBOMDelta is how the components of an SBOM changed
+type SBOMDelta struct {
+	Added   []SBOMComponent `json:"added,omitempty"`
+	Removed []SBOMComponent `json:"removed,omitempty"`
+	Changed []SBOMChange    `json:"changed,omitempty"`
+}
+
+// Empty reports whether no components changed
+func (d *SBOMDelta) Empty() bool {
+	return d == nil || len(d.Added)+len(d.Removed)+len(d.Changed) == 0
+}
+
+// parseCycloneDX returns the components of a CycloneDX JSON SBOM,
+// including nested ones
+func parseCycloneDX(data []byte) ([]SBOMComponent, error) {
+	type component struct {
+		Group      string      `json:"group"`
+		Name       string      `json:"name"`
+		Version    string      `json:"version"`
+		PURL       string      `json:"purl"`
+		Components []component `json:"components"`
+	}
+	var doc struct {
+		BOMFormat  string      `json:"bomFormat"`
+		Components []component `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse CycloneDX SBOM: %w", err)
+	}
+	if doc.BOMFormat != "" && doc.BOMFormat != "CycloneDX" {
+		return nil, fmt.Errorf("unsupported SBOM format %q", doc.BOMFormat)
+	}
+
+	var out []SBOMComponent
+	var walk func([]component)
+	walk = func(cs []component) {
+		for _, c := range cs {
+			name := c.Name
+			if c.Group != "" {
+				name = c.Group + "/" + c.Name
+			}
+			out = append(out, SBOMComponent{Name: name, Version: c.Version, PURL: c.PURL})
+			walk(c.Components)
+		}
+	}
+	walk(doc.Components)
+	return out, nil
+}
+
+// componentKey identifies a component across versions: its package URL
+// without the version, or its name
+func componentKey(c SBOMComponent) string {
+	if c.PURL == "" {
+		return c.Name
+	}
+	key, _, _ := strings.Cut(c.PURL, "?")
+	key, _, _ = strings.Cut(key, "#")
+	if i := strings.LastIndex(key, "@"); i > strings.LastIndex(key, "/") {
+		key = key[:i]
+	}
+	return key
+}
+
+// diffSBOM compares the components of two SBOMs
+func diffSBOM(base, target []SBOMComponent) *SBOMDelta {
+	baseByKey := make(map[string]SBOMComponent, len(base))
+	for _, c := range base {
+		baseByKey[componentKey(c)] = c
+	}
+	targetByKey := make(map[string]SBOMComponent, len(target))
+	for _, c := range target {
+		targetByKey[componentKey(c)] = c
+	}
+
+	delta := &SBOMDelta{}
+	for key, c := range targetByKey {
+		old, ok := baseByKey[key]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, c)
+		case old.Version != c.Version:
+			delta.Changed = append(delta.Changed, SBOMChange{Name: c.Name, OldVersion: old.Version, NewVersion: c.Version})
+		}
+	}
+	for key, c := range baseByKey {
+		if _, ok := targetByKey[key]; !ok {
+			delta.Removed = append(delta.Removed, c)
+		}
+	}
+	sort.Slice(delta.Added, func(i, j int) bool { return delta.Added[i].Name < delta.Added[j].Name })
+	sort.Slice(delta.Removed, func(i, j int) bool { return delta.Removed[i].Name < delta.Removed[j].Name })
+	sort.Slice(delta.Changed, func(i, j int) bool { return delta.Changed[i].Name < delta.Changed[j].Name })
+	return delta
+}
+
+// loadSBOM returns the repo's SBOM components at ref, or in the working
+// tree when ref is empty. A command runs in a temporary worktree for a ref
+// and must print the SBOM; otherwise sbomPath is read. A missing file or
+// the empty tree has no components.
+func loadSBOM(ctx context.Context, repoPath, ref, sbomPath, command string) ([]SBOMComponent, error) {
+	if ref == git.EmptyTreeSHA {
+		return nil, nil
+	}
+	var data []byte
+	if command != "" {
+		dir := repoPath
+		if ref != "" {
+			worktree, cleanup, err := git.AddDetachedWorktree(repoPath, ref)
+			if err != nil {
+				return nil, err
+			}
+			defer cleanup()
+			dir = worktree
+		}
+		ctx, cancel := context.WithTimeout(ctx, sbomTimeout)
+		defer cancel()
+		cmd := agent.ShellCommand(ctx, command)
+		cmd.Dir = dir
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("%s: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+		}
+		data = stdout.Bytes()
+	} else {
+		var err error
+		if ref == "" {
+			data, err = os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(sbomPath)))
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+		} else {
+			// git show fails for a path the commit doesn't have
+			if data, err = git.ReadFile(repoPath, ref, sbomPath); err != nil {
+				return nil, nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseCycloneDX(data)
+}
+
+// compareSBOMs returns how the repo's SBOM changes from baseRef to
+// targetRef, or to the working tree when targetRef is empty
+func compareSBOMs(ctx context.Context, repoPath, baseRef, targetRef, sbomPath, command string) (*SBOMDelta, error) {
+	base, err := loadSBOM(ctx, repoPath, baseRef, sbomPath, command)
+	if err != nil {
+		return nil, fmt.Errorf("base %s: %w", baseRef, err)
+	}
+	target, err := loadSBOM(ctx, repoPath, targetRef, sbomPath, command)
+	if err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+	return diffSBOM(base, target), nil
+}
+
+// writeSBOMDelta lists the component changes
+func writeSBOMDelta(sb *strings.Builder, delta *SBOMDelta) {
+	if delta.Empty() {
+		return
+	}
+
+	sb.WriteString(SBOMHeader)
+	sb.WriteString("\n")
+	listed := 0
+	line := func(format string, args ...interface{}) {
+		if listed < maxSBOMChanges {
+			fmt.Fprintf(sb, format, args...)
+		}
+		listed++
+	}
+	for _, c := range delta.Added {
+		line("- added %s\n", describeComponent(c))
+	}
+	for _, c := range delta.Changed {
+		line("- changed %s: %s -> %s\n", c.Name, c.OldVersion, c.NewVersion)
+	}
+	for _, c := range delta.Removed {
+		line("- removed %s\n", describeComponent(c))
+	}
+	if listed > maxSBOMChanges {
+		fmt.Fprintf(sb, "- ... and %d more\n", listed-maxSBOMChanges)
+	}
+	sb.WriteString("\n")
+}
+
+// describeComponent renders a component as name@version (purl)
+func describeComponent(c SBOMComponent) string {
+	s := c.Name
+	if c.Version != "" {
+		s += "@" + c.Version
+	}
+	if c.PURL != "" {
+		s += " (" + c.PURL + ")"
+	}
+	return s
+}
+
+// writeSBOMChanges compares the repo's SBOM at baseRef and targetRef for
+// security reviews, when the repo configures one, and keeps the delta so
+// it can be stored with the review
+func (b *Builder) writeSBOMChanges(sb *strings.Builder, repoPath, reviewType, baseRef, targetRef string) {
+	b.sbomDelta = nil
+	if reviewType != "security" {
+		return
+	}
+	repoCfg, err := config.LoadRepoConfig(repoPath)
+	if err != nil || repoCfg == nil || (repoCfg.SBOMPath == "" && repoCfg.SBOMCommand == "") {
+		return
+	}
+	delta, err := compareSBOMs(b.context(), repoPath, baseRef, targetRef, repoCfg.SBOMPath, repoCfg.SBOMCommand)
+	if err != nil {
+		log.Printf("sbom: %v", err)
+		return
+	}
+	b.sbomDelta = delta
+	writeSBOMDelta(sb, delta)
+}
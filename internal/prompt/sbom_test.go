@@ -0,0 +1,124 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseCycloneDX(t *testing.T) {
+	data := []byte(`{
+  "bomFormat": "CycloneDX",
+  "components": [
+    {"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0"},
+    {"group": "org.apache", "name": "commons-text", "version": "1.9",
+     "components": [{"name": "shaded", "version": "2.0"}]}
+  ]
+}`)
+	got, err := parseCycloneDX(data)
+	if err != nil {
+		t.Fatalf("parseCycloneDX failed: %v", err)
+	}
+	want := []SBOMComponent{
+		{Name: "left-pad", Version: "1.3.0", PURL: "pkg:npm/left-pad@1.3.0"},
+		{Name: "org.apache/commons-text", Version: "1.9"},
+		{Name: "shaded", Version: "2.0"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("component %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if _, err := parseCycloneDX([]byte(`{"bomFormat": "SPDX"}`)); err == nil {
+		t.Error("expected an error for a non-CycloneDX document")
+	}
+}
+
+func TestDiffSBOM(t *testing.T) {
+	base := []SBOMComponent{
+		{Name: "golang.org/x/net", Version: "v0.20.0", PURL: "pkg:golang/golang.org/x/net@v0.20.0"},
+		{Name: "old-lib", Version: "1.0"},
+		{Name: "@scope/pkg", Version: "2.0.0", PURL: "pkg:npm/%40scope/pkg@2.0.0?arch=x64"},
+	}
+	target := []SBOMComponent{
+		{Name: "golang.org/x/net", Version: "v0.23.0", PURL: "pkg:golang/golang.org/x/net@v0.23.0"},
+		{Name: "new-lib", Version: "0.1"},
+		{Name: "@scope/pkg", Version: "2.0.0", PURL: "pkg:npm/%40scope/pkg@2.0.0?arch=x64"},
+	}
+	delta := diffSBOM(base, target)
+	if len(delta.Added) != 1 || delta.Added[0].Name != "new-lib" {
+		t.Errorf("Added = %+v", delta.Added)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0].Name != "old-lib" {
+		t.Errorf("Removed = %+v", delta.Removed)
+	}
+	if len(delta.Changed) != 1 || delta.Changed[0] != (SBOMChange{Name: "golang.org/x/net", OldVersion: "v0.20.0", NewVersion: "v0.23.0"}) {
+		t.Errorf("Changed = %+v", delta.Changed)
+	}
+	if !diffSBOM(base, base).Empty() {
+		t.Error("expected no changes between identical SBOMs")
+	}
+}
+
+func TestBuildSecurityPromptIncludesSBOMDelta(t *testing.T) {
+	repoPath, _ := setupTestRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeBOM := func(components string) {
+		t.Helper()
+		bom := `{"bomFormat": "CycloneDX", "components": [` + components + `]}`
+		if err := os.WriteFile(filepath.Join(repoPath, "bom.json"), []byte(bom), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeBOM(`{"name": "lodash", "version": "4.17.20"}`)
+	git("add", "bom.json")
+	git("commit", "-q", "-m", "add sbom")
+	writeBOM(`{"name": "lodash", "version": "4.17.21"}, {"name": "event-stream", "version": "3.3.6"}`)
+	git("add", "bom.json")
+	git("commit", "-q", "-m", "bump deps")
+
+	configs := map[string]string{"sbom_path": "sbom_path = \"bom.json\"\n"}
+	if runtime.GOOS != "windows" {
+		configs["sbom_command"] = "sbom_command = \"cat bom.json\"\n"
+	}
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(cfg), 0644); err != nil {
+				t.Fatal(err)
+			}
+			b := NewBuilder(nil)
+			prompt, err := b.Build(repoPath, "HEAD", 0, 0, "test", "security")
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			for _, want := range []string{"### SBOM Changes", "- added event-stream@3.3.6", "- changed lodash: 4.17.20 -> 4.17.21"} {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("expected %q in prompt:\n%s", want, prompt)
+				}
+			}
+			if delta := b.SBOMDelta(); delta == nil || len(delta.Added) != 1 || len(delta.Changed) != 1 {
+				t.Errorf("SBOMDelta() = %+v", delta)
+			}
+
+			prompt, err = b.Build(repoPath, "HEAD", 0, 0, "test", "")
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			if strings.Contains(prompt, "### SBOM Changes") || b.SBOMDelta() != nil {
+				t.Error("expected SBOM changes only in security reviews")
+			}
+		})
+	}
+}
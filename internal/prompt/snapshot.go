@@ -0,0 +1,329 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/git"
+)
+
+// SnapshotDir is where prompt snapshot golden files live, relative to the
+// repository root
+const SnapshotDir = "internal/prompt/testdata/snapshots"
+
+// snapshotExt is the golden file extension
+const snapshotExt = ".golden"
+
+// snapshotTime is the clock snapshots are built with, and the date of the
+// fixture repo's first commit
+var snapshotTime = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// Snapshot modes: what a snapshot case builds a prompt for
+const (
+	SnapshotCommit = "commit" // The fixture repo's last commit
+	SnapshotRange  = "range"  // Every commit after the first
+	SnapshotDirty  = "dirty"  // Uncommitted changes on top of HEAD
+)
+
+// SnapshotCase is a prompt build kept as a golden file, so changes to
+// prompt layout show up in review
+type SnapshotCase struct {
+	Name       string // Golden file name, without extension
+	Mode       string // SnapshotCommit, SnapshotRange, or SnapshotDirty
+	Agent      string
+	ReviewType string
+	RepoConfig string // The fixture repo's .roborev.toml
+}
+
+// SnapshotCases covers each prompt type and the repo settings that change
+// a prompt's layout
+var SnapshotCases = []SnapshotCase{
+	{Name: "commit-default", Mode: SnapshotCommit, Agent: "codex"},
+	{Name: "commit-gemini", Mode: SnapshotCommit, Agent: "gemini"},
+	{Name: "commit-security", Mode: SnapshotCommit, Agent: "codex", ReviewType: "security"},
+	{Name: "commit-design", Mode: SnapshotCommit, Agent: "codex", ReviewType: "design"},
+	{
+		Name:  "commit-config",
+		Mode:  SnapshotCommit,
+		Agent: "codex",
+		RepoConfig: `review_guidelines = "Prefer returning errors over panicking. Ask {{.Author}} before flagging {{.CommitSHA}} as a breaking change."
+required_sections = ["Summary", "Risks"]
+max_findings = 5
+blame_context = true
+related_tests = false
+context_files = ["*.md"]
+`,
+	},
+	{
+		Name:  "commit-template",
+		Mode:  SnapshotCommit,
+		Agent: "codex",
+		RepoConfig: `[prompts]
+review = """
+You are reviewing {{.Repo}} for {{.Agent}} ({{.Type}}) on {{.Date}}.
+
+{{.Default}}"""
+`,
+	},
+	{Name: "range-default", Mode: SnapshotRange, Agent: "codex"},
+	{Name: "range-security", Mode: SnapshotRange, Agent: "codex", ReviewType: "security"},
+	{Name: "dirty-default", Mode: SnapshotDirty, Agent: "codex"},
+}
+
+// snapshotCommits are the fixture repo's commits, applied in order. Files
+// map paths to contents.
+var snapshotCommits = []struct {
+	Message string
+	Files   map[string]string
+}{
+	{
+		Message: "Initial commit",
+		Files: map[string]string{
+			"README.md": "# greet\n\nGreets people.\n",
+			"greet.go":  "package greet\n\n// Greet returns a greeting for name\nfunc Greet(name string) string {\n\treturn \"Hello, \" + name\n}\n",
+			"greet_test.go": "package greet\n\nimport \"testing\"\n\nfunc TestGreet(t *testing.T) {\n" +
+				"\tif got := Greet(\"Ada\"); got != \"Hello, Ada\" {\n\t\tt.Errorf(\"Greet() = %q\", got)\n\t}\n}\n",
+		},
+	},
+	{
+		Message: "Add farewell\n\nCallers asked for a matching goodbye.",
+		Files: map[string]string{
+			"greet.go": "package greet\n\n// Greet returns a greeting for name\nfunc Greet(name string) string {\n\treturn \"Hello, \" + name\n}\n\n" +
+				"// Farewell returns a goodbye for name\nfunc Farewell(name string) string {\n\treturn \"Goodbye, \" + name\n}\n",
+		},
+	},
+	{
+		Message: "Trim names before greeting",
+		Files: map[string]string{
+			"greet.go": "package greet\n\nimport \"strings\"\n\n// Greet returns a greeting for name\nfunc Greet(name string) string {\n\treturn \"Hello, \" + strings.TrimSpace(name)\n}\n\n" +
+				"// Farewell returns a goodbye for name\nfunc Farewell(name string) string {\n\treturn \"Goodbye, \" + name\n}\n",
+		},
+	},
+}
+
+// snapshotDirtyFiles are the uncommitted changes dirty snapshots review
+var snapshotDirtyFiles = map[string]string{
+	"README.md": "# greet\n\nGreets people, and says goodbye.\n",
+	"greet.go": "package greet\n\nimport \"strings\"\n\n// Greet returns a greeting for name\nfunc Greet(name string) string {\n\treturn \"Hello, \" + strings.TrimSpace(name)\n}\n\n" +
+		"// Farewell returns a goodbye for name\nfunc Farewell(name string) string {\n\treturn \"Goodbye, \" + strings.TrimSpace(name)\n}\n",
+}
+
+// snapshotEnv pins what git output depends on besides the repo: the
+// locale, time zone, and user and system config
+var snapshotEnv = [][2]string{
+	{"LC_ALL", "C"},
+	{"LANG", "C"},
+	{"TZ", "UTC"},
+	{"GIT_CONFIG_GLOBAL", os.DevNull},
+	{"GIT_CONFIG_NOSYSTEM", "1"},
+}
+
+// pinSnapshotEnvironment sets snapshotEnv and the prompt clock, and returns
+// a function restoring them
+func pinSnapshotEnvironment() func() {
+	type saved struct {
+		key, value string
+		ok         bool
+	}
+	var restore []saved
+	for _, kv := range snapshotEnv {
+		value, ok := os.LookupEnv(kv[0])
+		restore = append(restore, saved{kv[0], value, ok})
+		os.Setenv(kv[0], kv[1])
+	}
+	origNow := nowFunc
+	nowFunc = func() time.Time { return snapshotTime }
+
+	return func() {
+		nowFunc = origNow
+		for _, s := range restore {
+			if s.ok {
+				os.Setenv(s.key, s.value)
+			} else {
+				os.Unsetenv(s.key)
+			}
+		}
+	}
+}
+
+// writeSnapshotFiles writes files under dir in path order
+func writeSnapshotFiles(dir string, files map[string]string) error {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(p)), []byte(files[p]), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSnapshotRepo creates the fixture repo in dir with fixed authors and
+// dates, so its commit SHAs are the same everywhere. It returns the SHAs in
+// commit order.
+func createSnapshotRepo(dir string) ([]string, error) {
+	run := func(date time.Time, args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		stamp := date.Format(time.RFC3339)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Snapshot Author",
+			"GIT_AUTHOR_EMAIL=author@example.com",
+			"GIT_AUTHOR_DATE="+stamp,
+			"GIT_COMMITTER_NAME=Snapshot Author",
+			"GIT_COMMITTER_EMAIL=author@example.com",
+			"GIT_COMMITTER_DATE="+stamp,
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	if _, err := run(snapshotTime, "init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := run(snapshotTime, "symbolic-ref", "HEAD", "refs/heads/main"); err != nil {
+		return nil, err
+	}
+	// Each case writes its own .roborev.toml, which isn't part of the changes
+	exclude := filepath.Join(dir, ".git", "info", "exclude")
+	if err := os.MkdirAll(filepath.Dir(exclude), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(exclude, []byte(".roborev.toml\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	var shas []string
+	for i, c := range snapshotCommits {
+		if err := writeSnapshotFiles(dir, c.Files); err != nil {
+			return nil, err
+		}
+		date := snapshotTime.Add(time.Duration(i) * time.Hour)
+		if _, err := run(date, "add", "-A"); err != nil {
+			return nil, err
+		}
+		if _, err := run(date, "commit", "-q", "--no-verify", "-m", c.Message); err != nil {
+			return nil, err
+		}
+		sha, err := run(date, "rev-parse", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		shas = append(shas, sha)
+	}
+	return shas, nil
+}
+
+// RenderSnapshots builds the prompt of every snapshot case against a
+// fixture repo it creates in dir, and returns them by case name. While it
+// runs, it pins the prompt clock and the process's locale, time zone, and
+// git config, so it mustn't run alongside other prompt builds.
+func RenderSnapshots(dir string) (map[string]string, error) {
+	defer pinSnapshotEnvironment()()
+
+	// The repo's directory name appears in prompt templates
+	repoPath := filepath.Join(dir, "greet")
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		return nil, err
+	}
+	shas, err := createSnapshotRepo(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("create fixture repo: %w", err)
+	}
+	if err := writeSnapshotFiles(repoPath, snapshotDirtyFiles); err != nil {
+		return nil, err
+	}
+	dirtyDiff, err := git.GetDirtyDiff(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("dirty diff: %w", err)
+	}
+
+	configPath := filepath.Join(repoPath, ".roborev.toml")
+	b := NewBuilder(nil)
+	prompts := make(map[string]string, len(SnapshotCases))
+	for _, c := range SnapshotCases {
+		if err := os.WriteFile(configPath, []byte(c.RepoConfig), 0644); err != nil {
+			return nil, err
+		}
+
+		var p string
+		switch c.Mode {
+		case SnapshotCommit:
+			p, err = b.Build(repoPath, shas[len(shas)-1], 0, 0, c.Agent, c.ReviewType)
+		case SnapshotRange:
+			p, err = b.Build(repoPath, shas[0]+".."+shas[len(shas)-1], 0, 0, c.Agent, c.ReviewType)
+		case SnapshotDirty:
+			p, err = b.BuildDirty(repoPath, dirtyDiff, 0, 0, c.Agent, c.ReviewType)
+		default:
+			err = fmt.Errorf("unknown mode %q", c.Mode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", c.Name, err)
+		}
+		// Paths outside the repo would differ between machines
+		prompts[c.Name] = strings.ReplaceAll(p, dir, "$SNAPSHOT_DIR")
+	}
+	return prompts, nil
+}
+
+// SnapshotPath returns the golden file of a snapshot case in dir
+func SnapshotPath(dir, name string) string {
+	return filepath.Join(dir, name+snapshotExt)
+}
+
+// WriteSnapshots renders the snapshot cases and writes the golden files
+// that changed to outDir, removing ones for cases that no longer exist. It
+// returns the names of the snapshots written and removed.
+func WriteSnapshots(outDir string) (written, removed []string, err error) {
+	tmp, err := os.MkdirTemp("", "roborev-snapshot-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	prompts, err := RenderSnapshots(tmp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range SnapshotCases {
+		path := SnapshotPath(outDir, c.Name)
+		if existing, err := os.ReadFile(path); err == nil && string(existing) == prompts[c.Name] {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(prompts[c.Name]), 0644); err != nil {
+			return written, removed, err
+		}
+		written = append(written, c.Name)
+	}
+
+	stale, err := filepath.Glob(filepath.Join(outDir, "*"+snapshotExt))
+	if err != nil {
+		return written, removed, err
+	}
+	for _, path := range stale {
+		name := strings.TrimSuffix(filepath.Base(path), snapshotExt)
+		if _, ok := prompts[name]; ok {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return written, removed, err
+		}
+		removed = append(removed, name)
+	}
+	return written, removed, nil
+}
@@ -0,0 +1,91 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptSnapshots(t *testing.T) {
+	prompts, err := RenderSnapshots(t.TempDir())
+	if err != nil {
+		t.Fatalf("RenderSnapshots failed: %v", err)
+	}
+
+	for _, c := range SnapshotCases {
+		t.Run(c.Name, func(t *testing.T) {
+			want, err := os.ReadFile(SnapshotPath(filepath.Join("testdata", "snapshots"), c.Name))
+			if err != nil {
+				t.Fatalf("missing golden file (run `go run ./cmd/roborev prompt --snapshot` from the repo root): %v", err)
+			}
+			if got := prompts[c.Name]; got != string(want) {
+				t.Errorf("prompt differs from its golden file; if the change is intended, run `go run ./cmd/roborev prompt --snapshot` from the repo root and review the diff\n%s",
+					firstDifference(string(want), got))
+			}
+		})
+	}
+}
+
+func TestRenderSnapshotsIsDeterministic(t *testing.T) {
+	first, err := RenderSnapshots(t.TempDir())
+	if err != nil {
+		t.Fatalf("RenderSnapshots failed: %v", err)
+	}
+	t.Setenv("TZ", "America/Los_Angeles")
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	second, err := RenderSnapshots(t.TempDir())
+	if err != nil {
+		t.Fatalf("RenderSnapshots failed: %v", err)
+	}
+	for name, p := range first {
+		if second[name] != p {
+			t.Errorf("snapshot %s changed between renders:\n%s", name, firstDifference(p, second[name]))
+		}
+	}
+	if os.Getenv("TZ") != "America/Los_Angeles" {
+		t.Error("expected RenderSnapshots to restore the environment")
+	}
+}
+
+func TestWriteSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(SnapshotPath(dir, "retired-case"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, removed, err := WriteSnapshots(dir)
+	if err != nil {
+		t.Fatalf("WriteSnapshots failed: %v", err)
+	}
+	if len(written) != len(SnapshotCases) || len(removed) != 1 || removed[0] != "retired-case" {
+		t.Errorf("expected every case written and the retired one removed, got %v and %v", written, removed)
+	}
+
+	written, removed, err = WriteSnapshots(dir)
+	if err != nil {
+		t.Fatalf("WriteSnapshots failed: %v", err)
+	}
+	if len(written) != 0 || len(removed) != 0 {
+		t.Errorf("expected no changes on a second run, got %v and %v", written, removed)
+	}
+}
+
+// firstDifference shows the first line where two prompts differ
+func firstDifference(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return ""
+}
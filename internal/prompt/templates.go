@@ -3,8 +3,14 @@ package prompt
 import (
 	"embed"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
 )
 
 //go:embed templates/*.tmpl
@@ -13,7 +19,8 @@ var templateFS embed.FS
 // GetSystemPrompt returns the system prompt for the specified agent and type.
 // If a specific template exists for the agent, it uses that.
 // Otherwise, it falls back to the default constant.
-// Supported prompt types: review, range, dirty, address, design-review, run, security
+// Supported prompt types: review, range, dirty, address, design-review, run,
+// security, bench, performance, docs, tests, quick
 func GetSystemPrompt(agentName string, promptType string) string {
 	// Normalize agent name
 	agentName = strings.ToLower(agentName)
@@ -48,6 +55,16 @@ func GetSystemPrompt(agentName string, promptType string) string {
 		base = SystemPromptAddress
 	case "security":
 		base = SystemPromptSecurity
+	case "bench":
+		base = SystemPromptBench
+	case "performance":
+		base = SystemPromptPerformance
+	case "docs":
+		base = SystemPromptDocs
+	case "tests":
+		base = SystemPromptTests
+	case "quick":
+		base = SystemPromptQuick
 	case "design-review":
 		base = SystemPromptDesignReview
 	case "run":
@@ -59,6 +76,137 @@ func GetSystemPrompt(agentName string, promptType string) string {
 	return appendDateLine(base)
 }
 
+// RepoPromptDir is where repos keep templates that replace the system
+// prompts, relative to the repo root
+const RepoPromptDir = ".roborev/prompts"
+
+// repoPromptNames lists, per prompt type, the [prompts] keys and template
+// file names (with .md added) that replace its system prompt, most
+// specific first
+var repoPromptNames = map[string][]string{
+	"review":        {"review"},
+	"range":         {"range", "review"},
+	"dirty":         {"dirty", "review"},
+	"security":      {"security"},
+	"design-review": {"design"},
+	"bench":         {"bench"},
+	"performance":   {"performance"},
+	"docs":          {"docs"},
+	"tests":         {"tests"},
+	"quick":         {"quick"},
+	"address":       {"address"},
+}
+
+// PromptVars are the variables available to repo prompt templates
+type PromptVars struct {
+	Agent   string // Agent running the review
+	Type    string // Prompt type, e.g. "review", "dirty", or "security"
+	Repo    string // Repo directory name
+	Date    string // Current UTC date, YYYY-MM-DD
+	Default string // The built-in system prompt, for templates that extend it
+
+	// What's under review, empty where it doesn't apply
+	CommitSHA string // Commit under review: a range's last commit, or HEAD for uncommitted changes
+	Branch    string // Branch the commit is on
+	Author    string // Commit author, or a range's authors comma-separated
+}
+
+// GetRepoSystemPrompt returns the system prompt for the agent and type,
+// overridden by the repo's [prompts] config or its own template in
+// RepoPromptDir, in that order. Overrides use text/template syntax with
+// PromptVars. One that fails to render is logged and the built-in prompt
+// used instead.
+func GetRepoSystemPrompt(repoPath, agentName, promptType string) string {
+	return repoSystemPrompt(repoPath, agentName, promptType, PromptVars{})
+}
+
+// repoSystemPrompt is GetRepoSystemPrompt with the review target's
+// variables, CommitSHA, Branch and Author, taken from target
+func repoSystemPrompt(repoPath, agentName, promptType string, target PromptVars) string {
+	def := GetSystemPrompt(agentName, promptType)
+	content, source := repoPromptOverride(repoPath, promptType)
+	if source == "" {
+		return def
+	}
+	vars := promptVars(repoPath, agentName, promptType, target)
+	vars.Default = def
+	rendered, err := renderRepoPrompt(content, vars)
+	if err != nil {
+		log.Printf("prompt override %s: %v", source, err)
+		return def
+	}
+	return rendered
+}
+
+// promptVars fills in the variables that don't depend on the review target
+func promptVars(repoPath, agentName, promptType string, target PromptVars) PromptVars {
+	target.Agent = agentName
+	target.Type = promptType
+	target.Repo = filepath.Base(repoPath)
+	target.Date = nowFunc().UTC().Format("2006-01-02")
+	return target
+}
+
+// expandGuidelines renders template variables in review guidelines.
+// Guidelines that fail to render are logged and used as written.
+func expandGuidelines(guidelines string, vars PromptVars) string {
+	if !strings.Contains(guidelines, "{{") {
+		return guidelines
+	}
+	rendered, err := renderRepoPrompt(guidelines, vars)
+	if err != nil {
+		log.Printf("review_guidelines: %v", err)
+		return guidelines
+	}
+	return rendered
+}
+
+// repoPromptOverride finds the repo's replacement for a prompt type's
+// system prompt, returning it and where it came from, or an empty source if
+// there is none. A [prompts] value naming a file in the repo is replaced by
+// the file's contents.
+func repoPromptOverride(repoPath, promptType string) (string, string) {
+	names := repoPromptNames[promptType]
+	if repoCfg, err := config.LoadRepoConfig(repoPath); err == nil && repoCfg != nil {
+		for _, name := range names {
+			value, ok := repoCfg.Prompts[name]
+			if !ok && name == "review" {
+				value, ok = repoCfg.Prompts["default"]
+			}
+			if !ok {
+				continue
+			}
+			source := "[prompts] " + name
+			if !strings.Contains(value, "\n") && filepath.IsLocal(filepath.FromSlash(value)) {
+				if content, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(value))); err == nil {
+					return string(content), value
+				}
+			}
+			return value, source
+		}
+	}
+	for _, name := range names {
+		path := filepath.Join(repoPath, filepath.FromSlash(RepoPromptDir), name+".md")
+		if content, err := os.ReadFile(path); err == nil {
+			return string(content), path
+		}
+	}
+	return "", ""
+}
+
+// renderRepoPrompt executes a repo prompt template
+func renderRepoPrompt(content string, vars PromptVars) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
 // nowFunc is the time source for date lines in prompts. Override in tests.
 var nowFunc = time.Now
 
@@ -1,9 +1,13 @@
 package prompt
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/roborev-dev/roborev/internal/git"
 )
 
 // mockNow sets nowFunc to return fixedTime and restores it when the test ends.
@@ -88,3 +92,149 @@ func TestNonGeminiRunReturnsEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestRepoSystemPrompt(t *testing.T) {
+	mockNow(t, time.Date(2030, 6, 15, 0, 0, 0, 0, time.UTC))
+	repoPath, commits := setupTestRepo(t)
+	dir := filepath.Join(repoPath, RepoPromptDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTemplate := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := GetRepoSystemPrompt(repoPath, "codex", "review"); got != GetSystemPrompt("codex", "review") {
+		t.Errorf("expected built-in prompt without repo templates, got %q", got)
+	}
+
+	writeTemplate("review.md", "Review for {{.Repo}} with {{.Agent}} ({{.Type}}) on {{.Date}} using our rubric.\n")
+	want := "Review for " + filepath.Base(repoPath) + " with codex (dirty) on 2030-06-15 using our rubric."
+	if got := GetRepoSystemPrompt(repoPath, "codex", "dirty"); got != want {
+		t.Errorf("expected review.md to cover dirty reviews, got %q", got)
+	}
+	if got := GetRepoSystemPrompt(repoPath, "codex", "security"); got != GetSystemPrompt("codex", "security") {
+		t.Error("expected security reviews to keep the built-in prompt")
+	}
+
+	writeTemplate("security.md", "{{.Default}}\n\nAlso check our auth middleware.")
+	got := GetRepoSystemPrompt(repoPath, "codex", "security")
+	if !strings.HasPrefix(got, SystemPromptSecurity) || !strings.HasSuffix(got, "Also check our auth middleware.") {
+		t.Errorf("expected template extending the default, got %q", got)
+	}
+
+	// A broken template falls back to the built-in prompt
+	writeTemplate("range.md", "{{.Missing}}")
+	if got := GetRepoSystemPrompt(repoPath, "codex", "range"); got != GetSystemPrompt("codex", "range") {
+		t.Errorf("expected fallback for a broken template, got %q", got)
+	}
+
+	prompt, err := NewBuilder(nil).Build(repoPath, commits[5], 0, 0, "codex", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.HasPrefix(prompt, "Review for ") {
+		t.Errorf("expected prompt to start with the repo template:\n%s", prompt)
+	}
+}
+
+func TestRepoConfigPromptOverrides(t *testing.T) {
+	repoPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoPath, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "docs", "security.md"), []byte("Security rubric for {{.Agent}}."), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repoPath, RepoPromptDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, RepoPromptDir, "review.md"), []byte("From the prompt directory."), 0644); err != nil {
+		t.Fatal(err)
+	}
+	toml := `[prompts]
+default = """
+Inline rubric ({{.Type}})."""
+security = "docs/security.md"
+`
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		promptType string
+		want       string
+	}{
+		{"review", "Inline rubric (review)."},
+		{"dirty", "Inline rubric (dirty)."},
+		{"security", "Security rubric for codex."},
+		{"bench", GetSystemPrompt("codex", "bench")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.promptType, func(t *testing.T) {
+			if got := GetRepoSystemPrompt(repoPath, "codex", tt.promptType); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecialReviewTypePrompts(t *testing.T) {
+	repoPath, commits := setupTestRepo(t)
+	for reviewType, want := range map[string]string{
+		"performance": SystemPromptPerformance,
+		"docs":        SystemPromptDocs,
+		"tests":       SystemPromptTests,
+		"quick":       SystemPromptQuick,
+	} {
+		t.Run(reviewType, func(t *testing.T) {
+			prompt, err := NewBuilder(nil).Build(repoPath, commits[5], 0, 0, "codex", reviewType)
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			if !strings.HasPrefix(prompt, want) {
+				t.Errorf("expected %s system prompt, got start: %.100s", reviewType, prompt)
+			}
+		})
+	}
+}
+
+func TestReviewTargetTemplateVariables(t *testing.T) {
+	repoPath, commits := setupTestRepo(t)
+	toml := `review_guidelines = "Reviewing {{.CommitSHA}} on {{.Branch}} by {{.Author}}."
+[prompts]
+range = "Range ending at {{.CommitSHA}}."
+`
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	branch := git.GetCurrentBranch(repoPath)
+	head := commits[len(commits)-1]
+
+	p, err := NewBuilder(nil).Build(repoPath, commits[2], 0, 0, "codex", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	assertPromptContains(t, p, "Reviewing "+commits[2]+" on "+branch+" by Test.")
+
+	p, err = NewBuilder(nil).Build(repoPath, commits[2]+".."+head, 0, 0, "codex", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	assertPromptContains(t, p, "Range ending at "+head+".")
+	assertPromptContains(t, p, "Reviewing "+head+" on "+branch+" by Test.")
+
+	p, err = NewBuilder(nil).BuildDirty(repoPath, "diff --git a/file.txt b/file.txt\n", 0, 0, "codex", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	assertPromptContains(t, p, "Reviewing "+head+" on "+branch+" by .")
+
+	// Guidelines that don't render are used as written
+	if got := expandGuidelines("Flag {{.Missing}} uses.", PromptVars{}); got != "Flag {{.Missing}} uses." {
+		t.Errorf("expected broken guidelines unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,51 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// TestFailuresHeader introduces output from a failing test run
+const TestFailuresHeader = `### Failing Tests
+
+The repo's test command fails with the changes under review. Connect these
+failures to the diff where you can, and report breakage the diff causes.
+`
+
+// testRunTimeout bounds the test command
+const testRunTimeout = 10 * time.Minute
+
+// maxTestOutput caps how much test output goes in the prompt. The end of
+// the output is kept since that is where failure summaries usually are.
+const maxTestOutput = 16 * 1024
+
+// runTests runs command in repoPath and returns its output if it fails.
+// A passing run, or a command that can't be run, returns "".
+func runTests(ctx context.Context, repoPath, command string) string {
+	output, failed, err := runShellCommand(ctx, repoPath, command, testRunTimeout)
+	if err != nil {
+		log.Printf("tests: %v", err)
+		return ""
+	}
+	if !failed {
+		return ""
+	}
+	output = strings.TrimSpace(output)
+	if len(output) > maxTestOutput {
+		output = "(truncated) ...\n" + output[len(output)-maxTestOutput:]
+	}
+	return output
+}
+
+// writeTestFailures adds failing test output to the prompt
+func writeTestFailures(sb *strings.Builder, command, output string) {
+	if output == "" {
+		return
+	}
+
+	sb.WriteString(TestFailuresHeader)
+	fmt.Fprintf(sb, "\nOutput of `%s`:\n\n```\n%s\n```\n\n", command, output)
+}
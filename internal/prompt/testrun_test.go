@@ -0,0 +1,140 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildPromptWithFailingTests(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands use sh")
+	}
+	repoPath := setupLintRepo(t)
+	writeConfig := func(command string) {
+		t.Helper()
+		toml := "test_command = '" + command + "'\n"
+		if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig(`echo "--- FAIL: TestParse"; exit 1`)
+	b := NewBuilder(nil)
+	prompt, err := b.BuildDirty(repoPath, lintDiff, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if !strings.Contains(prompt, "### Failing Tests") || !strings.Contains(prompt, "--- FAIL: TestParse") {
+		t.Errorf("expected failing test output in prompt:\n%s", prompt)
+	}
+
+	writeConfig(`echo "ok all passed"`)
+	prompt, err = b.BuildDirty(repoPath, lintDiff, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if strings.Contains(prompt, "Failing Tests") || strings.Contains(prompt, "all passed") {
+		t.Error("passing tests should be left out of the prompt")
+	}
+
+	// Tests run in a worktree with the changes applied, not in the checkout
+	writeConfig(`touch artifact; test -f docs/*.md && test ! -f old.go || { echo "--- FAIL: changes missing"; exit 1; }`)
+	prompt, err = b.BuildDirty(repoPath, lintDiff, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if strings.Contains(prompt, "Failing Tests") {
+		t.Errorf("expected the tests to see the uncommitted changes:\n%s", prompt)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "artifact")); err == nil {
+		t.Error("tests should not write to the repo's checkout")
+	}
+}
+
+func TestBuildPromptWithCIStatus(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("CI status commands use sh")
+	}
+	repoPath, commits := setupTestRepo(t)
+	toml := `ci_status_command = 'echo status for {sha}; echo "FAIL: build"; exit 1'` + "\n"
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuilder(nil)
+	prompt, err := b.Build(repoPath, commits[5], 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(prompt, "### CI Results") || !strings.Contains(prompt, "status for "+commits[5]) {
+		t.Errorf("expected CI output for the commit in prompt:\n%s", prompt)
+	}
+	found := false
+	for _, block := range untrustedBlocks(prompt) {
+		if block.Source == "ci log" && strings.Contains(block.Content, "FAIL: build") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("CI output should be wrapped as untrusted content")
+	}
+
+	prompt, err = b.Build(repoPath, commits[2]+".."+commits[4], 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(prompt, "status for "+commits[4]) {
+		t.Error("range prompt should include CI output for the range's last commit")
+	}
+
+	prompt, err = b.BuildDirty(repoPath, lintDiff, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if strings.Contains(prompt, "CI Results") {
+		t.Error("uncommitted changes have no CI results")
+	}
+}
+
+func TestQuickReviewSkipsSlowChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands use sh")
+	}
+	repoPath := setupLintRepo(t)
+	toml := `test_command = 'echo "--- FAIL: TestParse"; exit 1'
+ci_status_command = 'echo "FAIL: build"'
+
+[[linters]]
+name = "fake-vet"
+command = "echo suspicious; exit 1"
+`
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuilder(nil)
+	full, err := b.Build(repoPath, "HEAD", 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(full, "Failing Tests") || !strings.Contains(full, "CI Results") || !strings.Contains(full, "Static Analysis") {
+		t.Fatalf("expected tests, CI status, and linters in a standard review:\n%s", full)
+	}
+
+	quick, err := b.Build(repoPath, "HEAD", 0, 0, "test", "quick")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	dirty, err := b.BuildDirty(repoPath, lintDiff, 0, 0, "test", "quick")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	for _, p := range []string{quick, dirty} {
+		if strings.Contains(p, "Failing Tests") || strings.Contains(p, "CI Results") || strings.Contains(p, "Static Analysis") {
+			t.Errorf("quick reviews should skip tests, CI status, and linters:\n%s", p)
+		}
+	}
+}
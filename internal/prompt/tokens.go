@@ -0,0 +1,230 @@
+package prompt
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Tokenizer estimates how many tokens a model counts for a text
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc adapts a function to the Tokenizer interface
+type TokenizerFunc func(text string) int
+
+// CountTokens calls f(text)
+func (f TokenizerFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// CharRatioTokenizer estimates tokens from text length: ASCII at BytesPerToken
+// bytes per token, and one token per other character, since scripts outside
+// ASCII tokenize far less densely
+type CharRatioTokenizer struct {
+	BytesPerToken float64
+}
+
+// CountTokens estimates the tokens in text
+func (c CharRatioTokenizer) CountTokens(text string) int {
+	ascii, other := 0, 0
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		other++
+		i += size
+	}
+	return int(float64(ascii)/c.BytesPerToken+0.5) + other
+}
+
+// AgentTokenBudget is the context window of a model and how densely it
+// tokenizes
+type AgentTokenBudget struct {
+	ContextTokens int
+	Tokenizer     Tokenizer
+}
+
+// promptContextShare is the part of the context window a review prompt
+// may fill. The rest is left for the files the agent reads and its reply.
+const promptContextShare = 0.5
+
+// agentTokenBudgets are the context windows of each agent's default model
+var agentTokenBudgets = map[string]AgentTokenBudget{
+	"claude-code": {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 3.5}},
+	"codex":       {ContextTokens: 272000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"gemini":      {ContextTokens: 1048576, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"copilot":     {ContextTokens: 128000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"cursor":      {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 3.5}},
+	"droid":       {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 3.5}},
+	"amazon-q":    {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 3.5}},
+	"opencode":    {ContextTokens: 128000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"aider":       {ContextTokens: 128000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+}
+
+// modelTokenBudgets override the agent's budget when a model name starts
+// with the key. Longer keys are more specific and win.
+var modelTokenBudgets = map[string]AgentTokenBudget{
+	"claude-":        {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 3.5}},
+	"gpt-5":          {ContextTokens: 272000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"gpt-4.1":        {ContextTokens: 1047576, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"gpt-4o":         {ContextTokens: 128000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"o3":             {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"o4-mini":        {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"gemini-":        {ContextTokens: 1048576, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"anthropic/":     {ContextTokens: 200000, Tokenizer: CharRatioTokenizer{BytesPerToken: 3.5}},
+	"openai/gpt-5":   {ContextTokens: 272000, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+	"google/gemini-": {ContextTokens: 1048576, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}},
+}
+
+var (
+	tokenizersMu sync.RWMutex
+	tokenizers   = map[string]Tokenizer{}
+)
+
+// RegisterTokenizer sets the tokenizer used for an agent or model name,
+// e.g. one wrapping an exact tokenizer library, in place of the built-in
+// estimate
+func RegisterTokenizer(name string, t Tokenizer) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	tokenizers[name] = t
+}
+
+// registeredTokenizer returns a tokenizer registered for name, if any
+func registeredTokenizer(name string) Tokenizer {
+	tokenizersMu.RLock()
+	defer tokenizersMu.RUnlock()
+	return tokenizers[name]
+}
+
+// PromptBudget limits how large a prompt may be. With a tokenizer the limit
+// is in tokens; otherwise it falls back to MaxBytes, or MaxPromptSize bytes
+// when that's unset.
+type PromptBudget struct {
+	Tokens    int
+	Tokenizer Tokenizer
+	MaxBytes  int
+}
+
+// defaultTokenizer estimates tokens for models the table doesn't know
+var defaultTokenizer = CharRatioTokenizer{BytesPerToken: 4}
+
+// WithTokens returns the budget limited to n tokens instead
+func (p PromptBudget) WithTokens(n int) PromptBudget {
+	if p.Tokenizer == nil {
+		p.Tokenizer = defaultTokenizer
+	}
+	p.Tokens = n
+	return p
+}
+
+// ResolvePromptBudget returns the prompt budget for an agent and model.
+// overrides, keyed by agent or model name, set a prompt token limit in
+// place of the built-in table. Agents and models in neither fall back to
+// the byte limit.
+func ResolvePromptBudget(agentName, model string, overrides map[string]int) PromptBudget {
+	budget, ok := lookupTokenBudget(agentName, model)
+	tokens := int(float64(budget.ContextTokens) * promptContextShare)
+	if n := overrides[model]; model != "" && n > 0 {
+		tokens, ok = n, true
+	} else if n := overrides[agentName]; n > 0 {
+		tokens, ok = n, true
+	}
+	if !ok {
+		return PromptBudget{}
+	}
+
+	tokenizer := budget.Tokenizer
+	if t := registeredTokenizer(model); model != "" && t != nil {
+		tokenizer = t
+	} else if t := registeredTokenizer(agentName); t != nil {
+		tokenizer = t
+	}
+	if tokenizer == nil {
+		tokenizer = defaultTokenizer
+	}
+	return PromptBudget{Tokens: tokens, Tokenizer: tokenizer}
+}
+
+// lookupTokenBudget finds the context window for a model, or failing that
+// for the agent's default model
+func lookupTokenBudget(agentName, model string) (AgentTokenBudget, bool) {
+	model = strings.ToLower(model)
+	best := ""
+	for prefix := range modelTokenBudgets {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best != "" {
+		return modelTokenBudgets[best], true
+	}
+	budget, ok := agentTokenBudgets[agentName]
+	return budget, ok
+}
+
+// size measures text in the budget's unit
+func (p PromptBudget) size(text string) int {
+	if p.Tokenizer == nil {
+		return len(text)
+	}
+	return p.Tokenizer.CountTokens(text)
+}
+
+// limit is the budget in its unit
+func (p PromptBudget) limit() int {
+	if p.Tokenizer != nil {
+		return p.Tokens
+	}
+	if p.MaxBytes > 0 {
+		return p.MaxBytes
+	}
+	return MaxPromptSize
+}
+
+// Fits reports whether prompt parts together stay within the budget
+func (p PromptBudget) Fits(parts ...string) bool {
+	total := 0
+	for _, s := range parts {
+		total += p.size(s)
+	}
+	return total <= p.limit()
+}
+
+// FitBytes returns how many leading bytes of text fit in the budget after
+// used, the prompt written so far, cut at a character boundary
+func (p PromptBudget) FitBytes(used, text string) int {
+	remaining := p.limit() - p.size(used)
+	if remaining <= 0 {
+		return 0
+	}
+	n := len(text)
+	for n > 0 {
+		size := p.size(text[:n])
+		if size <= remaining {
+			break
+		}
+		// Scale down by the overshoot, then a little more to converge
+		n = int(float64(n) * float64(remaining) / float64(size) * 0.98)
+		for n > 0 && !utf8.RuneStart(text[n]) {
+			n--
+		}
+	}
+	return n
+}
+
+// TruncateUTF8 shortens s to at most maxBytes without splitting a rune
+func TruncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
@@ -0,0 +1,151 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestCharRatioTokenizer(t *testing.T) {
+	tok := CharRatioTokenizer{BytesPerToken: 4}
+	if got := tok.CountTokens(strings.Repeat("a", 400)); got != 100 {
+		t.Errorf("CountTokens(ASCII) = %d, want 100", got)
+	}
+	if got := tok.CountTokens("日本語"); got != 3 {
+		t.Errorf("CountTokens(CJK) = %d, want 3", got)
+	}
+}
+
+func TestResolvePromptBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		agent     string
+		model     string
+		overrides map[string]int
+		want      int
+	}{
+		{name: "agent default model", agent: "claude-code", want: 100000},
+		{name: "model overrides agent", agent: "codex", model: "gpt-4o-mini", want: 64000},
+		{name: "longest model prefix wins", agent: "opencode", model: "openai/gpt-5-codex", want: 136000},
+		{name: "agent override", agent: "codex", overrides: map[string]int{"codex": 50000}, want: 50000},
+		{name: "model override", agent: "codex", model: "gpt-5", overrides: map[string]int{"codex": 50000, "gpt-5": 80000}, want: 80000},
+		{name: "override for unknown agent", agent: "my-plugin", overrides: map[string]int{"my-plugin": 9000}, want: 9000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := ResolvePromptBudget(tt.agent, tt.model, tt.overrides)
+			if budget.Tokens != tt.want || budget.Tokenizer == nil {
+				t.Errorf("ResolvePromptBudget() = %+v, want %d tokens", budget, tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown agent falls back to bytes", func(t *testing.T) {
+		budget := ResolvePromptBudget("test", "", nil)
+		if budget.Tokenizer != nil {
+			t.Fatalf("expected byte budget, got %+v", budget)
+		}
+		if !budget.Fits(strings.Repeat("a", MaxPromptSize)) || budget.Fits(strings.Repeat("a", MaxPromptSize+1)) {
+			t.Error("expected byte budget to allow exactly MaxPromptSize bytes")
+		}
+	})
+
+	t.Run("registered tokenizer", func(t *testing.T) {
+		RegisterTokenizer("words-agent", TokenizerFunc(func(s string) int { return len(strings.Fields(s)) }))
+		budget := ResolvePromptBudget("words-agent", "", map[string]int{"words-agent": 3})
+		if !budget.Fits("one two", "three") || budget.Fits("one two three four") {
+			t.Error("expected the registered tokenizer to count words")
+		}
+	})
+}
+
+func TestPromptBudgetFitBytes(t *testing.T) {
+	budget := PromptBudget{Tokens: 100, Tokenizer: CharRatioTokenizer{BytesPerToken: 4}}
+	text := strings.Repeat("abcd", 200)
+	n := budget.FitBytes(strings.Repeat("x", 40), text)
+	if got := budget.Tokenizer.CountTokens(text[:n]); got > 90 || got < 80 {
+		t.Errorf("FitBytes() = %d bytes (%d tokens), want close to 90 tokens", n, got)
+	}
+	if n := budget.FitBytes("", "short"); n != 5 {
+		t.Errorf("FitBytes() of text that fits = %d, want 5", n)
+	}
+	if n := budget.FitBytes(strings.Repeat("x", 500), text); n != 0 {
+		t.Errorf("FitBytes() with no room left = %d, want 0", n)
+	}
+}
+
+func TestBuildDirtyUsesTokenBudget(t *testing.T) {
+	var diff strings.Builder
+	diff.WriteString("diff --git a/big.go b/big.go\n")
+	for i := 0; i < 2000; i++ {
+		diff.WriteString("+var x = 1 // padding to make the diff larger than the budget\n")
+	}
+	cfg := &config.Config{PromptTokenBudgets: map[string]int{"codex": 8000}}
+	prompt, err := NewBuilderWithConfig(nil, cfg).BuildDirty(t.TempDir(), diff.String(), 0, 0, "codex", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if !strings.Contains(prompt, "(Diff too large to include in full)") || !strings.Contains(prompt, "... (truncated)") {
+		t.Fatalf("expected a truncated diff, got %d bytes", len(prompt))
+	}
+	if tokens := (CharRatioTokenizer{BytesPerToken: 4}).CountTokens(prompt); tokens > 8000 {
+		t.Errorf("prompt is %d tokens, over the 8000 token budget", tokens)
+	}
+
+	// The same diff fits the built-in budget
+	prompt, err = NewBuilder(nil).BuildDirty(t.TempDir(), diff.String(), 0, 0, "codex", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if strings.Contains(prompt, "(Diff too large to include in full)") {
+		t.Error("expected the diff to fit codex's context window")
+	}
+}
+
+func TestBuildDirtyUsesRepoPromptBudget(t *testing.T) {
+	repoPath := t.TempDir()
+	diff := "diff --git a/big.go b/big.go\n" + strings.Repeat("+var x = 1 // padding\n", 2000)
+
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte("max_prompt_size = 20000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompt, err := NewBuilder(nil).BuildDirty(repoPath, diff, 0, 0, "test", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if !strings.Contains(prompt, "(Diff too large to include in full)") || len(prompt) > 20000 {
+		t.Errorf("expected the repo's byte limit to truncate the diff, got %d bytes", len(prompt))
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, ".roborev.toml"), []byte("prompt_token_budget = 5000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompt, err = NewBuilder(nil).BuildDirty(repoPath, diff, 0, 0, "gemini", "")
+	if err != nil {
+		t.Fatalf("BuildDirty failed: %v", err)
+	}
+	if tokens := defaultTokenizer.CountTokens(prompt); !strings.Contains(prompt, "(Diff too large to include in full)") || tokens > 5000 {
+		t.Errorf("expected the repo's token budget to truncate the diff, got %d tokens", tokens)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"héllo", 2, "h"}, // don't split the 2-byte é
+		{"héllo", 3, "hé"},
+	}
+	for _, tt := range tests {
+		if got := TruncateUTF8(tt.in, tt.max); got != tt.want {
+			t.Errorf("TruncateUTF8(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
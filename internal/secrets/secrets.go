@@ -0,0 +1,154 @@
+// Package secrets finds credentials in text sent to agents, such as diffs
+// and context files, and redacts them.
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Redaction records one secret removed from text. The secret itself is
+// never kept, so redactions are safe to log.
+type Redaction struct {
+	Kind string // What was found, e.g. "aws-access-key" or "private-key"
+	Line int    // 1-based line in the scanned text
+}
+
+func (r Redaction) String() string {
+	return fmt.Sprintf("%s on line %d", r.Kind, r.Line)
+}
+
+// tokenPatterns match credentials with a recognizable shape. The first
+// submatch, when there is one, is the part redacted.
+var tokenPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws[a-z0-9_\-]{0,20}(?:secret|private)[a-z0-9_\-]{0,20}["']?\s*[:=]{1,2}\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9\-]{10,}`)},
+	{"stripe-key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"api-key", regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_\-]{32,}`)},
+}
+
+// assignmentPattern matches a value assigned to a name that suggests a
+// credential. The value is redacted when it looks random; see isHighEntropy.
+var assignmentPattern = regexp.MustCompile(`(?i)[a-z0-9_\-]*(?:password|passwd|secret|token|api_?key|access_?key|auth_?key|credentials?)[a-z0-9_\-]*["']?\s*[:=]{1,2}\s*["']?([A-Za-z0-9+/_\-.=]{16,})`)
+
+// privateKeyBegin and privateKeyEnd delimit PEM private keys
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+)
+
+// minEntropy is the Shannon entropy, in bits per character, above which an
+// assigned value is taken for a generated token rather than a word
+const minEntropy = 3.5
+
+// Scrub replaces the secrets in text with "[REDACTED:<kind>]" and reports
+// what it replaced. Lines are never added or removed, so diffs keep their
+// shape: the lines of a private key each become "[REDACTED:private-key]",
+// after the diff marker when the key is in a diff.
+func Scrub(text string) (string, []Redaction) {
+	lines := strings.Split(text, "\n")
+	var redactions []Redaction
+	inKey, marker := false, ""
+
+	for i, line := range lines {
+		if inKey {
+			end := privateKeyEnd.FindStringIndex(line)
+			prefix := ""
+			if marker != "" && strings.HasPrefix(line, marker) {
+				prefix = marker
+			}
+			if end == nil {
+				lines[i] = prefix + "[REDACTED:private-key]"
+				continue
+			}
+			lines[i] = prefix + "[REDACTED:private-key]" + line[end[1]:]
+			inKey = false
+			continue
+		}
+
+		if begin := privateKeyBegin.FindStringIndex(line); begin != nil {
+			redactions = append(redactions, Redaction{Kind: "private-key", Line: i + 1})
+			rest := line[begin[1]:]
+			if end := privateKeyEnd.FindStringIndex(rest); end != nil {
+				// The whole key is on one line, e.g. with escaped newlines
+				lines[i] = line[:begin[0]] + "[REDACTED:private-key]" + rest[end[1]:]
+				continue
+			}
+			lines[i] = line[:begin[0]] + "[REDACTED:private-key]"
+			inKey, marker = true, ""
+			if begin[0] > 0 && strings.ContainsRune("+- ", rune(line[0])) {
+				marker = line[:1]
+			}
+			continue
+		}
+
+		lines[i] = scrubLine(line, i+1, &redactions)
+	}
+	return strings.Join(lines, "\n"), redactions
+}
+
+// scrubLine redacts the tokens and credential assignments in one line
+func scrubLine(line string, lineNum int, redactions *[]Redaction) string {
+	for _, p := range tokenPatterns {
+		line = replaceMatches(line, p.pattern, func(string) bool { return true }, p.kind, lineNum, redactions)
+	}
+	return replaceMatches(line, assignmentPattern, isHighEntropy, "high-entropy-token", lineNum, redactions)
+}
+
+// replaceMatches redacts each match of pattern, or its first submatch,
+// that keep accepts
+func replaceMatches(line string, pattern *regexp.Regexp, keep func(string) bool, kind string, lineNum int, redactions *[]Redaction) string {
+	matches := pattern.FindAllStringSubmatchIndex(line, -1)
+	if matches == nil {
+		return line
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if !keep(line[start:end]) {
+			continue
+		}
+		sb.WriteString(line[last:start])
+		sb.WriteString("[REDACTED:" + kind + "]")
+		last = end
+		*redactions = append(*redactions, Redaction{Kind: kind, Line: lineNum})
+	}
+	sb.WriteString(line[last:])
+	return sb.String()
+}
+
+// isHighEntropy reports whether a value looks generated: letters and
+// digits mixed with high Shannon entropy
+func isHighEntropy(value string) bool {
+	hasLetter := strings.ContainsFunc(value, func(r rune) bool { return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') })
+	hasDigit := strings.ContainsFunc(value, func(r rune) bool { return r >= '0' && r <= '9' })
+	return hasLetter && hasDigit && entropy(value) >= minEntropy
+}
+
+// entropy is the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
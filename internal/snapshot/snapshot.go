@@ -0,0 +1,262 @@
+// Package snapshot captures the daemon's database, global config, and
+// runtime metadata in a single archive, and restores them, so upgrades and
+// moves to a new host can be undone.
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+	"github.com/roborev-dev/roborev/internal/version"
+)
+
+// Archive entry names
+const (
+	manifestName = "manifest.json"
+	dbName       = "reviews.db"
+	configName   = "config.toml"
+)
+
+// DaemonInfo describes the daemon that was running when a snapshot was taken
+type DaemonInfo struct {
+	PID       int       `json:"pid"`
+	Addr      string    `json:"addr"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+}
+
+// Manifest describes a snapshot's contents
+type Manifest struct {
+	Version    string      `json:"version"` // roborev version that took the snapshot
+	CreatedAt  time.Time   `json:"created_at"`
+	Hostname   string      `json:"hostname"`
+	DBPath     string      `json:"db_path"`
+	ConfigPath string      `json:"config_path,omitempty"` // Empty if there was no config file
+	Daemon     *DaemonInfo `json:"daemon,omitempty"`      // Nil if no daemon was running
+}
+
+// DefaultPath returns where a snapshot taken at t goes in dir
+func DefaultPath(dir string, t time.Time) string {
+	return filepath.Join(dir, "roborev-"+t.UTC().Format("20060102-150405")+".tar.gz")
+}
+
+// Create writes a snapshot of the database at dbPath and the config file at
+// configPath to dst. The archive only appears at dst once it is complete.
+func Create(dst, dbPath, configPath string, daemon *DaemonInfo) (*Manifest, error) {
+	if _, err := os.Stat(dst); err == nil {
+		return nil, fmt.Errorf("%s already exists", dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), ".snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Copy the database as it is: a newer roborev taking a snapshot before
+	// an upgrade must not migrate it under the running daemon
+	dbCopy := filepath.Join(tmpDir, dbName)
+	if err := storage.BackupFile(dbPath, dbCopy); err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	manifest := &Manifest{
+		Version:   version.Version,
+		CreatedAt: time.Now().UTC(),
+		Hostname:  hostname,
+		DBPath:    dbPath,
+		Daemon:    daemon,
+	}
+	entries := []struct{ name, path string }{{dbName, dbCopy}}
+	if _, err := os.Stat(configPath); err == nil {
+		manifest.ConfigPath = configPath
+		entries = append(entries, struct{ name, path string }{configName, configPath})
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("stat config: %w", err)
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	tmpArchive := filepath.Join(tmpDir, "snapshot.tar.gz")
+	f, err := os.OpenFile(tmpArchive, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = writeEntry(tw, manifestName, manifestJSON)
+	for _, e := range entries {
+		if err != nil {
+			break
+		}
+		err = copyEntry(tw, e.name, e.path)
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpArchive, dst); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeEntry adds a file with the given contents to the archive
+func writeEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// copyEntry adds the file at path to the archive under name
+func copyEntry(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Restore replaces the database at dbPath and the config file at
+// configPath with the contents of the snapshot at src. The daemon must not
+// be running. A snapshot taken without a config file leaves the current
+// one in place. The restored database is checked by opening it before
+// anything is replaced.
+func Restore(src, dbPath, configPath string) (*Manifest, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0700); err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dbPath), ".restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var manifest *Manifest
+	extracted := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read snapshot: %w", err)
+		}
+		switch hdr.Name {
+		case manifestName:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("read manifest: %w", err)
+			}
+		case dbName, configName:
+			path := filepath.Join(tmpDir, hdr.Name)
+			if err := extractEntry(tr, path); err != nil {
+				return nil, fmt.Errorf("extract %s: %w", hdr.Name, err)
+			}
+			extracted[hdr.Name] = path
+		}
+	}
+	if manifest == nil || extracted[dbName] == "" {
+		return nil, fmt.Errorf("%s is not a roborev snapshot", src)
+	}
+
+	db, err := storage.Open(extracted[dbName])
+	if err != nil {
+		return nil, fmt.Errorf("snapshot database is unusable: %w", err)
+	}
+	db.Close()
+
+	// Stale WAL and shared-memory files belong to the database being replaced
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err := os.Rename(extracted[dbName], dbPath); err != nil {
+		return nil, fmt.Errorf("restore database: %w", err)
+	}
+	if cfg := extracted[configName]; cfg != "" {
+		if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+			return nil, err
+		}
+		if err := replaceFile(cfg, configPath); err != nil {
+			return nil, fmt.Errorf("restore config: %w", err)
+		}
+	}
+	return manifest, nil
+}
+
+// extractEntry writes the current archive entry to path
+func extractEntry(r io.Reader, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replaceFile moves src over dst, copying when they are on different
+// filesystems
+func replaceFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0600)
+}
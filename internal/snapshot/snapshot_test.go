@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/storage"
+)
+
+func TestCreateAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "reviews.db")
+	configPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("default_agent = \"codex\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetOrCreateRepo(filepath.Join(dir, "before")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Snapshot while the database is open, as with a running daemon
+	dst := DefaultPath(filepath.Join(dir, "snapshots"), time.Now())
+	daemon := &DaemonInfo{PID: 42, Addr: "127.0.0.1:7373", Version: "test"}
+	manifest, err := Create(dst, dbPath, configPath, daemon)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if manifest.ConfigPath != configPath || manifest.Daemon.PID != 42 {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+	if _, err := Create(dst, dbPath, configPath, nil); err == nil {
+		t.Error("expected Create to refuse to overwrite a snapshot")
+	}
+
+	// Change both after the snapshot
+	if _, err := db.GetOrCreateRepo(filepath.Join(dir, "after")); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if err := os.WriteFile(configPath, []byte("default_agent = \"claude-code\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Restore(dst, dbPath, configPath)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored.Daemon == nil || restored.Daemon.Addr != "127.0.0.1:7373" {
+		t.Errorf("unexpected restored manifest %+v", restored)
+	}
+
+	db, err = storage.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos, err := db.ListRepos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Name != "before" {
+		t.Errorf("expected only the repo from before the snapshot, got %+v", repos)
+	}
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(config) != "default_agent = \"codex\"\n" {
+		t.Errorf("config not restored: %q", config)
+	}
+}
+
+func TestCreateDoesNotMigrate(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "reviews.db")
+	raw, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(`CREATE TABLE legacy (id INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	if _, err := Create(filepath.Join(dir, "snap.tar.gz"), dbPath, filepath.Join(dir, "config.toml"), nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	raw, err = sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	var tables int
+	if err := raw.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 1 {
+		t.Errorf("expected the database to be left unmigrated, got %d tables", tables)
+	}
+}
+
+func TestRestoreRejectsOtherArchives(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "not-a-snapshot.tar.gz")
+	if err := os.WriteFile(src, []byte("plain text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(dir, "reviews.db")
+	if _, err := Restore(src, dbPath, filepath.Join(dir, "config.toml")); err == nil {
+		t.Error("expected an error restoring a file that isn't a snapshot")
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Error("a failed restore should leave the database untouched")
+	}
+}
@@ -27,7 +27,7 @@ func (db *DB) HasCIReview(githubRepo string, prNumber int, headSHA string) (bool
 
 // RecordCIReview records that a PR was reviewed at a given HEAD SHA
 func (db *DB) RecordCIReview(githubRepo string, prNumber int, headSHA string, jobID int64) error {
-	_, err := db.Exec(`INSERT INTO ci_pr_reviews (github_repo, pr_number, head_sha, job_id) VALUES (?, ?, ?, ?)`,
+	_, err := db.Exec(`INSERT INTO ci_pr_reviews (github_repo, pr_number, head_sha, job_id, created_at) VALUES (?, ?, ?, ?, `+sqlNow+`)`,
 		githubRepo, prNumber, headSHA, jobID)
 	return err
 }
@@ -91,7 +91,7 @@ func (db *DB) HasCIBatch(githubRepo string, prNumber int, headSHA string) (bool,
 // (batch, false) if the batch already existed (another poller won the race).
 // Only the creator (created==true) should proceed to enqueue jobs.
 func (db *DB) CreateCIBatch(githubRepo string, prNumber int, headSHA string, totalJobs int) (*CIPRBatch, bool, error) {
-	result, err := db.Exec(`INSERT OR IGNORE INTO ci_pr_batches (github_repo, pr_number, head_sha, total_jobs, updated_at) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+	result, err := db.Exec(`INSERT OR IGNORE INTO ci_pr_batches (github_repo, pr_number, head_sha, total_jobs, created_at, updated_at) VALUES (?, ?, ?, ?, `+sqlNow+`, `+sqlNow+`)`,
 		githubRepo, prNumber, headSHA, totalJobs)
 	if err != nil {
 		return nil, false, err
@@ -111,6 +111,27 @@ func (db *DB) CreateCIBatch(githubRepo string, prNumber int, headSHA string, tot
 	return &batch, created, nil
 }
 
+// SetCIBatchFeedback stores the human review comments on a batch's PR,
+// formatted for the review prompt
+func (db *DB) SetCIBatchFeedback(batchID int64, feedback string) error {
+	_, err := db.Exec(`UPDATE ci_pr_batches SET pr_feedback = ? WHERE id = ?`, feedback, batchID)
+	return err
+}
+
+// GetJobPRFeedback returns the human review comments stored on the CI batch
+// a job belongs to, or "" if the job isn't part of a batch
+func (db *DB) GetJobPRFeedback(jobID int64) (string, error) {
+	var feedback string
+	err := db.QueryRow(`
+		SELECT b.pr_feedback FROM ci_pr_batches b
+		JOIN ci_pr_batch_jobs bj ON bj.batch_id = b.id
+		WHERE bj.job_id = ?`, jobID).Scan(&feedback)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return feedback, err
+}
+
 // CountBatchJobs returns the number of jobs linked to a batch.
 func (db *DB) CountBatchJobs(batchID int64) (int, error) {
 	var count int
@@ -124,7 +145,7 @@ func (db *DB) CountBatchJobs(batchID int64) (int, error) {
 // while they are still making progress.
 func (db *DB) IsBatchStale(batchID int64) (bool, error) {
 	var count int
-	err := db.QueryRow(`SELECT COUNT(*) FROM ci_pr_batches WHERE id = ? AND COALESCE(updated_at, created_at) < datetime('now', '-1 minute')`,
+	err := db.QueryRow(`SELECT COUNT(*) FROM ci_pr_batches WHERE id = ? AND datetime(COALESCE(updated_at, created_at)) < datetime('now', '-1 minute')`,
 		batchID).Scan(&count)
 	if err != nil {
 		return false, err
@@ -154,10 +175,10 @@ func (db *DB) GetBatchJobIDs(batchID int64) ([]int64, error) {
 // updated_at timestamp as a heartbeat so staleness detection is based on
 // inactivity rather than age from creation.
 func (db *DB) RecordBatchJob(batchID, jobID int64) error {
-	if _, err := db.Exec(`INSERT INTO ci_pr_batch_jobs (batch_id, job_id) VALUES (?, ?)`, batchID, jobID); err != nil {
+	if _, err := db.Exec(`INSERT INTO ci_pr_batch_jobs (batch_id, job_id, created_at) VALUES (?, ?, `+sqlNow+`)`, batchID, jobID); err != nil {
 		return err
 	}
-	_, err := db.Exec(`UPDATE ci_pr_batches SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, batchID)
+	_, err := db.Exec(`UPDATE ci_pr_batches SET updated_at = `+sqlNow+` WHERE id = ?`, batchID)
 	return err
 }
 
@@ -269,7 +290,7 @@ func (db *DB) GetCIBatchByJobID(jobID int64) (*CIPRBatch, error) {
 // hasn't been claimed yet (CAS). Sets claimed_at so stale claims can be
 // detected and recovered. Returns true if this caller won the claim.
 func (db *DB) ClaimBatchForSynthesis(batchID int64) (bool, error) {
-	result, err := db.Exec(`UPDATE ci_pr_batches SET synthesized = 1, claimed_at = datetime('now') WHERE id = ? AND synthesized = 0`, batchID)
+	result, err := db.Exec(`UPDATE ci_pr_batches SET synthesized = 1, claimed_at = `+sqlNow+` WHERE id = ? AND synthesized = 0`, batchID)
 	if err != nil {
 		return false, err
 	}
@@ -367,7 +388,7 @@ func (db *DB) DeleteEmptyBatches() (int, error) {
 		WHERE NOT EXISTS (
 			SELECT 1 FROM ci_pr_batch_jobs bj WHERE bj.batch_id = ci_pr_batches.id
 		)
-		AND created_at < datetime('now', '-1 minute')`)
+		AND datetime(created_at) < datetime('now', '-1 minute')`)
 	if err != nil {
 		return 0, err
 	}
@@ -384,7 +405,7 @@ func (db *DB) GetStaleBatches() ([]CIPRBatch, error) {
 		FROM ci_pr_batches b
 		WHERE (
 			b.synthesized = 0
-			OR (b.synthesized = 1 AND b.claimed_at IS NOT NULL AND b.claimed_at < datetime('now', '-5 minutes'))
+			OR (b.synthesized = 1 AND b.claimed_at IS NOT NULL AND datetime(b.claimed_at) < datetime('now', '-5 minutes'))
 		)
 		AND NOT EXISTS (
 			SELECT 1 FROM ci_pr_batch_jobs bj
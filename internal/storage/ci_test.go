@@ -182,6 +182,34 @@ func TestRecordBatchJob(t *testing.T) {
 	}
 }
 
+func TestCIBatchFeedback(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo, err := db.GetOrCreateRepo("/tmp/test-repo")
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo: %v", err)
+	}
+
+	batch, job := mustCreateLinkedBatchJob(t, db, repo.ID, "myorg/myrepo", 1, "sha1", "abc..def", "codex", "review")
+	if err := db.SetCIBatchFeedback(batch.ID, "- @alice: use a mutex here"); err != nil {
+		t.Fatalf("SetCIBatchFeedback: %v", err)
+	}
+	feedback, err := db.GetJobPRFeedback(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobPRFeedback: %v", err)
+	}
+	if feedback != "- @alice: use a mutex here" {
+		t.Errorf("unexpected feedback %q", feedback)
+	}
+
+	unbatched := mustEnqueueReviewJob(t, db, repo.ID, "abc..def", "codex", "security")
+	feedback, err = db.GetJobPRFeedback(unbatched.ID)
+	if err != nil || feedback != "" {
+		t.Errorf("GetJobPRFeedback for job outside a batch = %q, %v; want empty", feedback, err)
+	}
+}
+
 func TestIncrementBatchCompleted(t *testing.T) {
 	db := openTestDB(t)
 	defer db.Close()
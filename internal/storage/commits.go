@@ -6,39 +6,17 @@ import (
 )
 
 // GetOrCreateCommit finds or creates a commit record.
-// Lookups are by (repo_id, sha) to handle the same SHA in different repos.
+// Commits are unique per (repo_id, sha), so the same SHA in different repos
+// (forks, mirrors) gets separate records. The insert is an upsert, so
+// concurrent callers get the same row.
 func (db *DB) GetOrCreateCommit(repoID int64, sha, author, subject string, timestamp time.Time) (*Commit, error) {
-	// Try to find existing by (repo_id, sha)
-	var commit Commit
-	var ts, createdAt string
-	err := db.QueryRow(`SELECT id, repo_id, sha, author, subject, timestamp, created_at FROM commits WHERE repo_id = ? AND sha = ?`, repoID, sha).
-		Scan(&commit.ID, &commit.RepoID, &commit.SHA, &commit.Author, &commit.Subject, &ts, &createdAt)
-	if err == nil {
-		commit.Timestamp = parseSQLiteTime(ts)
-		commit.CreatedAt = parseSQLiteTime(createdAt)
-		return &commit, nil
-	}
-	if err != sql.ErrNoRows {
-		return nil, err
-	}
-
-	// Create new
-	result, err := db.Exec(`INSERT INTO commits (repo_id, sha, author, subject, timestamp) VALUES (?, ?, ?, ?, ?)`,
-		repoID, sha, author, subject, timestamp.Format(time.RFC3339))
+	_, err := db.Exec(`INSERT INTO commits (repo_id, sha, author, subject, timestamp, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(repo_id, sha) DO NOTHING`,
+		repoID, sha, author, subject, formatTime(timestamp), nowString())
 	if err != nil {
 		return nil, err
 	}
-
-	id, _ := result.LastInsertId()
-	return &Commit{
-		ID:        id,
-		RepoID:    repoID,
-		SHA:       sha,
-		Author:    author,
-		Subject:   subject,
-		Timestamp: timestamp,
-		CreatedAt: time.Now(),
-	}, nil
+	return db.GetCommitByRepoAndSHA(repoID, sha)
 }
 
 // ErrAmbiguousCommit is returned when a SHA lookup matches multiple repos
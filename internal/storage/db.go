@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -18,17 +19,18 @@ CREATE TABLE IF NOT EXISTS repos (
   id INTEGER PRIMARY KEY,
   root_path TEXT UNIQUE NOT NULL,
   name TEXT NOT NULL,
-  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 );
 
 CREATE TABLE IF NOT EXISTS commits (
   id INTEGER PRIMARY KEY,
   repo_id INTEGER NOT NULL REFERENCES repos(id),
-  sha TEXT UNIQUE NOT NULL,
+  sha TEXT NOT NULL,
   author TEXT NOT NULL,
   subject TEXT NOT NULL,
   timestamp TEXT NOT NULL,
-  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+  UNIQUE(repo_id, sha)
 );
 
 CREATE TABLE IF NOT EXISTS review_jobs (
@@ -40,8 +42,8 @@ CREATE TABLE IF NOT EXISTS review_jobs (
   agent TEXT NOT NULL DEFAULT 'codex',
   model TEXT,
   reasoning TEXT NOT NULL DEFAULT 'thorough',
-  status TEXT NOT NULL CHECK(status IN ('queued','running','done','failed','canceled')) DEFAULT 'queued',
-  enqueued_at TEXT NOT NULL DEFAULT (datetime('now')),
+  status TEXT NOT NULL CHECK(status IN ('queued','running','done','failed','canceled','skipped')) DEFAULT 'queued',
+  enqueued_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
   started_at TEXT,
   finished_at TEXT,
   worker_id TEXT,
@@ -51,7 +53,16 @@ CREATE TABLE IF NOT EXISTS review_jobs (
   diff_content TEXT,
   output_prefix TEXT,
   job_type TEXT NOT NULL DEFAULT 'review',
-  review_type TEXT NOT NULL DEFAULT ''
+  review_type TEXT NOT NULL DEFAULT '',
+  deleted_at TEXT,
+  error_class TEXT NOT NULL DEFAULT '',
+  tokens INTEGER NOT NULL DEFAULT 0,
+  cost_usd REAL NOT NULL DEFAULT 0,
+  parent_job_id INTEGER,
+  pipeline TEXT NOT NULL DEFAULT '',
+  pipeline_step INTEGER NOT NULL DEFAULT 0,
+  context_count INTEGER,
+  per_commit INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS reviews (
@@ -60,8 +71,12 @@ CREATE TABLE IF NOT EXISTS reviews (
   agent TEXT NOT NULL,
   prompt TEXT NOT NULL,
   output TEXT NOT NULL,
-  created_at TEXT NOT NULL DEFAULT (datetime('now')),
-  addressed INTEGER NOT NULL DEFAULT 0
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+  addressed INTEGER NOT NULL DEFAULT 0,
+  deleted_at TEXT,
+  agent_version TEXT NOT NULL DEFAULT '',
+  injection_warning TEXT NOT NULL DEFAULT '',
+  stale_reminders INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS responses (
@@ -69,7 +84,58 @@ CREATE TABLE IF NOT EXISTS responses (
   commit_id INTEGER REFERENCES commits(id),
   responder TEXT NOT NULL,
   response TEXT NOT NULL,
-  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+  deleted_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS review_attachments (
+  id INTEGER PRIMARY KEY,
+  review_id INTEGER NOT NULL REFERENCES reviews(id),
+  name TEXT NOT NULL,
+  content TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+  UNIQUE(review_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS job_output (
+  job_id INTEGER PRIMARY KEY REFERENCES review_jobs(id),
+  output TEXT NOT NULL DEFAULT '',
+  updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS job_logs (
+  job_id INTEGER PRIMARY KEY REFERENCES review_jobs(id),
+  command_line TEXT NOT NULL DEFAULT '',
+  exit_code INTEGER,
+  stderr TEXT NOT NULL DEFAULT '',
+  error TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS schema_version (
+  version INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS findings (
+  id INTEGER PRIMARY KEY,
+  job_id INTEGER NOT NULL REFERENCES review_jobs(id),
+  severity TEXT NOT NULL DEFAULT '',
+  file TEXT NOT NULL DEFAULT '',
+  line INTEGER NOT NULL DEFAULT 0,
+  message TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+  status TEXT NOT NULL DEFAULT 'open',
+  status_note TEXT NOT NULL DEFAULT '',
+  label TEXT NOT NULL DEFAULT '',
+  category TEXT NOT NULL DEFAULT '',
+  title TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS repo_allowlist (
+  path TEXT PRIMARY KEY,
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 );
 
 CREATE TABLE IF NOT EXISTS ci_pr_reviews (
@@ -78,7 +144,7 @@ CREATE TABLE IF NOT EXISTS ci_pr_reviews (
   pr_number INTEGER NOT NULL,
   head_sha TEXT NOT NULL,
   job_id INTEGER NOT NULL REFERENCES review_jobs(id),
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
   UNIQUE(github_repo, pr_number, head_sha)
 );
 
@@ -92,8 +158,9 @@ CREATE TABLE IF NOT EXISTS ci_pr_batches (
   failed_jobs INTEGER NOT NULL DEFAULT 0,
   synthesized INTEGER NOT NULL DEFAULT 0,
   claimed_at TIMESTAMP,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  pr_feedback TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
   UNIQUE(github_repo, pr_number, head_sha)
 );
 
@@ -101,15 +168,30 @@ CREATE TABLE IF NOT EXISTS ci_pr_batch_jobs (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   batch_id INTEGER NOT NULL REFERENCES ci_pr_batches(id),
   job_id INTEGER NOT NULL REFERENCES review_jobs(id),
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  url TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  job_id INTEGER NOT NULL DEFAULT 0,
+  payload TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TEXT NOT NULL,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 );
 
 CREATE INDEX IF NOT EXISTS idx_review_jobs_status ON review_jobs(status);
 CREATE INDEX IF NOT EXISTS idx_review_jobs_repo ON review_jobs(repo_id);
 CREATE INDEX IF NOT EXISTS idx_review_jobs_git_ref ON review_jobs(git_ref);
 CREATE INDEX IF NOT EXISTS idx_commits_sha ON commits(sha);
+CREATE INDEX IF NOT EXISTS idx_findings_job ON findings(job_id);
 CREATE INDEX IF NOT EXISTS idx_ci_pr_batch_jobs_batch ON ci_pr_batch_jobs(batch_id);
 CREATE INDEX IF NOT EXISTS idx_ci_pr_batch_jobs_job ON ci_pr_batch_jobs(job_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
 `
 
 type DB struct {
@@ -132,7 +214,9 @@ func Open(dbPath string) (*DB, error) {
 	// Open with WAL mode and busy timeout.
 	// 30s busy_timeout gives enough headroom for concurrent writers
 	// (worker pool + sync worker) to wait for locks rather than failing.
-	db, err := sql.Open("sqlite", dbPath+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(30000)")
+	// auto_vacuum only takes effect for newly created databases; it lets
+	// RunMaintenance reclaim free pages with incremental_vacuum.
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=auto_vacuum(INCREMENTAL)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(30000)")
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
@@ -150,6 +234,10 @@ func Open(dbPath string) (*DB, error) {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
+	if err := wrapped.applyMigrations(schemaMigrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
 
 	return wrapped, nil
 }
@@ -309,7 +397,7 @@ func (db *DB) migrate() error {
 				model TEXT,
 				reasoning TEXT NOT NULL DEFAULT 'thorough',
 				status TEXT NOT NULL CHECK(status IN ('queued','running','done','failed','canceled')) DEFAULT 'queued',
-				enqueued_at TEXT NOT NULL DEFAULT (datetime('now')),
+				enqueued_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 				started_at TEXT,
 				finished_at TEXT,
 				worker_id TEXT,
@@ -473,7 +561,7 @@ func (db *DB) migrate() error {
 				job_id INTEGER REFERENCES review_jobs(id),
 				responder TEXT NOT NULL,
 				response TEXT NOT NULL,
-				created_at TEXT NOT NULL DEFAULT (datetime('now'))
+				created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 			)
 		`)
 		if err != nil {
@@ -572,6 +660,167 @@ func (db *DB) migrate() error {
 		}
 	}
 
+	// Migration: add deleted_at column for soft deletes to jobs, reviews, and responses
+	for _, table := range []string{"review_jobs", "reviews", "responses"} {
+		err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'deleted_at'`, table).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("check deleted_at column in %s: %w", table, err)
+		}
+		if count == 0 {
+			_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN deleted_at TEXT`, table))
+			if err != nil {
+				return fmt.Errorf("add deleted_at column to %s: %w", table, err)
+			}
+		}
+	}
+
+	// Migration: add error_class column to review_jobs if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('review_jobs') WHERE name = 'error_class'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check error_class column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE review_jobs ADD COLUMN error_class TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			return fmt.Errorf("add error_class column: %w", err)
+		}
+	}
+
+	// Migration: add estimated usage columns to review_jobs if missing
+	for _, col := range []struct{ name, def string }{
+		{"tokens", "INTEGER NOT NULL DEFAULT 0"},
+		{"cost_usd", "REAL NOT NULL DEFAULT 0"},
+	} {
+		err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('review_jobs') WHERE name = ?`, col.name).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("check %s column: %w", col.name, err)
+		}
+		if count == 0 {
+			_, err = db.Exec(fmt.Sprintf(`ALTER TABLE review_jobs ADD COLUMN %s %s`, col.name, col.def))
+			if err != nil {
+				return fmt.Errorf("add %s column: %w", col.name, err)
+			}
+		}
+	}
+
+	// Migration: add pipeline columns to review_jobs if missing
+	for _, col := range []struct{ name, def string }{
+		{"parent_job_id", "INTEGER"},
+		{"pipeline", "TEXT NOT NULL DEFAULT ''"},
+		{"pipeline_step", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('review_jobs') WHERE name = ?`, col.name).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("check %s column: %w", col.name, err)
+		}
+		if count == 0 {
+			_, err = db.Exec(fmt.Sprintf(`ALTER TABLE review_jobs ADD COLUMN %s %s`, col.name, col.def))
+			if err != nil {
+				return fmt.Errorf("add %s column: %w", col.name, err)
+			}
+		}
+	}
+
+	// Migration: add context_count column to review_jobs if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('review_jobs') WHERE name = 'context_count'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check context_count column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE review_jobs ADD COLUMN context_count INTEGER`)
+		if err != nil {
+			return fmt.Errorf("add context_count column: %w", err)
+		}
+	}
+
+	// Migration: add per_commit column to review_jobs if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('review_jobs') WHERE name = 'per_commit'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check per_commit column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE review_jobs ADD COLUMN per_commit INTEGER NOT NULL DEFAULT 0`)
+		if err != nil {
+			return fmt.Errorf("add per_commit column: %w", err)
+		}
+	}
+
+	// Migration: add status columns to findings if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('findings') WHERE name = 'status'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check findings status column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE findings ADD COLUMN status TEXT NOT NULL DEFAULT 'open'`)
+		if err != nil {
+			return fmt.Errorf("add findings status column: %w", err)
+		}
+		_, err = db.Exec(`ALTER TABLE findings ADD COLUMN status_note TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			return fmt.Errorf("add findings status_note column: %w", err)
+		}
+	}
+
+	// Migration: add label column to findings if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('findings') WHERE name = 'label'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check findings label column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE findings ADD COLUMN label TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			return fmt.Errorf("add findings label column: %w", err)
+		}
+	}
+
+	// Migration: add stale_reminders column to reviews if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('reviews') WHERE name = 'stale_reminders'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check stale_reminders column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE reviews ADD COLUMN stale_reminders INTEGER NOT NULL DEFAULT 0`)
+		if err != nil {
+			return fmt.Errorf("add stale_reminders column: %w", err)
+		}
+	}
+
+	// Migration: add pr_feedback column to ci_pr_batches if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('ci_pr_batches') WHERE name = 'pr_feedback'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check pr_feedback column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE ci_pr_batches ADD COLUMN pr_feedback TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			return fmt.Errorf("add pr_feedback column: %w", err)
+		}
+	}
+
+	// Migration: add agent_version column to reviews if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('reviews') WHERE name = 'agent_version'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check agent_version column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE reviews ADD COLUMN agent_version TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			return fmt.Errorf("add agent_version column: %w", err)
+		}
+	}
+
+	// Migration: add injection_warning column to reviews if missing
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('reviews') WHERE name = 'injection_warning'`).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check injection_warning column: %w", err)
+	}
+	if count == 0 {
+		_, err = db.Exec(`ALTER TABLE reviews ADD COLUMN injection_warning TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			return fmt.Errorf("add injection_warning column: %w", err)
+		}
+	}
+
 	// Migration: add index on reviews.addressed for server-side filtering
 	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_reviews_addressed ON reviews(addressed)`)
 	if err != nil {
@@ -583,9 +832,111 @@ func (db *DB) migrate() error {
 		return err
 	}
 
+	// Runs last so the rebuilt table carries every column added above
+	if err := db.migrateSkippedStatus(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// skippedStatusPattern matches the review_jobs status CHECK constraint
+// from before 'skipped' was added
+var skippedStatusPattern = regexp.MustCompile(`'failed',\s*'canceled'\)`)
+
+// migrateSkippedStatus widens the review_jobs status CHECK constraint to
+// allow 'skipped'. SQLite can't alter constraints, so the table is rebuilt
+// from its current definition, keeping every column and index.
+func (db *DB) migrateSkippedStatus() error {
+	var tableSQL string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type='table' AND name='review_jobs'`).Scan(&tableSQL)
+	if err != nil {
+		return fmt.Errorf("check review_jobs schema: %w", err)
+	}
+	if strings.Contains(tableSQL, "'skipped'") {
+		return nil
+	}
+	if !skippedStatusPattern.MatchString(tableSQL) {
+		return fmt.Errorf("unexpected review_jobs status constraint: %s", tableSQL)
+	}
+
+	// Earlier renames may have left the table name quoted
+	newSQL := skippedStatusPattern.ReplaceAllString(tableSQL, "'failed','canceled','skipped')")
+	newSQL = regexp.MustCompile(`^CREATE TABLE\s+"?review_jobs"?`).ReplaceAllString(newSQL, "CREATE TABLE review_jobs_new")
+
+	var indexSQL []string
+	rows, err := db.Query(`SELECT sql FROM sqlite_master WHERE type='index' AND tbl_name='review_jobs' AND sql IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("list review_jobs indexes: %w", err)
+	}
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan review_jobs index: %w", err)
+		}
+		indexSQL = append(indexSQL, stmt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("list review_jobs indexes: %w", err)
+	}
+
+	// PRAGMA foreign_keys is connection-scoped, so the rebuild runs on one connection
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection for migration: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return fmt.Errorf("disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin migration transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(newSQL); err != nil {
+		return fmt.Errorf("create new review_jobs table: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO review_jobs_new SELECT * FROM review_jobs`); err != nil {
+		return fmt.Errorf("copy review_jobs data: %w", err)
+	}
+	if _, err := tx.Exec(`DROP TABLE review_jobs`); err != nil {
+		return fmt.Errorf("drop old review_jobs table: %w", err)
+	}
+	if _, err := tx.Exec(`ALTER TABLE review_jobs_new RENAME TO review_jobs`); err != nil {
+		return fmt.Errorf("rename review_jobs table: %w", err)
+	}
+	for _, stmt := range indexSQL {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("recreate review_jobs index: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration transaction: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`); err != nil {
+		return fmt.Errorf("re-enable foreign keys: %w", err)
+	}
+	fkRows, err := conn.QueryContext(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return fmt.Errorf("foreign key check failed: %w", err)
+	}
+	defer fkRows.Close()
+	if fkRows.Next() {
+		return fmt.Errorf("foreign key violations detected after migration")
+	}
+	return fkRows.Err()
+}
+
 // hasUniqueIndexOnShaOnly checks if commits table has a unique constraint on just sha
 // (not the composite repo_id, sha constraint). Uses PRAGMA index_list/index_info for robustness.
 func (db *DB) hasUniqueIndexOnShaOnly() (bool, error) {
@@ -872,7 +1223,7 @@ func (db *DB) migrateSyncColumns() error {
 				author TEXT NOT NULL,
 				subject TEXT NOT NULL,
 				timestamp TEXT NOT NULL,
-				created_at TEXT NOT NULL DEFAULT (datetime('now')),
+				created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 				UNIQUE(repo_id, sha)
 			)
 		`)
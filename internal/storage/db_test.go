@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -781,6 +782,92 @@ func TestRetryJobAtomic(t *testing.T) {
 	}
 }
 
+func TestClaimJobWithLimits(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/test-repo")
+	enqueue := func(sha, agentName string) *ReviewJob {
+		t.Helper()
+		commit := createCommit(t, db, repo.ID, sha)
+		job, err := db.EnqueueJob(EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: sha, Agent: agentName})
+		if err != nil {
+			t.Fatalf("EnqueueJob failed: %v", err)
+		}
+		return job
+	}
+	gemini1 := enqueue("sha-1", "gemini")
+	gemini2 := enqueue("sha-2", "gemini")
+	codex := enqueue("sha-3", "codex")
+
+	limits := map[string]int{"gemini": 1, "codex": 0}
+	claims := 0
+	claim := func() *ReviewJob {
+		t.Helper()
+		claims++
+		job, err := db.ClaimJobWithLimits(fmt.Sprintf("worker-%d", claims), limits)
+		if err != nil {
+			t.Fatalf("ClaimJobWithLimits failed: %v", err)
+		}
+		return job
+	}
+
+	if job := claim(); job == nil || job.ID != gemini1.ID {
+		t.Fatalf("Expected first gemini job, got %+v", job)
+	}
+	// gemini is at its limit, so the codex job behind it runs instead
+	if job := claim(); job == nil || job.ID != codex.ID {
+		t.Fatalf("Expected codex job while gemini is saturated, got %+v", job)
+	}
+	if job := claim(); job != nil {
+		t.Fatalf("Expected no claimable job, got job %d", job.ID)
+	}
+
+	if err := db.CompleteJob(gemini1.ID, "gemini", "prompt", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+	if job := claim(); job == nil || job.ID != gemini2.ID {
+		t.Fatalf("Expected second gemini job after the first finished, got %+v", job)
+	}
+}
+
+func TestFailJobWithClass(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, _, job := createJobChain(t, db, "/tmp/test-repo", "fail-class")
+	claimJob(t, db, "worker-1")
+
+	if err := db.FailJobWithClass(job.ID, "agent: 401 Unauthorized", ErrorClassAuth); err != nil {
+		t.Fatalf("FailJobWithClass failed: %v", err)
+	}
+
+	failed, err := db.GetJobByID(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobByID failed: %v", err)
+	}
+	if failed.Status != JobStatusFailed || failed.ErrorClass != ErrorClassAuth {
+		t.Errorf("Expected failed auth job, got status %q class %q", failed.Status, failed.ErrorClass)
+	}
+
+	counts, err := db.GetFailureClassCounts()
+	if err != nil {
+		t.Fatalf("GetFailureClassCounts failed: %v", err)
+	}
+	if len(counts) != 1 || counts[ErrorClassAuth] != 1 {
+		t.Errorf("Expected {auth: 1}, got %v", counts)
+	}
+
+	// Rerunning clears the class along with the error
+	if err := db.ReenqueueJob(job.ID); err != nil {
+		t.Fatalf("ReenqueueJob failed: %v", err)
+	}
+	requeued, _ := db.GetJobByID(job.ID)
+	if requeued.ErrorClass != "" {
+		t.Errorf("Expected error class cleared on rerun, got %q", requeued.ErrorClass)
+	}
+}
+
 func TestCancelJob(t *testing.T) {
 	db := openTestDB(t)
 	defer db.Close()
@@ -1064,6 +1151,75 @@ func TestMigrationFromOldSchema(t *testing.T) {
 	}
 }
 
+func TestMigrationAddsSkippedStatus(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "pre-skipped.db")
+
+	// Open once to get the full current schema, then rebuild review_jobs
+	// with the constraint from before 'skipped' existed
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	repo := createRepo(t, db, "/tmp/test-repo")
+	commit := createCommit(t, db, repo.ID, "abc123")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "abc123")
+
+	var tableSQL string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type='table' AND name='review_jobs'`).Scan(&tableSQL); err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	oldSQL := strings.Replace(tableSQL, ",'skipped')", ")", 1)
+	oldSQL = strings.Replace(oldSQL, "CREATE TABLE review_jobs", "CREATE TABLE review_jobs_old", 1)
+	_, err = db.Exec(`PRAGMA foreign_keys = OFF;
+		` + oldSQL + `;
+		INSERT INTO review_jobs_old SELECT * FROM review_jobs;
+		DROP TABLE review_jobs;
+		ALTER TABLE review_jobs_old RENAME TO review_jobs;
+		CREATE INDEX idx_review_jobs_status ON review_jobs(status);
+		CREATE UNIQUE INDEX idx_review_jobs_uuid ON review_jobs(uuid);`)
+	if err != nil {
+		t.Fatalf("downgrade schema: %v", err)
+	}
+	db.Close()
+
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open after downgrade failed: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type='table' AND name='review_jobs'`).Scan(&tableSQL); err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	if !strings.Contains(tableSQL, "'skipped'") {
+		t.Errorf("expected migrated constraint to allow 'skipped', got %s", tableSQL)
+	}
+
+	var gitRef, uuid string
+	if err := db.QueryRow(`SELECT git_ref, uuid FROM review_jobs WHERE id = ?`, job.ID).Scan(&gitRef, &uuid); err != nil {
+		t.Fatalf("read job after migration: %v", err)
+	}
+	if gitRef != "abc123" || uuid != job.UUID {
+		t.Errorf("job not preserved: git_ref=%q uuid=%q", gitRef, uuid)
+	}
+
+	var indexes int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='index' AND name IN ('idx_review_jobs_status', 'idx_review_jobs_uuid')`).Scan(&indexes); err != nil {
+		t.Fatalf("count indexes: %v", err)
+	}
+	if indexes != 2 {
+		t.Errorf("expected indexes to be recreated, found %d", indexes)
+	}
+
+	skipped, err := db.EnqueueJob(EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "abc123", Agent: "codex", SkipReason: "docs only"})
+	if err != nil {
+		t.Fatalf("EnqueueJob with skip reason after migration: %v", err)
+	}
+	if skipped.Status != JobStatusSkipped {
+		t.Errorf("expected skipped status, got %s", skipped.Status)
+	}
+}
+
 func TestMigrationWithAlterTableColumnOrder(t *testing.T) {
 	// Test that migration works when columns were added via ALTER TABLE,
 	// which puts them at the end of the table (different from CREATE TABLE order)
@@ -1910,6 +2066,27 @@ func TestReenqueueJob(t *testing.T) {
 		}
 	})
 
+	t.Run("rerun skipped job", func(t *testing.T) {
+		commit, _ := db.GetOrCreateCommit(repo.ID, "rerun-skipped", "A", "S", time.Now())
+		job, err := db.EnqueueJob(EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "rerun-skipped", Agent: "codex", SkipReason: "only documentation changed"})
+		if err != nil {
+			t.Fatalf("EnqueueJob failed: %v", err)
+		}
+
+		stored, _ := db.GetJobByID(job.ID)
+		if stored.Status != JobStatusSkipped || stored.Error != "only documentation changed" || stored.FinishedAt == nil {
+			t.Fatalf("Expected skipped job with reason and finished_at, got %+v", stored)
+		}
+
+		if err := db.ReenqueueJob(job.ID); err != nil {
+			t.Fatalf("ReenqueueJob failed: %v", err)
+		}
+		updated, _ := db.GetJobByID(job.ID)
+		if updated.Status != JobStatusQueued || updated.Error != "" {
+			t.Errorf("Expected queued job with reason cleared, got status %q error %q", updated.Status, updated.Error)
+		}
+	})
+
 	t.Run("rerun queued job fails", func(t *testing.T) {
 		commit, _ := db.GetOrCreateCommit(repo.ID, "rerun-queued", "A", "S", time.Now())
 		job, _ := db.EnqueueJob(EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: "rerun-queued", Agent: "codex"})
@@ -2691,3 +2868,38 @@ func createJobChain(t *testing.T, db *DB, repoPath, sha string) (*Repo, *Commit,
 	job := enqueueJob(t, db, repo.ID, commit.ID, sha)
 	return repo, commit, job
 }
+
+func TestCommitsUniquePerRepo(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	if needs, err := db.hasUniqueIndexOnShaOnly(); err != nil || needs {
+		t.Fatalf("fresh schema should be unique on (repo_id, sha): needs=%v err=%v", needs, err)
+	}
+
+	repoA := createRepo(t, db, "/tmp/fork-a")
+	repoB := createRepo(t, db, "/tmp/fork-b")
+	commitA := createCommit(t, db, repoA.ID, "shared")
+	commitB := createCommit(t, db, repoB.ID, "shared")
+	if commitA.ID == commitB.ID {
+		t.Fatal("same SHA in two repos should get separate commits")
+	}
+	// Upserting again returns the existing row
+	if again := createCommit(t, db, repoA.ID, "shared"); again.ID != commitA.ID {
+		t.Errorf("GetOrCreateCommit returned %d, want existing %d", again.ID, commitA.ID)
+	}
+
+	// Reviews of the shared SHA stay scoped to their repo
+	jobA := enqueueJob(t, db, repoA.ID, commitA.ID, "shared")
+	claimJob(t, db, "worker-a")
+	if err := db.CompleteJob(jobA.ID, "codex", "prompt", "review of A"); err != nil {
+		t.Fatalf("CompleteJob: %v", err)
+	}
+	if _, err := db.GetReviewByRepoAndCommitSHA(repoB.ID, "shared"); err == nil {
+		t.Error("repo B should have no review of the shared SHA")
+	}
+	review, err := db.GetReviewByRepoAndCommitSHA(repoA.ID, "shared")
+	if err != nil || review.Output != "review of A" {
+		t.Errorf("repo A review: %v, %+v", err, review)
+	}
+}
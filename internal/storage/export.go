@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportFilter selects the jobs whose data is exported. Empty fields match
+// everything.
+type ExportFilter struct {
+	RepoPath string    // Repo root path
+	Since    time.Time // Only jobs enqueued at or after this time
+}
+
+// FindingExport is a finding with the job it came from
+type FindingExport struct {
+	JobID      int64
+	RepoName   string
+	RepoPath   string
+	GitRef     string
+	Branch     string
+	Agent      string
+	ReviewType string
+	Severity   string
+	Category   string
+	File       string
+	Line       int
+	Title      string
+	Message    string
+	Status     string
+	CreatedAt  time.Time
+}
+
+// JobMetrics describes one job's run for reporting
+type JobMetrics struct {
+	JobID      int64
+	RepoName   string
+	RepoPath   string
+	GitRef     string
+	Branch     string
+	Agent      string
+	Model      string
+	JobType    string
+	ReviewType string
+	Status     JobStatus
+	ErrorClass ErrorClass
+	EnqueuedAt time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	RetryCount int
+	Tokens     int
+	CostUSD    float64
+	Verdict    string // P or F; empty for jobs without a review verdict
+	Findings   int
+
+	// Signature is the review's signature state, filled in by callers that
+	// verify signatures
+	Signature string
+}
+
+// exportConditions builds the WHERE clause shared by the exports, for
+// queries aliasing review_jobs as j and repos as r
+func exportConditions(filter ExportFilter) (string, []interface{}) {
+	conditions := []string{"j.deleted_at IS NULL"}
+	var args []interface{}
+	if filter.RepoPath != "" {
+		conditions = append(conditions, "r.root_path = ?")
+		args = append(args, canonicalRepoPath(filter.RepoPath))
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "datetime(j.enqueued_at) >= datetime(?)")
+		args = append(args, formatTime(filter.Since))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// ExportFindings returns the stored findings of the matching jobs, oldest
+// job first
+func (db *DB) ExportFindings(filter ExportFilter) ([]FindingExport, error) {
+	where, args := exportConditions(filter)
+	rows, err := db.Query(`
+		SELECT f.job_id, r.name, r.root_path, j.git_ref, COALESCE(j.branch, ''), j.agent,
+		       j.review_type, f.severity, f.category, f.file, f.line, f.title, f.message, f.status, f.created_at
+		FROM findings f
+		JOIN review_jobs j ON j.id = f.job_id
+		JOIN repos r ON r.id = j.repo_id
+		WHERE `+where+`
+		ORDER BY f.job_id, f.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("export findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []FindingExport
+	for rows.Next() {
+		var f FindingExport
+		var createdAt string
+		if err := rows.Scan(&f.JobID, &f.RepoName, &f.RepoPath, &f.GitRef, &f.Branch, &f.Agent,
+			&f.ReviewType, &f.Severity, &f.Category, &f.File, &f.Line, &f.Title, &f.Message, &f.Status, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan finding: %w", err)
+		}
+		f.CreatedAt = parseSQLiteTime(createdAt)
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
+
+// ExportJobMetrics returns timing, usage, and outcome of the matching jobs,
+// oldest first
+func (db *DB) ExportJobMetrics(filter ExportFilter) ([]JobMetrics, error) {
+	where, args := exportConditions(filter)
+	rows, err := db.Query(`
+		SELECT j.id, r.name, r.root_path, j.git_ref, COALESCE(j.branch, ''), j.agent, COALESCE(j.model, ''),
+		       COALESCE(j.job_type, ''), j.review_type, j.status, j.error_class, j.enqueued_at,
+		       j.started_at, j.finished_at, j.retry_count, j.tokens, j.cost_usd, c.id, rv.output,
+		       (SELECT COUNT(*) FROM findings f WHERE f.job_id = j.id)
+		FROM review_jobs j
+		JOIN repos r ON r.id = j.repo_id
+		LEFT JOIN commits c ON c.id = j.commit_id
+		LEFT JOIN reviews rv ON rv.job_id = j.id AND rv.deleted_at IS NULL
+		WHERE `+where+`
+		ORDER BY j.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("export job metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []JobMetrics
+	for rows.Next() {
+		var m JobMetrics
+		var enqueuedAt string
+		var startedAt, finishedAt, output sql.NullString
+		var commitID sql.NullInt64
+		if err := rows.Scan(&m.JobID, &m.RepoName, &m.RepoPath, &m.GitRef, &m.Branch, &m.Agent, &m.Model,
+			&m.JobType, &m.ReviewType, &m.Status, &m.ErrorClass, &enqueuedAt,
+			&startedAt, &finishedAt, &m.RetryCount, &m.Tokens, &m.CostUSD, &commitID, &output,
+			&m.Findings); err != nil {
+			return nil, fmt.Errorf("scan job metrics: %w", err)
+		}
+		m.EnqueuedAt = parseSQLiteTime(enqueuedAt)
+		if startedAt.Valid {
+			t := parseSQLiteTime(startedAt.String)
+			m.StartedAt = &t
+		}
+		if finishedAt.Valid {
+			t := parseSQLiteTime(finishedAt.String)
+			m.FinishedAt = &t
+		}
+		job := ReviewJob{GitRef: m.GitRef, JobType: m.JobType}
+		if commitID.Valid {
+			job.CommitID = &commitID.Int64
+		}
+		if output.Valid && !job.IsTaskJob() {
+			m.Verdict = ParseVerdict(output.String)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
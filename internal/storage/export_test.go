@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExportFindingsAndJobMetrics(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	job := createCompletedJob(t, db, "/tmp/export-a", "aaa111")
+	if err := db.SaveFindings(job.ID, []Finding{
+		{Severity: "high", File: "main.go", Line: 12, Message: "nil dereference"},
+		{Severity: "low", Message: "typo"},
+	}); err != nil {
+		t.Fatalf("SaveFindings failed: %v", err)
+	}
+	if err := db.RecordJobUsage(job.ID, 1500, 0.02); err != nil {
+		t.Fatalf("RecordJobUsage failed: %v", err)
+	}
+	other := createCompletedJob(t, db, "/tmp/export-b", "bbb222")
+	if err := db.SaveFindings(other.ID, []Finding{{Severity: "medium", Message: "elsewhere"}}); err != nil {
+		t.Fatalf("SaveFindings failed: %v", err)
+	}
+
+	findings, err := db.ExportFindings(ExportFilter{RepoPath: "/tmp/export-a"})
+	if err != nil {
+		t.Fatalf("ExportFindings failed: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.JobID != job.ID || f.RepoPath != "/tmp/export-a" || f.Agent != "codex" ||
+		f.Severity != "high" || f.File != "main.go" || f.Line != 12 || f.CreatedAt.IsZero() {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+
+	metrics, err := db.ExportJobMetrics(ExportFilter{})
+	if err != nil {
+		t.Fatalf("ExportJobMetrics failed: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 jobs, got %+v", metrics)
+	}
+	m := metrics[0]
+	if m.JobID != job.ID || m.Status != JobStatusDone || m.Tokens != 1500 || m.CostUSD != 0.02 ||
+		m.Findings != 2 || m.Verdict != "P" || m.StartedAt == nil || m.FinishedAt == nil {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+
+	metrics, err = db.ExportJobMetrics(ExportFilter{Since: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("ExportJobMetrics failed: %v", err)
+	}
+	if len(metrics) != 0 {
+		t.Errorf("expected no jobs since the future, got %d", len(metrics))
+	}
+
+	if _, err := db.SoftDeleteJob(other.ID); err != nil {
+		t.Fatalf("SoftDeleteJob failed: %v", err)
+	}
+	if findings, _ := db.ExportFindings(ExportFilter{}); len(findings) != 2 {
+		t.Errorf("expected deleted job's findings to be left out, got %d", len(findings))
+	}
+}
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// findingsBlockPattern matches fenced json code blocks in review output
+var findingsBlockPattern = regexp.MustCompile("(?s)```json[ \\t]*\\n(.*?)\\n[ \\t]*```")
+
+// findingsHeadingPattern matches a heading an agent may put above the
+// findings block
+var findingsHeadingPattern = regexp.MustCompile(`(?i)^#{1,6}\s.*(machine-readable|structured|json)`)
+
+// ExtractFindings finds the machine-readable findings block in review
+// output. It returns the output with the block removed and the parsed
+// findings; ok is false when the output has no valid block, in which case
+// output is returned unchanged.
+func ExtractFindings(output string) (prose string, findings []Finding, ok bool) {
+	matches := findingsBlockPattern.FindAllStringSubmatchIndex(output, -1)
+	// The block comes last, so earlier json blocks are code examples
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		var block struct {
+			Findings *[]Finding `json:"findings"`
+		}
+		if err := json.Unmarshal([]byte(output[m[2]:m[3]]), &block); err != nil || block.Findings == nil {
+			continue
+		}
+
+		for _, f := range *block.Findings {
+			f.ID, f.JobID = 0, 0
+			f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+			f.Category = strings.ToLower(strings.Join(strings.Fields(f.Category), " "))
+			f.File = strings.TrimSpace(f.File)
+			f.Message = strings.TrimSpace(f.Message)
+			if f.Title = strings.TrimSpace(f.Title); f.Title == "" {
+				f.Title = findingTitle(f.Message)
+			}
+			if f.Line < 0 {
+				f.Line = 0
+			}
+			if f.Message != "" {
+				findings = append(findings, f)
+			}
+		}
+
+		before := strings.TrimRight(output[:m[0]], " \t\n")
+		if idx := strings.LastIndex(before, "\n"); findingsHeadingPattern.MatchString(before[idx+1:]) {
+			before = strings.TrimRight(before[:max(idx, 0)], " \t\n")
+		}
+		after := strings.TrimSpace(output[m[1]:])
+		prose = before
+		if after != "" {
+			prose += "\n\n" + after
+		}
+		return prose, findings, true
+	}
+	return output, nil, false
+}
+
+// MapSeverities maps findings onto a repo's own severity labels. A finding
+// using one of the labels gets the built-in level it ranks as; one using a
+// built-in level is labeled with the highest label of that level. Other
+// severities are left as they are.
+func MapSeverities(findings []Finding, levels []config.SeverityLevel) {
+	if len(levels) == 0 {
+		return
+	}
+	for i := range findings {
+		f := &findings[i]
+		severity := strings.Trim(strings.ToLower(f.Severity), " *_`")
+		for _, l := range levels {
+			if severity == l.Name {
+				f.Label, f.Severity = l.Name, l.Level
+				break
+			}
+		}
+		if f.Label != "" {
+			continue
+		}
+		for _, l := range levels {
+			if severity == l.Level {
+				f.Label, f.Severity = l.Name, l.Level
+				break
+			}
+		}
+	}
+}
+
+// MapCategories matches each finding's category to a default or repo
+// category, ignoring case, markdown emphasis, and a plural "s", and sets it
+// to that category's name. Other categories are left as they are.
+func MapCategories(findings []Finding, categories []config.FindingCategory) {
+	names := append([]string(nil), config.DefaultFindingCategories...)
+	for _, c := range categories {
+		names = append(names, c.Name)
+	}
+	for i := range findings {
+		f := &findings[i]
+		category := strings.Trim(strings.ToLower(f.Category), " *_`")
+		if category == "" {
+			continue
+		}
+		for _, name := range names {
+			if category == name || strings.TrimSuffix(category, "s") == strings.TrimSuffix(name, "s") {
+				f.Category = name
+				break
+			}
+		}
+	}
+}
+
+// SaveFindings replaces the stored findings for a job
+func (db *DB) SaveFindings(jobID int64, findings []Finding) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM findings WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+	now := nowString()
+	for _, f := range findings {
+		_, err := tx.Exec(`INSERT INTO findings (job_id, severity, label, category, file, line, title, message, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			jobID, f.Severity, f.Label, f.Category, f.File, f.Line, f.Title, f.Message, now)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetFindingsForJob returns a job's findings in the order the review
+// reported them
+func (db *DB) GetFindingsForJob(jobID int64) ([]Finding, error) {
+	rows, err := db.Query(`SELECT id, job_id, severity, label, category, file, line, title, message, status, status_note FROM findings WHERE job_id = ? ORDER BY id`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var findings []Finding
+	for rows.Next() {
+		var f Finding
+		if err := rows.Scan(&f.ID, &f.JobID, &f.Severity, &f.Label, &f.Category, &f.File, &f.Line, &f.Title, &f.Message, &f.Status, &f.StatusNote); err != nil {
+			return nil, err
+		}
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
+
+// SetFindingStatus moves a finding to one of FindingStatuses, from any
+// other. Returns sql.ErrNoRows if there is no such finding.
+func (db *DB) SetFindingStatus(id int64, status, note string) error {
+	normalized, ok := NormalizeFindingStatus(status)
+	if !ok {
+		return fmt.Errorf("invalid finding status %q", status)
+	}
+	result, err := db.Exec(`UPDATE findings SET status = ?, status_note = ? WHERE id = ?`, normalized, note, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// fixedFindingLineSlack is how far apart two findings' lines in the same
+// file can be for a later review's finding to count as the earlier one
+const fixedFindingLineSlack = 10
+
+// MarkFixedFindings marks as fixed the open and acknowledged findings of
+// earlier reviews of the same type, repo, and branch as jobID that are in files
+// the review of jobID covered but that it no longer reports. Returns how
+// many findings it marked.
+func (db *DB) MarkFixedFindings(jobID int64, files []string) (int, error) {
+	if len(files) == 0 {
+		return 0, nil
+	}
+	covered := make(map[string]bool, len(files))
+	for _, f := range files {
+		covered[f] = true
+	}
+	current, err := db.GetFindingsForJob(jobID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT f.id, f.job_id, f.severity, f.file, f.line, f.title, f.message
+		FROM findings f
+		JOIN review_jobs j ON j.id = f.job_id
+		JOIN review_jobs cur ON cur.id = ?
+		WHERE j.repo_id = cur.repo_id AND COALESCE(j.branch, '') = COALESCE(cur.branch, '')
+		AND j.review_type = cur.review_type
+		AND j.id < cur.id AND j.deleted_at IS NULL
+		AND f.status IN (?, ?) AND f.file != ''`,
+		jobID, FindingOpen, FindingAcknowledged)
+	if err != nil {
+		return 0, err
+	}
+	var fixed []int64
+	for rows.Next() {
+		var f Finding
+		if err := rows.Scan(&f.ID, &f.JobID, &f.Severity, &f.File, &f.Line, &f.Title, &f.Message); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if covered[f.File] && !reportedAgain(f, current) {
+			fixed = append(fixed, f.ID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	note := fmt.Sprintf("not reported by the review in job %d", jobID)
+	for _, id := range fixed {
+		if _, err := db.Exec(`UPDATE findings SET status = ?, status_note = ? WHERE id = ?`, FindingFixed, note, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(fixed), nil
+}
+
+// reportedAgain reports whether a later review's findings include f: one
+// in the same file with the same title, or of the same severity at a
+// nearby line
+func reportedAgain(f Finding, later []Finding) bool {
+	title := strings.ToLower(strings.Join(strings.Fields(f.Title), " "))
+	for _, l := range later {
+		if l.File != f.File {
+			continue
+		}
+		if title != "" && strings.ToLower(strings.Join(strings.Fields(l.Title), " ")) == title {
+			return true
+		}
+		if f.Line > 0 && l.Line > 0 && l.Severity == f.Severity && max(l.Line-f.Line, f.Line-l.Line) <= fixedFindingLineSlack {
+			return true
+		}
+	}
+	return false
+}
+
+// StaleReview is an unaddressed review with open critical or high findings
+type StaleReview struct {
+	ReviewID  int64
+	JobID     int64
+	RepoPath  string
+	RepoName  string
+	GitRef    string
+	Agent     string
+	CreatedAt time.Time
+	Reminders int       // Reminders sent so far
+	Findings  []Finding // Only the open critical and high findings
+}
+
+// ListStaleReviews returns unaddressed reviews created before cutoff that
+// have open critical or high findings, oldest first
+func (db *DB) ListStaleReviews(cutoff time.Time) ([]StaleReview, error) {
+	rows, err := db.Query(`
+		SELECT rv.id, j.id, r.root_path, r.name, j.git_ref, j.agent, rv.created_at, rv.stale_reminders
+		FROM reviews rv
+		JOIN review_jobs j ON j.id = rv.job_id
+		JOIN repos r ON r.id = j.repo_id
+		WHERE rv.addressed = 0 AND rv.deleted_at IS NULL AND j.deleted_at IS NULL
+		AND datetime(rv.created_at) < datetime(?)
+		AND EXISTS (SELECT 1 FROM findings f WHERE f.job_id = j.id AND f.status = 'open' AND lower(f.severity) IN ('critical', 'high'))
+		ORDER BY datetime(rv.created_at), rv.id`, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	var stale []StaleReview
+	for rows.Next() {
+		var sr StaleReview
+		var createdAt string
+		if err := rows.Scan(&sr.ReviewID, &sr.JobID, &sr.RepoPath, &sr.RepoName, &sr.GitRef, &sr.Agent, &createdAt, &sr.Reminders); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sr.CreatedAt = parseSQLiteTime(createdAt)
+		stale = append(stale, sr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range stale {
+		findings, err := db.GetFindingsForJob(stale[i].JobID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range findings {
+			if severity := strings.ToLower(f.Severity); f.Status == FindingOpen && (severity == "critical" || severity == "high") {
+				stale[i].Findings = append(stale[i].Findings, f)
+			}
+		}
+	}
+	return stale, nil
+}
+
+// SetStaleReminders records how many stale-finding reminders a review has had
+func (db *DB) SetStaleReminders(reviewID int64, reminders int) error {
+	_, err := db.Exec(`UPDATE reviews SET stale_reminders = ? WHERE id = ?`, reminders, reviewID)
+	return err
+}
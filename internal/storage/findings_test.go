@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+func TestExtractFindings(t *testing.T) {
+	t.Run("strips block and heading", func(t *testing.T) {
+		output := "## Summary\nAdds a cache.\n\n- **High**: race in cache.go:10\n\n## Machine-Readable Findings\n\n```json\n" +
+			`{"findings": [{"severity": "High", "file": " cache.go ", "line": 10, "message": "Race on map"}, {"severity": "low", "message": ""}]}` +
+			"\n```\n"
+		prose, findings, ok := ExtractFindings(output)
+		if !ok {
+			t.Fatal("expected findings block")
+		}
+		if want := "## Summary\nAdds a cache.\n\n- **High**: race in cache.go:10"; prose != want {
+			t.Errorf("prose = %q, want %q", prose, want)
+		}
+		if len(findings) != 1 {
+			t.Fatalf("got %d findings, want 1 (empty messages dropped)", len(findings))
+		}
+		want := Finding{Severity: "high", File: "cache.go", Line: 10, Message: "Race on map", Title: "Race on map"}
+		if findings[0] != want {
+			t.Errorf("finding = %+v, want %+v", findings[0], want)
+		}
+	})
+
+	t.Run("empty findings array", func(t *testing.T) {
+		prose, findings, ok := ExtractFindings("No issues found.\n\n```json\n{\"findings\": []}\n```")
+		if !ok || len(findings) != 0 || prose != "No issues found." {
+			t.Errorf("got ok=%v findings=%v prose=%q", ok, findings, prose)
+		}
+	})
+
+	t.Run("ignores other json blocks", func(t *testing.T) {
+		output := "Consider this config:\n\n```json\n{\"name\": \"x\"}\n```\n"
+		prose, _, ok := ExtractFindings(output)
+		if ok || prose != output {
+			t.Errorf("expected output unchanged, got ok=%v prose=%q", ok, prose)
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		output := "Review\n\n```json\n{\"findings\": [\n```"
+		if _, _, ok := ExtractFindings(output); ok {
+			t.Error("expected invalid block to be ignored")
+		}
+	})
+}
+
+func TestSaveFindings(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/findings-repo")
+	commit := createCommit(t, db, repo.ID, "abc123")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "abc123")
+
+	if err := db.SaveFindings(job.ID, []Finding{
+		{Severity: "high", File: "a.go", Line: 3, Message: "first"},
+		{Severity: "low", Message: "second"},
+	}); err != nil {
+		t.Fatalf("SaveFindings: %v", err)
+	}
+	// Saving again replaces rather than appends
+	if err := db.SaveFindings(job.ID, []Finding{{Severity: "medium", Label: "major", File: "b.go", Message: "only"}}); err != nil {
+		t.Fatalf("SaveFindings: %v", err)
+	}
+
+	findings, err := db.GetFindingsForJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetFindingsForJob: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Message != "only" || findings[0].Label != "major" || findings[0].JobID != job.ID {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestMapSeverities(t *testing.T) {
+	levels := []config.SeverityLevel{
+		{Name: "blocker", Level: "critical"},
+		{Name: "major", Level: "high"},
+		{Name: "serious", Level: "high"},
+		{Name: "minor", Level: "low"},
+	}
+	findings := []Finding{
+		{Severity: "blocker", Message: "custom label"},
+		{Severity: "**Serious**", Message: "decorated label"},
+		{Severity: "high", Message: "built-in level"},
+		{Severity: "medium", Message: "level without a label"},
+		{Severity: "nit", Message: "unknown severity"},
+	}
+	MapSeverities(findings, levels)
+
+	want := []struct{ severity, label string }{
+		{"critical", "blocker"},
+		{"high", "serious"},
+		{"high", "major"},
+		{"medium", ""},
+		{"nit", ""},
+	}
+	for i, w := range want {
+		if findings[i].Severity != w.severity || findings[i].Label != w.label {
+			t.Errorf("%s: got severity %q label %q, want %q %q", findings[i].Message, findings[i].Severity, findings[i].Label, w.severity, w.label)
+		}
+	}
+}
+
+func TestSetFindingStatus(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/findings-repo")
+	commit := createCommit(t, db, repo.ID, "abc123")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "abc123")
+	if err := db.SaveFindings(job.ID, []Finding{{Severity: "high", File: "a.go", Line: 3, Message: "first"}}); err != nil {
+		t.Fatalf("SaveFindings: %v", err)
+	}
+	findings, err := db.GetFindingsForJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetFindingsForJob: %v", err)
+	}
+	if findings[0].Status != FindingOpen {
+		t.Errorf("new finding status = %q, want open", findings[0].Status)
+	}
+
+	if err := db.SetFindingStatus(findings[0].ID, FindingFixed, "guarded by the caller"); err != nil {
+		t.Fatalf("SetFindingStatus: %v", err)
+	}
+	findings, _ = db.GetFindingsForJob(job.ID)
+	if findings[0].Status != FindingFixed || findings[0].StatusNote != "guarded by the caller" {
+		t.Errorf("unexpected finding after update: %+v", findings[0])
+	}
+
+	if err := db.SetFindingStatus(findings[0].ID, "ignored", ""); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+	if err := db.SetFindingStatus(999, FindingOpen, ""); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows for a missing finding, got %v", err)
+	}
+}
+
+func TestMarkFixedFindings(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/fixed-findings-repo")
+	review := func(sha, branch string, findings ...Finding) int64 {
+		t.Helper()
+		commit := createCommit(t, db, repo.ID, sha)
+		job, err := db.EnqueueJob(EnqueueOpts{RepoID: repo.ID, CommitID: commit.ID, GitRef: sha, Branch: branch, Agent: "codex"})
+		if err != nil {
+			t.Fatalf("EnqueueJob: %v", err)
+		}
+		if err := db.SaveFindings(job.ID, findings); err != nil {
+			t.Fatalf("SaveFindings: %v", err)
+		}
+		return job.ID
+	}
+
+	first := review("sha1", "main",
+		Finding{Severity: "high", File: "a.go", Line: 10, Title: "Nil dereference", Message: "Nil dereference"},
+		Finding{Severity: "medium", File: "a.go", Line: 40, Title: "Unchecked error", Message: "Unchecked error"},
+		Finding{Severity: "low", File: "b.go", Line: 5, Title: "Typo", Message: "Typo"},
+		Finding{Severity: "low", File: "a.go", Line: 80, Title: "Won't fix", Message: "Won't fix"})
+	other := review("sha2", "feature",
+		Finding{Severity: "high", File: "a.go", Line: 10, Title: "Elsewhere", Message: "Elsewhere"})
+	found, _ := db.GetFindingsForJob(first)
+	if err := db.SetFindingStatus(found[3].ID, FindingDismissed, ""); err != nil {
+		t.Fatalf("SetFindingStatus: %v", err)
+	}
+
+	// The later review covers a.go, reports the nil dereference two lines
+	// down under another title, and drops the unchecked error
+	later := review("sha3", "main",
+		Finding{Severity: "high", File: "a.go", Line: 12, Title: "Possible nil pointer", Message: "Possible nil pointer"})
+	n, err := db.MarkFixedFindings(later, []string{"a.go"})
+	if err != nil {
+		t.Fatalf("MarkFixedFindings: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 finding marked fixed, got %d", n)
+	}
+
+	found, _ = db.GetFindingsForJob(first)
+	want := []string{FindingOpen, FindingFixed, FindingOpen, FindingDismissed}
+	for i, f := range found {
+		if f.Status != want[i] {
+			t.Errorf("%s: status %q, want %q", f.Title, f.Status, want[i])
+		}
+	}
+	if !strings.Contains(found[1].StatusNote, "job") {
+		t.Errorf("expected a note naming the later job, got %q", found[1].StatusNote)
+	}
+	if found, _ := db.GetFindingsForJob(other); found[0].Status != FindingOpen {
+		t.Errorf("a review on another branch should not close findings, got %q", found[0].Status)
+	}
+}
+
+func TestMapCategories(t *testing.T) {
+	_, findings, ok := ExtractFindings("```json\n" +
+		`{"findings": [{"severity": "high", "category": " **Compliance** ", "message": "custom category"}, {"severity": "low", "category": "Bug", "message": "singular default"}, {"severity": "low", "category": "style", "message": "unknown category"}, {"severity": "low", "message": "no category"}]}` +
+		"\n```")
+	if !ok {
+		t.Fatal("expected findings block")
+	}
+	MapCategories(findings, []config.FindingCategory{{Name: "compliance"}})
+
+	want := []string{"compliance", "bugs", "style", ""}
+	for i, w := range want {
+		if findings[i].Category != w {
+			t.Errorf("%s: category = %q, want %q", findings[i].Message, findings[i].Category, w)
+		}
+	}
+
+	db := openTestDB(t)
+	defer db.Close()
+	repo := createRepo(t, db, "/tmp/findings-repo")
+	commit := createCommit(t, db, repo.ID, "abc123")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "abc123")
+	if err := db.SaveFindings(job.ID, findings[:1]); err != nil {
+		t.Fatalf("SaveFindings: %v", err)
+	}
+	saved, err := db.GetFindingsForJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetFindingsForJob: %v", err)
+	}
+	if len(saved) != 1 || saved[0].Category != "compliance" {
+		t.Errorf("expected the category stored, got %+v", saved)
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// MaxJobLogSize caps the stderr and command line stored in a job log. The
+// end of stderr is kept since that is where errors usually are.
+const MaxJobLogSize = 256 * 1024
+
+// SaveJobLog stores the execution trace of a job's failed agent run,
+// replacing any earlier one
+func (db *DB) SaveJobLog(log JobLog) error {
+	stderr := log.Stderr
+	if len(stderr) > MaxJobLogSize {
+		stderr = stderr[len(stderr)-MaxJobLogSize:]
+	}
+	commandLine := log.CommandLine
+	if len(commandLine) > MaxJobLogSize {
+		commandLine = commandLine[:MaxJobLogSize]
+	}
+	var exitCode sql.NullInt64
+	if log.ExitCode != nil {
+		exitCode = sql.NullInt64{Int64: int64(*log.ExitCode), Valid: true}
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO job_logs (job_id, command_line, exit_code, stderr, error, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(job_id) DO UPDATE SET
+			command_line = excluded.command_line,
+			exit_code = excluded.exit_code,
+			stderr = excluded.stderr,
+			error = excluded.error,
+			created_at = excluded.created_at
+	`, log.JobID, commandLine, exitCode, stderr, log.Error, nowString())
+	return err
+}
+
+// GetJobLog returns a job's execution trace, or sql.ErrNoRows if none was
+// recorded
+func (db *DB) GetJobLog(jobID int64) (*JobLog, error) {
+	var log JobLog
+	var exitCode sql.NullInt64
+	var createdAt string
+	err := db.QueryRow(`SELECT job_id, command_line, exit_code, stderr, error, created_at FROM job_logs WHERE job_id = ?`, jobID).
+		Scan(&log.JobID, &log.CommandLine, &exitCode, &log.Stderr, &log.Error, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if exitCode.Valid {
+		code := int(exitCode.Int64)
+		log.ExitCode = &code
+	}
+	log.CreatedAt = parseSQLiteTime(createdAt)
+	return &log, nil
+}
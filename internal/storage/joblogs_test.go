@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSaveJobLog(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/joblogs-repo")
+	commit := createCommit(t, db, repo.ID, "abc123")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "abc123")
+
+	if _, err := db.GetJobLog(job.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows before save, got %v", err)
+	}
+
+	code := 2
+	if err := db.SaveJobLog(JobLog{JobID: job.ID, CommandLine: "codex exec", ExitCode: &code, Stderr: "first", Error: "agent: exit status 2"}); err != nil {
+		t.Fatalf("SaveJobLog: %v", err)
+	}
+	// A later failure replaces the log, and oversized stderr keeps its tail
+	big := strings.Repeat("x", MaxJobLogSize) + "tail"
+	if err := db.SaveJobLog(JobLog{JobID: job.ID, CommandLine: "codex exec", Stderr: big, Error: "agent: killed"}); err != nil {
+		t.Fatalf("SaveJobLog: %v", err)
+	}
+
+	got, err := db.GetJobLog(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobLog: %v", err)
+	}
+	if got.ExitCode != nil {
+		t.Errorf("ExitCode = %d, want nil", *got.ExitCode)
+	}
+	if len(got.Stderr) != MaxJobLogSize || !strings.HasSuffix(got.Stderr, "tail") {
+		t.Errorf("stderr not capped to its tail: %d bytes", len(got.Stderr))
+	}
+	if got.Error != "agent: killed" || got.CommandLine != "codex exec" {
+		t.Errorf("unexpected log: %+v", got)
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// MaxJobOutputSize caps the partial output persisted for a running job.
+// Chunks arriving after the cap are dropped; the in-memory tail buffer
+// still shows them live.
+const MaxJobOutputSize = 1024 * 1024
+
+// AppendJobOutput appends a chunk of agent output to the job's persisted
+// partial output. The worker calls this while the agent runs so output
+// survives an agent crash or daemon restart.
+func (db *DB) AppendJobOutput(jobID int64, chunk string) error {
+	if chunk == "" {
+		return nil
+	}
+	now := nowString()
+	_, err := db.Exec(`
+		INSERT INTO job_output (job_id, output, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(job_id) DO UPDATE SET
+			output = CASE WHEN length(output) < ? THEN output || excluded.output ELSE output END,
+			updated_at = excluded.updated_at
+	`, jobID, chunk, now, MaxJobOutputSize)
+	return err
+}
+
+// GetJobOutput returns the persisted partial output for a job, or an
+// empty string if none was recorded.
+func (db *DB) GetJobOutput(jobID int64) (string, error) {
+	var output string
+	err := db.QueryRow(`SELECT output FROM job_output WHERE job_id = ?`, jobID).Scan(&output)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return output, err
+}
+
+// ClearJobOutput removes any persisted partial output for a job
+func (db *DB) ClearJobOutput(jobID int64) error {
+	_, err := db.Exec(`DELETE FROM job_output WHERE job_id = ?`, jobID)
+	return err
+}
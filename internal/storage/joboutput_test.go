@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJobOutputAppendAndClear(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/test-repo")
+	commit := createCommit(t, db, repo.ID, "joboutput")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "joboutput")
+
+	if out, err := db.GetJobOutput(job.ID); err != nil || out != "" {
+		t.Fatalf("expected no output before append, got %q (err %v)", out, err)
+	}
+
+	for _, chunk := range []string{"line 1\n", "", "line 2\n"} {
+		if err := db.AppendJobOutput(job.ID, chunk); err != nil {
+			t.Fatalf("AppendJobOutput failed: %v", err)
+		}
+	}
+	out, err := db.GetJobOutput(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobOutput failed: %v", err)
+	}
+	if out != "line 1\nline 2\n" {
+		t.Errorf("got %q, want both chunks in order", out)
+	}
+
+	if err := db.ClearJobOutput(job.ID); err != nil {
+		t.Fatalf("ClearJobOutput failed: %v", err)
+	}
+	if out, _ := db.GetJobOutput(job.ID); out != "" {
+		t.Errorf("expected output cleared, got %q", out)
+	}
+}
+
+func TestJobOutputStopsAtCap(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/test-repo")
+	commit := createCommit(t, db, repo.ID, "joboutput-cap")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "joboutput-cap")
+
+	big := strings.Repeat("x", MaxJobOutputSize)
+	if err := db.AppendJobOutput(job.ID, big); err != nil {
+		t.Fatalf("AppendJobOutput failed: %v", err)
+	}
+	if err := db.AppendJobOutput(job.ID, "dropped"); err != nil {
+		t.Fatalf("AppendJobOutput failed: %v", err)
+	}
+	out, _ := db.GetJobOutput(job.ID)
+	if len(out) != MaxJobOutputSize {
+		t.Errorf("expected output capped at %d bytes, got %d", MaxJobOutputSize, len(out))
+	}
+}
+
+func TestCompleteJobClearsJobOutput(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/test-repo")
+	commit := createCommit(t, db, repo.ID, "joboutput-done")
+	enqueueJob(t, db, repo.ID, commit.ID, "joboutput-done")
+	job := claimJob(t, db, "worker-1")
+
+	if err := db.AppendJobOutput(job.ID, "partial\n"); err != nil {
+		t.Fatalf("AppendJobOutput failed: %v", err)
+	}
+	if err := db.CompleteJob(job.ID, "codex", "prompt", "final review"); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+	if out, _ := db.GetJobOutput(job.ID); out != "" {
+		t.Errorf("expected partial output removed after completion, got %q", out)
+	}
+}
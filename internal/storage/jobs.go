@@ -3,7 +3,6 @@ package storage
 import (
 	"context"
 	"database/sql"
-	"log"
 	"strings"
 	"time"
 )
@@ -698,30 +697,6 @@ func hasNotVerbPattern(prefix string) bool {
 	return false
 }
 
-// parseSQLiteTime parses a time string from SQLite which may be in different formats.
-// Handles RFC3339 (what we write), SQLite datetime('now') format, and timezone variants.
-// Returns zero time for empty strings. Logs a warning for non-empty unrecognized formats
-// to surface driver/schema issues instead of silently producing zero times.
-func parseSQLiteTime(s string) time.Time {
-	if s == "" {
-		return time.Time{}
-	}
-	// Try RFC3339 first (what we write for started_at, finished_at)
-	if t, err := time.Parse(time.RFC3339, s); err == nil {
-		return t
-	}
-	// Try SQLite datetime format (from datetime('now'))
-	if t, err := time.Parse("2006-01-02 15:04:05", s); err == nil {
-		return t
-	}
-	// Try with timezone
-	if t, err := time.Parse("2006-01-02T15:04:05Z07:00", s); err == nil {
-		return t
-	}
-	log.Printf("storage: warning: unrecognized time format %q", s)
-	return time.Time{}
-}
-
 // EnqueueOpts contains options for creating any type of review job.
 // The job type is inferred from which fields are set (in priority order):
 //   - Prompt != "" → "task" (custom prompt job)
@@ -742,6 +717,20 @@ type EnqueueOpts struct {
 	OutputPrefix string // Prefix to prepend to review output
 	Agentic      bool   // Allow file edits and command execution
 	Label        string // Display label in TUI for task jobs (default: "prompt")
+	SkipReason   string // When set, the job is recorded as skipped and never run
+
+	// Set for jobs a pipeline enqueues after an earlier job
+	ParentJobID  int64
+	Pipeline     string
+	PipelineStep int
+
+	// ContextCount is how many earlier commits' reviews the prompt includes,
+	// when the request chose; nil leaves it to the repo and global config
+	ContextCount *int
+
+	// PerCommit asks a range review for a sub-review of each commit plus an
+	// overall summary, instead of one review of the combined diff
+	PerCommit bool
 }
 
 // EnqueueJob creates a new review job. The job type is inferred from opts.
@@ -778,11 +767,22 @@ func (db *DB) EnqueueJob(opts EnqueueOpts) (*ReviewJob, error) {
 	if opts.Agentic {
 		agenticInt = 1
 	}
+	perCommitInt := 0
+	if opts.PerCommit {
+		perCommitInt = 1
+	}
 
 	uid := GenerateUUID()
 	machineID, _ := db.GetMachineID()
 	now := time.Now()
-	nowStr := now.Format(time.RFC3339)
+	nowStr := formatTime(now)
+
+	status := JobStatusQueued
+	var finishedAt interface{}
+	if opts.SkipReason != "" {
+		status = JobStatusSkipped
+		finishedAt = nowStr
+	}
 
 	// Use NULL for commit_id when not a single-commit review
 	var commitIDParam interface{}
@@ -790,17 +790,23 @@ func (db *DB) EnqueueJob(opts EnqueueOpts) (*ReviewJob, error) {
 		commitIDParam = opts.CommitID
 	}
 
+	var parentJobIDParam interface{}
+	if opts.ParentJobID > 0 {
+		parentJobIDParam = opts.ParentJobID
+	}
+
 	result, err := db.Exec(`
 		INSERT INTO review_jobs (repo_id, commit_id, git_ref, branch, agent, model, reasoning,
-			status, job_type, review_type, diff_content, prompt, agentic, output_prefix,
-			uuid, source_machine_id, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, 'queued', ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			status, finished_at, error, job_type, review_type, diff_content, prompt, agentic, output_prefix,
+			uuid, source_machine_id, updated_at, enqueued_at, parent_job_id, pipeline, pipeline_step, context_count, per_commit)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		opts.RepoID, commitIDParam, gitRef, nullString(opts.Branch),
 		opts.Agent, nullString(opts.Model), reasoning,
-		jobType, opts.ReviewType,
+		status, finishedAt, nullString(opts.SkipReason), jobType, opts.ReviewType,
 		nullString(opts.DiffContent), nullString(opts.Prompt), agenticInt,
 		nullString(opts.OutputPrefix),
-		uid, machineID, nowStr)
+		uid, machineID, nowStr, nowStr,
+		parentJobIDParam, opts.Pipeline, opts.PipelineStep, opts.ContextCount, perCommitInt)
 	if err != nil {
 		return nil, err
 	}
@@ -816,8 +822,9 @@ func (db *DB) EnqueueJob(opts EnqueueOpts) (*ReviewJob, error) {
 		Reasoning:       reasoning,
 		JobType:         jobType,
 		ReviewType:      opts.ReviewType,
-		Status:          JobStatusQueued,
+		Status:          status,
 		EnqueuedAt:      now,
+		Error:           opts.SkipReason,
 		Prompt:          opts.Prompt,
 		Agentic:         opts.Agentic,
 		OutputPrefix:    opts.OutputPrefix,
@@ -828,29 +835,103 @@ func (db *DB) EnqueueJob(opts EnqueueOpts) (*ReviewJob, error) {
 	if opts.CommitID > 0 {
 		job.CommitID = &opts.CommitID
 	}
+	if status == JobStatusSkipped {
+		job.FinishedAt = &now
+	}
 	if opts.DiffContent != "" {
 		job.DiffContent = &opts.DiffContent
 	}
 	return job, nil
 }
 
+// JobPipeline is where a job sits in a pipeline of chained jobs
+type JobPipeline struct {
+	ParentJobID int64  `json:"parent_job_id,omitempty"`
+	Pipeline    string `json:"pipeline,omitempty"` // empty for jobs no pipeline enqueued
+	Step        int    `json:"step"`
+}
+
+// GetJobPipeline returns the pipeline that enqueued a job, if any
+func (db *DB) GetJobPipeline(jobID int64) (JobPipeline, error) {
+	var p JobPipeline
+	var parent sql.NullInt64
+	err := db.QueryRow(`SELECT parent_job_id, pipeline, pipeline_step FROM review_jobs WHERE id = ?`, jobID).
+		Scan(&parent, &p.Pipeline, &p.Step)
+	p.ParentJobID = parent.Int64
+	return p, err
+}
+
+// GetJobContextCount returns the context count a job was enqueued with, or
+// nil if the request left it to the config
+func (db *DB) GetJobContextCount(jobID int64) (*int, error) {
+	var count sql.NullInt64
+	if err := db.QueryRow(`SELECT context_count FROM review_jobs WHERE id = ?`, jobID).Scan(&count); err != nil {
+		return nil, err
+	}
+	if !count.Valid {
+		return nil, nil
+	}
+	n := int(count.Int64)
+	return &n, nil
+}
+
+// GetJobPerCommit reports whether a range job was enqueued for per-commit
+// sub-reviews
+func (db *DB) GetJobPerCommit(jobID int64) (bool, error) {
+	var perCommit int
+	if err := db.QueryRow(`SELECT per_commit FROM review_jobs WHERE id = ?`, jobID).Scan(&perCommit); err != nil {
+		return false, err
+	}
+	return perCommit != 0, nil
+}
+
 // ClaimJob atomically claims the next queued job for a worker
 func (db *DB) ClaimJob(workerID string) (*ReviewJob, error) {
+	return db.ClaimJobWithLimits(workerID, nil)
+}
+
+// ClaimJobWithLimits claims the oldest queued job whose agent is below its
+// concurrency limit. Agents missing from agentLimits, or with a limit below
+// 1, are unlimited. Jobs for saturated agents stay queued so other agents'
+// jobs can run.
+func (db *DB) ClaimJobWithLimits(workerID string, agentLimits map[string]int) (*ReviewJob, error) {
 	now := time.Now()
-	nowStr := now.Format(time.RFC3339)
+	nowStr := formatTime(now)
+
+	// Limits become a VALUES table so the capacity check and the claim
+	// happen in the same statement
+	var limitRows []string
+	var args []interface{}
+	for agentName, limit := range agentLimits {
+		if limit < 1 {
+			continue
+		}
+		limitRows = append(limitRows, "(?, ?)")
+		args = append(args, agentName, limit)
+	}
+	limitsCTE := "SELECT NULL AS agent, 0 AS max_running WHERE 0"
+	if len(limitRows) > 0 {
+		limitsCTE = "VALUES " + strings.Join(limitRows, ", ")
+	}
+	args = append(args, workerID, nowStr, nowStr)
 
 	// Atomically claim a job by updating it in a single statement
 	// This prevents race conditions where two workers select the same job
 	result, err := db.Exec(`
+		WITH limits(agent, max_running) AS (`+limitsCTE+`)
 		UPDATE review_jobs
 		SET status = 'running', worker_id = ?, started_at = ?, updated_at = ?
 		WHERE id = (
-			SELECT id FROM review_jobs
-			WHERE status = 'queued'
-			ORDER BY enqueued_at
+			SELECT q.id FROM review_jobs q
+			LEFT JOIN limits l ON l.agent = q.agent
+			WHERE q.status = 'queued' AND q.deleted_at IS NULL
+			  AND (l.agent IS NULL OR (
+			    SELECT COUNT(*) FROM review_jobs r WHERE r.status = 'running' AND r.agent = q.agent
+			  ) < l.max_running)
+			ORDER BY q.enqueued_at
 			LIMIT 1
 		)
-	`, workerID, nowStr, nowStr)
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -932,9 +1013,18 @@ func (db *DB) SaveJobPrompt(jobID int64, prompt string) error {
 // Only updates if job is still in 'running' state (respects cancellation).
 // If the job has an output_prefix, it will be prepended to the output.
 func (db *DB) CompleteJob(jobID int64, agent, prompt, output string) error {
+	return db.CompleteJobWithFullOutput(jobID, agent, prompt, output, "")
+}
+
+// CompleteJobWithFullOutput marks a job as done and stores its review like
+// CompleteJob. When fullOutput is non-empty, output is treated as a condensed
+// version for the review row and fullOutput is saved as a review attachment.
+// webhooks are added to the outbox in the same transaction, so the job
+// can't complete without them being queued.
+func (db *DB) CompleteJobWithFullOutput(jobID int64, agent, prompt, output, fullOutput string, webhooks ...WebhookDelivery) error {
 	// Get machine ID and generate UUIDs before starting transaction
 	// to avoid potential lock conflicts with GetMachineID's writes
-	now := time.Now().Format(time.RFC3339)
+	now := nowString()
 	machineID, _ := db.GetMachineID()
 	reviewUUID := GenerateUUID()
 
@@ -987,12 +1077,36 @@ func (db *DB) CompleteJob(jobID int64, agent, prompt, output string) error {
 	}
 
 	// Insert review with sync columns
-	_, err = conn.ExecContext(ctx, `INSERT INTO reviews (job_id, agent, prompt, output, uuid, updated_by_machine_id, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		jobID, agent, prompt, finalOutput, reviewUUID, machineID, now)
+	result, err = conn.ExecContext(ctx, `INSERT INTO reviews (job_id, agent, prompt, output, uuid, updated_by_machine_id, updated_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		jobID, agent, prompt, finalOutput, reviewUUID, machineID, now, now)
 	if err != nil {
 		return err
 	}
 
+	if fullOutput != "" {
+		reviewID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if outputPrefix.Valid && outputPrefix.String != "" {
+			fullOutput = outputPrefix.String + fullOutput
+		}
+		_, err = conn.ExecContext(ctx, `INSERT INTO review_attachments (review_id, name, content, created_at) VALUES (?, ?, ?, ?)`,
+			reviewID, AttachmentFullOutput, fullOutput, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The review now holds the output; drop the partial copy kept while running
+	if _, err = conn.ExecContext(ctx, `DELETE FROM job_output WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+
+	if err := insertWebhooks(ctx, conn, webhooks, now); err != nil {
+		return err
+	}
+
 	_, err = conn.ExecContext(ctx, "COMMIT")
 	if err != nil {
 		return err
@@ -1004,15 +1118,64 @@ func (db *DB) CompleteJob(jobID int64, agent, prompt, output string) error {
 // FailJob marks a job as failed with an error message.
 // Only updates if job is still in 'running' state (respects cancellation).
 func (db *DB) FailJob(jobID int64, errorMsg string) error {
-	now := time.Now().Format(time.RFC3339)
-	_, err := db.Exec(`UPDATE review_jobs SET status = 'failed', finished_at = ?, error = ?, updated_at = ? WHERE id = ? AND status = 'running'`,
-		now, errorMsg, now, jobID)
-	return err
+	return db.FailJobWithClass(jobID, errorMsg, "")
+}
+
+// FailJobWithClass marks a running job as failed and records the failure
+// category alongside the error message. webhooks are added to the outbox in
+// the same transaction, and only if the job was still running.
+func (db *DB) FailJobWithClass(jobID int64, errorMsg string, class ErrorClass, webhooks ...WebhookDelivery) error {
+	now := nowString()
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE review_jobs SET status = 'failed', finished_at = ?, error = ?, error_class = ?, updated_at = ? WHERE id = ? AND status = 'running'`,
+		now, errorMsg, string(class), now, jobID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows > 0 {
+		if err := insertWebhooks(ctx, tx, webhooks, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetFailureClassCounts returns the number of failed jobs in each error
+// class. Unclassified failures are not included.
+func (db *DB) GetFailureClassCounts() (map[ErrorClass]int, error) {
+	rows, err := db.Query(`
+		SELECT error_class, COUNT(*) FROM review_jobs
+		WHERE status = 'failed' AND error_class != '' AND deleted_at IS NULL
+		GROUP BY error_class
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[ErrorClass]int)
+	for rows.Next() {
+		var class ErrorClass
+		var n int
+		if err := rows.Scan(&class, &n); err != nil {
+			return nil, err
+		}
+		counts[class] = n
+	}
+	return counts, rows.Err()
 }
 
 // CancelJob marks a running or queued job as canceled
 func (db *DB) CancelJob(jobID int64) error {
-	now := time.Now().Format(time.RFC3339)
+	now := nowString()
 	result, err := db.Exec(`
 		UPDATE review_jobs
 		SET status = 'canceled', finished_at = ?, updated_at = ?
@@ -1031,7 +1194,7 @@ func (db *DB) CancelJob(jobID int64) error {
 	return nil
 }
 
-// ReenqueueJob resets a completed, failed, or canceled job back to queued status.
+// ReenqueueJob resets a completed, failed, canceled, or skipped job back to queued status.
 // This allows manual re-running of jobs to get a fresh review.
 // For done jobs, the existing review is deleted to avoid unique constraint violations.
 func (db *DB) ReenqueueJob(jobID int64) error {
@@ -1052,7 +1215,23 @@ func (db *DB) ReenqueueJob(jobID int64) error {
 		}
 	}()
 
-	// Delete any existing review for this job (for done jobs being rerun)
+	// Delete any existing review and salvaged output for this job (for done jobs being rerun)
+	_, err = conn.ExecContext(ctx, `DELETE FROM job_output WHERE job_id = ?`, jobID)
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `DELETE FROM review_attachments WHERE review_id IN (SELECT id FROM reviews WHERE job_id = ?)`, jobID)
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `DELETE FROM findings WHERE job_id = ?`, jobID)
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `DELETE FROM job_logs WHERE job_id = ?`, jobID)
+	if err != nil {
+		return err
+	}
 	_, err = conn.ExecContext(ctx, `DELETE FROM reviews WHERE job_id = ?`, jobID)
 	if err != nil {
 		return err
@@ -1061,8 +1240,8 @@ func (db *DB) ReenqueueJob(jobID int64) error {
 	// Reset job status
 	result, err := conn.ExecContext(ctx, `
 		UPDATE review_jobs
-		SET status = 'queued', worker_id = NULL, started_at = NULL, finished_at = NULL, error = NULL, retry_count = 0
-		WHERE id = ? AND status IN ('done', 'failed', 'canceled')
+		SET status = 'queued', worker_id = NULL, started_at = NULL, finished_at = NULL, error = NULL, error_class = '', retry_count = 0
+		WHERE id = ? AND status IN ('done', 'failed', 'canceled', 'skipped')
 	`, jobID)
 	if err != nil {
 		return err
@@ -1091,7 +1270,7 @@ func (db *DB) RetryJob(jobID int64, maxRetries int) (bool, error) {
 	// This prevents race conditions with multiple workers
 	result, err := db.Exec(`
 		UPDATE review_jobs
-		SET status = 'queued', worker_id = NULL, started_at = NULL, finished_at = NULL, error = NULL, retry_count = retry_count + 1
+		SET status = 'queued', worker_id = NULL, started_at = NULL, finished_at = NULL, error = NULL, error_class = '', retry_count = retry_count + 1
 		WHERE id = ? AND retry_count < ? AND status = 'running'
 	`, jobID, maxRetries)
 	if err != nil {
@@ -1152,7 +1331,7 @@ func WithAddressed(addressed bool) ListJobsOption {
 func (db *DB) ListJobs(statusFilter string, repoFilter string, limit, offset int, opts ...ListJobsOption) ([]ReviewJob, error) {
 	query := `
 		SELECT j.id, j.repo_id, j.commit_id, j.git_ref, j.branch, j.agent, j.reasoning, j.status, j.enqueued_at,
-		       j.started_at, j.finished_at, j.worker_id, j.error, j.prompt, j.retry_count,
+		       j.started_at, j.finished_at, j.worker_id, j.error, j.error_class, j.prompt, j.retry_count,
 		       COALESCE(j.agentic, 0), r.root_path, r.name, c.subject, rv.addressed, rv.output,
 		       j.source_machine_id, j.uuid, j.model, j.job_type, j.review_type
 		FROM review_jobs j
@@ -1161,7 +1340,7 @@ func (db *DB) ListJobs(statusFilter string, repoFilter string, limit, offset int
 		LEFT JOIN reviews rv ON rv.job_id = j.id
 	`
 	var args []interface{}
-	var conditions []string
+	conditions := []string{"j.deleted_at IS NULL"}
 
 	if statusFilter != "" {
 		conditions = append(conditions, "j.status = ?")
@@ -1169,7 +1348,7 @@ func (db *DB) ListJobs(statusFilter string, repoFilter string, limit, offset int
 	}
 	if repoFilter != "" {
 		conditions = append(conditions, "r.root_path = ?")
-		args = append(args, repoFilter)
+		args = append(args, canonicalRepoPath(repoFilter))
 	}
 	var o listJobsOptions
 	for _, opt := range opts {
@@ -1228,7 +1407,7 @@ func (db *DB) ListJobs(statusFilter string, repoFilter string, limit, offset int
 		var agentic int
 
 		err := rows.Scan(&j.ID, &j.RepoID, &commitID, &j.GitRef, &branch, &j.Agent, &j.Reasoning, &j.Status, &enqueuedAt,
-			&startedAt, &finishedAt, &workerID, &errMsg, &prompt, &j.RetryCount,
+			&startedAt, &finishedAt, &workerID, &errMsg, &j.ErrorClass, &prompt, &j.RetryCount,
 			&agentic, &j.RepoPath, &j.RepoName, &commitSubject, &addressed, &output,
 			&sourceMachineID, &jobUUID, &model, &jobTypeStr, &reviewTypeStr)
 		if err != nil {
@@ -1316,11 +1495,11 @@ func (db *DB) CountJobStats(repoFilter string, opts ...ListJobsOption) (JobStats
 		LEFT JOIN reviews rv ON rv.job_id = j.id
 	`
 	var args []interface{}
-	var conditions []string
+	conditions := []string{"j.deleted_at IS NULL"}
 
 	if repoFilter != "" {
 		conditions = append(conditions, "r.root_path = ?")
-		args = append(args, repoFilter)
+		args = append(args, canonicalRepoPath(repoFilter))
 	}
 	var o listJobsOptions
 	for _, opt := range opts {
@@ -1355,14 +1534,14 @@ func (db *DB) GetJobByID(id int64) (*ReviewJob, error) {
 	var model, branch, jobTypeStr, reviewTypeStr sql.NullString
 	err := db.QueryRow(`
 		SELECT j.id, j.repo_id, j.commit_id, j.git_ref, j.branch, j.agent, j.reasoning, j.status, j.enqueued_at,
-		       j.started_at, j.finished_at, j.worker_id, j.error, j.prompt, COALESCE(j.agentic, 0),
+		       j.started_at, j.finished_at, j.worker_id, j.error, j.error_class, j.prompt, COALESCE(j.agentic, 0),
 		       r.root_path, r.name, c.subject, j.model, j.job_type, j.review_type
 		FROM review_jobs j
 		JOIN repos r ON r.id = j.repo_id
 		LEFT JOIN commits c ON c.id = j.commit_id
-		WHERE j.id = ?
+		WHERE j.id = ? AND j.deleted_at IS NULL
 	`, id).Scan(&j.ID, &j.RepoID, &commitID, &j.GitRef, &branch, &j.Agent, &j.Reasoning, &j.Status, &enqueuedAt,
-		&startedAt, &finishedAt, &workerID, &errMsg, &prompt, &agentic,
+		&startedAt, &finishedAt, &workerID, &errMsg, &j.ErrorClass, &prompt, &agentic,
 		&j.RepoPath, &j.RepoName, &commitSubject, &model, &jobTypeStr, &reviewTypeStr)
 	if err != nil {
 		return nil, err
@@ -1409,9 +1588,17 @@ func (db *DB) GetJobByID(id int64) (*ReviewJob, error) {
 	return &j, nil
 }
 
+// GetJobDiffContent returns the diff captured when a dirty job was
+// enqueued, or "" for jobs that review commits
+func (db *DB) GetJobDiffContent(jobID int64) (string, error) {
+	var diff sql.NullString
+	err := db.QueryRow(`SELECT diff_content FROM review_jobs WHERE id = ? AND deleted_at IS NULL`, jobID).Scan(&diff)
+	return diff.String, err
+}
+
 // GetJobCounts returns counts of jobs by status
 func (db *DB) GetJobCounts() (queued, running, done, failed, canceled int, err error) {
-	rows, err := db.Query(`SELECT status, COUNT(*) FROM review_jobs GROUP BY status`)
+	rows, err := db.Query(`SELECT status, COUNT(*) FROM review_jobs WHERE deleted_at IS NULL GROUP BY status`)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunMaintenance(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	// Generate WAL traffic and free pages
+	for i := 0; i < 20; i++ {
+		createJobChain(t, db, "/tmp/maint-repo", string(rune('a'+i))+"sha")
+	}
+	if _, err := db.Exec(`DELETE FROM review_jobs`); err != nil {
+		t.Fatalf("delete jobs: %v", err)
+	}
+
+	res, err := db.RunMaintenance(context.Background())
+	if err != nil {
+		t.Fatalf("RunMaintenance failed: %v", err)
+	}
+	if res.CheckpointBusy {
+		t.Error("Expected checkpoint to complete with no active readers")
+	}
+	if res.FreePagesAfter > res.FreePagesBefore {
+		t.Errorf("free pages grew: before=%d after=%d", res.FreePagesBefore, res.FreePagesAfter)
+	}
+
+	var mode int
+	if err := db.QueryRow(`PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		t.Fatalf("read auto_vacuum: %v", err)
+	}
+	if mode != 2 {
+		t.Errorf("Expected auto_vacuum=INCREMENTAL (2) for new databases, got %d", mode)
+	}
+
+	// Planner statistics should exist after ANALYZE
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_stat1`).Scan(&n); err != nil {
+		t.Fatalf("query sqlite_stat1: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFindingTitle caps the length of a finding's title, in characters
+const maxFindingTitle = 100
+
+var (
+	// findingItemPattern matches the start of a list item or heading that
+	// may hold a finding, capturing its indentation and text
+	findingItemPattern = regexp.MustCompile(`^( {0,8})(?:[-*+]|\d{1,3}[.)]|#{2,6})\s+(.*)$`)
+
+	// severityMarkerPattern matches a severity written as a label in a
+	// finding's first line: **High**, [High], (High), High:, or Severity: High
+	severityMarkerPattern = regexp.MustCompile(`(?i)(?:\*\*\s*(?:severity:?\s*)?(critical|high|medium|low)\s*:?\s*\*\*|\[(critical|high|medium|low)\]|\((critical|high|medium|low)\)|^(critical|high|medium|low)(?::|\s+[-–—])|severity\W{0,4}(critical|high|medium|low)\b)`)
+
+	// severityLinePattern matches a severity given on its own line in a
+	// finding's body, such as "**Severity:** High"
+	severityLinePattern = regexp.MustCompile(`(?i)severity\W{0,4}(critical|high|medium|low)\b`)
+
+	// codeFileRefPattern matches a file reference in backticks, with an
+	// optional line or line range: `path/to/file.go:42-48`
+	codeFileRefPattern = regexp.MustCompile("`([A-Za-z0-9_./-]*[A-Za-z0-9_-]\\.[A-Za-z][A-Za-z0-9]{0,9})(?::(\\d+)(?:[-–](\\d+))?)?(?:[-:,]\\d+)*`")
+
+	// plainFileRefPattern matches a file reference outside backticks. A
+	// directory or line number is required so words like "e.g." don't match.
+	plainFileRefPattern = regexp.MustCompile(`(?:^|[\s(])((?:[A-Za-z0-9_.-]+/)+[A-Za-z0-9_-]+\.[A-Za-z][A-Za-z0-9]{0,9}|[A-Za-z0-9_-]+\.[A-Za-z][A-Za-z0-9]{0,9}(?::\d+))(?::(\d+))?(?:[-–](\d+))?`)
+
+	// lineWordPattern matches a line given in words right after a file
+	// reference: "file.go, line 42" or "file.go (lines 10-20)"
+	lineWordPattern = regexp.MustCompile(`^,?\s+\(?lines?\s+(\d+)(?:\s*[-–]\s*(\d+))?`)
+
+	// bareFileLinePattern matches a file name without a directory that is
+	// followed by a line in words: "main.go, line 7"
+	bareFileLinePattern = regexp.MustCompile(`(?:^|[\s(])([A-Za-z0-9_-]+\.[A-Za-z][A-Za-z0-9]{0,9}),?\s+\(?lines?\s+(\d+)(?:\s*[-–]\s*(\d+))?`)
+)
+
+// MarkdownFinding is a finding read from review prose, with where it was
+// found
+type MarkdownFinding struct {
+	Finding
+
+	// LineEnd is the last line of the range the finding refers to, such
+	// as 48 in "file.go:42-48", or 0 when it names a single line
+	LineEnd int
+
+	// Start and End are the lines of the output the finding was read from,
+	// counting from 0 after splitting on newlines; End is exclusive and
+	// trailing blank lines are left out
+	Start, End int
+}
+
+// ParseMarkdownFindings pulls findings out of review prose, for reviews
+// without a machine-readable findings block. A finding is a list item or
+// heading labeled with a severity; its title is the rest of its first line
+// and its message the whole item. Items without a severity are skipped, so
+// summaries and section headings aren't mistaken for findings.
+func ParseMarkdownFindings(output string) []Finding {
+	var findings []Finding
+	for _, f := range FindMarkdownFindings(output) {
+		findings = append(findings, f.Finding)
+	}
+	return findings
+}
+
+// FindMarkdownFindings is ParseMarkdownFindings for callers that also need
+// to know where each finding is in the output, such as to filter or
+// annotate it. An item ends at the next item at the same or a shallower
+// indentation, the next heading, or an HTML tag or rule between sections.
+func FindMarkdownFindings(output string) []MarkdownFinding {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	var findings []MarkdownFinding
+	inFence := false
+	for i := 0; i < len(lines); i++ {
+		if isFenceLine(lines[i]) {
+			inFence = !inFence
+			continue
+		}
+		m := findingItemPattern.FindStringSubmatch(lines[i])
+		if inFence || m == nil {
+			continue
+		}
+
+		indent := len(m[1])
+		isHeading := strings.HasPrefix(strings.TrimSpace(lines[i]), "#")
+		end := i + 1
+		blockFence := false
+		for ; end < len(lines); end++ {
+			if isFenceLine(lines[end]) {
+				blockFence = !blockFence
+			}
+			if blockFence {
+				continue
+			}
+			if isSectionBreak(lines[end]) {
+				break
+			}
+			next := findingItemPattern.FindStringSubmatch(lines[end])
+			if next == nil {
+				continue
+			}
+			if strings.HasPrefix(strings.TrimSpace(lines[end]), "#") || (!isHeading && len(next[1]) <= indent) {
+				break
+			}
+		}
+
+		last := end
+		for last > i+1 && strings.TrimSpace(lines[last-1]) == "" {
+			last--
+		}
+		if f, ok := parseMarkdownFinding(m[2], lines[i+1:last], isHeading); ok {
+			f.Start, f.End = i, last
+			findings = append(findings, f)
+			i = end - 1
+		}
+	}
+	return findings
+}
+
+// isSectionBreak reports whether line is an HTML tag or a horizontal rule,
+// which reviews use to wrap or separate sections
+func isSectionBreak(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "<") || trimmed == "---" || trimmed == "***"
+}
+
+// parseMarkdownFinding reads a finding from an item's first line and the
+// lines under it. ok is false if the item has no severity. A heading's
+// severity must come before any list under it, so a section heading over a
+// list of findings isn't taken for one.
+func parseMarkdownFinding(first string, rest []string, isHeading bool) (MarkdownFinding, bool) {
+	var f MarkdownFinding
+	title := first
+	if m := severityMarkerPattern.FindStringSubmatchIndex(first); m != nil {
+		for g := 2; g < len(m); g += 2 {
+			if m[g] >= 0 {
+				f.Severity = strings.ToLower(first[m[g]:m[g+1]])
+				break
+			}
+		}
+		title = first[:m[0]] + " " + first[m[1]:]
+	} else {
+		for _, line := range rest {
+			if isHeading && findingItemPattern.MatchString(line) {
+				break
+			}
+			if m := severityLinePattern.FindStringSubmatch(line); m != nil {
+				f.Severity = strings.ToLower(m[1])
+				break
+			}
+		}
+	}
+	if f.Severity == "" {
+		return MarkdownFinding{}, false
+	}
+
+	body := strings.TrimSpace(first)
+	if len(rest) > 0 {
+		body = strings.TrimSpace(body + "\n" + dedent(rest))
+	}
+	f.Message = body
+	f.Title = findingTitle(cleanTitle(title))
+	if f.Title == "" {
+		f.Title = findingTitle(dedent(rest))
+	}
+	f.File, f.Line, f.LineEnd = findFileRef(first + "\n" + strings.Join(rest, "\n"))
+	return f, true
+}
+
+// findFileRef returns the first file reference in text, preferring ones in
+// backticks, with the line or line range it names. A line may also follow
+// the reference in words, as in "file.go, line 42".
+func findFileRef(text string) (file string, line, lineEnd int) {
+	for _, pattern := range []*regexp.Regexp{codeFileRefPattern, plainFileRefPattern, bareFileLinePattern} {
+		m := pattern.FindStringSubmatchIndex(text)
+		if m == nil {
+			continue
+		}
+		file = text[m[2]:m[3]]
+		if i := strings.LastIndex(file, ":"); i >= 0 {
+			line, _ = strconv.Atoi(file[i+1:])
+			file = file[:i]
+		}
+		if m[4] >= 0 {
+			line, _ = strconv.Atoi(text[m[4]:m[5]])
+		}
+		if m[6] >= 0 {
+			lineEnd, _ = strconv.Atoi(text[m[6]:m[7]])
+		}
+		if line == 0 {
+			if w := lineWordPattern.FindStringSubmatch(text[m[1]:]); w != nil {
+				line, _ = strconv.Atoi(w[1])
+				lineEnd, _ = strconv.Atoi(w[2])
+			}
+		}
+		if lineEnd < line {
+			lineEnd = 0
+		}
+		return file, line, lineEnd
+	}
+	return "", 0, 0
+}
+
+// findingTitle shortens a finding's text to a title: its first line, cut
+// at the end of the first sentence and at maxFindingTitle characters
+func findingTitle(text string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if i := strings.Index(title, ". "); i > 0 {
+		title = title[:i]
+	}
+	title = strings.TrimSpace(strings.TrimSuffix(title, "."))
+	if utf8.RuneCountInString(title) <= maxFindingTitle {
+		return title
+	}
+	runes := []rune(title)[:maxFindingTitle]
+	if i := strings.LastIndex(string(runes), " "); i > maxFindingTitle/2 {
+		return string(runes)[:i] + "..."
+	}
+	return string(runes) + "..."
+}
+
+// cleanTitle strips the markup and separators left around a title once its
+// severity label is removed
+func cleanTitle(s string) string {
+	s = strings.ReplaceAll(s, "**", "")
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.Trim(s, " :-–—|*_")
+}
+
+// dedent joins lines with their common leading spaces removed
+func dedent(lines []string) string {
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= common && common > 0 {
+			line = line[common:]
+		}
+		out[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// isFenceLine reports whether line opens or closes a fenced code block
+func isFenceLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
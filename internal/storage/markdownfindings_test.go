@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMarkdownFindings(t *testing.T) {
+	output := strings.Join([]string{
+		"## Summary",
+		"Adds a cache. High-level structure looks fine.",
+		"",
+		"## Issues",
+		"",
+		"1. **High**: Race on the cache map in `internal/cache/cache.go:42`. Guard it with the mutex.",
+		"   - Readers and writers run on different goroutines.",
+		"2. [Low] Unused helper in util.go:7",
+		"- Medium - Missing test for expiry",
+		"",
+		"### Error is dropped",
+		"**Severity:** Critical",
+		"**File:** internal/store/store.go",
+		"",
+		"The error from Save is ignored.",
+		"",
+		"```go",
+		"- **High**: not a finding, inside a code block",
+		"```",
+		"",
+		"- Consider renaming the package.",
+	}, "\n")
+
+	findings := ParseMarkdownFindings(output)
+	want := []Finding{
+		{Severity: "high", File: "internal/cache/cache.go", Line: 42, Title: "Race on the cache map in `internal/cache/cache.go:42`"},
+		{Severity: "low", File: "util.go", Line: 7, Title: "Unused helper in util.go:7"},
+		{Severity: "medium", Title: "Missing test for expiry"},
+		{Severity: "critical", File: "internal/store/store.go", Title: "Error is dropped"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i, w := range want {
+		got := findings[i]
+		if got.Severity != w.Severity || got.File != w.File || got.Line != w.Line || got.Title != w.Title {
+			t.Errorf("finding %d = {%s %s:%d %q}, want {%s %s:%d %q}", i, got.Severity, got.File, got.Line, got.Title, w.Severity, w.File, w.Line, w.Title)
+		}
+	}
+	if !strings.Contains(findings[0].Message, "Readers and writers run on different goroutines.") {
+		t.Errorf("message should include the item's nested lines, got %q", findings[0].Message)
+	}
+	if !strings.Contains(findings[3].Message, "The error from Save is ignored.") {
+		t.Errorf("heading finding should run to the next heading, got %q", findings[3].Message)
+	}
+
+	nested := "## Findings\n\n1. Race on map\n   - Severity: High\n   - File: `cache.go:10`\n2. Slow loop\n   - Severity: Low\n"
+	if got := ParseMarkdownFindings(nested); len(got) != 2 || got[0].Title != "Race on map" || got[0].Line != 10 || got[1].Severity != "low" {
+		t.Errorf("expected the two listed findings, not the section heading, got %+v", got)
+	}
+
+	if got := ParseMarkdownFindings("No issues found."); len(got) != 0 {
+		t.Errorf("expected no findings, got %+v", got)
+	}
+}
+
+func TestFindMarkdownFindings(t *testing.T) {
+	output := strings.Join([]string{
+		"Summary: two issues.",
+		"",
+		"- **High**: Query built from input in `internal/db.go:20-25`",
+		"  Use a parameterized query.",
+		"",
+		"- **Low**: Unused variable in main.go, line 7",
+		"<details>",
+		"1. **Medium** leaks a handle (see store.go, lines 3-4)",
+		"</details>",
+	}, "\n")
+
+	findings := FindMarkdownFindings(output)
+	want := []struct {
+		file          string
+		line, lineEnd int
+		start, end    int
+	}{
+		{"internal/db.go", 20, 25, 2, 4},
+		{"main.go", 7, 0, 5, 6},
+		{"store.go", 3, 4, 7, 8},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for i, w := range want {
+		f := findings[i]
+		if f.File != w.file || f.Line != w.line || f.LineEnd != w.lineEnd || f.Start != w.start || f.End != w.end {
+			t.Errorf("finding %d = %s:%d-%d at [%d,%d), want %s:%d-%d at [%d,%d)",
+				i, f.File, f.Line, f.LineEnd, f.Start, f.End, w.file, w.line, w.lineEnd, w.start, w.end)
+		}
+	}
+}
+
+func TestFindingTitle(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"Race on map. Guard it with a mutex.", "Race on map"},
+		{"First line\nsecond line", "First line"},
+		{strings.Repeat("word ", 30), strings.TrimSpace(strings.Repeat("word ", 20)) + "..."},
+	}
+	for _, tt := range tests {
+		if got := findingTitle(tt.text); got != tt.want {
+			t.Errorf("findingTitle(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/roborev-dev/roborev/internal/config"
+)
+
+// schemaMigration is one step of the versioned schema upgrade. Each runs
+// once per database, in version order, in its own transaction.
+//
+// The column checks in migrate predate versioning and run on every open;
+// add new schema changes here instead, and update schema to match, since
+// new databases get schema as written and then run every step too.
+type schemaMigration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// schemaMigrations lists every versioned migration, oldest first. Never
+// renumber or remove an entry that has shipped.
+var schemaMigrations = []schemaMigration{
+	{1, "add findings category column", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "findings", "category", "TEXT NOT NULL DEFAULT ''")
+	}},
+	{2, "add findings title column", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "findings", "title", "TEXT NOT NULL DEFAULT ''")
+	}},
+	{3, "backfill findings from review prose", backfillFindings},
+	{4, "rename resolved findings to fixed", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE findings SET status = ? WHERE status = 'resolved'`, FindingFixed)
+		return err
+	}},
+	{5, "add full-text search index", createSearchIndex},
+	{6, "normalize timestamps to UTC RFC3339", normalizeTimestamps},
+}
+
+// SchemaVersion returns the version of the last migration applied to the
+// database, or 0 if none has been
+func (db *DB) SchemaVersion() (int, error) {
+	var version int
+	err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
+
+// applyMigrations runs the migrations newer than the database's version.
+// A failed migration is rolled back and stops the upgrade, leaving the
+// database at the previous version.
+func (db *DB) applyMigrations(migrations []schemaMigration) error {
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if n := len(migrations); n > 0 && current > migrations[n-1].version {
+		return fmt.Errorf("database schema version %d is newer than this roborev supports (%d); upgrade roborev", current, migrations[n-1].version)
+	}
+
+	for i, m := range migrations {
+		if i > 0 && m.version <= migrations[i-1].version {
+			return fmt.Errorf("migration %d (%s) is out of order", m.version, m.name)
+		}
+		if m.version <= current {
+			continue
+		}
+		if err := db.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) applyMigration(m schemaMigration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Recording the version first takes the write lock, so a process
+	// opening the database at the same time waits and then skips the step
+	result, err := tx.Exec(`INSERT OR IGNORE INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`, m.version, m.name, nowString())
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addColumnIfMissing adds a column to a table unless it already has one by
+// that name
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("check %s.%s column: %w", table, column, err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition)); err != nil {
+		return fmt.Errorf("add %s.%s column: %w", table, column, err)
+	}
+	return nil
+}
+
+// backfillFindings parses the findings of completed reviews that have none
+// stored, such as reviews from before findings were kept or whose agent
+// left out the findings block, and titles the stored findings that lack one.
+// Parsed findings are mapped onto the repo's severities and categories like
+// a new review's, and dated by their review.
+func backfillFindings(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+		SELECT r.job_id, r.output, r.created_at, rp.root_path FROM reviews r
+		JOIN review_jobs j ON j.id = r.job_id
+		JOIN repos rp ON rp.id = j.repo_id
+		WHERE j.job_type IN (?, ?, ?)
+		AND NOT EXISTS (SELECT 1 FROM findings f WHERE f.job_id = r.job_id)`,
+		JobTypeReview, JobTypeRange, JobTypeDirty)
+	if err != nil {
+		return fmt.Errorf("list reviews without findings: %w", err)
+	}
+	type parsed struct {
+		jobID     int64
+		createdAt string
+		repoPath  string
+		findings  []Finding
+	}
+	var backfill []parsed
+	for rows.Next() {
+		var jobID int64
+		var output, createdAt, repoPath string
+		if err := rows.Scan(&jobID, &output, &createdAt, &repoPath); err != nil {
+			rows.Close()
+			return err
+		}
+		if findings := ParseMarkdownFindings(output); len(findings) > 0 {
+			backfill = append(backfill, parsed{jobID, createdAt, repoPath, findings})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range backfill {
+		MapSeverities(p.findings, config.ResolveSeverities(p.repoPath))
+		MapCategories(p.findings, config.ResolveCategories(p.repoPath))
+		for _, f := range p.findings {
+			if _, err := tx.Exec(`INSERT INTO findings (job_id, severity, label, category, file, line, title, message, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				p.jobID, f.Severity, f.Label, f.Category, f.File, f.Line, f.Title, f.Message, p.createdAt); err != nil {
+				return fmt.Errorf("backfill findings of job %d: %w", p.jobID, err)
+			}
+		}
+	}
+
+	titles, err := tx.Query(`SELECT id, message FROM findings WHERE title = ''`)
+	if err != nil {
+		return err
+	}
+	untitled := make(map[int64]string)
+	for titles.Next() {
+		var id int64
+		var message string
+		if err := titles.Scan(&id, &message); err != nil {
+			titles.Close()
+			return err
+		}
+		untitled[id] = message
+	}
+	titles.Close()
+	if err := titles.Err(); err != nil {
+		return err
+	}
+	for id, message := range untitled {
+		if _, err := tx.Exec(`UPDATE findings SET title = ? WHERE id = ?`, findingTitle(message), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
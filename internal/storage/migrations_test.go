@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenAppliesSchemaMigrations(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	if want := schemaMigrations[len(schemaMigrations)-1].version; version != want {
+		t.Errorf("schema version = %d, want %d", version, want)
+	}
+}
+
+func TestSchemaMigrationsUpgradeOldDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// A database from before findings had categories or versions were tracked
+	rawDB, err := sql.Open("sqlite", dbPath+"?_pragma=journal_mode(WAL)")
+	if err != nil {
+		t.Fatalf("Failed to open raw DB: %v", err)
+	}
+	_, err = rawDB.Exec(`
+		CREATE TABLE findings (
+			id INTEGER PRIMARY KEY,
+			job_id INTEGER NOT NULL,
+			severity TEXT NOT NULL DEFAULT '',
+			file TEXT NOT NULL DEFAULT '',
+			line INTEGER NOT NULL DEFAULT 0,
+			message TEXT NOT NULL,
+			created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+		INSERT INTO findings (job_id, severity, message) VALUES (1, 'high', 'kept');
+	`)
+	rawDB.Close()
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	var category, message string
+	if err := db.QueryRow(`SELECT category, message FROM findings`).Scan(&category, &message); err != nil {
+		t.Fatalf("query upgraded findings: %v", err)
+	}
+	if category != "" || message != "kept" {
+		t.Errorf("unexpected upgraded row: category %q message %q", category, message)
+	}
+	if version, _ := db.SchemaVersion(); version != schemaMigrations[len(schemaMigrations)-1].version {
+		t.Errorf("schema version = %d after upgrade", version)
+	}
+}
+
+func TestApplyMigrations(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	base, _ := db.SchemaVersion()
+
+	var ran []int
+	step := func(version int, up func(tx *sql.Tx) error) schemaMigration {
+		return schemaMigration{version: version, name: "test step", up: func(tx *sql.Tx) error {
+			ran = append(ran, version)
+			if up != nil {
+				return up(tx)
+			}
+			return nil
+		}}
+	}
+	migrations := append(append([]schemaMigration(nil), schemaMigrations...),
+		step(base+1, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`CREATE TABLE migration_test (id INTEGER PRIMARY KEY)`)
+			return err
+		}),
+		step(base+2, nil),
+	)
+
+	if err := db.applyMigrations(migrations); err != nil {
+		t.Fatalf("applyMigrations: %v", err)
+	}
+	if len(ran) != 2 || ran[0] != base+1 || ran[1] != base+2 {
+		t.Errorf("expected the new steps to run in order, ran %v", ran)
+	}
+
+	t.Run("applied steps don't run again", func(t *testing.T) {
+		ran = nil
+		if err := db.applyMigrations(migrations); err != nil {
+			t.Fatalf("applyMigrations: %v", err)
+		}
+		if len(ran) != 0 {
+			t.Errorf("expected nothing to run, ran %v", ran)
+		}
+	})
+
+	t.Run("failed step rolls back", func(t *testing.T) {
+		failing := append(migrations, step(base+3, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`CREATE TABLE rolled_back (id INTEGER PRIMARY KEY)`); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		}))
+		if err := db.applyMigrations(failing); err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("expected the step's error, got %v", err)
+		}
+		if version, _ := db.SchemaVersion(); version != base+2 {
+			t.Errorf("schema version = %d, want %d", version, base+2)
+		}
+		var count int
+		db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'rolled_back'`).Scan(&count)
+		if count != 0 {
+			t.Error("failed step's changes should be rolled back")
+		}
+	})
+
+	t.Run("rejects a newer database", func(t *testing.T) {
+		if err := db.applyMigrations(schemaMigrations); err == nil || !strings.Contains(err.Error(), "newer") {
+			t.Errorf("expected a newer schema error, got %v", err)
+		}
+	})
+
+	t.Run("rejects out of order steps", func(t *testing.T) {
+		bad := []schemaMigration{step(base+5, nil), step(base+4, nil)}
+		if err := db.applyMigrations(bad); err == nil || !strings.Contains(err.Error(), "out of order") {
+			t.Errorf("expected an ordering error, got %v", err)
+		}
+	})
+}
+
+func TestBackfillFindings(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, ".roborev.toml"), []byte("[[severities]]\nname = \"blocker\"\nlevel = \"high\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo := createRepo(t, db, repoDir)
+	complete := func(sha, output string) int64 {
+		t.Helper()
+		commit := createCommit(t, db, repo.ID, sha)
+		job := enqueueJob(t, db, repo.ID, commit.ID, sha)
+		if _, err := db.Exec(`UPDATE review_jobs SET status = 'running' WHERE id = ?`, job.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.CompleteJob(job.ID, "test", "prompt", output); err != nil {
+			t.Fatalf("CompleteJob: %v", err)
+		}
+		return job.ID
+	}
+	prose := complete("aaa111", "## Issues\n\n- **High**: Race in `cache.go:10`\n- **Low**: Typo in docs/README.md\n")
+	structured := complete("bbb222", "- **High**: Already parsed")
+	// Findings take the date of their review, not of the migration
+	if _, err := db.Exec(`UPDATE reviews SET created_at = '2024-01-02 03:04:05' WHERE job_id = ?`, prose); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveFindings(structured, []Finding{{Severity: "high", Message: "Already parsed"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE findings SET title = '' WHERE job_id = ?`, structured); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backfillFindings(tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("backfillFindings: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := db.GetFindingsForJob(prose)
+	if err != nil {
+		t.Fatalf("GetFindingsForJob: %v", err)
+	}
+	if len(findings) != 2 || findings[0].Severity != "high" || findings[0].Label != "blocker" || findings[0].File != "cache.go" || findings[0].Line != 10 ||
+		findings[1].Title != "Typo in docs/README.md" {
+		t.Errorf("unexpected backfilled findings %+v", findings)
+	}
+	var createdAt string
+	if err := db.QueryRow(`SELECT MAX(created_at) FROM findings WHERE job_id = ?`, prose).Scan(&createdAt); err != nil {
+		t.Fatal(err)
+	}
+	if createdAt != "2024-01-02 03:04:05" {
+		t.Errorf("expected findings dated by their review, got %q", createdAt)
+	}
+
+	kept, _ := db.GetFindingsForJob(structured)
+	if len(kept) != 1 || kept[0].Title != "Already parsed" {
+		t.Errorf("expected the stored finding kept and titled, got %+v", kept)
+	}
+}
@@ -31,6 +31,20 @@ const (
 	JobStatusDone     JobStatus = "done"
 	JobStatusFailed   JobStatus = "failed"
 	JobStatusCanceled JobStatus = "canceled"
+	JobStatusSkipped  JobStatus = "skipped" // Matched a skip rule; Error holds the reason
+)
+
+// ErrorClass categorizes why a job failed so retries, stats, and error
+// messages can treat each kind of failure differently. The empty class
+// means the failure was not classified.
+type ErrorClass string
+
+const (
+	ErrorClassRefusal   ErrorClass = "refusal"    // Agent declined to review
+	ErrorClassAuth      ErrorClass = "auth"       // Agent is not logged in or its credentials were rejected
+	ErrorClassRateLimit ErrorClass = "rate_limit" // Provider rate limit or quota exhausted
+	ErrorClassTimeout   ErrorClass = "timeout"    // Job exceeded its timeout
+	ErrorClassCrash     ErrorClass = "crash"      // Agent exited abnormally or produced unusable output
 )
 
 // JobType classifies what kind of work a review job represents.
@@ -57,6 +71,7 @@ type ReviewJob struct {
 	FinishedAt   *time.Time `json:"finished_at,omitempty"`
 	WorkerID     string     `json:"worker_id,omitempty"`
 	Error        string     `json:"error,omitempty"`
+	ErrorClass   ErrorClass `json:"error_class,omitempty"` // Failure category for failed jobs
 	Prompt       string     `json:"prompt,omitempty"`
 	RetryCount   int        `json:"retry_count"`
 	DiffContent  *string    `json:"diff_content,omitempty"`  // For dirty reviews (uncommitted changes)
@@ -121,6 +136,22 @@ type Review struct {
 	CreatedAt time.Time `json:"created_at"`
 	Addressed bool      `json:"addressed"`
 
+	// Summarized is true when Output is a condensed version of an oversized
+	// review; the complete text is available via GetReviewFullOutput.
+	Summarized bool `json:"summarized,omitempty"`
+
+	// AgentVersion is what the agent binary reported for --version when the
+	// review ran, for comparing results across upgrades
+	AgentVersion string `json:"agent_version,omitempty"`
+
+	// InjectionWarning explains why the review is suspected of following
+	// instructions embedded in the reviewed change instead of reviewing it
+	InjectionWarning string `json:"injection_warning,omitempty"`
+
+	// Language is set when Output has been translated into another language
+	// for display; the stored review is unchanged
+	Language string `json:"language,omitempty"`
+
 	// Sync fields
 	UUID               string     `json:"uuid,omitempty"`                  // Globally unique identifier for sync
 	UpdatedAt          *time.Time `json:"updated_at,omitempty"`            // Last modification time
@@ -131,6 +162,79 @@ type Review struct {
 	Job *ReviewJob `json:"job,omitempty"`
 }
 
+// JobLog is the execution trace of a job's last failed agent run
+type JobLog struct {
+	JobID       int64     `json:"job_id"`
+	CommandLine string    `json:"command_line"`
+	ExitCode    *int      `json:"exit_code,omitempty"` // nil when the process didn't exit normally
+	Stderr      string    `json:"stderr"`
+	Error       string    `json:"error"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Finding is one issue reported by a review, parsed from the
+// machine-readable block agents append to their output
+type Finding struct {
+	ID       int64  `json:"id"`
+	JobID    int64  `json:"job_id"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+
+	// Title is a one-line summary of Message, given by the agent or taken
+	// from the message's first sentence
+	Title string `json:"title,omitempty"`
+
+	// Label is the repo's own severity label, such as "blocker", when it
+	// defines severities; Severity then holds the built-in level it ranks as
+	Label string `json:"label,omitempty"`
+
+	// Category is the kind of issue, one of config.DefaultFindingCategories
+	// or a category the repo defines, when the agent gave one
+	Category string `json:"category,omitempty"`
+
+	// Status is where the finding stands: FindingOpen until it is fixed,
+	// dismissed, or acknowledged. StatusNote says why it last changed.
+	Status     string `json:"status,omitempty"`
+	StatusNote string `json:"status_note,omitempty"`
+}
+
+// SeverityLabel returns the label to show for a finding's severity
+func (f Finding) SeverityLabel() string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return f.Severity
+}
+
+// Finding statuses
+const (
+	FindingOpen         = "open"
+	FindingFixed        = "fixed"        // No longer applies to the code
+	FindingDismissed    = "dismissed"    // Not a real issue, or not worth fixing
+	FindingAcknowledged = "acknowledged" // A real issue, left for later
+)
+
+// FindingStatuses lists the finding statuses in lifecycle order
+var FindingStatuses = []string{FindingOpen, FindingAcknowledged, FindingFixed, FindingDismissed}
+
+// NormalizeFindingStatus returns the status a user or client named,
+// accepting any case and the old "resolved". ok is false if there is no
+// such status.
+func NormalizeFindingStatus(status string) (string, bool) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "resolved" {
+		return FindingFixed, true
+	}
+	for _, s := range FindingStatuses {
+		if status == s {
+			return s, true
+		}
+	}
+	return "", false
+}
+
 type Response struct {
 	ID        int64     `json:"id"`
 	CommitID  *int64    `json:"commit_id,omitempty"` // For commit-based responses (legacy)
@@ -146,17 +250,35 @@ type Response struct {
 }
 
 type DaemonStatus struct {
-	Version             string `json:"version"`
-	QueuedJobs          int    `json:"queued_jobs"`
-	RunningJobs         int    `json:"running_jobs"`
-	CompletedJobs       int    `json:"completed_jobs"`
-	FailedJobs          int    `json:"failed_jobs"`
-	CanceledJobs        int    `json:"canceled_jobs"`
-	ActiveWorkers       int    `json:"active_workers"`
-	MaxWorkers          int    `json:"max_workers"`
-	MachineID           string `json:"machine_id,omitempty"`            // Local machine ID for remote job detection
-	ConfigReloadedAt    string `json:"config_reloaded_at,omitempty"`    // Last config reload timestamp (RFC3339Nano)
-	ConfigReloadCounter uint64 `json:"config_reload_counter,omitempty"` // Monotonic reload counter (for sub-second detection)
+	Version             string             `json:"version"`
+	QueuedJobs          int                `json:"queued_jobs"`
+	RunningJobs         int                `json:"running_jobs"`
+	CompletedJobs       int                `json:"completed_jobs"`
+	FailedJobs          int                `json:"failed_jobs"`
+	FailureClasses      map[ErrorClass]int `json:"failure_classes,omitempty"` // Failed jobs by error class
+	Agents              []AgentHealth      `json:"agents,omitempty"`          // Install and last-run status per agent
+	CanceledJobs        int                `json:"canceled_jobs"`
+	ActiveWorkers       int                `json:"active_workers"`
+	MaxWorkers          int                `json:"max_workers"`
+	MachineID           string             `json:"machine_id,omitempty"`            // Local machine ID for remote job detection
+	ConfigReloadedAt    string             `json:"config_reloaded_at,omitempty"`    // Last config reload timestamp (RFC3339Nano)
+	ConfigReloadCounter uint64             `json:"config_reload_counter,omitempty"` // Monotonic reload counter (for sub-second detection)
+	Budget              *BudgetStatus      `json:"budget,omitempty"`                // Estimated spending today, when any is tracked or capped
+}
+
+// BudgetStatus reports estimated spending today against the daily budgets
+type BudgetStatus struct {
+	SpentTodayUSD float64          `json:"spent_today_usd"`
+	DailyLimitUSD float64          `json:"daily_limit_usd,omitempty"` // Cap across all repos (0 = none)
+	FallbackAgent string           `json:"fallback_agent,omitempty"`  // Agent new jobs switch to once over budget; empty pauses enqueuing
+	Exceeded      []BudgetExceeded `json:"exceeded,omitempty"`
+}
+
+// BudgetExceeded is a daily budget that has been reached
+type BudgetExceeded struct {
+	RepoPath string  `json:"repo_path,omitempty"` // Empty for the budget across all repos
+	SpentUSD float64 `json:"spent_usd"`
+	LimitUSD float64 `json:"limit_usd"`
 }
 
 // HealthStatus represents the overall daemon health
@@ -176,6 +298,23 @@ type ComponentHealth struct {
 	Message string `json:"message,omitempty"`
 }
 
+// AgentHealth reports whether an agent is installed and working
+type AgentHealth struct {
+	Name       string     `json:"name"`
+	Command    string     `json:"command,omitempty"` // Executable for command-line agents
+	Path       string     `json:"path,omitempty"`    // Resolved executable path
+	Version    string     `json:"version,omitempty"` // First line of the agent's --version output
+	Installed  bool       `json:"installed"`
+	Healthy    bool       `json:"healthy"`               // Installed and its last run succeeded
+	ErrorClass ErrorClass `json:"error_class,omitempty"` // Class of the last failure
+	Error      string     `json:"error,omitempty"`       // Last failure, with a hint where one applies
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`  // When the agent last ran a prompt
+
+	// QualityAlarm describes a spike in empty or unparseable reviews from
+	// the agent's recent jobs, if there is one
+	QualityAlarm string `json:"quality_alarm,omitempty"`
+}
+
 // ErrorEntry represents a single error log entry (mirrors daemon.ErrorEntry for API)
 type ErrorEntry struct {
 	Timestamp time.Time `json:"ts"`
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// canonicalRepoPath returns the one spelling repo records use for a path:
+// absolute, with symlinks resolved and, on case-insensitive filesystems
+// such as macOS and Windows defaults, each component in its on-disk case.
+// This keeps a repo reached through a symlink or typed as ~/Src/App from
+// getting a second record. Paths that don't exist are only made absolute.
+func canonicalRepoPath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+	return diskCase(filepath.Clean(absPath))
+}
+
+// diskCase rewrites each component of an absolute path to the case of its
+// directory entry when the filesystem treats the two as the same file. On
+// case-sensitive filesystems the entries already match, so nothing changes.
+func diskCase(path string) string {
+	volume := filepath.VolumeName(path)
+	if len(volume) == 2 && volume[1] == ':' {
+		volume = strings.ToUpper(volume)
+	}
+	sep := string(filepath.Separator)
+	result := volume + sep
+	for _, part := range strings.Split(strings.Trim(path[len(filepath.VolumeName(path)):], sep), sep) {
+		if part == "" {
+			continue
+		}
+		result = filepath.Join(result, entryName(result, part))
+	}
+	return result
+}
+
+// entryName returns the directory entry in dir spelled like name, or name
+// itself if it is an entry or no other spelling is the same file
+func entryName(dir, name string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return name
+	}
+	match := ""
+	for _, e := range entries {
+		if e.Name() == name {
+			return name
+		}
+		if match == "" && strings.EqualFold(e.Name(), name) {
+			match = e.Name()
+		}
+	}
+	if match == "" {
+		return name
+	}
+	given, err := os.Stat(filepath.Join(dir, name))
+	if err != nil {
+		return name
+	}
+	onDisk, err := os.Stat(filepath.Join(dir, match))
+	if err != nil || !os.SameFile(given, onDisk) {
+		return name
+	}
+	return match
+}
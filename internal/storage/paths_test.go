@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetOrCreateRepoCanonicalizesSymlinks(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoPath := filepath.Join(dir, "project")
+	if err := os.Mkdir(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(repoPath, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	viaLink, err := db.GetOrCreateRepo(link)
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	direct, err := db.GetOrCreateRepo(repoPath + string(filepath.Separator) + ".")
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	if viaLink.ID != direct.ID {
+		t.Errorf("expected one repo record, got %d and %d", viaLink.ID, direct.ID)
+	}
+	if viaLink.RootPath != repoPath || viaLink.Name != "project" {
+		t.Errorf("expected record for %s named project, got %s named %s", repoPath, viaLink.RootPath, viaLink.Name)
+	}
+	if found, err := db.GetRepoByPath(link); err != nil || found.ID != direct.ID {
+		t.Errorf("GetRepoByPath(link) = %v, %v; want repo %d", found, err, direct.ID)
+	}
+}
+
+func TestGetOrCreateRepoCaseInsensitive(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoPath := filepath.Join(dir, "MyProject")
+	if err := os.Mkdir(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lower := filepath.Join(dir, "myproject")
+	if _, err := os.Stat(lower); err != nil {
+		t.Skip("filesystem is case-sensitive")
+	}
+
+	first, err := db.GetOrCreateRepo(lower)
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	second, err := db.GetOrCreateRepo(filepath.Join(dir, strings.ToUpper("myproject")))
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	if first.ID != second.ID || first.RootPath != repoPath {
+		t.Errorf("expected one record for %s, got %s (%d) and %s (%d)", repoPath, first.RootPath, first.ID, second.RootPath, second.ID)
+	}
+}
+
+func TestGetOrCreateRepoAdoptsLegacyPath(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoPath := filepath.Join(dir, "project")
+	if err := os.Mkdir(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(repoPath, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	// A record from before canonicalization, under the symlinked spelling
+	result, err := db.Exec(`INSERT INTO repos (root_path, name, created_at) VALUES (?, 'link', ?)`, link, nowString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyID, _ := result.LastInsertId()
+
+	repo, err := db.GetOrCreateRepo(link)
+	if err != nil {
+		t.Fatalf("GetOrCreateRepo failed: %v", err)
+	}
+	if repo.ID != legacyID || repo.RootPath != repoPath {
+		t.Errorf("expected legacy repo %d moved to %s, got %d at %s", legacyID, repoPath, repo.ID, repo.RootPath)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -13,10 +14,7 @@ import (
 // If identity is provided, it will be stored; otherwise the identity field remains NULL.
 func (db *DB) GetOrCreateRepo(rootPath string, identity ...string) (*Repo, error) {
 	// Normalize path
-	absPath, err := filepath.Abs(rootPath)
-	if err != nil {
-		return nil, err
-	}
+	absPath := canonicalRepoPath(rootPath)
 
 	// Extract optional identity
 	var repoIdentity string
@@ -24,11 +22,17 @@ func (db *DB) GetOrCreateRepo(rootPath string, identity ...string) (*Repo, error
 		repoIdentity = identity[0]
 	}
 
+	// Adopt a record made before paths were canonicalized, under the
+	// spelling it was reached by, rather than starting a second history
+	if err := db.adoptLegacyRepoPath(rootPath, absPath); err != nil {
+		return nil, err
+	}
+
 	// Try to find existing by path
 	var repo Repo
 	var createdAt string
 	var identityNullable sql.NullString
-	err = db.QueryRow(`SELECT id, root_path, name, identity, created_at FROM repos WHERE root_path = ?`, absPath).
+	err := db.QueryRow(`SELECT id, root_path, name, identity, created_at FROM repos WHERE root_path = ?`, absPath).
 		Scan(&repo.ID, &repo.RootPath, &repo.Name, &identityNullable, &createdAt)
 	if err == nil {
 		repo.Identity = identityNullable.String
@@ -48,13 +52,31 @@ func (db *DB) GetOrCreateRepo(rootPath string, identity ...string) (*Repo, error
 		return nil, err
 	}
 
+	// A checkout that was moved or renamed on disk keeps its history: adopt
+	// the record left at its old path, matched by identity. Local identities
+	// embed the path, so they can't match after a move.
+	if repoIdentity != "" && !strings.HasPrefix(repoIdentity, "local://") && dirExists(absPath) {
+		moved, err := db.findMovedRepo(repoIdentity)
+		if err != nil {
+			return nil, fmt.Errorf("find moved repo: %w", err)
+		}
+		if moved != nil {
+			moved, err = db.MoveRepo(moved.ID, absPath)
+			if err != nil {
+				return nil, err
+			}
+			moved.Identity = repoIdentity
+			return moved, nil
+		}
+	}
+
 	// Create new — use INSERT OR IGNORE to handle concurrent inserts on the
 	// same root_path (UNIQUE constraint). If the row already exists, re-read it.
 	name := filepath.Base(absPath)
 	if repoIdentity != "" {
-		_, err = db.Exec(`INSERT OR IGNORE INTO repos (root_path, name, identity) VALUES (?, ?, ?)`, absPath, name, repoIdentity)
+		_, err = db.Exec(`INSERT OR IGNORE INTO repos (root_path, name, identity, created_at) VALUES (?, ?, ?, ?)`, absPath, name, repoIdentity, nowString())
 	} else {
-		_, err = db.Exec(`INSERT OR IGNORE INTO repos (root_path, name) VALUES (?, ?)`, absPath, name)
+		_, err = db.Exec(`INSERT OR IGNORE INTO repos (root_path, name, created_at) VALUES (?, ?, ?)`, absPath, name, nowString())
 	}
 	if err != nil {
 		return nil, err
@@ -85,16 +107,36 @@ func (db *DB) GetOrCreateRepo(rootPath string, identity ...string) (*Repo, error
 	return &created, nil
 }
 
-// GetRepoByPath returns a repo by its path
+// adoptLegacyRepoPath moves a repo recorded under a non-canonical spelling
+// of its path to the canonical one, unless the canonical path already has
+// a record
+func (db *DB) adoptLegacyRepoPath(rootPath, canonical string) error {
+	absPath, err := filepath.Abs(rootPath)
+	if err != nil || absPath == canonical {
+		return nil
+	}
+	_, err = db.Exec(`UPDATE repos SET root_path = ? WHERE root_path = ?
+		AND NOT EXISTS (SELECT 1 FROM repos WHERE root_path = ?)`, canonical, absPath, canonical)
+	if err != nil {
+		return fmt.Errorf("canonicalize repo path: %w", err)
+	}
+	return nil
+}
+
+// GetRepoByPath returns a repo by its path, in any spelling that resolves
+// to the recorded one
 func (db *DB) GetRepoByPath(rootPath string) (*Repo, error) {
 	absPath, err := filepath.Abs(rootPath)
 	if err != nil {
 		return nil, err
 	}
 
+	canonical := canonicalRepoPath(absPath)
+
 	var repo Repo
 	var createdAt string
-	err = db.QueryRow(`SELECT id, root_path, name, created_at FROM repos WHERE root_path = ?`, absPath).
+	err = db.QueryRow(`SELECT id, root_path, name, created_at FROM repos WHERE root_path IN (?, ?)
+		ORDER BY root_path = ? DESC LIMIT 1`, canonical, absPath, canonical).
 		Scan(&repo.ID, &repo.RootPath, &repo.Name, &createdAt)
 	if err != nil {
 		return nil, err
@@ -218,14 +260,14 @@ func (db *DB) ListBranchesWithCounts(repoPaths []string) (*BranchListResult, err
 			WHERE r.root_path = ?
 			GROUP BY branch_name
 			ORDER BY job_count DESC, branch_name
-		`, repoPaths[0])
+		`, canonicalRepoPath(repoPaths[0]))
 	} else {
 		// Multiple repo paths - build IN clause with placeholders
 		placeholders := make([]string, len(repoPaths))
 		args := make([]interface{}, len(repoPaths))
 		for i, p := range repoPaths {
 			placeholders[i] = "?"
-			args[i] = p
+			args[i] = canonicalRepoPath(p)
 		}
 		query := fmt.Sprintf(`
 			SELECT COALESCE(NULLIF(rj.branch, ''), '(none)') as branch_name, COUNT(*) as job_count
@@ -269,7 +311,7 @@ func (db *DB) RenameRepo(identifier, newName string) (int64, error) {
 	absPath, _ := filepath.Abs(identifier)
 
 	// Try path match first
-	result, err := db.Exec(`UPDATE repos SET name = ? WHERE root_path = ?`, newName, absPath)
+	result, err := db.Exec(`UPDATE repos SET name = ? WHERE root_path IN (?, ?)`, newName, canonicalRepoPath(identifier), absPath)
 	if err != nil {
 		return 0, err
 	}
@@ -487,7 +529,15 @@ func (db *DB) DeleteRepo(repoID int64, cascade bool) error {
 			return err
 		}
 
-		// 2. Delete reviews for jobs in this repo
+		// 2. Delete reviews (and their attachments) for jobs in this repo
+		_, err = conn.ExecContext(ctx, `
+			DELETE FROM review_attachments WHERE review_id IN (
+				SELECT rv.id FROM reviews rv JOIN review_jobs j ON j.id = rv.job_id WHERE j.repo_id = ?
+			)
+		`, repoID)
+		if err != nil {
+			return err
+		}
 		_, err = conn.ExecContext(ctx, `
 			DELETE FROM reviews WHERE job_id IN (
 				SELECT id FROM review_jobs WHERE repo_id = ?
@@ -497,7 +547,31 @@ func (db *DB) DeleteRepo(repoID int64, cascade bool) error {
 			return err
 		}
 
-		// 3. Delete jobs for this repo
+		// 3. Delete jobs (and their partial output, findings, and logs) for this repo
+		_, err = conn.ExecContext(ctx, `
+			DELETE FROM job_output WHERE job_id IN (
+				SELECT id FROM review_jobs WHERE repo_id = ?
+			)
+		`, repoID)
+		if err != nil {
+			return err
+		}
+		_, err = conn.ExecContext(ctx, `
+			DELETE FROM findings WHERE job_id IN (
+				SELECT id FROM review_jobs WHERE repo_id = ?
+			)
+		`, repoID)
+		if err != nil {
+			return err
+		}
+		_, err = conn.ExecContext(ctx, `
+			DELETE FROM job_logs WHERE job_id IN (
+				SELECT id FROM review_jobs WHERE repo_id = ?
+			)
+		`, repoID)
+		if err != nil {
+			return err
+		}
 		_, err = conn.ExecContext(ctx, `DELETE FROM review_jobs WHERE repo_id = ?`, repoID)
 		if err != nil {
 			return err
@@ -581,3 +655,139 @@ func (db *DB) MergeRepos(sourceRepoID, targetRepoID int64) (int64, error) {
 
 	return affected, nil
 }
+
+// MoveRepo points a repo at the new root path of its checkout, keeping its
+// jobs, reviews, and responses. A name that still follows the old directory
+// follows the new one, and an allowlist entry for the old path and a local
+// identity move with the repo. It fails if another repo is already recorded at newPath.
+func (db *DB) MoveRepo(repoID int64, newPath string) (*Repo, error) {
+	canonical := canonicalRepoPath(newPath)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var oldPath, name string
+	err = tx.QueryRow(`SELECT root_path, name FROM repos WHERE id = ?`, repoID).Scan(&oldPath, &name)
+	if err != nil {
+		return nil, err
+	}
+	if oldPath != canonical {
+		var otherName string
+		err = tx.QueryRow(`SELECT name FROM repos WHERE root_path = ?`, canonical).Scan(&otherName)
+		if err == nil {
+			return nil, fmt.Errorf("repo %q is already at %s; merge the two instead", otherName, canonical)
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if name == filepath.Base(oldPath) {
+			name = filepath.Base(canonical)
+		}
+		if _, err := tx.Exec(`UPDATE repos SET root_path = ?, name = ? WHERE id = ?`, canonical, name, repoID); err != nil {
+			return nil, fmt.Errorf("move repo: %w", err)
+		}
+		// A repo without a remote is identified by its path
+		if _, err := tx.Exec(`UPDATE repos SET identity = ? WHERE id = ? AND identity LIKE 'local://%'`, "local://"+canonical, repoID); err != nil {
+			return nil, fmt.Errorf("move repo identity: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE OR IGNORE repo_allowlist SET path = ? WHERE path = ?`, canonical, oldPath); err != nil {
+			return nil, fmt.Errorf("move allowlist entry: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return db.GetRepoByID(repoID)
+}
+
+// findMovedRepo returns the one repo with the given identity whose checkout
+// is gone from disk, or nil if there is none or more than one to choose from
+func (db *DB) findMovedRepo(identity string) (*Repo, error) {
+	rows, err := db.Query(`SELECT id, root_path FROM repos WHERE identity = ? AND root_path != identity`, identity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moved []int64
+	for rows.Next() {
+		var id int64
+		var rootPath string
+		if err := rows.Scan(&id, &rootPath); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(rootPath); errors.Is(err, os.ErrNotExist) {
+			moved = append(moved, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(moved) != 1 {
+		return nil, nil
+	}
+	return db.GetRepoByID(moved[0])
+}
+
+// dirExists reports whether path is a directory on disk
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// AllowRepoPath adds a checkout to the paths the daemon accepts when
+// require_registered_repos is set. Adding a path twice is a no-op.
+func (db *DB) AllowRepoPath(path string) error {
+	_, err := db.Exec(`INSERT OR IGNORE INTO repo_allowlist (path) VALUES (?)`, canonicalRepoPath(path))
+	return err
+}
+
+// DisallowRepoPath removes a checkout from the allowlist, reporting whether
+// it was there
+func (db *DB) DisallowRepoPath(path string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM repo_allowlist WHERE path = ?`, canonicalRepoPath(path))
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// ListAllowedRepoPaths returns the allowlisted checkouts, sorted by path
+func (db *DB) ListAllowedRepoPaths() ([]string, error) {
+	rows, err := db.Query(`SELECT path FROM repo_allowlist ORDER BY path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// IsRepoPathAllowed reports whether path is an allowlisted checkout or
+// inside one
+func (db *DB) IsRepoPathAllowed(path string) (bool, error) {
+	canonical := canonicalRepoPath(path)
+	allowed, err := db.ListAllowedRepoPaths()
+	if err != nil {
+		return false, err
+	}
+	for _, root := range allowed {
+		if canonical == root || strings.HasPrefix(canonical, root+string(filepath.Separator)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"database/sql"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -368,6 +370,55 @@ func TestListRepos(t *testing.T) {
 	})
 }
 
+func TestRepoAllowlist(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	dir := t.TempDir()
+	checkout := filepath.Join(dir, "project")
+	outside := filepath.Join(dir, "other")
+	for _, d := range []string{filepath.Join(checkout, "src"), outside} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(checkout, "escape")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	if err := db.AllowRepoPath(checkout); err != nil {
+		t.Fatalf("AllowRepoPath failed: %v", err)
+	}
+	if err := db.AllowRepoPath(checkout); err != nil {
+		t.Fatalf("AllowRepoPath should be idempotent: %v", err)
+	}
+
+	for path, want := range map[string]bool{
+		checkout:                       true,
+		filepath.Join(checkout, "src"): true,
+		checkout + "-sibling":          false,
+		outside:                        false,
+		link:                           false,
+	} {
+		got, err := db.IsRepoPathAllowed(path)
+		if err != nil {
+			t.Fatalf("IsRepoPathAllowed(%s) failed: %v", path, err)
+		}
+		if got != want {
+			t.Errorf("IsRepoPathAllowed(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	removed, err := db.DisallowRepoPath(checkout)
+	if err != nil || !removed {
+		t.Fatalf("DisallowRepoPath = %v, %v; want true, nil", removed, err)
+	}
+	if paths, _ := db.ListAllowedRepoPaths(); len(paths) != 0 {
+		t.Errorf("Expected empty allowlist, got %v", paths)
+	}
+}
+
 func TestGetRepoByID(t *testing.T) {
 	db := openTestDB(t)
 	defer db.Close()
@@ -830,6 +881,135 @@ func TestMergeRepos(t *testing.T) {
 	})
 }
 
+func TestMoveRepo(t *testing.T) {
+	t.Run("keeps jobs and follows the directory name", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		repo := createRepo(t, db, "/tmp/move-old")
+		commit := createCommit(t, db, repo.ID, "move-sha1")
+		enqueueJob(t, db, repo.ID, commit.ID, "move-sha1")
+		if err := db.AllowRepoPath("/tmp/move-old"); err != nil {
+			t.Fatalf("AllowRepoPath failed: %v", err)
+		}
+
+		moved, err := db.MoveRepo(repo.ID, "/tmp/move-new")
+		if err != nil {
+			t.Fatalf("MoveRepo failed: %v", err)
+		}
+		if moved.ID != repo.ID || moved.RootPath != canonicalRepoPath("/tmp/move-new") || moved.Name != "move-new" {
+			t.Errorf("unexpected repo after move: %+v", moved)
+		}
+		jobs, _ := db.ListJobs("", moved.RootPath, 100, 0)
+		if len(jobs) != 1 {
+			t.Errorf("Expected 1 job at the new path, got %d", len(jobs))
+		}
+		if allowed, _ := db.IsRepoPathAllowed("/tmp/move-new"); !allowed {
+			t.Error("Expected the allowlist entry to move with the repo")
+		}
+		if allowed, _ := db.IsRepoPathAllowed("/tmp/move-old"); allowed {
+			t.Error("Expected the old path to leave the allowlist")
+		}
+	})
+
+	t.Run("keeps a custom name", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		repo := createRepo(t, db, "/tmp/move-named")
+		if _, err := db.RenameRepo("/tmp/move-named", "my-project"); err != nil {
+			t.Fatalf("RenameRepo failed: %v", err)
+		}
+		moved, err := db.MoveRepo(repo.ID, "/tmp/move-named-new")
+		if err != nil {
+			t.Fatalf("MoveRepo failed: %v", err)
+		}
+		if moved.Name != "my-project" {
+			t.Errorf("Expected name my-project, got %q", moved.Name)
+		}
+	})
+
+	t.Run("refuses a path another repo has", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		repo := createRepo(t, db, "/tmp/move-from")
+		createRepo(t, db, "/tmp/move-taken")
+		if _, err := db.MoveRepo(repo.ID, "/tmp/move-taken"); err == nil {
+			t.Fatal("Expected an error moving onto another repo's path")
+		}
+	})
+}
+
+func TestGetOrCreateRepoAdoptsMovedCheckout(t *testing.T) {
+	const identity = "git@github.com:org/moved.git"
+
+	t.Run("adopts the record of a checkout gone from disk", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		dir := t.TempDir()
+		oldPath := filepath.Join(dir, "old")
+		newPath := filepath.Join(dir, "new")
+		if err := os.Mkdir(oldPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+		repo, err := db.GetOrCreateRepo(oldPath, identity)
+		if err != nil {
+			t.Fatalf("GetOrCreateRepo failed: %v", err)
+		}
+		if err := os.Rename(oldPath, newPath); err != nil {
+			t.Fatal(err)
+		}
+
+		adopted, err := db.GetOrCreateRepo(newPath, identity)
+		if err != nil {
+			t.Fatalf("GetOrCreateRepo failed: %v", err)
+		}
+		if adopted.ID != repo.ID || adopted.RootPath != canonicalRepoPath(newPath) {
+			t.Errorf("Expected repo %d moved to %s, got %+v", repo.ID, newPath, adopted)
+		}
+	})
+
+	t.Run("leaves existing clones alone", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		clone1, clone2 := t.TempDir(), t.TempDir()
+		repo1, err := db.GetOrCreateRepo(clone1, identity)
+		if err != nil {
+			t.Fatalf("GetOrCreateRepo failed: %v", err)
+		}
+		repo2, err := db.GetOrCreateRepo(clone2, identity)
+		if err != nil {
+			t.Fatalf("GetOrCreateRepo failed: %v", err)
+		}
+		if repo1.ID == repo2.ID {
+			t.Error("Expected a clone that still exists to keep its own record")
+		}
+	})
+
+	t.Run("does not guess between several missing checkouts", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		if _, err := db.GetOrCreateRepo("/nonexistent/move-a", identity); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetOrCreateRepo("/nonexistent/move-b", identity); err != nil {
+			t.Fatal(err)
+		}
+		repo, err := db.GetOrCreateRepo(t.TempDir(), identity)
+		if err != nil {
+			t.Fatalf("GetOrCreateRepo failed: %v", err)
+		}
+		repos, _ := db.ListRepos()
+		if len(repos) != 3 || repo.Name == "move-a" || repo.Name == "move-b" {
+			t.Errorf("Expected a new record, got %+v among %d repos", repo, len(repos))
+		}
+	})
+}
+
 func TestDeleteRepoCascadeDeletesCommits(t *testing.T) {
 	db := openTestDB(t)
 	defer db.Close()
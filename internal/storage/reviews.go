@@ -5,6 +5,26 @@ import (
 	"time"
 )
 
+// AttachmentFullOutput names the attachment holding the complete agent output
+// when the review row stores a summary instead.
+const AttachmentFullOutput = "full_output"
+
+// AttachmentSignature names the attachment holding a review's integrity
+// signature
+const AttachmentSignature = "signature"
+
+// AttachmentSBOMDelta names the attachment holding the SBOM changes a
+// security review was given, as JSON
+const AttachmentSBOMDelta = "sbom_delta"
+
+// AttachmentAutoAddressPatch names the attachment holding the patch
+// auto-address proposed for a review's findings
+const AttachmentAutoAddressPatch = "auto_address_patch"
+
+// AttachmentTranslationPrefix starts the names of attachments caching the
+// review translated into another language, e.g. "translation:french".
+const AttachmentTranslationPrefix = "translation:"
+
 // GetReviewByJobID finds a review by its job ID
 func (db *DB) GetReviewByJobID(jobID int64) (*Review, error) {
 	var r Review
@@ -17,7 +37,8 @@ func (db *DB) GetReviewByJobID(jobID int64) (*Review, error) {
 	var commitSubject sql.NullString
 
 	err := db.QueryRow(`
-		SELECT rv.id, rv.job_id, rv.agent, rv.prompt, rv.output, rv.created_at, rv.addressed, rv.uuid,
+		SELECT rv.id, rv.job_id, rv.agent, rv.prompt, rv.output, rv.created_at, rv.addressed, rv.uuid, rv.agent_version, rv.injection_warning,
+		       EXISTS(SELECT 1 FROM review_attachments a WHERE a.review_id = rv.id AND a.name = '`+AttachmentFullOutput+`'),
 		       j.id, j.repo_id, j.commit_id, j.git_ref, j.agent, j.reasoning, j.status, j.enqueued_at,
 		       j.started_at, j.finished_at, j.worker_id, j.error, j.model, j.job_type, j.review_type,
 		       rp.root_path, rp.name, c.subject
@@ -25,9 +46,9 @@ func (db *DB) GetReviewByJobID(jobID int64) (*Review, error) {
 		JOIN review_jobs j ON j.id = rv.job_id
 		JOIN repos rp ON rp.id = j.repo_id
 		LEFT JOIN commits c ON c.id = j.commit_id
-		WHERE rv.job_id = ?
-	`, jobID).Scan(&r.ID, &r.JobID, &r.Agent, &r.Prompt, &r.Output, &createdAt, &addressed, &reviewUUID,
-		&job.ID, &job.RepoID, &commitID, &job.GitRef, &job.Agent, &job.Reasoning, &job.Status, &enqueuedAt,
+		WHERE rv.job_id = ? AND rv.deleted_at IS NULL AND j.deleted_at IS NULL
+	`, jobID).Scan(&r.ID, &r.JobID, &r.Agent, &r.Prompt, &r.Output, &createdAt, &addressed, &reviewUUID, &r.AgentVersion, &r.InjectionWarning,
+		&r.Summarized, &job.ID, &job.RepoID, &commitID, &job.GitRef, &job.Agent, &job.Reasoning, &job.Status, &enqueuedAt,
 		&startedAt, &finishedAt, &workerID, &errMsg, &model, &jobTypeStr, &reviewTypeStr,
 		&job.RepoPath, &job.RepoName, &commitSubject)
 	if err != nil {
@@ -82,8 +103,21 @@ func (db *DB) GetReviewByJobID(jobID int64) (*Review, error) {
 	return &r, nil
 }
 
-// GetReviewByCommitSHA finds the most recent review by commit SHA (searches git_ref field)
+// GetReviewByCommitSHA finds the most recent review by commit SHA (searches git_ref field).
+// The same SHA can exist in several repos; prefer GetReviewByRepoAndCommitSHA
+// when the repo is known.
 func (db *DB) GetReviewByCommitSHA(sha string) (*Review, error) {
+	return db.getReviewByGitRef(0, sha)
+}
+
+// GetReviewByRepoAndCommitSHA finds the most recent review of a commit SHA in a repo
+func (db *DB) GetReviewByRepoAndCommitSHA(repoID int64, sha string) (*Review, error) {
+	return db.getReviewByGitRef(repoID, sha)
+}
+
+// getReviewByGitRef finds the most recent review by git_ref, limited to a
+// repo unless repoID is 0
+func (db *DB) getReviewByGitRef(repoID int64, sha string) (*Review, error) {
 	var r Review
 	var createdAt string
 	var addressed int
@@ -95,7 +129,8 @@ func (db *DB) GetReviewByCommitSHA(sha string) (*Review, error) {
 
 	// Search by git_ref which contains the SHA for single commits
 	err := db.QueryRow(`
-		SELECT rv.id, rv.job_id, rv.agent, rv.prompt, rv.output, rv.created_at, rv.addressed, rv.uuid,
+		SELECT rv.id, rv.job_id, rv.agent, rv.prompt, rv.output, rv.created_at, rv.addressed, rv.uuid, rv.agent_version, rv.injection_warning,
+		       EXISTS(SELECT 1 FROM review_attachments a WHERE a.review_id = rv.id AND a.name = '`+AttachmentFullOutput+`'),
 		       j.id, j.repo_id, j.commit_id, j.git_ref, j.agent, j.reasoning, j.status, j.enqueued_at,
 		       j.started_at, j.finished_at, j.worker_id, j.error, j.model, j.job_type, j.review_type,
 		       rp.root_path, rp.name, c.subject
@@ -103,11 +138,11 @@ func (db *DB) GetReviewByCommitSHA(sha string) (*Review, error) {
 		JOIN review_jobs j ON j.id = rv.job_id
 		JOIN repos rp ON rp.id = j.repo_id
 		LEFT JOIN commits c ON c.id = j.commit_id
-		WHERE j.git_ref = ?
+		WHERE j.git_ref = ? AND (? = 0 OR j.repo_id = ?) AND rv.deleted_at IS NULL AND j.deleted_at IS NULL
 		ORDER BY rv.created_at DESC
 		LIMIT 1
-	`, sha).Scan(&r.ID, &r.JobID, &r.Agent, &r.Prompt, &r.Output, &createdAt, &addressed, &reviewUUID,
-		&job.ID, &job.RepoID, &commitID, &job.GitRef, &job.Agent, &job.Reasoning, &job.Status, &enqueuedAt,
+	`, sha, repoID, repoID).Scan(&r.ID, &r.JobID, &r.Agent, &r.Prompt, &r.Output, &createdAt, &addressed, &reviewUUID, &r.AgentVersion, &r.InjectionWarning,
+		&r.Summarized, &job.ID, &job.RepoID, &commitID, &job.GitRef, &job.Agent, &job.Reasoning, &job.Status, &enqueuedAt,
 		&startedAt, &finishedAt, &workerID, &errMsg, &model, &jobTypeStr, &reviewTypeStr,
 		&job.RepoPath, &job.RepoName, &commitSubject)
 	if err != nil {
@@ -169,7 +204,7 @@ func (db *DB) GetAllReviewsForGitRef(gitRef string) ([]Review, error) {
 		SELECT rv.id, rv.job_id, rv.agent, rv.prompt, rv.output, rv.created_at, rv.addressed
 		FROM reviews rv
 		JOIN review_jobs j ON j.id = rv.job_id
-		WHERE j.git_ref = ?
+		WHERE j.git_ref = ? AND rv.deleted_at IS NULL AND j.deleted_at IS NULL
 		ORDER BY rv.created_at ASC
 	`, gitRef)
 	if err != nil {
@@ -199,7 +234,7 @@ func (db *DB) GetRecentReviewsForRepo(repoID int64, limit int) ([]Review, error)
 		SELECT rv.id, rv.job_id, rv.agent, rv.prompt, rv.output, rv.created_at, rv.addressed
 		FROM reviews rv
 		JOIN review_jobs j ON j.id = rv.job_id
-		WHERE j.repo_id = ?
+		WHERE j.repo_id = ? AND rv.deleted_at IS NULL AND j.deleted_at IS NULL
 		ORDER BY rv.created_at DESC
 		LIMIT ?
 	`, repoID, limit)
@@ -224,13 +259,81 @@ func (db *DB) GetRecentReviewsForRepo(repoID int64, limit int) ([]Review, error)
 	return reviews, rows.Err()
 }
 
+// GetReviewFullOutput returns the complete agent output for a job's review.
+// For reviews that were summarized this is the stored attachment; otherwise
+// it is the review output itself.
+func (db *DB) GetReviewFullOutput(jobID int64) (string, error) {
+	var output string
+	var full sql.NullString
+	err := db.QueryRow(`
+		SELECT rv.output, a.content
+		FROM reviews rv
+		JOIN review_jobs j ON j.id = rv.job_id
+		LEFT JOIN review_attachments a ON a.review_id = rv.id AND a.name = ?
+		WHERE rv.job_id = ? AND rv.deleted_at IS NULL AND j.deleted_at IS NULL
+	`, AttachmentFullOutput, jobID).Scan(&output, &full)
+	if err != nil {
+		return "", err
+	}
+	if full.Valid {
+		return full.String, nil
+	}
+	return output, nil
+}
+
+// GetReviewAttachment returns the named attachment of a job's review.
+// Returns sql.ErrNoRows if the review has no such attachment.
+func (db *DB) GetReviewAttachment(jobID int64, name string) (string, error) {
+	var content string
+	err := db.QueryRow(`
+		SELECT a.content
+		FROM review_attachments a
+		JOIN reviews rv ON rv.id = a.review_id
+		WHERE rv.job_id = ? AND rv.deleted_at IS NULL AND a.name = ?
+	`, jobID, name).Scan(&content)
+	return content, err
+}
+
+// SaveReviewAttachment stores a named attachment on a job's review,
+// replacing any attachment with the same name.
+// Returns sql.ErrNoRows if the job has no review.
+func (db *DB) SaveReviewAttachment(jobID int64, name, content string) error {
+	result, err := db.Exec(`
+		INSERT INTO review_attachments (review_id, name, content, created_at)
+		SELECT id, ?, ?, ? FROM reviews WHERE job_id = ? AND deleted_at IS NULL
+		ON CONFLICT(review_id, name) DO UPDATE SET content = excluded.content, created_at = excluded.created_at
+	`, name, content, nowString(), jobID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetReviewAgentVersion records the agent version that produced a job's review
+func (db *DB) SetReviewAgentVersion(jobID int64, version string) error {
+	_, err := db.Exec(`UPDATE reviews SET agent_version = ? WHERE job_id = ?`, version, jobID)
+	return err
+}
+
+// SetReviewInjectionWarning records why a job's review is suspected of
+// following instructions embedded in the reviewed change
+func (db *DB) SetReviewInjectionWarning(jobID int64, warning string) error {
+	_, err := db.Exec(`UPDATE reviews SET injection_warning = ? WHERE job_id = ?`, warning, jobID)
+	return err
+}
+
 // MarkReviewAddressed marks a review as addressed (or unaddressed) by review ID
 func (db *DB) MarkReviewAddressed(reviewID int64, addressed bool) error {
 	val := 0
 	if addressed {
 		val = 1
 	}
-	now := time.Now().Format(time.RFC3339)
+	now := nowString()
 	machineID, _ := db.GetMachineID()
 
 	result, err := db.Exec(`UPDATE reviews SET addressed = ?, updated_by_machine_id = ?, updated_at = ? WHERE id = ?`, val, machineID, now, reviewID)
@@ -253,7 +356,7 @@ func (db *DB) MarkReviewAddressedByJobID(jobID int64, addressed bool) error {
 	if addressed {
 		val = 1
 	}
-	now := time.Now().Format(time.RFC3339)
+	now := nowString()
 	machineID, _ := db.GetMachineID()
 
 	result, err := db.Exec(`UPDATE reviews SET addressed = ?, updated_by_machine_id = ?, updated_at = ? WHERE job_id = ?`, val, machineID, now, jobID)
@@ -270,16 +373,19 @@ func (db *DB) MarkReviewAddressedByJobID(jobID int64, addressed bool) error {
 	return nil
 }
 
-// GetReviewByID finds a review by its ID
+// GetReviewByID finds a review by its ID. Reviews in the trash, or whose
+// job is, are not found.
 func (db *DB) GetReviewByID(reviewID int64) (*Review, error) {
 	var r Review
 	var createdAt string
 	var addressed int
 
 	err := db.QueryRow(`
-		SELECT id, job_id, agent, prompt, output, created_at, addressed
-		FROM reviews WHERE id = ?
-	`, reviewID).Scan(&r.ID, &r.JobID, &r.Agent, &r.Prompt, &r.Output, &createdAt, &addressed)
+		SELECT rv.id, rv.job_id, rv.agent, rv.prompt, rv.output, rv.created_at, rv.addressed, rv.agent_version, rv.injection_warning
+		FROM reviews rv
+		JOIN review_jobs j ON j.id = rv.job_id
+		WHERE rv.id = ? AND rv.deleted_at IS NULL AND j.deleted_at IS NULL
+	`, reviewID).Scan(&r.ID, &r.JobID, &r.Agent, &r.Prompt, &r.Output, &createdAt, &addressed, &r.AgentVersion, &r.InjectionWarning)
 	if err != nil {
 		return nil, err
 	}
@@ -294,7 +400,7 @@ func (db *DB) AddComment(commitID int64, responder, response string) (*Response,
 	uuid := GenerateUUID()
 	machineID, _ := db.GetMachineID()
 	now := time.Now()
-	nowStr := now.Format(time.RFC3339)
+	nowStr := formatTime(now)
 
 	result, err := db.Exec(`INSERT INTO responses (commit_id, responder, response, uuid, source_machine_id, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
 		commitID, responder, response, uuid, machineID, nowStr)
@@ -329,7 +435,7 @@ func (db *DB) AddCommentToJob(jobID int64, responder, response string) (*Respons
 	uuid := GenerateUUID()
 	machineID, _ := db.GetMachineID()
 	now := time.Now()
-	nowStr := now.Format(time.RFC3339)
+	nowStr := formatTime(now)
 
 	result, err := db.Exec(`INSERT INTO responses (job_id, responder, response, uuid, source_machine_id, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
 		jobID, responder, response, uuid, machineID, nowStr)
@@ -354,7 +460,7 @@ func (db *DB) GetCommentsForCommit(commitID int64) ([]Response, error) {
 	rows, err := db.Query(`
 		SELECT id, commit_id, job_id, responder, response, created_at
 		FROM responses
-		WHERE commit_id = ?
+		WHERE commit_id = ? AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`, commitID)
 	if err != nil {
@@ -388,7 +494,7 @@ func (db *DB) GetCommentsForJob(jobID int64) ([]Response, error) {
 	rows, err := db.Query(`
 		SELECT id, commit_id, job_id, responder, response, created_at
 		FROM responses
-		WHERE job_id = ?
+		WHERE job_id = ? AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`, jobID)
 	if err != nil {
@@ -425,3 +531,12 @@ func (db *DB) GetCommentsForCommitSHA(sha string) ([]Response, error) {
 	}
 	return db.GetCommentsForCommit(commit.ID)
 }
+
+// GetCommentsForRepoCommitSHA returns all comments for a commit by repo and SHA
+func (db *DB) GetCommentsForRepoCommitSHA(repoID int64, sha string) ([]Response, error) {
+	commit, err := db.GetCommitByRepoAndSHA(repoID, sha)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetCommentsForCommit(commit.ID)
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"testing"
 )
 
@@ -209,3 +210,129 @@ func TestGetReviewByJobIDIncludesModel(t *testing.T) {
 		})
 	}
 }
+
+func TestCompleteJobWithFullOutput(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, _, job := createJobChain(t, db, "/tmp/test-repo", "abc123")
+	claimJob(t, db, "worker-1")
+
+	summary := "1. High: bug in main.go:10"
+	full := summary + "\n\nLong explanation that did not fit."
+	if err := db.CompleteJobWithFullOutput(job.ID, "codex", "prompt", summary, full); err != nil {
+		t.Fatalf("CompleteJobWithFullOutput failed: %v", err)
+	}
+
+	review, err := db.GetReviewByJobID(job.ID)
+	if err != nil {
+		t.Fatalf("GetReviewByJobID failed: %v", err)
+	}
+	if review.Output != summary {
+		t.Errorf("Expected summary in review row, got %q", review.Output)
+	}
+	if !review.Summarized {
+		t.Error("Expected review to be marked summarized")
+	}
+
+	got, err := db.GetReviewFullOutput(job.ID)
+	if err != nil {
+		t.Fatalf("GetReviewFullOutput failed: %v", err)
+	}
+	if got != full {
+		t.Errorf("Expected full output %q, got %q", full, got)
+	}
+
+	// Rerunning drops the review and its attachment
+	if err := db.ReenqueueJob(job.ID); err != nil {
+		t.Fatalf("ReenqueueJob failed: %v", err)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM review_attachments`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("Expected attachments removed on rerun, found %d", n)
+	}
+}
+
+func TestGetReviewFullOutputWithoutAttachment(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, _, job := createJobChain(t, db, "/tmp/test-repo", "abc123")
+	claimJob(t, db, "worker-1")
+	if err := db.CompleteJob(job.ID, "codex", "prompt", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+
+	review, err := db.GetReviewByJobID(job.ID)
+	if err != nil {
+		t.Fatalf("GetReviewByJobID failed: %v", err)
+	}
+	if review.Summarized {
+		t.Error("Expected review not to be marked summarized")
+	}
+	got, err := db.GetReviewFullOutput(job.ID)
+	if err != nil {
+		t.Fatalf("GetReviewFullOutput failed: %v", err)
+	}
+	if got != "No issues found." {
+		t.Errorf("Expected review output, got %q", got)
+	}
+
+	if _, err := db.GetReviewFullOutput(job.ID + 100); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for missing review, got %v", err)
+	}
+}
+
+func TestSetReviewAgentVersion(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, _, job := createJobChain(t, db, "/tmp/test-repo", "abc123")
+	claimJob(t, db, "worker-1")
+	if err := db.CompleteJob(job.ID, "codex", "prompt", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+	if err := db.SetReviewAgentVersion(job.ID, "codex-cli 1.2.3"); err != nil {
+		t.Fatalf("SetReviewAgentVersion failed: %v", err)
+	}
+
+	review, err := db.GetReviewByJobID(job.ID)
+	if err != nil {
+		t.Fatalf("GetReviewByJobID failed: %v", err)
+	}
+	if review.AgentVersion != "codex-cli 1.2.3" {
+		t.Errorf("Expected agent version from GetReviewByJobID, got %q", review.AgentVersion)
+	}
+	review, err = db.GetReviewByID(review.ID)
+	if err != nil {
+		t.Fatalf("GetReviewByID failed: %v", err)
+	}
+	if review.AgentVersion != "codex-cli 1.2.3" {
+		t.Errorf("Expected agent version from GetReviewByID, got %q", review.AgentVersion)
+	}
+}
+
+func TestSetReviewInjectionWarning(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, _, job := createJobChain(t, db, "/tmp/test-repo", "abc123")
+	claimJob(t, db, "worker-1")
+	if err := db.CompleteJob(job.ID, "codex", "prompt", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+	if err := db.SetReviewInjectionWarning(job.ID, "the review repeats text"); err != nil {
+		t.Fatalf("SetReviewInjectionWarning failed: %v", err)
+	}
+
+	review, err := db.GetReviewByJobID(job.ID)
+	if err != nil {
+		t.Fatalf("GetReviewByJobID failed: %v", err)
+	}
+	if review.InjectionWarning != "the review repeats text" {
+		t.Errorf("Expected injection warning, got %q", review.InjectionWarning)
+	}
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of text in the search index
+const (
+	SearchKindReview   = "review"
+	SearchKindResponse = "response"
+)
+
+// ErrEmptySearch is returned for a search with no words to match
+var ErrEmptySearch = errors.New("search query has no words")
+
+// searchIndexSchema creates the full-text index over review output and
+// job responses, and the triggers that keep it in step with both tables.
+// Each row holds the text and the kind and id of the row it came from.
+const searchIndexSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+  body,
+  kind UNINDEXED,
+  source_id UNINDEXED,
+  tokenize = 'porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS search_index_review_insert AFTER INSERT ON reviews BEGIN
+  INSERT INTO search_index (body, kind, source_id) VALUES (new.output, 'review', new.id);
+END;
+CREATE TRIGGER IF NOT EXISTS search_index_review_update AFTER UPDATE OF output ON reviews BEGIN
+  DELETE FROM search_index WHERE kind = 'review' AND source_id = old.id;
+  INSERT INTO search_index (body, kind, source_id) VALUES (new.output, 'review', new.id);
+END;
+CREATE TRIGGER IF NOT EXISTS search_index_review_delete AFTER DELETE ON reviews BEGIN
+  DELETE FROM search_index WHERE kind = 'review' AND source_id = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_index_response_insert AFTER INSERT ON responses
+WHEN new.job_id IS NOT NULL BEGIN
+  INSERT INTO search_index (body, kind, source_id) VALUES (new.response, 'response', new.id);
+END;
+CREATE TRIGGER IF NOT EXISTS search_index_response_update AFTER UPDATE OF response, job_id ON responses BEGIN
+  DELETE FROM search_index WHERE kind = 'response' AND source_id = old.id;
+  INSERT INTO search_index (body, kind, source_id) SELECT new.response, 'response', new.id WHERE new.job_id IS NOT NULL;
+END;
+CREATE TRIGGER IF NOT EXISTS search_index_response_delete AFTER DELETE ON responses BEGIN
+  DELETE FROM search_index WHERE kind = 'response' AND source_id = old.id;
+END;
+`
+
+// createSearchIndex sets up the search index and fills it from the
+// existing reviews and responses. The index lives here rather than in
+// schema because its triggers read responses.job_id, which migrate adds.
+// Responses to commits rather than jobs predate job-based responses and
+// aren't indexed.
+func createSearchIndex(tx *sql.Tx) error {
+	if _, err := tx.Exec(searchIndexSchema); err != nil {
+		return fmt.Errorf("create search index: %w", err)
+	}
+	for _, stmt := range []string{
+		`DELETE FROM search_index`,
+		`INSERT INTO search_index (body, kind, source_id) SELECT output, 'review', id FROM reviews`,
+		`INSERT INTO search_index (body, kind, source_id) SELECT response, 'response', id FROM responses WHERE job_id IS NOT NULL`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("fill search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// SearchResult is a review or response matching a search, with the job it
+// belongs to
+type SearchResult struct {
+	Kind      string    `json:"kind"` // SearchKindReview or SearchKindResponse
+	JobID     int64     `json:"job_id"`
+	RepoName  string    `json:"repo_name"`
+	RepoPath  string    `json:"repo_path"`
+	GitRef    string    `json:"git_ref"`
+	Branch    string    `json:"branch,omitempty"`
+	Agent     string    `json:"agent"`
+	Responder string    `json:"responder,omitempty"` // Who wrote a response
+	Snippet   string    `json:"snippet"`             // Matching text, with matches in [brackets]
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchReviews returns the reviews and responses whose text matches every
+// word of query, best match first. Words are matched by stem, so "racing"
+// finds "race"; a word ending in * matches as a prefix. repoID limits the
+// search to one repo unless it is 0. Deleted jobs, reviews, and responses
+// are left out.
+func (db *DB) SearchReviews(query string, repoID int64, limit int) ([]SearchResult, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, ErrEmptySearch
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	repoFilter := ""
+	args := []interface{}{match}
+	if repoID != 0 {
+		repoFilter = "AND j.repo_id = ?"
+		args = append(args, repoID)
+	}
+	args = append(args, limit)
+
+	rows, err := db.Query(`
+		SELECT s.kind, j.id, r.name, r.root_path, j.git_ref, COALESCE(j.branch, ''), j.agent,
+		       COALESCE(rp.responder, ''), snippet(search_index, 0, '[', ']', '...', 16),
+		       COALESCE(rv.created_at, rp.created_at)
+		FROM search_index s
+		LEFT JOIN reviews rv ON s.kind = 'review' AND rv.id = s.source_id
+		LEFT JOIN responses rp ON s.kind = 'response' AND rp.id = s.source_id
+		JOIN review_jobs j ON j.id = COALESCE(rv.job_id, rp.job_id)
+		JOIN repos r ON r.id = j.repo_id
+		WHERE search_index MATCH ? `+repoFilter+`
+		AND j.deleted_at IS NULL AND rv.deleted_at IS NULL AND rp.deleted_at IS NULL
+		ORDER BY bm25(search_index)
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var sr SearchResult
+		var createdAt string
+		if err := rows.Scan(&sr.Kind, &sr.JobID, &sr.RepoName, &sr.RepoPath, &sr.GitRef, &sr.Branch, &sr.Agent,
+			&sr.Responder, &sr.Snippet, &createdAt); err != nil {
+			return nil, err
+		}
+		sr.CreatedAt = parseSQLiteTime(createdAt)
+		results = append(results, sr)
+	}
+	return results, rows.Err()
+}
+
+// ftsQuery turns a search into an FTS5 query matching every word, quoting
+// each so punctuation in it isn't read as query syntax
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		prefix := strings.HasSuffix(word, "*")
+		word = strings.TrimRight(word, "*")
+		if word == "" {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSearchReviews(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	complete := func(repo *Repo, sha, output string) int64 {
+		t.Helper()
+		commit := createCommit(t, db, repo.ID, sha)
+		job := enqueueJob(t, db, repo.ID, commit.ID, sha)
+		if _, err := db.Exec(`UPDATE review_jobs SET status = 'running' WHERE id = ?`, job.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.CompleteJob(job.ID, "test", "prompt", output); err != nil {
+			t.Fatalf("CompleteJob: %v", err)
+		}
+		return job.ID
+	}
+	jobIDs := func(results []SearchResult) []int64 {
+		var ids []int64
+		for _, r := range results {
+			ids = append(ids, r.JobID)
+		}
+		return ids
+	}
+
+	api := createRepo(t, db, "/tmp/search-api")
+	web := createRepo(t, db, "/tmp/search-web")
+	raced := complete(api, "s1", "- **High**: Race condition on the session cache in `cache.go`")
+	other := complete(web, "s2", "A data race when two requests update the cache")
+	clean := complete(api, "s3", "No issues found.")
+	if _, err := db.AddCommentToJob(clean, "alice", "Should we worry about racing writers here?"); err != nil {
+		t.Fatalf("AddCommentToJob: %v", err)
+	}
+
+	t.Run("matches stems across repos", func(t *testing.T) {
+		results, err := db.SearchReviews("race", 0, 0)
+		if err != nil {
+			t.Fatalf("SearchReviews: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("expected 3 matches for race, got %v", jobIDs(results))
+		}
+		for _, r := range results {
+			if r.JobID == clean && (r.Kind != SearchKindResponse || r.Responder != "alice") {
+				t.Errorf("expected alice's response, got %+v", r)
+			}
+			if !strings.Contains(r.Snippet, "[") {
+				t.Errorf("expected the match marked in the snippet, got %q", r.Snippet)
+			}
+		}
+	})
+
+	t.Run("scopes to a repo and needs every word", func(t *testing.T) {
+		results, err := db.SearchReviews("race cache", api.ID, 0)
+		if err != nil {
+			t.Fatalf("SearchReviews: %v", err)
+		}
+		if len(results) != 1 || results[0].JobID != raced || results[0].RepoName != "search-api" {
+			t.Errorf("expected only job %d, got %v", raced, jobIDs(results))
+		}
+	})
+
+	t.Run("treats punctuation as text", func(t *testing.T) {
+		results, err := db.SearchReviews(`cache.go "session`, 0, 0)
+		if err != nil {
+			t.Fatalf("SearchReviews: %v", err)
+		}
+		if len(results) != 1 || results[0].JobID != raced {
+			t.Errorf("expected job %d, got %v", raced, jobIDs(results))
+		}
+	})
+
+	t.Run("leaves out deleted jobs", func(t *testing.T) {
+		if _, err := db.SoftDeleteJob(other); err != nil {
+			t.Fatalf("SoftDeleteJob: %v", err)
+		}
+		results, err := db.SearchReviews("race", 0, 0)
+		if err != nil {
+			t.Fatalf("SearchReviews: %v", err)
+		}
+		for _, r := range results {
+			if r.JobID == other {
+				t.Errorf("deleted job %d still found", other)
+			}
+		}
+	})
+
+	t.Run("rebuilds the index from existing rows", func(t *testing.T) {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.Exec(`DELETE FROM search_index`); err != nil {
+			t.Fatal(err)
+		}
+		if err := createSearchIndex(tx); err != nil {
+			t.Fatalf("createSearchIndex: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		results, err := db.SearchReviews("racing", api.ID, 0)
+		if err != nil {
+			t.Fatalf("SearchReviews: %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("expected both api matches after a rebuild, got %v", jobIDs(results))
+		}
+	})
+
+	if _, err := db.SearchReviews(" * ", 0, 0); !errors.Is(err, ErrEmptySearch) {
+		t.Errorf("expected ErrEmptySearch for a query without words, got %v", err)
+	}
+}
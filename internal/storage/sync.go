@@ -369,7 +369,7 @@ func (db *DB) GetJobsToSync(machineID string, limit int) ([]SyncableJob, error)
 
 // MarkJobSynced updates the synced_at timestamp for a job
 func (db *DB) MarkJobSynced(jobID int64) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	_, err := db.Exec(`UPDATE review_jobs SET synced_at = ? WHERE id = ?`, now, jobID)
 	return err
 }
@@ -379,7 +379,7 @@ func (db *DB) MarkJobsSynced(jobIDs []int64) error {
 	if len(jobIDs) == 0 {
 		return nil
 	}
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	placeholders := make([]string, len(jobIDs))
 	args := make([]interface{}, len(jobIDs)+1)
 	args[0] = now
@@ -460,7 +460,7 @@ func (db *DB) GetReviewsToSync(machineID string, limit int) ([]SyncableReview, e
 
 // MarkReviewSynced updates the synced_at timestamp for a review
 func (db *DB) MarkReviewSynced(reviewID int64) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	_, err := db.Exec(`UPDATE reviews SET synced_at = ? WHERE id = ?`, now, reviewID)
 	return err
 }
@@ -470,7 +470,7 @@ func (db *DB) MarkReviewsSynced(reviewIDs []int64) error {
 	if len(reviewIDs) == 0 {
 		return nil
 	}
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	placeholders := make([]string, len(reviewIDs))
 	args := make([]interface{}, len(reviewIDs)+1)
 	args[0] = now
@@ -543,7 +543,7 @@ func (db *DB) GetCommentsToSync(machineID string, limit int) ([]SyncableResponse
 
 // MarkCommentSynced updates the synced_at timestamp for a comment
 func (db *DB) MarkCommentSynced(responseID int64) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	_, err := db.Exec(`UPDATE responses SET synced_at = ? WHERE id = ?`, now, responseID)
 	return err
 }
@@ -553,7 +553,7 @@ func (db *DB) MarkCommentsSynced(responseIDs []int64) error {
 	if len(responseIDs) == 0 {
 		return nil
 	}
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	placeholders := make([]string, len(responseIDs))
 	args := make([]interface{}, len(responseIDs)+1)
 	args[0] = now
@@ -570,7 +570,7 @@ func (db *DB) MarkCommentsSynced(responseIDs []int64) error {
 // UpsertPulledJob inserts or updates a job from PostgreSQL into SQLite.
 // Sets synced_at to prevent re-pushing. Requires repo to exist.
 func (db *DB) UpsertPulledJob(j PulledJob, repoID int64, commitID *int64) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	_, err := db.Exec(`
 		INSERT INTO review_jobs (
 			uuid, repo_id, commit_id, git_ref, agent, model, reasoning, job_type, review_type, status, agentic,
@@ -585,10 +585,10 @@ func (db *DB) UpsertPulledJob(j PulledJob, repoID int64, commitID *int64) error
 			updated_at = excluded.updated_at,
 			synced_at = ?
 	`, j.UUID, repoID, commitID, j.GitRef, j.Agent, nullStr(j.Model), j.Reasoning, j.JobType,
-		j.ReviewType, j.Status, j.Agentic, j.EnqueuedAt.Format(time.RFC3339),
+		j.ReviewType, j.Status, j.Agentic, formatTime(j.EnqueuedAt),
 		nullTimeStr(j.StartedAt), nullTimeStr(j.FinishedAt),
 		nullStr(j.Prompt), j.DiffContent, nullStr(j.Error),
-		j.SourceMachineID, j.UpdatedAt.Format(time.RFC3339), now, now)
+		j.SourceMachineID, formatTime(j.UpdatedAt), now, now)
 	return err
 }
 
@@ -605,7 +605,7 @@ func (db *DB) UpsertPulledReview(r PulledReview) error {
 		return fmt.Errorf("find job for review: %w", err)
 	}
 
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	_, err = db.Exec(`
 		INSERT INTO reviews (
 			uuid, job_id, agent, prompt, output, addressed,
@@ -617,7 +617,7 @@ func (db *DB) UpsertPulledReview(r PulledReview) error {
 			updated_at = excluded.updated_at,
 			synced_at = ?
 	`, r.UUID, jobID, r.Agent, r.Prompt, r.Output, r.Addressed,
-		r.UpdatedByMachineID, r.CreatedAt.Format(time.RFC3339), r.UpdatedAt.Format(time.RFC3339), now, now)
+		r.UpdatedByMachineID, formatTime(r.CreatedAt), formatTime(r.UpdatedAt), now, now)
 	return err
 }
 
@@ -634,13 +634,13 @@ func (db *DB) UpsertPulledResponse(r PulledResponse) error {
 		return fmt.Errorf("find job for response: %w", err)
 	}
 
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := nowString()
 	_, err = db.Exec(`
 		INSERT INTO responses (
 			uuid, job_id, responder, response, source_machine_id, created_at, synced_at
 		) VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(uuid) DO NOTHING
-	`, r.UUID, jobID, r.Responder, r.Response, r.SourceMachineID, r.CreatedAt.Format(time.RFC3339), now)
+	`, r.UUID, jobID, r.Responder, r.Response, r.SourceMachineID, formatTime(r.CreatedAt), now)
 	return err
 }
 
@@ -717,9 +717,9 @@ func (db *DB) GetOrCreateRepoByIdentity(identity string) (int64, error) {
 	// Use extracted repo name for display, but root_path stays as identity to mark it as a placeholder
 	displayName := ExtractRepoNameFromIdentity(identity)
 	result, err := db.Exec(`
-		INSERT INTO repos (root_path, name, identity)
-		VALUES (?, ?, ?)
-	`, identity, displayName, identity)
+		INSERT INTO repos (root_path, name, identity, created_at)
+		VALUES (?, ?, ?, ?)
+	`, identity, displayName, identity, nowString())
 	if err != nil {
 		return 0, fmt.Errorf("create placeholder repo: %w", err)
 	}
@@ -776,9 +776,9 @@ func (db *DB) GetOrCreateCommitByRepoAndSHA(repoID int64, sha, author, subject s
 
 	// Create
 	result, err := db.Exec(`
-		INSERT INTO commits (repo_id, sha, author, subject, timestamp)
-		VALUES (?, ?, ?, ?, ?)
-	`, repoID, sha, author, subject, timestamp.Format(time.RFC3339))
+		INSERT INTO commits (repo_id, sha, author, subject, timestamp, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, repoID, sha, author, subject, formatTime(timestamp), nowString())
 	if err != nil {
 		return 0, fmt.Errorf("create commit: %w", err)
 	}
@@ -798,5 +798,5 @@ func nullTimeStr(t *time.Time) interface{} {
 	if t == nil {
 		return nil
 	}
-	return t.Format(time.RFC3339)
+	return formatTime(*t)
 }
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Timestamps are stored as UTC RFC3339 ("2006-01-02T15:04:05Z") so they
+// sort and compare correctly as text. Convert to local time only for display.
+
+// sqlNow is the SQL expression for the current time in the stored format
+const sqlNow = `strftime('%Y-%m-%dT%H:%M:%SZ', 'now')`
+
+// formatTime formats t in the stored timestamp format
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// nowString returns the current time in the stored timestamp format
+func nowString() string {
+	return formatTime(time.Now())
+}
+
+// sqliteTimeLayouts are the formats older rows may hold: RFC3339 with a
+// local offset (written by Go before timestamps were normalized), SQLite's
+// datetime('now') format, and the sqlite driver's time.Time format
+var sqliteTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+}
+
+// parseSQLiteTime parses a stored time string in any format rows may hold
+// and returns it in UTC. Returns zero time for empty strings. Logs a warning
+// for non-empty unrecognized formats to surface driver/schema issues instead
+// of silently producing zero times.
+func parseSQLiteTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range sqliteTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	log.Printf("storage: warning: unrecognized time format %q", s)
+	return time.Time{}
+}
+
+// timestampColumns lists the columns holding timestamps in the stored
+// format. deleted_at is not listed: it has its own fixed-width layout.
+var timestampColumns = map[string][]string{
+	"repos":              {"created_at"},
+	"commits":            {"timestamp", "created_at"},
+	"review_jobs":        {"enqueued_at", "started_at", "finished_at", "updated_at", "synced_at"},
+	"reviews":            {"created_at", "updated_at", "synced_at"},
+	"responses":          {"created_at", "synced_at"},
+	"review_attachments": {"created_at"},
+	"job_output":         {"updated_at"},
+	"job_logs":           {"created_at"},
+	"findings":           {"created_at"},
+	"ci_pr_reviews":      {"created_at"},
+	"ci_pr_batches":      {"claimed_at", "created_at", "updated_at"},
+	"ci_pr_batch_jobs":   {"created_at"},
+}
+
+// normalizeTimestamps rewrites timestamps stored in other formats (local
+// offsets, datetime('now') output) as UTC RFC3339. Values SQLite can't
+// parse are left alone.
+func normalizeTimestamps(tx *sql.Tx) error {
+	for table, columns := range timestampColumns {
+		for _, col := range columns {
+			var exists int
+			if err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, col).Scan(&exists); err != nil {
+				return fmt.Errorf("check %s.%s: %w", table, col, err)
+			}
+			if exists == 0 {
+				continue
+			}
+			// table and col come from the fixed list above
+			normalized := fmt.Sprintf(`strftime('%%Y-%%m-%%dT%%H:%%M:%%SZ', %s)`, col)
+			_, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET %s = %s WHERE %s IS NOT NULL AND %s IS NOT NULL AND %s != %s`,
+				table, col, normalized, col, normalized, col, normalized))
+			if err != nil {
+				return fmt.Errorf("normalize %s.%s: %w", table, col, err)
+			}
+		}
+	}
+	return nil
+}
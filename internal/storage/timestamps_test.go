@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSQLiteTimeReturnsUTC(t *testing.T) {
+	want := time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)
+	for _, input := range []string{
+		"2024-06-15T10:30:00Z",
+		"2024-06-15T12:30:00+02:00",
+		"2024-06-15 10:30:00",
+		"2024-06-15 05:30:00-05:00",
+	} {
+		got := parseSQLiteTime(input)
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("parseSQLiteTime(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestNormalizeTimestamps(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/ts-repo")
+	commit := createCommit(t, db, repo.ID, "abc123")
+	job := enqueueJob(t, db, repo.ID, commit.ID, "abc123")
+
+	// Rows written before timestamps were normalized
+	_, err := db.Exec(`UPDATE review_jobs SET enqueued_at = ?, started_at = ?, finished_at = ? WHERE id = ?`,
+		"2024-06-15 10:30:00", "2024-06-15T12:31:00+02:00", "not-a-date", job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := normalizeTimestamps(tx); err != nil {
+		t.Fatalf("normalizeTimestamps: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var enqueued, started, finished string
+	err = db.QueryRow(`SELECT enqueued_at, started_at, finished_at FROM review_jobs WHERE id = ?`, job.ID).
+		Scan(&enqueued, &started, &finished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enqueued != "2024-06-15T10:30:00Z" {
+		t.Errorf("enqueued_at = %q, want 2024-06-15T10:30:00Z", enqueued)
+	}
+	if started != "2024-06-15T10:31:00Z" {
+		t.Errorf("started_at = %q, want 2024-06-15T10:31:00Z", started)
+	}
+	if finished != "not-a-date" {
+		t.Errorf("finished_at = %q, want unparseable value left alone", finished)
+	}
+
+	// Values written now are already in the stored format
+	var repoCreated string
+	if err := db.QueryRow(`SELECT created_at FROM repos WHERE id = ?`, repo.ID).Scan(&repoCreated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05Z", repoCreated); err != nil {
+		t.Errorf("repos.created_at = %q, want UTC RFC3339", repoCreated)
+	}
+}
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// deletedAtLayout is a fixed-width UTC timestamp format for deleted_at.
+// Fixed width keeps values lexicographically ordered, and nanosecond
+// precision lets every row removed by one operation share a single key
+// that UndoLastDelete can restore as a unit.
+const deletedAtLayout = "2006-01-02T15:04:05.000000000Z"
+
+// DeleteCounts reports how many rows a soft delete, undo, or purge touched.
+type DeleteCounts struct {
+	Jobs      int64 `json:"jobs"`
+	Reviews   int64 `json:"reviews"`
+	Responses int64 `json:"responses"`
+}
+
+// Total returns the number of rows across all tables.
+func (c DeleteCounts) Total() int64 {
+	return c.Jobs + c.Reviews + c.Responses
+}
+
+// SoftDeleteJob marks a finished job, its review, and its comments as deleted.
+// Deleted rows are hidden from queries but remain restorable via
+// UndoLastDelete until purged. Queued and running jobs must be canceled first.
+// Returns sql.ErrNoRows if the job doesn't exist, is already deleted, or is active.
+func (db *DB) SoftDeleteJob(jobID int64) (DeleteCounts, error) {
+	var counts DeleteCounts
+	stamp := time.Now().UTC().Format(deletedAtLayout)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return counts, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE review_jobs SET deleted_at = ?
+		WHERE id = ? AND deleted_at IS NULL AND status IN ('done', 'failed', 'canceled', 'skipped')
+	`, stamp, jobID)
+	if err != nil {
+		return counts, err
+	}
+	if counts.Jobs, err = result.RowsAffected(); err != nil {
+		return counts, err
+	}
+	if counts.Jobs == 0 {
+		return counts, sql.ErrNoRows
+	}
+
+	result, err = tx.Exec(`UPDATE reviews SET deleted_at = ? WHERE job_id = ? AND deleted_at IS NULL`, stamp, jobID)
+	if err != nil {
+		return counts, err
+	}
+	if counts.Reviews, err = result.RowsAffected(); err != nil {
+		return counts, err
+	}
+
+	result, err = tx.Exec(`UPDATE responses SET deleted_at = ? WHERE job_id = ? AND deleted_at IS NULL`, stamp, jobID)
+	if err != nil {
+		return counts, err
+	}
+	if counts.Responses, err = result.RowsAffected(); err != nil {
+		return counts, err
+	}
+
+	return counts, tx.Commit()
+}
+
+// PurgeFilter selects the finished jobs PurgeJobs removes. Empty fields
+// match everything.
+type PurgeFilter struct {
+	RepoPath string    // Repo root path
+	Status   string    // One of done, failed, canceled, skipped
+	Before   time.Time // Only jobs enqueued before this time
+}
+
+// PurgeJobs soft-deletes every finished job matching filter, along with its
+// review and comments, in one transaction. All rows share one deletion stamp,
+// so a single UndoLastDelete restores the whole purge. With dryRun set,
+// nothing changes and the counts report what would be deleted.
+func (db *DB) PurgeJobs(filter PurgeFilter, dryRun bool) (DeleteCounts, error) {
+	var counts DeleteCounts
+	switch filter.Status {
+	case "", "done", "failed", "canceled", "skipped":
+	default:
+		return counts, fmt.Errorf("cannot purge %s jobs: only finished jobs can be deleted", filter.Status)
+	}
+
+	conditions := []string{"j.deleted_at IS NULL", "j.status IN ('done', 'failed', 'canceled', 'skipped')"}
+	var args []interface{}
+	if filter.RepoPath != "" {
+		conditions = append(conditions, "r.root_path = ?")
+		args = append(args, canonicalRepoPath(filter.RepoPath))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "j.status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.Before.IsZero() {
+		conditions = append(conditions, "datetime(j.enqueued_at) < datetime(?)")
+		args = append(args, filter.Before.UTC().Format(time.RFC3339))
+	}
+	matching := `SELECT j.id FROM review_jobs j JOIN repos r ON r.id = j.repo_id WHERE ` + strings.Join(conditions, " AND ")
+
+	tx, err := db.Begin()
+	if err != nil {
+		return counts, err
+	}
+	defer tx.Rollback()
+
+	if dryRun {
+		for _, t := range []struct {
+			query string
+			count *int64
+		}{
+			{`SELECT COUNT(*) FROM (` + matching + `)`, &counts.Jobs},
+			{`SELECT COUNT(*) FROM reviews WHERE deleted_at IS NULL AND job_id IN (` + matching + `)`, &counts.Reviews},
+			{`SELECT COUNT(*) FROM responses WHERE deleted_at IS NULL AND job_id IN (` + matching + `)`, &counts.Responses},
+		} {
+			if err := tx.QueryRow(t.query, args...).Scan(t.count); err != nil {
+				return counts, err
+			}
+		}
+		return counts, nil
+	}
+
+	// Children first, while the jobs still match the filter
+	stamp := time.Now().UTC().Format(deletedAtLayout)
+	for _, t := range []struct {
+		query string
+		count *int64
+	}{
+		{`UPDATE reviews SET deleted_at = ? WHERE deleted_at IS NULL AND job_id IN (` + matching + `)`, &counts.Reviews},
+		{`UPDATE responses SET deleted_at = ? WHERE deleted_at IS NULL AND job_id IN (` + matching + `)`, &counts.Responses},
+		{`UPDATE review_jobs SET deleted_at = ? WHERE id IN (` + matching + `)`, &counts.Jobs},
+	} {
+		result, err := tx.Exec(t.query, append([]interface{}{stamp}, args...)...)
+		if err != nil {
+			return counts, err
+		}
+		if *t.count, err = result.RowsAffected(); err != nil {
+			return counts, err
+		}
+	}
+
+	return counts, tx.Commit()
+}
+
+// UndoLastDelete restores the most recent soft delete made within the window.
+// All rows deleted by that operation are restored together.
+// Returns sql.ErrNoRows if there is nothing to restore.
+func (db *DB) UndoLastDelete(window time.Duration) (DeleteCounts, error) {
+	var counts DeleteCounts
+	cutoff := time.Now().UTC().Add(-window).Format(deletedAtLayout)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return counts, err
+	}
+	defer tx.Rollback()
+
+	var stamp sql.NullString
+	err = tx.QueryRow(`
+		SELECT MAX(deleted_at) FROM (
+			SELECT deleted_at FROM review_jobs WHERE deleted_at IS NOT NULL
+			UNION ALL SELECT deleted_at FROM reviews WHERE deleted_at IS NOT NULL
+			UNION ALL SELECT deleted_at FROM responses WHERE deleted_at IS NOT NULL
+		) WHERE deleted_at >= ?
+	`, cutoff).Scan(&stamp)
+	if err != nil {
+		return counts, err
+	}
+	if !stamp.Valid {
+		return counts, sql.ErrNoRows
+	}
+
+	for _, t := range []struct {
+		table string
+		count *int64
+	}{
+		{"review_jobs", &counts.Jobs},
+		{"reviews", &counts.Reviews},
+		{"responses", &counts.Responses},
+	} {
+		result, err := tx.Exec(`UPDATE `+t.table+` SET deleted_at = NULL WHERE deleted_at = ?`, stamp.String)
+		if err != nil {
+			return counts, err
+		}
+		if *t.count, err = result.RowsAffected(); err != nil {
+			return counts, err
+		}
+	}
+
+	return counts, tx.Commit()
+}
+
+// PurgeDeleted permanently removes rows soft-deleted longer ago than olderThan.
+// Children are removed before parents so no row is left pointing at a
+// purged job.
+func (db *DB) PurgeDeleted(olderThan time.Duration) (DeleteCounts, error) {
+	var counts DeleteCounts
+	cutoff := time.Now().UTC().Add(-olderThan).Format(deletedAtLayout)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return counts, err
+	}
+	defer tx.Rollback()
+
+	purgedJobs := `SELECT id FROM review_jobs WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+	_, err = tx.Exec(`
+		DELETE FROM review_attachments WHERE review_id IN (
+			SELECT id FROM reviews WHERE (deleted_at IS NOT NULL AND deleted_at < ?) OR job_id IN (`+purgedJobs+`)
+		)
+	`, cutoff, cutoff)
+	if err != nil {
+		return counts, err
+	}
+	for _, t := range []struct {
+		query string
+		count *int64
+	}{
+		{`DELETE FROM responses WHERE (deleted_at IS NOT NULL AND deleted_at < ?) OR job_id IN (` + purgedJobs + `)`, &counts.Responses},
+		{`DELETE FROM reviews WHERE (deleted_at IS NOT NULL AND deleted_at < ?) OR job_id IN (` + purgedJobs + `)`, &counts.Reviews},
+	} {
+		result, err := tx.Exec(t.query, cutoff, cutoff)
+		if err != nil {
+			return counts, err
+		}
+		if *t.count, err = result.RowsAffected(); err != nil {
+			return counts, err
+		}
+	}
+
+	// Batch membership references jobs and would otherwise dangle. ci_pr_reviews
+	// is kept: it records that a PR head was already reviewed, and dropping it
+	// would make the CI poller review the same head again.
+	if _, err := tx.Exec(`DELETE FROM ci_pr_batch_jobs WHERE job_id IN (`+purgedJobs+`)`, cutoff); err != nil {
+		return counts, err
+	}
+	if _, err := tx.Exec(`DELETE FROM job_output WHERE job_id IN (`+purgedJobs+`)`, cutoff); err != nil {
+		return counts, err
+	}
+	if _, err := tx.Exec(`DELETE FROM findings WHERE job_id IN (`+purgedJobs+`)`, cutoff); err != nil {
+		return counts, err
+	}
+	if _, err := tx.Exec(`DELETE FROM job_logs WHERE job_id IN (`+purgedJobs+`)`, cutoff); err != nil {
+		return counts, err
+	}
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE job_id IN (`+purgedJobs+`)`, cutoff); err != nil {
+		return counts, err
+	}
+
+	result, err := tx.Exec(`DELETE FROM review_jobs WHERE deleted_at IS NOT NULL AND deleted_at < ?`, cutoff)
+	if err != nil {
+		return counts, err
+	}
+	if counts.Jobs, err = result.RowsAffected(); err != nil {
+		return counts, err
+	}
+
+	return counts, tx.Commit()
+}
@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// createCompletedJob enqueues, claims, and completes a job with one comment.
+func createCompletedJob(t *testing.T, db *DB, repoPath, sha string) *ReviewJob {
+	t.Helper()
+	_, _, job := createJobChain(t, db, repoPath, sha)
+	claimJob(t, db, "worker-1")
+	if err := db.CompleteJob(job.ID, "codex", "prompt", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+	if _, err := db.AddCommentToJob(job.ID, "user", "looks good"); err != nil {
+		t.Fatalf("AddCommentToJob failed: %v", err)
+	}
+	return job
+}
+
+func TestSoftDeleteJobHidesJobReviewAndComments(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	job := createCompletedJob(t, db, "/tmp/trash-repo", "abc123")
+	review, err := db.GetReviewByJobID(job.ID)
+	if err != nil {
+		t.Fatalf("GetReviewByJobID failed: %v", err)
+	}
+
+	counts, err := db.SoftDeleteJob(job.ID)
+	if err != nil {
+		t.Fatalf("SoftDeleteJob failed: %v", err)
+	}
+	if counts != (DeleteCounts{Jobs: 1, Reviews: 1, Responses: 1}) {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+
+	if _, err := db.GetJobByID(job.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetJobByID: expected sql.ErrNoRows, got %v", err)
+	}
+	if _, err := db.GetReviewByJobID(job.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetReviewByJobID: expected sql.ErrNoRows, got %v", err)
+	}
+	if _, err := db.GetReviewByID(review.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetReviewByID: expected sql.ErrNoRows, got %v", err)
+	}
+	comments, err := db.GetCommentsForJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetCommentsForJob failed: %v", err)
+	}
+	if len(comments) != 0 {
+		t.Errorf("expected no comments, got %d", len(comments))
+	}
+	jobs, err := db.ListJobs("", "", 0, 0)
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("expected deleted job to be hidden from ListJobs, got %d jobs", len(jobs))
+	}
+	_, _, done, _, _, err := db.GetJobCounts()
+	if err != nil {
+		t.Fatalf("GetJobCounts failed: %v", err)
+	}
+	if done != 0 {
+		t.Errorf("expected done count 0, got %d", done)
+	}
+
+	// Deleting again is a no-op
+	if _, err := db.SoftDeleteJob(job.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("second SoftDeleteJob: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestSoftDeleteJobRejectsActiveJobs(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, _, job := createJobChain(t, db, "/tmp/trash-repo", "abc123")
+
+	if _, err := db.SoftDeleteJob(job.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("queued job: expected sql.ErrNoRows, got %v", err)
+	}
+	claimJob(t, db, "worker-1")
+	if _, err := db.SoftDeleteJob(job.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("running job: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestUndoLastDeleteRestoresMostRecent(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	first := createCompletedJob(t, db, "/tmp/trash-repo", "aaa111")
+	second := createCompletedJob(t, db, "/tmp/trash-repo", "bbb222")
+
+	if _, err := db.SoftDeleteJob(first.ID); err != nil {
+		t.Fatalf("SoftDeleteJob(first) failed: %v", err)
+	}
+	if _, err := db.SoftDeleteJob(second.ID); err != nil {
+		t.Fatalf("SoftDeleteJob(second) failed: %v", err)
+	}
+
+	counts, err := db.UndoLastDelete(time.Hour)
+	if err != nil {
+		t.Fatalf("UndoLastDelete failed: %v", err)
+	}
+	if counts != (DeleteCounts{Jobs: 1, Reviews: 1, Responses: 1}) {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+
+	if _, err := db.GetReviewByJobID(second.ID); err != nil {
+		t.Errorf("expected second review restored, got %v", err)
+	}
+	if _, err := db.GetJobByID(first.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected first job to stay deleted, got %v", err)
+	}
+
+	if _, err := db.UndoLastDelete(time.Hour); err != nil {
+		t.Fatalf("second UndoLastDelete failed: %v", err)
+	}
+	if _, err := db.GetJobByID(first.ID); err != nil {
+		t.Errorf("expected first job restored, got %v", err)
+	}
+
+	if _, err := db.UndoLastDelete(time.Hour); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows with nothing to undo, got %v", err)
+	}
+}
+
+func TestUndoLastDeleteRespectsWindow(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	job := createCompletedJob(t, db, "/tmp/trash-repo", "abc123")
+	if _, err := db.SoftDeleteJob(job.ID); err != nil {
+		t.Fatalf("SoftDeleteJob failed: %v", err)
+	}
+
+	old := time.Now().UTC().Add(-2 * time.Hour).Format(deletedAtLayout)
+	for _, table := range []string{"review_jobs", "reviews", "responses"} {
+		if _, err := db.Exec(`UPDATE `+table+` SET deleted_at = ? WHERE deleted_at IS NOT NULL`, old); err != nil {
+			t.Fatalf("backdate %s: %v", table, err)
+		}
+	}
+
+	if _, err := db.UndoLastDelete(time.Hour); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows outside window, got %v", err)
+	}
+}
+
+func TestPurgeDeletedRemovesExpiredRows(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	expired := createCompletedJob(t, db, "/tmp/trash-repo", "aaa111")
+	recent := createCompletedJob(t, db, "/tmp/trash-repo", "bbb222")
+	kept := createCompletedJob(t, db, "/tmp/trash-repo", "ccc333")
+
+	if _, err := db.EnqueueWebhook("https://example.com/hook", "review.completed", expired.ID, "{}"); err != nil {
+		t.Fatalf("EnqueueWebhook failed: %v", err)
+	}
+	if _, err := db.SoftDeleteJob(expired.ID); err != nil {
+		t.Fatalf("SoftDeleteJob(expired) failed: %v", err)
+	}
+	old := time.Now().UTC().Add(-48 * time.Hour).Format(deletedAtLayout)
+	for _, table := range []string{"review_jobs", "reviews", "responses"} {
+		if _, err := db.Exec(`UPDATE `+table+` SET deleted_at = ? WHERE deleted_at IS NOT NULL`, old); err != nil {
+			t.Fatalf("backdate %s: %v", table, err)
+		}
+	}
+	if _, err := db.SoftDeleteJob(recent.ID); err != nil {
+		t.Fatalf("SoftDeleteJob(recent) failed: %v", err)
+	}
+
+	counts, err := db.PurgeDeleted(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("PurgeDeleted failed: %v", err)
+	}
+	if counts != (DeleteCounts{Jobs: 1, Reviews: 1, Responses: 1}) {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM review_jobs WHERE id = ?`, expired.ID).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Error("expected expired job to be purged")
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries WHERE job_id = ?`, expired.ID).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Error("expected expired job's webhook deliveries to be purged")
+	}
+
+	// The recent delete is still undoable and the untouched job is intact
+	if _, err := db.UndoLastDelete(24 * time.Hour); err != nil {
+		t.Errorf("expected recent delete to be undoable, got %v", err)
+	}
+	if _, err := db.GetReviewByJobID(kept.ID); err != nil {
+		t.Errorf("expected kept review intact, got %v", err)
+	}
+}
+
+func TestPurgeJobsFilters(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	failJob := func(repoPath, sha string) *ReviewJob {
+		t.Helper()
+		_, _, job := createJobChain(t, db, repoPath, sha)
+		claimJob(t, db, "worker-1")
+		if err := db.FailJob(job.ID, "boom"); err != nil {
+			t.Fatalf("FailJob failed: %v", err)
+		}
+		return job
+	}
+	oldFailed := failJob("/tmp/purge-a", "aaa111")
+	newFailed := failJob("/tmp/purge-a", "bbb222")
+	done := createCompletedJob(t, db, "/tmp/purge-a", "ccc333")
+	otherRepo := failJob("/tmp/purge-b", "ddd444")
+	_, _, queued := createJobChain(t, db, "/tmp/purge-a", "eee555")
+
+	old := time.Now().UTC().Add(-40 * 24 * time.Hour).Format("2006-01-02T15:04:05Z")
+	if _, err := db.Exec(`UPDATE review_jobs SET enqueued_at = ? WHERE id IN (?, ?)`, old, oldFailed.ID, done.ID); err != nil {
+		t.Fatalf("backdate jobs: %v", err)
+	}
+
+	filter := PurgeFilter{RepoPath: "/tmp/purge-a", Status: "failed", Before: time.Now().Add(-30 * 24 * time.Hour)}
+	counts, err := db.PurgeJobs(filter, true)
+	if err != nil {
+		t.Fatalf("PurgeJobs dry run failed: %v", err)
+	}
+	if counts != (DeleteCounts{Jobs: 1}) {
+		t.Errorf("unexpected dry run counts: %+v", counts)
+	}
+	if _, err := db.GetJobByID(oldFailed.ID); err != nil {
+		t.Errorf("dry run deleted job: %v", err)
+	}
+
+	if _, err := db.PurgeJobs(filter, false); err != nil {
+		t.Fatalf("PurgeJobs failed: %v", err)
+	}
+	if _, err := db.GetJobByID(oldFailed.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected old failed job purged, got %v", err)
+	}
+	for _, job := range []*ReviewJob{newFailed, done, otherRepo, queued} {
+		if _, err := db.GetJobByID(job.ID); err != nil {
+			t.Errorf("job %d should not match the filter: %v", job.ID, err)
+		}
+	}
+
+	// Without filters every finished job goes, and one undo brings them back
+	counts, err = db.PurgeJobs(PurgeFilter{}, false)
+	if err != nil {
+		t.Fatalf("PurgeJobs failed: %v", err)
+	}
+	if counts != (DeleteCounts{Jobs: 3, Reviews: 1, Responses: 1}) {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+	if _, err := db.GetJobByID(queued.ID); err != nil {
+		t.Errorf("queued job should be kept: %v", err)
+	}
+	counts, err = db.UndoLastDelete(time.Hour)
+	if err != nil {
+		t.Fatalf("UndoLastDelete failed: %v", err)
+	}
+	if counts != (DeleteCounts{Jobs: 3, Reviews: 1, Responses: 1}) {
+		t.Errorf("unexpected restore counts: %+v", counts)
+	}
+
+	if _, err := db.PurgeJobs(PurgeFilter{Status: "running"}, false); err == nil {
+		t.Error("expected error purging running jobs")
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// RepoSpend is a repo's estimated spending over a period
+type RepoSpend struct {
+	RepoID   int64
+	RepoPath string
+	CostUSD  float64
+}
+
+// RecordJobUsage adds a run's estimated token count and cost to a job's
+// totals, so every attempt of a retried or rerun job is counted
+func (db *DB) RecordJobUsage(jobID int64, tokens int, costUSD float64) error {
+	_, err := db.Exec(`UPDATE review_jobs SET tokens = tokens + ?, cost_usd = cost_usd + ? WHERE id = ?`, tokens, costUSD, jobID)
+	if err != nil {
+		return fmt.Errorf("record job usage: %w", err)
+	}
+	return nil
+}
+
+// GetSpendSince returns the estimated cost of jobs finished since the given
+// time, or started since then and not yet finished, per repo. Repos with no
+// spending are left out.
+func (db *DB) GetSpendSince(since time.Time) ([]RepoSpend, error) {
+	rows, err := db.Query(`
+		SELECT j.repo_id, r.root_path, SUM(j.cost_usd)
+		FROM review_jobs j
+		JOIN repos r ON r.id = j.repo_id
+		WHERE datetime(COALESCE(j.finished_at, j.started_at)) >= datetime(?)
+		GROUP BY j.repo_id, r.root_path
+		HAVING SUM(j.cost_usd) > 0
+		ORDER BY r.root_path`, formatTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("get spend: %w", err)
+	}
+	defer rows.Close()
+
+	var spend []RepoSpend
+	for rows.Next() {
+		var s RepoSpend
+		if err := rows.Scan(&s.RepoID, &s.RepoPath, &s.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan spend: %w", err)
+		}
+		spend = append(spend, s)
+	}
+	return spend, rows.Err()
+}
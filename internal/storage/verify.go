@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"database/sql"
+)
+
+// Commit review states reported by GetCommitReviewStatuses
+const (
+	CommitReviewPassed     = "passed"     // latest completed review passed
+	CommitReviewFailed     = "failed"     // latest completed review has findings
+	CommitReviewAddressed  = "addressed"  // review had findings that were marked addressed
+	CommitReviewPending    = "pending"    // review queued or running
+	CommitReviewSkipped    = "skipped"    // commit matched a skip rule
+	CommitReviewUnreviewed = "unreviewed" // no completed review for the commit
+)
+
+// CommitReviewStatus describes the review state of a single commit
+type CommitReviewStatus struct {
+	SHA    string `json:"sha"`
+	Status string `json:"status"`
+	JobID  int64  `json:"job_id,omitempty"`
+
+	// Signature is the state of the review's integrity signature, set when
+	// the daemon has a signing key
+	Signature string `json:"signature,omitempty"`
+}
+
+// GetCommitReviewStatuses reports, for each SHA, the state of the most
+// relevant per-commit review job in the repo. A completed review wins
+// over newer failed or canceled attempts; otherwise the newest job is used.
+// Range and dirty reviews don't count toward a commit's status, and
+// neither do specialized review types such as security or quick, since a
+// passing narrow review says nothing about the code as a whole.
+func (db *DB) GetCommitReviewStatuses(repoID int64, shas []string) ([]CommitReviewStatus, error) {
+	stmt, err := db.Prepare(`
+		SELECT j.id, j.status, rv.output, COALESCE(rv.addressed, 0)
+		FROM review_jobs j
+		JOIN commits c ON c.id = j.commit_id
+		LEFT JOIN reviews rv ON rv.job_id = j.id AND rv.deleted_at IS NULL
+		WHERE j.repo_id = ? AND c.sha = ? AND j.deleted_at IS NULL AND j.job_type = ?
+		  AND j.review_type IN ('', 'default', 'general', 'review')
+		ORDER BY (j.status = 'done') DESC, j.id DESC
+		LIMIT 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	statuses := make([]CommitReviewStatus, 0, len(shas))
+	for _, sha := range shas {
+		st := CommitReviewStatus{SHA: sha, Status: CommitReviewUnreviewed}
+
+		var jobStatus string
+		var output sql.NullString
+		var addressed bool
+		err := stmt.QueryRow(repoID, sha, JobTypeReview).Scan(&st.JobID, &jobStatus, &output, &addressed)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			switch JobStatus(jobStatus) {
+			case JobStatusQueued, JobStatusRunning:
+				st.Status = CommitReviewPending
+			case JobStatusSkipped:
+				st.Status = CommitReviewSkipped
+			case JobStatusDone:
+				switch {
+				case !output.Valid:
+					// Review row was deleted; treat as unreviewed
+				case ParseVerdict(output.String) == "P":
+					st.Status = CommitReviewPassed
+				case addressed:
+					st.Status = CommitReviewAddressed
+				default:
+					st.Status = CommitReviewFailed
+				}
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestGetCommitReviewStatuses(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	repo := createRepo(t, db, "/tmp/test-repo")
+
+	complete := func(sha, output string) *ReviewJob {
+		t.Helper()
+		commit := createCommit(t, db, repo.ID, sha)
+		enqueueJob(t, db, repo.ID, commit.ID, sha)
+		job := claimJob(t, db, "worker-1")
+		if err := db.CompleteJob(job.ID, "codex", "prompt", output); err != nil {
+			t.Fatalf("CompleteJob failed: %v", err)
+		}
+		return job
+	}
+
+	passed := complete("pass-sha", "No issues found.")
+	failed := complete("fail-sha", "- **High**: SQL injection in db.go:12")
+	addressed := complete("addressed-sha", "- **Medium**: missing check in api.go:40")
+	if err := db.MarkReviewAddressedByJobID(addressed.ID, true); err != nil {
+		t.Fatalf("MarkReviewAddressedByJobID failed: %v", err)
+	}
+
+	// A newer failed attempt must not hide an older completed review
+	retried := complete("retried-sha", "No issues found.")
+	retryJob := enqueueJob(t, db, repo.ID, *retried.CommitID, "retried-sha")
+	claimJob(t, db, "worker-1")
+	if err := db.FailJob(retryJob.ID, "agent crashed"); err != nil {
+		t.Fatalf("FailJob failed: %v", err)
+	}
+
+	// A newer passing review of another type must not hide a failed
+	// default review
+	typed := complete("typed-sha", "- **High**: race in worker.go:88")
+	securityJob, err := db.EnqueueJob(EnqueueOpts{RepoID: repo.ID, CommitID: *typed.CommitID, GitRef: "typed-sha", Agent: "codex", ReviewType: "security"})
+	if err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+	claimJob(t, db, "worker-1")
+	if err := db.CompleteJob(securityJob.ID, "codex", "prompt", "No issues found."); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+
+	skippedCommit := createCommit(t, db, repo.ID, "skipped-sha")
+	skipped, err := db.EnqueueJob(EnqueueOpts{RepoID: repo.ID, CommitID: skippedCommit.ID, GitRef: "skipped-sha", Agent: "codex", SkipReason: "only documentation changed"})
+	if err != nil {
+		t.Fatalf("EnqueueJob failed: %v", err)
+	}
+
+	// Enqueued last so the claims above don't pick it up
+	pendingCommit := createCommit(t, db, repo.ID, "pending-sha")
+	pending := enqueueJob(t, db, repo.ID, pendingCommit.ID, "pending-sha")
+
+	statuses, err := db.GetCommitReviewStatuses(repo.ID, []string{
+		"pass-sha", "fail-sha", "addressed-sha", "pending-sha", "retried-sha", "typed-sha", "skipped-sha", "missing-sha",
+	})
+	if err != nil {
+		t.Fatalf("GetCommitReviewStatuses failed: %v", err)
+	}
+
+	want := []CommitReviewStatus{
+		{SHA: "pass-sha", Status: CommitReviewPassed, JobID: passed.ID},
+		{SHA: "fail-sha", Status: CommitReviewFailed, JobID: failed.ID},
+		{SHA: "addressed-sha", Status: CommitReviewAddressed, JobID: addressed.ID},
+		{SHA: "pending-sha", Status: CommitReviewPending, JobID: pending.ID},
+		{SHA: "retried-sha", Status: CommitReviewPassed, JobID: retried.ID},
+		{SHA: "typed-sha", Status: CommitReviewFailed, JobID: typed.ID},
+		{SHA: "skipped-sha", Status: CommitReviewSkipped, JobID: skipped.ID},
+		{SHA: "missing-sha", Status: CommitReviewUnreviewed},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("expected %d statuses, got %d", len(want), len(statuses))
+	}
+	for i, w := range want {
+		if statuses[i] != w {
+			t.Errorf("status %d = %+v, want %+v", i, statuses[i], w)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Webhook delivery statuses. Delivered webhooks are removed from the
+// outbox, so only pending and dead deliveries are ever stored.
+const (
+	WebhookPending = "pending"
+	WebhookDead    = "dead"
+)
+
+// WebhookDelivery is an event queued for POSTing to a webhook URL
+type WebhookDelivery struct {
+	ID            int64     `json:"id"`
+	URL           string    `json:"url"`
+	EventType     string    `json:"event_type"`
+	JobID         int64     `json:"job_id"`
+	Payload       string    `json:"payload"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const webhookColumns = `id, url, event_type, job_id, payload, status, attempts, next_attempt_at, last_error, created_at`
+
+// EnqueueWebhook adds a delivery of payload to url to the outbox, due
+// immediately
+func (db *DB) EnqueueWebhook(url, eventType string, jobID int64, payload string) (int64, error) {
+	result, err := db.Exec(`
+		INSERT INTO webhook_deliveries (url, event_type, job_id, payload, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?)`, url, eventType, jobID, payload, nowString())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// EnqueueWebhooks adds deliveries to the outbox in one transaction, due
+// immediately. Only their URL, EventType, JobID, and Payload are used.
+func (db *DB) EnqueueWebhooks(deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := insertWebhooks(ctx, tx, deliveries, nowString()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertWebhooks adds deliveries to the outbox through exec, due at now, so
+// they can be written in the same transaction as the change they report
+func insertWebhooks(ctx context.Context, exec interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, deliveries []WebhookDelivery, now string) error {
+	for _, d := range deliveries {
+		if _, err := exec.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (url, event_type, job_id, payload, next_attempt_at)
+			VALUES (?, ?, ?, ?, ?)`, d.URL, d.EventType, d.JobID, d.Payload, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListDueWebhooks returns up to limit pending deliveries whose next attempt
+// is at or before now, oldest first
+func (db *DB) ListDueWebhooks(now time.Time, limit int) ([]WebhookDelivery, error) {
+	return db.queryWebhooks(`
+		SELECT `+webhookColumns+` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id LIMIT ?`, WebhookPending, formatTime(now), limit)
+}
+
+// ListDeadWebhooks returns deliveries that exhausted their retries, newest
+// first
+func (db *DB) ListDeadWebhooks(limit int) ([]WebhookDelivery, error) {
+	return db.queryWebhooks(`
+		SELECT `+webhookColumns+` FROM webhook_deliveries
+		WHERE status = ?
+		ORDER BY id DESC LIMIT ?`, WebhookDead, limit)
+}
+
+func (db *DB) queryWebhooks(query string, args ...any) ([]WebhookDelivery, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var nextAttempt, created string
+		if err := rows.Scan(&d.ID, &d.URL, &d.EventType, &d.JobID, &d.Payload, &d.Status,
+			&d.Attempts, &nextAttempt, &d.LastError, &created); err != nil {
+			return nil, err
+		}
+		d.NextAttemptAt = parseSQLiteTime(nextAttempt)
+		d.CreatedAt = parseSQLiteTime(created)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkWebhookDelivered removes a delivered webhook from the outbox
+func (db *DB) MarkWebhookDelivered(id int64) error {
+	_, err := db.Exec(`DELETE FROM webhook_deliveries WHERE id = ?`, id)
+	return err
+}
+
+// RecordWebhookFailure counts a failed attempt and schedules the next one
+// at retryAt. A zero retryAt moves the delivery to the dead-letter list.
+func (db *DB) RecordWebhookFailure(id int64, errMsg string, retryAt time.Time) error {
+	status, next := WebhookPending, formatTime(retryAt)
+	if retryAt.IsZero() {
+		status, next = WebhookDead, nowString()
+	}
+	_, err := db.Exec(`
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_error = ?, status = ?, next_attempt_at = ?
+		WHERE id = ?`, errMsg, status, next, id)
+	return err
+}
+
+// PurgeDeadWebhooks deletes dead deliveries that gave up more than
+// olderThan ago, returning how many were removed
+func (db *DB) PurgeDeadWebhooks(olderThan time.Duration) (int64, error) {
+	result, err := db.Exec(`DELETE FROM webhook_deliveries WHERE status = ? AND next_attempt_at < ?`,
+		WebhookDead, formatTime(time.Now().Add(-olderThan)))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RetryDeadWebhook moves a dead delivery back to the outbox with a fresh
+// set of attempts. Returns sql.ErrNoRows if there is no such dead delivery.
+func (db *DB) RetryDeadWebhook(id int64) error {
+	result, err := db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND status = ?`, WebhookPending, nowString(), id, WebhookDead)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWebhookOutbox(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	first, err := db.EnqueueWebhook("https://example.com/a", "review.completed", 7, `{"job_id":7}`)
+	if err != nil {
+		t.Fatalf("EnqueueWebhook: %v", err)
+	}
+	second, err := db.EnqueueWebhook("https://example.com/b", "review.failed", 8, `{"job_id":8}`)
+	if err != nil {
+		t.Fatalf("EnqueueWebhook: %v", err)
+	}
+
+	now := time.Now()
+	due, err := db.ListDueWebhooks(now, 10)
+	if err != nil {
+		t.Fatalf("ListDueWebhooks: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != first || due[0].Payload != `{"job_id":7}` || due[0].Status != WebhookPending {
+		t.Fatalf("unexpected due deliveries %+v", due)
+	}
+
+	// A failure with a retry time leaves the delivery pending but not due
+	if err := db.RecordWebhookFailure(first, "503 Service Unavailable", now.Add(time.Minute)); err != nil {
+		t.Fatalf("RecordWebhookFailure: %v", err)
+	}
+	// A failure without one moves it to the dead letters
+	if err := db.RecordWebhookFailure(second, "connection refused", time.Time{}); err != nil {
+		t.Fatalf("RecordWebhookFailure: %v", err)
+	}
+	if due, _ := db.ListDueWebhooks(now, 10); len(due) != 0 {
+		t.Errorf("expected nothing due, got %+v", due)
+	}
+	if due, _ := db.ListDueWebhooks(now.Add(2*time.Minute), 10); len(due) != 1 || due[0].ID != first || due[0].Attempts != 1 {
+		t.Errorf("expected the retry to come due, got %+v", due)
+	}
+
+	dead, err := db.ListDeadWebhooks(10)
+	if err != nil {
+		t.Fatalf("ListDeadWebhooks: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != second || dead[0].LastError != "connection refused" || dead[0].Attempts != 1 {
+		t.Fatalf("unexpected dead letters %+v", dead)
+	}
+
+	if err := db.RetryDeadWebhook(first); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("retrying a pending delivery: got %v, want sql.ErrNoRows", err)
+	}
+	if err := db.RetryDeadWebhook(second); err != nil {
+		t.Fatalf("RetryDeadWebhook: %v", err)
+	}
+	if due, _ := db.ListDueWebhooks(time.Now(), 10); len(due) != 1 || due[0].ID != second || due[0].Attempts != 0 {
+		t.Errorf("expected the retried delivery to be due with fresh attempts, got %+v", due)
+	}
+
+	if err := db.MarkWebhookDelivered(second); err != nil {
+		t.Fatalf("MarkWebhookDelivered: %v", err)
+	}
+	if due, _ := db.ListDueWebhooks(now.Add(time.Hour), 10); len(due) != 1 || due[0].ID != first {
+		t.Errorf("expected the delivered webhook to leave the outbox, got %+v", due)
+	}
+}
+
+func TestPurgeDeadWebhooks(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	var ids []int64
+	for range 3 {
+		id, err := db.EnqueueWebhook("https://example.com/hook", "review.failed", 1, "{}")
+		if err != nil {
+			t.Fatalf("EnqueueWebhook: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	// The first two die, one of them long ago; the third stays pending
+	for _, id := range ids[:2] {
+		if err := db.RecordWebhookFailure(id, "connection refused", time.Time{}); err != nil {
+			t.Fatalf("RecordWebhookFailure: %v", err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id = ?`,
+		formatTime(time.Now().Add(-48*time.Hour)), ids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := db.PurgeDeadWebhooks(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("PurgeDeadWebhooks: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("purged %d deliveries, want 1", n)
+	}
+	if dead, _ := db.ListDeadWebhooks(10); len(dead) != 1 || dead[0].ID != ids[1] {
+		t.Errorf("expected the recent dead letter to be kept, got %+v", dead)
+	}
+	if due, _ := db.ListDueWebhooks(time.Now(), 10); len(due) != 1 || due[0].ID != ids[2] {
+		t.Errorf("expected the pending delivery to be kept, got %+v", due)
+	}
+}
+
+func TestFailJobQueuesWebhooksOnlyWhenRunning(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, _, job := createJobChain(t, db, "/tmp/webhook-repo", "abc123")
+	hooks := []WebhookDelivery{{URL: "https://example.com/a", EventType: "review.failed", JobID: job.ID, Payload: `{}`}}
+
+	// Not claimed yet, so not running: nothing changes and nothing is queued
+	if err := db.FailJobWithClass(job.ID, "boom", "", hooks...); err != nil {
+		t.Fatalf("FailJobWithClass: %v", err)
+	}
+	if due, _ := db.ListDueWebhooks(time.Now().Add(time.Minute), 10); len(due) != 0 {
+		t.Fatalf("expected no deliveries for a job that wasn't running, got %+v", due)
+	}
+
+	if _, err := db.ClaimJob("worker-1"); err != nil {
+		t.Fatalf("ClaimJob: %v", err)
+	}
+	if err := db.FailJobWithClass(job.ID, "boom", "", hooks...); err != nil {
+		t.Fatalf("FailJobWithClass: %v", err)
+	}
+	due, err := db.ListDueWebhooks(time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ListDueWebhooks: %v", err)
+	}
+	if len(due) != 1 || due[0].EventType != "review.failed" || due[0].JobID != job.ID {
+		t.Fatalf("expected the failure's delivery queued, got %+v", due)
+	}
+}